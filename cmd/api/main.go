@@ -104,6 +104,8 @@ func run() error {
 		logger.String("git_commit", GitCommit),
 		logger.String("mode", cfg.App.Mode),
 	)
+	// 完整配置便于排障，密码、密钥等敏感字段已脱敏
+	log.Debug("已加载配置", logger.Any("config", cfg.Redacted()))
 
 	// ==================== 3. 初始化数据库 ====================
 	db, err := repository.NewDatabase(&cfg.Database, log)
@@ -118,7 +120,11 @@ func run() error {
 	}()
 
 	// ==================== 4. 初始化路由 ====================
-	r := router.New(cfg, db.DB, log)
+	r := router.New(cfg, db.DB, log, router.WithDatabase(db), router.WithBuildInfo(router.BuildInfo{
+		Version:   Version,
+		BuildTime: BuildTime,
+		GitCommit: GitCommit,
+	}))
 	engine := r.Setup()
 
 	// ==================== 5. 创建 HTTP 服务器 ====================
@@ -170,6 +176,11 @@ func run() error {
 		return fmt.Errorf("服务器关闭失败: %w", err)
 	}
 
+	// 等待后台任务结束
+	if err := r.Shutdown(ctx); err != nil {
+		log.Warn("后台任务未能在超时前完成", logger.Err(err))
+	}
+
 	log.Info("服务器已安全关闭")
 	return nil
 }
@@ -2,8 +2,9 @@
 //
 // 本包使用 Viper 库来管理配置，支持以下配置来源（优先级从高到低）：
 // 1. 环境变量
-// 2. 配置文件 (config.yaml)
-// 3. 默认值
+// 2. 环境覆盖文件 (config.<APP_ENV>.yaml，如 config.prod.yaml)
+// 3. 配置文件 (config.yaml)
+// 4. 默认值
 //
 // 使用示例：
 //
@@ -16,6 +17,11 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -33,6 +39,148 @@ type Config struct {
 	RateLimit  RateLimitConfig  `mapstructure:"rate_limit"`
 	Pagination PaginationConfig `mapstructure:"pagination"`
 	RiskReport RiskReportConfig `mapstructure:"risk_report"`
+	Request    RequestConfig    `mapstructure:"request"`
+	Response   ResponseConfig   `mapstructure:"response"`
+	Jobs       JobsConfig       `mapstructure:"jobs"`
+	Cache      CacheConfig      `mapstructure:"cache"`
+	Mail       MailConfig       `mapstructure:"mail"`
+	Storage    StorageConfig    `mapstructure:"storage"`
+	Avatar     AvatarConfig     `mapstructure:"avatar"`
+	// Features 功能开关初始状态，false 表示关闭对应端点（返回 503），未列出的开关默认开启
+	Features map[string]bool `mapstructure:"features"`
+	// CircuitBreaker 外部依赖（令牌黑名单 Redis、邮件）的熔断配置
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+}
+
+// JobsConfig 后台任务队列配置
+type JobsConfig struct {
+	// Workers 并发执行任务的 worker 数
+	Workers int `mapstructure:"workers"`
+	// QueueSize 等待执行的任务上限，超过后拒绝提交
+	QueueSize int `mapstructure:"queue_size"`
+	// DataExportTTL 个人数据导出文件生成后的保留时间（分钟），过期后再次请求会重新生成
+	DataExportTTL int `mapstructure:"data_export_ttl"`
+	// UserExportTTL 管理端用户导出文件生成后的保留时间（分钟），过期后需重新提交导出任务
+	UserExportTTL int `mapstructure:"user_export_ttl"`
+	// UserExportDir 管理端用户导出文件的保存目录，包含用户数据，不能与上传文件目录共用
+	UserExportDir string `mapstructure:"user_export_dir"`
+}
+
+// DataExportTTLDuration 返回个人数据导出文件的保留时间
+func (c *JobsConfig) DataExportTTLDuration() time.Duration {
+	return time.Duration(c.DataExportTTL) * time.Minute
+}
+
+// UserExportTTLDuration 返回管理端用户导出文件的保留时间
+func (c *JobsConfig) UserExportTTLDuration() time.Duration {
+	return time.Duration(c.UserExportTTL) * time.Minute
+}
+
+// CacheConfig 查询缓存配置
+type CacheConfig struct {
+	// UserListTTL 用户列表查询结果的缓存时间（秒），0 表示不缓存
+	UserListTTL int `mapstructure:"user_list_ttl"`
+}
+
+// UserListTTLDuration 返回用户列表缓存时间
+func (c *CacheConfig) UserListTTLDuration() time.Duration {
+	return time.Duration(c.UserListTTL) * time.Second
+}
+
+// MailConfig 邮件发送配置
+type MailConfig struct {
+	// Enabled 是否通过 SMTP 发送邮件，关闭时只记录日志不实际发送
+	Enabled bool `mapstructure:"enabled"`
+	// Host SMTP 服务器地址
+	Host string `mapstructure:"host"`
+	// Port SMTP 服务器端口
+	Port int `mapstructure:"port"`
+	// Username SMTP 认证用户名，为空时不认证
+	Username string `mapstructure:"username"`
+	// Password SMTP 认证密码
+	Password string `mapstructure:"password"`
+	// From 发件人地址，例如 "User API <noreply@example.com>"
+	From string `mapstructure:"from"`
+	// Welcome 注册欢迎邮件
+	Welcome WelcomeMailConfig `mapstructure:"welcome"`
+	// Retry 发送失败邮件的重试队列
+	Retry MailRetryConfig `mapstructure:"retry"`
+}
+
+// MailRetryConfig 邮件重试队列配置
+type MailRetryConfig struct {
+	// QueueSize 等待补发的邮件上限
+	QueueSize int `mapstructure:"queue_size"`
+	// MaxAttempts 每封邮件的最大发送次数（含首次）
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// Interval 补发间隔（秒）
+	Interval int `mapstructure:"interval"`
+}
+
+// IntervalDuration 返回补发间隔
+func (c *MailRetryConfig) IntervalDuration() time.Duration {
+	return time.Duration(c.Interval) * time.Second
+}
+
+// CircuitBreakerConfig 外部依赖熔断配置
+// 连续失败达到阈值后开路，开路期间调用快速失败并回退，超时后放行一次试探调用
+type CircuitBreakerConfig struct {
+	// FailureThreshold 连续失败多少次后开路
+	FailureThreshold int `mapstructure:"failure_threshold"`
+	// OpenTimeout 开路持续时间（秒）
+	OpenTimeout int `mapstructure:"open_timeout"`
+}
+
+// OpenTimeoutDuration 返回开路持续时间
+func (c *CircuitBreakerConfig) OpenTimeoutDuration() time.Duration {
+	return time.Duration(c.OpenTimeout) * time.Second
+}
+
+// WelcomeMailConfig 注册欢迎邮件配置
+// 主题与正文均为 text/template 模板，可用变量：.AppName .Username .Nickname .Email
+type WelcomeMailConfig struct {
+	// Subject 邮件主题模板
+	Subject string `mapstructure:"subject"`
+	// TemplateFile 正文模板文件路径，为空时使用内置模板
+	TemplateFile string `mapstructure:"template_file"`
+}
+
+// Addr 返回 SMTP 服务器的 host:port
+func (c *MailConfig) Addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// StorageConfig 上传文件存储配置
+// 当前使用本地目录存储，并由服务在 URLPrefix 下提供静态访问
+type StorageConfig struct {
+	// LocalDir 本地存储目录
+	LocalDir string `mapstructure:"local_dir"`
+	// URLPrefix 文件的访问路径前缀，也可以是 CDN 地址，例如 https://cdn.example.com/uploads
+	URLPrefix string `mapstructure:"url_prefix"`
+}
+
+// AvatarConfig 头像上传配置
+type AvatarConfig struct {
+	// MaxSize 上传文件大小上限（MB）
+	MaxSize int `mapstructure:"max_size"`
+	// MaxDimension 图片宽、高的上限（像素），解码前检查，避免超大图片耗尽内存
+	MaxDimension int `mapstructure:"max_dimension"`
+	// ThumbnailSizes 生成的正方形缩略图边长（像素）
+	ThumbnailSizes []int `mapstructure:"thumbnail_sizes"`
+	// ProcessTimeout 单次头像处理的超时时间（秒）
+	ProcessTimeout int `mapstructure:"process_timeout"`
+	// MaxConcurrent 同时处理的头像数上限，超出的请求排队等待直到超时
+	MaxConcurrent int `mapstructure:"max_concurrent"`
+}
+
+// MaxSizeBytes 返回上传文件大小上限（字节）
+func (c *AvatarConfig) MaxSizeBytes() int64 {
+	return int64(c.MaxSize) << 20
+}
+
+// ProcessTimeoutDuration 返回单次头像处理的超时时间
+func (c *AvatarConfig) ProcessTimeoutDuration() time.Duration {
+	return time.Duration(c.ProcessTimeout) * time.Second
 }
 
 // AppConfig 应用程序基本配置
@@ -53,6 +201,9 @@ type AppConfig struct {
 	WriteTimeout int `mapstructure:"write_timeout"`
 	// ShutdownTimeout 优雅关闭超时时间（秒）
 	ShutdownTimeout int `mapstructure:"shutdown_timeout"`
+	// RequestTimeout 单个请求的处理 deadline（秒），写入请求 context，默认 0 表示不设置
+	// 应小于 WriteTimeout，否则连接先被关闭，客户端收不到超时响应；导入、SSE、同步导出等路由不受约束
+	RequestTimeout int `mapstructure:"request_timeout"`
 }
 
 // Address 返回服务器监听地址
@@ -75,6 +226,11 @@ func (c *AppConfig) ShutdownTimeoutDuration() time.Duration {
 	return time.Duration(c.ShutdownTimeout) * time.Second
 }
 
+// RequestTimeoutDuration 返回单个请求的处理 deadline
+func (c *AppConfig) RequestTimeoutDuration() time.Duration {
+	return time.Duration(c.RequestTimeout) * time.Second
+}
+
 // IsDebug 检查是否为调试模式
 func (c *AppConfig) IsDebug() bool {
 	return c.Mode == "debug"
@@ -97,14 +253,29 @@ type DatabaseConfig struct {
 	Pool PoolConfig `mapstructure:"pool"`
 	// AutoMigrate 是否自动迁移数据库
 	AutoMigrate bool `mapstructure:"auto_migrate"`
+	// MigrationLockTimeout 等待迁移锁的最长时间（秒），多实例同时启动时只有一个实例执行迁移
+	MigrationLockTimeout int `mapstructure:"migration_lock_timeout"`
 	// LogMode 是否启用 SQL 日志
 	LogMode bool `mapstructure:"log_mode"`
+	// IDGenerator 主键 ID 生成器: uuid, ulid
+	IDGenerator string `mapstructure:"id_generator"`
+}
+
+// MigrationLockTimeoutDuration 返回等待迁移锁的最长时间
+func (c *DatabaseConfig) MigrationLockTimeoutDuration() time.Duration {
+	return time.Duration(c.MigrationLockTimeout) * time.Second
 }
 
 // SQLiteConfig SQLite 数据库配置
 type SQLiteConfig struct {
 	// Path 数据库文件路径
 	Path string `mapstructure:"path"`
+	// ForeignKeys 是否启用外键约束（PRAGMA foreign_keys）
+	ForeignKeys bool `mapstructure:"foreign_keys"`
+	// WAL 是否启用 WAL 日志模式（PRAGMA journal_mode = WAL），改善并发读写
+	WAL bool `mapstructure:"wal"`
+	// BusyTimeout 数据库被锁时的等待时间（毫秒），0 表示不等待
+	BusyTimeout int `mapstructure:"busy_timeout"`
 }
 
 // MySQLConfig MySQL 数据库配置
@@ -155,12 +326,41 @@ func (c *MySQLConfig) DSN() string {
 type PoolConfig struct {
 	// MaxIdleConns 最大空闲连接数
 	MaxIdleConns int `mapstructure:"max_idle_conns"`
-	// MaxOpenConns 最大打开连接数
+	// MaxOpenConns 最大打开连接数，0 表示不限
 	MaxOpenConns int `mapstructure:"max_open_conns"`
 	// ConnMaxLifetime 连接最大生存时间（分钟）
 	ConnMaxLifetime int `mapstructure:"conn_max_lifetime"`
 	// ConnMaxIdleTime 空闲连接最大生存时间（分钟）
 	ConnMaxIdleTime int `mapstructure:"conn_max_idle_time"`
+	// SaturationThreshold 使用中连接数占最大连接数的比例达到该值时，就绪检查标记为降级（0-1）
+	SaturationThreshold float64 `mapstructure:"saturation_threshold"`
+	// DegradedStatusCode 连接池降级时就绪检查返回的 HTTP 状态码：200（仍接收流量）或 503（摘除流量）
+	DegradedStatusCode int `mapstructure:"degraded_status_code"`
+}
+
+// maxPoolOpenConns 最大打开连接数的上限，防止误配置耗尽数据库的连接数
+const maxPoolOpenConns = 10000
+
+// ValidateLimits 校验运行时调整的连接数与连接生存时间
+// 运行时调整必须给出明确的最大打开连接数（1-10000），不能改为不限
+func (c *PoolConfig) ValidateLimits() error {
+	return c.validateLimits(false)
+}
+
+// validateLimits 校验连接数与连接生存时间，启动时与运行时调整连接池时共用
+// allowUnlimited 为 true 时最大打开连接数可以为 0（不限），与 database/sql 的约定一致
+func (c *PoolConfig) validateLimits(allowUnlimited bool) error {
+	unlimited := allowUnlimited && c.MaxOpenConns == 0
+	if !unlimited && (c.MaxOpenConns < 1 || c.MaxOpenConns > maxPoolOpenConns) {
+		return fmt.Errorf("最大打开连接数必须在 1-%d 之间: %d", maxPoolOpenConns, c.MaxOpenConns)
+	}
+	if c.MaxIdleConns < 0 || (!unlimited && c.MaxIdleConns > c.MaxOpenConns) {
+		return fmt.Errorf("最大空闲连接数必须在 0 与最大打开连接数之间: %d", c.MaxIdleConns)
+	}
+	if c.ConnMaxLifetime < 0 || c.ConnMaxIdleTime < 0 {
+		return fmt.Errorf("连接生存时间不能为负数")
+	}
+	return nil
 }
 
 // ConnMaxLifetimeDuration 返回连接最大生存时间
@@ -175,7 +375,8 @@ func (c *PoolConfig) ConnMaxIdleTimeDuration() time.Duration {
 
 // JWTConfig JWT 认证配置
 type JWTConfig struct {
-	// Secret JWT 签名密钥
+	// Secret JWT 签名密钥，没有默认值
+	// 配置了 Keys 时只用于验证轮转前签发的、不带 kid 的旧令牌，为空表示不再接受这类令牌
 	Secret string `mapstructure:"secret"`
 	// Issuer JWT 签发者
 	Issuer string `mapstructure:"issuer"`
@@ -183,6 +384,106 @@ type JWTConfig struct {
 	AccessTokenExpire int `mapstructure:"access_token_expire"`
 	// RefreshTokenExpire 刷新令牌过期时间（小时）
 	RefreshTokenExpire int `mapstructure:"refresh_token_expire"`
+	// RenewThreshold 访问令牌自动续签阈值（分钟）
+	// 剩余有效期低于该值时，认证中间件会在响应头中返回新令牌；0 表示关闭
+	// 续签时重新读取用户角色，会话自登录起最长不超过 RefreshTokenExpire
+	RenewThreshold int `mapstructure:"renew_threshold"`
+	// Keys 签名密钥集合，用于密钥轮转
+	// 配置后签发使用 CurrentKeyID 对应的密钥并写入 kid 头，验证时按 kid 选择密钥；
+	// 未配置时退回单一的 Secret
+	Keys []JWTKey `mapstructure:"keys"`
+	// CurrentKeyID 当前签名密钥的 kid，为空时使用 Keys 中的第一个
+	CurrentKeyID string `mapstructure:"current_key_id"`
+	// Cookie 基于 cookie 传递访问令牌的配置
+	Cookie AuthCookieConfig `mapstructure:"cookie"`
+	// Blacklist 已吊销访问令牌的黑名单
+	Blacklist TokenBlacklistConfig `mapstructure:"blacklist"`
+	// ExtraClaims 写入访问令牌 ext 字段的额外声明
+	ExtraClaims ExtraClaimsConfig `mapstructure:"extra_claims"`
+}
+
+// ExtraClaimUserFields 可作为额外声明写入访问令牌的用户字段
+var ExtraClaimUserFields = []string{"nickname", "gender", "source", "profile_visibility"}
+
+// ExtraClaimsConfig 访问令牌额外声明配置
+// 签发与续签访问令牌时写入 ext 字段，供下游服务读取；两项都为空时不写入
+type ExtraClaimsConfig struct {
+	// Static 固定写入每个访问令牌的声明，例如 tenant、env；键名会被转为小写
+	Static map[string]string `mapstructure:"static"`
+	// UserFields 从用户资料写入的字段，取值见 ExtraClaimUserFields，声明名与字段名相同
+	UserFields []string `mapstructure:"user_fields"`
+}
+
+// 令牌黑名单存储
+const (
+	// TokenBlacklistMemory 进程内存，只在当前副本有效，重启后丢失
+	TokenBlacklistMemory = "memory"
+	// TokenBlacklistRedis Redis，多副本共享且跨重启保留
+	TokenBlacklistRedis = "redis"
+)
+
+// TokenBlacklistConfig 令牌黑名单配置
+// 登出时访问令牌的 jti 进入黑名单直到令牌过期，认证时命中即拒绝
+type TokenBlacklistConfig struct {
+	// Driver 存储方式: memory, redis
+	Driver string `mapstructure:"driver"`
+	// KeyPrefix Redis key 前缀
+	KeyPrefix string `mapstructure:"key_prefix"`
+	// Redis Redis 连接配置，Driver 为 redis 时使用
+	Redis RedisConfig `mapstructure:"redis"`
+	// FailClosed Redis 不可用时拒绝无法确认吊销状态的令牌（返回 503），默认关闭，
+	// 关闭时回退到本实例的内存黑名单与令牌版本校验
+	FailClosed bool `mapstructure:"fail_closed"`
+}
+
+// RedisConfig Redis 连接配置
+type RedisConfig struct {
+	// Addr 地址，例如 localhost:6379
+	Addr string `mapstructure:"addr"`
+	// Password 密码，未设置时为空
+	Password string `mapstructure:"password"`
+	// DB 数据库编号
+	DB int `mapstructure:"db"`
+}
+
+// AuthCookieConfig 访问令牌 cookie 配置
+// 启用后登录、刷新令牌时把访问令牌写入 HttpOnly cookie，请求未携带 Authorization 头时从该 cookie 读取令牌
+type AuthCookieConfig struct {
+	// Enabled 是否启用 cookie 认证
+	Enabled bool `mapstructure:"enabled"`
+	// Name cookie 名称
+	Name string `mapstructure:"name"`
+	// Domain cookie 作用域名，为空时只对当前域名生效
+	Domain string `mapstructure:"domain"`
+	// Path cookie 作用路径
+	Path string `mapstructure:"path"`
+	// Secure 是否只通过 HTTPS 发送
+	Secure bool `mapstructure:"secure"`
+	// SameSite 跨站发送策略: lax, strict, none
+	SameSite string `mapstructure:"same_site"`
+}
+
+// SameSiteMode 返回 SameSite 对应的 http.SameSite 取值
+func (c *AuthCookieConfig) SameSiteMode() http.SameSite {
+	switch strings.ToLower(c.SameSite) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+// insecureJWTSecret 早期版本的默认 JWT 密钥，已公开，任何人都能用它伪造令牌
+const insecureJWTSecret = "your-secret-key"
+
+// JWTKey JWT 签名密钥
+type JWTKey struct {
+	// ID 密钥标识，写入令牌的 kid 头
+	ID string `mapstructure:"id"`
+	// Secret 密钥内容
+	Secret string `mapstructure:"secret"`
 }
 
 // AccessTokenExpireDuration 返回访问令牌过期时间
@@ -195,6 +496,39 @@ func (c *JWTConfig) RefreshTokenExpireDuration() time.Duration {
 	return time.Duration(c.RefreshTokenExpire) * time.Hour
 }
 
+// RenewThresholdDuration 返回访问令牌自动续签阈值
+func (c *JWTConfig) RenewThresholdDuration() time.Duration {
+	return time.Duration(c.RenewThreshold) * time.Minute
+}
+
+// validateKeys 验证密钥集合：kid 非空且唯一、密钥长度足够、当前 kid 存在
+func (c *JWTConfig) validateKeys() error {
+	if len(c.Keys) == 0 {
+		if c.CurrentKeyID != "" {
+			return fmt.Errorf("未配置 JWT 密钥集合时不能指定 current_key_id")
+		}
+		return nil
+	}
+
+	seen := make(map[string]bool, len(c.Keys))
+	for _, key := range c.Keys {
+		if key.ID == "" {
+			return fmt.Errorf("JWT 密钥的 id 不能为空")
+		}
+		if seen[key.ID] {
+			return fmt.Errorf("JWT 密钥 id 重复: %s", key.ID)
+		}
+		seen[key.ID] = true
+		if len(key.Secret) < 8 {
+			return fmt.Errorf("JWT 密钥 %s 长度不能少于 8 个字符", key.ID)
+		}
+	}
+	if c.CurrentKeyID != "" && !seen[c.CurrentKeyID] {
+		return fmt.Errorf("JWT 当前密钥 %s 不在密钥集合中", c.CurrentKeyID)
+	}
+	return nil
+}
+
 // LogConfig 日志配置
 type LogConfig struct {
 	// Level 日志级别: debug, info, warn, error
@@ -207,6 +541,16 @@ type LogConfig struct {
 	File LogFileConfig `mapstructure:"file"`
 	// ShowCaller 是否显示调用者信息
 	ShowCaller bool `mapstructure:"show_caller"`
+	// SlowRequestThreshold 慢请求阈值（毫秒），超过时以 Warn 级别记录，0 表示不检测
+	SlowRequestThreshold int `mapstructure:"slow_request_threshold"`
+	// AccessLogExcludePaths 访问日志降为 debug 级别的路径（健康检查等高频探测），以 * 结尾时按前缀匹配
+	// 这些路径的请求出错或变慢时仍正常记录
+	AccessLogExcludePaths []string `mapstructure:"access_log_exclude_paths"`
+}
+
+// SlowRequestThresholdDuration 返回慢请求阈值
+func (c *LogConfig) SlowRequestThresholdDuration() time.Duration {
+	return time.Duration(c.SlowRequestThreshold) * time.Millisecond
 }
 
 // LogFileConfig 日志文件配置
@@ -227,8 +571,122 @@ type LogFileConfig struct {
 type SecurityConfig struct {
 	// BcryptCost 密码加密成本
 	BcryptCost int `mapstructure:"bcrypt_cost"`
+	// RequireEmail 注册时是否必须填写邮箱
+	RequireEmail bool `mapstructure:"require_email"`
+	// TrustedProxies 可信代理的 IP 或 CIDR，只有来自这些地址的请求才会解析 X-Forwarded-For
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// LoginAnomalyDetection 是否启用登录地点异常检测
+	LoginAnomalyDetection bool `mapstructure:"login_anomaly_detection"`
+	// GeoIPDatabase 本地 GeoIP 网段库（CSV）路径，为空时只比较登录 IP
+	GeoIPDatabase string `mapstructure:"geoip_database"`
+	// PasswordHistoryCount 修改密码时禁止重用的历史密码个数（不含当前密码），0 表示只禁止与当前密码相同
+	PasswordHistoryCount int `mapstructure:"password_history_count"`
+	// SoftDeleteRetentionDays 软删除用户的保留天数，超过后由后台任务永久删除；0 表示不清理
+	SoftDeleteRetentionDays int `mapstructure:"soft_delete_retention_days"`
+	// RefreshTokenRetentionDays 已过期或已撤销的刷新令牌记录的保留天数，超过后定期删除；0 表示不清理
+	RefreshTokenRetentionDays int `mapstructure:"refresh_token_retention_days"`
+	// OnlineThresholdMinutes 最近活跃时间在该分钟数以内的用户视为在线
+	OnlineThresholdMinutes int `mapstructure:"online_threshold_minutes"`
+	// Registration 注册开关与节流配置
+	Registration RegistrationConfig `mapstructure:"registration"`
+	// EmailDomains 注册邮箱的域名白/黑名单
+	EmailDomains EmailDomainsConfig `mapstructure:"email_domains"`
+	// Username 用户名格式与保留字策略
+	Username UsernamePolicyConfig `mapstructure:"username"`
+	// Impersonation 管理员模拟登录配置
+	Impersonation ImpersonationConfig `mapstructure:"impersonation"`
 	// CORS 跨域配置
 	CORS CORSConfig `mapstructure:"cors"`
+	// ReplayProtection 敏感写操作的请求重放防护
+	ReplayProtection ReplayProtectionConfig `mapstructure:"replay_protection"`
+}
+
+// SoftDeleteRetention 返回软删除用户的保留时长
+func (c *SecurityConfig) SoftDeleteRetention() time.Duration {
+	return time.Duration(c.SoftDeleteRetentionDays) * 24 * time.Hour
+}
+
+// RefreshTokenRetention 返回已失效刷新令牌记录的保留时长
+func (c *SecurityConfig) RefreshTokenRetention() time.Duration {
+	return time.Duration(c.RefreshTokenRetentionDays) * 24 * time.Hour
+}
+
+// OnlineThreshold 返回判定用户在线的活跃时间窗口
+func (c *SecurityConfig) OnlineThreshold() time.Duration {
+	return time.Duration(c.OnlineThresholdMinutes) * time.Minute
+}
+
+// ReplayProtectionConfig 请求重放防护配置
+// 启用后修改密码、删除用户等敏感写操作必须携带 X-Nonce 与 X-Timestamp
+type ReplayProtectionConfig struct {
+	// Enabled 是否启用
+	Enabled bool `mapstructure:"enabled"`
+	// Window 时间戳与服务器时间允许的最大偏差（秒）
+	Window int `mapstructure:"window"`
+}
+
+// WindowDuration 返回时间戳允许的最大偏差
+func (c *ReplayProtectionConfig) WindowDuration() time.Duration {
+	return time.Duration(c.Window) * time.Second
+}
+
+// RegistrationConfig 注册开关与节流配置
+type RegistrationConfig struct {
+	// Enabled 是否开放注册，关闭后注册接口返回 403
+	Enabled bool `mapstructure:"enabled"`
+	// HourlyLimit 每个自然小时全局允许的注册数，超过后返回 429；0 表示不限
+	HourlyLimit int `mapstructure:"hourly_limit"`
+}
+
+// EmailDomainsConfig 注册邮箱的域名白/黑名单
+// 域名不区分大小写，同时匹配其子域名（example.com 匹配 mail.example.com）；
+// 同时命中时禁止列表优先
+type EmailDomainsConfig struct {
+	// Allow 允许注册的邮箱域名，为空表示不限制
+	Allow []string `mapstructure:"allow"`
+	// Deny 禁止注册的邮箱域名
+	Deny []string `mapstructure:"deny"`
+}
+
+// UsernamePolicyConfig 用户名格式与保留字策略
+// 注册、管理员修改用户名与批量导入时校验
+type UsernamePolicyConfig struct {
+	// MinLength 最小长度（字符数）
+	MinLength int `mapstructure:"min_length"`
+	// MaxLength 最大长度（字符数），不能超过 50（数据库列宽）
+	MaxLength int `mapstructure:"max_length"`
+	// AllowUnderscore 是否允许下划线
+	AllowUnderscore bool `mapstructure:"allow_underscore"`
+	// AllowHyphen 是否允许连字符
+	AllowHyphen bool `mapstructure:"allow_hyphen"`
+	// Reserved 保留用户名，不区分大小写，忽略下划线与连字符（ad_min 同样视为 admin）
+	Reserved []string `mapstructure:"reserved"`
+}
+
+// ImpersonationConfig 管理员模拟登录配置
+// 超级管理员可签发以目标用户身份访问的短期令牌，用于客服排查问题
+type ImpersonationConfig struct {
+	// Enabled 是否允许模拟登录
+	Enabled bool `mapstructure:"enabled"`
+	// SuperAdmins 允许模拟登录的超级管理员用户 ID（必须同时是管理员）
+	SuperAdmins []string `mapstructure:"super_admins"`
+	// ExpireMinutes 模拟令牌有效期（分钟），不会自动续签
+	ExpireMinutes int `mapstructure:"expire_minutes"`
+}
+
+// ExpireDuration 返回模拟令牌有效期
+func (c *ImpersonationConfig) ExpireDuration() time.Duration {
+	return time.Duration(c.ExpireMinutes) * time.Minute
+}
+
+// IsSuperAdmin 判断用户是否为超级管理员
+func (c *ImpersonationConfig) IsSuperAdmin(userID string) bool {
+	for _, id := range c.SuperAdmins {
+		if id == userID {
+			return true
+		}
+	}
+	return false
 }
 
 // CORSConfig 跨域资源共享配置
@@ -247,6 +705,27 @@ type CORSConfig struct {
 	AllowCredentials bool `mapstructure:"allow_credentials"`
 	// MaxAge 预检请求缓存时间（秒）
 	MaxAge int `mapstructure:"max_age"`
+	// Policies 按路径前缀区分的 CORS 策略，未匹配的路径使用上面的全局配置
+	Policies []CORSPolicyConfig `mapstructure:"policies"`
+}
+
+// CORSPolicyConfig 按路径前缀生效的 CORS 策略
+// 未设置的字段沿用全局 CORS 配置
+type CORSPolicyConfig struct {
+	// PathPrefix 生效的路径前缀，如 /api/v1/users
+	PathPrefix string `mapstructure:"path_prefix"`
+	// AllowedOrigins 允许的来源
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+	// AllowedMethods 允许的方法
+	AllowedMethods []string `mapstructure:"allowed_methods"`
+	// AllowedHeaders 允许的请求头
+	AllowedHeaders []string `mapstructure:"allowed_headers"`
+	// ExposedHeaders 暴露的响应头
+	ExposedHeaders []string `mapstructure:"exposed_headers"`
+	// AllowCredentials 是否允许携带凭证，未设置时沿用全局配置
+	AllowCredentials *bool `mapstructure:"allow_credentials"`
+	// MaxAge 预检请求缓存时间（秒），0 表示沿用全局配置
+	MaxAge int `mapstructure:"max_age"`
 }
 
 // RateLimitConfig 速率限制配置
@@ -257,6 +736,48 @@ type RateLimitConfig struct {
 	RequestsPerSecond int `mapstructure:"requests_per_second"`
 	// Burst 突发请求数
 	Burst int `mapstructure:"burst"`
+	// Antiabuse 注册接口的请求指纹防刷限制
+	Antiabuse AntiabuseConfig `mapstructure:"antiabuse"`
+	// Concurrency 重型列表查询的并发数限制
+	Concurrency ConcurrencyLimitConfig `mapstructure:"concurrency"`
+}
+
+// ConcurrencyLimitConfig 重型端点（用户列表、使用记录列表与统计）的并发数限制
+// 这些端点共享同一组名额，超出上限的请求排队等待，超时返回 503
+type ConcurrencyLimitConfig struct {
+	// Enabled 是否启用
+	Enabled bool `mapstructure:"enabled"`
+	// MaxConcurrent 同时执行的最大请求数
+	MaxConcurrent int `mapstructure:"max_concurrent"`
+	// QueueTimeoutMs 并发已满时的最长排队时间（毫秒），0 表示不排队
+	QueueTimeoutMs int `mapstructure:"queue_timeout_ms"`
+}
+
+// QueueTimeout 返回最长排队时间
+func (c *ConcurrencyLimitConfig) QueueTimeout() time.Duration {
+	return time.Duration(c.QueueTimeoutMs) * time.Millisecond
+}
+
+// AntiabuseConfig 请求指纹（IP + User-Agent + 端点）防刷配置
+type AntiabuseConfig struct {
+	// Enabled 是否启用
+	Enabled bool `mapstructure:"enabled"`
+	// Window 统计窗口（秒）
+	Window int `mapstructure:"window"`
+	// Threshold 窗口内同一指纹允许的最大请求数
+	Threshold int `mapstructure:"threshold"`
+	// BlockDuration 超过阈值后拒绝该指纹的时长（秒）
+	BlockDuration int `mapstructure:"block_duration"`
+}
+
+// WindowDuration 返回统计窗口
+func (c *AntiabuseConfig) WindowDuration() time.Duration {
+	return time.Duration(c.Window) * time.Second
+}
+
+// BlockDurationDuration 返回拒绝时长
+func (c *AntiabuseConfig) BlockDurationDuration() time.Duration {
+	return time.Duration(c.BlockDuration) * time.Second
 }
 
 // PaginationConfig 分页配置
@@ -265,16 +786,168 @@ type PaginationConfig struct {
 	DefaultPageSize int `mapstructure:"default_page_size"`
 	// MaxPageSize 最大每页数量
 	MaxPageSize int `mapstructure:"max_page_size"`
+	// MaxOffset 列表查询允许的最大偏移量（(page-1)*page_size），0 表示不限制
+	// 深翻页需要数据库扫描并丢弃大量行，超过时拒绝请求
+	MaxOffset int `mapstructure:"max_offset"`
+	// DefaultSortBy 未指定排序字段时使用的字段，不在排序白名单内时按 created_at 排序
+	DefaultSortBy string `mapstructure:"default_sort_by"`
+	// DefaultSortOrder 未指定排序方向时使用的方向: asc, desc
+	DefaultSortOrder string `mapstructure:"default_sort_order"`
 }
 
 // RiskReportConfig 风险报告配置
 type RiskReportConfig struct {
 	// APIKeys 允许的 API Keys 列表（用于外部服务调用）
 	APIKeys []string `mapstructure:"api_keys"`
+	// AdminAPIKeys 具有管理权限的 API Keys，可修改、删除使用记录（同时视为有效的 API Key）
+	AdminAPIKeys []string `mapstructure:"admin_api_keys"`
+	// PromptTokenPrice 每千个 prompt token 的费用，用于导出对账
+	PromptTokenPrice float64 `mapstructure:"prompt_token_price"`
+	// CompletionTokenPrice 每千个 completion token 的费用，用于导出对账
+	CompletionTokenPrice float64 `mapstructure:"completion_token_price"`
+	// MonthlyTokenQuota 每个用户每自然月（UTC）可用的 token 数，0 表示不限
+	MonthlyTokenQuota int64 `mapstructure:"monthly_token_quota"`
+	// DailyTokenQuota 每个用户每自然日（UTC）可用的 token 数，0 表示不限
+	DailyTokenQuota int64 `mapstructure:"daily_token_quota"`
+	// QuotaOverrides 按用户覆盖的配额
+	QuotaOverrides []QuotaOverrideConfig `mapstructure:"quota_overrides"`
+	// Buffer 单条上报的异步批量写入配置
+	Buffer UsageBufferConfig `mapstructure:"buffer"`
+	// DailyAggregate 按用户按天预聚合的配置
+	DailyAggregate UsageDailyAggregateConfig `mapstructure:"daily_aggregate"`
+}
+
+// UsageDailyAggregateConfig 使用记录日聚合配置
+type UsageDailyAggregateConfig struct {
+	// Enabled 是否定期刷新日聚合；关闭时仍可通过管理接口手动刷新
+	Enabled bool `mapstructure:"enabled"`
+	// RefreshInterval 定期刷新的间隔（秒）
+	RefreshInterval int `mapstructure:"refresh_interval"`
+	// LookbackDays 定期刷新时重新计算最近多少天，用于容纳迟到的上报
+	LookbackDays int `mapstructure:"lookback_days"`
+}
+
+// RefreshIntervalDuration 返回定期刷新间隔
+func (c *UsageDailyAggregateConfig) RefreshIntervalDuration() time.Duration {
+	return time.Duration(c.RefreshInterval) * time.Second
+}
+
+// UsageBufferConfig 使用记录写入缓冲配置
+type UsageBufferConfig struct {
+	// Enabled 是否启用缓冲；启用后单条上报先进入内存缓冲，再批量写库
+	Enabled bool `mapstructure:"enabled"`
+	// BatchSize 缓冲达到该条数时立即写库
+	BatchSize int `mapstructure:"batch_size"`
+	// FlushInterval 定时写库的间隔（毫秒）
+	FlushInterval int `mapstructure:"flush_interval"`
+	// Sync 同步模式：不启动后台定时写库，达到批量大小时在上报请求中直接写库（用于测试）
+	Sync bool `mapstructure:"sync"`
+	// MaxPending 缓冲最多保留的记录数（含写库失败待重试的记录），超出时丢弃最早的记录；小于 BatchSize 时按 BatchSize
+	MaxPending int `mapstructure:"max_pending"`
+}
+
+// FlushIntervalDuration 返回定时写库间隔
+func (c *UsageBufferConfig) FlushIntervalDuration() time.Duration {
+	return time.Duration(c.FlushInterval) * time.Millisecond
+}
+
+// QuotaOverrideConfig 单个用户的配额覆盖
+type QuotaOverrideConfig struct {
+	// UserID 用户 ID
+	UserID string `mapstructure:"user_id"`
+	// MonthlyTokenQuota 该用户每月可用的 token 数，0 表示不限
+	MonthlyTokenQuota int64 `mapstructure:"monthly_token_quota"`
+	// DailyTokenQuota 该用户每日可用的 token 数，0 表示不限
+	DailyTokenQuota int64 `mapstructure:"daily_token_quota"`
 }
 
+// MonthlyTokenQuotaFor 返回指定用户的月度 token 配额，0 表示不限
+// 有覆盖配置时优先使用覆盖值
+func (c *RiskReportConfig) MonthlyTokenQuotaFor(userID string) int64 {
+	for _, o := range c.QuotaOverrides {
+		if o.UserID == userID {
+			return o.MonthlyTokenQuota
+		}
+	}
+	return c.MonthlyTokenQuota
+}
+
+// DailyTokenQuotaFor 返回指定用户的每日 token 配额，0 表示不限
+// 有覆盖配置时优先使用覆盖值（覆盖配置同时替换月度与每日配额）
+func (c *RiskReportConfig) DailyTokenQuotaFor(userID string) int64 {
+	for _, o := range c.QuotaOverrides {
+		if o.UserID == userID {
+			return o.DailyTokenQuota
+		}
+	}
+	return c.DailyTokenQuota
+}
+
+// RequestConfig 请求解析配置
+type RequestConfig struct {
+	// DisallowUnknownFields 是否拒绝 JSON 请求体中的未知字段
+	// 关闭时（默认）未知字段被忽略，便于新旧版本客户端兼容；开启后返回 400 并指出未知字段
+	DisallowUnknownFields bool `mapstructure:"disallow_unknown_fields"`
+	// JSONSchema 按路由用 JSON Schema 校验请求体
+	JSONSchema JSONSchemaConfig `mapstructure:"json_schema"`
+}
+
+// JSONSchemaConfig 请求体 JSON Schema 校验配置
+// 适合 struct tag 难以表达的复杂请求；校验在绑定之前进行，未配置 schema 的路由不受影响
+type JSONSchemaConfig struct {
+	// Enabled 是否启用
+	Enabled bool `mapstructure:"enabled"`
+	// Dir schema 文件所在目录
+	Dir string `mapstructure:"dir"`
+	// Routes 路由与 schema 文件的对应关系
+	Routes []JSONSchemaRoute `mapstructure:"routes"`
+}
+
+// JSONSchemaRoute 单个路由使用的 schema
+type JSONSchemaRoute struct {
+	// Method HTTP 方法，例如 POST
+	Method string `mapstructure:"method"`
+	// Path 路由模板，与注册时一致，例如 /api/v1/users/:id
+	Path string `mapstructure:"path"`
+	// Schema schema 文件名，相对于 Dir
+	Schema string `mapstructure:"schema"`
+}
+
+// ResponseConfig 响应输出配置
+type ResponseConfig struct {
+	// NamingConvention 默认响应字段命名风格: snake_case, camelCase
+	// 客户端可通过 X-Naming-Convention 请求头覆盖
+	NamingConvention string `mapstructure:"naming_convention"`
+	// DefaultLanguage 错误消息的默认语言: zh-CN, en
+	// 客户端可通过 X-Lang 或 Accept-Language 请求头覆盖
+	DefaultLanguage string `mapstructure:"default_language"`
+	// DefaultTimezone 时间字段的默认呈现时区（IANA 时区名，例如 UTC、Asia/Shanghai）
+	// 为空时按服务器序列化结果输出；客户端可通过 X-Timezone 请求头覆盖
+	DefaultTimezone string `mapstructure:"default_timezone"`
+	// ServerTime 是否在响应信封中附带 server_time（Unix 毫秒时间戳）
+	ServerTime bool `mapstructure:"server_time"`
+	// Compression 响应压缩
+	Compression CompressionConfig `mapstructure:"compression"`
+}
+
+// CompressionConfig 响应压缩配置
+// 按 Accept-Encoding 协商，优先 Brotli，其次 gzip
+type CompressionConfig struct {
+	// Enabled 是否启用响应压缩
+	Enabled bool `mapstructure:"enabled"`
+	// MinSize 响应体达到该字节数才压缩，过小的响应压缩收益不抵开销
+	MinSize int `mapstructure:"min_size"`
+}
+
+// EnvVar 指定运行环境的环境变量，用于选择环境覆盖文件
+const EnvVar = "APP_ENV"
+
+// defaultConfigPaths 未指定配置文件路径时的查找目录
+var defaultConfigPaths = []string{"./configs", ".", "../configs", "../../configs"}
+
 // Load 加载配置文件
-// configPath 是配置文件的路径，如果为空则使用默认路径
+// configPath 是配置文件的路径，如果为空则使用默认路径。
+// 读取基础配置后，若设置了 APP_ENV，再合并同目录下对应环境的覆盖文件
 func Load(configPath string) (*Config, error) {
 	// 设置默认值
 	setDefaults()
@@ -286,10 +959,9 @@ func Load(configPath string) (*Config, error) {
 		// 默认配置文件路径
 		viper.SetConfigName("config")
 		viper.SetConfigType("yaml")
-		viper.AddConfigPath("./configs")
-		viper.AddConfigPath(".")
-		viper.AddConfigPath("../configs")
-		viper.AddConfigPath("../../configs")
+		for _, dir := range defaultConfigPaths {
+			viper.AddConfigPath(dir)
+		}
 	}
 
 	// 设置环境变量
@@ -320,6 +992,11 @@ func Load(configPath string) (*Config, error) {
 		}
 	}
 
+	// 合并 APP_ENV 对应环境的覆盖文件
+	if err := mergeEnvConfig(os.Getenv(EnvVar)); err != nil {
+		return nil, err
+	}
+
 	// 解析配置到结构体
 	var cfg Config
 	if err := viper.Unmarshal(&cfg); err != nil {
@@ -334,6 +1011,43 @@ func Load(configPath string) (*Config, error) {
 	return &cfg, nil
 }
 
+// mergeEnvConfig 合并指定环境的覆盖文件
+// 覆盖文件与基础配置文件同目录，文件名为 <基础名>.<env>.<扩展名>，如 config.prod.yaml；
+// 未找到基础配置文件时在默认路径下查找 config.<env>.yaml。env 为空或覆盖文件不存在时不做任何事
+func mergeEnvConfig(env string) error {
+	if env == "" {
+		return nil
+	}
+
+	var candidates []string
+	if base := viper.ConfigFileUsed(); base != "" {
+		candidates = append(candidates, envConfigPath(base, env))
+	} else {
+		for _, dir := range defaultConfigPaths {
+			candidates = append(candidates, filepath.Join(dir, "config."+env+".yaml"))
+		}
+	}
+
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		viper.SetConfigFile(path)
+		if err := viper.MergeInConfig(); err != nil {
+			return fmt.Errorf("合并环境配置文件 %s 失败: %w", path, err)
+		}
+		return nil
+	}
+	return nil
+}
+
+// envConfigPath 返回基础配置文件对应环境的覆盖文件路径
+// 例如 configs/config.yaml + prod => configs/config.prod.yaml
+func envConfigPath(base, env string) string {
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "." + env + ext
+}
+
 // setDefaults 设置默认配置值
 func setDefaults() {
 	// 应用程序默认配置
@@ -345,10 +1059,14 @@ func setDefaults() {
 	viper.SetDefault("app.read_timeout", 10)
 	viper.SetDefault("app.write_timeout", 10)
 	viper.SetDefault("app.shutdown_timeout", 30)
+	viper.SetDefault("app.request_timeout", 0)
 
 	// 数据库默认配置
 	viper.SetDefault("database.driver", "sqlite")
 	viper.SetDefault("database.sqlite.path", "./data/app.db")
+	viper.SetDefault("database.sqlite.foreign_keys", true)
+	viper.SetDefault("database.sqlite.wal", true)
+	viper.SetDefault("database.sqlite.busy_timeout", 5000)
 	viper.SetDefault("database.mysql.host", "localhost")
 	viper.SetDefault("database.mysql.port", 3306)
 	viper.SetDefault("database.mysql.username", "root")
@@ -361,14 +1079,32 @@ func setDefaults() {
 	viper.SetDefault("database.pool.max_open_conns", 100)
 	viper.SetDefault("database.pool.conn_max_lifetime", 60)
 	viper.SetDefault("database.pool.conn_max_idle_time", 30)
+	viper.SetDefault("database.pool.saturation_threshold", 0.9)
+	viper.SetDefault("database.pool.degraded_status_code", 200)
 	viper.SetDefault("database.auto_migrate", true)
+	viper.SetDefault("database.migration_lock_timeout", 60)
 	viper.SetDefault("database.log_mode", true)
+	viper.SetDefault("database.id_generator", "uuid")
 
 	// JWT 默认配置
-	viper.SetDefault("jwt.secret", "your-secret-key")
+	// 密钥没有默认值，必须通过配置文件或 APP_JWT_SECRET 显式设置
+	viper.SetDefault("jwt.secret", "")
 	viper.SetDefault("jwt.issuer", "go-user-api")
 	viper.SetDefault("jwt.access_token_expire", 24)
 	viper.SetDefault("jwt.refresh_token_expire", 168)
+	viper.SetDefault("jwt.renew_threshold", 30)
+	viper.SetDefault("jwt.cookie.enabled", false)
+	viper.SetDefault("jwt.cookie.name", "access_token")
+	viper.SetDefault("jwt.cookie.domain", "")
+	viper.SetDefault("jwt.cookie.path", "/")
+	viper.SetDefault("jwt.cookie.secure", true)
+	viper.SetDefault("jwt.cookie.same_site", "lax")
+	viper.SetDefault("jwt.blacklist.driver", TokenBlacklistMemory)
+	viper.SetDefault("jwt.blacklist.key_prefix", "jwt:blacklist:")
+	viper.SetDefault("jwt.blacklist.redis.addr", "localhost:6379")
+	viper.SetDefault("jwt.blacklist.redis.password", "")
+	viper.SetDefault("jwt.blacklist.redis.db", 0)
+	viper.SetDefault("jwt.blacklist.fail_closed", false)
 
 	// 日志默认配置
 	viper.SetDefault("log.level", "debug")
@@ -380,14 +1116,37 @@ func setDefaults() {
 	viper.SetDefault("log.file.max_age", 28)
 	viper.SetDefault("log.file.compress", true)
 	viper.SetDefault("log.show_caller", true)
+	viper.SetDefault("log.slow_request_threshold", 1000)
+	viper.SetDefault("log.access_log_exclude_paths", []string{"/health", "/ready", "/metrics"})
 
 	// 安全默认配置
 	viper.SetDefault("security.bcrypt_cost", 10)
+	viper.SetDefault("security.require_email", true)
+	viper.SetDefault("security.trusted_proxies", []string{"127.0.0.1", "::1"})
+	viper.SetDefault("security.login_anomaly_detection", true)
+	viper.SetDefault("security.geoip_database", "")
+	viper.SetDefault("security.password_history_count", 5)
+	viper.SetDefault("security.soft_delete_retention_days", 0)
+	viper.SetDefault("security.refresh_token_retention_days", 7)
+	viper.SetDefault("security.online_threshold_minutes", 5)
+	viper.SetDefault("security.registration.enabled", true)
+	viper.SetDefault("security.registration.hourly_limit", 0)
+	viper.SetDefault("security.username.min_length", 3)
+	viper.SetDefault("security.username.max_length", 30)
+	viper.SetDefault("security.username.allow_underscore", false)
+	viper.SetDefault("security.username.allow_hyphen", false)
+	viper.SetDefault("security.username.reserved", []string{
+		"admin", "administrator", "root", "system", "api", "support", "security", "me",
+	})
+	viper.SetDefault("security.impersonation.enabled", false)
+	viper.SetDefault("security.impersonation.expire_minutes", 15)
+	viper.SetDefault("security.replay_protection.enabled", false)
+	viper.SetDefault("security.replay_protection.window", 300)
 	viper.SetDefault("security.cors.enabled", true)
 	viper.SetDefault("security.cors.allowed_origins", []string{"*"})
 	viper.SetDefault("security.cors.allowed_methods", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"})
-	viper.SetDefault("security.cors.allowed_headers", []string{"Origin", "Content-Type", "Accept", "Authorization"})
-	viper.SetDefault("security.cors.exposed_headers", []string{"Content-Length"})
+	viper.SetDefault("security.cors.allowed_headers", []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Lang", "X-Timezone"})
+	viper.SetDefault("security.cors.exposed_headers", []string{"Content-Length", "X-Renewed-Token", "X-Response-Time"})
 	viper.SetDefault("security.cors.allow_credentials", true)
 	viper.SetDefault("security.cors.max_age", 3600)
 
@@ -395,13 +1154,87 @@ func setDefaults() {
 	viper.SetDefault("rate_limit.enabled", true)
 	viper.SetDefault("rate_limit.requests_per_second", 100)
 	viper.SetDefault("rate_limit.burst", 200)
+	viper.SetDefault("rate_limit.antiabuse.enabled", true)
+	viper.SetDefault("rate_limit.antiabuse.window", 60)
+	viper.SetDefault("rate_limit.antiabuse.threshold", 5)
+	viper.SetDefault("rate_limit.antiabuse.block_duration", 600)
+	viper.SetDefault("rate_limit.concurrency.enabled", true)
+	viper.SetDefault("rate_limit.concurrency.max_concurrent", 20)
+	viper.SetDefault("rate_limit.concurrency.queue_timeout_ms", 2000)
 
 	// 分页默认配置
 	viper.SetDefault("pagination.default_page_size", 20)
 	viper.SetDefault("pagination.max_page_size", 100)
+	viper.SetDefault("pagination.max_offset", 10000)
+	viper.SetDefault("pagination.default_sort_by", "created_at")
+	viper.SetDefault("pagination.default_sort_order", "desc")
 
 	// 风险报告默认配置
 	viper.SetDefault("risk_report.api_keys", []string{})
+	viper.SetDefault("risk_report.admin_api_keys", []string{})
+	viper.SetDefault("risk_report.buffer.enabled", false)
+	viper.SetDefault("risk_report.buffer.batch_size", 100)
+	viper.SetDefault("risk_report.buffer.flush_interval", 1000)
+	viper.SetDefault("risk_report.buffer.sync", false)
+	viper.SetDefault("risk_report.buffer.max_pending", 10000)
+	viper.SetDefault("risk_report.daily_aggregate.enabled", false)
+	viper.SetDefault("risk_report.daily_aggregate.refresh_interval", 3600)
+	viper.SetDefault("risk_report.daily_aggregate.lookback_days", 2)
+	viper.SetDefault("risk_report.prompt_token_price", 0)
+	viper.SetDefault("risk_report.completion_token_price", 0)
+	viper.SetDefault("risk_report.monthly_token_quota", 0)
+	viper.SetDefault("risk_report.daily_token_quota", 0)
+
+	// 请求默认配置
+	viper.SetDefault("request.disallow_unknown_fields", false)
+	viper.SetDefault("request.json_schema.enabled", false)
+	viper.SetDefault("request.json_schema.dir", "./configs/schemas")
+
+	// 响应默认配置
+	viper.SetDefault("response.naming_convention", "snake_case")
+	viper.SetDefault("response.default_language", "zh-CN")
+	viper.SetDefault("response.default_timezone", "")
+	viper.SetDefault("response.server_time", false)
+	viper.SetDefault("response.compression.enabled", true)
+	viper.SetDefault("response.compression.min_size", 1024)
+
+	// 后台任务默认配置
+	viper.SetDefault("jobs.workers", 2)
+	viper.SetDefault("jobs.queue_size", 100)
+	viper.SetDefault("jobs.data_export_ttl", 60)
+	viper.SetDefault("jobs.user_export_ttl", 60)
+	viper.SetDefault("jobs.user_export_dir", "./data/exports")
+
+	// 缓存默认配置
+	viper.SetDefault("cache.user_list_ttl", 5)
+
+	// 邮件默认配置
+	viper.SetDefault("mail.enabled", false)
+	viper.SetDefault("mail.host", "")
+	viper.SetDefault("mail.port", 587)
+	viper.SetDefault("mail.username", "")
+	viper.SetDefault("mail.password", "")
+	viper.SetDefault("mail.from", "")
+	viper.SetDefault("mail.welcome.subject", "欢迎加入 {{.AppName}}")
+	viper.SetDefault("mail.welcome.template_file", "")
+	viper.SetDefault("mail.retry.queue_size", 1000)
+	viper.SetDefault("mail.retry.max_attempts", 5)
+	viper.SetDefault("mail.retry.interval", 60)
+
+	// 熔断默认配置
+	viper.SetDefault("circuit_breaker.failure_threshold", 5)
+	viper.SetDefault("circuit_breaker.open_timeout", 30)
+
+	// 文件存储默认配置
+	viper.SetDefault("storage.local_dir", "./data/uploads")
+	viper.SetDefault("storage.url_prefix", "/uploads")
+
+	// 头像默认配置
+	viper.SetDefault("avatar.max_size", 5)
+	viper.SetDefault("avatar.max_dimension", 4096)
+	viper.SetDefault("avatar.thumbnail_sizes", []int{64, 128, 256})
+	viper.SetDefault("avatar.process_timeout", 10)
+	viper.SetDefault("avatar.max_concurrent", 4)
 }
 
 // Validate 验证配置的有效性
@@ -416,16 +1249,87 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("无效的运行模式: %s，必须是 debug、release 或 test", c.App.Mode)
 	}
 
+	if rt := c.App.RequestTimeout; rt < 0 || (rt > 0 && c.App.WriteTimeout > 0 && rt >= c.App.WriteTimeout) {
+		return fmt.Errorf("请求处理超时 request_timeout=%d 无效，不能为负数且必须小于 write_timeout=%d", rt, c.App.WriteTimeout)
+	}
+
 	// 验证数据库配置
 	validDrivers := map[string]bool{"mysql": true, "sqlite": true}
 	if !validDrivers[c.Database.Driver] {
 		return fmt.Errorf("无效的数据库驱动: %s，必须是 mysql 或 sqlite", c.Database.Driver)
 	}
 
+	if c.Database.SQLite.BusyTimeout < 0 {
+		return fmt.Errorf("SQLite busy_timeout 不能为负数: %d", c.Database.SQLite.BusyTimeout)
+	}
+
+	// 启动配置中 max_open_conns 为 0 表示不限
+	if err := c.Database.Pool.validateLimits(true); err != nil {
+		return err
+	}
+	if t := c.Database.Pool.SaturationThreshold; t <= 0 || t > 1 {
+		return fmt.Errorf("连接池饱和阈值必须在 (0, 1] 之间: %v", t)
+	}
+	if code := c.Database.Pool.DegradedStatusCode; code != 200 && code != 503 {
+		return fmt.Errorf("连接池降级状态码必须是 200 或 503: %d", code)
+	}
+
+	if c.Database.MigrationLockTimeout < 1 {
+		return fmt.Errorf("迁移锁等待时间必须大于 0: %d", c.Database.MigrationLockTimeout)
+	}
+
+	validIDGenerators := map[string]bool{"uuid": true, "ulid": true}
+	if !validIDGenerators[c.Database.IDGenerator] {
+		return fmt.Errorf("无效的 ID 生成器: %s，必须是 uuid 或 ulid", c.Database.IDGenerator)
+	}
+
 	// 验证 JWT 配置
-	if len(c.JWT.Secret) < 8 {
+	if c.JWT.Secret == insecureJWTSecret {
+		return fmt.Errorf("JWT 密钥不能使用公开的默认值 %q，请通过 jwt.secret 或 APP_JWT_SECRET 设置", insecureJWTSecret)
+	}
+	if (len(c.JWT.Keys) == 0 || c.JWT.Secret != "") && len(c.JWT.Secret) < 8 {
 		return fmt.Errorf("JWT 密钥长度不能少于 8 个字符")
 	}
+	if err := c.JWT.validateKeys(); err != nil {
+		return err
+	}
+	if cookie := c.JWT.Cookie; cookie.Enabled {
+		if cookie.Name == "" {
+			return fmt.Errorf("启用 cookie 认证时 cookie 名称不能为空")
+		}
+		validSameSite := map[string]bool{"lax": true, "strict": true, "none": true}
+		if !validSameSite[strings.ToLower(cookie.SameSite)] {
+			return fmt.Errorf("无效的 cookie SameSite: %s，必须是 lax、strict 或 none", cookie.SameSite)
+		}
+		// 浏览器会拒绝未设置 Secure 的 SameSite=None cookie
+		if strings.EqualFold(cookie.SameSite, "none") && !cookie.Secure {
+			return fmt.Errorf("cookie SameSite 为 none 时必须启用 secure")
+		}
+	}
+	if c.JWT.RenewThreshold < 0 {
+		return fmt.Errorf("JWT 续签阈值不能为负数: %d", c.JWT.RenewThreshold)
+	}
+	switch bl := c.JWT.Blacklist; bl.Driver {
+	case TokenBlacklistMemory:
+	case TokenBlacklistRedis:
+		if bl.Redis.Addr == "" {
+			return fmt.Errorf("令牌黑名单使用 redis 时必须配置 redis.addr")
+		}
+	default:
+		return fmt.Errorf("无效的令牌黑名单存储: %s，必须是 memory 或 redis", bl.Driver)
+	}
+	validClaimFields := make(map[string]bool, len(ExtraClaimUserFields))
+	for _, field := range ExtraClaimUserFields {
+		validClaimFields[field] = true
+	}
+	for _, field := range c.JWT.ExtraClaims.UserFields {
+		if !validClaimFields[field] {
+			return fmt.Errorf("无效的额外声明用户字段: %s，必须是 %s 之一", field, strings.Join(ExtraClaimUserFields, "、"))
+		}
+		if _, ok := c.JWT.ExtraClaims.Static[field]; ok {
+			return fmt.Errorf("额外声明 %s 同时配置在 static 与 user_fields 中", field)
+		}
+	}
 
 	// 验证日志配置
 	validLevels := map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
@@ -438,5 +1342,182 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("无效的日志格式: %s", c.Log.Format)
 	}
 
+	if a := c.RateLimit.Antiabuse; a.Enabled && (a.Window < 1 || a.Threshold < 1 || a.BlockDuration < 1) {
+		return fmt.Errorf("防刷限制的 window、threshold、block_duration 必须大于 0")
+	}
+
+	if cl := c.RateLimit.Concurrency; cl.Enabled && (cl.MaxConcurrent < 1 || cl.QueueTimeoutMs < 0) {
+		return fmt.Errorf("并发限制配置无效: max_concurrent=%d 必须至少为 1，queue_timeout_ms=%d 不能为负数", cl.MaxConcurrent, cl.QueueTimeoutMs)
+	}
+
+	if c.Security.SoftDeleteRetentionDays < 0 {
+		return fmt.Errorf("软删除保留天数不能为负数: %d", c.Security.SoftDeleteRetentionDays)
+	}
+	if c.Security.RefreshTokenRetentionDays < 0 {
+		return fmt.Errorf("刷新令牌保留天数不能为负数: %d", c.Security.RefreshTokenRetentionDays)
+	}
+
+	if c.Security.Registration.HourlyLimit < 0 {
+		return fmt.Errorf("每小时注册上限不能为负数: %d", c.Security.Registration.HourlyLimit)
+	}
+
+	for _, domain := range append(append([]string{}, c.Security.EmailDomains.Allow...), c.Security.EmailDomains.Deny...) {
+		if domain == "" || strings.Contains(domain, "@") {
+			return fmt.Errorf("无效的邮箱域名: %q", domain)
+		}
+	}
+
+	if u := c.Security.Username; u.MinLength < 1 || u.MaxLength < u.MinLength || u.MaxLength > 50 {
+		return fmt.Errorf("用户名长度限制无效: %d-%d，必须满足 1 <= min_length <= max_length <= 50", u.MinLength, u.MaxLength)
+	}
+
+	if imp := c.Security.Impersonation; imp.Enabled && (imp.ExpireMinutes < 1 || imp.ExpireMinutes > 60) {
+		return fmt.Errorf("模拟令牌有效期必须在 1 到 60 分钟之间: %d", imp.ExpireMinutes)
+	}
+
+	if rp := c.Security.ReplayProtection; rp.Enabled && rp.Window < 1 {
+		return fmt.Errorf("重放防护时间窗口必须至少 1 秒: %d", rp.Window)
+	}
+
+	if c.Security.OnlineThresholdMinutes < 1 {
+		return fmt.Errorf("在线判定时间窗口必须至少 1 分钟: %d", c.Security.OnlineThresholdMinutes)
+	}
+
+	if c.Security.PasswordHistoryCount < 0 {
+		return fmt.Errorf("密码历史个数不能为负数: %d", c.Security.PasswordHistoryCount)
+	}
+
+	for _, policy := range c.Security.CORS.Policies {
+		if !strings.HasPrefix(policy.PathPrefix, "/") {
+			return fmt.Errorf("无效的 CORS 策略路径前缀: %q，必须以 / 开头", policy.PathPrefix)
+		}
+	}
+
+	for _, proxy := range c.Security.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return fmt.Errorf("无效的可信代理地址: %q，必须是 IP 或 CIDR", proxy)
+			}
+		}
+	}
+
+	if c.Jobs.Workers < 1 {
+		return fmt.Errorf("后台任务 worker 数必须大于 0: %d", c.Jobs.Workers)
+	}
+	if c.Jobs.QueueSize < 1 {
+		return fmt.Errorf("后台任务队列长度必须大于 0: %d", c.Jobs.QueueSize)
+	}
+	if c.Jobs.DataExportTTL < 1 {
+		return fmt.Errorf("个人数据导出保留时间必须至少 1 分钟: %d", c.Jobs.DataExportTTL)
+	}
+	if c.Jobs.UserExportTTL < 1 {
+		return fmt.Errorf("用户导出文件保留时间必须至少 1 分钟: %d", c.Jobs.UserExportTTL)
+	}
+
+	if c.RiskReport.MonthlyTokenQuota < 0 {
+		return fmt.Errorf("月度 token 配额不能为负数: %d", c.RiskReport.MonthlyTokenQuota)
+	}
+	if c.RiskReport.DailyTokenQuota < 0 {
+		return fmt.Errorf("每日 token 配额不能为负数: %d", c.RiskReport.DailyTokenQuota)
+	}
+	for _, o := range c.RiskReport.QuotaOverrides {
+		if o.UserID == "" {
+			return fmt.Errorf("配额覆盖必须指定 user_id")
+		}
+		if o.MonthlyTokenQuota < 0 {
+			return fmt.Errorf("用户 %s 的月度 token 配额不能为负数: %d", o.UserID, o.MonthlyTokenQuota)
+		}
+		if o.DailyTokenQuota < 0 {
+			return fmt.Errorf("用户 %s 的每日 token 配额不能为负数: %d", o.UserID, o.DailyTokenQuota)
+		}
+	}
+
+	if b := c.RiskReport.Buffer; b.Enabled && (b.BatchSize < 1 || b.FlushInterval < 1) {
+		return fmt.Errorf("使用记录缓冲的 batch_size、flush_interval 必须大于 0")
+	}
+	if a := c.RiskReport.DailyAggregate; a.Enabled && a.RefreshInterval < 1 {
+		return fmt.Errorf("使用记录日聚合的 refresh_interval 必须大于 0")
+	}
+	if c.RiskReport.DailyAggregate.LookbackDays < 0 {
+		return fmt.Errorf("使用记录日聚合的 lookback_days 不能为负数: %d", c.RiskReport.DailyAggregate.LookbackDays)
+	}
+
+	if c.Pagination.MaxOffset < 0 {
+		return fmt.Errorf("分页最大偏移量不能为负数: %d", c.Pagination.MaxOffset)
+	}
+
+	switch c.Pagination.DefaultSortOrder {
+	case "", "asc", "desc":
+	default:
+		return fmt.Errorf("默认排序方向必须为 asc 或 desc: %s", c.Pagination.DefaultSortOrder)
+	}
+
+	if c.Cache.UserListTTL < 0 {
+		return fmt.Errorf("用户列表缓存时间不能为负数: %d", c.Cache.UserListTTL)
+	}
+
+	if cb := c.CircuitBreaker; cb.FailureThreshold < 1 || cb.OpenTimeout < 1 {
+		return fmt.Errorf("熔断配置无效: failure_threshold=%d, open_timeout=%d，两者都必须至少为 1", cb.FailureThreshold, cb.OpenTimeout)
+	}
+
+	if m := c.Mail; m.Enabled {
+		if r := m.Retry; r.QueueSize < 1 || r.MaxAttempts < 1 || r.Interval < 1 {
+			return fmt.Errorf("邮件重试配置无效: queue_size=%d, max_attempts=%d, interval=%d，均必须至少为 1", r.QueueSize, r.MaxAttempts, r.Interval)
+		}
+		if m.Host == "" || m.From == "" {
+			return fmt.Errorf("启用邮件发送时必须配置 SMTP 服务器地址与发件人")
+		}
+		if m.Port < 1 || m.Port > 65535 {
+			return fmt.Errorf("无效的 SMTP 端口: %d", m.Port)
+		}
+	}
+
+	if c.Storage.LocalDir == "" || c.Storage.URLPrefix == "" {
+		return fmt.Errorf("文件存储的 local_dir 与 url_prefix 不能为空")
+	}
+
+	if a := c.Avatar; a.MaxSize < 1 || a.MaxDimension < 1 || a.ProcessTimeout < 1 || a.MaxConcurrent < 1 {
+		return fmt.Errorf("头像的 max_size、max_dimension、process_timeout、max_concurrent 必须大于 0")
+	}
+	if len(c.Avatar.ThumbnailSizes) == 0 {
+		return fmt.Errorf("头像缩略图尺寸不能为空")
+	}
+	for _, size := range c.Avatar.ThumbnailSizes {
+		if size < 1 || size > c.Avatar.MaxDimension {
+			return fmt.Errorf("无效的头像缩略图尺寸: %d，必须在 1 到 %d 之间", size, c.Avatar.MaxDimension)
+		}
+	}
+
+	validNamings := map[string]bool{"snake_case": true, "camelCase": true}
+	if !validNamings[c.Response.NamingConvention] {
+		return fmt.Errorf("无效的响应命名风格: %s，必须是 snake_case 或 camelCase", c.Response.NamingConvention)
+	}
+
+	validLanguages := map[string]bool{"zh-CN": true, "en": true}
+	if !validLanguages[c.Response.DefaultLanguage] {
+		return fmt.Errorf("无效的默认语言: %s，必须是 zh-CN 或 en", c.Response.DefaultLanguage)
+	}
+
+	if tz := c.Response.DefaultTimezone; tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil || tz == "Local" {
+			return fmt.Errorf("无效的默认时区: %s，必须是 IANA 时区名，例如 UTC、Asia/Shanghai", tz)
+		}
+	}
+
+	if js := c.Request.JSONSchema; js.Enabled {
+		if js.Dir == "" {
+			return fmt.Errorf("启用 JSON Schema 校验时 schema 目录不能为空")
+		}
+		for i, route := range js.Routes {
+			if route.Method == "" || route.Path == "" || route.Schema == "" {
+				return fmt.Errorf("JSON Schema 路由配置 %d 不完整，method、path、schema 均不能为空", i+1)
+			}
+		}
+	}
+
+	if c.Response.Compression.MinSize < 0 {
+		return fmt.Errorf("响应压缩阈值不能为负数: %d", c.Response.Compression.MinSize)
+	}
+
 	return nil
 }
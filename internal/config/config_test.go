@@ -0,0 +1,175 @@
+// Package config 提供应用程序配置管理功能
+//
+// 本文件包含配置加载的单元测试
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfigFile 在目录下写入配置文件
+func writeConfigFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// baseConfigYAML 基础配置
+const baseConfigYAML = `
+app:
+  name: "base-app"
+  port: 8080
+log:
+  level: "info"
+jwt:
+  secret: "base-secret-123"
+`
+
+func TestLoad_MergesEnvOverride(t *testing.T) {
+	// 准备
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	t.Setenv(EnvVar, "prod")
+	dir := t.TempDir()
+	base := writeConfigFile(t, dir, "config.yaml", baseConfigYAML)
+	writeConfigFile(t, dir, "config.prod.yaml", `
+app:
+  port: 9090
+log:
+  level: "warn"
+`)
+
+	// 执行
+	cfg, err := Load(base)
+
+	// 断言：覆盖文件中的键生效，其余保留基础配置
+	require.NoError(t, err)
+	assert.Equal(t, 9090, cfg.App.Port)
+	assert.Equal(t, "warn", cfg.Log.Level)
+	assert.Equal(t, "base-app", cfg.App.Name)
+	assert.Equal(t, "base-secret-123", cfg.JWT.Secret)
+}
+
+func TestLoad_MissingEnvOverrideIgnored(t *testing.T) {
+	// 准备
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	t.Setenv(EnvVar, "staging")
+	dir := t.TempDir()
+	base := writeConfigFile(t, dir, "config.yaml", baseConfigYAML)
+
+	// 执行
+	cfg, err := Load(base)
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, 8080, cfg.App.Port)
+	assert.Equal(t, "info", cfg.Log.Level)
+}
+
+func TestEnvConfigPath(t *testing.T) {
+	assert.Equal(t, filepath.Join("configs", "config.prod.yaml"), envConfigPath(filepath.Join("configs", "config.yaml"), "prod"))
+	assert.Equal(t, "app.dev.yml", envConfigPath("app.yml", "dev"))
+}
+
+func TestLoad_JWTSecretRequired(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr bool
+	}{
+		{"未设置密钥", "jwt:\n  issuer: x\n", true},
+		{"公开的旧默认密钥", "jwt:\n  secret: \"your-secret-key\"\n", true},
+		{"显式设置密钥", "jwt:\n  secret: \"explicit-secret\"\n", false},
+		{"只配置密钥集合", "jwt:\n  keys:\n    - id: k1\n      secret: \"rotation-secret\"\n", false},
+		{"密钥集合加公开默认密钥", "jwt:\n  secret: \"your-secret-key\"\n  keys:\n    - id: k1\n      secret: \"rotation-secret\"\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 准备
+			viper.Reset()
+			t.Cleanup(viper.Reset)
+			path := writeConfigFile(t, t.TempDir(), "config.yaml", tt.yaml)
+
+			// 执行
+			_, err := Load(path)
+
+			// 断言
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLoad_ExtraClaims(t *testing.T) {
+	tests := []struct {
+		name    string
+		claims  string
+		wantErr bool
+	}{
+		{"固定声明与用户字段", "static:\n      tenant: acme\n    user_fields: [nickname, source]", false},
+		{"未知用户字段", "user_fields: [password]", true},
+		{"固定声明与用户字段重名", "static:\n      nickname: x\n    user_fields: [nickname]", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 准备
+			viper.Reset()
+			t.Cleanup(viper.Reset)
+			path := writeConfigFile(t, t.TempDir(), "config.yaml", "jwt:\n  secret: \"base-secret-123\"\n  extra_claims:\n    "+tt.claims+"\n")
+
+			// 执行
+			cfg, err := Load(path)
+
+			// 断言
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"tenant": "acme"}, cfg.JWT.ExtraClaims.Static)
+			assert.Equal(t, []string{"nickname", "source"}, cfg.JWT.ExtraClaims.UserFields)
+		})
+	}
+}
+
+func TestLoad_PoolMaxOpenConns(t *testing.T) {
+	tests := []struct {
+		name    string
+		pool    string
+		wantErr bool
+	}{
+		{"0 表示不限", "max_open_conns: 0\n    max_idle_conns: 10", false},
+		{"明确上限", "max_open_conns: 20\n    max_idle_conns: 10", false},
+		{"空闲连接数超过上限", "max_open_conns: 5\n    max_idle_conns: 10", true},
+		{"负数", "max_open_conns: -1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 准备
+			viper.Reset()
+			t.Cleanup(viper.Reset)
+			path := writeConfigFile(t, t.TempDir(), "config.yaml", baseConfigYAML+"database:\n  pool:\n    "+tt.pool+"\n")
+
+			// 执行
+			_, err := Load(path)
+
+			// 断言
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
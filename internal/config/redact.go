@@ -0,0 +1,84 @@
+// Package config 提供应用程序配置管理功能
+//
+// 本文件实现了配置的脱敏副本，用于排障时把配置写入日志。
+// 按字段名识别敏感字段（包含 secret、password、apikey，不区分大小写），
+// 新增的密钥类配置只要遵循该命名即可自动脱敏，无需修改本文件。
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// RedactedValue 敏感字段脱敏后的取值
+const RedactedValue = "***"
+
+// sensitiveFieldKeywords 敏感字段名包含的关键字（小写）
+var sensitiveFieldKeywords = []string{"secret", "password", "apikey"}
+
+// Redacted 返回敏感字段替换为 *** 的配置副本，用于日志输出
+// 只遮蔽字符串与字符串切片类型的敏感字段；未配置（空字符串）的字段保持为空，便于排查漏配。
+// 副本与原配置不共享切片、map 与指针，修改副本不影响原配置
+func (c *Config) Redacted() *Config {
+	copied := reflect.New(reflect.TypeOf(*c)).Elem()
+	copyRedacted(copied, reflect.ValueOf(*c), false)
+	return copied.Addr().Interface().(*Config)
+}
+
+// copyRedacted 将 src 深拷贝到 dst，sensitive 表示当前值属于敏感字段
+func copyRedacted(dst, src reflect.Value, sensitive bool) {
+	switch src.Kind() {
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			field := src.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			copyRedacted(dst.Field(i), src.Field(i), sensitive || isSensitiveField(field.Name))
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		for i := 0; i < src.Len(); i++ {
+			copyRedacted(dst.Index(i), src.Index(i), sensitive)
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+		iter := src.MapRange()
+		for iter.Next() {
+			value := reflect.New(src.Type().Elem()).Elem()
+			copyRedacted(value, iter.Value(), sensitive)
+			dst.SetMapIndex(iter.Key(), value)
+		}
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.New(src.Type().Elem()))
+		copyRedacted(dst.Elem(), src.Elem(), sensitive)
+	case reflect.String:
+		if sensitive && src.Len() > 0 {
+			dst.SetString(RedactedValue)
+			return
+		}
+		dst.Set(src)
+	default:
+		dst.Set(src)
+	}
+}
+
+// isSensitiveField 按字段名判断是否为敏感字段，如 Password、Secret、APIKeys
+func isSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, keyword := range sensitiveFieldKeywords {
+		if strings.Contains(name, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+// Package config 提供应用程序配置管理功能
+//
+// 本文件包含配置脱敏的单元测试
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newRedactTestConfig 创建包含各类敏感字段的配置
+func newRedactTestConfig() *Config {
+	return &Config{
+		App: AppConfig{Name: "user-api", Port: 8080},
+		Database: DatabaseConfig{
+			Driver: "mysql",
+			MySQL: MySQLConfig{
+				Host:     "db.internal",
+				Port:     3306,
+				Username: "app",
+				Password: "db-password",
+			},
+		},
+		JWT: JWTConfig{
+			Secret: "jwt-secret-value",
+			Keys: []JWTKey{
+				{ID: "k1", Secret: "key-one-secret"},
+				{ID: "k2", Secret: "key-two-secret"},
+			},
+			CurrentKeyID: "k2",
+		},
+		Security: SecurityConfig{PasswordHistoryCount: 5},
+		RiskReport: RiskReportConfig{
+			APIKeys:      []string{"api-key-1", "api-key-2"},
+			AdminAPIKeys: []string{"admin-key"},
+		},
+		Features: map[string]bool{"user_export": false},
+	}
+}
+
+func TestConfig_Redacted_MasksSensitiveFields(t *testing.T) {
+	// 准备
+	cfg := newRedactTestConfig()
+
+	// 执行
+	redacted := cfg.Redacted()
+
+	// 断言：敏感字段被遮蔽
+	assert.Equal(t, RedactedValue, redacted.Database.MySQL.Password)
+	assert.Equal(t, RedactedValue, redacted.JWT.Secret)
+	assert.Equal(t, RedactedValue, redacted.JWT.Keys[0].Secret)
+	assert.Equal(t, RedactedValue, redacted.JWT.Keys[1].Secret)
+	assert.Equal(t, []string{RedactedValue, RedactedValue}, redacted.RiskReport.APIKeys)
+	assert.Equal(t, []string{RedactedValue}, redacted.RiskReport.AdminAPIKeys)
+
+	// 断言：非敏感字段保留
+	assert.Equal(t, "user-api", redacted.App.Name)
+	assert.Equal(t, 8080, redacted.App.Port)
+	assert.Equal(t, "db.internal", redacted.Database.MySQL.Host)
+	assert.Equal(t, "app", redacted.Database.MySQL.Username)
+	assert.Equal(t, "k1", redacted.JWT.Keys[0].ID)
+	assert.Equal(t, "k2", redacted.JWT.CurrentKeyID)
+	assert.Equal(t, 5, redacted.Security.PasswordHistoryCount)
+	assert.Equal(t, map[string]bool{"user_export": false}, redacted.Features)
+}
+
+func TestConfig_Redacted_DoesNotModifyOriginal(t *testing.T) {
+	// 准备
+	cfg := newRedactTestConfig()
+
+	// 执行
+	redacted := cfg.Redacted()
+	redacted.Features["user_export"] = true
+
+	// 断言
+	assert.Equal(t, "db-password", cfg.Database.MySQL.Password)
+	assert.Equal(t, "jwt-secret-value", cfg.JWT.Secret)
+	assert.Equal(t, "key-one-secret", cfg.JWT.Keys[0].Secret)
+	assert.Equal(t, []string{"api-key-1", "api-key-2"}, cfg.RiskReport.APIKeys)
+	assert.False(t, cfg.Features["user_export"])
+}
+
+func TestConfig_Redacted_KeepsEmptySecrets(t *testing.T) {
+	// 准备：未配置的密钥保持为空，便于排查漏配
+	cfg := &Config{JWT: JWTConfig{Secret: ""}}
+
+	// 执行
+	redacted := cfg.Redacted()
+
+	// 断言
+	assert.Empty(t, redacted.JWT.Secret)
+	assert.Nil(t, redacted.RiskReport.APIKeys)
+}
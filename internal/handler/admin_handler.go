@@ -0,0 +1,337 @@
+// Package handler 提供 HTTP 请求处理器
+package handler
+
+import (
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/internal/middleware"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/service"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/featureflag"
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/example/go-user-api/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// DatabasePool 可在运行时调整的数据库连接池
+type DatabasePool interface {
+	// PoolConfig 返回当前生效的连接池配置
+	PoolConfig() config.PoolConfig
+	// UpdatePool 调整连接池配置
+	UpdatePool(cfg config.PoolConfig) error
+	// Stats 返回连接池统计
+	Stats() (map[string]interface{}, error)
+}
+
+// AdminHandler 管理端处理器
+// 处理 /api/v1/admin 下面向全局的运维操作
+type AdminHandler struct {
+	userService service.UserService
+	jobService  service.JobService
+	flags       *featureflag.Flags
+	log         logger.Logger
+
+	// pool 数据库连接池，为 nil 时连接池接口返回服务不可用
+	pool DatabasePool
+}
+
+// AdminHandlerOption 管理端处理器的可选配置
+type AdminHandlerOption func(*AdminHandler)
+
+// WithDatabasePool 启用数据库连接池的查看与调整接口
+func WithDatabasePool(pool DatabasePool) AdminHandlerOption {
+	return func(h *AdminHandler) {
+		h.pool = pool
+	}
+}
+
+// NewAdminHandler 创建管理端处理器实例
+// 参数：
+//   - userService: 用户服务实例
+//   - jobService: 后台任务服务实例
+//   - flags: 功能开关集合
+//   - log: 日志记录器
+//   - opts: 可选配置
+func NewAdminHandler(userService service.UserService, jobService service.JobService, flags *featureflag.Flags, log logger.Logger, opts ...AdminHandlerOption) *AdminHandler {
+	h := &AdminHandler{
+		userService: userService,
+		jobService:  jobService,
+		flags:       flags,
+		log:         log.With(logger.String("handler", "admin")),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// RevokeAllTokens 强制所有用户下线
+// @Summary 强制所有用户下线
+// @Description 使所有用户已签发的令牌全部失效，用于安全事件应急处理
+// @Tags 管理
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=map[string]interface{}} "操作成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/admin/revoke-all-tokens [post]
+func (h *AdminHandler) RevokeAllTokens(c *gin.Context) {
+	affected, err := h.userService.RevokeAllTokens(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.log.Warn("管理员强制所有用户下线",
+		logger.String("operator_id", middleware.GetUserID(c)),
+		logger.Int64("affected", affected),
+	)
+
+	response.Success(c, map[string]interface{}{
+		"message":        "所有用户令牌已失效",
+		"affected_users": affected,
+	})
+}
+
+// Impersonate 模拟登录
+// @Summary 模拟登录
+// @Description 超级管理员以目标用户身份签发短期访问令牌，用于客服排查问题。令牌带 impersonated_by 声明，不能续签，不能修改密码等敏感信息
+// @Tags 管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "目标用户 ID"
+// @Success 200 {object} response.Response{data=model.ImpersonateResponse} "签发成功"
+// @Failure 400 {object} response.Response "不能模拟自己"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "非超级管理员或目标不可模拟"
+// @Failure 404 {object} response.Response "用户不存在"
+// @Router /api/v1/admin/impersonate/{id} [post]
+func (h *AdminHandler) Impersonate(c *gin.Context) {
+	resp, err := h.userService.Impersonate(c.Request.Context(), c.Param("id"), middleware.GetUserID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, resp)
+}
+
+// SubmitBatchTag 提交批量打标任务
+// @Summary 批量打标
+// @Description 给所有符合过滤条件的用户打上同一个标签，任务在后台异步执行
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.BatchTagRequest true "打标参数"
+// @Success 202 {object} response.Response{data=jobqueue.Job} "任务已提交"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 429 {object} response.Response "任务队列已满"
+// @Router /api/v1/admin/jobs/batch-tag [post]
+func (h *AdminHandler) SubmitBatchTag(c *gin.Context) {
+	var req model.BatchTagRequest
+
+	// 绑定并验证请求参数
+	if !bindJSON(c, &req, h.log) {
+		return
+	}
+
+	job, err := h.jobService.SubmitBatchTag(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.log.Info("管理员提交批量打标任务",
+		logger.String("operator_id", middleware.GetUserID(c)),
+		logger.String("job_id", job.ID),
+		logger.String("tag", req.Tag),
+	)
+
+	response.Accepted(c, job)
+}
+
+// GetJob 查询后台任务进度
+// @Summary 查询任务进度
+// @Description 查询后台任务的状态与处理进度
+// @Tags 管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "任务 ID"
+// @Success 200 {object} response.Response{data=jobqueue.Job} "查询成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Router /api/v1/admin/jobs/{id} [get]
+func (h *AdminHandler) GetJob(c *gin.Context) {
+	job, err := h.jobService.GetJob(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, job)
+}
+
+// ListFeatures 查看功能开关
+// @Summary 查看功能开关
+// @Description 列出所有已注册的接口级功能开关及其当前状态
+// @Tags 管理
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]featureflag.Flag} "查询成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Router /api/v1/admin/features [get]
+func (h *AdminHandler) ListFeatures(c *gin.Context) {
+	response.Success(c, h.flags.List())
+}
+
+// UpdateFeature 切换功能开关
+// @Summary 切换功能开关
+// @Description 开启或关闭指定的功能开关，关闭后对应端点返回 503；修改只在当前实例生效，重启后恢复为配置值
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "开关名称"
+// @Param request body model.UpdateFeatureRequest true "开关状态"
+// @Success 200 {object} response.Response{data=featureflag.Flag} "修改成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "开关不存在"
+// @Router /api/v1/admin/features/{name} [put]
+func (h *AdminHandler) UpdateFeature(c *gin.Context) {
+	var req model.UpdateFeatureRequest
+
+	// 绑定并验证请求参数
+	if !bindJSON(c, &req, h.log) {
+		return
+	}
+
+	name := c.Param("name")
+	if !h.flags.Set(name, *req.Enabled) {
+		response.NotFound(c, "功能开关不存在")
+		return
+	}
+
+	h.log.Warn("管理员切换功能开关",
+		logger.String("operator_id", middleware.GetUserID(c)),
+		logger.String("feature", name),
+		logger.Bool("enabled", *req.Enabled),
+	)
+
+	response.Success(c, featureflag.Flag{Name: name, Enabled: *req.Enabled})
+}
+
+// GetDatabasePool 查看数据库连接池
+// @Summary 查看数据库连接池
+// @Description 返回当前生效的连接池配置与连接统计
+// @Tags 管理
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.DatabasePoolResponse} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 503 {object} response.Response "未启用"
+// @Router /api/v1/admin/database/pool [get]
+func (h *AdminHandler) GetDatabasePool(c *gin.Context) {
+	if h.pool == nil {
+		h.handleError(c, errors.ErrServiceUnavailable.WithMessage("连接池管理未启用"))
+		return
+	}
+	h.respondDatabasePool(c)
+}
+
+// UpdateDatabasePool 调整数据库连接池
+// @Summary 调整数据库连接池
+// @Description 运行时调整连接池大小与连接生存时间，无需重启；只在当前实例生效，重启后恢复为配置值
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.UpdateDatabasePoolRequest true "新的连接池配置，未填写的字段保持不变"
+// @Success 200 {object} response.Response{data=model.DatabasePoolResponse} "调整成功"
+// @Failure 400 {object} response.Response "配置值不合理"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 503 {object} response.Response "未启用"
+// @Router /api/v1/admin/database/pool [put]
+func (h *AdminHandler) UpdateDatabasePool(c *gin.Context) {
+	if h.pool == nil {
+		h.handleError(c, errors.ErrServiceUnavailable.WithMessage("连接池管理未启用"))
+		return
+	}
+
+	var req model.UpdateDatabasePoolRequest
+	if !bindJSON(c, &req, h.log) {
+		return
+	}
+
+	previous := h.pool.PoolConfig()
+	cfg := previous
+	if req.MaxOpenConns != nil {
+		cfg.MaxOpenConns = *req.MaxOpenConns
+	}
+	if req.MaxIdleConns != nil {
+		cfg.MaxIdleConns = *req.MaxIdleConns
+	}
+	if req.ConnMaxLifetime != nil {
+		cfg.ConnMaxLifetime = *req.ConnMaxLifetime
+	}
+	if req.ConnMaxIdleTime != nil {
+		cfg.ConnMaxIdleTime = *req.ConnMaxIdleTime
+	}
+	if err := cfg.ValidateLimits(); err != nil {
+		h.handleError(c, errors.ErrValidation.WithDetail(err.Error()))
+		return
+	}
+	if err := h.pool.UpdatePool(cfg); err != nil {
+		h.handleError(c, errors.ErrInternalServer.WithError(err))
+		return
+	}
+
+	h.log.Warn("管理员调整数据库连接池",
+		logger.String("operator_id", middleware.GetUserID(c)),
+		logger.Any("previous", previous),
+		logger.Any("current", cfg),
+	)
+	h.respondDatabasePool(c)
+}
+
+// respondDatabasePool 返回连接池当前配置与统计
+func (h *AdminHandler) respondDatabasePool(c *gin.Context) {
+	stats, err := h.pool.Stats()
+	if err != nil {
+		h.handleError(c, errors.ErrInternalServer.WithError(err))
+		return
+	}
+	cfg := h.pool.PoolConfig()
+	response.Success(c, model.DatabasePoolResponse{
+		MaxOpenConns:    cfg.MaxOpenConns,
+		MaxIdleConns:    cfg.MaxIdleConns,
+		ConnMaxLifetime: cfg.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.ConnMaxIdleTime,
+		Stats:           stats,
+	})
+}
+
+// handleError 处理错误响应
+func (h *AdminHandler) handleError(c *gin.Context, err error) {
+	if abortIfCanceled(c, err, h.log) {
+		return
+	}
+
+	if appErr := errors.AsAppError(err); appErr != nil {
+		logAppError(c, h.log, appErr)
+		response.Error(c, appErr.HTTPStatus, appErr.Code, appErr.Message)
+		return
+	}
+
+	h.log.Error("处理请求时发生未知错误", logger.AppErr(err))
+	response.InternalError(c, "")
+}
@@ -0,0 +1,233 @@
+// Package handler 提供 HTTP 请求处理器
+package handler
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/example/go-user-api/internal/middleware"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/example/go-user-api/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// registerTagNameOnce 确保只注册一次字段名解析函数
+var registerTagNameOnce sync.Once
+
+// registerTagName 让验证错误使用 json/form 标签中的字段名，与客户端提交的参数名一致
+func registerTagName() {
+	registerTagNameOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			for _, tag := range []string{"json", "form", "uri"} {
+				name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+				if name == "-" {
+					return ""
+				}
+				if name != "" {
+					return name
+				}
+			}
+			return field.Name
+		})
+	})
+}
+
+// bindJSON 绑定并验证 JSON 请求体，失败时写入统一的验证错误响应并返回 false
+// 请求经过 StrictJSON 中间件时拒绝未知字段，否则忽略未知字段
+func bindJSON(c *gin.Context, req interface{}, log logger.Logger) bool {
+	if middleware.DisallowUnknownFields(c) {
+		return bindWith(c, req, strictJSONBinding{}, log)
+	}
+	return bindWith(c, req, binding.JSON, log)
+}
+
+// strictJSONBinding 拒绝未知字段的 JSON 绑定
+// gin 的 binding.EnableDecoderDisallowUnknownFields 是进程级开关，这里按请求选择
+type strictJSONBinding struct{}
+
+// Name 返回绑定名称
+func (strictJSONBinding) Name() string {
+	return "json"
+}
+
+// Bind 解码请求体并验证，遇到第一个未知字段即返回错误
+func (strictJSONBinding) Bind(req *http.Request, obj interface{}) error {
+	if req == nil || req.Body == nil {
+		return stderrors.New("invalid request")
+	}
+	decoder := json.NewDecoder(req.Body)
+	if binding.EnableDecoderUseNumber {
+		decoder.UseNumber()
+	}
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(obj); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// bindQuery 绑定并验证查询参数，失败时写入统一的验证错误响应并返回 false
+func bindQuery(c *gin.Context, req interface{}, log logger.Logger) bool {
+	return bindWith(c, req, binding.Query, log)
+}
+
+// bindWith 使用指定的绑定方式绑定请求参数
+// 验证失败返回 400，data 为 model.ValidationErrors，逐字段说明失败原因，
+// kind 区分缺少字段、类型错误、未知字段与规则不满足；
+// 请求体格式错误（如 JSON 语法错误）同样返回 400，但不带字段信息
+func bindWith(c *gin.Context, req interface{}, b binding.Binding, log logger.Logger) bool {
+	registerTagName()
+
+	err := c.ShouldBindWith(req, b)
+	if err == nil {
+		return true
+	}
+
+	log.Debug("请求参数验证失败",
+		logger.String("path", c.FullPath()),
+		logger.Err(err),
+	)
+
+	if fieldErrors := toFieldErrors(err); len(fieldErrors) > 0 {
+		response.ValidationError(c, "请求参数验证失败", model.ValidationErrors{Errors: fieldErrors})
+		return false
+	}
+	response.BadRequest(c, "请求参数格式错误: "+err.Error())
+	return false
+}
+
+// toFieldErrors 将绑定错误转换为字段级错误，无法定位到字段时返回 nil
+func toFieldErrors(err error) []model.FieldError {
+	var validationErrs validator.ValidationErrors
+	if stderrors.As(err, &validationErrs) {
+		fieldErrors := make([]model.FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fieldErrors = append(fieldErrors, model.FieldError{
+				Field:   fieldPath(fe),
+				Kind:    fieldErrorKind(fe),
+				Tag:     fe.Tag(),
+				Message: fieldErrorMessage(fe),
+			})
+		}
+		return fieldErrors
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if stderrors.As(err, &typeErr) && typeErr.Field != "" {
+		return []model.FieldError{{
+			Field:   typeErr.Field,
+			Kind:    model.FieldErrorKindType,
+			Tag:     "type",
+			Message: fmt.Sprintf("类型错误，应为 %s", typeErr.Type.String()),
+		}}
+	}
+
+	if name, ok := unknownField(err); ok {
+		return []model.FieldError{{
+			Field:   name,
+			Kind:    model.FieldErrorKindUnknown,
+			Tag:     "unknown",
+			Message: "不支持的字段",
+		}}
+	}
+	return nil
+}
+
+// unknownFieldPrefix encoding/json 在 DisallowUnknownFields 模式下的错误前缀
+const unknownFieldPrefix = "json: unknown field "
+
+// unknownField 从解码错误中取出未知字段名
+// encoding/json 没有导出该错误类型，只能按错误消息识别
+func unknownField(err error) (string, bool) {
+	msg := err.Error()
+	if !strings.HasPrefix(msg, unknownFieldPrefix) {
+		return "", false
+	}
+	name, unquoteErr := strconv.Unquote(strings.TrimPrefix(msg, unknownFieldPrefix))
+	if unquoteErr != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// fieldErrorKind 返回验证错误的类别，required 系列规则视为缺少字段
+func fieldErrorKind(fe validator.FieldError) string {
+	if strings.HasPrefix(fe.Tag(), "required") {
+		return model.FieldErrorKindMissing
+	}
+	return model.FieldErrorKindInvalid
+}
+
+// fieldPath 返回去掉顶层结构体名的字段路径，如 ids[0]
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.IndexByte(ns, '.'); i >= 0 {
+		return ns[i+1:]
+	}
+	return fe.Field()
+}
+
+// fieldErrorMessage 生成验证失败的中文说明
+func fieldErrorMessage(fe validator.FieldError) string {
+	param := fe.Param()
+	switch fe.Tag() {
+	case "required":
+		return "不能为空"
+	case "email":
+		return "邮箱格式不正确"
+	case "alphanum":
+		return "只能包含字母和数字"
+	case "oneof":
+		return "必须是以下值之一: " + param
+	case "eqfield":
+		return "必须与 " + param + " 一致"
+	case "nefield":
+		return "不能与 " + param + " 相同"
+	case "url":
+		return "URL 格式不正确"
+	case "len":
+		return "长度必须为 " + param
+	case "min", "gte":
+		if isSizedKind(fe.Kind()) {
+			return "长度不能少于 " + param
+		}
+		return "不能小于 " + param
+	case "max", "lte":
+		if isSizedKind(fe.Kind()) {
+			return "长度不能超过 " + param
+		}
+		return "不能大于 " + param
+	case "gt":
+		return "必须大于 " + param
+	case "lt":
+		return "必须小于 " + param
+	default:
+		if param != "" {
+			return fmt.Sprintf("不满足 %s=%s 规则", fe.Tag(), param)
+		}
+		return fmt.Sprintf("不满足 %s 规则", fe.Tag())
+	}
+}
+
+// isSizedKind 是否按长度比较的类型（字符串、切片、映射）
+func isSizedKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,176 @@
+// Package handler 提供 HTTP 请求处理器
+//
+// 本文件包含请求参数绑定助手的单元测试
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/example/go-user-api/internal/middleware"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/example/go-user-api/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bindTestResponse 验证错误响应结构
+type bindTestResponse struct {
+	Code    int                    `json:"code"`
+	Message string                 `json:"message"`
+	Data    model.ValidationErrors `json:"data"`
+}
+
+// newBindTestEngine 注册使用绑定助手的注册、登录与查询端点，业务逻辑不会被调用
+// middlewares 作为全局中间件注册，用于测试严格模式等绑定选项
+func newBindTestEngine(t *testing.T, middlewares ...gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	log, err := logger.New(&logger.Config{Level: "error", Format: "console"})
+	require.NoError(t, err)
+
+	engine := gin.New()
+	engine.Use(middlewares...)
+	h := NewUserHandler(nil, nil, log)
+	engine.POST("/register", h.Register)
+	engine.POST("/login", func(c *gin.Context) {
+		var req model.LoginRequest
+		if !bindJSON(c, &req, log) {
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+	engine.GET("/export", func(c *gin.Context) {
+		var req model.UserExportRequest
+		if !bindQuery(c, &req, log) {
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+	return engine
+}
+
+// doBindRequest 发送请求并解析响应
+func doBindRequest(t *testing.T, engine *gin.Engine, method, target, body string) (*httptest.ResponseRecorder, bindTestResponse) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	var resp bindTestResponse
+	if w.Body.Len() > 0 {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	}
+	return w, resp
+}
+
+// fieldErrorsByName 按字段名索引验证错误
+func fieldErrorsByName(errs []model.FieldError) map[string]model.FieldError {
+	m := make(map[string]model.FieldError, len(errs))
+	for _, e := range errs {
+		m[e.Field] = e
+	}
+	return m
+}
+
+func TestBindJSON_ValidationErrors(t *testing.T) {
+	engine := newBindTestEngine(t)
+
+	// 用户名格式由服务层策略校验，绑定层只限制最大长度
+	w, resp := doBindRequest(t, engine, http.MethodPost, "/register",
+		`{"username":"`+strings.Repeat("a", 51)+`","email":"not-an-email","password":"secret1","confirm_password":"other"}`)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, response.CodeValidationError, resp.Code)
+	assert.Equal(t, "请求参数验证失败", resp.Message)
+
+	// 字段名使用 json 标签，每个字段给出规则与说明
+	errs := fieldErrorsByName(resp.Data.Errors)
+	require.Len(t, errs, 3)
+	assert.Equal(t, model.FieldError{Field: "username", Kind: model.FieldErrorKindInvalid, Tag: "max", Message: "长度不能超过 50"}, errs["username"])
+	assert.Equal(t, model.FieldError{Field: "email", Kind: model.FieldErrorKindInvalid, Tag: "email", Message: "邮箱格式不正确"}, errs["email"])
+	assert.Equal(t, "eqfield", errs["confirm_password"].Tag)
+}
+
+func TestBindJSON_TypeError(t *testing.T) {
+	engine := newBindTestEngine(t)
+
+	w, resp := doBindRequest(t, engine, http.MethodPost, "/register", `{"username":123}`)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, response.CodeValidationError, resp.Code)
+	require.Len(t, resp.Data.Errors, 1)
+	assert.Equal(t, "username", resp.Data.Errors[0].Field)
+	assert.Equal(t, model.FieldErrorKindType, resp.Data.Errors[0].Kind)
+	assert.Equal(t, "type", resp.Data.Errors[0].Tag)
+}
+
+func TestBindJSON_MissingField(t *testing.T) {
+	engine := newBindTestEngine(t)
+
+	w, resp := doBindRequest(t, engine, http.MethodPost, "/login", `{"username":"alice"}`)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Len(t, resp.Data.Errors, 1)
+	assert.Equal(t, model.FieldError{Field: "password", Kind: model.FieldErrorKindMissing, Tag: "required", Message: "不能为空"}, resp.Data.Errors[0])
+}
+
+func TestBindJSON_UnknownFieldIgnoredByDefault(t *testing.T) {
+	engine := newBindTestEngine(t)
+
+	w, _ := doBindRequest(t, engine, http.MethodPost, "/login", `{"username":"alice","password":"secret1","remember_me":true}`)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestBindJSON_UnknownFieldRejectedInStrictMode(t *testing.T) {
+	engine := newBindTestEngine(t, middleware.StrictJSON())
+
+	w, resp := doBindRequest(t, engine, http.MethodPost, "/login", `{"username":"alice","password":"secret1","remember_me":true}`)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, response.CodeValidationError, resp.Code)
+	require.Len(t, resp.Data.Errors, 1)
+	assert.Equal(t, model.FieldError{Field: "remember_me", Kind: model.FieldErrorKindUnknown, Tag: "unknown", Message: "不支持的字段"}, resp.Data.Errors[0])
+
+	// 严格模式下已知字段的验证不受影响
+	w, resp = doBindRequest(t, engine, http.MethodPost, "/login", `{"username":"alice"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, model.FieldErrorKindMissing, resp.Data.Errors[0].Kind)
+
+	w, _ = doBindRequest(t, engine, http.MethodPost, "/login", `{"username":"alice","password":"secret1"}`)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestBindJSON_MalformedBody(t *testing.T) {
+	engine := newBindTestEngine(t)
+
+	w, resp := doBindRequest(t, engine, http.MethodPost, "/register", `{"username":`)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, response.CodeBadRequest, resp.Code)
+	assert.Contains(t, resp.Message, "请求参数格式错误")
+	assert.Empty(t, resp.Data.Errors)
+}
+
+func TestBindQuery_ValidationErrors(t *testing.T) {
+	engine := newBindTestEngine(t)
+
+	w, resp := doBindRequest(t, engine, http.MethodGet, "/export?format=pdf&role=root", "")
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	errs := fieldErrorsByName(resp.Data.Errors)
+	assert.Equal(t, "必须是以下值之一: csv xlsx", errs["format"].Message)
+	assert.Equal(t, "oneof", errs["role"].Tag)
+
+	// 合法参数正常通过
+	w, _ = doBindRequest(t, engine, http.MethodGet, "/export?format=csv", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
@@ -0,0 +1,74 @@
+// Package handler 提供 HTTP 请求处理器
+package handler
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/service"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/example/go-user-api/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// StatusClientClosedRequest 客户端在服务端响应前断开连接（沿用 Nginx 的 499 约定）
+// 仅用于访问日志区分，客户端不会收到该响应
+const StatusClientClosedRequest = 499
+
+// abortIfCanceled 检查错误是否由请求 context 取消引起
+// 客户端断开时不再写响应体，只以 499 终止请求；处理 deadline 到期（见 middleware.Timeout）时返回 504。
+// 两种情况都返回 true
+func abortIfCanceled(c *gin.Context, err error, log logger.Logger) bool {
+	switch {
+	case stderrors.Is(err, context.Canceled):
+		log.Debug("客户端已断开连接，放弃处理请求",
+			logger.String("path", c.Request.URL.Path),
+		)
+		c.AbortWithStatus(StatusClientClosedRequest)
+		return true
+	case stderrors.Is(err, context.DeadlineExceeded):
+		log.Warn("请求处理超过截止时间，已取消",
+			logger.String("path", c.Request.URL.Path),
+			logger.Err(err),
+		)
+		e := errors.ErrRequestTimeout
+		response.Abort(c, e.HTTPStatus, e.Code, e.Message)
+		return true
+	default:
+		return false
+	}
+}
+
+// respondBusinessValidation 检查错误是否为业务校验失败
+// 是则与绑定校验相同，返回 400 与逐字段的 model.ValidationErrors，并返回 true
+func respondBusinessValidation(c *gin.Context, err error, log logger.Logger) bool {
+	var validationErr *service.ValidationError
+	if !stderrors.As(err, &validationErr) {
+		return false
+	}
+
+	log.Debug("业务校验失败",
+		logger.String("path", c.FullPath()),
+		logger.Err(err),
+	)
+	response.ValidationError(c, "请求参数验证失败", model.ValidationErrors{Errors: validationErr.Fields})
+	return true
+}
+
+// logAppError 记录返回给客户端的应用错误，日志带错误码便于按错误码聚合
+// 5xx 记为 Error，其余（客户端错误）记为 Info
+func logAppError(c *gin.Context, log logger.Logger, appErr *errors.AppError) {
+	fields := []logger.Field{
+		logger.String("path", c.FullPath()),
+		logger.Int("status", appErr.HTTPStatus),
+		logger.AppErr(appErr),
+	}
+	if appErr.HTTPStatus >= http.StatusInternalServerError {
+		log.Error("请求处理失败", fields...)
+		return
+	}
+	log.Info("请求处理失败", fields...)
+}
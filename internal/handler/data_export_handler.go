@@ -0,0 +1,79 @@
+// Package handler 提供 HTTP 请求处理器
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/example/go-user-api/internal/middleware"
+	"github.com/example/go-user-api/internal/service"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/example/go-user-api/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// dataExportRetryAfter 导出未生成完成时建议客户端再次请求的间隔（秒）
+const dataExportRetryAfter = "5"
+
+// DataExportHandler 个人数据导出处理器
+// 处理 /api/v1/users/me/data-export
+type DataExportHandler struct {
+	exportService service.DataExportService
+	log           logger.Logger
+}
+
+// NewDataExportHandler 创建个人数据导出处理器实例
+func NewDataExportHandler(exportService service.DataExportService, log logger.Logger) *DataExportHandler {
+	return &DataExportHandler{
+		exportService: exportService,
+		log:           log.With(logger.String("handler", "data_export")),
+	}
+}
+
+// Export 导出当前用户的个人数据
+// @Summary 导出个人数据
+// @Description 导出当前用户在各子系统中的全部个人数据（资料、登录历史、会话、个人访问令牌、安全事件、变更记录、风险报告使用记录）。
+// @Description 首次请求提交后台生成任务并返回 202 与任务状态，客户端按 Retry-After 再次请求同一地址，生成完成后返回 JSON 文件；
+// @Description 生成失败时状态为 failed，再次请求会重新生成
+// @Tags 用户
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.UserDataExport "导出文件"
+// @Success 202 {object} response.Response{data=model.DataExportStatus} "生成中"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 429 {object} response.Response "任务队列已满"
+// @Router /api/v1/users/me/data-export [get]
+func (h *DataExportHandler) Export(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	status, data, err := h.exportService.Request(c.Request.Context(), userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if data != nil {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "user-data-"+userID+".json"))
+		c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+		return
+	}
+
+	c.Header(middleware.RetryAfterHeader, dataExportRetryAfter)
+	response.Accepted(c, status)
+}
+
+// handleError 处理错误
+func (h *DataExportHandler) handleError(c *gin.Context, err error) {
+	if abortIfCanceled(c, err, h.log) {
+		return
+	}
+
+	if appErr := errors.AsAppError(err); appErr != nil {
+		logAppError(c, h.log, appErr)
+		response.Error(c, appErr.HTTPStatus, appErr.Code, appErr.Message)
+		return
+	}
+
+	h.log.Error("处理请求时发生未知错误", logger.AppErr(err))
+	response.InternalError(c, "")
+}
@@ -0,0 +1,74 @@
+// Package handler 提供 HTTP 请求处理器
+package handler
+
+import (
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/service"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/example/go-user-api/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// DebugHandler 调试处理器
+// 提供帮助前端联调的辅助接口，仅在非 release 模式下注册
+type DebugHandler struct {
+	jwtService service.JWTService
+	log        logger.Logger
+}
+
+// NewDebugHandler 创建调试处理器实例
+// 参数：
+//   - jwtService: JWT 服务实例
+//   - log: 日志记录器
+func NewDebugHandler(jwtService service.JWTService, log logger.Logger) *DebugHandler {
+	return &DebugHandler{
+		jwtService: jwtService,
+		log:        log.With(logger.String("handler", "debug")),
+	}
+}
+
+// ParseToken 解析令牌
+// @Summary 解析令牌（调试）
+// @Description 返回令牌中的声明，不校验签名与有效期，仅在非 release 模式下可用
+// @Tags 调试
+// @Accept json
+// @Produce json
+// @Param request body model.DebugTokenRequest true "待解析的令牌"
+// @Success 200 {object} response.Response{data=service.TokenClaims} "解析成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "令牌格式错误"
+// @Router /api/v1/debug/token [post]
+func (h *DebugHandler) ParseToken(c *gin.Context) {
+	var req model.DebugTokenRequest
+
+	// 绑定并验证请求参数
+	if !bindJSON(c, &req, h.log) {
+		return
+	}
+
+	// 解析令牌（不校验签名）
+	claims, err := h.jwtService.ParseTokenUnvalidated(req.Token)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, claims)
+}
+
+// handleError 处理错误响应
+func (h *DebugHandler) handleError(c *gin.Context, err error) {
+	if abortIfCanceled(c, err, h.log) {
+		return
+	}
+
+	if appErr := errors.AsAppError(err); appErr != nil {
+		logAppError(c, h.log, appErr)
+		response.Error(c, appErr.HTTPStatus, appErr.Code, appErr.Message)
+		return
+	}
+
+	h.log.Error("处理请求时发生未知错误", logger.AppErr(err))
+	response.InternalError(c, "")
+}
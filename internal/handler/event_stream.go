@@ -0,0 +1,90 @@
+// Package handler 提供 HTTP 请求处理器
+//
+// 本文件实现了 Server-Sent Events（SSE）流式响应，用于批量操作推送实时进度。
+// 客户端在请求头中声明 Accept: text/event-stream 即可改为流式接收：
+//
+//	event:progress
+//	data:{"processed":1,"total":3,"succeeded":1,"failed":0}
+//
+//	event:done
+//	data:{"total":3,"created":2,"failed":1,"errors":[...]}
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/service"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// ContentTypeEventStream SSE 响应的内容类型
+const ContentTypeEventStream = "text/event-stream"
+
+// SSE 事件名
+const (
+	// EventProgress 进度事件，数据为 model.BatchProgress
+	EventProgress = "progress"
+	// EventDone 完成事件，数据为批量操作的最终结果，之后连接关闭
+	EventDone = "done"
+	// EventError 错误事件，推送开始后操作失败时发送，数据为 {code, message}
+	EventError = "error"
+)
+
+// wantsEventStream 判断客户端是否要求以 SSE 流式接收
+func wantsEventStream(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), ContentTypeEventStream)
+}
+
+// eventStream SSE 响应写入器
+// 首个事件发送前才写出响应头，在此之前出错仍可按普通 JSON 错误响应返回
+type eventStream struct {
+	c       *gin.Context
+	started bool
+}
+
+// newEventStream 创建 SSE 响应写入器
+func newEventStream(c *gin.Context) *eventStream {
+	return &eventStream{c: c}
+}
+
+// newProgressStream 客户端接受 SSE 时创建写入器与推送 progress 事件的进度回调，否则都返回 nil
+func newProgressStream(c *gin.Context) (*eventStream, service.BatchProgressFunc) {
+	if !wantsEventStream(c) {
+		return nil, nil
+	}
+	stream := newEventStream(c)
+	return stream, func(p model.BatchProgress) {
+		stream.Send(EventProgress, p)
+	}
+}
+
+// Started 是否已开始推送
+func (s *eventStream) Started() bool {
+	return s.started
+}
+
+// Send 发送一个事件并立即刷新到客户端
+// 事件数据与普通响应一样按当前请求转换时区与键名
+func (s *eventStream) Send(event string, data interface{}) {
+	if !s.started {
+		s.started = true
+		s.c.Header("Content-Type", ContentTypeEventStream)
+		s.c.Header("Cache-Control", "no-cache")
+		// 禁止 Nginx 等反向代理缓冲，保证进度实时送达
+		s.c.Header("X-Accel-Buffering", "no")
+		s.c.Status(http.StatusOK)
+	}
+	s.c.SSEvent(event, response.Transform(s.c, data))
+	s.c.Writer.Flush()
+}
+
+// Fail 以 error 事件告知客户端操作失败
+// 推送开始后状态码已经发出，只能通过事件传递错误
+func (s *eventStream) Fail(err error) {
+	appErr := errors.FromError(err)
+	s.Send(EventError, gin.H{"code": appErr.Code, "message": appErr.Message})
+}
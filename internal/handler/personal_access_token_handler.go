@@ -0,0 +1,107 @@
+// Package handler 提供 HTTP 请求处理器
+package handler
+
+import (
+	"github.com/example/go-user-api/internal/middleware"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/service"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/example/go-user-api/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// PersonalAccessTokenHandler 个人访问令牌处理器
+// 处理 /api/v1/users/me/tokens 下当前用户的令牌管理
+type PersonalAccessTokenHandler struct {
+	tokenService service.PersonalAccessTokenService
+	log          logger.Logger
+}
+
+// NewPersonalAccessTokenHandler 创建个人访问令牌处理器实例
+func NewPersonalAccessTokenHandler(tokenService service.PersonalAccessTokenService, log logger.Logger) *PersonalAccessTokenHandler {
+	return &PersonalAccessTokenHandler{
+		tokenService: tokenService,
+		log:          log.With(logger.String("handler", "personal_access_token")),
+	}
+}
+
+// Create 创建个人访问令牌
+// @Summary 创建个人访问令牌
+// @Description 创建长期有效的个人访问令牌（PAT），用于脚本调用。令牌明文只在本次响应中返回；授权范围为权限标识，不能超出自己的权限
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.CreatePersonalAccessTokenRequest true "令牌信息"
+// @Success 201 {object} response.Response{data=model.CreatePersonalAccessTokenResponse} "创建成功"
+// @Failure 400 {object} response.Response "请求参数错误或令牌数量已达上限"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "不能使用个人访问令牌或模拟令牌创建"
+// @Router /api/v1/users/me/tokens [post]
+func (h *PersonalAccessTokenHandler) Create(c *gin.Context) {
+	var req model.CreatePersonalAccessTokenRequest
+	if !bindJSON(c, &req, h.log) {
+		return
+	}
+
+	token, err := h.tokenService.Create(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	response.Created(c, token)
+}
+
+// List 列出个人访问令牌
+// @Summary 列出个人访问令牌
+// @Description 列出当前用户未撤销的个人访问令牌，不含令牌明文
+// @Tags 用户
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]model.PersonalAccessTokenResponse} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /api/v1/users/me/tokens [get]
+func (h *PersonalAccessTokenHandler) List(c *gin.Context) {
+	tokens, err := h.tokenService.List(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	response.Success(c, tokens)
+}
+
+// Revoke 撤销个人访问令牌
+// @Summary 撤销个人访问令牌
+// @Description 撤销当前用户的个人访问令牌，立即生效
+// @Tags 用户
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "令牌 ID"
+// @Success 200 {object} response.Response{data=model.MessageResponse} "撤销成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "令牌不存在"
+// @Router /api/v1/users/me/tokens/{id} [delete]
+func (h *PersonalAccessTokenHandler) Revoke(c *gin.Context) {
+	if err := h.tokenService.Revoke(c.Request.Context(), middleware.GetUserID(c), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	response.Success(c, model.MessageResponse{Message: "令牌已撤销"})
+}
+
+// handleError 处理错误并返回适当的 HTTP 响应
+func (h *PersonalAccessTokenHandler) handleError(c *gin.Context, err error) {
+	if abortIfCanceled(c, err, h.log) {
+		return
+	}
+
+	if appErr := errors.AsAppError(err); appErr != nil {
+		logAppError(c, h.log, appErr)
+		response.Error(c, appErr.HTTPStatus, appErr.Code, appErr.Message)
+		return
+	}
+
+	h.log.Error("处理请求时发生未知错误", logger.AppErr(err))
+	response.InternalError(c, "")
+}
@@ -2,7 +2,7 @@
 package handler
 
 import (
-	"net/http"
+	"fmt"
 	"time"
 
 	"github.com/example/go-user-api/internal/model"
@@ -43,9 +43,7 @@ func (h *RiskReportUsageHandler) Create(c *gin.Context) {
 	var req model.CreateRiskReportUsageRequest
 
 	// 绑定并验证请求参数
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Debug("使用记录参数验证失败", logger.Err(err))
-		h.handleValidationError(c, err)
+	if !bindJSON(c, &req, h.log) {
 		return
 	}
 
@@ -80,9 +78,7 @@ func (h *RiskReportUsageHandler) BatchCreate(c *gin.Context) {
 	var req model.BatchCreateRiskReportUsageRequest
 
 	// 绑定并验证请求参数
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Debug("批量使用记录参数验证失败", logger.Err(err))
-		h.handleValidationError(c, err)
+	if !bindJSON(c, &req, h.log) {
 		return
 	}
 
@@ -105,6 +101,35 @@ func (h *RiskReportUsageHandler) BatchCreate(c *gin.Context) {
 	response.Success(c, resp)
 }
 
+// Backfill 回填历史使用记录
+// @Summary 回填历史使用记录
+// @Description 从旧系统迁移历史调用数据，跳过未来时间与时间先后顺序校验，不占用配额；需要管理 API Key
+// @Tags 风险报告
+// @Accept json
+// @Produce json
+// @Param request body model.BackfillRiskReportUsageRequest true "历史使用记录，单次最多 1000 条"
+// @Success 200 {object} response.Response{data=model.BatchCreateRiskReportUsageResponse} "回填完成"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "需要管理 API Key"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/risk-report/usage/backfill [post]
+func (h *RiskReportUsageHandler) Backfill(c *gin.Context) {
+	var req model.BackfillRiskReportUsageRequest
+
+	// 绑定并验证请求参数
+	if !bindJSON(c, &req, h.log) {
+		return
+	}
+
+	result, err := h.service.Backfill(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
 // GetByID 获取使用记录详情
 // @Summary 获取使用记录详情
 // @Description 根据 ID 获取使用记录详情
@@ -129,6 +154,61 @@ func (h *RiskReportUsageHandler) GetByID(c *gin.Context) {
 	response.Success(c, usage.ToResponse())
 }
 
+// Update 更新使用记录
+// @Summary 更新使用记录
+// @Description 修正使用记录中的错误数据，只更新请求中出现的字段；更新后重新校验字段一致性（如 total_tokens）。需要管理 API Key
+// @Tags 风险报告
+// @Accept json
+// @Produce json
+// @Param id path string true "记录 ID"
+// @Param request body model.UpdateRiskReportUsageRequest true "要更新的字段"
+// @Success 200 {object} response.Response{data=model.RiskReportUsageResponse} "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无管理权限"
+// @Failure 404 {object} response.Response "记录不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/risk-report/usage/{id} [put]
+func (h *RiskReportUsageHandler) Update(c *gin.Context) {
+	var req model.UpdateRiskReportUsageRequest
+
+	// 绑定并验证请求参数
+	if !bindJSON(c, &req, h.log) {
+		return
+	}
+
+	// 调用服务层更新记录
+	usage, err := h.service.Update(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// 返回成功响应
+	response.Success(c, usage.ToResponse())
+}
+
+// Delete 删除使用记录
+// @Summary 删除使用记录
+// @Description 永久删除指定的使用记录。需要管理 API Key
+// @Tags 风险报告
+// @Produce json
+// @Param id path string true "记录 ID"
+// @Success 204 "删除成功"
+// @Failure 403 {object} response.Response "无管理权限"
+// @Failure 404 {object} response.Response "记录不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/risk-report/usage/{id} [delete]
+func (h *RiskReportUsageHandler) Delete(c *gin.Context) {
+	// 调用服务层删除记录
+	if err := h.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// 返回成功响应
+	response.NoContent(c)
+}
+
 // List 获取使用记录列表
 // @Summary 获取使用记录列表
 // @Description 根据条件查询使用记录列表
@@ -140,6 +220,7 @@ func (h *RiskReportUsageHandler) GetByID(c *gin.Context) {
 // @Param end_time query string false "结束时间（RFC3339 格式）"
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
+// @Param include_response query bool false "是否返回 ai_response 全文，默认省略" default(false)
 // @Success 200 {object} response.Response{data=response.PageData} "查询成功"
 // @Failure 400 {object} response.Response "请求参数错误"
 // @Failure 500 {object} response.Response "服务器内部错误"
@@ -148,9 +229,7 @@ func (h *RiskReportUsageHandler) List(c *gin.Context) {
 	var req model.RiskReportUsageListRequest
 
 	// 绑定并验证请求参数
-	if err := c.ShouldBindQuery(&req); err != nil {
-		h.log.Debug("使用记录列表参数验证失败", logger.Err(err))
-		h.handleValidationError(c, err)
+	if !bindQuery(c, &req, h.log) {
 		return
 	}
 
@@ -161,10 +240,14 @@ func (h *RiskReportUsageHandler) List(c *gin.Context) {
 		return
 	}
 
-	// 转换为响应格式
+	// 转换为响应格式，ai_response 可能包含敏感文本，未显式请求时省略
 	usageResponses := make([]interface{}, len(usages))
 	for i, usage := range usages {
-		usageResponses[i] = usage.ToResponse()
+		resp := usage.ToResponse()
+		if !req.IncludeResponse {
+			resp.OmitAIResponse()
+		}
+		usageResponses[i] = resp
 	}
 
 	// 返回分页响应
@@ -173,13 +256,13 @@ func (h *RiskReportUsageHandler) List(c *gin.Context) {
 
 // GetUserStats 获取用户统计信息
 // @Summary 获取用户统计信息
-// @Description 获取指定用户的使用统计信息
+// @Description 获取指定用户的使用统计信息，包含平均耗时与 P50/P95/P99 延迟百分位；已完成日聚合的整天读取预聚合结果
 // @Tags 风险报告
 // @Produce json
 // @Param user_id path string true "用户 ID"
 // @Param start_time query string false "开始时间（RFC3339 格式）"
 // @Param end_time query string false "结束时间（RFC3339 格式）"
-// @Success 200 {object} response.Response{data=map[string]interface{}} "查询成功"
+// @Success 200 {object} response.Response{data=model.UsageStatsResponse} "查询成功"
 // @Failure 400 {object} response.Response "请求参数错误"
 // @Failure 500 {object} response.Response "服务器内部错误"
 // @Router /api/v1/risk-report/usage/stats/{user_id} [get]
@@ -210,23 +293,174 @@ func (h *RiskReportUsageHandler) GetUserStats(c *gin.Context) {
 	response.Success(c, stats)
 }
 
-// handleValidationError 处理验证错误
-func (h *RiskReportUsageHandler) handleValidationError(c *gin.Context, err error) {
-	response.BadRequest(c, "参数验证失败: "+err.Error())
+// RefreshDailyStats 刷新使用记录日聚合
+// @Summary 刷新使用记录日聚合
+// @Description 从明细重新计算按用户按天（UTC）的预聚合，截止到当天零点；不指定 from 时重新计算最近 lookback_days 天。回填历史记录后需从最早的回填日期起刷新；需要管理 API Key
+// @Tags 风险报告
+// @Produce json
+// @Param from query string false "起始日期（YYYY-MM-DD）"
+// @Success 200 {object} response.Response{data=model.RefreshUsageDailyResponse} "刷新完成"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "需要管理 API Key"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/risk-report/usage/daily/refresh [post]
+func (h *RiskReportUsageHandler) RefreshDailyStats(c *gin.Context) {
+	var req model.RefreshUsageDailyRequest
+	if !bindQuery(c, &req, h.log) {
+		return
+	}
+
+	result, err := h.service.RefreshDailyStats(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+// GetQuotaUsage 获取用户当前配额用量
+// @Summary 获取用户配额用量
+// @Description 按当前自然日、自然月（UTC）窗口聚合已用 token，返回配额、已用量、剩余量与下次重置时间；remaining 为 -1 表示不限
+// @Tags 风险报告
+// @Produce json
+// @Param user_id path string true "用户 ID"
+// @Success 200 {object} response.Response{data=model.QuotaUsageResponse} "查询成功"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/risk-report/usage/quota/{user_id} [get]
+func (h *RiskReportUsageHandler) GetQuotaUsage(c *gin.Context) {
+	usage, err := h.service.GetCurrentQuotaUsage(c.Request.Context(), c.Param("user_id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, usage)
+}
+
+// GetMarketStateStats 按市场状态分组统计
+// @Summary 按市场状态统计
+// @Description 统计盘前/盘中/盘后/休市各时段的调用次数与平均 token，market_state 为空的记录归为 UNKNOWN
+// @Tags 风险报告
+// @Produce json
+// @Param user_id query string false "用户 ID，不传则统计全部用户"
+// @Param start_time query string false "开始时间（RFC3339 格式）"
+// @Param end_time query string false "结束时间（RFC3339 格式）"
+// @Success 200 {object} response.Response{data=[]model.MarketStateStats} "查询成功"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/risk-report/usage/stats/market-state [get]
+func (h *RiskReportUsageHandler) GetMarketStateStats(c *gin.Context) {
+	// 解析时间参数
+	var startTime, endTime time.Time
+	if startTimeStr := c.Query("start_time"); startTimeStr != "" {
+		if t, err := time.Parse(time.RFC3339, startTimeStr); err == nil {
+			startTime = t
+		}
+	}
+	if endTimeStr := c.Query("end_time"); endTimeStr != "" {
+		if t, err := time.Parse(time.RFC3339, endTimeStr); err == nil {
+			endTime = t
+		}
+	}
+
+	// 调用服务层获取统计信息
+	stats, err := h.service.StatsByMarketState(c.Request.Context(), c.Query("user_id"), startTime, endTime)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// 返回成功响应
+	response.Success(c, stats)
+}
+
+// GetActionSuggestionStats 按操作建议分组统计
+// @Summary 按操作建议统计
+// @Description 统计买入/卖出/持有等各类操作建议的调用次数与占比，action_suggestion 为空的记录归为 UNKNOWN
+// @Tags 风险报告
+// @Produce json
+// @Param user_id query string false "用户 ID，不传则统计全部用户"
+// @Param start_time query string false "开始时间（RFC3339 格式）"
+// @Param end_time query string false "结束时间（RFC3339 格式）"
+// @Success 200 {object} response.Response{data=[]model.ActionSuggestionStats} "查询成功"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/risk-report/usage/stats/action-suggestion [get]
+func (h *RiskReportUsageHandler) GetActionSuggestionStats(c *gin.Context) {
+	// 解析时间参数
+	var startTime, endTime time.Time
+	if startTimeStr := c.Query("start_time"); startTimeStr != "" {
+		if t, err := time.Parse(time.RFC3339, startTimeStr); err == nil {
+			startTime = t
+		}
+	}
+	if endTimeStr := c.Query("end_time"); endTimeStr != "" {
+		if t, err := time.Parse(time.RFC3339, endTimeStr); err == nil {
+			endTime = t
+		}
+	}
+
+	// 调用服务层获取统计信息
+	stats, err := h.service.StatsByActionSuggestion(c.Request.Context(), c.Query("user_id"), startTime, endTime)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// 返回成功响应
+	response.Success(c, stats)
+}
+
+// Export 导出使用记录
+// @Summary 导出使用记录
+// @Description 按用户和时间范围以 CSV 格式流式导出使用明细，用于对账
+// @Tags 风险报告
+// @Produce text/csv
+// @Param user_id query string true "用户 ID"
+// @Param start_time query string false "开始时间（RFC3339 格式）"
+// @Param end_time query string false "结束时间（RFC3339 格式）"
+// @Success 200 {file} file "CSV 文件"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/risk-report/usage/export [get]
+func (h *RiskReportUsageHandler) Export(c *gin.Context) {
+	var req model.RiskReportUsageExportRequest
+
+	// 绑定并验证请求参数
+	if !bindQuery(c, &req, h.log) {
+		return
+	}
+
+	filename := fmt.Sprintf("risk_report_usage_%s_%s.csv", req.UserID, time.Now().Format("20060102150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// 调用服务层流式导出
+	if _, err := h.service.Export(c.Request.Context(), &req, c.Writer); err != nil {
+		// 尚未写出任何内容时仍可返回 JSON 错误
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			h.handleError(c, err)
+			return
+		}
+		h.log.Error("导出过程中断", logger.String("user_id", req.UserID), logger.Err(err))
+	}
 }
 
 // handleError 处理错误
 func (h *RiskReportUsageHandler) handleError(c *gin.Context, err error) {
+	// 客户端已断开，无需响应
+	if abortIfCanceled(c, err, h.log) {
+		return
+	}
+
 	// 如果是自定义错误，使用错误中的状态码
-	if appErr, ok := err.(*errors.AppError); ok {
-		c.JSON(appErr.HTTPStatus, response.Response{
-			Code:    appErr.Code,
-			Message: appErr.Message,
-			Data:    nil,
-		})
+	if appErr := errors.AsAppError(err); appErr != nil {
+		logAppError(c, h.log, appErr)
+		response.Error(c, appErr.HTTPStatus, appErr.Code, appErr.Message)
 		return
 	}
 
 	// 默认返回 500 错误
-	response.Error(c, http.StatusInternalServerError, response.CodeInternalError, "服务器内部错误")
+	h.log.Error("处理请求时发生未知错误", logger.AppErr(err))
+	response.InternalError(c, "")
 }
@@ -0,0 +1,120 @@
+// Package handler 提供 HTTP 请求处理器
+package handler
+
+import (
+	"github.com/example/go-user-api/internal/middleware"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/service"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/example/go-user-api/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// userExportRetryAfter 导出任务未完成时建议客户端再次请求的间隔（秒）
+const userExportRetryAfter = "5"
+
+// UserExportHandler 用户批量导出任务处理器
+// 处理 /api/v1/admin/exports
+type UserExportHandler struct {
+	exportService service.UserExportJobService
+	log           logger.Logger
+}
+
+// NewUserExportHandler 创建用户批量导出任务处理器实例
+func NewUserExportHandler(exportService service.UserExportJobService, log logger.Logger) *UserExportHandler {
+	return &UserExportHandler{
+		exportService: exportService,
+		log:           log.With(logger.String("handler", "user_export")),
+	}
+}
+
+// Submit 提交用户导出任务
+// @Summary 提交用户导出任务
+// @Description 按过滤条件在后台生成用户导出文件，返回任务信息；通过 GET /api/v1/admin/exports/{id} 查询进度并下载
+// @Tags 管理
+// @Produce json
+// @Security BearerAuth
+// @Param format query string false "导出格式：csv, xlsx，默认 csv"
+// @Param username query string false "用户名（模糊搜索）"
+// @Param email query string false "邮箱（模糊搜索）"
+// @Param status query int false "状态：0-禁用，1-正常，2-未激活"
+// @Param role query string false "角色：user, admin"
+// @Param columns query string false "导出列，逗号分隔，如 id,username,email；默认全部列"
+// @Success 202 {object} response.Response{data=jobqueue.Job} "任务已提交"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 429 {object} response.Response "任务队列已满"
+// @Router /api/v1/admin/exports [post]
+func (h *UserExportHandler) Submit(c *gin.Context) {
+	var req model.UserExportRequest
+
+	// 绑定查询参数
+	if !bindQuery(c, &req, h.log) {
+		return
+	}
+
+	job, err := h.exportService.Submit(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.log.Info("管理员提交用户导出任务",
+		logger.String("operator_id", middleware.GetUserID(c)),
+		logger.String("job_id", job.ID),
+	)
+
+	response.Accepted(c, job)
+}
+
+// Download 下载用户导出文件
+// @Summary 下载用户导出文件
+// @Description 任务完成后返回导出文件；任务未完成时返回 202 与任务进度，客户端按 Retry-After 再次请求
+// @Tags 管理
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security BearerAuth
+// @Param id path string true "任务 ID"
+// @Success 200 {file} file "导出文件"
+// @Success 202 {object} response.Response{data=jobqueue.Job} "生成中"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "任务不存在或文件已过期"
+// @Failure 409 {object} response.Response "任务执行失败"
+// @Router /api/v1/admin/exports/{id} [get]
+func (h *UserExportHandler) Download(c *gin.Context) {
+	file, job, err := h.exportService.Download(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if file == nil {
+		c.Header(middleware.RetryAfterHeader, userExportRetryAfter)
+		response.Accepted(c, job)
+		return
+	}
+
+	// 显式设置 Content-Type，避免按扩展名推断；文件由 http.ServeContent 流式输出
+	c.Header("Content-Type", file.ContentType)
+	c.FileAttachment(file.Path, file.Filename)
+}
+
+// handleError 处理错误
+func (h *UserExportHandler) handleError(c *gin.Context, err error) {
+	if abortIfCanceled(c, err, h.log) {
+		return
+	}
+
+	if appErr := errors.AsAppError(err); appErr != nil {
+		logAppError(c, h.log, appErr)
+		response.Error(c, appErr.HTTPStatus, appErr.Code, appErr.Message)
+		return
+	}
+
+	h.log.Error("处理请求时发生未知错误", logger.AppErr(err))
+	response.InternalError(c, "")
+}
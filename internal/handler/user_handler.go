@@ -10,8 +10,14 @@
 package handler
 
 import (
+	stderrors "errors"
+	"fmt"
 	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/example/go-user-api/internal/config"
 	"github.com/example/go-user-api/internal/middleware"
 	"github.com/example/go-user-api/internal/model"
 	"github.com/example/go-user-api/internal/service"
@@ -24,42 +30,91 @@ import (
 // UserHandler 用户处理器
 // 处理所有用户相关的 HTTP 请求
 type UserHandler struct {
-	userService service.UserService
-	log         logger.Logger
+	userService       service.UserService
+	userDetailService service.UserDetailService
+	log               logger.Logger
+
+	// explainEnabled 是否允许列表接口通过 explain=true 返回执行计划
+	explainEnabled bool
+
+	// authCookie 访问令牌 cookie 配置，为 nil 时不写入 cookie
+	authCookie *config.AuthCookieConfig
+
+	// avatarMaxSize 头像文件大小上限（字节），<= 0 时不在读取请求体时限制
+	avatarMaxSize int64
+}
+
+// UserHandlerOption 用户处理器的可选配置
+type UserHandlerOption func(*UserHandler)
+
+// WithQueryExplain 允许用户列表接口通过 explain=true 返回查询执行计划
+// 执行计划会暴露表结构与索引信息，只应在 debug 模式下启用
+func WithQueryExplain() UserHandlerOption {
+	return func(h *UserHandler) {
+		h.explainEnabled = true
+	}
+}
+
+// WithAuthCookie 登录、刷新令牌时把访问令牌写入 HttpOnly cookie，登出时清除
+// 响应体中仍返回令牌，使用 Authorization 头的客户端不受影响
+func WithAuthCookie(cfg config.AuthCookieConfig) UserHandlerOption {
+	return func(h *UserHandler) {
+		h.authCookie = &cfg
+	}
+}
+
+// WithAvatarMaxSize 设置头像文件大小上限（字节）
+// 上传请求体超过上限加 multipart 编码余量后停止读取，不会先把整个请求体落盘再由服务层拒绝
+func WithAvatarMaxSize(maxSize int64) UserHandlerOption {
+	return func(h *UserHandler) {
+		h.avatarMaxSize = maxSize
+	}
 }
 
 // NewUserHandler 创建用户处理器实例
 // 参数：
 //   - userService: 用户服务实例
+//   - userDetailService: 用户详情服务实例
 //   - log: 日志记录器
-func NewUserHandler(userService service.UserService, log logger.Logger) *UserHandler {
-	return &UserHandler{
-		userService: userService,
-		log:         log.With(logger.String("handler", "user")),
+//   - opts: 可选配置
+func NewUserHandler(userService service.UserService, userDetailService service.UserDetailService, log logger.Logger, opts ...UserHandlerOption) *UserHandler {
+	h := &UserHandler{
+		userService:       userService,
+		userDetailService: userDetailService,
+		log:               log.With(logger.String("handler", "user")),
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
 // Register 用户注册
 // @Summary 用户注册
-// @Description 创建新用户账号
+// @Description 创建新用户账号。请求体未填写 source 时，依次取 utm_source 查询参数与 X-Registration-Source 请求头作为注册来源
 // @Tags 认证
 // @Accept json
 // @Produce json
 // @Param request body model.RegisterRequest true "注册信息"
+// @Param utm_source query string false "注册来源渠道"
+// @Param X-Registration-Source header string false "注册来源渠道"
 // @Success 201 {object} response.Response{data=model.UserResponse} "注册成功"
 // @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "注册暂未开放"
 // @Failure 409 {object} response.Response "用户名或邮箱已存在"
+// @Failure 429 {object} response.Response "注册过于频繁或本小时注册人数已达上限"
 // @Failure 500 {object} response.Response "服务器内部错误"
 // @Router /api/v1/auth/register [post]
 func (h *UserHandler) Register(c *gin.Context) {
 	var req model.RegisterRequest
 
 	// 绑定并验证请求参数
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Debug("注册参数验证失败", logger.Err(err))
-		h.handleValidationError(c, err)
+	if !bindJSON(c, &req, h.log) {
 		return
 	}
+	if req.Source == "" {
+		req.Source = registrationSource(c)
+	}
 
 	// 调用服务层注册用户
 	user, err := h.userService.Register(c.Request.Context(), &req)
@@ -89,9 +144,7 @@ func (h *UserHandler) Login(c *gin.Context) {
 	var req model.LoginRequest
 
 	// 绑定并验证请求参数
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Debug("登录参数验证失败", logger.Err(err))
-		h.handleValidationError(c, err)
+	if !bindJSON(c, &req, h.log) {
 		return
 	}
 
@@ -104,6 +157,7 @@ func (h *UserHandler) Login(c *gin.Context) {
 		h.handleError(c, err)
 		return
 	}
+	h.setAuthCookie(c, resp.AccessToken, resp.ExpiresIn)
 
 	// 返回成功响应
 	response.Success(c, resp)
@@ -125,9 +179,7 @@ func (h *UserHandler) RefreshToken(c *gin.Context) {
 	var req model.RefreshTokenRequest
 
 	// 绑定并验证请求参数
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Debug("刷新令牌参数验证失败", logger.Err(err))
-		h.handleValidationError(c, err)
+	if !bindJSON(c, &req, h.log) {
 		return
 	}
 
@@ -137,11 +189,51 @@ func (h *UserHandler) RefreshToken(c *gin.Context) {
 		h.handleError(c, err)
 		return
 	}
+	h.setAuthCookie(c, resp.AccessToken, resp.ExpiresIn)
 
 	// 返回成功响应
 	response.Success(c, resp)
 }
 
+// Logout 用户登出
+// @Summary 用户登出
+// @Description 撤销当前用户的所有刷新令牌；启用令牌黑名单时当前访问令牌同时失效
+// @Tags 认证
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.MessageResponse} "登出成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/auth/logout [post]
+func (h *UserHandler) Logout(c *gin.Context) {
+	// 从上下文获取令牌声明，登出时吊销的正是当前访问令牌
+	claims := middleware.GetClaims(c)
+	if claims == nil || claims.UserID == "" {
+		response.Unauthorized(c, "")
+		return
+	}
+
+	// 调用服务层登出
+	if err := h.userService.Logout(c.Request.Context(), claims); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	if h.authCookie != nil {
+		middleware.ClearAuthCookie(c, *h.authCookie)
+	}
+
+	// 返回成功响应
+	response.Success(c, model.MessageResponse{Message: "登出成功"})
+}
+
+// setAuthCookie 启用 cookie 认证时把访问令牌写入 cookie
+func (h *UserHandler) setAuthCookie(c *gin.Context, token string, expiresIn int64) {
+	if h.authCookie == nil {
+		return
+	}
+	middleware.SetAuthCookie(c, *h.authCookie, token, time.Duration(expiresIn)*time.Second)
+}
+
 // GetCurrentUser 获取当前登录用户信息
 // @Summary 获取当前用户
 // @Description 获取当前登录用户的详细信息
@@ -171,15 +263,48 @@ func (h *UserHandler) GetCurrentUser(c *gin.Context) {
 	response.Success(c, user.ToResponse())
 }
 
+// GetCurrentUserPermissions 获取当前用户的权限清单
+// @Summary 获取当前用户权限
+// @Description 返回当前用户的角色及由角色推导的权限清单，用于前端渲染菜单
+// @Tags 用户
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.PermissionsResponse} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/users/me/permissions [get]
+func (h *UserHandler) GetCurrentUserPermissions(c *gin.Context) {
+	// 从上下文获取用户 ID
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		response.Unauthorized(c, "")
+		return
+	}
+
+	// 以数据库中的角色为准，令牌中的角色可能已过时
+	user, err := h.userService.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, model.PermissionsResponse{
+		Role:        user.Role,
+		Permissions: user.Permissions(),
+	})
+}
+
 // GetUser 获取用户信息
 // @Summary 获取用户详情
-// @Description 根据用户 ID 获取用户详细信息
+// @Description 根据用户 ID 获取用户信息；非本人且非管理员时不返回邮箱、手机号等联系信息。
+// @Description 资料可见性为 friends 时非好友只返回简要信息（model.UserBrief），为 private 时他人访问返回 403
 // @Tags 用户
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "用户 ID"
 // @Success 200 {object} response.Response{data=model.UserResponse} "获取成功"
 // @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "资料不公开"
 // @Failure 404 {object} response.Response "用户不存在"
 // @Failure 500 {object} response.Response "服务器内部错误"
 // @Router /api/v1/users/{id} [get]
@@ -191,15 +316,193 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		return
 	}
 
-	// 调用服务层获取用户
-	user, err := h.userService.GetByID(c.Request.Context(), userID)
+	// 调用服务层按资料可见性获取用户
+	claims := middleware.GetClaims(c)
+	user, access, err := h.userService.GetProfile(c.Request.Context(), userID, claims)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if access == model.ProfileAccessBrief {
+		response.Success(c, user.ToBrief())
+		return
+	}
+	// 按查看者身份裁剪字段：本人与管理员可见联系方式，其他用户不可见
+	response.Success(c, user.ToResponseFor(claims))
+}
+
+// BatchGetUsers 批量获取用户
+// @Summary 批量获取用户
+// @Description 根据 ID 列表一次性获取多个用户，不存在或不能查看完整资料的 ID 在 missing 中列出
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.BatchGetUsersRequest true "用户 ID 列表（最多 100 个）"
+// @Success 200 {object} response.Response{data=model.BatchGetUsersResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/users/batch-get [post]
+func (h *UserHandler) BatchGetUsers(c *gin.Context) {
+	var req model.BatchGetUsersRequest
+
+	// 绑定并验证请求参数
+	if !bindJSON(c, &req, h.log) {
+		return
+	}
+
+	claims := middleware.GetClaims(c)
+	users, missing, err := h.userService.GetManyByIDs(c.Request.Context(), req.IDs, claims)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, model.BatchGetUsersResponse{
+		Users:   model.UsersToResponseFor(users, claims),
+		Missing: missing,
+	})
+}
+
+// BatchUpdateRole 批量修改用户角色（管理员）
+// @Summary 批量修改用户角色
+// @Description 在同一事务中将一批用户设为指定角色，任一用户不存在时全部不修改；不能降低自己的角色
+// @Tags 用户管理
+// @Accept json
+// @Produce json,text/event-stream
+// @Security BearerAuth
+// @Param request body model.BatchUpdateRoleRequest true "用户 ID 列表（最多 100 个）与目标角色"
+// @Param Accept header string false "为 text/event-stream 时以 SSE 推送逐个用户的进度（progress 事件），最后发送 done 事件"
+// @Success 200 {object} response.Response{data=model.BatchUpdateRoleResponse} "修改成功"
+// @Failure 400 {object} response.Response "请求参数错误或角色不合法"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 404 {object} response.Response "用户不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/users/batch/role [post]
+func (h *UserHandler) BatchUpdateRole(c *gin.Context) {
+	var req model.BatchUpdateRoleRequest
+
+	// 绑定并验证请求参数
+	if !bindJSON(c, &req, h.log) {
+		return
+	}
+
+	stream, progress := newProgressStream(c)
+	result, err := h.userService.BatchUpdateRole(c.Request.Context(), &req, middleware.GetUserID(c), progress)
+	h.respondBatch(c, stream, result, err)
+}
+
+// BatchUpdateStatus 批量修改用户状态（管理员）
+// @Summary 批量修改用户状态
+// @Description 逐个修改一批用户的状态，单个用户失败记录在结果中，不影响其他用户；不能禁用自己
+// @Tags 用户管理
+// @Accept json
+// @Produce json,text/event-stream
+// @Security BearerAuth
+// @Param request body model.BatchUpdateStatusRequest true "用户 ID 列表（最多 100 个）与目标状态"
+// @Param Accept header string false "为 text/event-stream 时以 SSE 推送逐个用户的进度（progress 事件），最后发送 done 事件"
+// @Success 200 {object} response.Response{data=model.BatchUpdateStatusResponse} "处理完成"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/users/batch/status [post]
+func (h *UserHandler) BatchUpdateStatus(c *gin.Context) {
+	var req model.BatchUpdateStatusRequest
+
+	// 绑定并验证请求参数
+	if !bindJSON(c, &req, h.log) {
+		return
+	}
+
+	stream, progress := newProgressStream(c)
+	result, err := h.userService.BatchUpdateStatus(c.Request.Context(), &req, middleware.GetUserID(c), progress)
+	h.respondBatch(c, stream, result, err)
+}
+
+// respondBatch 返回批量操作的结果
+// SSE 推送已开始时结果以 done 事件发送、错误以 error 事件发送，否则按普通 JSON 响应返回
+func (h *UserHandler) respondBatch(c *gin.Context, stream *eventStream, result interface{}, err error) {
+	if err != nil {
+		if stream != nil && stream.Started() {
+			if !abortIfCanceled(c, err, h.log) {
+				stream.Fail(err)
+			}
+			return
+		}
+		h.handleError(c, err)
+		return
+	}
+
+	if stream != nil {
+		stream.Send(EventDone, result)
+		return
+	}
+	response.Success(c, result)
+}
+
+// GetUserDetail 获取用户详细审计信息（管理员）
+// @Summary 获取用户审计详情
+// @Description 聚合用户核心信息、最近登录记录、活跃会话数和标签，部分数据获取失败时返回部分结果
+// @Tags 用户管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用户 ID"
+// @Success 200 {object} response.Response{data=model.UserDetailResponse} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 404 {object} response.Response "用户不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/users/{id}/detail [get]
+func (h *UserHandler) GetUserDetail(c *gin.Context) {
+	// 获取用户 ID 参数
+	userID := c.Param("id")
+	if userID == "" {
+		response.BadRequest(c, "用户 ID 不能为空")
+		return
+	}
+
+	// 调用服务层聚合用户详情
+	detail, err := h.userDetailService.GetDetail(c.Request.Context(), userID)
 	if err != nil {
 		h.handleError(c, err)
 		return
 	}
 
 	// 返回成功响应
-	response.Success(c, user.ToResponse())
+	response.Success(c, detail)
+}
+
+// GetOnlineStatus 查询用户在线状态（管理员）
+// @Summary 查询用户在线状态
+// @Description 最近活跃时间在 security.online_threshold_minutes 以内视为在线
+// @Tags 用户管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用户 ID"
+// @Success 200 {object} response.Response{data=model.OnlineStatusResponse} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 404 {object} response.Response "用户不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/users/{id}/online-status [get]
+func (h *UserHandler) GetOnlineStatus(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		response.BadRequest(c, "用户 ID 不能为空")
+		return
+	}
+
+	status, err := h.userService.GetOnlineStatus(c.Request.Context(), userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, status)
 }
 
 // UpdateCurrentUser 更新当前用户信息
@@ -226,9 +529,7 @@ func (h *UserHandler) UpdateCurrentUser(c *gin.Context) {
 	var req model.UpdateUserRequest
 
 	// 绑定并验证请求参数
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Debug("更新用户参数验证失败", logger.Err(err))
-		h.handleValidationError(c, err)
+	if !bindJSON(c, &req, h.log) {
 		return
 	}
 
@@ -243,6 +544,100 @@ func (h *UserHandler) UpdateCurrentUser(c *gin.Context) {
 	response.Success(c, user.ToResponse())
 }
 
+// avatarFormOverhead 头像上传请求体中 multipart 边界、表单头等编码内容的余量（字节）
+const avatarFormOverhead = 64 << 10
+
+// UploadAvatar 上传当前用户头像
+// @Summary 上传头像
+// @Description 上传 JPEG、PNG 或 GIF 头像，服务端保存原图并生成各尺寸缩略图（居中裁剪为正方形）
+// @Tags 用户
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "头像图片"
+// @Success 200 {object} response.Response{data=model.UserResponse} "上传成功"
+// @Failure 400 {object} response.Response "文件过大或不是有效的图片"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 503 {object} response.Response "头像处理繁忙或超时"
+// @Router /api/v1/users/me/avatar [post]
+func (h *UserHandler) UploadAvatar(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		response.Unauthorized(c, "")
+		return
+	}
+
+	if h.avatarMaxSize > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.avatarMaxSize+avatarFormOverhead)
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if stderrors.As(err, &tooLarge) {
+			response.BadRequest(c, fmt.Sprintf("头像文件不能超过 %dMB", h.avatarMaxSize>>20))
+			return
+		}
+		response.BadRequest(c, "请上传头像文件")
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		h.log.Error("打开头像文件失败", logger.Err(err))
+		response.InternalError(c, "")
+		return
+	}
+	defer file.Close()
+
+	// 文件大小与图片格式由服务层校验
+	user, err := h.userService.UploadAvatar(c.Request.Context(), userID, file)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, user.ToResponse())
+}
+
+// GetUserChangeLog 获取用户关键字段变更历史（管理员）
+// @Summary 获取用户变更历史
+// @Description 分页获取用户 email、role、status 的变更记录，按时间倒序
+// @Tags 用户管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用户 ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=response.PageData{list=[]model.UserChangeLog}} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 404 {object} response.Response "用户不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/users/{id}/changelog [get]
+func (h *UserHandler) GetUserChangeLog(c *gin.Context) {
+	// 获取用户 ID 参数
+	userID := c.Param("id")
+	if userID == "" {
+		response.BadRequest(c, "用户 ID 不能为空")
+		return
+	}
+
+	var req model.UserChangeLogListRequest
+
+	// 绑定并验证查询参数
+	if !bindQuery(c, &req, h.log) {
+		return
+	}
+
+	logs, total, err := h.userService.ListChangeLogs(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.SuccessWithPagination(c, logs, req.Page, req.PageSize, total)
+}
+
 // UpdateUser 更新用户信息（管理员）
 // @Summary 更新用户（管理员）
 // @Description 管理员更新指定用户的信息
@@ -267,17 +662,15 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	var req model.UpdateUserRequest
+	var req model.AdminUpdateUserRequest
 
 	// 绑定并验证请求参数
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Debug("更新用户参数验证失败", logger.Err(err))
-		h.handleValidationError(c, err)
+	if !bindJSON(c, &req, h.log) {
 		return
 	}
 
-	// 调用服务层更新用户
-	user, err := h.userService.Update(c.Request.Context(), userID, &req)
+	// 调用服务层更新用户，记录操作人以便追踪变更历史
+	user, err := h.userService.AdminUpdate(c.Request.Context(), userID, &req, middleware.GetUserID(c))
 	if err != nil {
 		h.handleError(c, err)
 		return
@@ -311,9 +704,7 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	var req model.ChangePasswordRequest
 
 	// 绑定并验证请求参数
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Debug("修改密码参数验证失败", logger.Err(err))
-		h.handleValidationError(c, err)
+	if !bindJSON(c, &req, h.log) {
 		return
 	}
 
@@ -329,12 +720,14 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 
 // DeleteUser 删除用户
 // @Summary 删除用户
-// @Description 删除指定用户（软删除）
+// @Description 删除指定用户，默认软删除；hard=true 时永久删除用户及其个人数据，审计记录匿名化保留
 // @Tags 用户管理
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "用户 ID"
+// @Param hard query bool false "是否永久删除" default(false)
 // @Success 204 "删除成功"
+// @Failure 400 {object} response.Response "请求参数错误"
 // @Failure 401 {object} response.Response "未授权"
 // @Failure 403 {object} response.Response "无权限"
 // @Failure 404 {object} response.Response "用户不存在"
@@ -355,8 +748,19 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
+	var req model.DeleteUserRequest
+
+	// 绑定查询参数
+	if !bindQuery(c, &req, h.log) {
+		return
+	}
+
 	// 调用服务层删除用户
-	if err := h.userService.Delete(c.Request.Context(), userID); err != nil {
+	deleteFn := h.userService.Delete
+	if req.Hard {
+		deleteFn = h.userService.HardDelete
+	}
+	if err := deleteFn(c.Request.Context(), userID); err != nil {
 		h.handleError(c, err)
 		return
 	}
@@ -365,6 +769,70 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	response.NoContent(c)
 }
 
+// RevokeUserTokens 强制用户下线
+// @Summary 强制用户下线
+// @Description 使指定用户已签发的所有令牌失效，用户需要重新登录
+// @Tags 用户管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用户 ID"
+// @Success 200 {object} response.Response{data=model.MessageResponse} "操作成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "用户不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/users/{id}/revoke-tokens [post]
+func (h *UserHandler) RevokeUserTokens(c *gin.Context) {
+	// 获取用户 ID 参数
+	userID := c.Param("id")
+	if userID == "" {
+		response.BadRequest(c, "用户 ID 不能为空")
+		return
+	}
+
+	// 调用服务层撤销令牌
+	if err := h.userService.RevokeTokens(c.Request.Context(), userID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.log.Warn("管理员强制用户下线",
+		logger.String("operator_id", middleware.GetUserID(c)),
+		logger.String("user_id", userID),
+	)
+
+	// 返回成功响应
+	response.Success(c, model.MessageResponse{Message: "用户令牌已全部失效"})
+}
+
+// LookupUser 按确切邮箱或用户名查找用户
+// @Summary 按邮箱或用户名查找用户
+// @Description 按确切的邮箱或用户名定位单个用户（精确匹配，区别于列表的模糊搜索），email 与 username 只能提供一个
+// @Tags 用户管理
+// @Produce json
+// @Security BearerAuth
+// @Param email query string false "邮箱（精确匹配）"
+// @Param username query string false "用户名（精确匹配）"
+// @Success 200 {object} response.Response{data=model.UserResponse} "获取成功"
+// @Failure 400 {object} response.Response "未提供或同时提供了 email 与 username"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Failure 404 {object} response.Response "用户不存在"
+// @Router /api/v1/users/lookup [get]
+func (h *UserHandler) LookupUser(c *gin.Context) {
+	var req model.UserLookupRequest
+	if !bindQuery(c, &req, h.log) {
+		return
+	}
+
+	user, err := h.userService.Lookup(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	response.Success(c, user.ToResponseFor(middleware.GetClaims(c)))
+}
+
 // ListUsers 获取用户列表
 // @Summary 获取用户列表
 // @Description 分页获取用户列表，支持搜索和过滤
@@ -377,8 +845,11 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 // @Param email query string false "邮箱（模糊搜索）"
 // @Param status query int false "状态：0-禁用，1-正常，2-未激活"
 // @Param role query string false "角色：user, admin"
+// @Param source query string false "注册来源（不区分大小写）"
 // @Param sort_by query string false "排序字段：created_at, updated_at, username, email"
 // @Param sort_order query string false "排序方向：asc, desc"
+// @Param skip_total query bool false "跳过总数统计（total 返回 -1）"
+// @Param explain query bool false "返回查询执行计划而不是数据（仅 debug 模式）"
 // @Success 200 {object} response.Response{data=response.PageData} "获取成功"
 // @Failure 401 {object} response.Response "未授权"
 // @Failure 403 {object} response.Response "无权限"
@@ -388,9 +859,18 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 	var req model.UserListRequest
 
 	// 绑定查询参数
-	if err := c.ShouldBindQuery(&req); err != nil {
-		h.log.Debug("用户列表参数验证失败", logger.Err(err))
-		h.handleValidationError(c, err)
+	if !bindQuery(c, &req, h.log) {
+		return
+	}
+
+	// 调试模式下返回执行计划，供排查慢查询；其他模式忽略 explain 参数
+	if req.Explain && h.explainEnabled {
+		plan, err := h.userService.ExplainList(c.Request.Context(), &req)
+		if err != nil {
+			h.handleError(c, err)
+			return
+		}
+		response.Success(c, plan)
 		return
 	}
 
@@ -408,28 +888,180 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 	response.SuccessWithPagination(c, userResponses, req.GetDefaultPage(), req.GetDefaultPageSize(20, 100), total)
 }
 
+// maxImportFileSize 用户导入文件的最大字节数
+const maxImportFileSize = 10 << 20
+
+// ExportUsers 导出用户
+// @Summary 导出用户
+// @Description 按过滤条件导出用户，格式由 format 参数或 Accept 头决定，默认 CSV。
+// @Description 同步生成，受请求处理超时限制；用户量大时使用 POST /api/v1/admin/exports 在后台生成
+// @Tags 用户管理
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security BearerAuth
+// @Param format query string false "导出格式：csv, xlsx"
+// @Param username query string false "用户名（模糊搜索）"
+// @Param email query string false "邮箱（模糊搜索）"
+// @Param status query int false "状态：0-禁用，1-正常，2-未激活"
+// @Param role query string false "角色：user, admin"
+// @Param columns query string false "导出列，逗号分隔，如 id,username,email；默认全部列"
+// @Success 200 {file} file "导出文件"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Router /api/v1/users/export [get]
+func (h *UserHandler) ExportUsers(c *gin.Context) {
+	var req model.UserExportRequest
+
+	// 绑定查询参数
+	if !bindQuery(c, &req, h.log) {
+		return
+	}
+	req.Format = negotiateTableFormat(c, req.Format)
+
+	contentType := service.ContentTypeCSV
+	if req.Format == service.FormatXLSX {
+		contentType = service.ContentTypeXLSX
+	}
+	filename := fmt.Sprintf("users_%s.%s", time.Now().Format("20060102150405"), req.Format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// 调用服务层导出
+	if _, err := h.userService.ExportUsers(c.Request.Context(), &req, c.Writer); err != nil {
+		// 尚未写出任何内容时仍可返回 JSON 错误
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			h.handleError(c, err)
+			return
+		}
+		h.log.Error("导出用户过程中断", logger.Err(err))
+	}
+}
+
+// ImportUsers 导入用户
+// @Summary 导入用户
+// @Description 上传 CSV 或 xlsx 文件批量创建用户，首行为表头，必须包含 username 和 password 列
+// @Tags 用户管理
+// @Accept multipart/form-data
+// @Produce json,text/event-stream
+// @Security BearerAuth
+// @Param file formData file true "导入文件"
+// @Param format query string false "文件格式：csv, xlsx，默认按文件扩展名判断"
+// @Param Accept header string false "为 text/event-stream 时以 SSE 推送逐行进度（progress 事件），最后发送 done 事件"
+// @Success 200 {object} response.Response{data=model.UserImportResult} "导入完成"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Router /api/v1/users/import [post]
+func (h *UserHandler) ImportUsers(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "请上传导入文件")
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		response.BadRequest(c, "导入文件不能超过 10MB")
+		return
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = service.FormatCSV
+		if strings.EqualFold(filepath.Ext(fileHeader.Filename), "."+service.FormatXLSX) {
+			format = service.FormatXLSX
+		}
+	}
+	if !service.IsSupportedFormat(format) {
+		response.BadRequest(c, "不支持的文件格式: "+format)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		h.log.Error("打开导入文件失败", logger.Err(err))
+		response.InternalError(c, "")
+		return
+	}
+	defer file.Close()
+
+	// 客户端接受 SSE 时逐行推送进度，最后以 done 事件返回导入结果
+	stream, progress := newProgressStream(c)
+
+	// 调用服务层导入
+	result, err := h.userService.ImportUsers(c.Request.Context(), format, file, progress)
+	if err != nil {
+		if stream != nil && stream.Started() {
+			if !abortIfCanceled(c, err, h.log) {
+				stream.Fail(err)
+			}
+			return
+		}
+		h.handleError(c, err)
+		return
+	}
+
+	h.log.Info("管理员导入用户",
+		logger.String("operator_id", middleware.GetUserID(c)),
+		logger.Int("created", result.Created),
+		logger.Int("failed", result.Failed),
+	)
+
+	if stream != nil {
+		stream.Send(EventDone, result)
+		return
+	}
+	response.Success(c, result)
+}
+
+// RegistrationSourceHeader 携带注册来源的请求头
+const RegistrationSourceHeader = "X-Registration-Source"
+
+// registrationSource 从查询参数或请求头提取注册来源
+// 用于落地页直接携带 UTM 参数调用注册接口的场景
+func registrationSource(c *gin.Context) string {
+	if source := c.Query("utm_source"); source != "" {
+		return source
+	}
+	return c.GetHeader(RegistrationSourceHeader)
+}
+
+// negotiateTableFormat 确定导出格式
+// 优先使用查询参数，其次根据 Accept 头，默认 CSV
+func negotiateTableFormat(c *gin.Context, requested string) string {
+	if requested != "" {
+		return requested
+	}
+	if strings.Contains(c.GetHeader("Accept"), service.ContentTypeXLSX) {
+		return service.FormatXLSX
+	}
+	return service.FormatCSV
+}
+
 // handleError 处理错误响应
 // 根据错误类型返回相应的 HTTP 响应
 func (h *UserHandler) handleError(c *gin.Context, err error) {
+	// 客户端已断开，无需响应
+	if abortIfCanceled(c, err, h.log) {
+		return
+	}
+	if respondBusinessValidation(c, err, h.log) {
+		return
+	}
+
 	// 检查是否是应用错误
 	if appErr := errors.AsAppError(err); appErr != nil {
+		logAppError(c, h.log, appErr)
 		response.Error(c, appErr.HTTPStatus, appErr.Code, appErr.Message)
 		return
 	}
 
 	// 未知错误，记录日志并返回内部错误
-	h.log.Error("处理请求时发生未知错误", logger.Err(err))
+	h.log.Error("处理请求时发生未知错误", logger.AppErr(err))
 	response.InternalError(c, "")
 }
 
-// handleValidationError 处理验证错误
-// 解析验证错误并返回格式化的错误响应
-func (h *UserHandler) handleValidationError(c *gin.Context, err error) {
-	// 这里可以根据需要解析 validator 的错误，提取字段级别的错误信息
-	// 为简化示例，这里直接返回通用的错误消息
-	response.BadRequest(c, "请求参数验证失败: "+err.Error())
-}
-
 // HealthCheck 健康检查
 // @Summary 健康检查
 // @Description 检查服务是否正常运行
@@ -438,7 +1070,7 @@ func (h *UserHandler) handleValidationError(c *gin.Context, err error) {
 // @Success 200 {object} response.Response{data=model.HealthResponse} "服务正常"
 // @Router /health [get]
 func (h *UserHandler) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, model.HealthResponse{
+	response.Raw(c, http.StatusOK, model.HealthResponse{
 		Status:  "healthy",
 		Version: "v1.0.0",
 	})
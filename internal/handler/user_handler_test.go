@@ -0,0 +1,655 @@
+// Package handler 提供 HTTP 请求处理器
+//
+// 本文件包含用户处理器的单元测试
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/internal/middleware"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/service"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/example/go-user-api/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// stubUserService 只实现测试用到的方法，其余方法调用时 panic
+type stubUserService struct {
+	service.UserService
+	user *model.User
+}
+
+func (s *stubUserService) GetByID(_ context.Context, id string) (*model.User, error) {
+	return s.user, nil
+}
+
+// GetProfile 按资料可见性返回 user，不存在好友关系
+func (s *stubUserService) GetProfile(_ context.Context, _ string, viewer model.Viewer) (*model.User, model.ProfileAccess, error) {
+	access := s.user.ProfileAccessFor(viewer, false)
+	if access == model.ProfileAccessDenied {
+		return nil, access, errors.ErrForbidden
+	}
+	return s.user, access, nil
+}
+
+// newGetUserEngine 构建以指定身份访问 GET /users/:id 的路由，目标用户资料可见性为 visibility
+func newGetUserEngine(t *testing.T, claims *service.TokenClaims, visibility string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	log, err := logger.New(&logger.Config{Level: "error", Format: "console"})
+	require.NoError(t, err)
+
+	target := &model.User{
+		BaseModel:         model.BaseModel{ID: "target"},
+		Username:          "alice",
+		Email:             "alice@example.com",
+		Phone:             "13800000000",
+		Role:              model.RoleUser,
+		ProfileVisibility: visibility,
+	}
+	h := NewUserHandler(&stubUserService{user: target}, nil, log)
+
+	engine := gin.New()
+	engine.GET("/users/:id", func(c *gin.Context) {
+		if claims != nil {
+			c.Set(middleware.ContextKeyClaims, claims)
+		}
+		c.Next()
+	}, h.GetUser)
+	return engine
+}
+
+// getUserAs 以指定身份请求公开资料的 GET /users/:id
+func getUserAs(t *testing.T, claims *service.TokenClaims) model.UserResponse {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	newGetUserEngine(t, claims, model.ProfileVisibilityPublic).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/target", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data model.UserResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Data
+}
+
+// ============================================================
+// GetUser 字段过滤测试
+// ============================================================
+
+func TestGetUser_AdminSeesContactInfo(t *testing.T) {
+	resp := getUserAs(t, &service.TokenClaims{UserID: "admin-1", Role: model.RoleAdmin})
+
+	assert.Equal(t, "alice@example.com", resp.Email)
+	assert.Equal(t, "13800000000", resp.Phone)
+}
+
+func TestGetUser_SelfSeesContactInfo(t *testing.T) {
+	resp := getUserAs(t, &service.TokenClaims{UserID: "target", Role: model.RoleUser})
+
+	assert.Equal(t, "alice@example.com", resp.Email)
+}
+
+func TestGetUser_StrangerDoesNotSeeContactInfo(t *testing.T) {
+	resp := getUserAs(t, &service.TokenClaims{UserID: "stranger", Role: model.RoleUser})
+
+	assert.Equal(t, "alice", resp.Username)
+	assert.Empty(t, resp.Email)
+	assert.Empty(t, resp.Phone)
+}
+
+func TestGetUser_NoClaimsTreatedAsStranger(t *testing.T) {
+	resp := getUserAs(t, nil)
+
+	assert.Empty(t, resp.Email)
+}
+
+func TestGetUser_PrivateProfile(t *testing.T) {
+	// 陌生人被拒绝
+	w := httptest.NewRecorder()
+	newGetUserEngine(t, &service.TokenClaims{UserID: "stranger", Role: model.RoleUser}, model.ProfileVisibilityPrivate).
+		ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/target", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 本人看到完整资料
+	w = httptest.NewRecorder()
+	newGetUserEngine(t, &service.TokenClaims{UserID: "target", Role: model.RoleUser}, model.ProfileVisibilityPrivate).
+		ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/target", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data model.UserResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "alice@example.com", resp.Data.Email)
+	assert.Equal(t, model.ProfileVisibilityPrivate, resp.Data.ProfileVisibility)
+}
+
+func TestGetUser_FriendsOnlyProfileShowsBriefToStranger(t *testing.T) {
+	// 执行
+	w := httptest.NewRecorder()
+	newGetUserEngine(t, &service.TokenClaims{UserID: "stranger", Role: model.RoleUser}, model.ProfileVisibilityFriends).
+		ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/target", nil))
+
+	// 断言：只有简要信息的字段
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "alice", resp.Data["username"])
+	assert.NotContains(t, resp.Data, "role")
+	assert.NotContains(t, resp.Data, "created_at")
+}
+
+// ============================================================
+// DeleteUser 删除策略测试
+// ============================================================
+
+// deleteRecorder 记录调用的删除方式
+type deleteRecorder struct {
+	service.UserService
+	calls []string
+}
+
+func (s *deleteRecorder) Delete(_ context.Context, id string) error {
+	s.calls = append(s.calls, "soft:"+id)
+	return nil
+}
+
+func (s *deleteRecorder) HardDelete(_ context.Context, id string) error {
+	s.calls = append(s.calls, "hard:"+id)
+	return nil
+}
+
+func TestDeleteUser_HardQuerySelectsHardDelete(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log, err := logger.New(&logger.Config{Level: "error", Format: "console"})
+	require.NoError(t, err)
+
+	svc := &deleteRecorder{}
+	engine := gin.New()
+	engine.DELETE("/users/:id", NewUserHandler(svc, nil, log).DeleteUser)
+
+	for _, target := range []string{"/users/u1", "/users/u2?hard=false", "/users/u3?hard=true"} {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, target, nil))
+		assert.Equal(t, http.StatusNoContent, w.Code, target)
+	}
+
+	// 非法取值返回 400
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/u4?hard=maybe", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	assert.Equal(t, []string{"soft:u1", "soft:u2", "hard:u3"}, svc.calls)
+}
+
+// logEntry 记录的一条日志
+type logEntry struct {
+	level  string
+	msg    string
+	fields map[string]interface{}
+}
+
+// recordingLogger 记录日志条目的 Logger，用于断言日志字段
+type recordingLogger struct {
+	entries []logEntry
+}
+
+func (l *recordingLogger) record(level, msg string, fields []logger.Field) {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	l.entries = append(l.entries, logEntry{level: level, msg: msg, fields: enc.Fields})
+}
+
+func (l *recordingLogger) Debug(msg string, fields ...logger.Field) { l.record("debug", msg, fields) }
+func (l *recordingLogger) Info(msg string, fields ...logger.Field)  { l.record("info", msg, fields) }
+func (l *recordingLogger) Warn(msg string, fields ...logger.Field)  { l.record("warn", msg, fields) }
+func (l *recordingLogger) Error(msg string, fields ...logger.Field) { l.record("error", msg, fields) }
+func (l *recordingLogger) Fatal(msg string, fields ...logger.Field) { l.record("fatal", msg, fields) }
+func (l *recordingLogger) With(...logger.Field) logger.Logger       { return l }
+func (l *recordingLogger) Sync() error                              { return nil }
+
+func TestUserHandler_HandleError_LogsErrorCode(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		level string
+	}{
+		{name: "客户端错误", err: errors.ErrUserNotFound.WithDetail("id=u1"), level: "info"},
+		{name: "服务端错误", err: errors.ErrInternalServer, level: "error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 准备
+			gin.SetMode(gin.TestMode)
+			log := &recordingLogger{}
+			h := NewUserHandler(nil, nil, log)
+			engine := gin.New()
+			engine.GET("/users/:id", func(c *gin.Context) { h.handleError(c, tt.err) })
+
+			// 执行
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/u1", nil))
+
+			// 断言：日志带错误码等结构化字段
+			appErr := errors.AsAppError(tt.err)
+			assert.Equal(t, appErr.HTTPStatus, w.Code)
+			require.Len(t, log.entries, 1)
+			entry := log.entries[0]
+			assert.Equal(t, tt.level, entry.level)
+			assert.Equal(t, appErr.Code, entry.fields["error_code"])
+			assert.Equal(t, appErr.Message, entry.fields["error_message"])
+			assert.Equal(t, "/users/:id", entry.fields["path"])
+		})
+	}
+}
+
+func TestUserHandler_HandleError_BusinessValidation(t *testing.T) {
+	// 准备
+	gin.SetMode(gin.TestMode)
+	h := NewUserHandler(nil, nil, &recordingLogger{})
+	engine := gin.New()
+	fieldErr := model.FieldError{Field: "birthday", Kind: model.FieldErrorKindInvalid, Tag: "before_today", Message: "生日必须早于今天"}
+	engine.PUT("/users/me", func(c *gin.Context) {
+		h.handleError(c, &service.ValidationError{Fields: []model.FieldError{fieldErr}})
+	})
+
+	// 执行
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/users/me", nil))
+
+	// 断言：与绑定校验失败的响应结构一致
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp struct {
+		Code int                    `json:"code"`
+		Data model.ValidationErrors `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, response.CodeValidationError, resp.Code)
+	assert.Equal(t, []model.FieldError{fieldErr}, resp.Data.Errors)
+}
+
+// ============================================================
+// ListUsers 执行计划测试
+// ============================================================
+
+// explainStubService 记录列表接口调用的是数据查询还是执行计划
+type explainStubService struct {
+	service.UserService
+	listCalled    bool
+	explainCalled bool
+}
+
+func (s *explainStubService) List(_ context.Context, _ *model.UserListRequest) ([]model.User, int64, error) {
+	s.listCalled = true
+	return []model.User{{Username: "alice"}}, 1, nil
+}
+
+func (s *explainStubService) ExplainList(_ context.Context, _ *model.UserListRequest) (*model.QueryPlan, error) {
+	s.explainCalled = true
+	return &model.QueryPlan{
+		SQL:  "SELECT * FROM `users` ORDER BY created_at desc,id desc LIMIT 20",
+		Plan: []map[string]interface{}{{"detail": "SCAN users"}},
+	}, nil
+}
+
+// listUsersWithExplain 以 explain=true 请求用户列表
+func listUsersWithExplain(t *testing.T, svc *explainStubService, opts ...UserHandlerOption) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	log, err := logger.New(&logger.Config{Level: "error", Format: "console"})
+	require.NoError(t, err)
+
+	engine := gin.New()
+	engine.GET("/users", NewUserHandler(svc, nil, log, opts...).ListUsers)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users?explain=true", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	return w
+}
+
+func TestListUsers_ExplainInDebugMode(t *testing.T) {
+	// 准备
+	svc := &explainStubService{}
+
+	// 执行
+	w := listUsersWithExplain(t, svc, WithQueryExplain())
+
+	// 断言：返回执行计划而不是用户数据
+	assert.True(t, svc.explainCalled)
+	assert.False(t, svc.listCalled)
+
+	var resp struct {
+		Data model.QueryPlan `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Contains(t, resp.Data.SQL, "FROM `users`")
+	assert.Equal(t, model.QueryPlanRows{{"detail": "SCAN users"}}, resp.Data.Plan)
+}
+
+func TestListUsers_ExplainIgnoredInReleaseMode(t *testing.T) {
+	// 准备
+	svc := &explainStubService{}
+
+	// 执行：未启用 WithQueryExplain（release 模式）
+	w := listUsersWithExplain(t, svc)
+
+	// 断言：忽略 explain 参数，照常返回列表
+	assert.False(t, svc.explainCalled)
+	assert.True(t, svc.listCalled)
+	assert.Contains(t, w.Body.String(), `"username":"alice"`)
+	assert.NotContains(t, w.Body.String(), "plan")
+}
+
+// ============================================================
+// cookie 认证测试
+// ============================================================
+
+// loginStubService 登录总是成功，返回固定令牌
+type loginStubService struct {
+	service.UserService
+}
+
+func (s *loginStubService) Login(_ context.Context, _ *model.LoginRequest, _ string) (*model.LoginResponse, error) {
+	return &model.LoginResponse{AccessToken: "access-token", RefreshToken: "refresh-token", TokenType: "Bearer", ExpiresIn: 7200}, nil
+}
+
+// performLogin 请求登录接口
+func performLogin(t *testing.T, opts ...UserHandlerOption) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	log, err := logger.New(&logger.Config{Level: "error", Format: "console"})
+	require.NoError(t, err)
+
+	engine := gin.New()
+	engine.POST("/auth/login", NewUserHandler(&loginStubService{}, nil, log, opts...).Login)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"username":"alice","password":"password123"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	return w
+}
+
+func TestLogin_SetsAuthCookie(t *testing.T) {
+	// 执行
+	w := performLogin(t, WithAuthCookie(config.AuthCookieConfig{
+		Enabled:  true,
+		Name:     "access_token",
+		Domain:   "example.com",
+		Path:     "/api",
+		Secure:   true,
+		SameSite: "strict",
+	}))
+
+	// 断言
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	cookie := cookies[0]
+	assert.Equal(t, "access_token", cookie.Name)
+	assert.Equal(t, "access-token", cookie.Value)
+	assert.Equal(t, "example.com", cookie.Domain)
+	assert.Equal(t, "/api", cookie.Path)
+	assert.Equal(t, 7200, cookie.MaxAge)
+	assert.True(t, cookie.Secure)
+	assert.True(t, cookie.HttpOnly)
+	assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+
+	// 响应体仍返回令牌，使用 Authorization 头的客户端不受影响
+	assert.Contains(t, w.Body.String(), `"access_token":"access-token"`)
+}
+
+func TestLogin_NoCookieWhenDisabled(t *testing.T) {
+	// 执行
+	w := performLogin(t)
+
+	// 断言
+	assert.Empty(t, w.Result().Cookies())
+}
+
+// ============================================================
+// 导入进度流式返回测试
+// ============================================================
+
+// importStubService 逐行回调进度，第 2 行失败
+type importStubService struct {
+	service.UserService
+}
+
+func (s *importStubService) ImportUsers(_ context.Context, _ string, _ io.Reader, progress service.BatchProgressFunc) (*model.UserImportResult, error) {
+	result := &model.UserImportResult{Total: 3, Errors: []model.UserImportError{}}
+	for i := 1; i <= 3; i++ {
+		p := model.BatchProgress{Processed: i, Total: 3}
+		if i == 2 {
+			result.Failed++
+			rowErr := model.UserImportError{Row: i + 1, Username: "bob", Message: "用户名已存在"}
+			result.Errors = append(result.Errors, rowErr)
+			p.Error = &rowErr
+		} else {
+			result.Created++
+		}
+		p.Succeeded, p.Failed = result.Created, result.Failed
+		if progress != nil {
+			progress(p)
+		}
+	}
+	return result, nil
+}
+
+// sseEvent 解析出的一个 SSE 事件
+type sseEvent struct {
+	name string
+	data string
+}
+
+// readEvents 逐行读取 SSE 流，直到连接关闭
+func readEvents(t *testing.T, r io.Reader) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var current sseEvent
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			current.name = strings.TrimPrefix(line, "event:")
+		case strings.HasPrefix(line, "data:"):
+			current.data = strings.TrimPrefix(line, "data:")
+		case line == "" && current.name != "":
+			events = append(events, current)
+			current = sseEvent{}
+		}
+	}
+	require.NoError(t, scanner.Err())
+	return events
+}
+
+func TestImportUsers_StreamsProgress(t *testing.T) {
+	// 准备
+	gin.SetMode(gin.TestMode)
+	log, err := logger.New(&logger.Config{Level: "error", Format: "console"})
+	require.NoError(t, err)
+	engine := gin.New()
+	engine.POST("/users/import", NewUserHandler(&importStubService{}, nil, log).ImportUsers)
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "users.csv")
+	require.NoError(t, err)
+	_, _ = part.Write([]byte("username,password\nalice,password1\nbob,password2\ncarol,password3\n"))
+	require.NoError(t, form.Close())
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/users/import", &body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Accept", ContentTypeEventStream)
+
+	// 执行
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// 断言：每行一个 progress 事件，最后是 done 事件
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), ContentTypeEventStream)
+	events := readEvents(t, resp.Body)
+	require.Len(t, events, 4)
+
+	for i, event := range events[:3] {
+		assert.Equal(t, EventProgress, event.name)
+		var p model.BatchProgress
+		require.NoError(t, json.Unmarshal([]byte(event.data), &p))
+		assert.Equal(t, i+1, p.Processed)
+		assert.Equal(t, 3, p.Total)
+		if i == 1 {
+			require.NotNil(t, p.Error)
+			assert.Equal(t, "bob", p.Error.Username)
+			assert.Equal(t, 1, p.Failed)
+		} else {
+			assert.Nil(t, p.Error)
+		}
+	}
+
+	assert.Equal(t, EventDone, events[3].name)
+	var result model.UserImportResult
+	require.NoError(t, json.Unmarshal([]byte(events[3].data), &result))
+	assert.Equal(t, 2, result.Created)
+	assert.Equal(t, 1, result.Failed)
+}
+
+// batchStubService 批量修改角色与状态时逐个用户回调进度
+type batchStubService struct {
+	service.UserService
+}
+
+func (s *batchStubService) BatchUpdateRole(_ context.Context, req *model.BatchUpdateRoleRequest, _ string, progress service.BatchProgressFunc) (*model.BatchUpdateRoleResponse, error) {
+	for i := range req.IDs {
+		if progress != nil {
+			progress(model.BatchProgress{Processed: i + 1, Total: len(req.IDs), Succeeded: i + 1})
+		}
+	}
+	return &model.BatchUpdateRoleResponse{Role: req.Role, Total: len(req.IDs), Updated: int64(len(req.IDs))}, nil
+}
+
+func (s *batchStubService) BatchUpdateStatus(_ context.Context, req *model.BatchUpdateStatusRequest, _ string, progress service.BatchProgressFunc) (*model.BatchUpdateStatusResponse, error) {
+	for i := range req.IDs {
+		if progress != nil {
+			progress(model.BatchProgress{Processed: i + 1, Total: len(req.IDs), Succeeded: i + 1})
+		}
+	}
+	return &model.BatchUpdateStatusResponse{Status: *req.Status, Total: len(req.IDs), Succeeded: len(req.IDs), Errors: []model.UserImportError{}}, nil
+}
+
+func TestBatchUpdate_StreamsProgress(t *testing.T) {
+	// 准备
+	gin.SetMode(gin.TestMode)
+	log, err := logger.New(&logger.Config{Level: "error", Format: "console"})
+	require.NoError(t, err)
+	h := NewUserHandler(&batchStubService{}, nil, log)
+	engine := gin.New()
+	engine.POST("/users/batch/role", h.BatchUpdateRole)
+	engine.POST("/users/batch/status", h.BatchUpdateStatus)
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/users/batch/role", `{"ids":["u1","u2","u3"],"role":"admin"}`},
+		{"/users/batch/status", `{"ids":["u1","u2","u3"],"status":0}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, server.URL+tt.path, strings.NewReader(tt.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", ContentTypeEventStream)
+
+			// 执行
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			// 断言：每个用户一个 progress 事件，最后是 done 事件
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			events := readEvents(t, resp.Body)
+			require.Len(t, events, 4)
+			for i, event := range events[:3] {
+				assert.Equal(t, EventProgress, event.name)
+				var p model.BatchProgress
+				require.NoError(t, json.Unmarshal([]byte(event.data), &p))
+				assert.Equal(t, i+1, p.Processed)
+				assert.Equal(t, 3, p.Total)
+			}
+			assert.Equal(t, EventDone, events[3].name)
+		})
+	}
+}
+
+// avatarStubService 记录是否调用了头像上传
+type avatarStubService struct {
+	service.UserService
+	called bool
+}
+
+func (s *avatarStubService) UploadAvatar(_ context.Context, id string, _ io.Reader) (*model.User, error) {
+	s.called = true
+	return &model.User{BaseModel: model.BaseModel{ID: id}}, nil
+}
+
+func TestUploadAvatar_RejectsOversizedBody(t *testing.T) {
+	// 准备：头像上限 1MB，上传 2MB 的文件
+	gin.SetMode(gin.TestMode)
+	log, err := logger.New(&logger.Config{Level: "error", Format: "console"})
+	require.NoError(t, err)
+	svc := &avatarStubService{}
+	engine := gin.New()
+	engine.POST("/users/me/avatar", func(c *gin.Context) {
+		c.Set(middleware.ContextKeyUserID, "u1")
+	}, NewUserHandler(svc, nil, log, WithAvatarMaxSize(1<<20)).UploadAvatar)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "avatar.png")
+	require.NoError(t, err)
+	_, _ = part.Write(bytes.Repeat([]byte{0}, 2<<20))
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/users/me/avatar", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+
+	// 执行
+	engine.ServeHTTP(w, req)
+
+	// 断言：读取请求体时即拒绝，不调用服务层
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "1MB")
+	assert.False(t, svc.called)
+}
@@ -0,0 +1,201 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件实现基于请求指纹的防刷限流。
+// 批量注册脚本常在同一出口 IP 下并发请求，仅按 IP 限流会误伤共享出口的正常用户，
+// 这里把 IP、User-Agent 与目标端点组合成指纹，对同一指纹的高频请求单独限制。
+// 每个响应都带 X-RateLimit-* 头告知剩余配额，被拒绝时另带 Retry-After。
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/example/go-user-api/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RateLimitLimitHeader 窗口内允许的最大请求数
+	RateLimitLimitHeader = "X-RateLimit-Limit"
+	// RateLimitRemainingHeader 当前窗口内剩余的请求数
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	// RateLimitResetHeader 配额恢复的时间点（Unix 秒），被封禁时为封禁结束时间
+	RateLimitResetHeader = "X-RateLimit-Reset"
+	// RetryAfterHeader 被拒绝后需要等待的秒数
+	RetryAfterHeader = "Retry-After"
+)
+
+// AntiabuseConfig 防刷限流配置
+type AntiabuseConfig struct {
+	// Window 统计窗口
+	Window time.Duration
+	// Threshold 窗口内同一指纹允许的最大请求数
+	Threshold int
+	// BlockDuration 超过阈值后该指纹被拒绝的时长，期间的请求不再计数
+	BlockDuration time.Duration
+}
+
+// fingerprintState 单个指纹的计数状态
+type fingerprintState struct {
+	windowStart  time.Time
+	count        int
+	blockedUntil time.Time
+}
+
+// rateLimitResult 一次请求的限流判定结果
+type rateLimitResult struct {
+	allowed    bool
+	limit      int
+	remaining  int
+	reset      time.Time
+	retryAfter time.Duration
+}
+
+// antiabuseLimiter 按指纹计数的固定窗口限流器
+type antiabuseLimiter struct {
+	cfg AntiabuseConfig
+	now func() time.Time
+
+	mu        sync.Mutex
+	states    map[string]*fingerprintState
+	lastPrune time.Time
+}
+
+// newAntiabuseLimiter 创建限流器
+func newAntiabuseLimiter(cfg AntiabuseConfig) *antiabuseLimiter {
+	return &antiabuseLimiter{
+		cfg:    cfg,
+		now:    time.Now,
+		states: make(map[string]*fingerprintState),
+	}
+}
+
+// allow 记录一次请求，返回判定结果与剩余配额，被限制时附带需要等待的时间
+func (l *antiabuseLimiter) allow(fingerprint string) rateLimitResult {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.pruneLocked(now)
+
+	st, ok := l.states[fingerprint]
+	if !ok {
+		st = &fingerprintState{windowStart: now}
+		l.states[fingerprint] = st
+	}
+
+	if now.Before(st.blockedUntil) {
+		return l.blockedResult(st, now)
+	}
+	if now.Sub(st.windowStart) >= l.cfg.Window {
+		st.windowStart = now
+		st.count = 0
+	}
+
+	st.count++
+	if st.count > l.cfg.Threshold {
+		st.blockedUntil = now.Add(l.cfg.BlockDuration)
+		st.windowStart = st.blockedUntil
+		st.count = 0
+		return l.blockedResult(st, now)
+	}
+	return rateLimitResult{
+		allowed:   true,
+		limit:     l.cfg.Threshold,
+		remaining: l.cfg.Threshold - st.count,
+		reset:     st.windowStart.Add(l.cfg.Window),
+	}
+}
+
+// blockedResult 封禁期内的判定结果，配额在封禁结束时恢复
+func (l *antiabuseLimiter) blockedResult(st *fingerprintState, now time.Time) rateLimitResult {
+	return rateLimitResult{
+		limit:      l.cfg.Threshold,
+		reset:      st.blockedUntil,
+		retryAfter: st.blockedUntil.Sub(now),
+	}
+}
+
+// pruneLocked 清理窗口与封禁都已过期的指纹，每个窗口最多清理一次，调用方需持有锁
+func (l *antiabuseLimiter) pruneLocked(now time.Time) {
+	if now.Sub(l.lastPrune) < l.cfg.Window {
+		return
+	}
+	l.lastPrune = now
+	for key, st := range l.states {
+		if now.Sub(st.windowStart) >= l.cfg.Window && !now.Before(st.blockedUntil) {
+			delete(l.states, key)
+		}
+	}
+}
+
+// requestFingerprint 由客户端 IP、User-Agent 与目标端点生成请求指纹
+// 端点使用路由模板（如 /users/:id），避免路径参数变化绕过限制
+func requestFingerprint(c *gin.Context) string {
+	endpoint := c.FullPath()
+	if endpoint == "" {
+		endpoint = c.Request.URL.Path
+	}
+	sum := sha256.Sum256([]byte(c.ClientIP() + "\n" + c.Request.UserAgent() + "\n" + c.Request.Method + " " + endpoint))
+	return hex.EncodeToString(sum[:])
+}
+
+// Antiabuse 请求指纹防刷中间件
+// 同一指纹（IP + User-Agent + 端点）在 Window 内的请求超过 Threshold 后，
+// 在 BlockDuration 内直接返回 429 并设置 Retry-After；不同 User-Agent 或端点互不影响。
+// 放行的请求同样带 X-RateLimit-Limit / Remaining / Reset，客户端可据此自行降速。
+// Threshold 或 Window 不大于 0 时不做限制。
+//
+// 使用示例：
+//
+//	authGroup.POST("/register", middleware.Antiabuse(middleware.AntiabuseConfig{
+//		Window:        time.Minute,
+//		Threshold:     5,
+//		BlockDuration: 10 * time.Minute,
+//	}, log), h.User.Register)
+func Antiabuse(cfg AntiabuseConfig, log logger.Logger) gin.HandlerFunc {
+	return antiabuse(newAntiabuseLimiter(cfg), log)
+}
+
+// antiabuse 使用指定限流器构建中间件，便于测试注入时钟
+func antiabuse(limiter *antiabuseLimiter, log logger.Logger) gin.HandlerFunc {
+	if limiter.cfg.Threshold <= 0 || limiter.cfg.Window <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		result := limiter.allow(requestFingerprint(c))
+		setRateLimitHeaders(c, result)
+		if result.allowed {
+			c.Next()
+			return
+		}
+
+		log.Warn("请求指纹触发防刷限制",
+			logger.String("request_id", GetRequestID(c)),
+			logger.String("client_ip", c.ClientIP()),
+			logger.String("user_agent", c.Request.UserAgent()),
+			logger.String("path", c.FullPath()),
+		)
+		c.Header(RetryAfterHeader, strconv.Itoa(ceilSeconds(result.retryAfter)))
+		response.AbortWithTooManyRequests(c, "")
+	}
+}
+
+// setRateLimitHeaders 写入剩余配额响应头，恢复时间向上取整到秒
+func setRateLimitHeaders(c *gin.Context, result rateLimitResult) {
+	c.Header(RateLimitLimitHeader, strconv.Itoa(result.limit))
+	c.Header(RateLimitRemainingHeader, strconv.Itoa(result.remaining))
+	c.Header(RateLimitResetHeader, strconv.FormatInt(result.reset.Add(time.Second-1).Unix(), 10))
+}
+
+// ceilSeconds 将时长向上取整为秒，避免客户端提前重试
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,148 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件包含请求指纹防刷中间件的单元测试
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// antiabuseTestEngine 构建挂载防刷中间件的引擎，返回可调整的时钟
+func antiabuseTestEngine(cfg AntiabuseConfig) (*gin.Engine, *time.Time) {
+	gin.SetMode(gin.TestMode)
+
+	now := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	limiter := newAntiabuseLimiter(cfg)
+	limiter.now = func() time.Time { return now }
+
+	engine := gin.New()
+	mw := antiabuse(limiter, &recordingLogger{})
+	engine.POST("/register", mw, func(c *gin.Context) { c.Status(http.StatusCreated) })
+	engine.POST("/login", mw, func(c *gin.Context) { c.Status(http.StatusOK) })
+	return engine, &now
+}
+
+// doAntiabuseRequest 以指定 IP 与 User-Agent 发送请求
+func doAntiabuseRequest(engine *gin.Engine, path, ip, userAgent string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	req.RemoteAddr = ip + ":12345"
+	req.Header.Set("User-Agent", userAgent)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestAntiabuse_SameFingerprintBlocked(t *testing.T) {
+	engine, now := antiabuseTestEngine(AntiabuseConfig{
+		Window:        time.Minute,
+		Threshold:     3,
+		BlockDuration: 10 * time.Minute,
+	})
+
+	for i := 0; i < 3; i++ {
+		w := doAntiabuseRequest(engine, "/register", "203.0.113.7", "bot/1.0")
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+
+	// 第 4 次超过阈值
+	w := doAntiabuseRequest(engine, "/register", "203.0.113.7", "bot/1.0")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "600", w.Header().Get("Retry-After"))
+
+	// 窗口结束后仍在封禁期内
+	*now = now.Add(2 * time.Minute)
+	w = doAntiabuseRequest(engine, "/register", "203.0.113.7", "bot/1.0")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "480", w.Header().Get("Retry-After"))
+
+	// 封禁结束后恢复
+	*now = now.Add(9 * time.Minute)
+	w = doAntiabuseRequest(engine, "/register", "203.0.113.7", "bot/1.0")
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestAntiabuse_OtherFingerprintsUnaffected(t *testing.T) {
+	engine, _ := antiabuseTestEngine(AntiabuseConfig{
+		Window:        time.Minute,
+		Threshold:     2,
+		BlockDuration: 10 * time.Minute,
+	})
+
+	// 脚本把自己的指纹刷到封禁
+	for i := 0; i < 3; i++ {
+		doAntiabuseRequest(engine, "/register", "203.0.113.7", "bot/1.0")
+	}
+	assert.Equal(t, http.StatusTooManyRequests, doAntiabuseRequest(engine, "/register", "203.0.113.7", "bot/1.0").Code)
+
+	// 同一出口 IP 下使用浏览器的正常用户不受影响
+	assert.Equal(t, http.StatusCreated, doAntiabuseRequest(engine, "/register", "203.0.113.7", "Mozilla/5.0").Code)
+	// 其他 IP 不受影响
+	assert.Equal(t, http.StatusCreated, doAntiabuseRequest(engine, "/register", "198.51.100.1", "bot/1.0").Code)
+	// 同一客户端访问其他端点不受影响
+	assert.Equal(t, http.StatusOK, doAntiabuseRequest(engine, "/login", "203.0.113.7", "bot/1.0").Code)
+}
+
+func TestAntiabuse_WindowResets(t *testing.T) {
+	engine, now := antiabuseTestEngine(AntiabuseConfig{
+		Window:        time.Minute,
+		Threshold:     2,
+		BlockDuration: 10 * time.Minute,
+	})
+
+	// 每个窗口内不超过阈值的正常频率不会被限制
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusCreated, doAntiabuseRequest(engine, "/register", "203.0.113.7", "Mozilla/5.0").Code)
+		assert.Equal(t, http.StatusCreated, doAntiabuseRequest(engine, "/register", "203.0.113.7", "Mozilla/5.0").Code)
+		*now = now.Add(time.Minute)
+	}
+}
+
+func TestAntiabuse_DisabledWhenThresholdZero(t *testing.T) {
+	engine, _ := antiabuseTestEngine(AntiabuseConfig{Window: time.Minute})
+
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, http.StatusCreated, doAntiabuseRequest(engine, "/register", "203.0.113.7", "bot/1.0").Code)
+	}
+}
+
+func TestAntiabuse_RateLimitHeaders(t *testing.T) {
+	// 准备
+	engine, now := antiabuseTestEngine(AntiabuseConfig{
+		Window:        time.Minute,
+		Threshold:     2,
+		BlockDuration: 10 * time.Minute,
+	})
+	windowReset := strconv.FormatInt(now.Add(time.Minute).Unix(), 10)
+
+	// 执行：未触发限制时返回剩余配额
+	w := doAntiabuseRequest(engine, "/register", "203.0.113.7", "bot/1.0")
+
+	// 断言
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "2", w.Header().Get(RateLimitLimitHeader))
+	assert.Equal(t, "1", w.Header().Get(RateLimitRemainingHeader))
+	assert.Equal(t, windowReset, w.Header().Get(RateLimitResetHeader))
+	assert.Empty(t, w.Header().Get(RetryAfterHeader))
+
+	w = doAntiabuseRequest(engine, "/register", "203.0.113.7", "bot/1.0")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "0", w.Header().Get(RateLimitRemainingHeader))
+
+	// 执行：触发限制
+	*now = now.Add(30 * time.Second)
+	w = doAntiabuseRequest(engine, "/register", "203.0.113.7", "bot/1.0")
+
+	// 断言：429 带 Retry-After，配额在封禁结束时恢复
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "600", w.Header().Get(RetryAfterHeader))
+	assert.Equal(t, "2", w.Header().Get(RateLimitLimitHeader))
+	assert.Equal(t, "0", w.Header().Get(RateLimitRemainingHeader))
+	assert.Equal(t, strconv.FormatInt(now.Add(10*time.Minute).Unix(), 10), w.Header().Get(RateLimitResetHeader))
+}
@@ -65,8 +65,32 @@ func (m *APIKeyMiddleware) RequireAPIKey() gin.HandlerFunc {
 	}
 }
 
+// RequireAdminAPIKey 返回需要管理 API Key 的中间件处理函数
+// 用于修改、删除等高权限操作，需放在 RequireAPIKey 之后；
+// API Key 有效但不在管理列表中时返回 403 Forbidden
+func (m *APIKeyMiddleware) RequireAdminAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey := c.GetHeader(APIKeyHeader)
+		if !containsKey(m.config.RiskReport.AdminAPIKeys, apiKey) {
+			m.log.Warn("API Key 无管理权限",
+				logger.String("path", c.Request.URL.Path),
+				logger.String("method", c.Request.Method),
+				logger.String("api_key_prefix", m.maskAPIKey(apiKey)),
+			)
+			response.AbortWithForbidden(c, "API Key 无权执行该操作")
+			return
+		}
+		c.Next()
+	}
+}
+
 // validateAPIKey 验证 API Key 是否有效
+// 管理 API Key 同样视为有效
 func (m *APIKeyMiddleware) validateAPIKey(apiKey string) bool {
+	if containsKey(m.config.RiskReport.AdminAPIKeys, apiKey) {
+		return true
+	}
+
 	// 从配置中获取有效的 API Keys
 	validKeys := m.config.RiskReport.APIKeys
 
@@ -86,6 +110,19 @@ func (m *APIKeyMiddleware) validateAPIKey(apiKey string) bool {
 	return false
 }
 
+// containsKey 检查 apiKey 是否在 keys 中，空 key 视为不存在
+func containsKey(keys []string, apiKey string) bool {
+	if apiKey == "" {
+		return false
+	}
+	for _, key := range keys {
+		if apiKey == key {
+			return true
+		}
+	}
+	return false
+}
+
 // maskAPIKey 遮蔽 API Key，只显示前几位（用于日志）
 func (m *APIKeyMiddleware) maskAPIKey(apiKey string) string {
 	if len(apiKey) <= 8 {
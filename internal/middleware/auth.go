@@ -2,15 +2,20 @@
 //
 // 本文件实现了 JWT 认证中间件，用于保护需要认证的 API 端点。
 // 中间件会从请求头中提取令牌，验证其有效性，并将用户信息注入到上下文中。
+// 个人访问令牌（PAT）只能访问以 RequireAuthScope 声明了授权范围的端点。
 package middleware
 
 import (
+	"context"
 	"strings"
 
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/internal/model"
 	"github.com/example/go-user-api/internal/service"
 	"github.com/example/go-user-api/pkg/errors"
 	"github.com/example/go-user-api/pkg/logger"
 	"github.com/example/go-user-api/pkg/response"
+	"github.com/example/go-user-api/pkg/tokenblacklist"
 	"github.com/gin-gonic/gin"
 )
 
@@ -20,6 +25,8 @@ const (
 	AuthorizationHeader = "Authorization"
 	// BearerPrefix Bearer 令牌前缀
 	BearerPrefix = "Bearer "
+	// RenewedTokenHeader 自动续签的新访问令牌响应头
+	RenewedTokenHeader = "X-Renewed-Token"
 	// ContextKeyUserID 用户 ID 上下文键
 	ContextKeyUserID = "userID"
 	// ContextKeyUsername 用户名上下文键
@@ -30,29 +37,123 @@ const (
 	ContextKeyUserEmail = "userEmail"
 	// ContextKeyClaims 完整令牌声明上下文键
 	ContextKeyClaims = "claims"
+	// ContextKeyImpersonatedBy 模拟登录真实操作者 ID 上下文键
+	ContextKeyImpersonatedBy = "impersonatedBy"
 )
 
+// TokenVersionValidator 令牌版本校验器
+// 用于判断令牌是否因用户被强制下线而失效
+type TokenVersionValidator interface {
+	// ValidateTokenVersion 校验令牌版本是否与用户当前版本一致
+	ValidateTokenVersion(ctx context.Context, userID string, version int) error
+}
+
+// TokenRenewer 访问令牌续签器
+// 续签时从数据库重新读取用户的角色与额外声明，避免旧令牌中的权限被无限延续
+type TokenRenewer interface {
+	// RenewAccessToken 为即将过期的访问令牌签发新令牌
+	RenewAccessToken(ctx context.Context, claims *service.TokenClaims) (string, error)
+}
+
+// ActivityRecorder 用户活跃记录器
+// 用于维护用户最近活跃时间，支撑在线状态查询
+type ActivityRecorder interface {
+	// RecordActivity 记录用户活跃
+	RecordActivity(ctx context.Context, userID string)
+}
+
+// PersonalAccessTokenAuthenticator 个人访问令牌认证器
+type PersonalAccessTokenAuthenticator interface {
+	// Authenticate 校验令牌，返回令牌所属用户与令牌记录
+	Authenticate(ctx context.Context, token string) (*model.User, *model.PersonalAccessToken, error)
+}
+
 // AuthMiddleware 认证中间件
 // 验证请求中的 JWT 令牌，并将用户信息注入到上下文中
 type AuthMiddleware struct {
-	jwtService service.JWTService
-	log        logger.Logger
+	jwtService       service.JWTService
+	versionValidator TokenVersionValidator
+	renewer          TokenRenewer
+	activityRecorder ActivityRecorder
+	patAuthenticator PersonalAccessTokenAuthenticator
+	tokenBlacklist   tokenblacklist.TokenBlacklist
+	cookie           *config.AuthCookieConfig
+	log              logger.Logger
+}
+
+// AuthOption 认证中间件的可选配置
+type AuthOption func(*AuthMiddleware)
+
+// WithTokenVersionValidator 设置令牌版本校验器
+// 设置后每次认证都会比对令牌版本，支持强制下线
+func WithTokenVersionValidator(v TokenVersionValidator) AuthOption {
+	return func(m *AuthMiddleware) {
+		m.versionValidator = v
+	}
+}
+
+// WithTokenRenewer 设置访问令牌续签器
+// 未设置时不自动续签
+func WithTokenRenewer(r TokenRenewer) AuthOption {
+	return func(m *AuthMiddleware) {
+		m.renewer = r
+	}
+}
+
+// WithActivityRecorder 设置用户活跃记录器
+// 设置后每次认证通过都会记录用户活跃；模拟令牌的请求不计为用户本人活跃
+func WithActivityRecorder(r ActivityRecorder) AuthOption {
+	return func(m *AuthMiddleware) {
+		m.activityRecorder = r
+	}
+}
+
+// WithPersonalAccessTokens 设置个人访问令牌认证器
+// 未设置时 RequireAuthScope 只接受 JWT
+func WithPersonalAccessTokens(a PersonalAccessTokenAuthenticator) AuthOption {
+	return func(m *AuthMiddleware) {
+		m.patAuthenticator = a
+	}
+}
+
+// WithTokenBlacklist 设置令牌黑名单
+// 设置后 jti 在黑名单中的访问令牌（如已登出）视为已撤销
+func WithTokenBlacklist(bl tokenblacklist.TokenBlacklist) AuthOption {
+	return func(m *AuthMiddleware) {
+		m.tokenBlacklist = bl
+	}
 }
 
 // NewAuthMiddleware 创建认证中间件实例
 // 参数：
 //   - jwtService: JWT 服务实例
 //   - log: 日志记录器
-func NewAuthMiddleware(jwtService service.JWTService, log logger.Logger) *AuthMiddleware {
-	return &AuthMiddleware{
+//   - opts: 可选配置
+func NewAuthMiddleware(jwtService service.JWTService, log logger.Logger, opts ...AuthOption) *AuthMiddleware {
+	m := &AuthMiddleware{
 		jwtService: jwtService,
 		log:        log.With(logger.String("middleware", "auth")),
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // RequireAuth 返回需要认证的中间件处理函数
-// 如果认证失败，返回 401 Unauthorized 响应并中止请求
+// 如果认证失败，返回 401 Unauthorized 响应并中止请求；个人访问令牌返回 403
 func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
+	return m.requireAuth("")
+}
+
+// RequireAuthScope 返回需要认证的中间件处理函数，除 JWT 外还接受包含 scope 授权范围的个人访问令牌
+// scope 为权限标识（如 profile:read），令牌所属用户当前不再拥有该权限时同样拒绝
+func (m *AuthMiddleware) RequireAuthScope(scope string) gin.HandlerFunc {
+	return m.requireAuth(scope)
+}
+
+// requireAuth 认证处理函数，scope 为空时不接受个人访问令牌
+func (m *AuthMiddleware) requireAuth(scope string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从请求头获取令牌
 		token, err := m.extractToken(c)
@@ -65,6 +166,12 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
+		// 个人访问令牌走独立的校验流程
+		if service.IsPersonalAccessToken(token) {
+			m.authenticatePersonalAccessToken(c, token, scope)
+			return
+		}
+
 		// 验证令牌
 		claims, err := m.validateToken(token)
 		if err != nil {
@@ -86,8 +193,27 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
+		// 检查令牌是否已撤销（用户被强制下线或已登出）
+		if appErr := m.validateNotRevoked(c, claims); appErr != nil {
+			m.log.Debug("令牌撤销校验失败",
+				logger.String("path", c.Request.URL.Path),
+				logger.String("user_id", claims.UserID),
+				logger.Err(appErr),
+			)
+			if errors.Is(appErr, errors.ErrServiceUnavailable) {
+				response.Abort(c, appErr.HTTPStatus, appErr.Code, appErr.Message)
+				return
+			}
+			response.AbortWithUnauthorized(c, appErr.Message)
+			return
+		}
+
 		// 将用户信息注入到上下文中
 		m.setContextValues(c, claims)
+		m.recordActivity(c, claims)
+
+		// 令牌接近过期时下发续签令牌
+		m.renewIfNeeded(c, claims)
 
 		// 继续处理请求
 		c.Next()
@@ -121,14 +247,77 @@ func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 			return
 		}
 
+		// 令牌已被撤销时视同未认证
+		if m.validateNotRevoked(c, claims) != nil {
+			c.Next()
+			return
+		}
+
 		// 将用户信息注入到上下文中
 		m.setContextValues(c, claims)
+		m.recordActivity(c, claims)
 
 		// 继续处理请求
 		c.Next()
 	}
 }
 
+// authenticatePersonalAccessToken 校验个人访问令牌并检查授权范围
+// 令牌无效返回 401；端点不接受 PAT 或令牌缺少授权范围返回 403
+func (m *AuthMiddleware) authenticatePersonalAccessToken(c *gin.Context, token, scope string) {
+	if scope == "" || m.patAuthenticator == nil {
+		response.AbortWithForbidden(c, "该接口不支持个人访问令牌")
+		return
+	}
+
+	user, record, err := m.patAuthenticator.Authenticate(c.Request.Context(), token)
+	if err != nil {
+		m.log.Debug("个人访问令牌校验失败",
+			logger.String("path", c.Request.URL.Path),
+			logger.Err(err),
+		)
+		appErr := errors.AsAppError(err)
+		if appErr == nil || appErr.HTTPStatus >= 500 {
+			c.Abort()
+			response.InternalError(c, "")
+			return
+		}
+		response.AbortWithUnauthorized(c, appErr.Message)
+		return
+	}
+
+	if !record.HasScope(scope) || !hasPermission(user, scope) {
+		m.log.Debug("个人访问令牌授权范围不足",
+			logger.String("path", c.Request.URL.Path),
+			logger.String("token_id", record.ID),
+			logger.String("required_scope", scope),
+		)
+		response.AbortWithForbidden(c, "个人访问令牌缺少授权范围: "+scope)
+		return
+	}
+
+	claims := &service.TokenClaims{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Role:      user.Role,
+		TokenType: service.TokenTypePersonalAccess,
+	}
+	m.setContextValues(c, claims)
+	m.recordActivity(c, claims)
+	c.Next()
+}
+
+// hasPermission 检查用户当前角色是否拥有权限
+func hasPermission(user *model.User, permission string) bool {
+	for _, p := range user.Permissions() {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
 // RequireRole 返回需要特定角色的中间件处理函数
 // 必须在 RequireAuth 之后使用
 // 参数 roles 是允许访问的角色列表
@@ -171,11 +360,31 @@ func (m *AuthMiddleware) RequireAdmin() gin.HandlerFunc {
 	return m.RequireRole("admin")
 }
 
-// extractToken 从请求头中提取令牌
+// DenyImpersonation 返回禁止模拟令牌访问的中间件处理函数
+// 用于修改密码等敏感操作，必须在 RequireAuth 之后使用
+func (m *AuthMiddleware) DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if operatorID := GetImpersonatedBy(c); operatorID != "" {
+			m.log.Warn("模拟令牌尝试执行敏感操作",
+				logger.String("path", c.Request.URL.Path),
+				logger.String("user_id", GetUserID(c)),
+				logger.String("impersonated_by", operatorID),
+			)
+			response.AbortWithForbidden(c, "模拟登录不能执行此操作")
+			return
+		}
+		c.Next()
+	}
+}
+
+// extractToken 从请求头中提取令牌，未携带 Authorization 头且启用了 cookie 认证时从 cookie 读取
 func (m *AuthMiddleware) extractToken(c *gin.Context) (string, *errors.AppError) {
-	// 获取 Authorization 头
+	// 获取 Authorization 头，缺失时回退到 cookie
 	authHeader := c.GetHeader(AuthorizationHeader)
 	if authHeader == "" {
+		if token := m.cookieToken(c); token != "" {
+			return token, nil
+		}
 		return "", errors.ErrTokenNotFound
 	}
 
@@ -205,6 +414,66 @@ func (m *AuthMiddleware) validateToken(token string) (*service.TokenClaims, *err
 	return claims, nil
 }
 
+// validateNotRevoked 校验令牌未被撤销
+// 依次检查令牌黑名单与令牌版本，未设置的校验直接通过；
+// 黑名单返回错误（仅在配置了 fail_closed 时）表示无法确认令牌状态，返回 ErrServiceUnavailable 拒绝请求，客户端可稍后重试
+func (m *AuthMiddleware) validateNotRevoked(c *gin.Context, claims *service.TokenClaims) *errors.AppError {
+	if m.tokenBlacklist != nil && claims.ID != "" {
+		revoked, err := m.tokenBlacklist.IsRevoked(c.Request.Context(), claims.ID)
+		if err != nil {
+			m.log.Error("查询令牌黑名单失败", logger.String("jti", claims.ID), logger.Err(err))
+			return errors.ErrServiceUnavailable.WithError(err)
+		}
+		if revoked {
+			return errors.ErrTokenRevoked
+		}
+	}
+
+	if m.versionValidator == nil {
+		return nil
+	}
+	if err := m.versionValidator.ValidateTokenVersion(c.Request.Context(), claims.UserID, claims.TokenVersion); err != nil {
+		if appErr := errors.AsAppError(err); appErr != nil {
+			return appErr
+		}
+		return errors.ErrInvalidToken.WithError(err)
+	}
+	return nil
+}
+
+// recordActivity 记录用户活跃
+// 未设置记录器或使用模拟令牌时跳过
+func (m *AuthMiddleware) recordActivity(c *gin.Context, claims *service.TokenClaims) {
+	if m.activityRecorder == nil || claims.IsImpersonated() {
+		return
+	}
+	m.activityRecorder.RecordActivity(c.Request.Context(), claims.UserID)
+}
+
+// renewIfNeeded 在访问令牌剩余有效期低于阈值时签发新令牌
+// 新令牌通过 X-Renewed-Token 响应头返回，续签失败不影响本次请求；模拟令牌不续签
+func (m *AuthMiddleware) renewIfNeeded(c *gin.Context, claims *service.TokenClaims) {
+	threshold := m.jwtService.GetRenewThreshold()
+	if m.renewer == nil || threshold <= 0 || claims.IsImpersonated() || claims.TimeToExpire() > threshold {
+		return
+	}
+
+	token, err := m.renewer.RenewAccessToken(c.Request.Context(), claims)
+	if err != nil {
+		m.log.Warn("续签访问令牌失败",
+			logger.String("user_id", claims.UserID),
+			logger.Err(err),
+		)
+		return
+	}
+	c.Header(RenewedTokenHeader, token)
+
+	// HttpOnly cookie 无法由前端脚本更新，令牌来自 cookie 时由服务端写回
+	if m.cookie != nil && c.GetHeader(AuthorizationHeader) == "" {
+		SetAuthCookie(c, *m.cookie, token, m.jwtService.GetAccessTokenExpiration())
+	}
+}
+
 // setContextValues 将用户信息设置到上下文中
 func (m *AuthMiddleware) setContextValues(c *gin.Context, claims *service.TokenClaims) {
 	c.Set(ContextKeyUserID, claims.UserID)
@@ -212,6 +481,12 @@ func (m *AuthMiddleware) setContextValues(c *gin.Context, claims *service.TokenC
 	c.Set(ContextKeyUserRole, claims.Role)
 	c.Set(ContextKeyUserEmail, claims.Email)
 	c.Set(ContextKeyClaims, claims)
+	ctx := logger.ContextWithUserID(c.Request.Context(), claims.UserID)
+	if claims.IsImpersonated() {
+		c.Set(ContextKeyImpersonatedBy, claims.ImpersonatedBy)
+		ctx = logger.ContextWithImpersonator(ctx, claims.ImpersonatedBy)
+	}
+	c.Request = c.Request.WithContext(ctx)
 }
 
 // GetUserID 从上下文中获取用户 ID
@@ -280,6 +555,12 @@ func GetClaims(c *gin.Context) *service.TokenClaims {
 	return tokenClaims
 }
 
+// GetImpersonatedBy 从上下文中获取模拟登录的真实操作者 ID
+// 非模拟登录请求返回空字符串
+func GetImpersonatedBy(c *gin.Context) string {
+	return c.GetString(ContextKeyImpersonatedBy)
+}
+
 // IsAuthenticated 检查请求是否已认证
 func IsAuthenticated(c *gin.Context) bool {
 	return GetUserID(c) != ""
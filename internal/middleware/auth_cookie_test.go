@@ -0,0 +1,101 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件包含基于 cookie 认证的单元测试
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAuthCookie 测试用 cookie 配置
+var testAuthCookie = config.AuthCookieConfig{
+	Enabled:  true,
+	Name:     "access_token",
+	Path:     "/",
+	Secure:   true,
+	SameSite: "strict",
+}
+
+// performCookieAuthRequest 把访问令牌放在 cookie 中请求受保护的端点
+func performCookieAuthRequest(t *testing.T, jwtCfg *config.JWTConfig, opts ...AuthOption) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	jwtService := service.NewJWTService(jwtCfg)
+	user := &model.User{Username: "alice", Role: model.RoleUser}
+	user.ID = "user-1"
+	token, err := jwtService.GenerateAccessToken(user)
+	require.NoError(t, err)
+
+	opts = append([]AuthOption{WithTokenRenewer(&stubRenewer{jwtService: jwtService, user: user})}, opts...)
+	auth := NewAuthMiddleware(jwtService, newTestLogger(), opts...)
+	engine := gin.New()
+	engine.GET("/protected", auth.RequireAuth(), func(c *gin.Context) {
+		c.String(http.StatusOK, GetUserID(c))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.AddCookie(&http.Cookie{Name: testAuthCookie.Name, Value: token})
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+// testCookieJWTConfig 令牌有效期 24 小时、续签阈值 30 分钟的 JWT 配置
+func testCookieJWTConfig() *config.JWTConfig {
+	return &config.JWTConfig{
+		Secret:             "test-secret-key-at-least-32-characters",
+		AccessTokenExpire:  24,
+		RefreshTokenExpire: 168,
+		RenewThreshold:     30,
+	}
+}
+
+func TestRequireAuth_TokenFromCookie(t *testing.T) {
+	// 执行
+	w := performCookieAuthRequest(t, testCookieJWTConfig(), WithAuthCookie(testAuthCookie))
+
+	// 断言
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "user-1", w.Body.String())
+	assert.Empty(t, w.Header().Values("Set-Cookie"))
+}
+
+func TestRequireAuth_CookieIgnoredWhenDisabled(t *testing.T) {
+	// 执行：未启用 cookie 认证
+	w := performCookieAuthRequest(t, testCookieJWTConfig())
+
+	// 断言
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestRequireAuth_RenewedTokenWrittenBackToCookie(t *testing.T) {
+	// 准备：令牌有效期 1 小时，续签阈值 2 小时，请求时已处于续签窗口内
+	jwtCfg := testCookieJWTConfig()
+	jwtCfg.AccessTokenExpire = 1
+	jwtCfg.RenewThreshold = 120
+
+	// 执行
+	w := performCookieAuthRequest(t, jwtCfg, WithAuthCookie(testAuthCookie))
+
+	// 断言
+	require.Equal(t, http.StatusOK, w.Code)
+	renewed := w.Header().Get(RenewedTokenHeader)
+	require.NotEmpty(t, renewed)
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "access_token", cookies[0].Name)
+	assert.Equal(t, renewed, cookies[0].Value)
+	assert.Equal(t, 3600, cookies[0].MaxAge)
+	assert.True(t, cookies[0].HttpOnly)
+}
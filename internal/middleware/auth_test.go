@@ -0,0 +1,382 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件包含认证中间件的单元测试
+package middleware
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/service"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLogger 创建测试用日志记录器
+func newTestLogger() logger.Logger {
+	log, _ := logger.New(&logger.Config{
+		Level:  "debug",
+		Format: "console",
+	})
+	return log
+}
+
+// stubRenewer 以预置的用户当前信息续签令牌，模拟从数据库重新读取用户
+type stubRenewer struct {
+	jwtService service.JWTService
+	user       *model.User
+}
+
+func (r *stubRenewer) RenewAccessToken(_ context.Context, claims *service.TokenClaims) (string, error) {
+	return r.jwtService.RenewAccessToken(claims, r.user, nil)
+}
+
+// performAuthRequest 使用给定的 JWT 配置签发访问令牌，并请求受保护的端点
+// current 不为 nil 时作为续签时从数据库读取到的用户当前信息
+func performAuthRequest(t *testing.T, jwtCfg *config.JWTConfig, current ...*model.User) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	jwtService := service.NewJWTService(jwtCfg)
+	user := &model.User{Username: "alice", Email: "alice@example.com", Role: model.RoleUser}
+	user.ID = "user-1"
+	token, err := jwtService.GenerateAccessToken(user)
+	require.NoError(t, err)
+
+	renewer := &stubRenewer{jwtService: jwtService, user: user}
+	if len(current) > 0 {
+		renewer.user = current[0]
+	}
+	auth := NewAuthMiddleware(jwtService, newTestLogger(), WithTokenRenewer(renewer))
+	engine := gin.New()
+	engine.GET("/protected", auth.RequireAuth(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set(AuthorizationHeader, BearerPrefix+token)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequireAuth_RenewsTokenNearExpiry(t *testing.T) {
+	// 令牌有效期 1 小时，续签阈值 2 小时：请求时已处于续签窗口内
+	w := performAuthRequest(t, &config.JWTConfig{
+		Secret:             "test-secret-key-at-least-32-characters",
+		Issuer:             "test-issuer",
+		AccessTokenExpire:  1,
+		RefreshTokenExpire: 168,
+		RenewThreshold:     120,
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	renewed := w.Header().Get(RenewedTokenHeader)
+	require.NotEmpty(t, renewed)
+
+	// 续签的令牌应是有效的访问令牌，且保留原用户信息
+	claims, err := service.NewJWTService(&config.JWTConfig{
+		Secret: "test-secret-key-at-least-32-characters",
+	}).ValidateToken(renewed)
+	require.NoError(t, err)
+	assert.True(t, claims.IsAccessToken())
+	assert.Equal(t, "user-1", claims.UserID)
+	assert.Equal(t, "alice", claims.Username)
+}
+
+func TestRequireAuth_RenewUsesCurrentRole(t *testing.T) {
+	// 准备：令牌签发后用户被提升为管理员，续签时读取到的是当前角色
+	jwtCfg := &config.JWTConfig{
+		Secret:             "test-secret-key-at-least-32-characters",
+		AccessTokenExpire:  1,
+		RefreshTokenExpire: 24,
+		RenewThreshold:     120,
+	}
+	current := &model.User{Username: "alice", Email: "alice@example.com", Role: model.RoleAdmin}
+	current.ID = "user-1"
+
+	// 执行
+	w := performAuthRequest(t, jwtCfg, current)
+
+	// 断言：续签的令牌使用当前角色，而不是沿用原令牌中的角色
+	renewed := w.Header().Get(RenewedTokenHeader)
+	require.NotEmpty(t, renewed)
+	claims, err := service.NewJWTService(jwtCfg).ValidateToken(renewed)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, claims.Role)
+}
+
+func TestRequireAuth_ExtraClaimsAvailableFromGetClaims(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtService := service.NewJWTService(&config.JWTConfig{
+		Secret:            "test-secret-key-at-least-32-characters",
+		AccessTokenExpire: 24,
+	})
+	user := &model.User{Username: "alice", Role: model.RoleUser}
+	user.ID = "user-1"
+	token, err := jwtService.GenerateAccessTokenWithClaims(user, map[string]interface{}{
+		"department": "risk",
+		"level":      3,
+	})
+	require.NoError(t, err)
+
+	var claims *service.TokenClaims
+	engine := gin.New()
+	engine.GET("/protected", NewAuthMiddleware(jwtService, newTestLogger()).RequireAuth(), func(c *gin.Context) {
+		claims = GetClaims(c)
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set(AuthorizationHeader, BearerPrefix+token)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, claims)
+	department, ok := claims.Claim("department")
+	assert.True(t, ok)
+	assert.Equal(t, "risk", department)
+	// 数字经 JSON 解码后为 float64
+	assert.Equal(t, float64(3), claims.Extra["level"])
+	_, ok = claims.Claim("missing")
+	assert.False(t, ok)
+}
+
+func TestRequireAuth_NoRenewWhenFarFromExpiry(t *testing.T) {
+	// 令牌有效期 24 小时，续签阈值 30 分钟：无需续签
+	w := performAuthRequest(t, &config.JWTConfig{
+		Secret:            "test-secret-key-at-least-32-characters",
+		Issuer:            "test-issuer",
+		AccessTokenExpire: 24,
+		RenewThreshold:    30,
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(RenewedTokenHeader))
+}
+
+func TestRequireAuth_RenewDisabled(t *testing.T) {
+	// 阈值为 0 时关闭续签
+	w := performAuthRequest(t, &config.JWTConfig{
+		Secret:            "test-secret-key-at-least-32-characters",
+		Issuer:            "test-issuer",
+		AccessTokenExpire: 1,
+		RenewThreshold:    0,
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(RenewedTokenHeader))
+}
+
+// stubVersionValidator 以固定的当前版本校验令牌
+type stubVersionValidator struct {
+	current int
+}
+
+func (v *stubVersionValidator) ValidateTokenVersion(_ context.Context, _ string, version int) error {
+	if version != v.current {
+		return errors.ErrTokenRevoked
+	}
+	return nil
+}
+
+func TestRequireAuth_RejectsRevokedTokenVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jwtCfg := &config.JWTConfig{
+		Secret:            "test-secret-key-at-least-32-characters",
+		AccessTokenExpire: 24,
+	}
+	jwtService := service.NewJWTService(jwtCfg)
+	user := &model.User{Username: "alice", Role: model.RoleUser}
+	user.ID = "user-1"
+	oldToken, err := jwtService.GenerateAccessToken(user)
+	require.NoError(t, err)
+
+	// 强制下线后版本递增，重新签发的令牌携带新版本
+	validator := &stubVersionValidator{current: 1}
+	user.TokenVersion = 1
+	newToken, err := jwtService.GenerateAccessToken(user)
+	require.NoError(t, err)
+
+	auth := NewAuthMiddleware(jwtService, newTestLogger(), WithTokenVersionValidator(validator))
+	engine := gin.New()
+	engine.GET("/protected", auth.RequireAuth(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set(AuthorizationHeader, BearerPrefix+token)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, request(oldToken))
+	assert.Equal(t, http.StatusOK, request(newToken))
+}
+
+func TestDenyImpersonation_BlocksPasswordChange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// 续签阈值大于有效期：普通令牌会续签，模拟令牌不应续签
+	jwtService := service.NewJWTService(&config.JWTConfig{
+		Secret:             "test-secret-key-at-least-32-characters",
+		AccessTokenExpire:  1,
+		RefreshTokenExpire: 168,
+		RenewThreshold:     120,
+	})
+	user := &model.User{Username: "alice", Role: model.RoleUser}
+	user.ID = "user-1"
+	impersonationToken, _, err := jwtService.GenerateImpersonationToken(user, "admin-1", 15*time.Minute)
+	require.NoError(t, err)
+	normalToken, err := jwtService.GenerateAccessToken(user)
+	require.NoError(t, err)
+
+	auth := NewAuthMiddleware(jwtService, newTestLogger(), WithTokenRenewer(&stubRenewer{jwtService: jwtService, user: user}))
+	var impersonatedBy string
+	engine := gin.New()
+	engine.GET("/me", auth.RequireAuth(), func(c *gin.Context) {
+		impersonatedBy = GetImpersonatedBy(c)
+		c.Status(http.StatusOK)
+	})
+	engine.PUT("/me/password", auth.RequireAuth(), auth.DenyImpersonation(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	perform := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(AuthorizationHeader, BearerPrefix+token)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	// 模拟令牌可以查看，上下文中带真实操作者，且不续签
+	w := perform(http.MethodGet, "/me", impersonationToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "admin-1", impersonatedBy)
+	assert.Empty(t, w.Header().Get(RenewedTokenHeader))
+
+	// 模拟令牌不能修改密码
+	w = perform(http.MethodPut, "/me/password", impersonationToken)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 普通令牌不受影响
+	w = perform(http.MethodPut, "/me/password", normalToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = perform(http.MethodGet, "/me", normalToken)
+	assert.Empty(t, impersonatedBy)
+	assert.NotEmpty(t, w.Header().Get(RenewedTokenHeader))
+}
+
+// stubPATAuthenticator 按令牌明文返回预置的用户与令牌记录
+type stubPATAuthenticator struct {
+	user   *model.User
+	tokens map[string]*model.PersonalAccessToken
+}
+
+func (a *stubPATAuthenticator) Authenticate(_ context.Context, token string) (*model.User, *model.PersonalAccessToken, error) {
+	record, ok := a.tokens[token]
+	if !ok {
+		return nil, nil, errors.ErrInvalidToken
+	}
+	return a.user, record, nil
+}
+
+func TestRequireAuthScope_PersonalAccessToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := &model.User{Username: "alice", Role: model.RoleAdmin}
+	user.ID = "user-1"
+	authenticator := &stubPATAuthenticator{
+		user: user,
+		tokens: map[string]*model.PersonalAccessToken{
+			"pat_list": {Scopes: model.PermissionProfileRead + " " + model.PermissionUsersList},
+		},
+	}
+	auth := NewAuthMiddleware(service.NewJWTService(&config.JWTConfig{
+		Secret: "test-secret-key-at-least-32-characters",
+	}), newTestLogger(), WithPersonalAccessTokens(authenticator))
+
+	var claims *service.TokenClaims
+	engine := gin.New()
+	ok := func(c *gin.Context) {
+		claims = GetClaims(c)
+		c.Status(http.StatusOK)
+	}
+	engine.GET("/users", auth.RequireAuthScope(model.PermissionUsersList), ok)
+	engine.DELETE("/users", auth.RequireAuthScope(model.PermissionUsersDelete), ok)
+	engine.PUT("/password", auth.RequireAuth(), ok)
+
+	request := func(method, path, token string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(AuthorizationHeader, BearerPrefix+token)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 授权范围内
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/users", "pat_list"))
+	require.NotNil(t, claims)
+	assert.Equal(t, "user-1", claims.UserID)
+	assert.Equal(t, service.TokenTypePersonalAccess, claims.TokenType)
+
+	// 缺少授权范围、端点不接受 PAT、令牌无效
+	assert.Equal(t, http.StatusForbidden, request(http.MethodDelete, "/users", "pat_list"))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodPut, "/password", "pat_list"))
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "/users", "pat_unknown"))
+
+	// 用户降级后不再拥有的权限，即使令牌包含该授权范围也拒绝
+	user.Role = model.RoleUser
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/users", "pat_list"))
+}
+
+// downBlacklist 始终查询失败的令牌黑名单，模拟 Redis 不可用
+type downBlacklist struct{}
+
+func (downBlacklist) Revoke(context.Context, string, time.Time) error {
+	return stderrors.New("连接超时")
+}
+
+func (downBlacklist) IsRevoked(context.Context, string) (bool, error) {
+	return false, stderrors.New("连接超时")
+}
+
+func TestRequireAuth_BlacklistUnavailableReturns503(t *testing.T) {
+	// 准备
+	gin.SetMode(gin.TestMode)
+	jwtService := service.NewJWTService(&config.JWTConfig{
+		Secret:            "test-secret-key-at-least-32-characters",
+		AccessTokenExpire: 60,
+	})
+	user := &model.User{Username: "alice", Role: model.RoleUser}
+	user.ID = "user-1"
+	token, err := jwtService.GenerateAccessToken(user)
+	require.NoError(t, err)
+
+	auth := NewAuthMiddleware(jwtService, newTestLogger(), WithTokenBlacklist(downBlacklist{}))
+	engine := gin.New()
+	engine.GET("/protected", auth.RequireAuth(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// 执行
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set(AuthorizationHeader, BearerPrefix+token)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	// 断言：无法确认令牌状态时不当作未授权，客户端可重试
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
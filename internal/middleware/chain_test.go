@@ -0,0 +1,57 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件包含中间件链构建器的单元测试
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingMiddleware 返回记录执行顺序的中间件
+func recordingMiddleware(name string, order *[]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		*order = append(*order, name)
+		c.Next()
+	}
+}
+
+func TestMiddlewareChain_AppliesInDeclaredOrder(t *testing.T) {
+	// 准备
+	var order []string
+	chain := NewMiddlewareChain().
+		Use("first", recordingMiddleware("first", &order)).
+		UseIf(false, "skipped", func() gin.HandlerFunc { return recordingMiddleware("skipped", &order) }).
+		UseIf(true, "second", func() gin.HandlerFunc { return recordingMiddleware("second", &order) }).
+		Use("third", recordingMiddleware("third", &order))
+
+	router := gin.New()
+	chain.Apply(router)
+	router.GET("/test", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// 执行
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	// 断言
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"first", "second", "third"}, chain.Names())
+	assert.Equal(t, []string{"first", "second", "third"}, order)
+	assert.Equal(t, 1, chain.Index("second"))
+	assert.Equal(t, -1, chain.Index("skipped"))
+}
+
+func TestMiddlewareChain_RejectsDuplicateName(t *testing.T) {
+	chain := NewMiddlewareChain().Use("recovery", func(c *gin.Context) {})
+
+	assert.Panics(t, func() {
+		chain.Use("recovery", func(c *gin.Context) {})
+	})
+	assert.Panics(t, func() {
+		chain.Use("", func(c *gin.Context) {})
+	})
+}
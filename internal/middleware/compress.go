@@ -0,0 +1,295 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件包含响应压缩中间件。
+// 按 Accept-Encoding 协商编码：优先 Brotli（br），其次 gzip，都不接受时不压缩。
+// 响应体先缓冲到阈值大小再决定是否压缩，过小的响应原样输出。
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// encodingBrotli Brotli 编码名
+	encodingBrotli = "br"
+	// encodingGzip gzip 编码名
+	encodingGzip = "gzip"
+
+	// brotliLevel Brotli 压缩级别，动态响应使用中等级别，兼顾压缩率与 CPU 开销
+	brotliLevel = 5
+)
+
+// supportedEncodings 服务端支持的编码，按优先级排列（q 值相同时靠前的优先）
+var supportedEncodings = []string{encodingBrotli, encodingGzip}
+
+// compressibleTypes 可压缩的 Content-Type 前缀
+// 图片、压缩包、xlsx 等本身已压缩的格式再压缩没有收益
+var compressibleTypes = []string{
+	"text/",
+	"application/json",
+	"application/problem+json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+}
+
+// encoder 压缩编码器，gzip.Writer 与 brotli.Writer 都满足
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// 编码器初始化开销较大，按编码复用
+var encoderPools = map[string]*sync.Pool{
+	encodingBrotli: {New: func() interface{} {
+		return brotli.NewWriterLevel(io.Discard, brotliLevel)
+	}},
+	encodingGzip: {New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	}},
+}
+
+// compressState 压缩写入器的状态
+type compressState int
+
+const (
+	// compressPending 尚未决定是否压缩，响应体缓冲中
+	compressPending compressState = iota
+	// compressPassthrough 不压缩，原样输出
+	compressPassthrough
+	// compressActive 压缩输出
+	compressActive
+)
+
+// compressWriter 缓冲响应体，达到阈值后切换为压缩输出
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+	state    compressState
+	buf      []byte
+	enc      encoder
+}
+
+// Write 实现 gin.ResponseWriter
+func (w *compressWriter) Write(data []byte) (int, error) {
+	switch w.state {
+	case compressPassthrough:
+		return w.ResponseWriter.Write(data)
+	case compressActive:
+		return w.enc.Write(data)
+	}
+
+	if !w.compressible() {
+		if err := w.passthrough(); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.minSize {
+		if err := w.start(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// WriteString 实现 gin.ResponseWriter
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow 实现 gin.ResponseWriter
+// 响应头一旦写出就不能再加 Content-Encoding，因此尚未决定时按不压缩处理
+func (w *compressWriter) WriteHeaderNow() {
+	if w.state == compressPending {
+		_ = w.passthrough()
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Flush 实现 http.Flusher
+// 流式响应（如 SSE）在首次 Flush 时即决定是否压缩，不再等待阈值
+func (w *compressWriter) Flush() {
+	if w.state == compressPending {
+		if w.compressible() {
+			_ = w.start()
+		} else {
+			_ = w.passthrough()
+		}
+	}
+	if w.state == compressActive {
+		_ = w.enc.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Written 实现 gin.ResponseWriter，缓冲中的响应同样视为已写出
+func (w *compressWriter) Written() bool {
+	return w.state == compressActive || len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+// compressible 根据状态码与响应头判断当前响应是否可压缩
+func (w *compressWriter) compressible() bool {
+	switch status := w.Status(); {
+	case status < http.StatusOK,
+		status == http.StatusNoContent,
+		status == http.StatusPartialContent,
+		status == http.StatusNotModified:
+		return false
+	}
+
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	return isCompressibleType(header.Get("Content-Type"))
+}
+
+// passthrough 切换为不压缩，并写出已缓冲的内容
+func (w *compressWriter) passthrough() error {
+	w.state = compressPassthrough
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// start 切换为压缩输出，设置编码响应头并压缩已缓冲的内容
+func (w *compressWriter) start() error {
+	w.state = compressActive
+
+	header := w.Header()
+	header.Set("Content-Encoding", w.encoding)
+	// 压缩后长度改变，由服务器改用分块传输
+	header.Del("Content-Length")
+
+	w.enc = encoderPools[w.encoding].Get().(encoder)
+	w.enc.Reset(w.ResponseWriter)
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.enc.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// close 结束响应：未达阈值的缓冲内容原样写出，压缩输出则写出尾部并归还编码器
+func (w *compressWriter) close() {
+	switch w.state {
+	case compressPending:
+		_ = w.passthrough()
+	case compressActive:
+		_ = w.enc.Close()
+		w.enc.Reset(io.Discard)
+		encoderPools[w.encoding].Put(w.enc)
+		w.enc = nil
+	}
+}
+
+// Compress 响应压缩中间件
+// 按 Accept-Encoding 选择 br 或 gzip，只压缩文本类且不小于 minSize 字节的响应。
+// 所有响应都带 Vary: Accept-Encoding，避免缓存把压缩结果返回给不支持的客户端
+func Compress(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		addVary(c.Writer.Header(), "Accept-Encoding")
+
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{ResponseWriter: c.Writer, encoding: encoding, minSize: minSize}
+		c.Writer = w
+		defer func() {
+			w.close()
+			c.Writer = w.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+// negotiateEncoding 按 Accept-Encoding 选择编码，都不接受时返回空字符串
+// q 值高者优先，相同时按 supportedEncodings 的顺序；q=0 表示明确拒绝，* 匹配未列出的编码
+func negotiateEncoding(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		weight := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || !strings.EqualFold(key, "q") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				weight = q
+			}
+		}
+		weights[name] = weight
+	}
+
+	best, bestWeight := "", 0.0
+	for _, encoding := range supportedEncodings {
+		weight, ok := weights[encoding]
+		if !ok {
+			weight, ok = weights["*"]
+		}
+		if ok && weight > bestWeight {
+			best, bestWeight = encoding, weight
+		}
+	}
+	return best
+}
+
+// isCompressibleType 判断 Content-Type 是否属于可压缩的文本类格式
+func isCompressibleType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if mediaType == "" {
+		return false
+	}
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// addVary 向 Vary 响应头追加字段，已存在时不重复添加
+func addVary(header http.Header, field string) {
+	for _, value := range header.Values("Vary") {
+		for _, existing := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(existing), field) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", field)
+}
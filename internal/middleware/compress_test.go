@@ -0,0 +1,149 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件包含响应压缩中间件的单元测试
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// compressTestBody 超过压缩阈值的 JSON 响应内容
+var compressTestBody = strings.Repeat("user-api ", 200)
+
+// serveWithCompress 以 Compress 中间件处理一次请求
+func serveWithCompress(acceptEncoding string, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(ResponseTime(), Compress(1024))
+	router.GET("/test", handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// jsonTestHandler 返回较大的 JSON 响应
+func jsonTestHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"message": compressTestBody})
+}
+
+// decodeBody 按编码解压响应体
+func decodeBody(t *testing.T, encoding string, body io.Reader) string {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case "br":
+		r = brotli.NewReader(body)
+	case "gzip":
+		gr, err := gzip.NewReader(body)
+		require.NoError(t, err)
+		defer gr.Close()
+		r = gr
+	default:
+		r = body
+	}
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestCompress_PrefersBrotli(t *testing.T) {
+	// 执行
+	w := serveWithCompress("gzip, deflate, br", jsonTestHandler)
+
+	// 断言
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.Empty(t, w.Header().Get("Content-Length"))
+	assert.NotEmpty(t, w.Header().Get(ResponseTimeHeader))
+	assert.Less(t, w.Body.Len(), len(compressTestBody))
+	assert.Contains(t, decodeBody(t, "br", w.Body), compressTestBody)
+}
+
+func TestCompress_FallsBackToGzip(t *testing.T) {
+	// 执行
+	w := serveWithCompress("gzip", jsonTestHandler)
+
+	// 断言
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.Contains(t, decodeBody(t, "gzip", w.Body), compressTestBody)
+}
+
+func TestCompress_NotCompressed(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		handler        gin.HandlerFunc
+	}{
+		{name: "未声明 Accept-Encoding", acceptEncoding: "", handler: jsonTestHandler},
+		{name: "不支持的编码", acceptEncoding: "deflate", handler: jsonTestHandler},
+		{name: "明确拒绝", acceptEncoding: "br;q=0, gzip;q=0", handler: jsonTestHandler},
+		{
+			name:           "小于阈值",
+			acceptEncoding: "br",
+			handler: func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"ok": true})
+			},
+		},
+		{
+			name:           "不可压缩的类型",
+			acceptEncoding: "br",
+			handler: func(c *gin.Context) {
+				c.Data(http.StatusOK, "image/png", []byte(compressTestBody))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 执行
+			w := serveWithCompress(tt.acceptEncoding, tt.handler)
+
+			// 断言：原样输出，但仍带 Vary
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Empty(t, w.Header().Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+			assert.NotEmpty(t, w.Body.String())
+		})
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		expected       string
+	}{
+		{"", ""},
+		{"br", "br"},
+		{"gzip", "gzip"},
+		{"gzip, br", "br"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"br;q=0, gzip;q=0.1", "gzip"},
+		{"*", "br"},
+		{"*;q=0.5, br;q=0", "gzip"},
+		{"identity", ""},
+		{"GZIP", "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			assert.Equal(t, tt.expected, negotiateEncoding(tt.acceptEncoding))
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件实现基于信号量的并发数限制。
+// 大量并发的重型列表查询可能压垮数据库，限制同时执行的请求数后，
+// 超出上限的请求在队列中最多等待 QueueTimeout，仍未获得名额时返回 503 并带 Retry-After。
+// 同一个中间件实例挂到多个端点时，这些端点共享同一组名额。
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/example/go-user-api/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// concurrencyRetryAfter 并发已满被拒绝时建议客户端等待的秒数
+const concurrencyRetryAfter = "1"
+
+// ConcurrencyLimitOption ConcurrencyLimit 的可选配置
+type ConcurrencyLimitOption func(*concurrencyLimiter)
+
+// WithQueueTimeout 设置并发已满时请求的最长排队时间，0 表示不排队直接返回 503
+func WithQueueTimeout(d time.Duration) ConcurrencyLimitOption {
+	return func(l *concurrencyLimiter) {
+		if d >= 0 {
+			l.queueTimeout = d
+		}
+	}
+}
+
+// concurrencyLimiter 并发名额
+type concurrencyLimiter struct {
+	slots        chan struct{}
+	queueTimeout time.Duration
+}
+
+// acquire 获取名额，排队超时或请求取消时返回 false
+func (l *concurrencyLimiter) acquire(ctx context.Context) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if l.queueTimeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release 归还名额
+func (l *concurrencyLimiter) release() {
+	<-l.slots
+}
+
+// ConcurrencyLimit 限制同时执行的请求数不超过 n，n <= 0 时不限制
+// 默认不排队，超出上限立即返回 503；可通过 WithQueueTimeout 允许短暂排队
+//
+// 使用示例：
+//
+//	heavy := middleware.ConcurrencyLimit(10, middleware.WithQueueTimeout(2*time.Second))
+//	usersGroup.GET("", heavy, h.User.ListUsers)
+//	riskReportGroup.GET("/usage", heavy, h.RiskReportUsage.List)
+func ConcurrencyLimit(n int, opts ...ConcurrencyLimitOption) gin.HandlerFunc {
+	if n <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	l := &concurrencyLimiter{slots: make(chan struct{}, n)}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return func(c *gin.Context) {
+		if !l.acquire(c.Request.Context()) {
+			c.Header(RetryAfterHeader, concurrencyRetryAfter)
+			response.AbortWithServiceUnavailable(c, "服务繁忙，请稍后重试")
+			return
+		}
+		defer l.release()
+		c.Next()
+	}
+}
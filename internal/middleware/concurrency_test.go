@@ -0,0 +1,139 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件包含并发数限制中间件的单元测试
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrencyTestEngine 构建挂载并发限制的引擎
+// /slow 在 entered 上通知已进入处理，阻塞到 release 收到信号；/fast 立即返回
+func concurrencyTestEngine(n int, opts ...ConcurrencyLimitOption) (engine *gin.Engine, entered chan struct{}, release chan struct{}) {
+	gin.SetMode(gin.TestMode)
+
+	entered = make(chan struct{}, 10)
+	release = make(chan struct{})
+	limit := ConcurrencyLimit(n, opts...)
+
+	engine = gin.New()
+	engine.GET("/slow", limit, func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+	engine.GET("/fast", limit, func(c *gin.Context) { c.Status(http.StatusOK) })
+	return engine, entered, release
+}
+
+// serveAsync 在后台发送请求，返回接收响应的通道
+func serveAsync(engine *gin.Engine, path string) <-chan *httptest.ResponseRecorder {
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		done <- w
+	}()
+	return done
+}
+
+// waitEntered 等待 count 个请求进入处理
+func waitEntered(t *testing.T, entered chan struct{}, count int) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		select {
+		case <-entered:
+		case <-time.After(time.Second):
+			t.Fatal("请求未进入处理")
+		}
+	}
+}
+
+// doGet 同步发送请求
+func doGet(engine *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestConcurrencyLimit_RejectsOverLimitAndRecovers(t *testing.T) {
+	engine, entered, release := concurrencyTestEngine(2)
+
+	// 占满两个名额
+	first := serveAsync(engine, "/slow")
+	second := serveAsync(engine, "/slow")
+	waitEntered(t, entered, 2)
+
+	// 超过上限的请求立即返回 503，共享名额的其他端点同样受限
+	w := doGet(engine, "/fast")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get(RetryAfterHeader))
+
+	// 释放一个名额后恢复（先结束的可能是任意一个）
+	release <- struct{}{}
+	remaining := second
+	select {
+	case w := <-first:
+		require.Equal(t, http.StatusOK, w.Code)
+	case w := <-second:
+		require.Equal(t, http.StatusOK, w.Code)
+		remaining = first
+	}
+	assert.Equal(t, http.StatusOK, doGet(engine, "/fast").Code)
+
+	close(release)
+	assert.Equal(t, http.StatusOK, (<-remaining).Code)
+	assert.Equal(t, http.StatusOK, doGet(engine, "/fast").Code)
+}
+
+func TestConcurrencyLimit_QueuedRequestProceedsWhenSlotFreed(t *testing.T) {
+	engine, entered, release := concurrencyTestEngine(1, WithQueueTimeout(5*time.Second))
+
+	first := serveAsync(engine, "/slow")
+	waitEntered(t, entered, 1)
+
+	// 第二个请求排队，名额释放后继续执行
+	queued := serveAsync(engine, "/fast")
+	select {
+	case <-queued:
+		t.Fatal("名额未释放时排队的请求不应返回")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	assert.Equal(t, http.StatusOK, (<-first).Code)
+	assert.Equal(t, http.StatusOK, (<-queued).Code)
+}
+
+func TestConcurrencyLimit_QueueTimeout(t *testing.T) {
+	engine, entered, release := concurrencyTestEngine(1, WithQueueTimeout(20*time.Millisecond))
+	defer close(release)
+
+	first := serveAsync(engine, "/slow")
+	waitEntered(t, entered, 1)
+
+	// 排队超时后返回 503
+	start := time.Now()
+	w := doGet(engine, "/fast")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	release <- struct{}{}
+	assert.Equal(t, http.StatusOK, (<-first).Code)
+}
+
+func TestConcurrencyLimit_Disabled(t *testing.T) {
+	engine, _, release := concurrencyTestEngine(0)
+	close(release)
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, doGet(engine, "/fast").Code)
+	}
+}
@@ -0,0 +1,25 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件包含接口级别的功能开关中间件。
+package middleware
+
+import (
+	"github.com/example/go-user-api/pkg/featureflag"
+	"github.com/example/go-user-api/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// FeatureGate 功能开关中间件
+// 创建时在 flags 中注册开关 name，开关关闭时对该路由返回 503，开启后恢复正常。
+// 开关在每次请求时读取，运行时修改立即生效
+func FeatureGate(flags *featureflag.Flags, name string) gin.HandlerFunc {
+	flags.Register(name)
+
+	return func(c *gin.Context) {
+		if !flags.Enabled(name) {
+			response.AbortWithServiceUnavailable(c, "该功能已暂时关闭，请稍后再试")
+			return
+		}
+		c.Next()
+	}
+}
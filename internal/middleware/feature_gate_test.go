@@ -0,0 +1,55 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件包含功能开关中间件的单元测试
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/example/go-user-api/pkg/featureflag"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeatureGate_ToggleAtRuntime(t *testing.T) {
+	// 准备
+	gin.SetMode(gin.TestMode)
+	flags := featureflag.New(nil)
+	engine := gin.New()
+	engine.POST("/users/import", FeatureGate(flags, "user_import"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	perform := func() int {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/import", nil))
+		return w.Code
+	}
+
+	// 默认开启
+	assert.Equal(t, http.StatusOK, perform())
+
+	// 关闭后返回 503
+	assert.True(t, flags.Set("user_import", false))
+	assert.Equal(t, http.StatusServiceUnavailable, perform())
+
+	// 重新开启后恢复正常
+	assert.True(t, flags.Set("user_import", true))
+	assert.Equal(t, http.StatusOK, perform())
+}
+
+func TestFeatureGate_ClosedByConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	flags := featureflag.New(map[string]bool{"register": false})
+	engine := gin.New()
+	engine.POST("/register", FeatureGate(flags, "register"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/register", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "10009")
+}
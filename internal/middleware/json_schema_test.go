@@ -0,0 +1,167 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件包含请求体 JSON Schema 校验中间件的单元测试
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// profileSchema 测试用 schema：嵌套对象、格式、范围与禁止未知字段
+const profileSchema = `{
+  "type": "object",
+  "required": ["username", "email"],
+  "additionalProperties": false,
+  "properties": {
+    "username": {"type": "string", "minLength": 3},
+    "email": {"type": "string", "format": "email"},
+    "profile": {
+      "type": "object",
+      "properties": {
+        "age": {"type": "integer", "minimum": 0, "maximum": 150},
+        "gender": {"enum": ["male", "female"]}
+      }
+    }
+  }
+}`
+
+// schemaTestResponse 校验失败时的响应
+type schemaTestResponse struct {
+	Code    int                    `json:"code"`
+	Message string                 `json:"message"`
+	Data    model.ValidationErrors `json:"data"`
+}
+
+// newSchemaTestEngine 创建挂载 JSONSchema 中间件的路由，POST /profiles 配置了 schema
+func newSchemaTestEngine(t *testing.T) *gin.Engine {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "profile.json"), []byte(profileSchema), 0o600))
+
+	registry, err := NewSchemaRegistry(dir, []SchemaRoute{
+		{Method: "post", Path: "/profiles", File: "profile.json"},
+	})
+	require.NoError(t, err)
+
+	engine := gin.New()
+	engine.Use(JSONSchema(registry))
+	echo := func(c *gin.Context) {
+		var body map[string]interface{}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.String(http.StatusTeapot, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, body)
+	}
+	engine.POST("/profiles", echo)
+	engine.POST("/other", echo)
+	return engine
+}
+
+// postJSON 发送 JSON 请求
+func postJSON(engine *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestJSONSchema_InvalidBodyReturnsFieldErrors(t *testing.T) {
+	// 准备
+	engine := newSchemaTestEngine(t)
+
+	// 执行：缺少 username，邮箱格式错误，嵌套字段类型与取值错误，多出未知字段
+	w := postJSON(engine, "/profiles",
+		`{"email":"not-an-email","profile":{"age":"18","gender":"other"},"extra":1}`)
+
+	// 断言
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var resp schemaTestResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, response.CodeValidationError, resp.Code)
+	assert.Equal(t, "请求参数验证失败", resp.Message)
+
+	assert.Equal(t, []model.FieldError{
+		{Field: "email", Kind: model.FieldErrorKindInvalid, Tag: "format", Message: "格式不正确，应为 email"},
+		{Field: "extra", Kind: model.FieldErrorKindUnknown, Tag: "additionalProperties", Message: "不支持的字段"},
+		{Field: "profile.age", Kind: model.FieldErrorKindType, Tag: "type", Message: "类型错误，应为 integer"},
+		{Field: "profile.gender", Kind: model.FieldErrorKindInvalid, Tag: "enum", Message: "必须是以下值之一: male female"},
+		{Field: "username", Kind: model.FieldErrorKindMissing, Tag: "required", Message: "不能为空"},
+	}, resp.Data.Errors)
+}
+
+func TestJSONSchema_RangeErrors(t *testing.T) {
+	// 准备
+	engine := newSchemaTestEngine(t)
+
+	// 执行
+	w := postJSON(engine, "/profiles", `{"username":"ab","email":"a@example.com","profile":{"age":200}}`)
+
+	// 断言
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var resp schemaTestResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []model.FieldError{
+		{Field: "profile.age", Kind: model.FieldErrorKindInvalid, Tag: "maximum", Message: "不能大于 150"},
+		{Field: "username", Kind: model.FieldErrorKindInvalid, Tag: "minLength", Message: "长度不能少于 3"},
+	}, resp.Data.Errors)
+}
+
+func TestJSONSchema_ValidBodyIsRestored(t *testing.T) {
+	// 准备
+	engine := newSchemaTestEngine(t)
+
+	// 执行
+	w := postJSON(engine, "/profiles", `{"username":"alice","email":"alice@example.com","profile":{"age":18}}`)
+
+	// 断言：校验通过后 handler 仍能读取完整请求体
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"username":"alice","email":"alice@example.com","profile":{"age":18}}`, w.Body.String())
+}
+
+func TestJSONSchema_SkipsRoutesWithoutSchema(t *testing.T) {
+	// 准备
+	engine := newSchemaTestEngine(t)
+
+	// 执行
+	w := postJSON(engine, "/other", `{"extra":1}`)
+
+	// 断言
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestJSONSchema_MalformedJSON(t *testing.T) {
+	// 准备
+	engine := newSchemaTestEngine(t)
+
+	// 执行
+	w := postJSON(engine, "/profiles", `{"username":`)
+
+	// 断言
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "请求参数格式错误")
+}
+
+func TestNewSchemaRegistry_MissingFile(t *testing.T) {
+	// 执行
+	_, err := NewSchemaRegistry(t.TempDir(), []SchemaRoute{
+		{Method: http.MethodPost, Path: "/profiles", File: "missing.json"},
+	})
+
+	// 断言
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "POST /profiles")
+}
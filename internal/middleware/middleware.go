@@ -8,15 +8,19 @@
 //
 // 中间件的执行顺序很重要，建议的顺序是：
 // 1. Recovery（最先执行，捕获所有 panic）
-// 2. RequestID（生成请求 ID）
-// 3. Logger（记录请求日志）
-// 4. CORS（处理跨域）
-// 5. 其他业务中间件
+// 2. ResponseTime（记录请求开始时间并写入耗时响应头）
+// 3. RequestID（生成请求 ID）
+// 4. Logger（记录请求日志）
+// 5. CORS（处理跨域）
+// 6. 其他业务中间件
 package middleware
 
 import (
 	"net/http"
 	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/example/go-user-api/pkg/logger"
@@ -37,6 +41,20 @@ const (
 	UserRoleKey = "user_role"
 )
 
+// verySlowFactor 请求耗时超过慢请求阈值的该倍数时以 Error 级别记录
+const verySlowFactor = 5
+
+// LoggerConfig 日志中间件配置
+type LoggerConfig struct {
+	// SlowThreshold 慢请求阈值，0 表示不检测慢请求
+	SlowThreshold time.Duration
+	// ExcludePaths 降为 Debug 级别记录的路径（如健康检查），以 * 结尾时按前缀匹配
+	// 匹配路径的请求出错或超过慢请求阈值时仍按正常级别记录，避免掩盖故障
+	ExcludePaths []string
+	// Now 时间函数，默认 time.Now，测试时可注入
+	Now func() time.Time
+}
+
 // Logger 日志中间件
 // 记录每个 HTTP 请求的详细信息，包括：
 // - 请求方法和路径
@@ -50,9 +68,25 @@ const (
 //	router := gin.New()
 //	router.Use(middleware.Logger(log))
 func Logger(log logger.Logger) gin.HandlerFunc {
+	return LoggerWithConfig(log, LoggerConfig{})
+}
+
+// LoggerWithConfig 返回带配置的日志中间件
+// 超过慢请求阈值的请求以 Warn 级别记录并带 slow 字段，
+// 超过阈值 5 倍的以 Error 级别记录
+func LoggerWithConfig(log logger.Logger, cfg LoggerConfig) gin.HandlerFunc {
+	now := cfg.Now
+	if now == nil {
+		now = time.Now
+	}
+	excluded := newPathMatcher(cfg.ExcludePaths)
+
 	return func(c *gin.Context) {
-		// 记录开始时间
-		start := time.Now()
+		// 记录开始时间，ResponseTime 已记录时沿用，保证与 X-Response-Time 起点一致
+		start := now()
+		if s, ok := requestStart(c); ok {
+			start = s
+		}
 
 		// 获取请求 ID
 		requestID := c.GetString(RequestIDKey)
@@ -71,7 +105,7 @@ func Logger(log logger.Logger) gin.HandlerFunc {
 		c.Next()
 
 		// 计算处理时间
-		latency := time.Since(start)
+		latency := now().Sub(start)
 
 		// 获取响应状态码
 		statusCode := c.Writer.Status()
@@ -84,6 +118,7 @@ func Logger(log logger.Logger) gin.HandlerFunc {
 			logger.String("client_ip", clientIP),
 			logger.Int("status", statusCode),
 			logger.Duration("latency", latency),
+			logger.String("latency_bucket", latencyBucket(latency)),
 			logger.String("user_agent", userAgent),
 		}
 
@@ -97,26 +132,98 @@ func Logger(log logger.Logger) gin.HandlerFunc {
 			fields = append(fields, logger.String("user_id", userID))
 		}
 
+		// 模拟登录请求标记真实操作者
+		if operatorID := logger.ImpersonatorFromContext(c.Request.Context()); operatorID != "" {
+			fields = append(fields, logger.String("impersonated_by", operatorID))
+		}
+
 		// 如果有错误，添加到日志
 		if len(c.Errors) > 0 {
 			fields = append(fields, logger.String("errors", c.Errors.String()))
 		}
 
-		// 根据状态码选择日志级别
+		// 标记慢请求
+		slow := cfg.SlowThreshold > 0 && latency > cfg.SlowThreshold
+		verySlow := slow && latency > cfg.SlowThreshold*verySlowFactor
+		if slow {
+			fields = append(fields, logger.Bool("slow", true))
+		}
+
+		// 标记因处理 deadline 到期而取消的请求（见 Timeout）
+		if DeadlineExceeded(c) {
+			fields = append(fields, logger.Bool("deadline_exceeded", true))
+		}
+
+		// 根据状态码和耗时选择日志级别
 		switch {
 		case statusCode >= 500:
 			log.Error("请求处理失败", fields...)
+		case verySlow:
+			log.Error("请求严重超时", fields...)
 		case statusCode >= 400:
 			log.Warn("请求错误", fields...)
+		case slow:
+			log.Warn("慢请求", fields...)
+		case excluded.match(path):
+			log.Debug("请求完成", fields...)
 		default:
 			log.Info("请求完成", fields...)
 		}
 	}
 }
 
+// pathMatcher 路径匹配器，支持精确匹配与以 * 结尾的前缀匹配
+type pathMatcher struct {
+	exact    map[string]bool
+	prefixes []string
+}
+
+// newPathMatcher 创建路径匹配器
+func newPathMatcher(patterns []string) pathMatcher {
+	m := pathMatcher{exact: make(map[string]bool, len(patterns))}
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			m.prefixes = append(m.prefixes, prefix)
+			continue
+		}
+		m.exact[p] = true
+	}
+	return m
+}
+
+// match 检查路径是否匹配
+func (m pathMatcher) match(path string) bool {
+	if m.exact[path] {
+		return true
+	}
+	for _, prefix := range m.prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// latencyBucket 返回请求耗时所在的分桶，便于按耗时区间聚合日志
+func latencyBucket(latency time.Duration) string {
+	switch {
+	case latency < 100*time.Millisecond:
+		return "<100ms"
+	case latency < 500*time.Millisecond:
+		return "100ms-500ms"
+	case latency < time.Second:
+		return "500ms-1s"
+	case latency < 5*time.Second:
+		return "1s-5s"
+	default:
+		return ">=5s"
+	}
+}
+
 // Recovery 恢复中间件
 // 捕获处理请求时发生的 panic，防止程序崩溃
 // 记录 panic 信息和堆栈跟踪，并返回 500 错误
+// 错误响应与普通错误结构一致，data 中包含当前请求的 request_id
 //
 // 使用示例：
 //
@@ -131,6 +238,9 @@ func Recovery(log logger.Logger) gin.HandlerFunc {
 
 				// 获取请求 ID
 				requestID := c.GetString(RequestIDKey)
+				if requestID == "" {
+					requestID = logger.RequestIDFromContext(c.Request.Context())
+				}
 
 				// 记录错误日志
 				log.Error("请求处理发生 panic",
@@ -141,12 +251,10 @@ func Recovery(log logger.Logger) gin.HandlerFunc {
 					logger.String("method", c.Request.Method),
 				)
 
-				// 返回 500 错误
-				c.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
-					Code:    response.CodeInternalError,
-					Message: "服务器内部错误",
-					Data:    nil,
-				})
+				// 返回 500 错误，附带 request_id 便于用户反馈时定位日志
+				c.Abort()
+				response.ErrorWithData(c, http.StatusInternalServerError, response.CodeInternalError, "服务器内部错误",
+					gin.H{"request_id": requestID})
 			}
 		}()
 		c.Next()
@@ -173,6 +281,8 @@ func RequestID() gin.HandlerFunc {
 
 		// 设置到上下文中
 		c.Set(RequestIDKey, requestID)
+		// 同时写入请求的 context，供服务层和数据库日志使用
+		c.Request = c.Request.WithContext(logger.ContextWithRequestID(c.Request.Context(), requestID))
 
 		// 设置到响应头中，方便客户端追踪
 		c.Header(RequestIDKey, requestID)
@@ -252,7 +362,7 @@ func CORS(config CORSConfig) gin.HandlerFunc {
 			}
 
 			if config.MaxAge > 0 {
-				c.Header("Access-Control-Max-Age", string(rune(config.MaxAge)))
+				c.Header("Access-Control-Max-Age", strconv.Itoa(config.MaxAge))
 			}
 
 			c.AbortWithStatus(http.StatusNoContent)
@@ -263,6 +373,56 @@ func CORS(config CORSConfig) gin.HandlerFunc {
 	}
 }
 
+// CORSPolicy 按路径前缀生效的 CORS 策略
+type CORSPolicy struct {
+	// PathPrefix 生效的路径前缀，如 /api/v1/users
+	PathPrefix string
+	// Config 该路径下使用的 CORS 配置
+	Config CORSConfig
+}
+
+// CORSWithPolicies 返回按路径前缀选择策略的 CORS 中间件
+// 请求路径匹配最长的前缀策略，未匹配任何策略时使用 defaultConfig。
+// 之所以在全局按路径分发而不是挂在路由组上，是因为浏览器的 OPTIONS 预检请求
+// 不会命中只注册了 GET/POST 等方法的路由组，组级中间件无法处理预检。
+//
+// 使用示例：
+//
+//	router.Use(middleware.CORSWithPolicies(defaultCfg, []middleware.CORSPolicy{
+//	    {PathPrefix: "/api/v1/auth", Config: publicCfg},
+//	    {PathPrefix: "/api/v1/users", Config: adminCfg},
+//	}))
+func CORSWithPolicies(defaultConfig CORSConfig, policies []CORSPolicy) gin.HandlerFunc {
+	type compiledPolicy struct {
+		prefix  string
+		handler gin.HandlerFunc
+	}
+
+	compiled := make([]compiledPolicy, 0, len(policies))
+	for _, p := range policies {
+		compiled = append(compiled, compiledPolicy{
+			prefix:  strings.TrimSuffix(p.PathPrefix, "/"),
+			handler: CORS(p.Config),
+		})
+	}
+	// 前缀越长越具体，优先匹配
+	sort.SliceStable(compiled, func(i, j int) bool {
+		return len(compiled[i].prefix) > len(compiled[j].prefix)
+	})
+	defaultHandler := CORS(defaultConfig)
+
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		for _, p := range compiled {
+			if path == p.prefix || strings.HasPrefix(path, p.prefix+"/") {
+				p.handler(c)
+				return
+			}
+		}
+		defaultHandler(c)
+	}
+}
+
 // DefaultCORS 返回默认配置的 CORS 中间件
 // 允许所有来源、常用方法和头部
 func DefaultCORS() gin.HandlerFunc {
@@ -276,16 +436,31 @@ func DefaultCORS() gin.HandlerFunc {
 	})
 }
 
-// Timeout 请求超时中间件
-// 设置请求的最大处理时间，超时后返回 504 错误
+// 常用的 Cache-Control 指令
+const (
+	// CacheNoStore 禁止任何缓存，用于令牌、用户资料等敏感响应
+	CacheNoStore = "no-store"
+	// CachePublicShort 允许客户端和代理短期缓存，用于公开且不常变化的内容
+	CachePublicShort = "public, max-age=60"
+)
+
+// CacheControl 缓存控制中间件
+// 按路由组或单个路由设置 Cache-Control 响应头，
+// 指令包含 no-store 时同时设置 Pragma 与 Expires 兼容 HTTP/1.0 缓存。
+// handler 中再次设置 Cache-Control 可覆盖此处的值。
+//
+// 使用示例：
 //
-// 注意：此中间件依赖于 Go 的 context.WithTimeout
-// 如果处理函数没有检查 context，可能无法正确响应超时
-func Timeout(timeout time.Duration) gin.HandlerFunc {
+//	authGroup.Use(middleware.CacheControl(middleware.CacheNoStore))
+//	engine.GET("/", middleware.CacheControl(middleware.CachePublicShort), home)
+func CacheControl(directive string) gin.HandlerFunc {
+	noStore := strings.Contains(directive, "no-store")
 	return func(c *gin.Context) {
-		// 这里只是设置超时，实际的超时处理需要在 handler 中检查 context
-		// 可以使用 context.WithTimeout 来实现真正的超时
-		c.Set("timeout", timeout)
+		c.Header("Cache-Control", directive)
+		if noStore {
+			c.Header("Pragma", "no-cache")
+			c.Header("Expires", "0")
+		}
 		c.Next()
 	}
 }
@@ -293,12 +468,7 @@ func Timeout(timeout time.Duration) gin.HandlerFunc {
 // NoCache 禁止缓存中间件
 // 设置响应头禁止客户端和代理缓存
 func NoCache() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
-		c.Header("Pragma", "no-cache")
-		c.Header("Expires", "0")
-		c.Next()
-	}
+	return CacheControl("no-cache, no-store, must-revalidate")
 }
 
 // SecureHeaders 安全响应头中间件
@@ -334,6 +504,103 @@ func joinStrings(strs []string) string {
 	return result
 }
 
+// ResponseNaming 响应字段命名风格中间件
+// 优先使用请求头 X-Naming-Convention 指定的风格，未指定或无法识别时使用配置的默认风格
+//
+// 使用示例：
+//
+//	router := gin.New()
+//	router.Use(middleware.ResponseNaming("snake_case"))
+func ResponseNaming(defaultConvention string) gin.HandlerFunc {
+	fallback, ok := response.ParseNamingConvention(defaultConvention)
+	if !ok {
+		fallback = response.NamingSnakeCase
+	}
+
+	return func(c *gin.Context) {
+		convention, ok := response.ParseNamingConvention(c.GetHeader(response.NamingConventionHeader))
+		if !ok {
+			convention = fallback
+		}
+		response.SetNamingConvention(c, convention)
+		c.Next()
+	}
+}
+
+// Locale 响应语言中间件
+// 按以下优先级决定错误消息的语言并存入上下文，同时写入 Content-Language 响应头：
+//  1. X-Lang 请求头
+//  2. Accept-Language 请求头
+//  3. defaultLanguage（无法识别时为中文）
+func Locale(defaultLanguage string) gin.HandlerFunc {
+	fallback, ok := response.ParseLanguage(defaultLanguage)
+	if !ok {
+		fallback = response.LanguageZhCN
+	}
+
+	return func(c *gin.Context) {
+		lang, ok := response.ParseLanguage(c.GetHeader(response.LanguageHeader))
+		if !ok {
+			lang, ok = response.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+		}
+		if !ok {
+			lang = fallback
+		}
+		response.SetLanguage(c, lang)
+		c.Header("Content-Language", string(lang))
+		c.Next()
+	}
+}
+
+// Timezone 响应时区中间件
+// 优先使用请求头 X-Timezone 指定的 IANA 时区，未指定或无法识别时使用配置的默认时区；
+// 两者都没有时不转换，时间字段按服务器序列化结果输出
+//
+// 使用示例：
+//
+//	router := gin.New()
+//	router.Use(middleware.Timezone("UTC"))
+func Timezone(defaultTimezone string) gin.HandlerFunc {
+	fallback, _ := response.ParseTimezone(defaultTimezone)
+
+	return func(c *gin.Context) {
+		loc, ok := response.ParseTimezone(c.GetHeader(response.TimezoneHeader))
+		if !ok {
+			loc = fallback
+		}
+		if loc != nil {
+			response.SetTimezone(c, loc)
+		}
+		c.Next()
+	}
+}
+
+// ServerTime 服务器时间戳中间件
+// 为请求开启响应信封中的 server_time 字段，供客户端做请求签名或时钟同步
+func ServerTime() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response.EnableServerTime(c)
+		c.Next()
+	}
+}
+
+// DisallowUnknownFieldsKey 严格 JSON 绑定开关在 gin.Context 中的键
+const DisallowUnknownFieldsKey = "disallowUnknownFields"
+
+// StrictJSON 严格 JSON 绑定中间件
+// 标记当前请求在绑定 JSON 请求体时拒绝未知字段，由处理器的绑定助手读取
+func StrictJSON() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(DisallowUnknownFieldsKey, true)
+		c.Next()
+	}
+}
+
+// DisallowUnknownFields 当前请求是否拒绝 JSON 请求体中的未知字段
+func DisallowUnknownFields(c *gin.Context) bool {
+	return c.GetBool(DisallowUnknownFieldsKey)
+}
+
 // GetRequestID 从上下文获取请求 ID
 func GetRequestID(c *gin.Context) string {
 	return c.GetString(RequestIDKey)
@@ -0,0 +1,469 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件包含通用中间件的单元测试
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/example/go-user-api/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================
+// 测试辅助
+// ============================================================
+
+// logEntry 记录的一条日志
+type logEntry struct {
+	level  string
+	msg    string
+	fields map[string]interface{}
+}
+
+// recordingLogger 记录日志级别与字段的 Logger 实现
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *recordingLogger) record(level, msg string, fields []logger.Field) {
+	entry := logEntry{level: level, msg: msg, fields: make(map[string]interface{})}
+	for _, f := range fields {
+		switch {
+		case f.String != "":
+			entry.fields[f.Key] = f.String
+		case f.Interface != nil:
+			entry.fields[f.Key] = f.Interface
+		default:
+			entry.fields[f.Key] = f.Integer
+		}
+	}
+	l.mu.Lock()
+	l.entries = append(l.entries, entry)
+	l.mu.Unlock()
+}
+
+func (l *recordingLogger) Debug(msg string, fields ...logger.Field) { l.record("debug", msg, fields) }
+func (l *recordingLogger) Info(msg string, fields ...logger.Field)  { l.record("info", msg, fields) }
+func (l *recordingLogger) Warn(msg string, fields ...logger.Field)  { l.record("warn", msg, fields) }
+func (l *recordingLogger) Error(msg string, fields ...logger.Field) { l.record("error", msg, fields) }
+func (l *recordingLogger) Fatal(msg string, fields ...logger.Field) { l.record("fatal", msg, fields) }
+func (l *recordingLogger) With(_ ...logger.Field) logger.Logger     { return l }
+func (l *recordingLogger) Sync() error                              { return nil }
+
+// last 返回最后一条日志
+func (l *recordingLogger) last(t *testing.T) logEntry {
+	t.Helper()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	require.NotEmpty(t, l.entries)
+	return l.entries[len(l.entries)-1]
+}
+
+// ============================================================
+// 日志中间件测试
+// ============================================================
+
+// fakeClock 可手动推进的时钟
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+// performTimedRequest 请求一个耗时为 elapsed 的端点并返回记录的日志
+func performTimedRequest(t *testing.T, elapsed time.Duration, status int) logEntry {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	rec := &recordingLogger{}
+	engine := gin.New()
+	engine.Use(LoggerWithConfig(rec, LoggerConfig{
+		SlowThreshold: 500 * time.Millisecond,
+		Now:           clock.Now,
+	}))
+	engine.GET("/work", func(c *gin.Context) {
+		clock.now = clock.now.Add(elapsed)
+		c.Status(status)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/work", nil))
+	return rec.last(t)
+}
+
+func TestLogger_FastRequestInfo(t *testing.T) {
+	entry := performTimedRequest(t, 50*time.Millisecond, http.StatusOK)
+
+	assert.Equal(t, "info", entry.level)
+	assert.NotContains(t, entry.fields, "slow")
+	assert.Equal(t, "<100ms", entry.fields["latency_bucket"])
+}
+
+func TestLogger_SlowRequestWarn(t *testing.T) {
+	entry := performTimedRequest(t, 800*time.Millisecond, http.StatusOK)
+
+	assert.Equal(t, "warn", entry.level)
+	assert.Contains(t, entry.fields, "slow")
+	assert.Equal(t, "500ms-1s", entry.fields["latency_bucket"])
+}
+
+func TestLogger_VerySlowRequestError(t *testing.T) {
+	// 超过阈值 5 倍
+	entry := performTimedRequest(t, 3*time.Second, http.StatusOK)
+
+	assert.Equal(t, "error", entry.level)
+	assert.Contains(t, entry.fields, "slow")
+}
+
+func TestLogger_ExcludePaths(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := &recordingLogger{}
+	engine := gin.New()
+	engine.Use(LoggerWithConfig(rec, LoggerConfig{
+		ExcludePaths: []string{"/health", "/ready", "/metrics", "/internal/*"},
+	}))
+	status := http.StatusOK
+	handler := func(c *gin.Context) { c.Status(status) }
+	for _, path := range []string{"/health", "/ready", "/metrics", "/internal/probe", "/api/v1/users", "/healthz"} {
+		engine.GET(path, handler)
+	}
+
+	request := func(path string) logEntry {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.last(t)
+	}
+
+	// 排除路径只产生 debug 日志
+	for _, path := range []string{"/health", "/ready", "/metrics", "/internal/probe"} {
+		entry := request(path)
+		assert.Equal(t, "debug", entry.level, path)
+		assert.Equal(t, path, entry.fields["path"])
+	}
+	// 其他路径正常记录，精确匹配不影响相似路径
+	assert.Equal(t, "info", request("/api/v1/users").level)
+	assert.Equal(t, "info", request("/healthz").level)
+
+	// 排除路径出错时仍按正常级别记录
+	status = http.StatusServiceUnavailable
+	assert.Equal(t, "error", request("/ready").level)
+}
+
+// ============================================================
+// CORS 测试
+// ============================================================
+
+// newCORSTestEngine 创建按路径区分 CORS 策略的测试引擎
+func newCORSTestEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(CORSWithPolicies(
+		CORSConfig{AllowedOrigins: []string{"https://www.example.com"}},
+		[]CORSPolicy{
+			{PathPrefix: "/api/v1/auth", Config: CORSConfig{AllowedOrigins: []string{"*"}}},
+			{PathPrefix: "/api/v1/users", Config: CORSConfig{
+				AllowedOrigins: []string{"https://admin.example.com"},
+				AllowedMethods: []string{"GET", "PUT"},
+			}},
+		},
+	))
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	engine.POST("/api/v1/auth/login", ok)
+	engine.GET("/api/v1/users/me", ok)
+	engine.GET("/health", ok)
+	return engine
+}
+
+func performCORSRequest(engine *gin.Engine, method, path, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Origin", origin)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSWithPolicies_PerGroupOrigins(t *testing.T) {
+	engine := newCORSTestEngine()
+
+	// 公开的认证接口允许任意来源
+	w := performCORSRequest(engine, http.MethodPost, "/api/v1/auth/login", "https://any.example.org")
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+
+	// 用户管理接口只允许后台域名
+	w = performCORSRequest(engine, http.MethodGet, "/api/v1/users/me", "https://admin.example.com")
+	assert.Equal(t, "https://admin.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = performCORSRequest(engine, http.MethodGet, "/api/v1/users/me", "https://any.example.org")
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	// 未匹配任何策略的路径使用全局配置
+	w = performCORSRequest(engine, http.MethodGet, "/health", "https://www.example.com")
+	assert.Equal(t, "https://www.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSWithPolicies_Preflight(t *testing.T) {
+	engine := newCORSTestEngine()
+
+	// 预检请求没有对应的 OPTIONS 路由，仍应按路径策略响应
+	w := performCORSRequest(engine, http.MethodOptions, "/api/v1/users/me", "https://admin.example.com")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://admin.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, PUT", w.Header().Get("Access-Control-Allow-Methods"))
+}
+
+func TestCORSWithPolicies_PrefixBoundary(t *testing.T) {
+	engine := newCORSTestEngine()
+	engine.GET("/api/v1/usersearch", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// /api/v1/usersearch 不属于 /api/v1/users 前缀，使用全局配置
+	w := performCORSRequest(engine, http.MethodGet, "/api/v1/usersearch", "https://admin.example.com")
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+// ============================================================
+// CacheControl 测试
+// ============================================================
+
+func TestCacheControl_PerRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	engine.GET("/public", CacheControl(CachePublicShort), ok)
+	private := engine.Group("/private")
+	private.Use(CacheControl(CacheNoStore))
+	private.GET("/me", ok)
+	private.GET("/override", func(c *gin.Context) {
+		c.Header("Cache-Control", "private, max-age=10")
+		c.Status(http.StatusOK)
+	})
+	engine.GET("/plain", ok)
+
+	perform := func(path string) http.Header {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Header()
+	}
+
+	h := perform("/public")
+	assert.Equal(t, "public, max-age=60", h.Get("Cache-Control"))
+	assert.Empty(t, h.Get("Pragma"))
+
+	h = perform("/private/me")
+	assert.Equal(t, "no-store", h.Get("Cache-Control"))
+	assert.Equal(t, "no-cache", h.Get("Pragma"))
+	assert.Equal(t, "0", h.Get("Expires"))
+
+	// handler 可以覆盖路由组设置的值
+	h = perform("/private/override")
+	assert.Equal(t, "private, max-age=10", h.Get("Cache-Control"))
+
+	h = perform("/plain")
+	assert.Empty(t, h.Get("Cache-Control"))
+}
+
+// ============================================================
+// Recovery 测试
+// ============================================================
+
+func TestRecovery_ResponseIncludesRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log := &recordingLogger{}
+	engine := gin.New()
+	engine.Use(RequestID(), Recovery(log))
+	engine.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set(RequestIDKey, "req-panic-1")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+
+	var body struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    struct {
+			RequestID string `json:"request_id"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, response.CodeInternalError, body.Code)
+	assert.Equal(t, "服务器内部错误", body.Message)
+	assert.Equal(t, "req-panic-1", body.Data.RequestID)
+
+	// 日志中的 request_id 与响应一致
+	entry := log.last(t)
+	assert.Equal(t, "error", entry.level)
+	assert.Equal(t, "req-panic-1", entry.fields["request_id"])
+}
+
+// ============================================================
+// 响应语言测试
+// ============================================================
+
+// localeErrorMessage 以指定请求头请求一个返回 404 的端点，返回错误消息与 Content-Language
+func localeErrorMessage(t *testing.T, defaultLanguage string, headers map[string]string) (string, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(Locale(defaultLanguage))
+	router.GET("/test", func(c *gin.Context) {
+		response.NotFound(c, "用户不存在")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	var resp response.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Message, w.Header().Get("Content-Language")
+}
+
+func TestLocale_XLangOverridesAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		name        string
+		headers     map[string]string
+		wantMessage string
+		wantLang    string
+	}{
+		{
+			name:        "X-Lang 覆盖 Accept-Language",
+			headers:     map[string]string{"X-Lang": "en", "Accept-Language": "zh-CN,zh;q=0.9"},
+			wantMessage: "Resource not found",
+			wantLang:    "en",
+		},
+		{
+			name:        "X-Lang 指定中文",
+			headers:     map[string]string{"X-Lang": "zh", "Accept-Language": "en-US"},
+			wantMessage: "用户不存在",
+			wantLang:    "zh-CN",
+		},
+		{
+			name:        "X-Lang 无法识别时使用 Accept-Language",
+			headers:     map[string]string{"X-Lang": "fr", "Accept-Language": "fr-FR, en;q=0.8"},
+			wantMessage: "Resource not found",
+			wantLang:    "en",
+		},
+		{
+			name:        "都缺省时使用默认语言",
+			headers:     nil,
+			wantMessage: "用户不存在",
+			wantLang:    "zh-CN",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, lang := localeErrorMessage(t, "zh-CN", tt.headers)
+
+			assert.Equal(t, tt.wantMessage, message)
+			assert.Equal(t, tt.wantLang, lang)
+		})
+	}
+}
+
+func TestLocale_DefaultLanguageFromConfig(t *testing.T) {
+	message, lang := localeErrorMessage(t, "en", nil)
+
+	assert.Equal(t, "Resource not found", message)
+	assert.Equal(t, "en", lang)
+}
+
+// ============================================================
+// 响应时区测试
+// ============================================================
+
+// timezoneResponse 以指定请求头请求一个返回固定时间的端点，返回 created_at 与 X-Timezone 响应头
+func timezoneResponse(t *testing.T, defaultTimezone string, headers map[string]string) (string, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(Timezone(defaultTimezone))
+	router.GET("/test", func(c *gin.Context) {
+		response.Success(c, gin.H{
+			"created_at": time.Date(2024, 3, 15, 16, 30, 0, 0, time.UTC),
+		})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data struct {
+			CreatedAt string `json:"created_at"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Data.CreatedAt, w.Header().Get("X-Timezone")
+}
+
+func TestTimezone_ConvertsTimeFields(t *testing.T) {
+	tests := []struct {
+		name            string
+		defaultTimezone string
+		headers         map[string]string
+		wantCreatedAt   string
+		wantTimezone    string
+	}{
+		{
+			name:          "X-Timezone 转换到目标时区",
+			headers:       map[string]string{"X-Timezone": "Asia/Shanghai"},
+			wantCreatedAt: "2024-03-16T00:30:00+08:00",
+			wantTimezone:  "Asia/Shanghai",
+		},
+		{
+			name:            "X-Timezone 覆盖默认时区",
+			defaultTimezone: "UTC",
+			headers:         map[string]string{"X-Timezone": "America/New_York"},
+			wantCreatedAt:   "2024-03-15T12:30:00-04:00",
+			wantTimezone:    "America/New_York",
+		},
+		{
+			name:            "无法识别时使用默认时区",
+			defaultTimezone: "Asia/Shanghai",
+			headers:         map[string]string{"X-Timezone": "Mars/Olympus"},
+			wantCreatedAt:   "2024-03-16T00:30:00+08:00",
+			wantTimezone:    "Asia/Shanghai",
+		},
+		{
+			name:          "都缺省时不转换",
+			wantCreatedAt: "2024-03-15T16:30:00Z",
+			wantTimezone:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			createdAt, timezone := timezoneResponse(t, tt.defaultTimezone, tt.headers)
+
+			assert.Equal(t, tt.wantCreatedAt, createdAt)
+			assert.Equal(t, tt.wantTimezone, timezone)
+		})
+	}
+}
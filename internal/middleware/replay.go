@@ -0,0 +1,106 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件实现基于 nonce 的请求重放防护。
+// 客户端为每个敏感请求生成随机 nonce，并携带当前 Unix 时间戳（秒）；
+// 服务端要求时间戳与服务器时间的偏差不超过窗口，且 nonce 在窗口内未被使用过。
+// 截获的请求超出窗口后因时间戳过期被拒，窗口内重放则因 nonce 已用被拒。
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/example/go-user-api/pkg/cache"
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/example/go-user-api/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// NonceHeader 请求唯一随机串，同一 nonce 在时间窗口内只能使用一次
+	NonceHeader = "X-Nonce"
+	// TimestampHeader 请求发出时的 Unix 时间戳（秒）
+	TimestampHeader = "X-Timestamp"
+
+	// maxNonceLen nonce 的最大长度
+	maxNonceLen = 128
+	// replayNonceKeyPrefix nonce 在缓存中的键前缀
+	replayNonceKeyPrefix = "replay:nonce:"
+)
+
+// ReplayProtectionConfig 重放防护配置
+type ReplayProtectionConfig struct {
+	// Window 时间戳与服务器时间允许的最大偏差（早于或晚于均可）
+	Window time.Duration
+	// Store 已用 nonce 的存储；多副本部署需使用各副本共享的缓存，否则重放到其他副本无法识别
+	Store cache.Cache
+}
+
+// ReplayProtection 请求重放防护中间件
+// 缺少或格式错误的 X-Nonce、X-Timestamp 与超出窗口的时间戳返回 400，nonce 已使用过返回 409；
+// nonce 保留 2 倍窗口，覆盖时间戳可被接受的整个区间
+//
+// 使用示例：
+//
+//	usersGroup.PUT("/me/password", middleware.ReplayProtection(middleware.ReplayProtectionConfig{
+//		Window: 5 * time.Minute,
+//		Store:  cache.NewMemoryCache(),
+//	}, log), h.User.ChangePassword)
+func ReplayProtection(cfg ReplayProtectionConfig, log logger.Logger) gin.HandlerFunc {
+	return replayProtection(cfg, log, time.Now)
+}
+
+// replayProtection 使用指定时钟构建中间件，便于测试
+func replayProtection(cfg ReplayProtectionConfig, log logger.Logger, now func() time.Time) gin.HandlerFunc {
+	if cfg.Window <= 0 || cfg.Store == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		nonce := c.GetHeader(NonceHeader)
+		if nonce == "" || len(nonce) > maxNonceLen {
+			response.Abort(c, http.StatusBadRequest, response.CodeBadRequest, "请求缺少有效的 "+NonceHeader)
+			return
+		}
+		ts, err := strconv.ParseInt(c.GetHeader(TimestampHeader), 10, 64)
+		if err != nil {
+			response.Abort(c, http.StatusBadRequest, response.CodeBadRequest, "请求缺少有效的 "+TimestampHeader+"（Unix 秒）")
+			return
+		}
+		if skew := now().Sub(time.Unix(ts, 0)); skew > cfg.Window || skew < -cfg.Window {
+			log.Warn("请求时间戳超出重放防护窗口",
+				logger.String("request_id", GetRequestID(c)),
+				logger.String("client_ip", c.ClientIP()),
+				logger.String("path", c.FullPath()),
+				logger.Any("skew", skew),
+			)
+			response.Abort(c, http.StatusBadRequest, response.CodeBadRequest, "请求已过期，请校准时间后重新发起")
+			return
+		}
+
+		fresh, err := claimNonce(c.Request.Context(), cfg, nonce)
+		if err != nil {
+			log.Error("记录请求 nonce 失败", logger.String("request_id", GetRequestID(c)), logger.Err(err))
+			response.AbortWithServiceUnavailable(c, "")
+			return
+		}
+		if !fresh {
+			log.Warn("拒绝重放的请求",
+				logger.String("request_id", GetRequestID(c)),
+				logger.String("client_ip", c.ClientIP()),
+				logger.String("path", c.FullPath()),
+				logger.String("user_id", GetUserID(c)),
+			)
+			response.Abort(c, http.StatusConflict, response.CodeConflict, "请求已处理过，请勿重复提交")
+			return
+		}
+		c.Next()
+	}
+}
+
+// claimNonce 占用 nonce，返回该 nonce 是否首次使用
+func claimNonce(ctx context.Context, cfg ReplayProtectionConfig, nonce string) (bool, error) {
+	return cfg.Store.SetNX(ctx, replayNonceKeyPrefix+nonce, []byte{1}, 2*cfg.Window)
+}
@@ -0,0 +1,93 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件包含请求重放防护中间件的单元测试
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/pkg/cache"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// replayTestEngine 构建挂载重放防护中间件的引擎，返回可调整的时钟
+func replayTestEngine(window time.Duration) (*gin.Engine, *time.Time) {
+	gin.SetMode(gin.TestMode)
+
+	now := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	engine := gin.New()
+	engine.Use(replayProtection(ReplayProtectionConfig{
+		Window: window,
+		Store:  cache.NewMemoryCache(),
+	}, &recordingLogger{}, func() time.Time { return now }))
+	engine.PUT("/users/me/password", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return engine, &now
+}
+
+// doReplayRequest 携带 nonce 与时间戳发送请求，为空时不设置对应请求头
+func doReplayRequest(engine *gin.Engine, nonce, timestamp string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPut, "/users/me/password", nil)
+	if nonce != "" {
+		req.Header.Set(NonceHeader, nonce)
+	}
+	if timestamp != "" {
+		req.Header.Set(TimestampHeader, timestamp)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestReplayProtection_SameNonceRejected(t *testing.T) {
+	engine, now := replayTestEngine(5 * time.Minute)
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	w := doReplayRequest(engine, "nonce-1", ts)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 原样重放被拒
+	w = doReplayRequest(engine, "nonce-1", ts)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// 窗口内稍后使用同一 nonce 仍被拒
+	*now = now.Add(time.Minute)
+	w = doReplayRequest(engine, "nonce-1", strconv.FormatInt(now.Unix(), 10))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// 新的 nonce 正常通过
+	w = doReplayRequest(engine, "nonce-2", strconv.FormatInt(now.Unix(), 10))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestReplayProtection_ExpiredTimestampRejected(t *testing.T) {
+	engine, now := replayTestEngine(5 * time.Minute)
+
+	// 早于窗口
+	w := doReplayRequest(engine, "nonce-old", strconv.FormatInt(now.Add(-6*time.Minute).Unix(), 10))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 晚于窗口
+	w = doReplayRequest(engine, "nonce-future", strconv.FormatInt(now.Add(6*time.Minute).Unix(), 10))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 窗口边缘内可以通过
+	w = doReplayRequest(engine, "nonce-edge", strconv.FormatInt(now.Add(-5*time.Minute).Unix(), 10))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestReplayProtection_MissingHeaders(t *testing.T) {
+	engine, now := replayTestEngine(5 * time.Minute)
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	assert.Equal(t, http.StatusBadRequest, doReplayRequest(engine, "", ts).Code)
+	assert.Equal(t, http.StatusBadRequest, doReplayRequest(engine, "nonce-1", "").Code)
+	assert.Equal(t, http.StatusBadRequest, doReplayRequest(engine, "nonce-1", "not-a-number").Code)
+
+	// 校验失败的请求不占用 nonce
+	assert.Equal(t, http.StatusOK, doReplayRequest(engine, "nonce-1", ts).Code)
+}
@@ -0,0 +1,85 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件包含请求耗时响应头中间件。
+// 响应头必须在写出状态行之前设置，因此包装 ResponseWriter，在首次写出时计算耗时。
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResponseTimeHeader 请求耗时响应头，单位毫秒
+const ResponseTimeHeader = "X-Response-Time"
+
+// RequestStartKey 请求开始时间在上下文中的键
+// 由 ResponseTime 写入，Logger 计算 latency 时优先使用，保证两者起点一致
+const RequestStartKey = "request_start"
+
+// responseTimeWriter 在首次写出响应前设置耗时头
+type responseTimeWriter struct {
+	gin.ResponseWriter
+	start time.Time
+	now   func() time.Time
+}
+
+// setHeader 设置耗时头，响应已写出时不再修改
+func (w *responseTimeWriter) setHeader() {
+	if w.Written() {
+		return
+	}
+	w.Header().Set(ResponseTimeHeader, formatResponseTime(w.now().Sub(w.start)))
+}
+
+// WriteHeaderNow 实现 gin.ResponseWriter
+func (w *responseTimeWriter) WriteHeaderNow() {
+	w.setHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Write 实现 gin.ResponseWriter
+func (w *responseTimeWriter) Write(data []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString 实现 gin.ResponseWriter
+func (w *responseTimeWriter) WriteString(s string) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// formatResponseTime 将耗时格式化为毫秒，保留三位小数
+func formatResponseTime(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
+
+// ResponseTime 请求耗时响应头中间件
+// 在响应头 X-Response-Time 中写入从请求开始到写出响应前的耗时（毫秒），
+// 应放在 Logger 之前，使日志中的 latency 与该响应头使用同一个开始时间
+func ResponseTime() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Set(RequestStartKey, start)
+
+		c.Writer = &responseTimeWriter{ResponseWriter: c.Writer, start: start, now: time.Now}
+		c.Next()
+
+		// 没有响应体时 gin 在中间件返回后才写出状态行，且不经过包装的 Writer
+		if !c.Writer.Written() {
+			c.Header(ResponseTimeHeader, formatResponseTime(time.Since(start)))
+		}
+	}
+}
+
+// requestStart 返回 ResponseTime 记录的请求开始时间
+func requestStart(c *gin.Context) (time.Time, bool) {
+	v, ok := c.Get(RequestStartKey)
+	if !ok {
+		return time.Time{}, false
+	}
+	start, ok := v.(time.Time)
+	return start, ok
+}
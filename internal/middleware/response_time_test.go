@@ -0,0 +1,85 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件包含请求耗时响应头中间件的单元测试
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveWithResponseTime 以 ResponseTime 中间件处理一次请求
+func serveWithResponseTime(handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(ResponseTime())
+	router.GET("/test", handler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+	return w
+}
+
+// responseTimeMillis 解析耗时头
+func responseTimeMillis(t *testing.T, w *httptest.ResponseRecorder) float64 {
+	t.Helper()
+	value := w.Header().Get(ResponseTimeHeader)
+	require.NotEmpty(t, value)
+	ms, err := strconv.ParseFloat(value, 64)
+	require.NoError(t, err)
+	return ms
+}
+
+func TestResponseTime_SetsHeaderBeforeBody(t *testing.T) {
+	// 执行：处理耗时至少 5ms
+	w := serveWithResponseTime(func(c *gin.Context) {
+		time.Sleep(5 * time.Millisecond)
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	// 断言
+	assert.Equal(t, http.StatusOK, w.Code)
+	ms := responseTimeMillis(t, w)
+	assert.GreaterOrEqual(t, ms, 5.0)
+	assert.Less(t, ms, 5000.0)
+}
+
+func TestResponseTime_SetsHeaderWithoutBody(t *testing.T) {
+	// 执行
+	w := serveWithResponseTime(func(c *gin.Context) {
+		time.Sleep(2 * time.Millisecond)
+		c.Status(http.StatusNoContent)
+	})
+
+	// 断言
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.GreaterOrEqual(t, responseTimeMillis(t, w), 2.0)
+}
+
+func TestResponseTime_SharesStartWithLogger(t *testing.T) {
+	// 准备
+	var loggerStart, recorded time.Time
+	router := gin.New()
+	router.Use(ResponseTime())
+	router.Use(func(c *gin.Context) {
+		loggerStart, _ = requestStart(c)
+		c.Next()
+	})
+	router.GET("/test", func(c *gin.Context) {
+		recorded = c.MustGet(RequestStartKey).(time.Time)
+		c.Status(http.StatusOK)
+	})
+
+	// 执行
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	// 断言
+	assert.False(t, loggerStart.IsZero())
+	assert.Equal(t, recorded, loggerStart)
+}
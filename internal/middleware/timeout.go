@@ -0,0 +1,79 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件实现请求处理的 deadline。
+// http.Server 的 ReadTimeout/WriteTimeout 只约束连接读写，到期后连接被关闭，
+// 但 handler 及其发起的数据库、缓存调用仍会继续执行。
+// Timeout 把 deadline 设置进请求 context，下游通过 c.Request.Context() 感知到期并尽早返回；
+// deadline 到期的请求在 gin.Context 中标记，由日志中间件输出 deadline_exceeded 字段。
+// 导入、SSE 推送、同步导出等耗时较长的路由通过 TimeoutConfig.ExcludePaths 豁免。
+package middleware
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// DeadlineExceededKey 请求因处理 deadline 到期被取消时在上下文中的标记
+const DeadlineExceededKey = "deadline_exceeded"
+
+// TimeoutConfig 请求超时中间件配置
+type TimeoutConfig struct {
+	// Timeout 请求处理 deadline，<= 0 时不设置
+	Timeout time.Duration
+	// ExcludePaths 不设置 deadline 的路由（gin 路由模板，如 /api/v1/users/import），以 * 结尾时按前缀匹配
+	ExcludePaths []string
+}
+
+// Timeout 请求超时中间件
+// 为请求 context 设置 timeout 后到期的 deadline，timeout <= 0 时不设置。
+// deadline 到期后标记请求；handler 尚未写出响应时返回 504。
+// 应挂在 Logger 之后，日志才能带上标记；timeout 应小于 http.Server 的 WriteTimeout，
+// 否则连接先被关闭，客户端收不到 504
+//
+// 使用示例：
+//
+//	router.Use(middleware.Logger(log), middleware.Timeout(8*time.Second))
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return TimeoutWithConfig(TimeoutConfig{Timeout: timeout})
+}
+
+// TimeoutWithConfig 使用自定义配置的请求超时中间件
+// 豁免路由按匹配到的路由模板判断，未匹配到路由的请求（404）照常设置 deadline
+func TimeoutWithConfig(cfg TimeoutConfig) gin.HandlerFunc {
+	if cfg.Timeout <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	excluded := newPathMatcher(cfg.ExcludePaths)
+
+	return func(c *gin.Context) {
+		if route := c.FullPath(); route != "" && excluded.match(route) {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.Timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if !stderrors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return
+		}
+		c.Set(DeadlineExceededKey, true)
+		if !c.Writer.Written() {
+			e := errors.ErrRequestTimeout
+			response.Abort(c, e.HTTPStatus, e.Code, e.Message)
+		}
+	}
+}
+
+// DeadlineExceeded 返回请求是否因处理 deadline 到期被取消
+func DeadlineExceeded(c *gin.Context) bool {
+	return c.GetBool(DeadlineExceededKey)
+}
@@ -0,0 +1,120 @@
+// Package middleware 提供 HTTP 中间件
+//
+// 本文件包含请求处理 deadline 的单元测试
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// timeoutTestEngine 构建挂载日志与超时中间件的引擎
+// /wait 阻塞到请求 context 结束且不写响应，/fast 立即返回 200
+func timeoutTestEngine(timeout time.Duration) (*gin.Engine, *recordingLogger) {
+	gin.SetMode(gin.TestMode)
+
+	rec := &recordingLogger{}
+	engine := gin.New()
+	engine.Use(LoggerWithConfig(rec, LoggerConfig{}), Timeout(timeout))
+	engine.GET("/wait", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+	})
+	engine.GET("/fast", func(c *gin.Context) {
+		_, hasDeadline := c.Request.Context().Deadline()
+		c.JSON(http.StatusOK, gin.H{"has_deadline": hasDeadline})
+	})
+	return engine, rec
+}
+
+func TestTimeout_DeadlineExceededMarkedAndLogged(t *testing.T) {
+	// 准备
+	engine, rec := timeoutTestEngine(20 * time.Millisecond)
+
+	// 执行
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/wait", nil))
+
+	// 断言：handler 未写响应时返回 504，日志标记 deadline_exceeded
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	var body struct {
+		Code int `json:"code"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, errors.CodeTimeout, body.Code)
+
+	entry := rec.last(t)
+	assert.Equal(t, "error", entry.level)
+	assert.Contains(t, entry.fields, "deadline_exceeded")
+}
+
+func TestTimeout_CompletedRequestNotMarked(t *testing.T) {
+	// 准备
+	engine, rec := timeoutTestEngine(time.Second)
+
+	// 执行
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+
+	// 断言：deadline 已设置进请求 context，未到期的请求不标记
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"has_deadline":true}`, w.Body.String())
+	assert.NotContains(t, rec.last(t).fields, "deadline_exceeded")
+}
+
+func TestTimeout_ClientCancelNotMarked(t *testing.T) {
+	// 准备：客户端在 deadline 之前断开
+	engine, rec := timeoutTestEngine(time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// 执行
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/wait", nil).WithContext(ctx))
+
+	// 断言
+	assert.NotContains(t, rec.last(t).fields, "deadline_exceeded")
+}
+
+func TestTimeout_DisabledSetsNoDeadline(t *testing.T) {
+	engine, _ := timeoutTestEngine(0)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+
+	assert.JSONEq(t, `{"has_deadline":false}`, w.Body.String())
+}
+
+func TestTimeout_ExcludedRouteSetsNoDeadline(t *testing.T) {
+	// 准备：/stream/:id 豁免，/fast 照常设置 deadline
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(TimeoutWithConfig(TimeoutConfig{
+		Timeout:      time.Second,
+		ExcludePaths: []string{"/stream/:id"},
+	}))
+	hasDeadline := func(c *gin.Context) {
+		_, ok := c.Request.Context().Deadline()
+		c.JSON(http.StatusOK, gin.H{"has_deadline": ok})
+	}
+	engine.GET("/stream/:id", hasDeadline)
+	engine.GET("/fast", hasDeadline)
+
+	// 执行
+	excluded := httptest.NewRecorder()
+	engine.ServeHTTP(excluded, httptest.NewRequest(http.MethodGet, "/stream/42", nil))
+	normal := httptest.NewRecorder()
+	engine.ServeHTTP(normal, httptest.NewRequest(http.MethodGet, "/fast", nil))
+
+	// 断言：按路由模板匹配豁免
+	assert.JSONEq(t, `{"has_deadline":false}`, excluded.Body.String())
+	assert.JSONEq(t, `{"has_deadline":true}`, normal.Body.String())
+}
@@ -5,6 +5,7 @@
 //
 // 包结构：
 // - base.go: 基础模型，包含通用字段
+// - id.go: 主键 ID 生成器
 // - user.go: 用户模型
 // - dto.go: 数据传输对象
 package model
@@ -12,14 +13,13 @@ package model
 import (
 	"time"
 
-	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 // BaseModel 基础模型
 // 所有数据模型都应该嵌入此结构体，以获得通用字段
 type BaseModel struct {
-	// ID 主键，使用 UUID
+	// ID 主键，由 DefaultIDGenerator 生成（默认 UUID）
 	ID string `gorm:"type:varchar(36);primaryKey" json:"id"`
 	// CreatedAt 创建时间
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
@@ -27,10 +27,10 @@ type BaseModel struct {
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
-// BeforeCreate GORM 钩子：创建前自动生成 UUID
+// BeforeCreate GORM 钩子：创建前自动生成 ID
 func (m *BaseModel) BeforeCreate(tx *gorm.DB) error {
 	if m.ID == "" {
-		m.ID = uuid.New().String()
+		m.ID = DefaultIDGenerator.NewID()
 	}
 	return nil
 }
@@ -0,0 +1,88 @@
+// Package model 定义了应用程序的数据模型
+package model
+
+import "time"
+
+// 个人数据导出的状态
+const (
+	// DataExportPending 已提交，等待生成
+	DataExportPending = "pending"
+	// DataExportRunning 生成中
+	DataExportRunning = "running"
+	// DataExportFailed 生成失败，再次请求会重新生成
+	DataExportFailed = "failed"
+)
+
+// DataExportStatus 个人数据导出的生成进度
+// 导出尚未生成完成时返回，客户端稍后再次请求同一地址即可下载
+type DataExportStatus struct {
+	// JobID 生成任务 ID
+	JobID string `json:"job_id"`
+	// Status 状态：pending、running、failed
+	Status string `json:"status"`
+	// Error 失败原因
+	Error string `json:"error,omitempty"`
+	// RequestedAt 提交时间
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// UserDataExport 用户的完整个人数据（GDPR 数据可携带权）
+// 包含各子系统中与该用户相关的全部记录；密码哈希、令牌明文等凭据不导出
+type UserDataExport struct {
+	// ExportedAt 生成时间
+	ExportedAt time.Time `json:"exported_at"`
+	// Profile 用户资料，包含资料可见性等偏好设置
+	Profile *UserResponse `json:"profile"`
+	// Tags 用户标签
+	Tags []string `json:"tags"`
+	// LoginHistory 登录历史，按时间倒序
+	LoginHistory []LoginHistoryResponse `json:"login_history"`
+	// Sessions 登录会话（刷新令牌）记录，包括已退出与已过期的会话
+	Sessions []DataExportSession `json:"sessions"`
+	// PersonalAccessTokens 未撤销的个人访问令牌（不含令牌明文）
+	PersonalAccessTokens []PersonalAccessTokenResponse `json:"personal_access_tokens"`
+	// SecurityEvents 安全事件（如异地登录提醒）
+	SecurityEvents []DataExportSecurityEvent `json:"security_events"`
+	// ChangeLog 资料关键字段的变更记录
+	ChangeLog []DataExportChange `json:"change_log"`
+	// RiskReportUsage 风险报告使用记录
+	RiskReportUsage []RiskReportUsageResponse `json:"risk_report_usage"`
+}
+
+// DataExportSession 导出的登录会话
+type DataExportSession struct {
+	// CreatedAt 登录（签发）时间
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt 过期时间
+	ExpiresAt time.Time `json:"expires_at"`
+	// RevokedAt 退出或被撤销的时间
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	// Active 导出时是否仍然有效
+	Active bool `json:"active"`
+}
+
+// DataExportSecurityEvent 导出的安全事件
+type DataExportSecurityEvent struct {
+	// Type 事件类型
+	Type string `json:"type"`
+	// IP 触发事件的 IP
+	IP string `json:"ip"`
+	// Location 地理位置描述
+	Location string `json:"location,omitempty"`
+	// Detail 事件说明
+	Detail string `json:"detail,omitempty"`
+	// CreatedAt 发生时间
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DataExportChange 导出的资料变更记录
+type DataExportChange struct {
+	// Field 变更的字段
+	Field string `json:"field"`
+	// OldValue 变更前的值
+	OldValue string `json:"old_value"`
+	// NewValue 变更后的值
+	NewValue string `json:"new_value"`
+	// ChangedAt 变更时间
+	ChangedAt time.Time `json:"changed_at"`
+}
@@ -12,16 +12,21 @@ import "time"
 
 // RegisterRequest 用户注册请求
 type RegisterRequest struct {
-	// Username 用户名，必填，3-30 个字符，只能包含字母、数字和下划线
-	Username string `json:"username" binding:"required,min=3,max=30,alphanum"`
-	// Email 邮箱地址，必填，有效的邮箱格式
-	Email string `json:"email" binding:"required,email,max=100"`
+	// Username 用户名，必填
+	// 长度、字符集与保留字由 security.username 策略在服务层校验（默认 3-30 位字母或数字）
+	Username string `json:"username" binding:"required,max=50"`
+	// Email 邮箱地址，有效的邮箱格式；是否必填由 security.require_email 配置决定
+	Email string `json:"email" binding:"omitempty,email,max=100"`
 	// Password 密码，必填，6-50 个字符
 	Password string `json:"password" binding:"required,min=6,max=50"`
 	// ConfirmPassword 确认密码，必须与密码一致
 	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=Password"`
 	// Nickname 昵称，可选，最多 50 个字符
 	Nickname string `json:"nickname" binding:"omitempty,max=50"`
+	// Source 注册来源渠道，可选；未填写时依次取 utm_source 查询参数与 X-Registration-Source 请求头
+	Source string `json:"source" binding:"omitempty,max=50"`
+	// ReferralCode 推荐码，可选
+	ReferralCode string `json:"referral_code" binding:"omitempty,max=50"`
 }
 
 // LoginRequest 用户登录请求
@@ -44,6 +49,10 @@ type LoginResponse struct {
 	ExpiresIn int64 `json:"expires_in"`
 	// User 用户信息
 	User *UserResponse `json:"user"`
+	// Permissions 用户权限清单，由角色推导
+	Permissions []string `json:"permissions"`
+	// FirstLogin 是否首次登录（本次登录前从未登录过），前端据此展示引导
+	FirstLogin bool `json:"first_login"`
 }
 
 // RefreshTokenRequest 刷新令牌请求
@@ -64,6 +73,27 @@ type RefreshTokenResponse struct {
 	ExpiresIn int64 `json:"expires_in"`
 }
 
+// ImpersonateResponse 模拟登录响应
+// 只返回短期访问令牌，不签发刷新令牌
+type ImpersonateResponse struct {
+	// AccessToken 以目标用户身份访问的模拟令牌
+	AccessToken string `json:"access_token"`
+	// TokenType 令牌类型
+	TokenType string `json:"token_type"`
+	// ExpiresIn 过期时间（秒）
+	ExpiresIn int64 `json:"expires_in"`
+	// ImpersonatedBy 真实操作者（管理员）ID
+	ImpersonatedBy string `json:"impersonated_by"`
+	// User 被模拟的用户
+	User *UserResponse `json:"user"`
+}
+
+// DebugTokenRequest 调试解析令牌请求
+type DebugTokenRequest struct {
+	// Token 待解析的令牌
+	Token string `json:"token" binding:"required"`
+}
+
 // ChangePasswordRequest 修改密码请求
 type ChangePasswordRequest struct {
 	// OldPassword 旧密码
@@ -92,6 +122,8 @@ type UpdateUserRequest struct {
 	Gender *int8 `json:"gender" binding:"omitempty,min=0,max=2"`
 	// Birthday 生日
 	Birthday *time.Time `json:"birthday" binding:"omitempty"`
+	// ProfileVisibility 资料可见性: public, friends, private
+	ProfileVisibility string `json:"profile_visibility" binding:"omitempty,oneof=public friends private"`
 }
 
 // UpdateEmailRequest 更新邮箱请求
@@ -102,14 +134,6 @@ type UpdateEmailRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-// UpdateUsernameRequest 更新用户名请求
-type UpdateUsernameRequest struct {
-	// NewUsername 新用户名
-	NewUsername string `json:"new_username" binding:"required,min=3,max=30,alphanum"`
-	// Password 当前密码（用于验证身份）
-	Password string `json:"password" binding:"required"`
-}
-
 // UserListRequest 用户列表请求（管理员使用）
 type UserListRequest struct {
 	// Page 页码
@@ -124,12 +148,46 @@ type UserListRequest struct {
 	Status *int8 `json:"status" form:"status" binding:"omitempty,min=0,max=2"`
 	// Role 用户角色过滤
 	Role string `json:"role" form:"role" binding:"omitempty,oneof=user admin"`
+	// Source 注册来源过滤（精确匹配，不区分大小写）
+	Source string `json:"source" form:"source" binding:"omitempty,max=50"`
+	// LastLoginBefore 最后登录早于该时间（RFC3339），从未登录的用户按注册时间判断，用于查找久未登录的用户
+	LastLoginBefore string `json:"last_login_before" form:"last_login_before"`
+	// LastLoginAfter 最后登录不早于该时间（RFC3339），从未登录的用户不会匹配
+	LastLoginAfter string `json:"last_login_after" form:"last_login_after"`
 	// SortBy 排序字段
-	SortBy string `json:"sort_by" form:"sort_by" binding:"omitempty,oneof=created_at updated_at username email"`
+	SortBy string `json:"sort_by" form:"sort_by" binding:"omitempty,oneof=created_at updated_at username email last_login_at"`
 	// SortOrder 排序方向
 	SortOrder string `json:"sort_order" form:"sort_order" binding:"omitempty,oneof=asc desc"`
+	// Preload 需要预加载的关联（可多值），例如 preload=Tags
+	Preload []string `json:"preload" form:"preload" binding:"omitempty,dive,oneof=Tags"`
+	// SkipTotal 跳过总数统计，分页信息中 total 为 -1 并标记 total_unknown
+	SkipTotal bool `json:"skip_total" form:"skip_total"`
+	// Explain 返回查询的执行计划而不是数据，仅 debug 模式下生效
+	Explain bool `json:"explain" form:"explain"`
 }
 
+// UserLookupRequest 按确切邮箱或用户名查找用户请求（管理员使用），两者必须且只能提供一个
+type UserLookupRequest struct {
+	// Email 邮箱（精确匹配）
+	Email string `form:"email" binding:"omitempty,email,max=100"`
+	// Username 用户名（精确匹配）
+	Username string `form:"username" binding:"omitempty,max=50"`
+}
+
+// QueryPlan 查询执行计划（调试用）
+type QueryPlan struct {
+	// SQL 代入参数后的查询语句，仅供阅读
+	SQL string `json:"sql"`
+	// Plan EXPLAIN 的结果行，列名随数据库驱动不同
+	Plan QueryPlanRows `json:"plan"`
+}
+
+// QueryPlanRows EXPLAIN 的结果行，键为数据库返回的列名
+type QueryPlanRows []map[string]interface{}
+
+// OpaqueJSON 实现 response.Opaque，响应中的列名原样输出
+func (QueryPlanRows) OpaqueJSON() {}
+
 // GetDefaultPage 获取默认页码
 func (r *UserListRequest) GetDefaultPage() int {
 	if r.Page < 1 {
@@ -149,20 +207,138 @@ func (r *UserListRequest) GetDefaultPageSize(defaultSize, maxSize int) int {
 	return r.PageSize
 }
 
-// CreateUserRequest 创建用户请求（管理员使用）
-type CreateUserRequest struct {
-	// Username 用户名
-	Username string `json:"username" binding:"required,min=3,max=30,alphanum"`
-	// Email 邮箱
-	Email string `json:"email" binding:"required,email,max=100"`
-	// Password 密码
-	Password string `json:"password" binding:"required,min=6,max=50"`
-	// Nickname 昵称
-	Nickname string `json:"nickname" binding:"omitempty,max=50"`
-	// Role 角色
-	Role string `json:"role" binding:"omitempty,oneof=user admin"`
-	// Status 状态
+// UserExportRequest 用户导出请求（管理员使用）
+type UserExportRequest struct {
+	// Format 导出格式: csv, xlsx；为空时由 Accept 头决定，默认 csv
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx"`
+	// Username 用户名搜索（模糊匹配）
+	Username string `form:"username" binding:"omitempty,max=50"`
+	// Email 邮箱搜索（模糊匹配）
+	Email string `form:"email" binding:"omitempty,max=100"`
+	// Status 用户状态过滤
+	Status *int8 `form:"status" binding:"omitempty,min=0,max=2"`
+	// Role 用户角色过滤
+	Role string `form:"role" binding:"omitempty,oneof=user admin"`
+	// Columns 导出列，逗号分隔，按参数顺序输出，如 id,username,email；为空时导出全部列
+	Columns string `form:"columns" binding:"omitempty,max=200"`
+}
+
+// MaxBatchGetUsers 单次批量获取用户的 ID 数量上限
+const MaxBatchGetUsers = 100
+
+// BatchGetUsersRequest 批量获取用户请求
+type BatchGetUsersRequest struct {
+	// IDs 用户 ID 列表
+	IDs []string `json:"ids" binding:"required,min=1,max=100,dive,required,max=36"`
+}
+
+// BatchGetUsersResponse 批量获取用户响应
+type BatchGetUsersResponse struct {
+	// Users 找到的用户，顺序与请求中的 ID 一致
+	Users []*UserResponse `json:"users"`
+	// Missing 不存在或查看者不能查看完整资料的用户 ID
+	Missing []string `json:"missing"`
+}
+
+// MaxBatchUpdateRole 单次批量修改角色的用户数量上限
+const MaxBatchUpdateRole = 100
+
+// BatchUpdateRoleRequest 批量修改用户角色请求
+type BatchUpdateRoleRequest struct {
+	// IDs 用户 ID 列表
+	IDs []string `json:"ids" binding:"required,min=1,max=100,dive,required,max=36"`
+	// Role 目标角色
+	Role string `json:"role" binding:"required"`
+}
+
+// BatchUpdateRoleResponse 批量修改用户角色响应
+type BatchUpdateRoleResponse struct {
+	// Role 目标角色
+	Role string `json:"role"`
+	// Total 请求中的用户数（去重后）
+	Total int `json:"total"`
+	// Updated 角色实际发生变化的用户数
+	Updated int64 `json:"updated"`
+}
+
+// MaxBatchUpdateStatus 单次批量修改状态的用户数量上限
+const MaxBatchUpdateStatus = 100
+
+// BatchUpdateStatusRequest 批量修改用户状态请求
+type BatchUpdateStatusRequest struct {
+	// IDs 用户 ID 列表
+	IDs []string `json:"ids" binding:"required,min=1,max=100,dive,required,max=36"`
+	// Status 目标状态：0-禁用，1-正常，2-未激活
+	Status *int8 `json:"status" binding:"required,min=0,max=2"`
+}
+
+// BatchUpdateStatusResponse 批量修改用户状态响应
+type BatchUpdateStatusResponse struct {
+	// Status 目标状态
+	Status int8 `json:"status"`
+	// Total 请求中的用户数（去重后）
+	Total int `json:"total"`
+	// Succeeded 修改成功的用户数
+	Succeeded int `json:"succeeded"`
+	// Failed 修改失败的用户数
+	Failed int `json:"failed"`
+	// Errors 失败用户的错误明细
+	Errors []UserImportError `json:"errors"`
+}
+
+// UserImportError 用户导入或批量操作中单项的错误
+type UserImportError struct {
+	// Row 行号：导入时与表格中的行号一致（表头为第 1 行），批量操作时为 ID 在请求列表中的序号（从 1 开始）
+	Row int `json:"row"`
+	// ID 批量操作中失败的用户 ID，导入时为空
+	ID string `json:"id,omitempty"`
+	// Username 该行的用户名
+	Username string `json:"username,omitempty"`
+	// Message 错误原因
+	Message string `json:"message"`
+}
+
+// UserImportResult 用户导入结果
+type UserImportResult struct {
+	// Total 数据行总数（不含表头和空行）
+	Total int `json:"total"`
+	// Created 成功创建的用户数
+	Created int `json:"created"`
+	// Failed 失败的行数
+	Failed int `json:"failed"`
+	// Errors 失败行的错误明细
+	Errors []UserImportError `json:"errors"`
+}
+
+// BatchProgress 批量操作进度，流式返回时作为 progress 事件的数据
+type BatchProgress struct {
+	// Processed 已处理的行数
+	Processed int `json:"processed"`
+	// Total 需要处理的总行数
+	Total int `json:"total"`
+	// Succeeded 成功的行数
+	Succeeded int `json:"succeeded"`
+	// Failed 失败的行数
+	Failed int `json:"failed"`
+	// Error 刚处理的这一项失败时的错误明细
+	Error *UserImportError `json:"error,omitempty"`
+}
+
+// BatchTagRequest 批量打标请求（管理员使用）
+// 给所有符合过滤条件的用户打上同一个标签
+type BatchTagRequest struct {
+	// Tag 标签名称
+	Tag string `json:"tag" binding:"required,max=50"`
+	// Status 用户状态过滤
 	Status *int8 `json:"status" binding:"omitempty,min=0,max=2"`
+	// Role 用户角色过滤
+	Role string `json:"role" binding:"omitempty,oneof=user admin"`
+}
+
+// DeleteUserRequest 删除用户请求参数
+type DeleteUserRequest struct {
+	// Hard 是否永久删除（默认软删除）
+	Hard bool `form:"hard"`
 }
 
 // AdminUpdateUserRequest 管理员更新用户请求
@@ -170,14 +346,39 @@ type AdminUpdateUserRequest struct {
 	UpdateUserRequest
 	// Email 邮箱（管理员可直接修改）
 	Email string `json:"email" binding:"omitempty,email,max=100"`
-	// Username 用户名（管理员可直接修改）
-	Username string `json:"username" binding:"omitempty,min=3,max=30,alphanum"`
+	// Username 用户名（管理员可直接修改），格式按 security.username 策略校验
+	Username string `json:"username" binding:"omitempty,max=50"`
 	// Status 状态
 	Status *int8 `json:"status" binding:"omitempty,min=0,max=2"`
 	// Role 角色
 	Role string `json:"role" binding:"omitempty,oneof=user admin"`
 }
 
+// LoginHistoryResponse 登录历史响应
+type LoginHistoryResponse struct {
+	// IP 登录 IP
+	IP string `json:"ip"`
+	// LoginAt 登录时间
+	LoginAt time.Time `json:"login_at"`
+}
+
+// UserDetailResponse 用户详细审计信息响应（管理员使用）
+// 聚合用户核心信息、最近登录历史、活跃会话数和标签
+type UserDetailResponse struct {
+	// User 用户核心信息
+	User *UserResponse `json:"user"`
+	// RecentLogins 最近登录历史
+	RecentLogins []LoginHistoryResponse `json:"recent_logins"`
+	// ActiveSessions 活跃会话数（未撤销且未过期的刷新令牌数）
+	ActiveSessions int64 `json:"active_sessions"`
+	// Tags 用户标签
+	Tags []string `json:"tags"`
+	// Partial 是否有部分数据查询失败
+	Partial bool `json:"partial"`
+	// Errors 查询失败的部分及原因，键为部分名称
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
 // ====================================================================
 // 通用响应 DTO
 // ====================================================================
@@ -208,18 +409,66 @@ type ReadyResponse struct {
 	Status string `json:"status"`
 	// Database 数据库状态
 	Database string `json:"database"`
+	// Pool 连接池健康状况，数据库不可用时为空
+	Pool *PoolHealth `json:"pool,omitempty"`
 	// Timestamp 当前时间戳
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// PoolHealth 数据库连接池健康状况
+type PoolHealth struct {
+	// Status 连接池状态: healthy, degraded
+	Status string `json:"status"`
+	// InUse 使用中的连接数
+	InUse int `json:"in_use"`
+	// MaxOpenConnections 最大连接数，0 表示不限
+	MaxOpenConnections int `json:"max_open_connections"`
+	// WaitCount 累计等待连接的次数
+	WaitCount int64 `json:"wait_count"`
+	// Reasons 降级原因
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// 连接池状态
+const (
+	PoolStatusHealthy  = "healthy"
+	PoolStatusDegraded = "degraded"
+)
+
+// VersionResponse 版本信息响应
+type VersionResponse struct {
+	// Version 应用版本号
+	Version string `json:"version"`
+	// BuildTime 构建时间
+	BuildTime string `json:"build_time"`
+	// GitCommit Git 提交哈希
+	GitCommit string `json:"git_commit"`
+	// GoVersion 编译所用的 Go 版本
+	GoVersion string `json:"go_version"`
+}
+
 // ====================================================================
 // 验证错误相关
 // ====================================================================
 
+// 字段错误类别，便于客户端区分处理
+const (
+	// FieldErrorKindMissing 缺少必填字段
+	FieldErrorKindMissing = "missing"
+	// FieldErrorKindType 字段类型错误
+	FieldErrorKindType = "type"
+	// FieldErrorKindUnknown 未知字段（仅严格模式）
+	FieldErrorKindUnknown = "unknown"
+	// FieldErrorKindInvalid 字段值不满足验证规则
+	FieldErrorKindInvalid = "invalid"
+)
+
 // FieldError 字段验证错误
 type FieldError struct {
 	// Field 字段名
 	Field string `json:"field"`
+	// Kind 错误类别: missing, type, unknown, invalid
+	Kind string `json:"kind"`
 	// Tag 验证标签
 	Tag string `json:"tag"`
 	// Message 错误消息
@@ -231,3 +480,35 @@ type ValidationErrors struct {
 	// Errors 错误列表
 	Errors []FieldError `json:"errors"`
 }
+
+// UpdateFeatureRequest 切换功能开关请求
+type UpdateFeatureRequest struct {
+	// Enabled 是否开启
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// UpdateDatabasePoolRequest 调整数据库连接池请求，未填写的字段保持当前值
+type UpdateDatabasePoolRequest struct {
+	// MaxOpenConns 最大打开连接数
+	MaxOpenConns *int `json:"max_open_conns"`
+	// MaxIdleConns 最大空闲连接数，不能超过最大打开连接数
+	MaxIdleConns *int `json:"max_idle_conns"`
+	// ConnMaxLifetime 连接最大生存时间（分钟），0 表示不限
+	ConnMaxLifetime *int `json:"conn_max_lifetime"`
+	// ConnMaxIdleTime 空闲连接最大生存时间（分钟），0 表示不限
+	ConnMaxIdleTime *int `json:"conn_max_idle_time"`
+}
+
+// DatabasePoolResponse 数据库连接池当前配置与统计
+type DatabasePoolResponse struct {
+	// MaxOpenConns 最大打开连接数，0 表示不限（仅启动配置可设为 0）
+	MaxOpenConns int `json:"max_open_conns"`
+	// MaxIdleConns 最大空闲连接数
+	MaxIdleConns int `json:"max_idle_conns"`
+	// ConnMaxLifetime 连接最大生存时间（分钟）
+	ConnMaxLifetime int `json:"conn_max_lifetime"`
+	// ConnMaxIdleTime 空闲连接最大生存时间（分钟）
+	ConnMaxIdleTime int `json:"conn_max_idle_time"`
+	// Stats 连接池统计
+	Stats map[string]interface{} `json:"stats"`
+}
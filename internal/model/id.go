@@ -0,0 +1,69 @@
+// Package model 定义了应用程序的数据模型
+package model
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+)
+
+// ID 生成器类型名称，对应配置项 database.id_generator
+const (
+	// IDGeneratorUUID 随机 UUID（v4），默认
+	IDGeneratorUUID = "uuid"
+	// IDGeneratorULID 按时间有序的 ULID，写入时索引局部性更好
+	IDGeneratorULID = "ulid"
+)
+
+// IDGenerator 主键 ID 生成器接口
+type IDGenerator interface {
+	// NewID 生成一个新的 ID
+	NewID() string
+}
+
+// UUIDGenerator 生成 UUID v4 字符串（36 个字符）
+type UUIDGenerator struct{}
+
+// NewID 生成一个新的 UUID v4
+func (UUIDGenerator) NewID() string {
+	return uuid.New().String()
+}
+
+// ULIDGenerator 生成 ULID 字符串（26 个字符）
+// 同一毫秒内生成的 ID 单调递增，可安全并发使用
+type ULIDGenerator struct {
+	mu      sync.Mutex
+	entropy *ulid.MonotonicEntropy
+}
+
+// NewULIDGenerator 创建 ULID 生成器
+func NewULIDGenerator() *ULIDGenerator {
+	return &ULIDGenerator{entropy: ulid.Monotonic(rand.Reader, 0)}
+}
+
+// NewID 生成一个新的 ULID
+func (g *ULIDGenerator) NewID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return ulid.MustNew(ulid.Timestamp(time.Now()), g.entropy).String()
+}
+
+// DefaultIDGenerator 模型创建时使用的 ID 生成器，默认 UUID v4
+// 应在启动阶段替换，运行期间不应再修改
+var DefaultIDGenerator IDGenerator = UUIDGenerator{}
+
+// NewIDGenerator 根据名称创建 ID 生成器
+func NewIDGenerator(name string) (IDGenerator, error) {
+	switch name {
+	case "", IDGeneratorUUID:
+		return UUIDGenerator{}, nil
+	case IDGeneratorULID:
+		return NewULIDGenerator(), nil
+	default:
+		return nil, fmt.Errorf("不支持的 ID 生成器: %s", name)
+	}
+}
@@ -0,0 +1,58 @@
+// Package model 定义了应用程序的数据模型
+//
+// 本文件包含 ID 生成器的单元测试
+package model
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ulidPattern = regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`)
+)
+
+// useIDGenerator 在测试期间替换默认 ID 生成器，结束后恢复
+func useIDGenerator(t *testing.T, g IDGenerator) {
+	t.Helper()
+	previous := DefaultIDGenerator
+	DefaultIDGenerator = g
+	t.Cleanup(func() { DefaultIDGenerator = previous })
+}
+
+func TestBeforeCreate_DefaultUUID(t *testing.T) {
+	user := &User{}
+	require.NoError(t, user.BeforeCreate(nil))
+	assert.Regexp(t, uuidPattern, user.ID)
+}
+
+func TestBeforeCreate_ULIDGenerator(t *testing.T) {
+	g, err := NewIDGenerator(IDGeneratorULID)
+	require.NoError(t, err)
+	useIDGenerator(t, g)
+
+	first := &User{}
+	second := &User{}
+	require.NoError(t, first.BeforeCreate(nil))
+	require.NoError(t, second.BeforeCreate(nil))
+
+	assert.Regexp(t, ulidPattern, first.ID)
+	assert.Regexp(t, ulidPattern, second.ID)
+	// ULID 按生成顺序递增
+	assert.Less(t, first.ID, second.ID)
+}
+
+func TestBeforeCreate_KeepsExistingID(t *testing.T) {
+	user := &User{BaseModel: BaseModel{ID: "preset-id"}}
+	require.NoError(t, user.BeforeCreate(nil))
+	assert.Equal(t, "preset-id", user.ID)
+}
+
+func TestNewIDGenerator_Unknown(t *testing.T) {
+	_, err := NewIDGenerator("snowflake")
+	assert.Error(t, err)
+}
@@ -0,0 +1,18 @@
+// Package model 定义了应用程序的数据模型
+package model
+
+// PasswordHistory 密码历史记录
+// 用户修改密码时保存被替换掉的旧密码哈希，用于阻止换回最近用过的密码
+type PasswordHistory struct {
+	BaseModel
+
+	// UserID 用户 ID
+	UserID string `gorm:"type:varchar(36);not null;index" json:"user_id"`
+	// PasswordHash 旧密码的 bcrypt 哈希，不对外暴露
+	PasswordHash string `gorm:"type:varchar(255);not null" json:"-"`
+}
+
+// TableName 指定表名
+func (PasswordHistory) TableName() string {
+	return "password_histories"
+}
@@ -0,0 +1,90 @@
+// Package model 定义了应用程序的数据模型
+//
+// 本文件包含基于角色的权限定义（RBAC）。
+// 权限由角色推导，不单独存储；前端根据权限清单渲染菜单，
+// 后端接口仍以 RequireAdmin 等中间件为准。
+package model
+
+import "sort"
+
+// 权限常量，格式为 "资源:操作"
+const (
+	// PermissionProfileRead 查看自己的资料
+	PermissionProfileRead = "profile:read"
+	// PermissionProfileUpdate 修改自己的资料
+	PermissionProfileUpdate = "profile:update"
+	// PermissionPasswordChange 修改自己的密码
+	PermissionPasswordChange = "password:change"
+	// PermissionUsersRead 查看其他用户的公开信息
+	PermissionUsersRead = "users:read"
+
+	// PermissionUsersList 查询用户列表
+	PermissionUsersList = "users:list"
+	// PermissionUsersUpdate 修改任意用户
+	PermissionUsersUpdate = "users:update"
+	// PermissionUsersDelete 删除用户
+	PermissionUsersDelete = "users:delete"
+	// PermissionUsersImport 批量导入用户
+	PermissionUsersImport = "users:import"
+	// PermissionUsersExport 导出用户
+	PermissionUsersExport = "users:export"
+	// PermissionUsersAudit 查看用户审计详情与变更历史
+	PermissionUsersAudit = "users:audit"
+	// PermissionTokensRevoke 吊销用户令牌
+	PermissionTokensRevoke = "tokens:revoke"
+	// PermissionJobsManage 提交与查询后台任务
+	PermissionJobsManage = "jobs:manage"
+)
+
+// basePermissions 所有登录用户具备的基础权限
+var basePermissions = []string{
+	PermissionProfileRead,
+	PermissionProfileUpdate,
+	PermissionPasswordChange,
+	PermissionUsersRead,
+}
+
+// rolePermissions 角色与额外权限的映射，角色权限 = 基础权限 + 额外权限
+var rolePermissions = map[string][]string{
+	RoleUser: {},
+	RoleAdmin: {
+		PermissionUsersList,
+		PermissionUsersUpdate,
+		PermissionUsersDelete,
+		PermissionUsersImport,
+		PermissionUsersExport,
+		PermissionUsersAudit,
+		PermissionTokensRevoke,
+		PermissionJobsManage,
+	},
+}
+
+// IsValidRole 检查角色是否为已定义的角色
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// PermissionsForRole 返回角色的权限清单（已排序）
+// 未知角色只有基础权限
+func PermissionsForRole(role string) []string {
+	extra := rolePermissions[role]
+	permissions := make([]string, 0, len(basePermissions)+len(extra))
+	permissions = append(permissions, basePermissions...)
+	permissions = append(permissions, extra...)
+	sort.Strings(permissions)
+	return permissions
+}
+
+// Permissions 返回用户的权限清单
+func (u *User) Permissions() []string {
+	return PermissionsForRole(u.Role)
+}
+
+// PermissionsResponse 当前用户权限响应
+type PermissionsResponse struct {
+	// Role 角色
+	Role string `json:"role"`
+	// Permissions 权限清单
+	Permissions []string `json:"permissions"`
+}
@@ -0,0 +1,109 @@
+// Package model 定义了应用程序的数据模型
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// PersonalAccessToken 个人访问令牌（PAT）
+// 供脚本等非交互场景长期使用，只保存令牌的 SHA-256 哈希，明文只在创建时返回一次；
+// 令牌只能访问 scopes 内的接口，且不超过用户当前角色的权限
+type PersonalAccessToken struct {
+	BaseModel
+
+	// UserID 所属用户 ID
+	UserID string `gorm:"type:varchar(36);not null;index" json:"user_id"`
+	// Name 令牌名称，便于用户区分用途
+	Name string `gorm:"type:varchar(100);not null" json:"name"`
+	// TokenHash 令牌的 SHA-256 哈希（十六进制），不对外暴露
+	TokenHash string `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	// TokenPrefix 令牌开头的若干字符，用于在列表中辨认令牌
+	TokenPrefix string `gorm:"type:varchar(16);not null" json:"token_prefix"`
+	// Scopes 授权范围，以空格分隔的权限标识，例如 "profile:read users:read"
+	Scopes string `gorm:"type:varchar(500);not null" json:"-"`
+	// LastUsedAt 最近一次用于认证的时间
+	LastUsedAt *time.Time `gorm:"type:datetime" json:"last_used_at,omitempty"`
+	// ExpiresAt 过期时间，为空表示永不过期
+	ExpiresAt *time.Time `gorm:"type:datetime" json:"expires_at,omitempty"`
+	// RevokedAt 撤销时间，为空表示未撤销
+	RevokedAt *time.Time `gorm:"type:datetime;index" json:"revoked_at,omitempty"`
+}
+
+// TableName 指定表名
+func (PersonalAccessToken) TableName() string {
+	return "personal_access_tokens"
+}
+
+// ScopeList 返回授权范围列表
+func (t *PersonalAccessToken) ScopeList() []string {
+	return strings.Fields(t.Scopes)
+}
+
+// HasScope 检查令牌是否包含指定授权范围
+func (t *PersonalAccessToken) HasScope(scope string) bool {
+	for _, s := range t.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired 检查令牌是否已过期
+func (t *PersonalAccessToken) IsExpired() bool {
+	return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
+
+// IsRevoked 检查令牌是否已撤销
+func (t *PersonalAccessToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// ToResponse 转换为响应结构
+func (t *PersonalAccessToken) ToResponse() *PersonalAccessTokenResponse {
+	return &PersonalAccessTokenResponse{
+		ID:          t.ID,
+		Name:        t.Name,
+		TokenPrefix: t.TokenPrefix,
+		Scopes:      t.ScopeList(),
+		LastUsedAt:  t.LastUsedAt,
+		ExpiresAt:   t.ExpiresAt,
+		CreatedAt:   t.CreatedAt,
+	}
+}
+
+// CreatePersonalAccessTokenRequest 创建个人访问令牌请求
+type CreatePersonalAccessTokenRequest struct {
+	// Name 令牌名称
+	Name string `json:"name" binding:"required,max=100"`
+	// Scopes 授权范围，取值为权限标识（见 GET /users/me/permissions），不能超出自己的权限
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,required"`
+	// ExpiresInDays 有效天数，不填表示永不过期
+	ExpiresInDays int `json:"expires_in_days" binding:"omitempty,min=1,max=3650"`
+}
+
+// PersonalAccessTokenResponse 个人访问令牌响应（不含令牌明文）
+type PersonalAccessTokenResponse struct {
+	// ID 令牌 ID，用于撤销
+	ID string `json:"id"`
+	// Name 令牌名称
+	Name string `json:"name"`
+	// TokenPrefix 令牌开头的若干字符
+	TokenPrefix string `json:"token_prefix"`
+	// Scopes 授权范围
+	Scopes []string `json:"scopes"`
+	// LastUsedAt 最近使用时间
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	// ExpiresAt 过期时间，为空表示永不过期
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// CreatedAt 创建时间
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreatePersonalAccessTokenResponse 创建个人访问令牌响应
+type CreatePersonalAccessTokenResponse struct {
+	PersonalAccessTokenResponse
+	// Token 令牌明文，只在创建时返回一次，请妥善保存
+	Token string `json:"token"`
+}
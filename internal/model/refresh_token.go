@@ -0,0 +1,39 @@
+// Package model 定义了应用程序的数据模型
+package model
+
+import (
+	"time"
+)
+
+// RefreshToken 刷新令牌记录
+// 签发刷新令牌时入库，刷新时校验其存在且未被撤销，
+// 从而支持登出、改密等场景下的主动撤销
+type RefreshToken struct {
+	BaseModel
+
+	// JTI 令牌唯一标识（对应 JWT 的 jti 声明）
+	JTI string `gorm:"type:varchar(36);uniqueIndex;not null" json:"jti"`
+	// UserID 所属用户 ID
+	UserID string `gorm:"type:varchar(36);index;not null" json:"user_id"`
+	// ExpiresAt 过期时间
+	ExpiresAt time.Time `gorm:"type:datetime;not null" json:"expires_at"`
+	// Revoked 是否已撤销
+	Revoked bool `gorm:"default:false;index" json:"revoked"`
+	// RevokedAt 撤销时间
+	RevokedAt *time.Time `gorm:"type:datetime" json:"revoked_at,omitempty"`
+}
+
+// TableName 指定表名
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// IsExpired 检查令牌是否已过期
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// IsUsable 检查令牌是否可用（未撤销且未过期）
+func (t *RefreshToken) IsUsable() bool {
+	return !t.Revoked && !t.IsExpired()
+}
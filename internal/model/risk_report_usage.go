@@ -2,6 +2,7 @@
 package model
 
 import (
+	"strings"
 	"time"
 )
 
@@ -43,8 +44,121 @@ const (
 	MarketStateREGULAR = "REGULAR" // 盘中
 	MarketStatePOST    = "POST"    // 盘后
 	MarketStateCLOSED  = "CLOSED"  // 休市
+	// MarketStateUnknown 统计时 market_state 为空的记录归入该分组
+	MarketStateUnknown = "UNKNOWN"
 )
 
+// ActionSuggestionUnknown 统计时 action_suggestion 为空的记录归入该分组
+const ActionSuggestionUnknown = "UNKNOWN"
+
+// NewsSentimentLabel 新闻情绪标签
+const (
+	SentimentLabelPositive = "positive" // 偏多
+	SentimentLabelNeutral  = "neutral"  // 中性
+	SentimentLabelNegative = "negative" // 偏空
+)
+
+// 新闻情绪分数范围（含边界）
+const (
+	SentimentScoreMin = -100
+	SentimentScoreMax = 100
+)
+
+// sentimentLabelAliases 情绪标签别名，兼容早期上报的中文标签
+var sentimentLabelAliases = map[string]string{
+	"偏多": SentimentLabelPositive,
+	"中性": SentimentLabelNeutral,
+	"偏空": SentimentLabelNegative,
+}
+
+// NormalizeSentimentLabel 将情绪标签归一化为小写英文枚举值
+// 中文别名转换为对应的枚举值，无法识别的标签原样（去空白、小写）返回，由调用方校验
+func NormalizeSentimentLabel(label string) string {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if canonical, ok := sentimentLabelAliases[label]; ok {
+		return canonical
+	}
+	return label
+}
+
+// IsValidSentimentLabel 判断情绪标签是否为合法的枚举值
+func IsValidSentimentLabel(label string) bool {
+	switch label {
+	case SentimentLabelPositive, SentimentLabelNeutral, SentimentLabelNegative:
+		return true
+	}
+	return false
+}
+
+// MarketStateStats 按市场状态分组的调用统计
+type MarketStateStats struct {
+	MarketState string  `json:"market_state"`
+	Count       int64   `json:"count"`
+	AvgTokens   float64 `json:"avg_tokens"`
+}
+
+// ActionSuggestionStats 按操作建议分组的调用分布
+type ActionSuggestionStats struct {
+	ActionSuggestion string `json:"action_suggestion"`
+	Count            int64  `json:"count"`
+	// Ratio 占统计范围内总调用次数的比例，保留 4 位小数
+	Ratio float64 `json:"ratio"`
+}
+
+// LatencyPercentiles 请求延迟（response_duration_ms）的百分位统计
+// 采用最近秩法：Pn 为升序排列后第 ceil(n% * count) 个样本，没有样本时均为 0
+type LatencyPercentiles struct {
+	Count int64 `json:"count"`
+	P50   int64 `json:"p50_ms"`
+	P95   int64 `json:"p95_ms"`
+	P99   int64 `json:"p99_ms"`
+}
+
+// UsageStatsResponse 用户使用统计
+// 汇总字段由仓储查询得到，延迟百分位由服务层补充
+type UsageStatsResponse struct {
+	// TotalQueries 调用次数
+	TotalQueries int64 `json:"total_queries"`
+	// TotalTokens 总 token 数
+	TotalTokens int64 `json:"total_tokens"`
+	// TotalPromptTokens 输入 token 总数
+	TotalPromptTokens int64 `json:"total_prompt_tokens"`
+	// TotalCompletionTokens 输出 token 总数
+	TotalCompletionTokens int64 `json:"total_completion_tokens"`
+	// AvgResponseTimeMs 平均耗时（毫秒）
+	AvgResponseTimeMs int64 `json:"avg_response_time_ms"`
+	// P50ResponseTimeMs 耗时 P50（毫秒）
+	P50ResponseTimeMs int64 `json:"p50_response_time_ms"`
+	// P95ResponseTimeMs 耗时 P95（毫秒）
+	P95ResponseTimeMs int64 `json:"p95_response_time_ms"`
+	// P99ResponseTimeMs 耗时 P99（毫秒）
+	P99ResponseTimeMs int64 `json:"p99_response_time_ms"`
+}
+
+// QuotaPeriodUsage 单个配额周期（自然日/自然月）的用量
+type QuotaPeriodUsage struct {
+	// Quota 周期内可用的 token 数，0 表示不限
+	Quota int64 `json:"quota"`
+	// Used 当前周期已用的 token 数
+	Used int64 `json:"used"`
+	// Remaining 当前周期剩余的 token 数，不限额时为 -1
+	Remaining int64 `json:"remaining"`
+	// PeriodStart 当前周期开始时间（UTC）
+	PeriodStart time.Time `json:"period_start"`
+	// ResetAt 下一周期开始时间（UTC），届时已用量归零
+	ResetAt time.Time `json:"reset_at"`
+}
+
+// QuotaUsageResponse 用户当前配额用量
+type QuotaUsageResponse struct {
+	// UserID 用户 ID
+	UserID string `json:"user_id"`
+	// Daily 当前自然日用量
+	Daily QuotaPeriodUsage `json:"daily"`
+	// Monthly 当前自然月用量
+	Monthly QuotaPeriodUsage `json:"monthly"`
+}
+
 // RiskReportUsageResponse 使用记录响应结构（用于 API 响应）
 type RiskReportUsageResponse struct {
 	ID                     string    `json:"id"`
@@ -55,7 +169,9 @@ type RiskReportUsageResponse struct {
 	PromptTokens           int       `json:"prompt_tokens"`
 	CompletionTokens       int       `json:"completion_tokens"`
 	TotalTokens            int       `json:"total_tokens"`
-	AIResponse             string    `json:"ai_response"`
+	AIResponse             string    `json:"ai_response,omitempty"`
+	// AIResponseOmitted 为 true 表示列表响应省略了 ai_response，需通过详情接口或 include_response=true 获取
+	AIResponseOmitted      bool      `json:"ai_response_omitted,omitempty"`
 	StockPrice             *float64  `json:"stock_price,omitempty"`
 	MarketState            string    `json:"market_state,omitempty"`
 	NewsSentimentScore     *int      `json:"news_sentiment_score,omitempty"`
@@ -93,6 +209,14 @@ func (r *RiskReportUsage) ToResponse() *RiskReportUsageResponse {
 	}
 }
 
+// OmitAIResponse 省略 AI 响应全文
+// ai_response 可能包含敏感文本，列表接口默认不返回
+func (r *RiskReportUsageResponse) OmitAIResponse() *RiskReportUsageResponse {
+	r.AIResponse = ""
+	r.AIResponseOmitted = true
+	return r
+}
+
 // CreateRiskReportUsageRequest 创建使用记录请求
 type CreateRiskReportUsageRequest struct {
 	// 核心字段（必填）
@@ -117,11 +241,44 @@ type CreateRiskReportUsageRequest struct {
 	ResponseDurationMs     *int     `json:"response_duration_ms,omitempty"`
 }
 
+// UpdateRiskReportUsageRequest 更新使用记录请求
+// 只更新请求中出现的字段，user_id 不允许修改
+type UpdateRiskReportUsageRequest struct {
+	Ticker           *string    `json:"ticker,omitempty" binding:"omitempty,min=1,max=10"`
+	RequestTime      *time.Time `json:"request_time,omitempty"`
+	ResponseTime     *time.Time `json:"response_time,omitempty"`
+	PromptTokens     *int       `json:"prompt_tokens,omitempty" binding:"omitempty,min=0"`
+	CompletionTokens *int       `json:"completion_tokens,omitempty" binding:"omitempty,min=0"`
+	TotalTokens      *int       `json:"total_tokens,omitempty" binding:"omitempty,min=0"`
+	AIResponse       *string    `json:"ai_response,omitempty" binding:"omitempty,min=1"`
+
+	StockPrice           *float64 `json:"stock_price,omitempty"`
+	MarketState          *string  `json:"market_state,omitempty"`
+	NewsSentimentScore   *int     `json:"news_sentiment_score,omitempty"`
+	NewsSentimentLabel   *string  `json:"news_sentiment_label,omitempty"`
+	PeakSignalsTriggered *int     `json:"peak_signals_triggered,omitempty"`
+	ActionSuggestion     *string  `json:"action_suggestion,omitempty"`
+	RateLimitRemaining   *int     `json:"rate_limit_remaining,omitempty"`
+	ErrorMessage         *string  `json:"error_message,omitempty"`
+	ResponseDurationMs   *int     `json:"response_duration_ms,omitempty"`
+}
+
+// Cost 按每千 token 单价计算本次调用费用
+func (r *RiskReportUsage) Cost(promptPrice, completionPrice float64) float64 {
+	return float64(r.PromptTokens)/1000*promptPrice + float64(r.CompletionTokens)/1000*completionPrice
+}
+
 // BatchCreateRiskReportUsageRequest 批量创建使用记录请求
 type BatchCreateRiskReportUsageRequest struct {
 	Records []CreateRiskReportUsageRequest `json:"records" binding:"required,min=1,max=100,dive"`
 }
 
+// BackfillRiskReportUsageRequest 回填历史使用记录请求
+// 用于从旧系统迁移数据，单次最多 1000 条
+type BackfillRiskReportUsageRequest struct {
+	Records []CreateRiskReportUsageRequest `json:"records" binding:"required,min=1,max=1000,dive"`
+}
+
 // BatchCreateRiskReportUsageResponse 批量创建使用记录响应
 type BatchCreateRiskReportUsageResponse struct {
 	SuccessCount int      `json:"success_count"`
@@ -138,4 +295,13 @@ type RiskReportUsageListRequest struct {
 	EndTime   string `form:"end_time"`   // RFC3339 格式
 	Page      int    `form:"page" binding:"omitempty,min=1"`
 	PageSize  int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+	// IncludeResponse 为 true 时列表返回 ai_response 全文，默认省略
+	IncludeResponse bool `form:"include_response"`
+}
+
+// RiskReportUsageExportRequest 使用记录导出请求
+type RiskReportUsageExportRequest struct {
+	UserID    string `form:"user_id" binding:"required"`
+	StartTime string `form:"start_time"` // RFC3339 格式
+	EndTime   string `form:"end_time"`   // RFC3339 格式
 }
@@ -0,0 +1,54 @@
+// Package model 定义了应用程序的数据模型
+package model
+
+import "time"
+
+// RiskReportUsageDaily 按用户按自然日（UTC）预聚合的使用统计
+// 由定期任务或手动刷新从明细表重新计算，统计接口对已聚合的整天直接读取本表
+type RiskReportUsageDaily struct {
+	BaseModel
+
+	// UserID 用户 ID
+	UserID string `gorm:"type:varchar(50);not null;uniqueIndex:idx_usage_daily_user_day" json:"user_id"`
+	// Day 统计日（UTC 零点）
+	Day time.Time `gorm:"type:date;not null;uniqueIndex:idx_usage_daily_user_day;index" json:"day"`
+	// TotalQueries 调用次数
+	TotalQueries int64 `gorm:"not null" json:"total_queries"`
+	// TotalTokens 总 token 数
+	TotalTokens int64 `gorm:"not null" json:"total_tokens"`
+	// PromptTokens 输入 token 总数
+	PromptTokens int64 `gorm:"not null" json:"prompt_tokens"`
+	// CompletionTokens 输出 token 总数
+	CompletionTokens int64 `gorm:"not null" json:"completion_tokens"`
+	// ResponseDurationSumMs 记录了耗时的请求的耗时之和（毫秒），与 ResponseDurationCount 一起计算平均耗时
+	ResponseDurationSumMs int64 `gorm:"not null" json:"response_duration_sum_ms"`
+	// ResponseDurationCount 记录了耗时的请求数
+	ResponseDurationCount int64 `gorm:"not null" json:"response_duration_count"`
+}
+
+// TableName 指定表名
+func (RiskReportUsageDaily) TableName() string {
+	return "risk_report_usage_daily"
+}
+
+// UsageDay 返回时间所在的统计日（UTC 零点）
+func UsageDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// RefreshUsageDailyRequest 手动刷新日聚合请求
+type RefreshUsageDailyRequest struct {
+	// From 从该日（YYYY-MM-DD，UTC）起重新聚合，用于修正绕过接口直接写库的变更；不填时只刷新最近几天
+	From string `form:"from" json:"from"`
+}
+
+// RefreshUsageDailyResponse 日聚合刷新结果
+type RefreshUsageDailyResponse struct {
+	// From 本次重新聚合的起始日，为空表示从最早的记录开始
+	From *time.Time `json:"from,omitempty"`
+	// Before 本次聚合截止日（不含），即当天零点；当天的数据始终从明细实时统计
+	Before time.Time `json:"before"`
+	// Rows 写入的聚合行数
+	Rows int `json:"rows"`
+}
@@ -0,0 +1,34 @@
+// Package model 定义了应用程序的数据模型
+package model
+
+// 安全事件类型
+const (
+	// SecurityEventLoginAnomaly 异常地点登录
+	SecurityEventLoginAnomaly = "login_anomaly"
+)
+
+// SecurityEvent 安全事件记录
+// 检测到可疑行为（如异地登录）时写入，用于审计与用户提醒
+type SecurityEvent struct {
+	BaseModel
+
+	// UserID 用户 ID
+	UserID string `gorm:"type:varchar(36);not null;index" json:"user_id"`
+	// Type 事件类型
+	Type string `gorm:"type:varchar(50);not null;index" json:"type"`
+	// IP 触发事件的 IP
+	IP string `gorm:"type:varchar(45)" json:"ip"`
+	// PreviousIP 上一次登录 IP
+	PreviousIP string `gorm:"type:varchar(45)" json:"previous_ip,omitempty"`
+	// Location 本次 IP 的地理位置描述
+	Location string `gorm:"type:varchar(100)" json:"location,omitempty"`
+	// PreviousLocation 上一次登录 IP 的地理位置描述
+	PreviousLocation string `gorm:"type:varchar(100)" json:"previous_location,omitempty"`
+	// Detail 事件说明
+	Detail string `gorm:"type:varchar(255)" json:"detail,omitempty"`
+}
+
+// TableName 指定表名
+func (SecurityEvent) TableName() string {
+	return "security_events"
+}
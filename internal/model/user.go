@@ -5,6 +5,10 @@
 package model
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -17,14 +21,16 @@ type User struct {
 
 	// Username 用户名，唯一且必填
 	Username string `gorm:"type:varchar(50);uniqueIndex;not null" json:"username"`
-	// Email 邮箱地址，唯一且必填
-	Email string `gorm:"type:varchar(100);uniqueIndex;not null" json:"email"`
+	// Email 邮箱地址，唯一；未填写时存储为 NULL，以免多个无邮箱账号触发唯一约束
+	Email string `gorm:"type:varchar(100);uniqueIndex;default:null" json:"email"`
 	// Password 密码哈希值，不对外暴露
 	Password string `gorm:"type:varchar(255);not null" json:"-"`
 	// Nickname 昵称，可选
 	Nickname string `gorm:"type:varchar(50)" json:"nickname"`
 	// Avatar 头像 URL
 	Avatar string `gorm:"type:varchar(255)" json:"avatar"`
+	// AvatarThumbnails 上传头像生成的各尺寸缩略图 URL；通过资料更新直接修改头像 URL 时清空
+	AvatarThumbnails AvatarThumbnails `gorm:"type:text" json:"avatar_thumbnails,omitempty"`
 	// Phone 手机号，可选
 	Phone string `gorm:"type:varchar(20);index" json:"phone,omitempty"`
 	// Bio 个人简介
@@ -41,8 +47,21 @@ type User struct {
 	LastLoginAt *time.Time `gorm:"type:datetime" json:"last_login_at,omitempty"`
 	// LastLoginIP 最后登录 IP
 	LastLoginIP string `gorm:"type:varchar(45)" json:"last_login_ip,omitempty"`
+	// LastActiveAt 最近活跃时间，登录及携带有效令牌访问接口时更新（有节流，精度约 1 分钟）
+	LastActiveAt *time.Time `gorm:"type:datetime;index" json:"last_active_at,omitempty"`
+	// Source 注册来源渠道（小写），例如 "google"、"newsletter"；未知来源为空
+	Source string `gorm:"type:varchar(50);index" json:"source,omitempty"`
+	// ReferralCode 注册时填写的推荐码
+	ReferralCode string `gorm:"type:varchar(50)" json:"referral_code,omitempty"`
+	// ProfileVisibility 资料可见性: public, friends, private
+	ProfileVisibility string `gorm:"type:varchar(20);not null;default:public" json:"profile_visibility"`
+	// TokenVersion 令牌版本，签发的令牌携带该值；递增后所有旧令牌失效
+	TokenVersion int `gorm:"not null;default:0" json:"-"`
 	// DeletedAt 软删除时间
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Tags 用户标签（关联，需通过预加载获取）
+	Tags []UserTag `gorm:"foreignKey:UserID" json:"tags,omitempty"`
 }
 
 // TableName 指定表名
@@ -50,6 +69,81 @@ func (User) TableName() string {
 	return "users"
 }
 
+// AvatarThumbnails 头像缩略图 URL，键为边长（像素）的字符串形式，例如 "128"
+// 以 JSON 存储在单个字段中
+type AvatarThumbnails map[string]string
+
+// Value 实现 driver.Valuer，空值存储为 NULL
+func (t AvatarThumbnails) Value() (driver.Value, error) {
+	if len(t) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(map[string]string(t))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 实现 sql.Scanner
+func (t *AvatarThumbnails) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("无法将 %T 转换为 AvatarThumbnails", value)
+	}
+	if len(data) == 0 {
+		*t = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*map[string]string)(t))
+}
+
+// UserTag 用户标签
+// 用于给用户打标分组，例如运营活动、风险标记等
+type UserTag struct {
+	BaseModel
+
+	// UserID 所属用户 ID
+	UserID string `gorm:"type:varchar(36);not null;uniqueIndex:idx_user_tag" json:"user_id"`
+	// Name 标签名称
+	Name string `gorm:"type:varchar(50);not null;uniqueIndex:idx_user_tag" json:"name"`
+}
+
+// TableName 指定表名
+func (UserTag) TableName() string {
+	return "user_tags"
+}
+
+// LoginHistory 登录历史记录
+// 每次成功登录时写入一条，用于审计与账号问题排查
+type LoginHistory struct {
+	BaseModel
+
+	// UserID 用户 ID
+	UserID string `gorm:"type:varchar(36);not null;index" json:"user_id"`
+	// IP 登录 IP
+	IP string `gorm:"type:varchar(45)" json:"ip"`
+}
+
+// TableName 指定表名
+func (LoginHistory) TableName() string {
+	return "login_histories"
+}
+
+// 用户可预加载的关联名称
+const (
+	// PreloadTags 用户标签
+	PreloadTags = "Tags"
+)
+
 // 用户状态常量
 const (
 	// UserStatusDisabled 禁用状态
@@ -68,6 +162,28 @@ const (
 	RoleAdmin = "admin"
 )
 
+// 资料可见性常量
+const (
+	// ProfileVisibilityPublic 所有人可见
+	ProfileVisibilityPublic = "public"
+	// ProfileVisibilityFriends 仅好友可见完整资料，其他人只能看到简要信息
+	ProfileVisibilityFriends = "friends"
+	// ProfileVisibilityPrivate 仅本人与管理员可见
+	ProfileVisibilityPrivate = "private"
+)
+
+// ProfileAccess 查看者对用户资料的访问范围
+type ProfileAccess int
+
+const (
+	// ProfileAccessDenied 不可查看
+	ProfileAccessDenied ProfileAccess = iota
+	// ProfileAccessBrief 只能查看简要信息（见 UserBrief）
+	ProfileAccessBrief
+	// ProfileAccessFull 可查看完整资料，联系方式等字段仍按 ToResponseFor 裁剪
+	ProfileAccessFull
+)
+
 // 用户性别常量
 const (
 	// GenderUnknown 未知
@@ -96,42 +212,136 @@ func (u *User) IsAdmin() bool {
 // UserResponse 用户响应结构（用于 API 响应）
 // 过滤掉敏感信息
 type UserResponse struct {
-	ID          string     `json:"id"`
-	Username    string     `json:"username"`
-	Email       string     `json:"email"`
-	Nickname    string     `json:"nickname"`
-	Avatar      string     `json:"avatar"`
-	Phone       string     `json:"phone,omitempty"`
-	Bio         string     `json:"bio,omitempty"`
-	Gender      int8       `json:"gender"`
-	Birthday    *time.Time `json:"birthday,omitempty"`
-	Status      int8       `json:"status"`
-	Role        string     `json:"role"`
-	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID                string           `json:"id"`
+	Username          string           `json:"username"`
+	Email             string           `json:"email,omitempty"`
+	Nickname          string           `json:"nickname"`
+	Avatar            string           `json:"avatar"`
+	AvatarThumbnails  AvatarThumbnails `json:"avatar_thumbnails,omitempty"`
+	Phone             string           `json:"phone,omitempty"`
+	Bio               string           `json:"bio,omitempty"`
+	Gender            int8             `json:"gender"`
+	Birthday          *time.Time       `json:"birthday,omitempty"`
+	Status            int8             `json:"status"`
+	Role              string           `json:"role"`
+	LastLoginAt       *time.Time       `json:"last_login_at,omitempty"`
+	Source            string           `json:"source,omitempty"`
+	ReferralCode      string           `json:"referral_code,omitempty"`
+	ProfileVisibility string           `json:"profile_visibility,omitempty"`
+	Tags              []string         `json:"tags,omitempty"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+}
+
+// OnlineStatusResponse 用户在线状态响应
+type OnlineStatusResponse struct {
+	// Online 是否在线
+	Online bool `json:"online"`
+	// LastActiveAt 最近活跃时间，从未活跃时为 null
+	LastActiveAt *time.Time `json:"last_active_at"`
 }
 
 // ToResponse 将 User 转换为 UserResponse
 func (u *User) ToResponse() *UserResponse {
 	return &UserResponse{
-		ID:          u.ID,
-		Username:    u.Username,
-		Email:       u.Email,
-		Nickname:    u.Nickname,
-		Avatar:      u.Avatar,
-		Phone:       u.Phone,
-		Bio:         u.Bio,
-		Gender:      u.Gender,
-		Birthday:    u.Birthday,
-		Status:      u.Status,
-		Role:        u.Role,
-		LastLoginAt: u.LastLoginAt,
-		CreatedAt:   u.CreatedAt,
-		UpdatedAt:   u.UpdatedAt,
+		ID:                u.ID,
+		Username:          u.Username,
+		Email:             u.Email,
+		Nickname:          u.Nickname,
+		Avatar:            u.Avatar,
+		AvatarThumbnails:  u.AvatarThumbnails,
+		Phone:             u.Phone,
+		Bio:               u.Bio,
+		Gender:            u.Gender,
+		Birthday:          u.Birthday,
+		Status:            u.Status,
+		Role:              u.Role,
+		LastLoginAt:       u.LastLoginAt,
+		Source:            u.Source,
+		ReferralCode:      u.ReferralCode,
+		ProfileVisibility: u.ProfileVisibility,
+		Tags:              u.TagNames(),
+		CreatedAt:         u.CreatedAt,
+		UpdatedAt:         u.UpdatedAt,
+	}
+}
+
+// Viewer 查看用户资料的一方
+// 由认证信息（如 service.TokenClaims）实现，用于决定响应中暴露哪些字段
+type Viewer interface {
+	// ViewerID 查看者的用户 ID，未认证时为空
+	ViewerID() string
+	// ViewerRole 查看者的角色
+	ViewerRole() string
+}
+
+// ToResponseFor 按查看者身份生成用户响应
+//   - 本人或管理员：返回全部字段
+//   - 其他用户或未认证：隐藏邮箱、手机号、生日、最后登录时间、注册来源、资料可见性与标签
+func (u *User) ToResponseFor(viewer Viewer) *UserResponse {
+	resp := u.ToResponse()
+	if u.isOwnerOrAdmin(viewer) {
+		return resp
+	}
+
+	resp.Email = ""
+	resp.Phone = ""
+	resp.Birthday = nil
+	resp.LastLoginAt = nil
+	resp.Source = ""
+	resp.ReferralCode = ""
+	resp.ProfileVisibility = ""
+	resp.Tags = nil
+	return resp
+}
+
+// ProfileAccessFor 按资料可见性与查看者关系确定资料访问范围
+// 本人与管理员始终可查看完整资料；isFriend 表示查看者是该用户的好友，
+// 可见性为 friends 时好友可查看完整资料，其他人只能查看简要信息；private 对其他人不可见
+func (u *User) ProfileAccessFor(viewer Viewer, isFriend bool) ProfileAccess {
+	if u.isOwnerOrAdmin(viewer) {
+		return ProfileAccessFull
+	}
+	switch u.ProfileVisibility {
+	case ProfileVisibilityPrivate:
+		return ProfileAccessDenied
+	case ProfileVisibilityFriends:
+		if isFriend {
+			return ProfileAccessFull
+		}
+		return ProfileAccessBrief
+	default:
+		return ProfileAccessFull
 	}
 }
 
+// isOwnerOrAdmin 判断查看者是否为用户本人或管理员
+func (u *User) isOwnerOrAdmin(viewer Viewer) bool {
+	return viewer != nil && (viewer.ViewerRole() == RoleAdmin || (viewer.ViewerID() != "" && viewer.ViewerID() == u.ID))
+}
+
+// TagNames 返回用户标签名称列表
+// 未预加载标签时返回 nil
+func (u *User) TagNames() []string {
+	if len(u.Tags) == 0 {
+		return nil
+	}
+	names := make([]string, len(u.Tags))
+	for i, tag := range u.Tags {
+		names[i] = tag.Name
+	}
+	return names
+}
+
+// MaxRegistrationSourceLen 注册来源的最大长度
+const MaxRegistrationSourceLen = 50
+
+// NormalizeRegistrationSource 规范化注册来源：去除首尾空白并转为小写
+// 同一渠道的不同写法（如 "Google"、"google "）归为同一来源，便于按来源统计
+func NormalizeRegistrationSource(source string) string {
+	return strings.ToLower(strings.TrimSpace(source))
+}
+
 // UsersToResponse 将用户列表转换为响应列表
 func UsersToResponse(users []User) []*UserResponse {
 	result := make([]*UserResponse, len(users))
@@ -141,6 +351,15 @@ func UsersToResponse(users []User) []*UserResponse {
 	return result
 }
 
+// UsersToResponseFor 按查看者身份将用户列表转换为响应列表
+func UsersToResponseFor(users []User, viewer Viewer) []*UserResponse {
+	result := make([]*UserResponse, len(users))
+	for i := range users {
+		result[i] = users[i].ToResponseFor(viewer)
+	}
+	return result
+}
+
 // UserBrief 用户简要信息（用于列表展示等场景）
 type UserBrief struct {
 	ID       string `json:"id"`
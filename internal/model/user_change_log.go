@@ -0,0 +1,34 @@
+// Package model 定义了应用程序的数据模型
+package model
+
+// UserChangeLog 用户关键字段变更记录
+// 审计要求保留 email、role、status 等字段的变更历史，每个字段的每次变更一条记录
+type UserChangeLog struct {
+	BaseModel
+
+	// UserID 被修改的用户 ID
+	UserID string `gorm:"type:varchar(36);not null;index" json:"user_id"`
+	// Field 变更的字段名
+	Field string `gorm:"type:varchar(50);not null" json:"field"`
+	// OldValue 变更前的值
+	OldValue string `gorm:"type:varchar(255)" json:"old_value"`
+	// NewValue 变更后的值
+	NewValue string `gorm:"type:varchar(255)" json:"new_value"`
+	// ChangedBy 操作人用户 ID
+	ChangedBy string `gorm:"type:varchar(36);index" json:"changed_by"`
+	// ImpersonatedBy 通过模拟登录修改时的真实操作者（管理员）ID
+	ImpersonatedBy string `gorm:"type:varchar(36);index" json:"impersonated_by,omitempty"`
+}
+
+// TableName 指定表名
+func (UserChangeLog) TableName() string {
+	return "user_change_logs"
+}
+
+// UserChangeLogListRequest 用户变更记录列表请求
+type UserChangeLogListRequest struct {
+	// Page 页码
+	Page int `form:"page" binding:"omitempty,min=1"`
+	// PageSize 每页数量
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
@@ -0,0 +1,115 @@
+// Package model 定义了应用程序的数据模型
+//
+// 本文件包含用户响应字段过滤的单元测试
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testViewer 测试用查看者
+type testViewer struct {
+	id   string
+	role string
+}
+
+func (v testViewer) ViewerID() string   { return v.id }
+func (v testViewer) ViewerRole() string { return v.role }
+
+// newViewTestUser 创建带联系方式的用户
+func newViewTestUser() *User {
+	birthday := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+	lastLogin := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	return &User{
+		BaseModel:   BaseModel{ID: "owner"},
+		Username:    "alice",
+		Email:       "alice@example.com",
+		Phone:       "13800000000",
+		Nickname:    "Alice",
+		Birthday:    &birthday,
+		LastLoginAt: &lastLogin,
+		Role:        RoleUser,
+		Tags:        []UserTag{{Name: "vip"}},
+	}
+}
+
+func TestUser_ToResponseFor(t *testing.T) {
+	user := newViewTestUser()
+
+	tests := []struct {
+		name        string
+		viewer      Viewer
+		wantPrivate bool
+	}{
+		{"本人", testViewer{id: "owner", role: RoleUser}, true},
+		{"管理员", testViewer{id: "admin-1", role: RoleAdmin}, true},
+		{"其他用户", testViewer{id: "stranger", role: RoleUser}, false},
+		{"未认证", nil, false},
+		{"空 ID 不视为本人", testViewer{role: RoleUser}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := user.ToResponseFor(tt.viewer)
+
+			// 公开字段始终可见
+			assert.Equal(t, "owner", resp.ID)
+			assert.Equal(t, "alice", resp.Username)
+			assert.Equal(t, "Alice", resp.Nickname)
+
+			if tt.wantPrivate {
+				assert.Equal(t, "alice@example.com", resp.Email)
+				assert.Equal(t, "13800000000", resp.Phone)
+				assert.NotNil(t, resp.Birthday)
+				assert.NotNil(t, resp.LastLoginAt)
+				assert.Equal(t, []string{"vip"}, resp.Tags)
+			} else {
+				assert.Empty(t, resp.Email)
+				assert.Empty(t, resp.Phone)
+				assert.Nil(t, resp.Birthday)
+				assert.Nil(t, resp.LastLoginAt)
+				assert.Nil(t, resp.Tags)
+			}
+		})
+	}
+
+	// 过滤不影响原始用户数据
+	assert.Equal(t, "alice@example.com", user.Email)
+}
+
+func TestUser_ProfileAccessFor(t *testing.T) {
+	owner := testViewer{id: "owner", role: RoleUser}
+	admin := testViewer{id: "admin-1", role: RoleAdmin}
+	stranger := testViewer{id: "stranger", role: RoleUser}
+
+	tests := []struct {
+		name       string
+		visibility string
+		viewer     Viewer
+		isFriend   bool
+		want       ProfileAccess
+	}{
+		{"公开资料对陌生人完整可见", ProfileVisibilityPublic, stranger, false, ProfileAccessFull},
+		{"未设置按公开处理", "", nil, false, ProfileAccessFull},
+		{"私密资料拒绝陌生人", ProfileVisibilityPrivate, stranger, false, ProfileAccessDenied},
+		{"私密资料拒绝未认证", ProfileVisibilityPrivate, nil, false, ProfileAccessDenied},
+		{"私密资料对好友同样不可见", ProfileVisibilityPrivate, stranger, true, ProfileAccessDenied},
+		{"私密资料对本人完整可见", ProfileVisibilityPrivate, owner, false, ProfileAccessFull},
+		{"私密资料对管理员完整可见", ProfileVisibilityPrivate, admin, false, ProfileAccessFull},
+		{"仅好友可见时陌生人看到简要信息", ProfileVisibilityFriends, stranger, false, ProfileAccessBrief},
+		{"仅好友可见时未认证看到简要信息", ProfileVisibilityFriends, nil, false, ProfileAccessBrief},
+		{"仅好友可见时好友完整可见", ProfileVisibilityFriends, stranger, true, ProfileAccessFull},
+		{"仅好友可见时本人完整可见", ProfileVisibilityFriends, owner, false, ProfileAccessFull},
+		{"仅好友可见时管理员完整可见", ProfileVisibilityFriends, admin, false, ProfileAccessFull},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newViewTestUser()
+			user.ProfileVisibility = tt.visibility
+
+			assert.Equal(t, tt.want, user.ProfileAccessFor(tt.viewer, tt.isFriend))
+		})
+	}
+}
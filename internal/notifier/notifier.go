@@ -0,0 +1,60 @@
+// Package notifier 提供用户通知的发送实现
+//
+// 内置两种实现：
+// - SMTPNotifier：按配置的模板渲染邮件，通过 SMTP 发送
+// - LogNotifier：只记录日志不实际发送，用于未配置邮件服务的环境
+//
+// 两者都满足 service.Notifier 接口，由路由层按配置选择注入。
+package notifier
+
+import (
+	"context"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+// WelcomeData 欢迎邮件模板可用的变量
+type WelcomeData struct {
+	// AppName 应用名称
+	AppName string
+	// Username 用户名
+	Username string
+	// Nickname 昵称，未设置时为用户名
+	Nickname string
+	// Email 邮箱
+	Email string
+}
+
+// newWelcomeData 根据用户生成欢迎邮件模板变量
+func newWelcomeData(appName string, user *model.User) WelcomeData {
+	nickname := user.Nickname
+	if nickname == "" {
+		nickname = user.Username
+	}
+	return WelcomeData{
+		AppName:  appName,
+		Username: user.Username,
+		Nickname: nickname,
+		Email:    user.Email,
+	}
+}
+
+// LogNotifier 只记录日志的通知实现
+type LogNotifier struct {
+	log logger.Logger
+}
+
+// NewLogNotifier 创建只记录日志的通知实现
+func NewLogNotifier(log logger.Logger) *LogNotifier {
+	return &LogNotifier{log: log.With(logger.String("component", "notifier"))}
+}
+
+// SendWelcome 实现 service.Notifier
+func (n *LogNotifier) SendWelcome(_ context.Context, user *model.User) error {
+	n.log.Info("未启用邮件发送，跳过欢迎邮件",
+		logger.String("user_id", user.ID),
+		logger.String("email", user.Email),
+	)
+	return nil
+}
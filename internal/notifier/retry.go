@@ -0,0 +1,161 @@
+// Package notifier 提供用户通知的发送实现
+//
+// 本文件实现带熔断与重试队列的通知发送。
+// 邮件服务故障时发送经熔断器快速失败，未发出的通知进入内存重试队列后立即返回，
+// 由定时任务调用 RetryPending 补发；超过最大尝试次数的通知丢弃并记录日志。
+// 队列只在当前进程内有效，重启后未补发的通知丢失。
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/breaker"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+// Sender 实际发送通知的实现，如 SMTPNotifier
+type Sender interface {
+	// SendWelcome 向新注册用户发送欢迎通知
+	SendWelcome(ctx context.Context, user *model.User) error
+}
+
+// RetryConfig 重试队列配置
+type RetryConfig struct {
+	// QueueSize 等待补发的通知上限，超过后新的失败通知直接丢弃
+	QueueSize int
+	// MaxAttempts 每条通知的最大发送次数（含首次），达到后丢弃
+	MaxAttempts int
+}
+
+// pendingWelcome 等待补发的欢迎通知
+type pendingWelcome struct {
+	user model.User
+	// attempts 已实际尝试发送的次数，熔断开路时未发送不计入
+	attempts int
+}
+
+// RetryNotifier 带熔断与重试队列的通知发送
+type RetryNotifier struct {
+	sender  Sender
+	breaker *breaker.Breaker
+	cfg     RetryConfig
+	log     logger.Logger
+
+	mu      sync.Mutex
+	pending []pendingWelcome
+}
+
+// NewRetryNotifier 用熔断器与重试队列包装通知发送
+func NewRetryNotifier(sender Sender, b *breaker.Breaker, cfg RetryConfig, log logger.Logger) *RetryNotifier {
+	return &RetryNotifier{
+		sender:  sender,
+		breaker: b,
+		cfg:     cfg,
+		log:     log.With(logger.String("component", "notifier")),
+	}
+}
+
+// SendWelcome 实现 service.Notifier
+// 发送失败或熔断开路时进入重试队列并返回 nil，只有队列已满无法补发时返回错误
+func (n *RetryNotifier) SendWelcome(ctx context.Context, user *model.User) error {
+	err := n.breaker.Execute(func() error {
+		return n.sender.SendWelcome(ctx, user)
+	})
+	if err == nil {
+		return nil
+	}
+
+	item := pendingWelcome{user: *user}
+	if !errors.Is(err, breaker.ErrOpen) {
+		item.attempts = 1
+	}
+	if item.attempts >= n.cfg.MaxAttempts {
+		return err
+	}
+	if !n.enqueue(item) {
+		return fmt.Errorf("通知重试队列已满: %w", err)
+	}
+	n.log.Warn("欢迎通知发送失败，已进入重试队列",
+		logger.String("user_id", user.ID),
+		logger.Err(err),
+	)
+	return nil
+}
+
+// RetryPending 补发重试队列中的通知，由定时任务周期调用
+// 熔断器开路或 ctx 结束时停止，剩余通知留待下次补发
+func (n *RetryNotifier) RetryPending(ctx context.Context) {
+	n.mu.Lock()
+	batch := n.pending
+	n.pending = nil
+	n.mu.Unlock()
+
+	for i, item := range batch {
+		if ctx.Err() != nil {
+			n.requeue(batch[i:])
+			return
+		}
+
+		err := n.breaker.Execute(func() error {
+			return n.sender.SendWelcome(ctx, &item.user)
+		})
+		switch {
+		case err == nil:
+			n.log.Info("欢迎通知补发成功", logger.String("user_id", item.user.ID), logger.Int("attempts", item.attempts+1))
+		case errors.Is(err, breaker.ErrOpen):
+			n.requeue(batch[i:])
+			return
+		default:
+			item.attempts++
+			if item.attempts >= n.cfg.MaxAttempts {
+				n.log.Error("欢迎通知多次发送失败，已丢弃",
+					logger.String("user_id", item.user.ID),
+					logger.Int("attempts", item.attempts),
+					logger.Err(err),
+				)
+				continue
+			}
+			n.requeue([]pendingWelcome{item})
+		}
+	}
+}
+
+// Pending 返回等待补发的通知数
+func (n *RetryNotifier) Pending() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.pending)
+}
+
+// enqueue 加入重试队列，队列已满时返回 false
+func (n *RetryNotifier) enqueue(item pendingWelcome) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.pending) >= n.cfg.QueueSize {
+		return false
+	}
+	n.pending = append(n.pending, item)
+	return true
+}
+
+// requeue 将未能补发的通知放回队列头部，保持先失败先补发
+// 补发期间新进入队列的通知排在其后；超出容量的部分丢弃并记录日志
+func (n *RetryNotifier) requeue(items []pendingWelcome) {
+	n.mu.Lock()
+	merged := append(append(make([]pendingWelcome, 0, len(items)+len(n.pending)), items...), n.pending...)
+	dropped := 0
+	if len(merged) > n.cfg.QueueSize {
+		dropped = len(merged) - n.cfg.QueueSize
+		merged = merged[:n.cfg.QueueSize]
+	}
+	n.pending = merged
+	n.mu.Unlock()
+
+	if dropped > 0 {
+		n.log.Error("通知重试队列已满，丢弃部分通知", logger.Int("dropped", dropped))
+	}
+}
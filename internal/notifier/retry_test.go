@@ -0,0 +1,127 @@
+// Package notifier 提供用户通知的发送实现
+//
+// 本文件包含带熔断与重试队列的通知发送的单元测试
+package notifier
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/breaker"
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errSMTPDown = errors.New("dial tcp: i/o timeout")
+
+// stubSender 可切换为故障状态的发送实现，记录实际发送次数与成功送达的用户
+type stubSender struct {
+	mu        sync.Mutex
+	down      bool
+	calls     int
+	delivered []string
+}
+
+func (s *stubSender) SendWelcome(_ context.Context, user *model.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.down {
+		return errSMTPDown
+	}
+	s.delivered = append(s.delivered, user.ID)
+	return nil
+}
+
+// setDown 切换故障状态
+func (s *stubSender) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+// newTestRetryNotifier 创建连续失败 threshold 次即开路、开路 1 分钟的重试通知
+func newTestRetryNotifier(t *testing.T, sender *stubSender, cfg RetryConfig, threshold int) (*RetryNotifier, *breaker.Breaker) {
+	t.Helper()
+	log, err := logger.New(&logger.Config{Level: "error", Format: "console"})
+	require.NoError(t, err)
+	b := breaker.New("mail", breaker.WithFailureThreshold(threshold), breaker.WithOpenTimeout(time.Minute))
+	return NewRetryNotifier(sender, b, cfg, log), b
+}
+
+// newRetryTestUser 创建测试用户
+func newRetryTestUser(id string) *model.User {
+	return &model.User{BaseModel: model.BaseModel{ID: id}, Username: id, Email: id + "@example.com"}
+}
+
+func TestRetryNotifier_OpensAfterFailuresAndQueues(t *testing.T) {
+	sender := &stubSender{down: true}
+	n, b := newTestRetryNotifier(t, sender, RetryConfig{QueueSize: 10, MaxAttempts: 5}, 2)
+	ctx := context.Background()
+
+	// 连续失败两次后开路
+	for _, id := range []string{"u1", "u2"} {
+		assert.NoError(t, n.SendWelcome(ctx, newRetryTestUser(id)))
+	}
+	assert.Equal(t, breaker.StateOpen, b.State())
+
+	// 开路后注册不再等待邮件服务，直接进入重试队列
+	start := time.Now()
+	assert.NoError(t, n.SendWelcome(ctx, newRetryTestUser("u3")))
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	assert.Equal(t, 2, sender.calls)
+	assert.Equal(t, 3, n.Pending())
+
+	// 开路期间补发也快速返回，队列保持不变
+	n.RetryPending(ctx)
+	assert.Equal(t, 2, sender.calls)
+	assert.Equal(t, 3, n.Pending())
+}
+
+func TestRetryNotifier_RetryPendingAfterRecovery(t *testing.T) {
+	sender := &stubSender{down: true}
+	n, _ := newTestRetryNotifier(t, sender, RetryConfig{QueueSize: 10, MaxAttempts: 5}, 10)
+	ctx := context.Background()
+
+	for _, id := range []string{"u1", "u2"} {
+		require.NoError(t, n.SendWelcome(ctx, newRetryTestUser(id)))
+	}
+	require.Equal(t, 2, n.Pending())
+
+	// 邮件服务恢复后按失败先后补发
+	sender.setDown(false)
+	n.RetryPending(ctx)
+	assert.Equal(t, []string{"u1", "u2"}, sender.delivered)
+	assert.Zero(t, n.Pending())
+}
+
+func TestRetryNotifier_DropsAfterMaxAttempts(t *testing.T) {
+	sender := &stubSender{down: true}
+	n, _ := newTestRetryNotifier(t, sender, RetryConfig{QueueSize: 10, MaxAttempts: 3}, 10)
+	ctx := context.Background()
+
+	require.NoError(t, n.SendWelcome(ctx, newRetryTestUser("u1")))
+	n.RetryPending(ctx)
+	assert.Equal(t, 1, n.Pending())
+
+	// 第 3 次发送仍失败，丢弃
+	n.RetryPending(ctx)
+	assert.Zero(t, n.Pending())
+	assert.Equal(t, 3, sender.calls)
+}
+
+func TestRetryNotifier_QueueFull(t *testing.T) {
+	sender := &stubSender{down: true}
+	n, _ := newTestRetryNotifier(t, sender, RetryConfig{QueueSize: 1, MaxAttempts: 5}, 10)
+	ctx := context.Background()
+
+	require.NoError(t, n.SendWelcome(ctx, newRetryTestUser("u1")))
+	err := n.SendWelcome(ctx, newRetryTestUser("u2"))
+	assert.ErrorIs(t, err, errSMTPDown)
+	assert.Equal(t, 1, n.Pending())
+}
@@ -0,0 +1,170 @@
+// Package notifier 提供用户通知的发送实现
+//
+// 本文件实现了基于 SMTP 的邮件通知。
+// 服务器支持 STARTTLS 时自动启用加密；配置了用户名时使用 PLAIN 认证。
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/internal/model"
+)
+
+// defaultWelcomeTemplate 未配置模板文件时使用的欢迎邮件正文
+const defaultWelcomeTemplate = `{{.Nickname}}，你好：
+
+欢迎加入 {{.AppName}}！你的账号 {{.Username}} 已注册成功，现在就可以登录使用了。
+
+如果这不是你本人的操作，请忽略本邮件或联系我们。
+
+—— {{.AppName}}
+`
+
+// SMTPNotifier 基于 SMTP 的邮件通知实现
+type SMTPNotifier struct {
+	cfg     config.MailConfig
+	appName string
+	from    *mail.Address
+	subject *template.Template
+	body    *template.Template
+}
+
+// NewSMTPNotifier 创建 SMTP 邮件通知实现
+// 发件人地址无效、模板文件不存在或模板语法错误时返回错误
+func NewSMTPNotifier(cfg config.MailConfig, appName string) (*SMTPNotifier, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("无效的发件人地址 %q: %w", cfg.From, err)
+	}
+
+	subject, err := template.New("subject").Parse(cfg.Welcome.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("解析欢迎邮件主题模板失败: %w", err)
+	}
+
+	text := defaultWelcomeTemplate
+	if cfg.Welcome.TemplateFile != "" {
+		data, err := os.ReadFile(cfg.Welcome.TemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取欢迎邮件模板失败: %w", err)
+		}
+		text = string(data)
+	}
+	body, err := template.New("welcome").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析欢迎邮件模板失败: %w", err)
+	}
+
+	return &SMTPNotifier{cfg: cfg, appName: appName, from: from, subject: subject, body: body}, nil
+}
+
+// SendWelcome 实现 service.Notifier
+func (n *SMTPNotifier) SendWelcome(ctx context.Context, user *model.User) error {
+	msg, err := n.welcomeMessage(user)
+	if err != nil {
+		return err
+	}
+	return n.send(ctx, user.Email, msg)
+}
+
+// welcomeMessage 渲染欢迎邮件，返回完整的 MIME 邮件内容
+func (n *SMTPNotifier) welcomeMessage(user *model.User) ([]byte, error) {
+	data := newWelcomeData(n.appName, user)
+
+	var subject, body bytes.Buffer
+	if err := n.subject.Execute(&subject, data); err != nil {
+		return nil, fmt.Errorf("渲染欢迎邮件主题失败: %w", err)
+	}
+	if err := n.body.Execute(&body, data); err != nil {
+		return nil, fmt.Errorf("渲染欢迎邮件正文失败: %w", err)
+	}
+	return buildMessage(n.from, user.Email, strings.TrimSpace(subject.String()), body.Bytes()), nil
+}
+
+// send 通过 SMTP 发送邮件
+// net/smtp 不支持 context，连接建立后以 ctx 的截止时间作为读写超时
+func (n *SMTPNotifier) send(ctx context.Context, to string, msg []byte) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", n.cfg.Addr())
+	if err != nil {
+		return fmt.Errorf("连接 SMTP 服务器失败: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("连接 SMTP 服务器失败: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.cfg.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("SMTP STARTTLS 失败: %w", err)
+		}
+	}
+	if n.cfg.Username != "" {
+		auth := smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP 认证失败: %w", err)
+		}
+	}
+
+	if err := client.Mail(n.from.Address); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM 失败: %w", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("SMTP RCPT TO 失败: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA 失败: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("写入邮件内容失败: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("写入邮件内容失败: %w", err)
+	}
+	return client.Quit()
+}
+
+// buildMessage 构造纯文本 MIME 邮件
+// 主题按 RFC 2047 编码，正文使用 base64 编码，避免中文在传输中被破坏
+func buildMessage(from *mail.Address, to, subject string, body []byte) []byte {
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		buf.WriteString(key + ": " + value + "\r\n")
+	}
+	header("From", from.String())
+	header("To", to)
+	header("Subject", mime.BEncoding.Encode("UTF-8", subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=UTF-8")
+	header("Content-Transfer-Encoding", "base64")
+	buf.WriteString("\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString(body)
+	// base64 正文按 RFC 2045 每行不超过 76 个字符
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	return buf.Bytes()
+}
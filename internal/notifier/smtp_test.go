@@ -0,0 +1,114 @@
+// Package notifier 提供用户通知的发送实现
+//
+// 本文件包含 SMTP 邮件通知的单元测试
+package notifier
+
+import (
+	"encoding/base64"
+	"io"
+	"mime"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestMailConfig 创建测试用邮件配置
+func newTestMailConfig() config.MailConfig {
+	return config.MailConfig{
+		Enabled: true,
+		Host:    "smtp.example.com",
+		Port:    587,
+		From:    "User API <noreply@example.com>",
+		Welcome: config.WelcomeMailConfig{Subject: "欢迎加入 {{.AppName}}"},
+	}
+}
+
+// parseMessage 解析邮件，返回解码后的主题与正文
+func parseMessage(t *testing.T, raw []byte) (*mail.Message, string, string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(msg.Body)
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+
+	encoded, err := base64.StdEncoding.DecodeString(
+		strings.NewReplacer("\r", "", "\n", "").Replace(string(body)),
+	)
+	require.NoError(t, err)
+	return msg, subject, string(encoded)
+}
+
+func TestSMTPNotifier_WelcomeMessage_DefaultTemplate(t *testing.T) {
+	// 准备
+	n, err := NewSMTPNotifier(newTestMailConfig(), "User API")
+	require.NoError(t, err)
+	user := &model.User{Username: "alice", Email: "alice@example.com"}
+
+	// 执行
+	raw, err := n.welcomeMessage(user)
+	require.NoError(t, err)
+
+	// 断言
+	msg, subject, body := parseMessage(t, raw)
+	assert.Equal(t, "alice@example.com", msg.Header.Get("To"))
+	assert.Contains(t, msg.Header.Get("From"), "noreply@example.com")
+	assert.Equal(t, "text/plain; charset=UTF-8", msg.Header.Get("Content-Type"))
+	assert.Equal(t, "欢迎加入 User API", subject)
+	// 未设置昵称时以用户名称呼
+	assert.True(t, strings.HasPrefix(body, "alice，你好"))
+	assert.Contains(t, body, "你的账号 alice 已注册成功")
+}
+
+func TestSMTPNotifier_WelcomeMessage_CustomTemplate(t *testing.T) {
+	// 准备
+	path := filepath.Join(t.TempDir(), "welcome.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte("Hi {{.Nickname}} <{{.Email}}>"), 0o600))
+	cfg := newTestMailConfig()
+	cfg.Welcome.TemplateFile = path
+	n, err := NewSMTPNotifier(cfg, "User API")
+	require.NoError(t, err)
+
+	// 执行
+	raw, err := n.welcomeMessage(&model.User{Username: "bob", Nickname: "Bobby", Email: "bob@example.com"})
+	require.NoError(t, err)
+
+	// 断言
+	_, _, body := parseMessage(t, raw)
+	assert.Equal(t, "Hi Bobby <bob@example.com>", body)
+}
+
+func TestNewSMTPNotifier_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *config.MailConfig)
+	}{
+		{name: "发件人地址无效", modify: func(cfg *config.MailConfig) { cfg.From = "not an address" }},
+		{name: "主题模板语法错误", modify: func(cfg *config.MailConfig) { cfg.Welcome.Subject = "{{.AppName" }},
+		{name: "模板文件不存在", modify: func(cfg *config.MailConfig) { cfg.Welcome.TemplateFile = "/nonexistent/welcome.tmpl" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 准备
+			cfg := newTestMailConfig()
+			tt.modify(&cfg)
+
+			// 执行
+			_, err := NewSMTPNotifier(cfg, "User API")
+
+			// 断言
+			assert.Error(t, err)
+		})
+	}
+}
@@ -8,8 +8,12 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/example/go-user-api/internal/config"
@@ -29,6 +33,11 @@ type Database struct {
 	DB *gorm.DB
 	// config 数据库配置
 	config *config.DatabaseConfig
+
+	// poolMu 保护 pool
+	poolMu sync.Mutex
+	// pool 当前生效的连接池配置，运行时调整后与启动配置不同
+	pool config.PoolConfig
 }
 
 // NewDatabase 创建数据库连接
@@ -37,6 +46,13 @@ func NewDatabase(cfg *config.DatabaseConfig, log logger.Logger) (*Database, erro
 	var db *gorm.DB
 	var err error
 
+	// 设置主键 ID 生成器
+	idGenerator, err := model.NewIDGenerator(cfg.IDGenerator)
+	if err != nil {
+		return nil, err
+	}
+	model.DefaultIDGenerator = idGenerator
+
 	// 配置 GORM 日志
 	gormConfig := &gorm.Config{
 		Logger: newGormLogger(cfg.LogMode, log),
@@ -68,7 +84,12 @@ func NewDatabase(cfg *config.DatabaseConfig, log logger.Logger) (*Database, erro
 
 	// 自动迁移数据库结构
 	if cfg.AutoMigrate {
-		if err := autoMigrate(db); err != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.MigrationLockTimeoutDuration())
+		err := withMigrationLock(ctx, db, func() error {
+			return autoMigrate(db)
+		})
+		cancel()
+		if err != nil {
 			return nil, fmt.Errorf("数据库迁移失败: %w", err)
 		}
 		log.Info("数据库迁移完成")
@@ -78,10 +99,16 @@ func NewDatabase(cfg *config.DatabaseConfig, log logger.Logger) (*Database, erro
 		logger.String("driver", cfg.Driver),
 	)
 
+	return WrapDatabase(db, cfg), nil
+}
+
+// WrapDatabase 包装已建立的 GORM 连接，连接池按 cfg.Pool 视为已配置
+func WrapDatabase(db *gorm.DB, cfg *config.DatabaseConfig) *Database {
 	return &Database{
 		DB:     db,
 		config: cfg,
-	}, nil
+		pool:   cfg.Pool,
+	}
 }
 
 // initMySQL 初始化 MySQL 连接
@@ -115,7 +142,32 @@ func initSQLite(cfg *config.DatabaseConfig, gormConfig *gorm.Config) (*gorm.DB,
 		return nil, fmt.Errorf("创建数据目录失败: %w", err)
 	}
 
-	return gorm.Open(sqlite.Open(dbPath), gormConfig)
+	return gorm.Open(sqlite.Open(sqliteDSN(&cfg.SQLite)), gormConfig)
+}
+
+// sqliteDSN 根据配置生成带 PRAGMA 参数的 SQLite 连接串
+// foreign_keys、busy_timeout 等 PRAGMA 只对当前连接生效，
+// 通过连接参数设置可保证连接池中每个新建连接都会执行
+func sqliteDSN(cfg *config.SQLiteConfig) string {
+	params := url.Values{}
+	if cfg.ForeignKeys {
+		params.Set("_foreign_keys", "1")
+	}
+	if cfg.WAL {
+		params.Set("_journal_mode", "WAL")
+	}
+	if cfg.BusyTimeout > 0 {
+		params.Set("_busy_timeout", strconv.Itoa(cfg.BusyTimeout))
+	}
+
+	if len(params) == 0 {
+		return cfg.Path
+	}
+	sep := "?"
+	if strings.Contains(cfg.Path, "?") {
+		sep = "&"
+	}
+	return cfg.Path + sep + params.Encode()
 }
 
 // configurePool 配置数据库连接池
@@ -143,7 +195,15 @@ func autoMigrate(db *gorm.DB) error {
 	// 在这里添加所有需要迁移的模型
 	return db.AutoMigrate(
 		&model.User{},
+		&model.UserTag{},
+		&model.LoginHistory{},
 		&model.RiskReportUsage{},
+		&model.RiskReportUsageDaily{},
+		&model.RefreshToken{},
+		&model.SecurityEvent{},
+		&model.PasswordHistory{},
+		&model.UserChangeLog{},
+		&model.PersonalAccessToken{},
 		// 添加其他模型...
 	)
 }
@@ -166,6 +226,29 @@ func (d *Database) Close() error {
 	return sqlDB.Close()
 }
 
+// PoolConfig 返回当前生效的连接池配置
+func (d *Database) PoolConfig() config.PoolConfig {
+	d.poolMu.Lock()
+	defer d.poolMu.Unlock()
+	return d.pool
+}
+
+// UpdatePool 在运行时调整连接池大小与连接生存时间，无需重启
+// 新值不合理时返回错误且不做任何修改；调小上限时超出的连接在归还后关闭，不中断进行中的查询
+func (d *Database) UpdatePool(cfg config.PoolConfig) error {
+	if err := cfg.ValidateLimits(); err != nil {
+		return err
+	}
+
+	d.poolMu.Lock()
+	defer d.poolMu.Unlock()
+	if err := configurePool(d.DB, &cfg); err != nil {
+		return err
+	}
+	d.pool = cfg
+	return nil
+}
+
 // Stats 获取数据库连接池统计信息
 func (d *Database) Stats() (map[string]interface{}, error) {
 	sqlDB, err := d.DB.DB()
@@ -214,28 +297,29 @@ func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
 }
 
 // Info 记录信息日志
-func (l *gormLogger) Info(_ context.Context, msg string, data ...interface{}) {
+func (l *gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
 	if l.logLevel >= gormlogger.Info {
-		l.log.Info(fmt.Sprintf(msg, data...))
+		l.log.Info(fmt.Sprintf(msg, data...), logger.FieldsFromContext(ctx)...)
 	}
 }
 
 // Warn 记录警告日志
-func (l *gormLogger) Warn(_ context.Context, msg string, data ...interface{}) {
+func (l *gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
 	if l.logLevel >= gormlogger.Warn {
-		l.log.Warn(fmt.Sprintf(msg, data...))
+		l.log.Warn(fmt.Sprintf(msg, data...), logger.FieldsFromContext(ctx)...)
 	}
 }
 
 // Error 记录错误日志
-func (l *gormLogger) Error(_ context.Context, msg string, data ...interface{}) {
+func (l *gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
 	if l.logLevel >= gormlogger.Error {
-		l.log.Error(fmt.Sprintf(msg, data...))
+		l.log.Error(fmt.Sprintf(msg, data...), logger.FieldsFromContext(ctx)...)
 	}
 }
 
 // Trace 记录 SQL 执行日志
-func (l *gormLogger) Trace(_ context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+// 上下文中的 request_id、user_id 会作为日志字段输出，便于将 SQL 关联到具体请求
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
 	if l.logLevel <= gormlogger.Silent {
 		return
 	}
@@ -248,6 +332,7 @@ func (l *gormLogger) Trace(_ context.Context, begin time.Time, fc func() (sql st
 		logger.Int64("rows", rows),
 		logger.Duration("elapsed", elapsed),
 	}
+	fields = append(fields, logger.FieldsFromContext(ctx)...)
 
 	if err != nil {
 		fields = append(fields, logger.Err(err))
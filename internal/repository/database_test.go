@@ -0,0 +1,180 @@
+// Package repository 提供数据访问层的实现
+//
+// 本文件包含数据库初始化与日志适配器的单元测试
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// recordedEntry 记录的一条日志
+type recordedEntry struct {
+	msg    string
+	fields map[string]string
+}
+
+// recordingLogger 记录日志内容的 Logger 实现，用于断言日志字段
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []recordedEntry
+}
+
+func (l *recordingLogger) record(msg string, fields []logger.Field) {
+	entry := recordedEntry{msg: msg, fields: make(map[string]string)}
+	for _, f := range fields {
+		entry.fields[f.Key] = f.String
+	}
+	l.mu.Lock()
+	l.entries = append(l.entries, entry)
+	l.mu.Unlock()
+}
+
+func (l *recordingLogger) Debug(msg string, fields ...logger.Field) { l.record(msg, fields) }
+func (l *recordingLogger) Info(msg string, fields ...logger.Field)  { l.record(msg, fields) }
+func (l *recordingLogger) Warn(msg string, fields ...logger.Field)  { l.record(msg, fields) }
+func (l *recordingLogger) Error(msg string, fields ...logger.Field) { l.record(msg, fields) }
+func (l *recordingLogger) Fatal(msg string, fields ...logger.Field) { l.record(msg, fields) }
+func (l *recordingLogger) With(_ ...logger.Field) logger.Logger     { return l }
+func (l *recordingLogger) Sync() error                              { return nil }
+
+func TestGormLogger_TraceIncludesContextFields(t *testing.T) {
+	// 准备：使用记录日志的适配器，开启 SQL 日志
+	db := newTestDB(t)
+	rec := &recordingLogger{}
+	db.Logger = newGormLogger(true, rec)
+
+	ctx := logger.ContextWithRequestID(context.Background(), "req-123")
+	ctx = logger.ContextWithUserID(ctx, "user-456")
+
+	// 执行
+	var count int64
+	require.NoError(t, db.WithContext(ctx).Model(&model.User{}).Count(&count).Error)
+
+	// 断言：SQL 日志带有请求 ID 与用户 ID
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	require.NotEmpty(t, rec.entries)
+	last := rec.entries[len(rec.entries)-1]
+	assert.Contains(t, last.fields["sql"], "SELECT count(*)")
+	assert.Equal(t, "req-123", last.fields["request_id"])
+	assert.Equal(t, "user-456", last.fields["user_id"])
+}
+
+func TestGormLogger_TraceWithoutContextFields(t *testing.T) {
+	db := newTestDB(t)
+	rec := &recordingLogger{}
+	db.Logger = newGormLogger(true, rec)
+
+	var count int64
+	require.NoError(t, db.WithContext(context.Background()).Model(&model.User{}).Count(&count).Error)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	require.NotEmpty(t, rec.entries)
+	assert.NotContains(t, rec.entries[len(rec.entries)-1].fields, "request_id")
+}
+
+func TestInitSQLite_AppliesPragmas(t *testing.T) {
+	// 准备：使用临时文件数据库（WAL 模式不适用于内存数据库）
+	cfg := &config.DatabaseConfig{
+		SQLite: config.SQLiteConfig{
+			Path:        filepath.Join(t.TempDir(), "pragma.db"),
+			ForeignKeys: true,
+			WAL:         true,
+			BusyTimeout: 5000,
+		},
+	}
+
+	// 执行
+	db, err := initSQLite(cfg, &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	// 断言：连接后查询 PRAGMA 均已生效
+	var foreignKeys, busyTimeout int
+	var journalMode string
+	require.NoError(t, db.Raw("PRAGMA foreign_keys").Scan(&foreignKeys).Error)
+	require.NoError(t, db.Raw("PRAGMA journal_mode").Scan(&journalMode).Error)
+	require.NoError(t, db.Raw("PRAGMA busy_timeout").Scan(&busyTimeout).Error)
+	assert.Equal(t, 1, foreignKeys)
+	assert.Equal(t, "wal", strings.ToLower(journalMode))
+	assert.Equal(t, 5000, busyTimeout)
+}
+
+func TestInitSQLite_PragmasDisabled(t *testing.T) {
+	cfg := &config.DatabaseConfig{
+		SQLite: config.SQLiteConfig{Path: filepath.Join(t.TempDir(), "plain.db")},
+	}
+
+	db, err := initSQLite(cfg, &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	var foreignKeys int
+	var journalMode string
+	require.NoError(t, db.Raw("PRAGMA foreign_keys").Scan(&foreignKeys).Error)
+	require.NoError(t, db.Raw("PRAGMA journal_mode").Scan(&journalMode).Error)
+	assert.Equal(t, 0, foreignKeys)
+	assert.NotEqual(t, "wal", strings.ToLower(journalMode))
+}
+
+func TestDatabase_UpdatePool(t *testing.T) {
+	// 准备
+	db := newTestDB(t)
+	database := WrapDatabase(db, &config.DatabaseConfig{Pool: config.PoolConfig{MaxOpenConns: 10, MaxIdleConns: 5}})
+	require.NoError(t, configurePool(db, &config.PoolConfig{MaxOpenConns: 10, MaxIdleConns: 5}))
+
+	// 执行
+	err := database.UpdatePool(config.PoolConfig{MaxOpenConns: 3, MaxIdleConns: 2, ConnMaxLifetime: 5})
+
+	// 断言：Stats 反映新上限
+	require.NoError(t, err)
+	stats, err := database.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats["max_open_connections"])
+	assert.Equal(t, 3, database.PoolConfig().MaxOpenConns)
+	assert.Equal(t, 2, database.PoolConfig().MaxIdleConns)
+}
+
+func TestDatabase_UpdatePool_RejectsInvalidValues(t *testing.T) {
+	db := newTestDB(t)
+	current := config.PoolConfig{MaxOpenConns: 10, MaxIdleConns: 5}
+	database := WrapDatabase(db, &config.DatabaseConfig{Pool: current})
+	require.NoError(t, configurePool(db, &current))
+
+	for name, cfg := range map[string]config.PoolConfig{
+		"最大连接数为 0":  {MaxOpenConns: 0},
+		"最大连接数过大":   {MaxOpenConns: 100000},
+		"空闲连接数超过上限": {MaxOpenConns: 5, MaxIdleConns: 6},
+		"空闲连接数为负":   {MaxOpenConns: 5, MaxIdleConns: -1},
+		"生存时间为负":    {MaxOpenConns: 5, ConnMaxLifetime: -1},
+	} {
+		assert.Error(t, database.UpdatePool(cfg), name)
+	}
+
+	// 校验失败不修改连接池
+	stats, err := database.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 10, stats["max_open_connections"])
+	assert.Equal(t, current, database.PoolConfig())
+}
@@ -0,0 +1,93 @@
+// Package repository 提供数据访问层的实现
+package repository
+
+import (
+	"sort"
+
+	"github.com/example/go-user-api/internal/model"
+	"gorm.io/gorm"
+)
+
+// latencyPercentileCalculator 请求延迟百分位计算
+// 不同数据库对窗口函数的支持不同，按驱动选择实现
+type latencyPercentileCalculator interface {
+	// Calculate 对已附加过滤条件的查询计算百分位
+	Calculate(query *gorm.DB) (*model.LatencyPercentiles, error)
+}
+
+// newLatencyPercentileCalculator 根据数据库驱动选择百分位计算实现
+// MySQL 8 使用窗口函数在数据库内计算；其他驱动（如 SQLite）拉取样本后在应用层计算
+func newLatencyPercentileCalculator(db *gorm.DB) latencyPercentileCalculator {
+	if db.Dialector.Name() == "mysql" {
+		return windowPercentileCalculator{}
+	}
+	return inMemoryPercentileCalculator{}
+}
+
+// windowPercentileCalculator 基于 MySQL 8 窗口函数的实现
+// 用 ROW_NUMBER 给样本排序，取秩不小于 ceil(p * count) 的最小值，与 nearestRank 的结果一致
+type windowPercentileCalculator struct{}
+
+// Calculate 实现 latencyPercentileCalculator
+func (windowPercentileCalculator) Calculate(query *gorm.DB) (*model.LatencyPercentiles, error) {
+	ranked := query.Select(`
+		response_duration_ms AS duration,
+		ROW_NUMBER() OVER (ORDER BY response_duration_ms) AS rn,
+		COUNT(*) OVER () AS cnt
+	`)
+
+	var result model.LatencyPercentiles
+	err := query.Session(&gorm.Session{NewDB: true}).
+		Table("(?) AS ranked", ranked).
+		Select(`
+			COUNT(*) AS count,
+			COALESCE(MIN(CASE WHEN rn >= CEIL(cnt * 0.50) THEN duration END), 0) AS p50,
+			COALESCE(MIN(CASE WHEN rn >= CEIL(cnt * 0.95) THEN duration END), 0) AS p95,
+			COALESCE(MIN(CASE WHEN rn >= CEIL(cnt * 0.99) THEN duration END), 0) AS p99
+		`).
+		Scan(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// inMemoryPercentileCalculator 在应用层计算的实现
+// 需要把时间范围内的全部延迟样本读入内存，调用方应限制统计的时间范围
+type inMemoryPercentileCalculator struct{}
+
+// Calculate 实现 latencyPercentileCalculator
+func (inMemoryPercentileCalculator) Calculate(query *gorm.DB) (*model.LatencyPercentiles, error) {
+	var durations []int64
+	if err := query.Order("response_duration_ms").Pluck("response_duration_ms", &durations).Error; err != nil {
+		return nil, err
+	}
+	return computeLatencyPercentiles(durations), nil
+}
+
+// computeLatencyPercentiles 对样本计算 P50/P95/P99
+func computeLatencyPercentiles(durations []int64) *model.LatencyPercentiles {
+	sorted := make([]int64, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return &model.LatencyPercentiles{
+		Count: int64(len(sorted)),
+		P50:   nearestRank(sorted, 50),
+		P95:   nearestRank(sorted, 95),
+		P99:   nearestRank(sorted, 99),
+	}
+}
+
+// nearestRank 最近秩法求第 pct 百分位，sorted 须已升序排列
+// 用整数运算求 ceil(pct * n / 100)，避免浮点误差导致秩偏移
+func nearestRank(sorted []int64, pct int) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (pct*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
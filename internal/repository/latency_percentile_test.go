@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeLatencyPercentiles(t *testing.T) {
+	// 1..100 的乱序样本
+	hundred := make([]int64, 0, 100)
+	for i := int64(100); i >= 1; i-- {
+		hundred = append(hundred, i)
+	}
+
+	tests := []struct {
+		name    string
+		samples []int64
+		want    model.LatencyPercentiles
+	}{
+		{
+			name:    "无样本",
+			samples: nil,
+			want:    model.LatencyPercentiles{},
+		},
+		{
+			name:    "单个样本",
+			samples: []int64{42},
+			want:    model.LatencyPercentiles{Count: 1, P50: 42, P95: 42, P99: 42},
+		},
+		{
+			name:    "1 到 100",
+			samples: hundred,
+			want:    model.LatencyPercentiles{Count: 100, P50: 50, P95: 95, P99: 99},
+		},
+		{
+			// 秩 = ceil(p * 20)：P50 第 10 个，P95 第 19 个，P99 第 20 个
+			name: "20 个样本含极端值",
+			samples: []int64{
+				5000, 10, 20, 30, 40, 50, 60, 70, 80, 90,
+				100, 110, 120, 130, 140, 150, 160, 170, 180, 900,
+			},
+			want: model.LatencyPercentiles{Count: 20, P50: 100, P95: 900, P99: 5000},
+		},
+		{
+			// 秩 = ceil(p * 10)：P50 第 5 个，P95 与 P99 均为第 10 个
+			name:    "10 个样本",
+			samples: []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			want:    model.LatencyPercentiles{Count: 10, P50: 5, P95: 10, P99: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeLatencyPercentiles(tt.samples)
+			assert.Equal(t, tt.want, *got)
+		})
+	}
+}
+
+func TestComputeLatencyPercentiles_DoesNotMutateInput(t *testing.T) {
+	samples := []int64{3, 1, 2}
+	computeLatencyPercentiles(samples)
+	assert.Equal(t, []int64{3, 1, 2}, samples)
+}
+
+func TestRiskReportUsageRepository_LatencyPercentiles(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewRiskReportUsageRepository(db)
+	ctx := context.Background()
+
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	create := func(userID string, duration *int) {
+		usage := &model.RiskReportUsage{
+			UserID:             userID,
+			Ticker:             "AAPL",
+			RequestTime:        base,
+			ResponseTime:       base.Add(time.Second),
+			AIResponse:         "ok",
+			ResponseDurationMs: duration,
+		}
+		require.NoError(t, db.Create(usage).Error)
+	}
+
+	for i := 1; i <= 100; i++ {
+		d := i * 10
+		create("user-1", &d)
+	}
+	create("user-1", nil) // 未记录耗时，不参与统计
+	outlier := 999999
+	create("user-2", &outlier) // 其他用户，不应计入
+
+	got, err := repo.LatencyPercentiles(ctx, "user-1", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, model.LatencyPercentiles{Count: 100, P50: 500, P95: 950, P99: 990}, *got)
+
+	all, err := repo.LatencyPercentiles(ctx, "", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	// 101 个样本时 P99 的秩为 ceil(99.99) = 100，单个极端值不会拉高 P99
+	assert.Equal(t, int64(101), all.Count)
+	assert.Equal(t, int64(1000), all.P99)
+}
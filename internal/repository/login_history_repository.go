@@ -0,0 +1,51 @@
+// Package repository 提供数据访问层的实现
+package repository
+
+import (
+	"context"
+
+	"github.com/example/go-user-api/internal/model"
+	apperrors "github.com/example/go-user-api/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// LoginHistoryRepository 登录历史仓储接口
+type LoginHistoryRepository interface {
+	// Create 记录一次登录
+	Create(ctx context.Context, history *model.LoginHistory) error
+	// ListRecentByUser 获取用户最近的登录记录，按时间倒序；limit <= 0 时返回全部
+	ListRecentByUser(ctx context.Context, userID string, limit int) ([]model.LoginHistory, error)
+}
+
+// loginHistoryRepository 登录历史仓储实现
+type loginHistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginHistoryRepository 创建登录历史仓储实例
+func NewLoginHistoryRepository(db *gorm.DB) LoginHistoryRepository {
+	return &loginHistoryRepository{db: db}
+}
+
+// Create 记录一次登录
+func (r *loginHistoryRepository) Create(ctx context.Context, history *model.LoginHistory) error {
+	if err := r.db.WithContext(ctx).Create(history).Error; err != nil {
+		return apperrors.ErrDatabaseError.WithError(err)
+	}
+	return nil
+}
+
+// ListRecentByUser 获取用户最近的登录记录，按时间倒序；limit <= 0 时返回全部
+func (r *loginHistoryRepository) ListRecentByUser(ctx context.Context, userID string, limit int) ([]model.LoginHistory, error) {
+	var histories []model.LoginHistory
+	query := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order(stableOrder("created_at", "desc"))
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&histories).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError.WithError(err)
+	}
+	return histories, nil
+}
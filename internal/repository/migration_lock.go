@@ -0,0 +1,165 @@
+// Package repository 提供数据访问层的实现
+//
+// 本文件包含数据库迁移锁的实现。
+// 多副本同时启动时并发执行 AutoMigrate 可能因 DDL 冲突而失败，
+// 迁移前先获取数据库级的 advisory lock，保证同一时刻只有一个实例迁移。
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationLockName 迁移锁名称（MySQL GET_LOCK 使用）
+const migrationLockName = "go-user-api:migration"
+
+// migrationLockKey 迁移锁键值（PostgreSQL pg_advisory_lock 使用）
+const migrationLockKey int64 = 0x676f7573657261 // "gousera"
+
+// processMigrationMu 进程内迁移锁
+// SQLite 为单机文件数据库，没有跨进程的 advisory lock，退化为进程内互斥
+var processMigrationMu sync.Mutex
+
+// migrationLocker 迁移锁
+type migrationLocker interface {
+	// Lock 获取锁，阻塞直到成功或 ctx 到期；返回的 unlock 用于释放锁
+	Lock(ctx context.Context) (unlock func() error, err error)
+}
+
+// newMigrationLocker 根据数据库驱动选择迁移锁实现
+func newMigrationLocker(db *gorm.DB) migrationLocker {
+	switch db.Dialector.Name() {
+	case "mysql":
+		return &sessionMigrationLocker{
+			db:          db,
+			lockSQL:     "SELECT GET_LOCK(?, ?)",
+			unlockSQL:   "SELECT RELEASE_LOCK(?)",
+			lockArgs:    func(ctx context.Context) []interface{} { return []interface{}{migrationLockName, lockWaitSeconds(ctx)} },
+			unlockArgs:  []interface{}{migrationLockName},
+			checkResult: true,
+		}
+	case "postgres":
+		return &sessionMigrationLocker{
+			db:         db,
+			lockSQL:    "SELECT pg_advisory_lock($1)",
+			unlockSQL:  "SELECT pg_advisory_unlock($1)",
+			lockArgs:   func(context.Context) []interface{} { return []interface{}{migrationLockKey} },
+			unlockArgs: []interface{}{migrationLockKey},
+		}
+	default:
+		return processMigrationLocker{}
+	}
+}
+
+// withMigrationLock 在持有迁移锁期间执行 fn
+func withMigrationLock(ctx context.Context, db *gorm.DB, fn func() error) error {
+	unlock, err := newMigrationLocker(db).Lock(ctx)
+	if err != nil {
+		return fmt.Errorf("获取迁移锁失败: %w", err)
+	}
+	defer unlock()
+
+	return fn()
+}
+
+// processMigrationLocker 进程内迁移锁
+type processMigrationLocker struct{}
+
+// Lock 实现 migrationLocker
+func (processMigrationLocker) Lock(ctx context.Context) (func() error, error) {
+	acquired := make(chan struct{})
+	go func() {
+		processMigrationMu.Lock()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		return func() error {
+			processMigrationMu.Unlock()
+			return nil
+		}, nil
+	case <-ctx.Done():
+		// 放弃等待，但后台 goroutine 仍会拿到锁，拿到后立即释放
+		go func() {
+			<-acquired
+			processMigrationMu.Unlock()
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// sessionMigrationLocker 会话级 advisory lock
+// MySQL 与 PostgreSQL 的 advisory lock 绑定在数据库连接上，
+// 加锁和解锁必须使用连接池中的同一个连接，因此持锁期间独占一个连接
+type sessionMigrationLocker struct {
+	db         *gorm.DB
+	lockSQL    string
+	unlockSQL  string
+	lockArgs   func(ctx context.Context) []interface{}
+	unlockArgs []interface{}
+	// checkResult 是否检查加锁语句的返回值（GET_LOCK 超时返回 0，出错返回 NULL）
+	checkResult bool
+}
+
+// Lock 实现 migrationLocker
+func (l *sessionMigrationLocker) Lock(ctx context.Context) (func() error, error) {
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := l.acquire(ctx, conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return func() error {
+		// 释放锁不受加锁 ctx 到期的影响
+		_, err := conn.ExecContext(context.Background(), l.unlockSQL, l.unlockArgs...)
+		if closeErr := conn.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}, nil
+}
+
+// acquire 在指定连接上执行加锁语句
+func (l *sessionMigrationLocker) acquire(ctx context.Context, conn *sql.Conn) error {
+	if !l.checkResult {
+		_, err := conn.ExecContext(ctx, l.lockSQL, l.lockArgs(ctx)...)
+		return err
+	}
+
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, l.lockSQL, l.lockArgs(ctx)...).Scan(&acquired); err != nil {
+		return err
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		return fmt.Errorf("等待迁移锁超时")
+	}
+	return nil
+}
+
+// lockWaitSeconds 根据 ctx 的截止时间计算 GET_LOCK 的等待秒数
+// 没有截止时间时返回 -1，表示无限等待
+func lockWaitSeconds(ctx context.Context) int {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return -1
+	}
+	seconds := int(time.Until(deadline).Seconds())
+	if seconds < 0 {
+		return 0
+	}
+	return seconds
+}
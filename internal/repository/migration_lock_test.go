@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMigrationLocker_SQLiteFallsBackToProcessLock(t *testing.T) {
+	db := newTestDB(t)
+	assert.IsType(t, processMigrationLocker{}, newMigrationLocker(db))
+}
+
+func TestWithMigrationLock_SerializesConcurrentMigrations(t *testing.T) {
+	// 两个连接模拟同时启动的两个实例
+	dbA := newTestDB(t)
+	dbB := newTestDB(t)
+
+	var running, maxRunning int32
+	var order []string
+	var mu sync.Mutex
+
+	migrate := func(name string) func() error {
+		return func() error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+					break
+				}
+			}
+			mu.Lock()
+			order = append(order, name+":start")
+			mu.Unlock()
+
+			time.Sleep(50 * time.Millisecond)
+
+			mu.Lock()
+			order = append(order, name+":end")
+			mu.Unlock()
+			atomic.AddInt32(&running, -1)
+			return nil
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, inst := range []struct {
+		name string
+		run  func() error
+	}{
+		{"a", func() error { return withMigrationLock(context.Background(), dbA, migrate("a")) }},
+		{"b", func() error { return withMigrationLock(context.Background(), dbB, migrate("b")) }},
+	} {
+		wg.Add(1)
+		go func(i int, run func() error) {
+			defer wg.Done()
+			errs[i] = run()
+		}(i, inst.run)
+	}
+	wg.Wait()
+
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[1])
+	assert.Equal(t, int32(1), maxRunning, "同一时刻只应有一个迁移在执行")
+	require.Len(t, order, 4)
+	// 每个迁移的开始与结束必须相邻，不能交错
+	assert.Equal(t, order[0][:1], order[1][:1])
+	assert.Equal(t, order[2][:1], order[3][:1])
+}
+
+func TestWithMigrationLock_TimeoutWhileHeld(t *testing.T) {
+	db := newTestDB(t)
+
+	held := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- withMigrationLock(context.Background(), db, func() error {
+			close(held)
+			<-release
+			return nil
+		})
+	}()
+	<-held
+
+	// 锁被占用时，等待超过 ctx 截止时间应返回错误且不执行迁移
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	called := false
+	err := withMigrationLock(ctx, db, func() error {
+		called = true
+		return nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, called)
+
+	// 释放后锁可再次获取
+	close(release)
+	require.NoError(t, <-done)
+	require.NoError(t, withMigrationLock(context.Background(), db, func() error { return nil }))
+}
+
+func TestLockWaitSeconds(t *testing.T) {
+	assert.Equal(t, -1, lockWaitSeconds(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+	assert.InDelta(t, 89, lockWaitSeconds(ctx), 1)
+}
@@ -0,0 +1,15 @@
+// Package repository 提供数据访问层的实现
+//
+// 本文件包含分页查询的排序辅助函数。
+package repository
+
+// stableOrder 返回以 id 兜底的排序子句
+// 排序字段相同的记录（如同一时刻批量创建）在数据库中的返回顺序不确定，
+// 翻页时可能重复或遗漏；追加主键后顺序唯一确定，与主排序方向一致。
+// column 与 direction 必须来自代码中的常量或白名单，不能直接使用用户输入
+func stableOrder(column, direction string) string {
+	if column == "id" {
+		return "id " + direction
+	}
+	return column + " " + direction + ", id " + direction
+}
@@ -0,0 +1,77 @@
+// Package repository 提供数据访问层的实现
+package repository
+
+import (
+	"context"
+
+	"github.com/example/go-user-api/internal/model"
+	apperrors "github.com/example/go-user-api/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// PasswordHistoryRepository 密码历史仓储接口
+type PasswordHistoryRepository interface {
+	// Create 记录一个旧密码哈希
+	Create(ctx context.Context, history *model.PasswordHistory) error
+	// ListRecentByUser 获取用户最近的密码历史，按时间倒序
+	ListRecentByUser(ctx context.Context, userID string, limit int) ([]model.PasswordHistory, error)
+	// PruneByUser 只保留用户最近的 keep 条密码历史，返回删除的条数
+	PruneByUser(ctx context.Context, userID string, keep int) (int64, error)
+}
+
+// passwordHistoryRepository 密码历史仓储实现
+type passwordHistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordHistoryRepository 创建密码历史仓储实例
+func NewPasswordHistoryRepository(db *gorm.DB) PasswordHistoryRepository {
+	return &passwordHistoryRepository{db: db}
+}
+
+// Create 记录一个旧密码哈希
+func (r *passwordHistoryRepository) Create(ctx context.Context, history *model.PasswordHistory) error {
+	if err := r.db.WithContext(ctx).Create(history).Error; err != nil {
+		return apperrors.ErrDatabaseError.WithError(err)
+	}
+	return nil
+}
+
+// ListRecentByUser 获取用户最近的密码历史，按时间倒序
+func (r *passwordHistoryRepository) ListRecentByUser(ctx context.Context, userID string, limit int) ([]model.PasswordHistory, error) {
+	var histories []model.PasswordHistory
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order(stableOrder("created_at", "desc")).
+		Limit(limit).
+		Find(&histories).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError.WithError(err)
+	}
+	return histories, nil
+}
+
+// PruneByUser 只保留用户最近的 keep 条密码历史，返回删除的条数
+// MySQL 不支持在 IN 子查询中使用 LIMIT，因此先查出需要保留的 ID 再删除其余记录
+func (r *passwordHistoryRepository) PruneByUser(ctx context.Context, userID string, keep int) (int64, error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if keep > 0 {
+		var keepIDs []string
+		if err := r.db.WithContext(ctx).
+			Model(&model.PasswordHistory{}).
+			Where("user_id = ?", userID).
+			Order(stableOrder("created_at", "desc")).
+			Limit(keep).
+			Pluck("id", &keepIDs).Error; err != nil {
+			return 0, apperrors.ErrDatabaseError.WithError(err)
+		}
+		if len(keepIDs) > 0 {
+			query = query.Where("id NOT IN ?", keepIDs)
+		}
+	}
+
+	result := query.Delete(&model.PasswordHistory{})
+	if result.Error != nil {
+		return 0, apperrors.ErrDatabaseError.WithError(result.Error)
+	}
+	return result.RowsAffected, nil
+}
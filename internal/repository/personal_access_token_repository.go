@@ -0,0 +1,131 @@
+// Package repository 提供数据访问层的实现
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	apperrors "github.com/example/go-user-api/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// PersonalAccessTokenRepository 个人访问令牌仓储接口
+type PersonalAccessTokenRepository interface {
+	// Create 保存新创建的令牌
+	Create(ctx context.Context, token *model.PersonalAccessToken) error
+	// GetByHash 根据令牌哈希获取令牌，包括已撤销与已过期的令牌
+	GetByHash(ctx context.Context, hash string) (*model.PersonalAccessToken, error)
+	// ListByUser 获取用户未撤销的令牌，按创建时间倒序
+	ListByUser(ctx context.Context, userID string) ([]model.PersonalAccessToken, error)
+	// CountActiveByUser 统计用户未撤销且未过期的令牌数
+	CountActiveByUser(ctx context.Context, userID string) (int64, error)
+	// Revoke 撤销用户的指定令牌，令牌不存在、不属于该用户或已撤销时返回 ErrResourceNotFound
+	Revoke(ctx context.Context, userID, id string) error
+	// RevokeAllByUser 撤销用户所有未撤销的令牌
+	RevokeAllByUser(ctx context.Context, userID string) error
+	// RevokeAll 撤销所有用户未撤销的令牌
+	RevokeAll(ctx context.Context) error
+	// UpdateLastUsed 更新令牌最近使用时间
+	UpdateLastUsed(ctx context.Context, id string, at time.Time) error
+}
+
+// personalAccessTokenRepository 个人访问令牌仓储实现
+type personalAccessTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewPersonalAccessTokenRepository 创建个人访问令牌仓储实例
+func NewPersonalAccessTokenRepository(db *gorm.DB) PersonalAccessTokenRepository {
+	return &personalAccessTokenRepository{db: db}
+}
+
+// Create 保存新创建的令牌
+func (r *personalAccessTokenRepository) Create(ctx context.Context, token *model.PersonalAccessToken) error {
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return apperrors.ErrDatabaseError.WithError(err)
+	}
+	return nil
+}
+
+// GetByHash 根据令牌哈希获取令牌
+// 令牌不存在时返回 ErrInvalidToken
+func (r *personalAccessTokenRepository) GetByHash(ctx context.Context, hash string) (*model.PersonalAccessToken, error) {
+	var token model.PersonalAccessToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrInvalidToken.WithDetail("个人访问令牌不存在")
+		}
+		return nil, apperrors.ErrDatabaseError.WithError(err)
+	}
+	return &token, nil
+}
+
+// ListByUser 获取用户未撤销的令牌，按创建时间倒序
+func (r *personalAccessTokenRepository) ListByUser(ctx context.Context, userID string) ([]model.PersonalAccessToken, error) {
+	var tokens []model.PersonalAccessToken
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order(stableOrder("created_at", "desc")).
+		Find(&tokens).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError.WithError(err)
+	}
+	return tokens, nil
+}
+
+// CountActiveByUser 统计用户未撤销且未过期的令牌数
+func (r *personalAccessTokenRepository) CountActiveByUser(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Count(&count).Error; err != nil {
+		return 0, apperrors.ErrDatabaseError.WithError(err)
+	}
+	return count, nil
+}
+
+// Revoke 撤销用户的指定令牌
+// 条件中带上 user_id，用户不能撤销他人的令牌
+func (r *personalAccessTokenRepository) Revoke(ctx context.Context, userID, id string) error {
+	result := r.db.WithContext(ctx).Model(&model.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return apperrors.ErrDatabaseError.WithError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrResourceNotFound.WithDetail("个人访问令牌不存在")
+	}
+	return nil
+}
+
+// RevokeAllByUser 撤销用户所有未撤销的令牌
+func (r *personalAccessTokenRepository) RevokeAllByUser(ctx context.Context, userID string) error {
+	if err := r.db.WithContext(ctx).Model(&model.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return apperrors.ErrDatabaseError.WithError(err)
+	}
+	return nil
+}
+
+// RevokeAll 撤销所有用户未撤销的令牌
+func (r *personalAccessTokenRepository) RevokeAll(ctx context.Context) error {
+	if err := r.db.WithContext(ctx).Model(&model.PersonalAccessToken{}).
+		Where("revoked_at IS NULL").
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return apperrors.ErrDatabaseError.WithError(err)
+	}
+	return nil
+}
+
+// UpdateLastUsed 更新令牌最近使用时间
+func (r *personalAccessTokenRepository) UpdateLastUsed(ctx context.Context, id string, at time.Time) error {
+	if err := r.db.WithContext(ctx).Model(&model.PersonalAccessToken{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", at).Error; err != nil {
+		return apperrors.ErrDatabaseError.WithError(err)
+	}
+	return nil
+}
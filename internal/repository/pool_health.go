@@ -0,0 +1,65 @@
+// Package repository 提供数据访问层的实现
+//
+// 本文件实现了数据库连接池的健康判断，供就绪检查使用。
+// 连接池接近饱和或请求开始排队等待连接时，实例仍可服务但响应会变慢，标记为降级。
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/example/go-user-api/internal/model"
+)
+
+// PoolHealthChecker 连接池健康检查器
+// 通过比较相邻两次检查的 wait_count 判断等待是否在持续增长，因此需要在多次检查间复用同一实例
+type PoolHealthChecker struct {
+	threshold float64
+
+	mu            sync.Mutex
+	lastWaitCount int64
+	checked       bool
+}
+
+// NewPoolHealthChecker 创建连接池健康检查器
+// threshold 为使用中连接数占最大连接数的比例（0-1），达到即视为饱和
+func NewPoolHealthChecker(threshold float64) *PoolHealthChecker {
+	return &PoolHealthChecker{threshold: threshold}
+}
+
+// Check 根据连接池统计判断健康状况
+// 满足以下任一条件即为降级：
+//   - 设置了最大连接数且使用中连接数达到阈值
+//   - wait_count 比上一次检查时增长（首次检查只记录基线）
+func (c *PoolHealthChecker) Check(stats sql.DBStats) *model.PoolHealth {
+	health := &model.PoolHealth{
+		Status:             model.PoolStatusHealthy,
+		InUse:              stats.InUse,
+		MaxOpenConnections: stats.MaxOpenConnections,
+		WaitCount:          stats.WaitCount,
+	}
+
+	if stats.MaxOpenConnections > 0 {
+		limit := int(math.Ceil(c.threshold * float64(stats.MaxOpenConnections)))
+		if stats.InUse >= limit {
+			health.Reasons = append(health.Reasons,
+				fmt.Sprintf("使用中连接数 %d 已达最大连接数 %d 的 %.0f%%", stats.InUse, stats.MaxOpenConnections, c.threshold*100))
+		}
+	}
+
+	c.mu.Lock()
+	if c.checked && stats.WaitCount > c.lastWaitCount {
+		health.Reasons = append(health.Reasons,
+			fmt.Sprintf("等待连接次数自上次检查增加 %d", stats.WaitCount-c.lastWaitCount))
+	}
+	c.lastWaitCount = stats.WaitCount
+	c.checked = true
+	c.mu.Unlock()
+
+	if len(health.Reasons) > 0 {
+		health.Status = model.PoolStatusDegraded
+	}
+	return health
+}
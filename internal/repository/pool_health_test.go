@@ -0,0 +1,47 @@
+// Package repository 提供数据访问层的实现
+//
+// 本文件包含连接池健康判断的单元测试
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolHealthChecker_Saturation(t *testing.T) {
+	checker := NewPoolHealthChecker(0.9)
+
+	// 10 个连接中 8 个在用：健康
+	health := checker.Check(sql.DBStats{MaxOpenConnections: 10, InUse: 8})
+	assert.Equal(t, model.PoolStatusHealthy, health.Status)
+	assert.Empty(t, health.Reasons)
+
+	// 9 个在用，达到 90%：降级
+	health = checker.Check(sql.DBStats{MaxOpenConnections: 10, InUse: 9})
+	assert.Equal(t, model.PoolStatusDegraded, health.Status)
+	assert.Len(t, health.Reasons, 1)
+
+	// 未限制最大连接数时不判断饱和
+	health = checker.Check(sql.DBStats{MaxOpenConnections: 0, InUse: 500})
+	assert.Equal(t, model.PoolStatusHealthy, health.Status)
+}
+
+func TestPoolHealthChecker_WaitCountGrowth(t *testing.T) {
+	checker := NewPoolHealthChecker(0.9)
+
+	// 首次检查只记录基线，即使历史上有过等待
+	health := checker.Check(sql.DBStats{MaxOpenConnections: 10, WaitCount: 5})
+	assert.Equal(t, model.PoolStatusHealthy, health.Status)
+
+	// 等待次数增长：降级
+	health = checker.Check(sql.DBStats{MaxOpenConnections: 10, WaitCount: 8})
+	assert.Equal(t, model.PoolStatusDegraded, health.Status)
+	assert.Equal(t, int64(8), health.WaitCount)
+
+	// 不再增长：恢复
+	health = checker.Check(sql.DBStats{MaxOpenConnections: 10, WaitCount: 8})
+	assert.Equal(t, model.PoolStatusHealthy, health.Status)
+}
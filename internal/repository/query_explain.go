@@ -0,0 +1,60 @@
+// Package repository 提供数据访问层的实现
+//
+// 本文件实现了查询执行计划（EXPLAIN）的获取，用于排查慢查询。
+// 先以 DryRun 模式生成与实际查询相同的 SQL 与参数，再按驱动拼接 EXPLAIN 语句执行，
+// 参数仍以占位符传递，不会拼接进 SQL。
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/example/go-user-api/internal/model"
+	apperrors "github.com/example/go-user-api/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// explainSQL 按数据库驱动拼接 EXPLAIN 语句
+func explainSQL(dialect, sql string) (string, error) {
+	switch dialect {
+	case "mysql", "postgres":
+		return "EXPLAIN " + sql, nil
+	case "sqlite":
+		return "EXPLAIN QUERY PLAN " + sql, nil
+	default:
+		return "", fmt.Errorf("数据库驱动 %s 不支持 EXPLAIN", dialect)
+	}
+}
+
+// explainQuery 返回 build 所构造查询的执行计划
+// build 需在传入的 tx 上构造并以 Find 等终结方法结束查询，tx 处于 DryRun 模式，不会真正执行
+func explainQuery(ctx context.Context, db *gorm.DB, build func(tx *gorm.DB) *gorm.DB) (*model.QueryPlan, error) {
+	stmt := build(db.WithContext(ctx).Session(&gorm.Session{DryRun: true})).Statement
+	if stmt.Error != nil {
+		return nil, apperrors.ErrDatabaseError.WithError(stmt.Error)
+	}
+
+	sql := stmt.SQL.String()
+	explain, err := explainSQL(db.Dialector.Name(), sql)
+	if err != nil {
+		return nil, apperrors.ErrBadRequest.WithDetail(err.Error())
+	}
+
+	var rows []map[string]interface{}
+	if err := db.WithContext(ctx).Raw(explain, stmt.Vars...).Scan(&rows).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError.WithError(err)
+	}
+	// MySQL 驱动以 []byte 返回文本列，转为字符串便于 JSON 输出
+	for _, row := range rows {
+		for key, value := range row {
+			if b, ok := value.([]byte); ok {
+				row[key] = string(b)
+			}
+		}
+	}
+
+	return &model.QueryPlan{
+		SQL:  db.Dialector.Explain(sql, stmt.Vars...),
+		Plan: rows,
+	}, nil
+}
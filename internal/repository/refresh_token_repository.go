@@ -0,0 +1,111 @@
+// Package repository 提供数据访问层的实现
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	apperrors "github.com/example/go-user-api/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// RefreshTokenRepository 刷新令牌仓储接口
+// 定义了刷新令牌持久化相关的数据库操作
+type RefreshTokenRepository interface {
+	// Create 保存新签发的刷新令牌
+	Create(ctx context.Context, token *model.RefreshToken) error
+	// GetByJTI 根据 jti 获取刷新令牌
+	GetByJTI(ctx context.Context, jti string) (*model.RefreshToken, error)
+	// RevokeAllByUser 撤销用户的所有刷新令牌
+	RevokeAllByUser(ctx context.Context, userID string) error
+	// CountActiveByUser 统计用户未撤销且未过期的刷新令牌数（即活跃会话数）
+	CountActiveByUser(ctx context.Context, userID string) (int64, error)
+	// ListByUser 获取用户的全部刷新令牌（包括已撤销与已过期的），按签发时间倒序
+	ListByUser(ctx context.Context, userID string) ([]model.RefreshToken, error)
+	// DeleteInactiveBefore 删除在 before 之前过期或被撤销的刷新令牌，返回删除的条数
+	DeleteInactiveBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// refreshTokenRepository 刷新令牌仓储实现
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository 创建刷新令牌仓储实例
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+// Create 保存新签发的刷新令牌
+func (r *refreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return apperrors.ErrDatabaseError.WithError(err)
+	}
+	return nil
+}
+
+// GetByJTI 根据 jti 获取刷新令牌
+// 令牌不存在时返回 ErrInvalidToken
+func (r *refreshTokenRepository) GetByJTI(ctx context.Context, jti string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	if err := r.db.WithContext(ctx).Where("jti = ?", jti).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrInvalidToken.WithDetail("刷新令牌不存在")
+		}
+		return nil, apperrors.ErrDatabaseError.WithError(err)
+	}
+	return &token, nil
+}
+
+// RevokeAllByUser 撤销用户的所有刷新令牌
+// 用于登出、修改密码等需要让所有会话失效的场景
+func (r *refreshTokenRepository) RevokeAllByUser(ctx context.Context, userID string) error {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked = ?", userID, false).
+		Updates(map[string]interface{}{
+			"revoked":    true,
+			"revoked_at": now,
+		})
+	if result.Error != nil {
+		return apperrors.ErrDatabaseError.WithError(result.Error)
+	}
+	return nil
+}
+
+// CountActiveByUser 统计用户未撤销且未过期的刷新令牌数
+func (r *refreshTokenRepository) CountActiveByUser(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked = ? AND expires_at > ?", userID, false, time.Now()).
+		Count(&count).Error; err != nil {
+		return 0, apperrors.ErrDatabaseError.WithError(err)
+	}
+	return count, nil
+}
+
+// ListByUser 获取用户的全部刷新令牌
+func (r *refreshTokenRepository) ListByUser(ctx context.Context, userID string) ([]model.RefreshToken, error) {
+	var tokens []model.RefreshToken
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order(stableOrder("created_at", "desc")).
+		Find(&tokens).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError.WithError(err)
+	}
+	return tokens, nil
+}
+
+// DeleteInactiveBefore 删除在 before 之前过期或被撤销的刷新令牌
+// 这些令牌已不能用于刷新，删除后使用它们同样返回 ErrInvalidToken
+func (r *refreshTokenRepository) DeleteInactiveBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("expires_at < ? OR (revoked = ? AND revoked_at < ?)", before, true, before).
+		Delete(&model.RefreshToken{})
+	if result.Error != nil {
+		return 0, apperrors.ErrDatabaseError.WithError(result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,297 @@
+// Package repository 提供数据访问层的实现
+//
+// 本文件实现了使用记录的按日预聚合。
+// 聚合表 risk_report_usage_daily 按用户按自然日（UTC）保存调用次数、token 与耗时之和，
+// 覆盖到表中最晚的一天（含）为止；统计时覆盖范围内的整天读聚合表，
+// 范围两端不足一天的部分与尚未聚合的日期（包括当天）从明细表实时补齐。
+// 明细的创建、修改、删除（包括回填）落在已覆盖的日期时，同一事务中重新计算受影响的 (用户, 日) 聚合；
+// 删除用户不会删除使用记录（计费对账需要保留），聚合不受影响。
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// usageTotals 一段时间内的使用量合计
+type usageTotals struct {
+	TotalQueries     int64 `gorm:"column:total_queries"`
+	TotalTokens      int64 `gorm:"column:total_tokens"`
+	PromptTokens     int64 `gorm:"column:prompt_tokens"`
+	CompletionTokens int64 `gorm:"column:completion_tokens"`
+	DurationSumMs    int64 `gorm:"column:duration_sum_ms"`
+	DurationCount    int64 `gorm:"column:duration_count"`
+}
+
+// add 累加另一段时间的合计
+func (t *usageTotals) add(other usageTotals) {
+	t.TotalQueries += other.TotalQueries
+	t.TotalTokens += other.TotalTokens
+	t.PromptTokens += other.PromptTokens
+	t.CompletionTokens += other.CompletionTokens
+	t.DurationSumMs += other.DurationSumMs
+	t.DurationCount += other.DurationCount
+}
+
+// toStats 转换为统计响应，平均耗时只计算记录了耗时的请求
+func (t usageTotals) toStats() *model.UsageStatsResponse {
+	stats := &model.UsageStatsResponse{
+		TotalQueries:          t.TotalQueries,
+		TotalTokens:           t.TotalTokens,
+		TotalPromptTokens:     t.PromptTokens,
+		TotalCompletionTokens: t.CompletionTokens,
+	}
+	if t.DurationCount > 0 {
+		stats.AvgResponseTimeMs = t.DurationSumMs / t.DurationCount
+	}
+	return stats
+}
+
+// dailyAggregateColumns 按用户汇总明细的查询列，列名与 RiskReportUsageDaily 一致
+const dailyAggregateColumns = `
+	user_id,
+	COUNT(*) AS total_queries,
+	COALESCE(SUM(total_tokens), 0) AS total_tokens,
+	COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens,
+	COALESCE(SUM(completion_tokens), 0) AS completion_tokens,
+	COALESCE(SUM(response_duration_ms), 0) AS response_duration_sum_ms,
+	COUNT(response_duration_ms) AS response_duration_count
+`
+
+// upsertDailyClause 按 idx_usage_daily_user_day 覆盖已存在的聚合行，并发刷新同一天时不会因唯一索引冲突失败
+var upsertDailyClause = clause.OnConflict{
+	Columns: []clause.Column{{Name: "user_id"}, {Name: "day"}},
+	DoUpdates: clause.AssignmentColumns([]string{
+		"total_queries", "total_tokens", "prompt_tokens", "completion_tokens",
+		"response_duration_sum_ms", "response_duration_count", "updated_at",
+	}),
+}
+
+// usageDayKey 一条日聚合行对应的用户与统计日
+type usageDayKey struct {
+	userID string
+	day    time.Time
+}
+
+// usageDayKeyOf 返回使用记录所属的日聚合行
+func usageDayKeyOf(usage *model.RiskReportUsage) usageDayKey {
+	return usageDayKey{userID: usage.UserID, day: model.UsageDay(usage.RequestTime)}
+}
+
+// DailyCoverage 返回聚合表覆盖范围的结束日（不含），聚合表为空时返回零值
+func (r *riskReportUsageRepository) DailyCoverage(ctx context.Context) (time.Time, error) {
+	coverage, err := dailyCoverage(conn(ctx, r.db))
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, errors.CodeDatabaseError, "查询日聚合覆盖范围失败")
+	}
+	return coverage, nil
+}
+
+// dailyCoverage 返回聚合表覆盖范围的结束日（不含）
+func dailyCoverage(db *gorm.DB) (time.Time, error) {
+	var latest model.RiskReportUsageDaily
+	result := db.Order("day DESC").Limit(1).Find(&latest)
+	if result.Error != nil {
+		return time.Time{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return time.Time{}, nil
+	}
+	return model.UsageDay(latest.Day).AddDate(0, 0, 1), nil
+}
+
+// RefreshDaily 从明细重新计算 [from, before) 内各日的聚合
+// from 为零值时从最早的记录开始；逐日用 GROUP BY 汇总，区间内原有的聚合行先删除再写入，
+// 整个过程在一个事务中完成
+func (r *riskReportUsageRepository) RefreshDaily(ctx context.Context, from, before time.Time) (int, error) {
+	rows := 0
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if from.IsZero() {
+			var earliest model.RiskReportUsage
+			result := tx.Select("request_time").Order("request_time").Limit(1).Find(&earliest)
+			if result.Error != nil {
+				return result.Error
+			}
+			// 早于最早记录的聚合行已没有对应明细
+			from = before
+			if result.RowsAffected > 0 {
+				from = model.UsageDay(earliest.RequestTime)
+			}
+			if err := tx.Where("day < ?", from).Delete(&model.RiskReportUsageDaily{}).Error; err != nil {
+				return err
+			}
+		}
+
+		for day := model.UsageDay(from); day.Before(before); day = day.AddDate(0, 0, 1) {
+			n, err := refreshDay(tx, day, nil)
+			if err != nil {
+				return err
+			}
+			rows += n
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, errors.CodeDatabaseError, "写入使用记录日聚合失败")
+	}
+	return rows, nil
+}
+
+// refreshDay 从明细重新计算 day 的聚合，返回写入的聚合行数
+// userIDs 为空时重算该天全部用户，否则只重算这些用户；没有明细的用户删除其聚合行
+func refreshDay(db *gorm.DB, day time.Time, userIDs []string) (int, error) {
+	query := db.Model(&model.RiskReportUsage{}).
+		Select(dailyAggregateColumns).
+		Where("request_time >= ? AND request_time < ?", day, day.AddDate(0, 0, 1))
+	del := db.Where("day = ?", day)
+	if len(userIDs) > 0 {
+		query = query.Where("user_id IN ?", userIDs)
+		del = del.Where("user_id IN ?", userIDs)
+	}
+
+	var rows []model.RiskReportUsageDaily
+	if err := query.Group("user_id").Scan(&rows).Error; err != nil {
+		return 0, err
+	}
+	if err := del.Delete(&model.RiskReportUsageDaily{}).Error; err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	for i := range rows {
+		rows[i].Day = day
+	}
+	if err := db.Clauses(upsertDailyClause).CreateInBatches(rows, 100).Error; err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+// writeUsage 执行明细写入；keys 为写入前后涉及的日聚合行
+// 涉及聚合表已覆盖的日期时，在同一事务中重新计算这些 (用户, 日) 的聚合，避免聚合与明细不一致；
+// 只涉及当天的写入不会落在覆盖范围内（聚合截止到当天零点），直接写入
+func (r *riskReportUsageRepository) writeUsage(ctx context.Context, keys []usageDayKey, write func(db *gorm.DB) error) error {
+	today := model.UsageDay(time.Now())
+	past := make([]usageDayKey, 0, len(keys))
+	for _, key := range keys {
+		if key.day.Before(today) {
+			past = append(past, key)
+		}
+	}
+	if len(past) == 0 {
+		return write(conn(ctx, r.db))
+	}
+
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := write(tx); err != nil {
+			return err
+		}
+		return refreshAffectedDays(tx, past)
+	})
+}
+
+// refreshAffectedDays 重新计算聚合表覆盖范围内受影响的 (用户, 日) 聚合
+// 覆盖范围之后的日期统计时从明细读取，不写入聚合行，以免提前扩大覆盖范围
+func refreshAffectedDays(db *gorm.DB, keys []usageDayKey) error {
+	coverage, err := dailyCoverage(db)
+	if err != nil || coverage.IsZero() {
+		return err
+	}
+
+	usersByDay := make(map[time.Time][]string)
+	seen := make(map[usageDayKey]bool, len(keys))
+	for _, key := range keys {
+		if seen[key] || !key.day.Before(coverage) {
+			continue
+		}
+		seen[key] = true
+		usersByDay[key.day] = append(usersByDay[key.day], key.userID)
+	}
+
+	days := make([]time.Time, 0, len(usersByDay))
+	for day := range usersByDay {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	for _, day := range days {
+		if _, err := refreshDay(db, day, usersByDay[day]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dailyTotals 汇总聚合表中 [from, before) 内的日聚合，from 为零值时不限起始
+func (r *riskReportUsageRepository) dailyTotals(ctx context.Context, userID string, from, before time.Time) (usageTotals, error) {
+	query := conn(ctx, r.db).Model(&model.RiskReportUsageDaily{}).
+		Select(`
+			COALESCE(SUM(total_queries), 0) AS total_queries,
+			COALESCE(SUM(total_tokens), 0) AS total_tokens,
+			COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens,
+			COALESCE(SUM(completion_tokens), 0) AS completion_tokens,
+			COALESCE(SUM(response_duration_sum_ms), 0) AS duration_sum_ms,
+			COALESCE(SUM(response_duration_count), 0) AS duration_count
+		`).
+		Where("user_id = ? AND day < ?", userID, before)
+	if !from.IsZero() {
+		query = query.Where("day >= ?", from)
+	}
+
+	var totals usageTotals
+	if err := query.Scan(&totals).Error; err != nil {
+		return usageTotals{}, errors.Wrap(err, errors.CodeDatabaseError, "读取使用记录日聚合失败")
+	}
+	return totals, nil
+}
+
+// detailTotals 从明细表汇总用户的使用量，scope 追加时间条件
+func (r *riskReportUsageRepository) detailTotals(ctx context.Context, userID string, scope func(*gorm.DB) *gorm.DB) (usageTotals, error) {
+	query := conn(ctx, r.db).Model(&model.RiskReportUsage{}).
+		Select(`
+			COUNT(*) AS total_queries,
+			COALESCE(SUM(total_tokens), 0) AS total_tokens,
+			COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens,
+			COALESCE(SUM(completion_tokens), 0) AS completion_tokens,
+			COALESCE(SUM(response_duration_ms), 0) AS duration_sum_ms,
+			COUNT(response_duration_ms) AS duration_count
+		`).
+		Where("user_id = ?", userID)
+
+	var totals usageTotals
+	if err := scope(query).Scan(&totals).Error; err != nil {
+		return usageTotals{}, errors.Wrap(err, errors.CodeDatabaseError, "获取统计信息失败")
+	}
+	return totals, nil
+}
+
+// aggregatedDays 计算 [startTime, endTime] 内可直接读聚合表的整天区间 [from, before)
+// startTime 为零值时 from 为零值（不限起始）；没有可用的整天时 ok 为 false
+func aggregatedDays(startTime, endTime, coverage time.Time) (from, before time.Time, ok bool) {
+	if coverage.IsZero() {
+		return time.Time{}, time.Time{}, false
+	}
+
+	before = coverage
+	if !endTime.IsZero() {
+		if endDay := model.UsageDay(endTime); endDay.Before(before) {
+			before = endDay
+		}
+	}
+	if !startTime.IsZero() {
+		from = model.UsageDay(startTime)
+		if from.Before(startTime) {
+			from = from.AddDate(0, 0, 1)
+		}
+		if !from.Before(before) {
+			return time.Time{}, time.Time{}, false
+		}
+	}
+	return from, before, true
+}
@@ -0,0 +1,179 @@
+// Package repository 提供数据访问层的实现
+//
+// 本文件包含使用记录日聚合的单元测试，使用内存 SQLite 数据库
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiskReportUsageRepository_GetStatsByUser_ReadsDailyAggregate(t *testing.T) {
+	// 准备：user-1 连续三天各一条，user-2 第一天一条
+	db := newTestDB(t)
+	repo := NewRiskReportUsageRepository(db)
+	ctx := context.Background()
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	create := func(userID string, requestTime time.Time, durationMs *int) {
+		usage := &model.RiskReportUsage{
+			UserID:             userID,
+			Ticker:             "AAPL",
+			RequestTime:        requestTime,
+			ResponseTime:       requestTime.Add(time.Second),
+			PromptTokens:       100,
+			CompletionTokens:   50,
+			TotalTokens:        150,
+			ResponseDurationMs: durationMs,
+			AIResponse:         "ok",
+		}
+		require.NoError(t, db.Create(usage).Error)
+	}
+	fast, slow := 800, 1200
+	create("user-1", day.Add(10*time.Hour), &fast)
+	create("user-1", day.AddDate(0, 0, 1).Add(10*time.Hour), &slow)
+	create("user-1", day.AddDate(0, 0, 2).Add(10*time.Hour), nil)
+	create("user-2", day.Add(10*time.Hour), nil)
+
+	// 执行：聚合前两天
+	rows, err := repo.RefreshDaily(ctx, time.Time{}, day.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	assert.Equal(t, 3, rows)
+	coverage, err := repo.DailyCoverage(ctx)
+	require.NoError(t, err)
+	assert.True(t, coverage.Equal(day.AddDate(0, 0, 2)), coverage)
+
+	// 删除第一天的明细，统计结果不变说明该天读的是聚合表
+	require.NoError(t, db.Where("request_time < ?", day.AddDate(0, 0, 1)).Delete(&model.RiskReportUsage{}).Error)
+
+	// 断言：前两天读聚合表，第三天从明细补齐
+	stats, err := repo.GetStatsByUser(ctx, "user-1", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, &model.UsageStatsResponse{
+		TotalQueries:          3,
+		TotalTokens:           450,
+		TotalPromptTokens:     300,
+		TotalCompletionTokens: 150,
+		AvgResponseTimeMs:     1000,
+	}, stats)
+
+	// 断言：起始时间落在第一天中间时，第一天不读聚合表，只统计其后的明细
+	stats, err = repo.GetStatsByUser(ctx, "user-1", day.Add(12*time.Hour), time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.TotalQueries)
+	assert.Equal(t, int64(1200), stats.AvgResponseTimeMs)
+
+	// 断言：结束时间落在第二天中间时，第一天读聚合表，第二天从明细统计
+	stats, err = repo.GetStatsByUser(ctx, "user-1", day, day.AddDate(0, 0, 1).Add(12*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.TotalQueries)
+	assert.Equal(t, int64(1000), stats.AvgResponseTimeMs)
+}
+
+func TestRiskReportUsageRepository_RefreshDaily_ReplacesWindow(t *testing.T) {
+	// 准备
+	db := newTestDB(t)
+	repo := NewRiskReportUsageRepository(db)
+	ctx := context.Background()
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	createTestUsage(t, db, "user-1", day.Add(time.Hour))
+	createTestUsage(t, db, "user-1", day.AddDate(0, 0, 1).Add(time.Hour))
+	_, err := repo.RefreshDaily(ctx, time.Time{}, day.AddDate(0, 0, 2))
+	require.NoError(t, err)
+
+	// 执行：第二天迟到一条上报后只重新计算第二天
+	createTestUsage(t, db, "user-1", day.AddDate(0, 0, 1).Add(2*time.Hour))
+	rows, err := repo.RefreshDaily(ctx, day.AddDate(0, 0, 1), day.AddDate(0, 0, 2))
+	require.NoError(t, err)
+
+	// 断言：第一天的聚合保留，第二天更新为 2 条
+	assert.Equal(t, 1, rows)
+	var daily []model.RiskReportUsageDaily
+	require.NoError(t, db.Order("day").Find(&daily).Error)
+	require.Len(t, daily, 2)
+	assert.Equal(t, int64(1), daily[0].TotalQueries)
+	assert.Equal(t, int64(2), daily[1].TotalQueries)
+}
+
+func TestRiskReportUsageRepository_WritesKeepDailyAggregateInSync(t *testing.T) {
+	// 准备：第一、二天已聚合
+	db := newTestDB(t)
+	repo := NewRiskReportUsageRepository(db)
+	ctx := context.Background()
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	createTestUsage(t, db, "user-1", day.Add(time.Hour))
+	createTestUsage(t, db, "user-1", day.AddDate(0, 0, 1).Add(time.Hour))
+	_, err := repo.RefreshDaily(ctx, time.Time{}, day.AddDate(0, 0, 2))
+	require.NoError(t, err)
+
+	queries := func() int64 {
+		stats, err := repo.GetStatsByUser(ctx, "user-1", time.Time{}, time.Time{})
+		require.NoError(t, err)
+		return stats.TotalQueries
+	}
+	require.Equal(t, int64(2), queries())
+
+	// 执行：回填第一天的两条历史记录
+	backfilled := []model.RiskReportUsage{
+		{UserID: "user-1", Ticker: "AAPL", RequestTime: day.Add(2 * time.Hour), ResponseTime: day.Add(2 * time.Hour), TotalTokens: 10, AIResponse: "ok"},
+		{UserID: "user-1", Ticker: "AAPL", RequestTime: day.Add(3 * time.Hour), ResponseTime: day.Add(3 * time.Hour), TotalTokens: 10, AIResponse: "ok"},
+	}
+	require.NoError(t, repo.BatchCreate(ctx, backfilled))
+
+	// 断言：聚合随之更新
+	assert.Equal(t, int64(4), queries())
+
+	// 执行：把一条记录改到第二天，再删除另一条
+	moved, err := repo.GetByID(ctx, backfilled[0].ID)
+	require.NoError(t, err)
+	moved.RequestTime = day.AddDate(0, 0, 1).Add(2 * time.Hour)
+	require.NoError(t, repo.Update(ctx, moved))
+	require.NoError(t, repo.Delete(ctx, backfilled[1].ID))
+
+	// 断言：两天的聚合行都与明细一致
+	var daily []model.RiskReportUsageDaily
+	require.NoError(t, db.Order("day").Find(&daily).Error)
+	require.Len(t, daily, 2)
+	assert.Equal(t, int64(1), daily[0].TotalQueries)
+	assert.Equal(t, int64(2), daily[1].TotalQueries)
+	assert.Equal(t, int64(3), queries())
+
+	// 覆盖范围之后的写入不产生聚合行
+	later := day.AddDate(0, 0, 3)
+	require.NoError(t, repo.Create(ctx, &model.RiskReportUsage{
+		UserID: "user-1", Ticker: "AAPL", RequestTime: later, ResponseTime: later, AIResponse: "ok",
+	}))
+	coverage, err := repo.DailyCoverage(ctx)
+	require.NoError(t, err)
+	assert.True(t, coverage.Equal(day.AddDate(0, 0, 2)), coverage)
+}
+
+func TestRiskReportUsageRepository_RefreshDaily_Idempotent(t *testing.T) {
+	// 准备
+	db := newTestDB(t)
+	repo := NewRiskReportUsageRepository(db)
+	ctx := context.Background()
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	createTestUsage(t, db, "user-1", day.Add(time.Hour))
+	createTestUsage(t, db, "user-2", day.Add(time.Hour))
+
+	// 执行：同一区间重复刷新
+	for i := 0; i < 2; i++ {
+		rows, err := repo.RefreshDaily(ctx, day, day.AddDate(0, 0, 1))
+		require.NoError(t, err)
+		assert.Equal(t, 2, rows)
+	}
+
+	// 断言：唯一索引下每个 (用户, 日) 只有一行
+	var count int64
+	require.NoError(t, db.Model(&model.RiskReportUsageDaily{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+}
@@ -19,27 +19,49 @@ type RiskReportUsageRepository interface {
 	BatchCreate(ctx context.Context, usages []model.RiskReportUsage) error
 	// GetByID 根据 ID 获取使用记录
 	GetByID(ctx context.Context, id string) (*model.RiskReportUsage, error)
+	// Update 更新使用记录（整条保存）
+	Update(ctx context.Context, usage *model.RiskReportUsage) error
+	// Delete 删除使用记录，记录不存在时返回 ErrResourceNotFound
+	Delete(ctx context.Context, id string) error
 	// List 获取使用记录列表
 	List(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]model.RiskReportUsage, int64, error)
-	// GetStatsByUser 获取用户统计信息
-	GetStatsByUser(ctx context.Context, userID string, startTime, endTime time.Time) (map[string]interface{}, error)
+	// GetStatsByUser 获取用户统计信息，已聚合的整天读日聚合表，其余部分从明细实时统计
+	GetStatsByUser(ctx context.Context, userID string, startTime, endTime time.Time) (*model.UsageStatsResponse, error)
+	// StatsByMarketState 按市场状态分组统计调用次数与平均 token
+	StatsByMarketState(ctx context.Context, userID string, startTime, endTime time.Time) ([]model.MarketStateStats, error)
+	// StatsByActionSuggestion 按操作建议分组统计调用次数
+	StatsByActionSuggestion(ctx context.Context, userID string, startTime, endTime time.Time) ([]model.ActionSuggestionStats, error)
+	// LatencyPercentiles 统计请求延迟的 P50/P95/P99，userID 为空时统计全部用户
+	LatencyPercentiles(ctx context.Context, userID string, startTime, endTime time.Time) (*model.LatencyPercentiles, error)
+	// FindInBatches 按过滤条件分批读取使用记录，每批调用一次 fn
+	FindInBatches(ctx context.Context, filters map[string]interface{}, batchSize int, fn func(batch []model.RiskReportUsage) error) error
+	// DailyCoverage 返回日聚合覆盖范围的结束日（不含），尚未聚合时返回零值
+	DailyCoverage(ctx context.Context) (time.Time, error)
+	// RefreshDaily 从明细重新计算 [from, before) 内各日的聚合，返回写入的聚合行数；from 为零值时从最早的记录开始
+	RefreshDaily(ctx context.Context, from, before time.Time) (int, error)
 }
 
 // riskReportUsageRepository 风险报告使用记录仓储实现
+// ctx 中带事务时（见 tx.go）读写都在该事务中执行
 type riskReportUsageRepository struct {
-	db *gorm.DB
+	db          *gorm.DB
+	percentiles latencyPercentileCalculator
 }
 
 // NewRiskReportUsageRepository 创建风险报告使用记录仓储实例
 func NewRiskReportUsageRepository(db *gorm.DB) RiskReportUsageRepository {
 	return &riskReportUsageRepository{
-		db: db,
+		db:          db,
+		percentiles: newLatencyPercentileCalculator(db),
 	}
 }
 
 // Create 创建使用记录
 func (r *riskReportUsageRepository) Create(ctx context.Context, usage *model.RiskReportUsage) error {
-	if err := r.db.WithContext(ctx).Create(usage).Error; err != nil {
+	err := r.writeUsage(ctx, []usageDayKey{usageDayKeyOf(usage)}, func(db *gorm.DB) error {
+		return db.Create(usage).Error
+	})
+	if err != nil {
 		return errors.Wrap(err, errors.CodeDatabaseError, "创建使用记录失败")
 	}
 	return nil
@@ -51,8 +73,15 @@ func (r *riskReportUsageRepository) BatchCreate(ctx context.Context, usages []mo
 		return nil
 	}
 
+	keys := make([]usageDayKey, len(usages))
+	for i := range usages {
+		keys[i] = usageDayKeyOf(&usages[i])
+	}
 	// 使用批量插入提高性能
-	if err := r.db.WithContext(ctx).CreateInBatches(usages, 100).Error; err != nil {
+	err := r.writeUsage(ctx, keys, func(db *gorm.DB) error {
+		return db.CreateInBatches(usages, 100).Error
+	})
+	if err != nil {
 		return errors.Wrap(err, errors.CodeDatabaseError, "批量创建使用记录失败")
 	}
 	return nil
@@ -61,7 +90,7 @@ func (r *riskReportUsageRepository) BatchCreate(ctx context.Context, usages []mo
 // GetByID 根据 ID 获取使用记录
 func (r *riskReportUsageRepository) GetByID(ctx context.Context, id string) (*model.RiskReportUsage, error) {
 	var usage model.RiskReportUsage
-	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&usage).Error; err != nil {
+	if err := conn(ctx, r.db).Where("id = ?", id).First(&usage).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, errors.ErrResourceNotFound
 		}
@@ -70,27 +99,57 @@ func (r *riskReportUsageRepository) GetByID(ctx context.Context, id string) (*mo
 	return &usage, nil
 }
 
+// Update 更新使用记录
+// 修改前后所属的日聚合行都会重新计算
+func (r *riskReportUsageRepository) Update(ctx context.Context, usage *model.RiskReportUsage) error {
+	keys := []usageDayKey{usageDayKeyOf(usage)}
+	old, err := r.GetByID(ctx, usage.ID)
+	switch {
+	case err == nil:
+		keys = append(keys, usageDayKeyOf(old))
+	case !errors.Is(err, errors.ErrResourceNotFound):
+		return err
+	}
+
+	err = r.writeUsage(ctx, keys, func(db *gorm.DB) error {
+		return db.Save(usage).Error
+	})
+	if err != nil {
+		return errors.Wrap(err, errors.CodeDatabaseError, "更新使用记录失败")
+	}
+	return nil
+}
+
+// Delete 删除使用记录
+func (r *riskReportUsageRepository) Delete(ctx context.Context, id string) error {
+	old, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = r.writeUsage(ctx, []usageDayKey{usageDayKeyOf(old)}, func(db *gorm.DB) error {
+		result := db.Where("id = ?", id).Delete(&model.RiskReportUsage{})
+		if result.Error == nil && result.RowsAffected == 0 {
+			return errors.ErrResourceNotFound
+		}
+		return result.Error
+	})
+	if errors.Is(err, errors.ErrResourceNotFound) {
+		return err
+	}
+	if err != nil {
+		return errors.Wrap(err, errors.CodeDatabaseError, "删除使用记录失败")
+	}
+	return nil
+}
+
 // List 获取使用记录列表
 func (r *riskReportUsageRepository) List(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]model.RiskReportUsage, int64, error) {
 	var usages []model.RiskReportUsage
 	var total int64
 
 	// 构建查询
-	query := r.db.WithContext(ctx).Model(&model.RiskReportUsage{})
-
-	// 应用过滤条件
-	if userID, ok := filters["user_id"].(string); ok && userID != "" {
-		query = query.Where("user_id = ?", userID)
-	}
-	if ticker, ok := filters["ticker"].(string); ok && ticker != "" {
-		query = query.Where("ticker = ?", ticker)
-	}
-	if startTime, ok := filters["start_time"].(time.Time); ok {
-		query = query.Where("request_time >= ?", startTime)
-	}
-	if endTime, ok := filters["end_time"].(time.Time); ok {
-		query = query.Where("request_time <= ?", endTime)
-	}
+	query := applyUsageFilters(conn(ctx, r.db).Model(&model.RiskReportUsage{}), filters)
 
 	// 获取总数
 	if err := query.Count(&total).Error; err != nil {
@@ -99,7 +158,7 @@ func (r *riskReportUsageRepository) List(ctx context.Context, filters map[string
 
 	// 分页查询
 	offset := (page - 1) * pageSize
-	if err := query.Order("request_time DESC").
+	if err := query.Order(stableOrder("request_time", "DESC")).
 		Offset(offset).
 		Limit(pageSize).
 		Find(&usages).Error; err != nil {
@@ -110,25 +169,71 @@ func (r *riskReportUsageRepository) List(ctx context.Context, filters map[string
 }
 
 // GetStatsByUser 获取用户统计信息
-func (r *riskReportUsageRepository) GetStatsByUser(ctx context.Context, userID string, startTime, endTime time.Time) (map[string]interface{}, error) {
-	var result struct {
-		TotalQueries      int64 `gorm:"column:total_queries"`
-		TotalTokens       int64 `gorm:"column:total_tokens"`
-		TotalPromptTokens int64 `gorm:"column:total_prompt_tokens"`
-		TotalCompTokens   int64 `gorm:"column:total_comp_tokens"`
-		AvgResponseTime   int64 `gorm:"column:avg_response_time"`
-	}
-
-	query := r.db.WithContext(ctx).Model(&model.RiskReportUsage{}).
-		Select(`
-			COUNT(*) as total_queries,
-			SUM(total_tokens) as total_tokens,
-			SUM(prompt_tokens) as total_prompt_tokens,
-			SUM(completion_tokens) as total_comp_tokens,
-			AVG(response_duration_ms) as avg_response_time
-		`).
-		Where("user_id = ?", userID)
+// 日聚合覆盖范围内的整天读聚合表（见 risk_report_usage_daily.go），范围两端不足一天的部分
+// 与尚未聚合的日期从明细补齐；只填充汇总字段，延迟百分位需另行通过 LatencyPercentiles 查询
+func (r *riskReportUsageRepository) GetStatsByUser(ctx context.Context, userID string, startTime, endTime time.Time) (*model.UsageStatsResponse, error) {
+	coverage, err := r.DailyCoverage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	from, before, ok := aggregatedDays(startTime, endTime, coverage)
+	if !ok {
+		totals, err := r.detailTotals(ctx, userID, func(q *gorm.DB) *gorm.DB {
+			return requestTimeBetween(q, startTime, endTime)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return totals.toStats(), nil
+	}
+
+	totals, err := r.dailyTotals(ctx, userID, from, before)
+	if err != nil {
+		return nil, err
+	}
+	// 聚合区间之前不足一天的部分
+	if !from.IsZero() && startTime.Before(from) {
+		head, err := r.detailTotals(ctx, userID, func(q *gorm.DB) *gorm.DB {
+			return q.Where("request_time >= ? AND request_time < ?", startTime, from)
+		})
+		if err != nil {
+			return nil, err
+		}
+		totals.add(head)
+	}
+	// 聚合区间之后的部分，包括当天
+	tail, err := r.detailTotals(ctx, userID, func(q *gorm.DB) *gorm.DB {
+		return requestTimeBetween(q.Where("request_time >= ?", before), time.Time{}, endTime)
+	})
+	if err != nil {
+		return nil, err
+	}
+	totals.add(tail)
+	return totals.toStats(), nil
+}
+
+// requestTimeBetween 追加 request_time 的闭区间条件，零值表示不限
+func requestTimeBetween(query *gorm.DB, startTime, endTime time.Time) *gorm.DB {
+	if !startTime.IsZero() {
+		query = query.Where("request_time >= ?", startTime)
+	}
+	if !endTime.IsZero() {
+		query = query.Where("request_time <= ?", endTime)
+	}
+	return query
+}
+
+// StatsByMarketState 按市场状态分组统计
+// market_state 为空的记录归为 UNKNOWN；userID 为空时统计全部用户
+func (r *riskReportUsageRepository) StatsByMarketState(ctx context.Context, userID string, startTime, endTime time.Time) ([]model.MarketStateStats, error) {
+	stateExpr := "COALESCE(NULLIF(market_state, ''), '" + model.MarketStateUnknown + "')"
+
+	query := conn(ctx, r.db).Model(&model.RiskReportUsage{}).
+		Select(stateExpr + " AS market_state, COUNT(*) AS count, AVG(total_tokens) AS avg_tokens")
 
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
 	if !startTime.IsZero() {
 		query = query.Where("request_time >= ?", startTime)
 	}
@@ -136,17 +241,96 @@ func (r *riskReportUsageRepository) GetStatsByUser(ctx context.Context, userID s
 		query = query.Where("request_time <= ?", endTime)
 	}
 
-	if err := query.Scan(&result).Error; err != nil {
-		return nil, errors.Wrap(err, errors.CodeDatabaseError, "获取统计信息失败")
+	var stats []model.MarketStateStats
+	if err := query.Group(stateExpr).Order("market_state").Scan(&stats).Error; err != nil {
+		return nil, errors.Wrap(err, errors.CodeDatabaseError, "按市场状态统计失败")
 	}
+	return stats, nil
+}
+
+// StatsByActionSuggestion 按操作建议分组统计
+// action_suggestion 为空的记录归为 UNKNOWN；userID 为空时统计全部用户；结果按次数降序排列
+// 只填充 Count，Ratio 由服务层计算
+func (r *riskReportUsageRepository) StatsByActionSuggestion(ctx context.Context, userID string, startTime, endTime time.Time) ([]model.ActionSuggestionStats, error) {
+	suggestionExpr := "COALESCE(NULLIF(action_suggestion, ''), '" + model.ActionSuggestionUnknown + "')"
+
+	query := conn(ctx, r.db).Model(&model.RiskReportUsage{}).
+		Select(suggestionExpr + " AS action_suggestion, COUNT(*) AS count")
 
-	stats := map[string]interface{}{
-		"total_queries":           result.TotalQueries,
-		"total_tokens":            result.TotalTokens,
-		"total_prompt_tokens":     result.TotalPromptTokens,
-		"total_completion_tokens": result.TotalCompTokens,
-		"avg_response_time_ms":    result.AvgResponseTime,
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if !startTime.IsZero() {
+		query = query.Where("request_time >= ?", startTime)
+	}
+	if !endTime.IsZero() {
+		query = query.Where("request_time <= ?", endTime)
 	}
 
+	var stats []model.ActionSuggestionStats
+	if err := query.Group(suggestionExpr).Order("count DESC, action_suggestion").Scan(&stats).Error; err != nil {
+		return nil, errors.Wrap(err, errors.CodeDatabaseError, "按操作建议统计失败")
+	}
 	return stats, nil
 }
+
+// LatencyPercentiles 统计请求延迟百分位
+// 未记录 response_duration_ms 的请求不参与统计
+func (r *riskReportUsageRepository) LatencyPercentiles(ctx context.Context, userID string, startTime, endTime time.Time) (*model.LatencyPercentiles, error) {
+	query := conn(ctx, r.db).Model(&model.RiskReportUsage{}).
+		Where("response_duration_ms IS NOT NULL")
+
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if !startTime.IsZero() {
+		query = query.Where("request_time >= ?", startTime)
+	}
+	if !endTime.IsZero() {
+		query = query.Where("request_time <= ?", endTime)
+	}
+
+	result, err := r.percentiles.Calculate(query)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.CodeDatabaseError, "统计请求延迟百分位失败")
+	}
+	return result, nil
+}
+
+// FindInBatches 按过滤条件分批读取使用记录
+// 每次只在内存中保留一批数据，适合导出等大结果集场景
+func (r *riskReportUsageRepository) FindInBatches(ctx context.Context, filters map[string]interface{}, batchSize int, fn func(batch []model.RiskReportUsage) error) error {
+	var batch []model.RiskReportUsage
+	query := applyUsageFilters(conn(ctx, r.db).Model(&model.RiskReportUsage{}), filters)
+
+	// 区分回调返回的错误与数据库错误，回调错误原样返回
+	var fnErr error
+	result := query.FindInBatches(&batch, batchSize, func(_ *gorm.DB, _ int) error {
+		fnErr = fn(batch)
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if result.Error != nil {
+		return errors.Wrap(result.Error, errors.CodeDatabaseError, "分批查询使用记录失败")
+	}
+	return nil
+}
+
+// applyUsageFilters 应用使用记录的通用过滤条件
+func applyUsageFilters(query *gorm.DB, filters map[string]interface{}) *gorm.DB {
+	if userID, ok := filters["user_id"].(string); ok && userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if ticker, ok := filters["ticker"].(string); ok && ticker != "" {
+		query = query.Where("ticker = ?", ticker)
+	}
+	if startTime, ok := filters["start_time"].(time.Time); ok {
+		query = query.Where("request_time >= ?", startTime)
+	}
+	if endTime, ok := filters["end_time"].(time.Time); ok {
+		query = query.Where("request_time <= ?", endTime)
+	}
+	return query
+}
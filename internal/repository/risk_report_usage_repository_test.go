@@ -0,0 +1,232 @@
+// Package repository 提供数据访问层的实现
+//
+// 本文件包含风险报告使用记录仓储的单元测试，使用内存 SQLite 数据库
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// createTestUsage 在测试数据库中创建使用记录
+func createTestUsage(t *testing.T, db *gorm.DB, userID string, requestTime time.Time) {
+	t.Helper()
+
+	usage := &model.RiskReportUsage{
+		UserID:           userID,
+		Ticker:           "AAPL",
+		RequestTime:      requestTime,
+		ResponseTime:     requestTime.Add(time.Second),
+		PromptTokens:     100,
+		CompletionTokens: 50,
+		TotalTokens:      150,
+		AIResponse:       "ok",
+	}
+	require.NoError(t, db.Create(usage).Error)
+}
+
+func TestRiskReportUsageRepository_FindInBatches_Filters(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewRiskReportUsageRepository(db)
+	ctx := context.Background()
+
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	// user-1 在时间范围内 5 条，范围外 2 条；user-2 在范围内 3 条
+	for i := 0; i < 5; i++ {
+		createTestUsage(t, db, "user-1", base.Add(time.Duration(i)*time.Hour))
+	}
+	createTestUsage(t, db, "user-1", base.Add(-48*time.Hour))
+	createTestUsage(t, db, "user-1", base.Add(48*time.Hour))
+	for i := 0; i < 3; i++ {
+		createTestUsage(t, db, "user-2", base.Add(time.Duration(i)*time.Hour))
+	}
+
+	filters := map[string]interface{}{
+		"user_id":    "user-1",
+		"start_time": base,
+		"end_time":   base.Add(24 * time.Hour),
+	}
+
+	var batches, rows int
+	err := repo.FindInBatches(ctx, filters, 2, func(batch []model.RiskReportUsage) error {
+		batches++
+		for _, u := range batch {
+			assert.Equal(t, "user-1", u.UserID)
+		}
+		rows += len(batch)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, rows)
+	assert.Equal(t, 3, batches)
+}
+
+func TestRiskReportUsageRepository_GetStatsByUser(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewRiskReportUsageRepository(db)
+	ctx := context.Background()
+
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	create := func(userID string, durationMs int) {
+		usage := &model.RiskReportUsage{
+			UserID:             userID,
+			Ticker:             "AAPL",
+			RequestTime:        base,
+			ResponseTime:       base.Add(time.Second),
+			PromptTokens:       100,
+			CompletionTokens:   50,
+			TotalTokens:        150,
+			ResponseDurationMs: &durationMs,
+			AIResponse:         "ok",
+		}
+		require.NoError(t, db.Create(usage).Error)
+	}
+	create("user-1", 800)
+	create("user-1", 1200)
+	create("user-2", 5000) // 其他用户，不应计入
+
+	stats, err := repo.GetStatsByUser(ctx, "user-1", time.Time{}, time.Time{})
+	require.NoError(t, err)
+
+	assert.Equal(t, &model.UsageStatsResponse{
+		TotalQueries:          2,
+		TotalTokens:           300,
+		TotalPromptTokens:     200,
+		TotalCompletionTokens: 100,
+		AvgResponseTimeMs:     1000,
+	}, stats)
+}
+
+func TestRiskReportUsageRepository_StatsByMarketState(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewRiskReportUsageRepository(db)
+	ctx := context.Background()
+
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	create := func(userID, state string, totalTokens int) {
+		usage := &model.RiskReportUsage{
+			UserID:           userID,
+			Ticker:           "AAPL",
+			RequestTime:      base,
+			ResponseTime:     base.Add(time.Second),
+			PromptTokens:     totalTokens,
+			CompletionTokens: 0,
+			TotalTokens:      totalTokens,
+			AIResponse:       "ok",
+			MarketState:      state,
+		}
+		require.NoError(t, db.Create(usage).Error)
+	}
+
+	create("user-1", model.MarketStatePRE, 100)
+	create("user-1", model.MarketStatePRE, 300)
+	create("user-1", model.MarketStateREGULAR, 200)
+	create("user-1", "", 50)
+	create("user-1", "", 150)
+	create("user-2", model.MarketStatePOST, 999) // 其他用户，不应计入
+
+	stats, err := repo.StatsByMarketState(ctx, "user-1", time.Time{}, time.Time{})
+	require.NoError(t, err)
+
+	byState := make(map[string]model.MarketStateStats)
+	for _, s := range stats {
+		byState[s.MarketState] = s
+	}
+	require.Len(t, byState, 3)
+	assert.Equal(t, int64(2), byState[model.MarketStatePRE].Count)
+	assert.InDelta(t, 200, byState[model.MarketStatePRE].AvgTokens, 0.001)
+	assert.Equal(t, int64(1), byState[model.MarketStateREGULAR].Count)
+	assert.InDelta(t, 200, byState[model.MarketStateREGULAR].AvgTokens, 0.001)
+	assert.Equal(t, int64(2), byState[model.MarketStateUnknown].Count)
+	assert.InDelta(t, 100, byState[model.MarketStateUnknown].AvgTokens, 0.001)
+	assert.NotContains(t, byState, model.MarketStatePOST)
+}
+
+func TestRiskReportUsageRepository_StatsByActionSuggestion(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewRiskReportUsageRepository(db)
+	ctx := context.Background()
+
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	create := func(userID, suggestion string, requestTime time.Time) {
+		usage := &model.RiskReportUsage{
+			UserID:           userID,
+			Ticker:           "AAPL",
+			RequestTime:      requestTime,
+			ResponseTime:     requestTime.Add(time.Second),
+			PromptTokens:     100,
+			TotalTokens:      100,
+			AIResponse:       "ok",
+			ActionSuggestion: suggestion,
+		}
+		require.NoError(t, db.Create(usage).Error)
+	}
+
+	create("user-1", "买入", base)
+	create("user-1", "持有", base)
+	create("user-1", "持有", base)
+	create("user-1", "持有", base)
+	create("user-1", "", base)
+	create("user-1", "卖出", base.Add(-48*time.Hour)) // 早于统计范围，不应计入
+	create("user-2", "卖出", base)                    // 其他用户，不应计入
+
+	stats, err := repo.StatsByActionSuggestion(ctx, "user-1", base.Add(-time.Hour), base.Add(time.Hour))
+	require.NoError(t, err)
+
+	// 按次数降序，次数相同时按建议名称排序
+	assert.Equal(t, []model.ActionSuggestionStats{
+		{ActionSuggestion: "持有", Count: 3},
+		{ActionSuggestion: model.ActionSuggestionUnknown, Count: 1},
+		{ActionSuggestion: "买入", Count: 1},
+	}, stats)
+
+	// 不指定用户时统计全部用户
+	all, err := repo.StatsByActionSuggestion(ctx, "", base.Add(-time.Hour), base.Add(time.Hour))
+	require.NoError(t, err)
+	var total int64
+	for _, s := range all {
+		total += s.Count
+	}
+	assert.Equal(t, int64(6), total)
+}
+
+func TestRiskReportUsageRepository_UpdateAndDelete(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewRiskReportUsageRepository(db)
+	ctx := context.Background()
+
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	createTestUsage(t, db, "user-1", base)
+
+	var usage model.RiskReportUsage
+	require.NoError(t, db.First(&usage).Error)
+
+	// 更新后字段变化
+	usage.Ticker = "MSFT"
+	usage.PromptTokens = 200
+	usage.TotalTokens = 250
+	require.NoError(t, repo.Update(ctx, &usage))
+
+	updated, err := repo.GetByID(ctx, usage.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "MSFT", updated.Ticker)
+	assert.Equal(t, 200, updated.PromptTokens)
+	assert.Equal(t, 250, updated.TotalTokens)
+	assert.Equal(t, "user-1", updated.UserID)
+
+	// 删除后查不到
+	require.NoError(t, repo.Delete(ctx, usage.ID))
+	_, err = repo.GetByID(ctx, usage.ID)
+	assert.ErrorIs(t, err, errors.ErrResourceNotFound)
+
+	// 重复删除返回不存在
+	assert.ErrorIs(t, repo.Delete(ctx, usage.ID), errors.ErrResourceNotFound)
+}
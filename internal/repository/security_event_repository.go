@@ -0,0 +1,51 @@
+// Package repository 提供数据访问层的实现
+package repository
+
+import (
+	"context"
+
+	"github.com/example/go-user-api/internal/model"
+	apperrors "github.com/example/go-user-api/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// SecurityEventRepository 安全事件仓储接口
+type SecurityEventRepository interface {
+	// Create 记录一条安全事件
+	Create(ctx context.Context, event *model.SecurityEvent) error
+	// ListRecentByUser 获取用户最近的安全事件，按时间倒序；limit <= 0 时返回全部
+	ListRecentByUser(ctx context.Context, userID string, limit int) ([]model.SecurityEvent, error)
+}
+
+// securityEventRepository 安全事件仓储实现
+type securityEventRepository struct {
+	db *gorm.DB
+}
+
+// NewSecurityEventRepository 创建安全事件仓储实例
+func NewSecurityEventRepository(db *gorm.DB) SecurityEventRepository {
+	return &securityEventRepository{db: db}
+}
+
+// Create 记录一条安全事件
+func (r *securityEventRepository) Create(ctx context.Context, event *model.SecurityEvent) error {
+	if err := r.db.WithContext(ctx).Create(event).Error; err != nil {
+		return apperrors.ErrDatabaseError.WithError(err)
+	}
+	return nil
+}
+
+// ListRecentByUser 获取用户最近的安全事件，按时间倒序；limit <= 0 时返回全部
+func (r *securityEventRepository) ListRecentByUser(ctx context.Context, userID string, limit int) ([]model.SecurityEvent, error) {
+	var events []model.SecurityEvent
+	query := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order(stableOrder("created_at", "desc"))
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&events).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError.WithError(err)
+	}
+	return events, nil
+}
@@ -0,0 +1,71 @@
+// Package repository 提供数据访问层的实现
+//
+// 本文件实现了基于 context 的事务传播。
+// 事务 DB 存放在 context 中，仓储通过 conn 取得当前连接，
+// 使多个仓储、多个服务调用可以在同一事务中提交或回滚：
+//
+//	err := txManager.WithinTx(ctx, func(ctx context.Context) error {
+//	    if _, err := usageService.BatchCreate(ctx, req); err != nil {
+//	        return err
+//	    }
+//	    return quotaRepo.Update(ctx, ...)
+//	})
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// txContextKey 事务 DB 在 context 中的键
+type txContextKey struct{}
+
+// TxManager 事务管理器
+type TxManager interface {
+	// WithinTx 在事务中执行 fn，fn 返回错误时回滚，否则提交
+	// ctx 中已有事务时直接加入外层事务，由最外层统一提交或回滚
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// gormTxManager 基于 GORM 的事务管理器
+type gormTxManager struct {
+	db *gorm.DB
+}
+
+// NewTxManager 创建事务管理器
+func NewTxManager(db *gorm.DB) TxManager {
+	return &gormTxManager{db: db}
+}
+
+// WithinTx 在事务中执行 fn
+func (m *gormTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(ContextWithTx(ctx, tx))
+	})
+}
+
+// ContextWithTx 返回携带事务 DB 的上下文
+func ContextWithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// TxFromContext 从上下文中获取事务 DB
+func TxFromContext(ctx context.Context) (*gorm.DB, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	tx, ok := ctx.Value(txContextKey{}).(*gorm.DB)
+	return tx, ok && tx != nil
+}
+
+// conn 返回当前请求应使用的连接：ctx 中有事务时使用事务，否则使用 db
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
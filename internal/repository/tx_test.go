@@ -0,0 +1,83 @@
+// Package repository 提供数据访问层的实现
+//
+// 本文件包含事务管理器的单元测试，使用内存 SQLite 数据库
+package repository
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countUsages 统计使用记录条数
+func countUsages(t *testing.T, repo RiskReportUsageRepository) int64 {
+	t.Helper()
+	_, total, err := repo.List(context.Background(), nil, 1, 1)
+	require.NoError(t, err)
+	return total
+}
+
+// newTxTestUsage 创建一条待写入的使用记录
+func newTxTestUsage(userID string) *model.RiskReportUsage {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	return &model.RiskReportUsage{
+		UserID:       userID,
+		Ticker:       "AAPL",
+		RequestTime:  now,
+		ResponseTime: now.Add(time.Second),
+		TotalTokens:  100,
+		AIResponse:   "ok",
+	}
+}
+
+func TestTxManager_WithinTx_CommitAndRollback(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewRiskReportUsageRepository(db)
+	txm := NewTxManager(db)
+	ctx := context.Background()
+
+	// 成功时提交
+	err := txm.WithinTx(ctx, func(ctx context.Context) error {
+		return repo.Create(ctx, newTxTestUsage("user-1"))
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), countUsages(t, repo))
+
+	// 返回错误时回滚，错误原样返回
+	failure := stderrors.New("boom")
+	err = txm.WithinTx(ctx, func(ctx context.Context) error {
+		if err := repo.Create(ctx, newTxTestUsage("user-2")); err != nil {
+			return err
+		}
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+	assert.Equal(t, int64(1), countUsages(t, repo))
+}
+
+func TestTxManager_WithinTx_JoinsOuterTransaction(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewRiskReportUsageRepository(db)
+	txm := NewTxManager(db)
+	ctx := context.Background()
+
+	// 内层成功、外层失败：内层写入随外层一起回滚
+	err := txm.WithinTx(ctx, func(outer context.Context) error {
+		outerTx, _ := TxFromContext(outer)
+		innerErr := txm.WithinTx(outer, func(inner context.Context) error {
+			innerTx, ok := TxFromContext(inner)
+			require.True(t, ok)
+			assert.Same(t, outerTx, innerTx)
+			return repo.Create(inner, newTxTestUsage("user-1"))
+		})
+		require.NoError(t, innerErr)
+		return stderrors.New("outer failed")
+	})
+	require.Error(t, err)
+	assert.Equal(t, int64(0), countUsages(t, repo))
+}
@@ -0,0 +1,58 @@
+// Package repository 提供数据访问层的实现
+package repository
+
+import (
+	"context"
+
+	"github.com/example/go-user-api/internal/model"
+	apperrors "github.com/example/go-user-api/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// UserChangeLogRepository 用户变更记录仓储接口
+type UserChangeLogRepository interface {
+	// CreateBatch 批量写入变更记录
+	CreateBatch(ctx context.Context, logs []model.UserChangeLog) error
+	// ListByUser 分页获取用户的变更记录，按时间倒序
+	ListByUser(ctx context.Context, userID string, page, pageSize int) ([]model.UserChangeLog, int64, error)
+}
+
+// userChangeLogRepository 用户变更记录仓储实现
+type userChangeLogRepository struct {
+	db *gorm.DB
+}
+
+// NewUserChangeLogRepository 创建用户变更记录仓储实例
+func NewUserChangeLogRepository(db *gorm.DB) UserChangeLogRepository {
+	return &userChangeLogRepository{db: db}
+}
+
+// CreateBatch 批量写入变更记录
+func (r *userChangeLogRepository) CreateBatch(ctx context.Context, logs []model.UserChangeLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Create(&logs).Error; err != nil {
+		return apperrors.ErrDatabaseError.WithError(err)
+	}
+	return nil
+}
+
+// ListByUser 分页获取用户的变更记录，按时间倒序
+func (r *userChangeLogRepository) ListByUser(ctx context.Context, userID string, page, pageSize int) ([]model.UserChangeLog, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.UserChangeLog{}).Where("user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, apperrors.ErrDatabaseError.WithError(err)
+	}
+
+	var logs []model.UserChangeLog
+	if err := query.Order(stableOrder("created_at", "desc")).
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&logs).Error; err != nil {
+		return nil, 0, apperrors.ErrDatabaseError.WithError(err)
+	}
+	return logs, total, nil
+}
@@ -0,0 +1,52 @@
+// Package repository 提供数据访问层的实现
+//
+// 本文件包含用户变更记录仓储的单元测试，使用内存 SQLite 数据库
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserChangeLogRepository_CreateBatchAndList(t *testing.T) {
+	// 准备
+	db := newTestDB(t)
+	repo := NewUserChangeLogRepository(db)
+	ctx := context.Background()
+	user := createTestUser(t, db, "changelog")
+	other := createTestUser(t, db, "otheruser")
+
+	base := time.Now().Add(-time.Hour)
+	logs := []model.UserChangeLog{
+		{UserID: user.ID, Field: "email", OldValue: "a@example.com", NewValue: "b@example.com", ChangedBy: "admin"},
+		{UserID: user.ID, Field: "role", OldValue: model.RoleUser, NewValue: model.RoleAdmin, ChangedBy: "admin"},
+		{UserID: other.ID, Field: "status", OldValue: "1", NewValue: "0", ChangedBy: "admin"},
+	}
+	for i := range logs {
+		logs[i].CreatedAt = base.Add(time.Duration(i) * time.Minute)
+	}
+
+	// 执行
+	require.NoError(t, repo.CreateBatch(ctx, logs))
+	got, total, err := repo.ListByUser(ctx, user.ID, 1, 10)
+
+	// 断言：只返回该用户的记录，按时间倒序
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, got, 2)
+	assert.Equal(t, "role", got[0].Field)
+	assert.Equal(t, "email", got[1].Field)
+	assert.Equal(t, "b@example.com", got[1].NewValue)
+
+	// 分页
+	got, total, err = repo.ListByUser(ctx, user.ID, 2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, got, 1)
+	assert.Equal(t, "email", got[0].Field)
+}
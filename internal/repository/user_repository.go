@@ -13,6 +13,7 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/example/go-user-api/internal/model"
 	apperrors "github.com/example/go-user-api/pkg/errors"
@@ -26,6 +27,8 @@ type UserRepository interface {
 	Create(ctx context.Context, user *model.User) error
 	// GetByID 根据 ID 获取用户
 	GetByID(ctx context.Context, id string) (*model.User, error)
+	// GetByIDs 根据 ID 列表批量获取用户，不存在的 ID 直接忽略
+	GetByIDs(ctx context.Context, ids []string) ([]model.User, error)
 	// GetByUsername 根据用户名获取用户
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
 	// GetByEmail 根据邮箱获取用户
@@ -36,12 +39,21 @@ type UserRepository interface {
 	Update(ctx context.Context, user *model.User) error
 	// UpdateFields 更新指定字段
 	UpdateFields(ctx context.Context, id string, fields map[string]interface{}) error
+	// UpdateRoleBatch 在同一事务中将一批用户设为指定角色并递增其令牌版本，任一用户不存在时全部不修改
+	UpdateRoleBatch(ctx context.Context, ids []string, role string) (int64, error)
 	// Delete 删除用户（软删除）
 	Delete(ctx context.Context, id string) error
-	// HardDelete 永久删除用户
-	HardDelete(ctx context.Context, id string) error
+	// HardDelete 永久删除用户，同时删除个人数据并匿名化审计记录，返回被删除的用户
+	HardDelete(ctx context.Context, id string) (*model.User, error)
+	// PurgeDeletedBefore 永久删除 deleted_at 早于 before 的软删除用户及其关联数据，返回被删除的用户
+	PurgeDeletedBefore(ctx context.Context, before time.Time) ([]model.User, error)
 	// List 获取用户列表
 	List(ctx context.Context, opts *UserListOptions) ([]model.User, int64, error)
+	// ExplainList 返回用户列表查询的执行计划，用于排查慢查询
+	ExplainList(ctx context.Context, opts *UserListOptions) (*model.QueryPlan, error)
+	// FindInBatches 按过滤条件分批读取用户，每批调用一次 fn
+	// 只使用 opts 中的过滤条件，分页与预加载参数被忽略
+	FindInBatches(ctx context.Context, opts *UserListOptions, batchSize int, fn func(batch []model.User) error) error
 	// ExistsByUsername 检查用户名是否存在
 	ExistsByUsername(ctx context.Context, username string) (bool, error)
 	// ExistsByEmail 检查邮箱是否存在
@@ -52,6 +64,12 @@ type UserRepository interface {
 	UpdatePassword(ctx context.Context, id string, hashedPassword string) error
 	// UpdateLastLogin 更新最后登录信息
 	UpdateLastLogin(ctx context.Context, id string, ip string) error
+	// UpdateLastActive 更新最近活跃时间
+	UpdateLastActive(ctx context.Context, id string, at time.Time) error
+	// IncrementTokenVersion 递增用户的令牌版本，使其已签发的令牌全部失效
+	IncrementTokenVersion(ctx context.Context, id string) error
+	// IncrementAllTokenVersions 递增所有用户的令牌版本，返回影响的用户数
+	IncrementAllTokenVersions(ctx context.Context) (int64, error)
 }
 
 // UserListOptions 用户列表查询选项
@@ -68,36 +86,72 @@ type UserListOptions struct {
 	Status *int8
 	// Role 角色过滤
 	Role string
+	// Source 注册来源过滤（精确匹配，需已规范化）
+	Source string
+	// LastLoginBefore 最后登录早于该时间；从未登录的用户在注册时间早于该时间时同样匹配
+	LastLoginBefore *time.Time
+	// LastLoginAfter 最后登录不早于该时间；从未登录的用户不匹配
+	LastLoginAfter *time.Time
 	// SortBy 排序字段
 	SortBy string
 	// SortOrder 排序方向: asc, desc
 	SortOrder string
+	// Preloads 需要预加载的关联，仅允许 allowedUserPreloads 中的名称
+	Preloads []string
+	// SkipTotal 跳过 COUNT 查询，total 返回 TotalUnknown
+	// 用于无限滚动等不需要精确总数的场景，避免大表上的全表计数
+	SkipTotal bool
+}
+
+// TotalUnknown 跳过计数时返回的 total
+const TotalUnknown int64 = -1
+
+// allowedUserPreloads 用户列表允许预加载的关联白名单
+var allowedUserPreloads = map[string]bool{
+	model.PreloadTags: true,
 }
 
 // userRepository 用户仓储实现
 type userRepository struct {
 	db *gorm.DB
+	// defaultSortBy、defaultSortOrder 未指定排序时使用的字段与方向
+	defaultSortBy    string
+	defaultSortOrder string
+}
+
+// UserRepositoryOption 用户仓储的可选配置
+type UserRepositoryOption func(*userRepository)
+
+// WithDefaultUserSort 设置列表未指定排序时的默认字段与方向
+// 字段不在排序白名单内时忽略，方向只接受 asc、desc
+func WithDefaultUserSort(sortBy, order string) UserRepositoryOption {
+	return func(r *userRepository) {
+		if allowedUserSortFields[sortBy] {
+			r.defaultSortBy = sortBy
+		}
+		if order == "asc" || order == "desc" {
+			r.defaultSortOrder = order
+		}
+	}
 }
 
 // NewUserRepository 创建用户仓储实例
-// 参数 db 是 GORM 数据库连接
-func NewUserRepository(db *gorm.DB) UserRepository {
-	return &userRepository{db: db}
+// 参数 db 是 GORM 数据库连接；默认按创建时间降序排列列表
+func NewUserRepository(db *gorm.DB, opts ...UserRepositoryOption) UserRepository {
+	r := &userRepository{db: db, defaultSortBy: "created_at", defaultSortOrder: "desc"}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // Create 创建用户
 // 如果用户名或邮箱已存在，返回相应的错误
 func (r *userRepository) Create(ctx context.Context, user *model.User) error {
 	if err := r.db.WithContext(ctx).Create(user).Error; err != nil {
-		// 检查是否是唯一约束冲突
+		// 唯一索引是用户名/邮箱唯一性的最终保证，并发注册时由这里给出明确错误
 		if isDuplicateKeyError(err) {
-			if strings.Contains(err.Error(), "username") {
-				return apperrors.ErrUsernameExists
-			}
-			if strings.Contains(err.Error(), "email") {
-				return apperrors.ErrEmailAlreadyUsed
-			}
-			return apperrors.ErrDuplicateEntry.WithError(err)
+			return userDuplicateError(err)
 		}
 		return apperrors.ErrDatabaseError.WithError(err)
 	}
@@ -116,6 +170,20 @@ func (r *userRepository) GetByID(ctx context.Context, id string) (*model.User, e
 	return &user, nil
 }
 
+// GetByIDs 根据 ID 列表批量获取用户
+// 返回顺序不保证与 ids 一致，不存在的 ID 直接忽略
+func (r *userRepository) GetByIDs(ctx context.Context, ids []string) ([]model.User, error) {
+	if len(ids) == 0 {
+		return []model.User{}, nil
+	}
+
+	var users []model.User
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError.WithError(err)
+	}
+	return users, nil
+}
+
 // GetByUsername 根据用户名获取用户
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	var user model.User
@@ -142,11 +210,17 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.U
 
 // GetByUsernameOrEmail 根据用户名或邮箱获取用户
 // 用于登录时同时支持用户名和邮箱登录
+// 输入不是邮箱格式时只按用户名查询，避免与未填写邮箱的账号误匹配
 func (r *userRepository) GetByUsernameOrEmail(ctx context.Context, usernameOrEmail string) (*model.User, error) {
+	query := r.db.WithContext(ctx)
+	if strings.Contains(usernameOrEmail, "@") {
+		query = query.Where("username = ? OR email = ?", usernameOrEmail, usernameOrEmail)
+	} else {
+		query = query.Where("username = ?", usernameOrEmail)
+	}
+
 	var user model.User
-	if err := r.db.WithContext(ctx).
-		Where("username = ? OR email = ?", usernameOrEmail, usernameOrEmail).
-		First(&user).Error; err != nil {
+	if err := query.First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, apperrors.ErrUserNotFound
 		}
@@ -161,13 +235,7 @@ func (r *userRepository) Update(ctx context.Context, user *model.User) error {
 	result := r.db.WithContext(ctx).Save(user)
 	if result.Error != nil {
 		if isDuplicateKeyError(result.Error) {
-			if strings.Contains(result.Error.Error(), "username") {
-				return apperrors.ErrUsernameExists
-			}
-			if strings.Contains(result.Error.Error(), "email") {
-				return apperrors.ErrEmailAlreadyUsed
-			}
-			return apperrors.ErrDuplicateEntry.WithError(result.Error)
+			return userDuplicateError(result.Error)
 		}
 		return apperrors.ErrDatabaseError.WithError(result.Error)
 	}
@@ -180,7 +248,7 @@ func (r *userRepository) UpdateFields(ctx context.Context, id string, fields map
 	result := r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Updates(fields)
 	if result.Error != nil {
 		if isDuplicateKeyError(result.Error) {
-			return apperrors.ErrDuplicateEntry.WithError(result.Error)
+			return userDuplicateError(result.Error)
 		}
 		return apperrors.ErrDatabaseError.WithError(result.Error)
 	}
@@ -190,6 +258,38 @@ func (r *userRepository) UpdateFields(ctx context.Context, id string, fields map
 	return nil
 }
 
+// UpdateRoleBatch 批量更新用户角色
+// 先在事务中确认所有 ID 都存在，再一次性更新；返回角色实际发生变化的用户数。
+// 角色变化的用户在同一语句中递增令牌版本，携带旧角色的令牌随之失效
+func (r *userRepository) UpdateRoleBatch(ctx context.Context, ids []string, role string) (int64, error) {
+	var updated int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.User{}).Where("id IN ?", ids).Count(&count).Error; err != nil {
+			return apperrors.ErrDatabaseError.WithError(err)
+		}
+		if count != int64(len(ids)) {
+			return apperrors.ErrUserNotFound
+		}
+
+		result := tx.Model(&model.User{}).
+			Where("id IN ? AND role <> ?", ids, role).
+			Updates(map[string]interface{}{
+				"role":          role,
+				"token_version": gorm.Expr("token_version + 1"),
+			})
+		if result.Error != nil {
+			return apperrors.ErrDatabaseError.WithError(result.Error)
+		}
+		updated = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
+
 // Delete 删除用户（软删除）
 // 只设置 deleted_at 字段，数据仍保留在数据库中
 func (r *userRepository) Delete(ctx context.Context, id string) error {
@@ -204,81 +304,263 @@ func (r *userRepository) Delete(ctx context.Context, id string) error {
 }
 
 // HardDelete 永久删除用户
-// 从数据库中彻底删除记录
-func (r *userRepository) HardDelete(ctx context.Context, id string) error {
-	result := r.db.WithContext(ctx).Unscoped().Where("id = ?", id).Delete(&model.User{})
-	if result.Error != nil {
-		return apperrors.ErrDatabaseError.WithError(result.Error)
+// 无论是否已软删除，都从数据库中彻底删除记录。同一事务中删除会话、标签、登录历史、
+// 密码历史等个人数据；安全事件与变更记录作为审计保留，但抹去其中的 IP、位置与邮箱。
+// 返回删除前的用户记录，调用方据此清理头像等数据库之外的文件
+func (r *userRepository) HardDelete(ctx context.Context, id string) (*model.User, error) {
+	var user model.User
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("id = ?", id).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperrors.ErrUserNotFound
+			}
+			return apperrors.ErrDatabaseError.WithError(err)
+		}
+
+		// 与 PurgeDeletedBefore 相同，先处理子表再删除用户，开启外键约束时才不会被拒绝
+		for _, table := range hardDeleteErasedTables {
+			if err := tx.Where("user_id = ?", id).Delete(table).Error; err != nil {
+				return apperrors.ErrDatabaseError.WithError(err)
+			}
+		}
+		if err := anonymizeUserAudit(tx, id); err != nil {
+			return err
+		}
+
+		// 用户不存在时回滚事务
+		result := tx.Unscoped().Where("id = ?", id).Delete(&model.User{})
+		if result.Error != nil {
+			return apperrors.ErrDatabaseError.WithError(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.ErrUserNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	if result.RowsAffected == 0 {
-		return apperrors.ErrUserNotFound
+	return &user, nil
+}
+
+// hardDeleteErasedTables 硬删除用户时一并删除的个人数据
+var hardDeleteErasedTables = []interface{}{
+	&model.UserTag{},
+	&model.LoginHistory{},
+	&model.RefreshToken{},
+	&model.PasswordHistory{},
+	&model.PersonalAccessToken{},
+}
+
+// redactedValue 匿名化后的字段值
+const redactedValue = "[redacted]"
+
+// anonymizeUserAudit 匿名化用户的审计记录
+// 保留事件类型与时间，清除可识别个人身份的 IP、地理位置与邮箱
+func anonymizeUserAudit(tx *gorm.DB, userID string) error {
+	if err := tx.Model(&model.SecurityEvent{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+		"ip":                "",
+		"previous_ip":       "",
+		"location":          "",
+		"previous_location": "",
+	}).Error; err != nil {
+		return apperrors.ErrDatabaseError.WithError(err)
+	}
+	if err := tx.Model(&model.UserChangeLog{}).Where("user_id = ? AND field = ?", userID, "email").Updates(map[string]interface{}{
+		"old_value": redactedValue,
+		"new_value": redactedValue,
+	}).Error; err != nil {
+		return apperrors.ErrDatabaseError.WithError(err)
 	}
 	return nil
 }
 
+// userOwnedTables 以 user_id 关联用户的个人数据，清理软删除用户时一并删除
+// 风险报告使用记录用于计费对账、用户变更记录作为审计，均不在此列
+var userOwnedTables = []interface{}{
+	&model.UserTag{},
+	&model.LoginHistory{},
+	&model.RefreshToken{},
+	&model.SecurityEvent{},
+	&model.PasswordHistory{},
+	&model.PersonalAccessToken{},
+}
+
+// PurgeDeletedBefore 永久删除 deleted_at 早于 before 的软删除用户
+// 在同一事务中删除这些用户的标签、登录历史、刷新令牌等关联数据。
+// 返回被删除的用户（只含 ID 与头像字段），调用方据此清理头像文件；
+// 清理后其用户名、邮箱的唯一索引占用也随之释放
+func (r *userRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) ([]model.User, error) {
+	var purged []model.User
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Select("id", "avatar", "avatar_thumbnails").
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+			Find(&purged).Error; err != nil {
+			return err
+		}
+		if len(purged) == 0 {
+			return nil
+		}
+
+		// 按查出的 ID 删除，返回的用户与实际删除的一致
+		ids := make([]string, len(purged))
+		for i := range purged {
+			ids[i] = purged[i].ID
+		}
+		for _, table := range userOwnedTables {
+			if err := tx.Where("user_id IN ?", ids).Delete(table).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&model.User{}).Error
+	})
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError.WithError(err)
+	}
+	return purged, nil
+}
+
 // List 获取用户列表
 // 返回用户列表和总数，支持分页、搜索和排序
 func (r *userRepository) List(ctx context.Context, opts *UserListOptions) ([]model.User, int64, error) {
 	var users []model.User
 	var total int64
 
-	// 构建基础查询
-	query := r.db.WithContext(ctx).Model(&model.User{})
+	// 构建基础查询并应用过滤条件
+	query := applyUserFilters(r.db.WithContext(ctx).Model(&model.User{}), opts)
 
-	// 应用过滤条件
+	// 获取总数
+	if opts != nil && opts.SkipTotal {
+		total = TotalUnknown
+	} else if err := query.Count(&total).Error; err != nil {
+		return nil, 0, apperrors.ErrDatabaseError.WithError(err)
+	}
+
+	// 应用排序与分页
+	query = r.applyListPage(query, opts)
+
+	// 应用关联预加载（白名单校验，防止任意关联被加载）
 	if opts != nil {
-		if opts.Username != "" {
-			query = query.Where("username LIKE ?", "%"+opts.Username+"%")
-		}
-		if opts.Email != "" {
-			query = query.Where("email LIKE ?", "%"+opts.Email+"%")
-		}
-		if opts.Status != nil {
-			query = query.Where("status = ?", *opts.Status)
-		}
-		if opts.Role != "" {
-			query = query.Where("role = ?", opts.Role)
+		for _, preload := range opts.Preloads {
+			if !allowedUserPreloads[preload] {
+				return nil, 0, apperrors.ErrValidation.WithDetail("不支持的预加载关联: " + preload)
+			}
+			query = query.Preload(preload)
 		}
 	}
 
-	// 获取总数
-	if err := query.Count(&total).Error; err != nil {
+	// 执行查询
+	if err := query.Find(&users).Error; err != nil {
 		return nil, 0, apperrors.ErrDatabaseError.WithError(err)
 	}
 
-	// 应用排序
-	if opts != nil && opts.SortBy != "" {
-		order := "desc"
-		if opts.SortOrder == "asc" {
-			order = "asc"
-		}
-		// 安全检查：只允许特定字段排序，防止 SQL 注入
-		allowedSortFields := map[string]bool{
-			"created_at": true,
-			"updated_at": true,
-			"username":   true,
-			"email":      true,
+	return users, total, nil
+}
+
+// ExplainList 返回 List 数据查询（不含 COUNT 与预加载）的执行计划
+func (r *userRepository) ExplainList(ctx context.Context, opts *UserListOptions) (*model.QueryPlan, error) {
+	return explainQuery(ctx, r.db, func(tx *gorm.DB) *gorm.DB {
+		query := applyUserFilters(tx.Model(&model.User{}), opts)
+		return r.applyListPage(query, opts).Find(&[]model.User{})
+	})
+}
+
+// allowedUserSortFields 用户列表允许排序的字段
+// 安全检查：只允许特定字段排序，防止 SQL 注入
+var allowedUserSortFields = map[string]bool{
+	"created_at":    true,
+	"updated_at":    true,
+	"username":      true,
+	"email":         true,
+	"last_login_at": true,
+}
+
+// applyListPage 应用用户列表的排序与分页
+// 未指定或不支持的字段、方向使用仓储的默认排序，末尾追加 id 保证翻页顺序稳定
+func (r *userRepository) applyListPage(query *gorm.DB, opts *UserListOptions) *gorm.DB {
+	sortBy, order := r.defaultSortBy, r.defaultSortOrder
+	if opts != nil {
+		if allowedUserSortFields[opts.SortBy] {
+			sortBy = opts.SortBy
 		}
-		if allowedSortFields[opts.SortBy] {
-			query = query.Order(opts.SortBy + " " + order)
+		if opts.SortOrder == "asc" || opts.SortOrder == "desc" {
+			order = opts.SortOrder
 		}
+	}
+	if sortBy == "last_login_at" {
+		query = query.Order(lastLoginOrder(order))
 	} else {
-		// 默认按创建时间降序
-		query = query.Order("created_at desc")
+		query = query.Order(stableOrder(sortBy, order))
 	}
 
-	// 应用分页
 	if opts != nil && opts.Page > 0 && opts.PageSize > 0 {
 		offset := (opts.Page - 1) * opts.PageSize
 		query = query.Offset(offset).Limit(opts.PageSize)
 	}
+	return query
+}
 
-	// 执行查询
-	if err := query.Find(&users).Error; err != nil {
-		return nil, 0, apperrors.ErrDatabaseError.WithError(err)
+// lastLoginOrder 按最后登录时间排序的子句
+// 从未登录（NULL）的用户视为最久未登录：升序排在最前，降序排在最后；
+// 显式排列 NULL 而不依赖数据库默认行为（PostgreSQL 与 MySQL、SQLite 相反）
+func lastLoginOrder(direction string) string {
+	nulls := "DESC"
+	if direction == "desc" {
+		nulls = "ASC"
 	}
+	return "last_login_at IS NULL " + nulls + ", " + stableOrder("last_login_at", direction)
+}
 
-	return users, total, nil
+// FindInBatches 按过滤条件分批读取用户
+// 每次只在内存中保留一批数据，适合导出等大结果集场景
+func (r *userRepository) FindInBatches(ctx context.Context, opts *UserListOptions, batchSize int, fn func(batch []model.User) error) error {
+	var batch []model.User
+	query := applyUserFilters(r.db.WithContext(ctx).Model(&model.User{}), opts)
+
+	// 区分回调返回的错误与数据库错误，回调错误原样返回
+	var fnErr error
+	result := query.FindInBatches(&batch, batchSize, func(_ *gorm.DB, _ int) error {
+		fnErr = fn(batch)
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if result.Error != nil {
+		return apperrors.ErrDatabaseError.WithError(result.Error)
+	}
+	return nil
+}
+
+// applyUserFilters 应用用户列表的通用过滤条件
+func applyUserFilters(query *gorm.DB, opts *UserListOptions) *gorm.DB {
+	if opts == nil {
+		return query
+	}
+	if opts.Username != "" {
+		query = query.Where("username LIKE ?", "%"+opts.Username+"%")
+	}
+	if opts.Email != "" {
+		query = query.Where("email LIKE ?", "%"+opts.Email+"%")
+	}
+	if opts.Status != nil {
+		query = query.Where("status = ?", *opts.Status)
+	}
+	if opts.Role != "" {
+		query = query.Where("role = ?", opts.Role)
+	}
+	if opts.Source != "" {
+		query = query.Where("source = ?", opts.Source)
+	}
+	if opts.LastLoginBefore != nil {
+		query = query.Where("(last_login_at < ? OR (last_login_at IS NULL AND created_at < ?))",
+			*opts.LastLoginBefore, *opts.LastLoginBefore)
+	}
+	if opts.LastLoginAfter != nil {
+		query = query.Where("last_login_at >= ?", *opts.LastLoginAfter)
+	}
+	return query
 }
 
 // ExistsByUsername 检查用户名是否存在
@@ -317,12 +599,46 @@ func (r *userRepository) UpdatePassword(ctx context.Context, id string, hashedPa
 
 // UpdateLastLogin 更新最后登录信息
 func (r *userRepository) UpdateLastLogin(ctx context.Context, id string, ip string) error {
+	now := time.Now()
 	return r.UpdateFields(ctx, id, map[string]interface{}{
-		"last_login_at": gorm.Expr("NOW()"),
-		"last_login_ip": ip,
+		// 使用应用时间而不是 NOW()，SQLite 不支持该函数
+		"last_login_at":  now,
+		"last_login_ip":  ip,
+		"last_active_at": now,
 	})
 }
 
+// UpdateLastActive 更新最近活跃时间
+// 活跃时间不属于资料变更，使用 UpdateColumn 不刷新 updated_at
+func (r *userRepository) UpdateLastActive(ctx context.Context, id string, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ?", id).
+		UpdateColumn("last_active_at", at)
+	if result.Error != nil {
+		return apperrors.ErrDatabaseError.WithError(result.Error)
+	}
+	return nil
+}
+
+// IncrementTokenVersion 递增用户的令牌版本
+func (r *userRepository) IncrementTokenVersion(ctx context.Context, id string) error {
+	return r.UpdateFields(ctx, id, map[string]interface{}{
+		"token_version": gorm.Expr("token_version + 1"),
+	})
+}
+
+// IncrementAllTokenVersions 递增所有用户的令牌版本
+// 用于安全事件时强制全体用户重新登录
+func (r *userRepository) IncrementAllTokenVersions(ctx context.Context) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("1 = 1").
+		Update("token_version", gorm.Expr("token_version + 1"))
+	if result.Error != nil {
+		return 0, apperrors.ErrDatabaseError.WithError(result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
 // isDuplicateKeyError 检查是否是唯一键冲突错误
 // 支持 MySQL 和 SQLite
 func isDuplicateKeyError(err error) bool {
@@ -344,3 +660,41 @@ func isDuplicateKeyError(err error) bool {
 	}
 	return false
 }
+
+// userDuplicateError 将用户表的唯一约束冲突映射为业务错误
+// 按冲突的约束名（而非整条错误信息）判断字段，避免冲突值本身包含 "username" 等字样时误判
+func userDuplicateError(err error) error {
+	constraint := duplicateKeyConstraint(err)
+	switch {
+	case strings.Contains(constraint, "username"):
+		return apperrors.ErrUsernameExists
+	case strings.Contains(constraint, "email"):
+		return apperrors.ErrEmailAlreadyUsed
+	default:
+		return apperrors.ErrDuplicateEntry.WithError(err)
+	}
+}
+
+// duplicateKeyConstraint 从唯一键冲突错误中提取约束名（小写），无法识别时返回空字符串
+//
+//	MySQL:      Error 1062 (23000): Duplicate entry 'a@b.com' for key 'users.idx_users_email'
+//	SQLite:     UNIQUE constraint failed: users.email
+//	PostgreSQL: duplicate key value violates unique constraint "idx_users_email"
+func duplicateKeyConstraint(err error) string {
+	lower := strings.ToLower(err.Error())
+
+	if i := strings.LastIndex(lower, "for key "); i >= 0 {
+		return strings.Trim(lower[i+len("for key "):], "'\" ")
+	}
+	if i := strings.Index(lower, "unique constraint failed:"); i >= 0 {
+		return strings.TrimSpace(lower[i+len("unique constraint failed:"):])
+	}
+	if i := strings.Index(lower, "unique constraint "); i >= 0 {
+		rest := strings.TrimPrefix(lower[i+len("unique constraint "):], "\"")
+		if end := strings.IndexByte(rest, '"'); end >= 0 {
+			rest = rest[:end]
+		}
+		return rest
+	}
+	return ""
+}
@@ -0,0 +1,899 @@
+// Package repository 提供数据访问层的实现
+//
+// 本文件包含用户仓储的单元测试，使用内存 SQLite 数据库
+package repository
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	apperrors "github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// ============================================================
+// 测试辅助函数
+// ============================================================
+
+// newTestDB 创建测试用的内存 SQLite 数据库并完成迁移
+// 每个测试使用独立的数据库，互不干扰
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	return openTestDB(t, fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()))
+}
+
+// newForeignKeyTestDB 创建开启外键约束的测试数据库，与默认配置 database.sqlite.foreign_keys: true 一致
+func newForeignKeyTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	return openTestDB(t, fmt.Sprintf("file:%s?mode=memory&cache=shared&_foreign_keys=1", t.Name()))
+}
+
+// openTestDB 打开测试数据库并完成迁移
+func openTestDB(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, autoMigrate(db))
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// createTestUser 在测试数据库中创建用户
+func createTestUser(t *testing.T, db *gorm.DB, username string) *model.User {
+	t.Helper()
+
+	user := &model.User{
+		Username: username,
+		Email:    username + "@example.com",
+		Password: "hashed",
+		Status:   model.UserStatusActive,
+		Role:     model.RoleUser,
+	}
+	require.NoError(t, db.Create(user).Error)
+	return user
+}
+
+// ============================================================
+// 列表预加载测试
+// ============================================================
+
+func TestUserRepository_List_PreloadTags(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	user := createTestUser(t, db, "alice")
+	require.NoError(t, db.Create(&model.UserTag{UserID: user.ID, Name: "vip"}).Error)
+
+	// 不预加载时不包含关联数据
+	users, _, err := repo.List(ctx, &UserListOptions{})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Empty(t, users[0].Tags)
+
+	// 预加载后包含关联数据
+	users, total, err := repo.List(ctx, &UserListOptions{Preloads: []string{model.PreloadTags}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, users, 1)
+	assert.Equal(t, []string{"vip"}, users[0].TagNames())
+}
+
+func TestUserRepository_List_PreloadNotAllowed(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+
+	users, _, err := repo.List(context.Background(), &UserListOptions{Preloads: []string{"Password"}})
+
+	assert.Error(t, err)
+	assert.Nil(t, users)
+}
+
+func TestUserRepository_List_SkipTotal(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	createTestUser(t, db, "alice")
+	createTestUser(t, db, "bob")
+
+	// 记录执行的查询语句
+	var statements []string
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:capture_sql", func(tx *gorm.DB) {
+		statements = append(statements, strings.ToLower(tx.Statement.SQL.String()))
+	}))
+
+	// 执行
+	users, total, err := repo.List(context.Background(), &UserListOptions{Page: 1, PageSize: 10, SkipTotal: true})
+
+	// 断言：不执行 count，仍返回数据
+	require.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, TotalUnknown, total)
+	require.NotEmpty(t, statements)
+	for _, stmt := range statements {
+		assert.NotContains(t, stmt, "count(")
+	}
+}
+
+func TestUserRepository_List_FilterBySource(t *testing.T) {
+	// 准备
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	for _, name := range []string{"alice", "bob"} {
+		user := createTestUser(t, db, name)
+		require.NoError(t, db.Model(user).Update("source", "newsletter").Error)
+	}
+	createTestUser(t, db, "carol")
+
+	// 执行
+	users, total, err := repo.List(context.Background(), &UserListOptions{Page: 1, PageSize: 10, Source: "newsletter"})
+
+	// 断言：total 即该来源的注册人数
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, users, 2)
+	for _, u := range users {
+		assert.Equal(t, "newsletter", u.Source)
+	}
+}
+
+// ============================================================
+// 可选邮箱测试
+// ============================================================
+
+func TestUserRepository_List_StableOrderWithSameCreatedAt(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	// 同一时刻批量创建的用户，created_at 完全相同
+	const total = 53
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < total; i++ {
+		user := &model.User{
+			Username: fmt.Sprintf("user%02d", i),
+			Password: "hashed",
+			Status:   model.UserStatusActive,
+			Role:     model.RoleUser,
+		}
+		user.CreatedAt = createdAt
+		require.NoError(t, db.Create(user).Error)
+	}
+
+	for _, sortOrder := range []string{"", "asc"} {
+		opts := &UserListOptions{PageSize: 7}
+		if sortOrder != "" {
+			opts.SortBy, opts.SortOrder = "created_at", sortOrder
+		}
+
+		// 逐页读取，所有用户恰好出现一次
+		seen := make(map[string]bool, total)
+		var ids []string
+		for page := 1; ; page++ {
+			opts.Page = page
+			users, _, err := repo.List(ctx, opts)
+			require.NoError(t, err)
+			if len(users) == 0 {
+				break
+			}
+			for _, u := range users {
+				assert.False(t, seen[u.ID], "用户 %s 重复出现", u.ID)
+				seen[u.ID] = true
+				ids = append(ids, u.ID)
+			}
+		}
+		assert.Len(t, seen, total, sortOrder)
+
+		// created_at 相同时按 id 与主排序同向排列
+		sorted := append([]string(nil), ids...)
+		sort.Strings(sorted)
+		if sortOrder != "asc" {
+			sort.Sort(sort.Reverse(sort.StringSlice(sorted)))
+		}
+		assert.Equal(t, sorted, ids, sortOrder)
+	}
+}
+
+func TestUserRepository_List_ConfiguredDefaultSort(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	// 按创建先后插入，created_at 依次递增
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	var created []string
+	for i, name := range []string{"carol", "alice", "bob"} {
+		user := createTestUser(t, db, name)
+		require.NoError(t, db.Model(user).Update("created_at", base.Add(time.Duration(i)*time.Hour)).Error)
+		created = append(created, user.ID)
+	}
+
+	usernames := func(users []model.User) []string {
+		names := make([]string, len(users))
+		for i, u := range users {
+			names[i] = u.Username
+		}
+		return names
+	}
+
+	tests := []struct {
+		name     string
+		options  []UserRepositoryOption
+		opts     *UserListOptions
+		expected []string
+	}{
+		{name: "未配置时按创建时间降序", opts: &UserListOptions{}, expected: []string{"bob", "alice", "carol"}},
+		{name: "配置为升序", options: []UserRepositoryOption{WithDefaultUserSort("created_at", "asc")}, opts: &UserListOptions{}, expected: []string{"carol", "alice", "bob"}},
+		{name: "配置默认字段", options: []UserRepositoryOption{WithDefaultUserSort("username", "asc")}, opts: nil, expected: []string{"alice", "bob", "carol"}},
+		{name: "用户指定的排序优先", options: []UserRepositoryOption{WithDefaultUserSort("created_at", "asc")}, opts: &UserListOptions{SortBy: "username", SortOrder: "desc"}, expected: []string{"carol", "bob", "alice"}},
+		{name: "只指定方向时使用默认字段", options: []UserRepositoryOption{WithDefaultUserSort("created_at", "asc")}, opts: &UserListOptions{SortOrder: "desc"}, expected: []string{"bob", "alice", "carol"}},
+		{name: "默认字段不在白名单内时忽略", options: []UserRepositoryOption{WithDefaultUserSort("password", "asc")}, opts: &UserListOptions{}, expected: []string{"carol", "alice", "bob"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 执行
+			users, total, err := NewUserRepository(db, tt.options...).List(ctx, tt.opts)
+
+			// 断言
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(created)), total)
+			assert.Equal(t, tt.expected, usernames(users))
+		})
+	}
+}
+
+// seedLastLoginUsers 创建最后登录时间各不相同的用户，注册时间均为 base 前 90 天
+// lastLogins 的值为 nil 表示从未登录
+func seedLastLoginUsers(t *testing.T, db *gorm.DB, base time.Time, lastLogins map[string]*time.Time) {
+	t.Helper()
+
+	for name, lastLogin := range lastLogins {
+		user := createTestUser(t, db, name)
+		require.NoError(t, db.Model(user).Updates(map[string]interface{}{
+			"created_at":    base.AddDate(0, 0, -90),
+			"last_login_at": lastLogin,
+		}).Error)
+	}
+}
+
+func TestUserRepository_List_LastLoginBefore_FindsDormantUsers(t *testing.T) {
+	// 准备
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) *time.Time {
+		t := now.AddDate(0, 0, -days)
+		return &t
+	}
+	seedLastLoginUsers(t, db, now, map[string]*time.Time{
+		"active":  daysAgo(1),
+		"recent":  daysAgo(29),
+		"dormant": daysAgo(45),
+		"ancient": daysAgo(80),
+		"never":   nil,
+	})
+	// 刚注册、还没登录过的用户不算沉睡用户
+	newcomer := createTestUser(t, db, "newcomer")
+	require.NoError(t, db.Model(newcomer).Update("created_at", now.AddDate(0, 0, -2)).Error)
+
+	// 执行：30 天未登录
+	cutoff := now.AddDate(0, 0, -30)
+	users, total, err := repo.List(context.Background(), &UserListOptions{
+		Page: 1, PageSize: 10, LastLoginBefore: &cutoff, SortBy: "last_login_at", SortOrder: "asc",
+	})
+
+	// 断言：从未登录的排在最前，其余按最后登录时间由远到近
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	var names []string
+	for _, u := range users {
+		names = append(names, u.Username)
+	}
+	assert.Equal(t, []string{"never", "ancient", "dormant"}, names)
+}
+
+func TestUserRepository_List_LastLoginAfter_ExcludesNeverLoggedIn(t *testing.T) {
+	// 准备
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) *time.Time {
+		t := now.AddDate(0, 0, -days)
+		return &t
+	}
+	seedLastLoginUsers(t, db, now, map[string]*time.Time{
+		"active":  daysAgo(1),
+		"recent":  daysAgo(6),
+		"dormant": daysAgo(45),
+		"never":   nil,
+	})
+
+	// 执行
+	since := now.AddDate(0, 0, -7)
+	users, total, err := repo.List(context.Background(), &UserListOptions{
+		Page: 1, PageSize: 10, LastLoginAfter: &since, SortBy: "last_login_at", SortOrder: "desc",
+	})
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, users, 2)
+	assert.Equal(t, "active", users[0].Username)
+	assert.Equal(t, "recent", users[1].Username)
+}
+
+func TestUserRepository_List_SortByLastLogin_NullsAsOldest(t *testing.T) {
+	// 准备
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) *time.Time {
+		t := now.AddDate(0, 0, -days)
+		return &t
+	}
+	seedLastLoginUsers(t, db, now, map[string]*time.Time{
+		"active":  daysAgo(1),
+		"dormant": daysAgo(45),
+		"never":   nil,
+	})
+
+	tests := []struct {
+		order    string
+		expected []string
+	}{
+		{order: "asc", expected: []string{"never", "dormant", "active"}},
+		{order: "desc", expected: []string{"active", "dormant", "never"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			// 执行
+			users, _, err := repo.List(context.Background(), &UserListOptions{SortBy: "last_login_at", SortOrder: tt.order})
+
+			// 断言：从未登录的用户视为最久未登录
+			require.NoError(t, err)
+			var names []string
+			for _, u := range users {
+				names = append(names, u.Username)
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+}
+
+func TestUserRepository_ExplainList(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+	createTestUser(t, db, "alice")
+	status := model.UserStatusActive
+
+	plan, err := repo.ExplainList(ctx, &UserListOptions{
+		Page: 2, PageSize: 10, Username: "ali", Status: &status, SortBy: "username", SortOrder: "asc",
+	})
+	require.NoError(t, err)
+
+	// SQL 与 List 的数据查询一致，参数已代入便于阅读
+	assert.Contains(t, plan.SQL, "SELECT * FROM `users`")
+	assert.Contains(t, plan.SQL, "%ali%")
+	assert.Contains(t, plan.SQL, "ORDER BY username asc")
+	assert.Contains(t, plan.SQL, "LIMIT 10 OFFSET 10")
+
+	// SQLite 的 EXPLAIN QUERY PLAN 每行包含 detail 列
+	require.NotEmpty(t, plan.Plan)
+	assert.Contains(t, plan.Plan[0], "detail")
+}
+
+func TestExplainSQL(t *testing.T) {
+	sql, err := explainSQL("sqlite", "SELECT 1")
+	require.NoError(t, err)
+	assert.Equal(t, "EXPLAIN QUERY PLAN SELECT 1", sql)
+
+	sql, err = explainSQL("mysql", "SELECT 1")
+	require.NoError(t, err)
+	assert.Equal(t, "EXPLAIN SELECT 1", sql)
+
+	_, err = explainSQL("sqlserver", "SELECT 1")
+	assert.Error(t, err)
+}
+
+func TestUserRepository_CreateMultipleUsersWithoutEmail(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	// 多个未填写邮箱的用户不应触发唯一约束
+	for _, name := range []string{"alice", "bob"} {
+		err := repo.Create(ctx, &model.User{
+			Username: name,
+			Password: "hashed",
+			Status:   model.UserStatusActive,
+			Role:     model.RoleUser,
+		})
+		require.NoError(t, err)
+	}
+
+	user, err := repo.GetByUsernameOrEmail(ctx, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", user.Username)
+	assert.Empty(t, user.Email)
+
+	// 空字符串不能匹配到无邮箱用户
+	_, err = repo.GetByUsernameOrEmail(ctx, "")
+	assert.Error(t, err)
+}
+
+// ============================================================
+// 令牌版本测试
+// ============================================================
+
+func TestUserRepository_IncrementTokenVersions(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+
+	require.NoError(t, repo.IncrementTokenVersion(ctx, alice.ID))
+	affected, err := repo.IncrementAllTokenVersions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), affected)
+
+	got, err := repo.GetByID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.TokenVersion)
+
+	got, err = repo.GetByID(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.TokenVersion)
+}
+
+// ============================================================
+// 分批读取测试
+// ============================================================
+
+func TestUserRepository_FindInBatches(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	for _, name := range []string{"alice", "bob", "carol"} {
+		createTestUser(t, db, name)
+	}
+	admin := createTestUser(t, db, "dave")
+	require.NoError(t, db.Model(admin).Update("role", model.RoleAdmin).Error)
+
+	// 按角色过滤，每批 2 条
+	var batchSizes []int
+	var usernames []string
+	err := repo.FindInBatches(ctx, &UserListOptions{Role: model.RoleUser}, 2, func(batch []model.User) error {
+		batchSizes = append(batchSizes, len(batch))
+		for _, u := range batch {
+			usernames = append(usernames, u.Username)
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 1}, batchSizes)
+	assert.ElementsMatch(t, []string{"alice", "bob", "carol"}, usernames)
+}
+
+// ============================================================
+// 批量获取测试
+// ============================================================
+
+func TestUserRepository_GetByIDs(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	deleted := createTestUser(t, db, "deleted")
+	require.NoError(t, repo.Delete(ctx, deleted.ID))
+
+	users, err := repo.GetByIDs(ctx, []string{alice.ID, "missing-id", bob.ID, deleted.ID})
+	require.NoError(t, err)
+
+	ids := make([]string, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	assert.ElementsMatch(t, []string{alice.ID, bob.ID}, ids)
+
+	empty, err := repo.GetByIDs(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestUserRepository_UpdateRoleBatch(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+
+	updated, err := repo.UpdateRoleBatch(ctx, []string{alice.ID, bob.ID}, model.RoleAdmin)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated)
+
+	// 已是目标角色的用户不计入更新数
+	updated, err = repo.UpdateRoleBatch(ctx, []string{alice.ID, carol.ID}, model.RoleAdmin)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+
+	// 含不存在的 ID 时整批不修改
+	_, err = repo.UpdateRoleBatch(ctx, []string{alice.ID, "missing-id"}, model.RoleUser)
+	assert.True(t, apperrors.Is(err, apperrors.ErrUserNotFound))
+
+	got, err := repo.GetByID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, got.Role)
+
+	// 只有角色发生变化时递增令牌版本
+	assert.Equal(t, 1, got.TokenVersion)
+	got, err = repo.GetByID(ctx, carol.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.TokenVersion)
+}
+
+func TestUserRepository_UpdateLastLogin(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	user := createTestUser(t, db, "alice")
+	require.Nil(t, user.LastLoginAt)
+
+	require.NoError(t, repo.UpdateLastLogin(ctx, user.ID, "203.0.113.7"))
+
+	got, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.LastLoginAt)
+	assert.WithinDuration(t, time.Now(), *got.LastLoginAt, time.Minute)
+	assert.Equal(t, "203.0.113.7", got.LastLoginIP)
+}
+
+func TestUserRepository_Create_ConcurrentSameEmail(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	const n = 5
+	errs := make([]error, n)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = repo.Create(ctx, &model.User{
+				Username: fmt.Sprintf("racer%d", i),
+				Email:    "race@example.com",
+				Password: "hashed",
+			})
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	var succeeded int
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.True(t, apperrors.Is(err, apperrors.ErrEmailAlreadyUsed), "unexpected error: %v", err)
+	}
+	assert.Equal(t, 1, succeeded)
+}
+
+func TestUserRepository_Create_DuplicateUsername(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	createTestUser(t, db, "alice")
+
+	// 邮箱中包含 "username" 字样也不影响按约束名判断
+	err := repo.Create(context.Background(), &model.User{
+		Username: "alice",
+		Email:    "username@example.com",
+		Password: "hashed",
+	})
+	assert.True(t, apperrors.Is(err, apperrors.ErrUsernameExists), "unexpected error: %v", err)
+}
+
+func TestDuplicateKeyConstraint(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{"mysql", "Error 1062 (23000): Duplicate entry 'username@example.com' for key 'users.idx_users_email'", "users.idx_users_email"},
+		{"sqlite", "UNIQUE constraint failed: users.username", "users.username"},
+		{"postgres", `ERROR: duplicate key value violates unique constraint "idx_users_email" (SQLSTATE 23505)`, "idx_users_email"},
+		{"unknown", "duplicate entry", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, duplicateKeyConstraint(stderrors.New(tt.msg)))
+		})
+	}
+}
+
+func TestRefreshTokenRepository_DeleteInactiveBefore(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewRefreshTokenRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+	longAgo := now.AddDate(0, 0, -10)
+	recently := now.Add(-time.Hour)
+
+	tokens := []*model.RefreshToken{
+		{JTI: "active", UserID: "u1", ExpiresAt: now.Add(time.Hour)},
+		{JTI: "expired-long-ago", UserID: "u1", ExpiresAt: longAgo},
+		{JTI: "expired-recently", UserID: "u1", ExpiresAt: recently},
+		{JTI: "revoked-long-ago", UserID: "u1", ExpiresAt: now.Add(time.Hour), Revoked: true, RevokedAt: &longAgo},
+		{JTI: "revoked-recently", UserID: "u1", ExpiresAt: now.Add(time.Hour), Revoked: true, RevokedAt: &recently},
+	}
+	for _, token := range tokens {
+		require.NoError(t, repo.Create(ctx, token))
+	}
+
+	// 执行：保留 7 天
+	deleted, err := repo.DeleteInactiveBefore(ctx, now.AddDate(0, 0, -7))
+
+	// 断言：只删除超过保留期的过期与撤销记录
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	remaining, err := repo.ListByUser(ctx, "u1")
+	require.NoError(t, err)
+	jtis := make([]string, len(remaining))
+	for i, token := range remaining {
+		jtis[i] = token.JTI
+	}
+	assert.ElementsMatch(t, []string{"active", "expired-recently", "revoked-recently"}, jtis)
+}
+
+func TestPasswordHistoryRepository_PruneByUser(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewPasswordHistoryRepository(db)
+	ctx := context.Background()
+
+	base := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, repo.Create(ctx, &model.PasswordHistory{
+			BaseModel:    model.BaseModel{CreatedAt: base.Add(time.Duration(i) * time.Minute)},
+			UserID:       "u1",
+			PasswordHash: fmt.Sprintf("hash-%d", i),
+		}))
+	}
+	require.NoError(t, repo.Create(ctx, &model.PasswordHistory{UserID: "u2", PasswordHash: "other"}))
+
+	deleted, err := repo.PruneByUser(ctx, "u1", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+
+	histories, err := repo.ListRecentByUser(ctx, "u1", 10)
+	require.NoError(t, err)
+	require.Len(t, histories, 2)
+	assert.Equal(t, "hash-4", histories[0].PasswordHash)
+	assert.Equal(t, "hash-3", histories[1].PasswordHash)
+
+	// 其他用户的记录不受影响
+	others, err := repo.ListRecentByUser(ctx, "u2", 10)
+	require.NoError(t, err)
+	assert.Len(t, others, 1)
+
+	// keep 为 0 时清空
+	deleted, err = repo.PruneByUser(ctx, "u1", 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+}
+
+func TestUserRepository_SoftDeleteRecoverable(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+	user := createTestUser(t, db, "softdeleted")
+
+	// 执行
+	require.NoError(t, repo.Delete(ctx, user.ID))
+
+	// 断言：常规查询不可见，但记录仍在，清除 deleted_at 后恢复
+	_, err := repo.GetByID(ctx, user.ID)
+	assert.True(t, apperrors.Is(err, apperrors.ErrUserNotFound))
+
+	var count int64
+	require.NoError(t, db.Unscoped().Model(&model.User{}).Where("id = ?", user.ID).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	require.NoError(t, db.Unscoped().Model(&model.User{}).Where("id = ?", user.ID).Update("deleted_at", nil).Error)
+	restored, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "softdeleted", restored.Username)
+}
+
+func TestUserRepository_HardDeleteRemovesUserAndPersonalData(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+	user := createTestUser(t, db, "harddeleted")
+	other := createTestUser(t, db, "remaining")
+
+	for _, u := range []*model.User{user, other} {
+		require.NoError(t, db.Create(&model.UserTag{UserID: u.ID, Name: "vip"}).Error)
+		require.NoError(t, db.Create(&model.LoginHistory{UserID: u.ID, IP: "127.0.0.1"}).Error)
+	}
+	require.NoError(t, db.Create(&model.SecurityEvent{UserID: user.ID, Type: model.SecurityEventLoginAnomaly, IP: "1.2.3.4", Location: "CN"}).Error)
+	require.NoError(t, db.Create(&model.UserChangeLog{UserID: user.ID, Field: "email", OldValue: "a@example.com", NewValue: "b@example.com"}).Error)
+
+	// 执行：先软删再硬删，硬删对已软删除的用户同样生效
+	require.NoError(t, repo.Delete(ctx, user.ID))
+	deleted, err := repo.HardDelete(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, deleted.ID)
+
+	// 断言：用户记录彻底消失
+	var count int64
+	require.NoError(t, db.Unscoped().Model(&model.User{}).Where("id = ?", user.ID).Count(&count).Error)
+	assert.Zero(t, count)
+	_, err = repo.HardDelete(ctx, user.ID)
+	assert.True(t, apperrors.Is(err, apperrors.ErrUserNotFound))
+
+	// 个人数据删除，其他用户的数据保留
+	require.NoError(t, db.Model(&model.UserTag{}).Where("user_id = ?", user.ID).Count(&count).Error)
+	assert.Zero(t, count)
+	require.NoError(t, db.Model(&model.LoginHistory{}).Where("user_id = ?", user.ID).Count(&count).Error)
+	assert.Zero(t, count)
+	require.NoError(t, db.Model(&model.UserTag{}).Where("user_id = ?", other.ID).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// 审计记录保留但已匿名化
+	var event model.SecurityEvent
+	require.NoError(t, db.Where("user_id = ?", user.ID).First(&event).Error)
+	assert.Empty(t, event.IP)
+	assert.Empty(t, event.Location)
+	var changeLog model.UserChangeLog
+	require.NoError(t, db.Where("user_id = ?", user.ID).First(&changeLog).Error)
+	assert.Equal(t, redactedValue, changeLog.OldValue)
+	assert.Equal(t, redactedValue, changeLog.NewValue)
+}
+
+func TestUserRepository_PurgeDeletedBefore(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	expired := createTestUser(t, db, "expired")
+	recent := createTestUser(t, db, "recent")
+	active := createTestUser(t, db, "active")
+	require.NoError(t, db.Unscoped().Model(&model.User{}).Where("id = ?", expired.ID).
+		Update("deleted_at", now.AddDate(0, 0, -40)).Error)
+	require.NoError(t, db.Unscoped().Model(&model.User{}).Where("id = ?", recent.ID).
+		Update("deleted_at", now.AddDate(0, 0, -10)).Error)
+
+	// 关联数据
+	for _, u := range []*model.User{expired, recent} {
+		require.NoError(t, db.Create(&model.UserTag{UserID: u.ID, Name: "vip"}).Error)
+		require.NoError(t, db.Create(&model.LoginHistory{UserID: u.ID, IP: "127.0.0.1"}).Error)
+		require.NoError(t, db.Create(&model.PersonalAccessToken{UserID: u.ID, Name: "ci", TokenHash: "hash-" + u.ID, TokenPrefix: "pat_", Scopes: "profile:read"}).Error)
+	}
+	require.NoError(t, db.Create(&model.UserChangeLog{UserID: expired.ID, Field: "role", OldValue: "user", NewValue: "admin"}).Error)
+
+	// 执行：保留期 30 天
+	purged, err := repo.PurgeDeletedBefore(ctx, now.AddDate(0, 0, -30))
+
+	// 断言：只硬删超过保留期的软删除用户
+	require.NoError(t, err)
+	require.Len(t, purged, 1)
+	assert.Equal(t, expired.ID, purged[0].ID)
+
+	var ids []string
+	require.NoError(t, db.Unscoped().Model(&model.User{}).Order("username").Pluck("id", &ids).Error)
+	assert.ElementsMatch(t, []string{recent.ID, active.ID}, ids)
+
+	// 被清理用户的关联数据一并删除，未超期用户的保留
+	var tagCount, historyCount, tokenCount int64
+	require.NoError(t, db.Model(&model.UserTag{}).Where("user_id = ?", expired.ID).Count(&tagCount).Error)
+	require.NoError(t, db.Model(&model.LoginHistory{}).Where("user_id = ?", expired.ID).Count(&historyCount).Error)
+	require.NoError(t, db.Model(&model.PersonalAccessToken{}).Where("user_id = ?", expired.ID).Count(&tokenCount).Error)
+	assert.Zero(t, tagCount)
+	assert.Zero(t, historyCount)
+	assert.Zero(t, tokenCount)
+	require.NoError(t, db.Model(&model.UserTag{}).Where("user_id = ?", recent.ID).Count(&tagCount).Error)
+	assert.Equal(t, int64(1), tagCount)
+
+	// 变更记录作为审计保留
+	var changeLogCount int64
+	require.NoError(t, db.Model(&model.UserChangeLog{}).Where("user_id = ?", expired.ID).Count(&changeLogCount).Error)
+	assert.Equal(t, int64(1), changeLogCount)
+
+	// 用户名占用已释放
+	require.NoError(t, repo.Create(ctx, &model.User{Username: "expired", Password: "hashed"}))
+}
+
+// ============================================================
+// 头像缩略图测试
+// ============================================================
+
+func TestUserRepository_UpdateFields_AvatarThumbnails(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+	user := createTestUser(t, db, "alice")
+
+	// 写入缩略图
+	thumbnails := model.AvatarThumbnails{"64": "/uploads/a/64.png", "128": "/uploads/a/128.png"}
+	require.NoError(t, repo.UpdateFields(ctx, user.ID, map[string]interface{}{
+		"avatar":            "/uploads/a/original.png",
+		"avatar_thumbnails": thumbnails,
+	}))
+
+	got, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "/uploads/a/original.png", got.Avatar)
+	assert.Equal(t, thumbnails, got.AvatarThumbnails)
+
+	// 清空后读取为 nil
+	require.NoError(t, repo.UpdateFields(ctx, user.ID, map[string]interface{}{
+		"avatar_thumbnails": model.AvatarThumbnails(nil),
+	}))
+	got, err = repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.AvatarThumbnails)
+}
+
+func TestUserRepository_DeleteWithForeignKeys(t *testing.T) {
+	// 准备：开启外键约束，用户标签引用 users 表
+	db := newForeignKeyTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	var enabled int
+	require.NoError(t, db.Raw("PRAGMA foreign_keys").Scan(&enabled).Error)
+	require.Equal(t, 1, enabled)
+
+	hard := createTestUser(t, db, "fkhard")
+	purged := createTestUser(t, db, "fkpurged")
+	for _, u := range []*model.User{hard, purged} {
+		require.NoError(t, db.Create(&model.UserTag{UserID: u.ID, Name: "vip"}).Error)
+	}
+
+	// 执行
+	_, err := repo.HardDelete(ctx, hard.ID)
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, purged.ID))
+	users, err := repo.PurgeDeletedBefore(ctx, time.Now().Add(time.Minute))
+
+	// 断言：先删除子表记录，外键约束不会阻止删除
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+	var count int64
+	require.NoError(t, db.Unscoped().Model(&model.User{}).Count(&count).Error)
+	assert.Zero(t, count)
+	require.NoError(t, db.Model(&model.UserTag{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
@@ -0,0 +1,62 @@
+// Package repository 提供数据访问层的实现
+package repository
+
+import (
+	"context"
+
+	"github.com/example/go-user-api/internal/model"
+	apperrors "github.com/example/go-user-api/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserTagRepository 用户标签仓储接口
+type UserTagRepository interface {
+	// ListByUser 获取用户的所有标签
+	ListByUser(ctx context.Context, userID string) ([]model.UserTag, error)
+	// AddToUsers 给一批用户打上同一个标签，已有该标签的用户跳过，返回新增的标签数
+	AddToUsers(ctx context.Context, userIDs []string, name string) (int64, error)
+}
+
+// userTagRepository 用户标签仓储实现
+type userTagRepository struct {
+	db *gorm.DB
+}
+
+// NewUserTagRepository 创建用户标签仓储实例
+func NewUserTagRepository(db *gorm.DB) UserTagRepository {
+	return &userTagRepository{db: db}
+}
+
+// ListByUser 获取用户的所有标签
+func (r *userTagRepository) ListByUser(ctx context.Context, userID string) ([]model.UserTag, error) {
+	var tags []model.UserTag
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("name asc").
+		Find(&tags).Error; err != nil {
+		return nil, apperrors.ErrDatabaseError.WithError(err)
+	}
+	return tags, nil
+}
+
+// AddToUsers 给一批用户打上同一个标签
+// 依赖 (user_id, name) 唯一索引忽略重复，可安全地重复执行
+func (r *userTagRepository) AddToUsers(ctx context.Context, userIDs []string, name string) (int64, error) {
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+
+	tags := make([]model.UserTag, len(userIDs))
+	for i, userID := range userIDs {
+		tags[i] = model.UserTag{UserID: userID, Name: name}
+	}
+
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&tags)
+	if result.Error != nil {
+		return 0, apperrors.ErrDatabaseError.WithError(result.Error)
+	}
+	return result.RowsAffected, nil
+}
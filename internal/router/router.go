@@ -10,25 +10,41 @@
 //
 //	/health              - 健康检查
 //	/ready               - 就绪检查
+//	/version             - 版本与构建信息
 //	/api/v1/auth/*       - 认证相关（公开）
 //	/api/v1/users/*      - 用户管理（需要认证）
+//	/api/v1/admin/*      - 管理端操作（需要管理员）
 package router
 
 import (
+	"context"
 	"embed"
 	"html/template"
 	"net/http"
+	"runtime"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/example/go-user-api/internal/config"
 	"github.com/example/go-user-api/internal/handler"
 	"github.com/example/go-user-api/internal/middleware"
 	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/notifier"
 	"github.com/example/go-user-api/internal/repository"
 	"github.com/example/go-user-api/internal/service"
+	"github.com/example/go-user-api/pkg/breaker"
+	"github.com/example/go-user-api/pkg/cache"
+	"github.com/example/go-user-api/pkg/eventbus"
+	"github.com/example/go-user-api/pkg/featureflag"
+	"github.com/example/go-user-api/pkg/geoip"
+	"github.com/example/go-user-api/pkg/jobqueue"
 	"github.com/example/go-user-api/pkg/logger"
 	"github.com/example/go-user-api/pkg/response"
+	"github.com/example/go-user-api/pkg/storage"
+	"github.com/example/go-user-api/pkg/tokenblacklist"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
@@ -38,10 +54,94 @@ var templatesFS embed.FS
 // Router 路由器结构
 // 封装了 Gin 引擎和所有依赖
 type Router struct {
-	engine *gin.Engine
-	config *config.Config
-	db     *gorm.DB
-	log    logger.Logger
+	engine    *gin.Engine
+	config    *config.Config
+	db        *gorm.DB
+	log       logger.Logger
+	jobQueue  *jobqueue.MemoryQueue
+	buildInfo BuildInfo
+
+	// database db 的包装，提供运行时调整连接池；为 nil 时不注册连接池管理能力
+	database *repository.Database
+
+	// poolHealth 连接池健康检查，跨多次就绪检查比较等待次数
+	poolHealth *repository.PoolHealthChecker
+
+	// features 接口级功能开关，初始状态来自配置
+	features *featureflag.Flags
+
+	// usageBuffer 使用记录写入缓冲，未启用时为 nil
+	usageBuffer *service.UsageBuffer
+
+	// eventBus 进程内事件总线，子系统通过 EventBus 订阅用户生命周期事件
+	eventBus *eventbus.MemoryBus
+
+	// storage 上传文件的本地存储，初始化失败时为 nil（头像上传不可用）
+	storage *storage.LocalStorage
+
+	// mailRetry 欢迎邮件的熔断与重试队列，未启用邮件发送时为 nil
+	mailRetry *notifier.RetryNotifier
+
+	// tokenBlacklist 已吊销访问令牌的黑名单，用户服务与认证中间件共用
+	tokenBlacklist tokenblacklist.TokenBlacklist
+	// redisClient 令牌黑名单使用的 Redis 连接，未使用 Redis 时为 nil
+	redisClient *redis.Client
+
+	// stopScheduler 停止所有定时任务，未启动定时任务时为 nil
+	stopScheduler context.CancelFunc
+	schedulerCtx  context.Context
+	schedulers    sync.WaitGroup
+}
+
+// purgeInterval 软删除用户清理任务的执行间隔
+const purgeInterval = 24 * time.Hour
+
+// longRunningRoutes 不设置请求处理 deadline 的路由
+// 导入、SSE 进度推送与同步导出的耗时随数据量增长，不适用统一的 request_timeout
+var longRunningRoutes = []string{
+	"/api/v1/users/import",
+	"/api/v1/users/export",
+	"/api/v1/users/batch/role",
+	"/api/v1/users/batch/status",
+	"/api/v1/users/me/data-export",
+	"/api/v1/risk-report/usage/export",
+}
+
+// BuildInfo 编译期注入的构建信息
+type BuildInfo struct {
+	// Version 应用版本号
+	Version string
+	// BuildTime 构建时间
+	BuildTime string
+	// GitCommit Git 提交哈希
+	GitCommit string
+}
+
+// Option 路由器可选配置
+type Option func(*Router)
+
+// WithBuildInfo 设置构建信息，用于 /version、/health 与首页展示
+// 未设置的字段保持默认值（版本号取配置中的 app.version，其余为 unknown）
+func WithBuildInfo(info BuildInfo) Option {
+	return func(r *Router) {
+		if info.Version != "" {
+			r.buildInfo.Version = info.Version
+		}
+		if info.BuildTime != "" {
+			r.buildInfo.BuildTime = info.BuildTime
+		}
+		if info.GitCommit != "" {
+			r.buildInfo.GitCommit = info.GitCommit
+		}
+	}
+}
+
+// WithDatabase 设置 db 对应的 *repository.Database
+// 管理接口通过它调整连接池，必须与 New 传入的 db 为同一连接，当前连接池配置才与实际一致
+func WithDatabase(database *repository.Database) Option {
+	return func(r *Router) {
+		r.database = database
+	}
 }
 
 // New 创建路由器实例
@@ -49,9 +149,10 @@ type Router struct {
 //   - cfg: 应用配置
 //   - db: 数据库连接
 //   - log: 日志记录器
+//   - opts: 可选配置
 //
 // 返回配置好的路由器实例
-func New(cfg *config.Config, db *gorm.DB, log logger.Logger) *Router {
+func New(cfg *config.Config, db *gorm.DB, log logger.Logger, opts ...Option) *Router {
 	// 根据配置设置 Gin 模式
 	switch cfg.App.Mode {
 	case "release":
@@ -65,12 +166,37 @@ func New(cfg *config.Config, db *gorm.DB, log logger.Logger) *Router {
 	// 创建 Gin 引擎
 	engine := gin.New()
 
-	return &Router{
-		engine: engine,
-		config: cfg,
-		db:     db,
-		log:    log,
+	// 只信任配置中的代理转发的客户端 IP，影响 ClientIP() 的结果（限流、审计、日志）
+	// 配置已在加载时校验，这里失败说明配置未经校验，退回到不信任任何代理
+	if err := engine.SetTrustedProxies(cfg.Security.TrustedProxies); err != nil {
+		log.Warn("设置可信代理失败，将不信任任何代理", logger.Err(err))
+		_ = engine.SetTrustedProxies(nil)
+	}
+
+	r := &Router{
+		engine:   engine,
+		config:   cfg,
+		db:       db,
+		log:      log,
+		jobQueue: jobqueue.NewMemoryQueue(cfg.Jobs.Workers, cfg.Jobs.QueueSize),
+		buildInfo: BuildInfo{
+			Version:   cfg.App.Version,
+			BuildTime: "unknown",
+			GitCommit: "unknown",
+		},
+		poolHealth: repository.NewPoolHealthChecker(cfg.Database.Pool.SaturationThreshold),
+		features:   featureflag.New(cfg.Features),
+		eventBus: eventbus.NewMemoryBus(eventbus.WithPanicHandler(func(event eventbus.Event, recovered interface{}) {
+			log.Error("事件订阅者 panic",
+				logger.String("event", event.Name),
+				logger.Any("panic", recovered),
+			)
+		})),
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // Setup 配置路由
@@ -88,122 +214,554 @@ func (r *Router) Setup() *gin.Engine {
 	// 配置路由
 	r.setupRoutes(handlers, authMiddleware)
 
+	// 启动后台任务 worker
+	r.jobQueue.Start()
+
+	// 定期清理超过保留期的软删除用户，提交失败（如队列已满）等下个周期重试
+	if retention := r.config.Security.SoftDeleteRetention(); retention > 0 {
+		r.startScheduler(purgeInterval, func(ctx context.Context) {
+			if _, err := services.Job.SubmitPurgeDeletedUsers(ctx, retention); err != nil {
+				r.log.Warn("提交软删除用户清理任务失败", logger.Err(err))
+			}
+		})
+	}
+
+	// 定期删除已过期或已撤销的刷新令牌记录，避免表无限增长
+	if retention := r.config.Security.RefreshTokenRetention(); retention > 0 {
+		r.startScheduler(purgeInterval, func(ctx context.Context) {
+			deleted, err := repos.RefreshToken.DeleteInactiveBefore(ctx, time.Now().Add(-retention))
+			if err != nil {
+				r.log.Warn("清理失效刷新令牌失败", logger.Err(err))
+				return
+			}
+			if deleted > 0 {
+				r.log.Info("已清理失效刷新令牌", logger.Int64("count", deleted))
+			}
+		})
+	}
+
+	// 定期刷新使用记录日聚合
+	if agg := r.config.RiskReport.DailyAggregate; agg.Enabled {
+		r.startScheduler(agg.RefreshIntervalDuration(), func(ctx context.Context) {
+			if _, err := services.RiskReportUsage.RefreshDailyStats(ctx, &model.RefreshUsageDailyRequest{}); err != nil {
+				r.log.Warn("定期刷新使用记录日聚合失败", logger.Err(err))
+			}
+		})
+	}
+
+	// 定期补发重试队列中的邮件
+	if r.mailRetry != nil {
+		r.startScheduler(r.config.Mail.Retry.IntervalDuration(), r.mailRetry.RetryPending)
+	}
+
 	return r.engine
 }
 
+// Shutdown 释放路由器持有的后台资源
+// 先停止定时任务并写入缓冲中的使用记录，再等待已提交的后台任务执行完毕，ctx 到期时取消仍在执行的任务
+func (r *Router) Shutdown(ctx context.Context) error {
+	if r.stopScheduler != nil {
+		r.stopScheduler()
+		done := make(chan struct{})
+		go func() {
+			r.schedulers.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+	}
+	if r.usageBuffer != nil {
+		if err := r.usageBuffer.Close(ctx); err != nil {
+			r.log.Error("写入缓冲中的使用记录失败", logger.Err(err))
+		}
+	}
+	if r.redisClient != nil {
+		if err := r.redisClient.Close(); err != nil {
+			r.log.Warn("关闭 Redis 连接失败", logger.Err(err))
+		}
+	}
+	if err := r.eventBus.Shutdown(ctx); err != nil {
+		r.log.Error("等待事件订阅者结束超时", logger.Err(err))
+	}
+	return r.jobQueue.Shutdown(ctx)
+}
+
+// EventBus 返回事件总线，用于在 Setup 之前订阅用户生命周期事件（见 service.EventUserDisabled 等）
+func (r *Router) EventBus() eventbus.EventBus {
+	return r.eventBus
+}
+
+// startScheduler 启动定时任务
+// 启动时立即执行一次 run，之后每 interval 执行一次；所有定时任务共用一个上下文，Shutdown 时一并停止
+func (r *Router) startScheduler(interval time.Duration, run func(ctx context.Context)) {
+	if r.stopScheduler == nil {
+		r.schedulerCtx, r.stopScheduler = context.WithCancel(context.Background())
+	}
+	ctx := r.schedulerCtx
+
+	r.schedulers.Add(1)
+	go func() {
+		defer r.schedulers.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			run(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
 // Repositories 仓储层集合
 type Repositories struct {
 	User            repository.UserRepository
+	RefreshToken    repository.RefreshTokenRepository
+	LoginHistory    repository.LoginHistoryRepository
+	UserTag         repository.UserTagRepository
 	RiskReportUsage repository.RiskReportUsageRepository
+	SecurityEvent   repository.SecurityEventRepository
+	PasswordHistory repository.PasswordHistoryRepository
+	UserChangeLog   repository.UserChangeLogRepository
+	PersonalToken   repository.PersonalAccessTokenRepository
 }
 
 // Services 服务层集合
 type Services struct {
 	User            service.UserService
+	UserDetail      service.UserDetailService
+	Job             service.JobService
 	JWT             service.JWTService
 	RiskReportUsage service.RiskReportUsageService
+	PersonalToken   service.PersonalAccessTokenService
+	DataExport      service.DataExportService
+	UserExport      service.UserExportJobService
 }
 
 // Handlers 处理器集合
 type Handlers struct {
 	User            *handler.UserHandler
+	Admin           *handler.AdminHandler
+	Debug           *handler.DebugHandler
 	RiskReportUsage *handler.RiskReportUsageHandler
+	PersonalToken   *handler.PersonalAccessTokenHandler
+	DataExport      *handler.DataExportHandler
+	UserExport      *handler.UserExportHandler
 }
 
 // initRepositories 初始化仓储层
 func (r *Router) initRepositories() *Repositories {
 	return &Repositories{
-		User:            repository.NewUserRepository(r.db),
+		User:            repository.NewUserRepository(r.db, repository.WithDefaultUserSort(r.config.Pagination.DefaultSortBy, r.config.Pagination.DefaultSortOrder)),
+		RefreshToken:    repository.NewRefreshTokenRepository(r.db),
+		LoginHistory:    repository.NewLoginHistoryRepository(r.db),
+		UserTag:         repository.NewUserTagRepository(r.db),
 		RiskReportUsage: repository.NewRiskReportUsageRepository(r.db),
+		SecurityEvent:   repository.NewSecurityEventRepository(r.db),
+		PasswordHistory: repository.NewPasswordHistoryRepository(r.db),
+		UserChangeLog:   repository.NewUserChangeLogRepository(r.db),
+		PersonalToken:   repository.NewPersonalAccessTokenRepository(r.db),
+	}
+}
+
+// newGeoResolver 创建 IP 地理位置解析器
+// 配置了本地 GeoIP 库时从文件加载，加载失败或未配置时使用只识别内网地址的占位实现
+func (r *Router) newGeoResolver() service.GeoResolver {
+	path := r.config.Security.GeoIPDatabase
+	if path == "" {
+		return geoip.StubResolver{}
+	}
+	resolver, err := geoip.LoadCIDRFile(path)
+	if err != nil {
+		r.log.Warn("加载 GeoIP 库失败，异地登录检测退化为 IP 比较", logger.String("path", path), logger.Err(err))
+		return geoip.StubResolver{}
+	}
+	r.log.Info("GeoIP 库加载完成", logger.Int("networks", resolver.Len()))
+	return resolver
+}
+
+// newNotifier 创建用户通知发送器
+// 未启用邮件发送或 SMTP 配置无效时使用只记录日志的实现
+func (r *Router) newNotifier() service.Notifier {
+	if !r.config.Mail.Enabled {
+		return notifier.NewLogNotifier(r.log)
 	}
+	n, err := notifier.NewSMTPNotifier(r.config.Mail, r.config.App.Name)
+	if err != nil {
+		r.log.Warn("初始化邮件发送失败，欢迎邮件只记录日志", logger.Err(err))
+		return notifier.NewLogNotifier(r.log)
+	}
+	r.mailRetry = notifier.NewRetryNotifier(n, r.newBreaker("mail"), notifier.RetryConfig{
+		QueueSize:   r.config.Mail.Retry.QueueSize,
+		MaxAttempts: r.config.Mail.Retry.MaxAttempts,
+	}, r.log)
+	return r.mailRetry
+}
+
+// newBreaker 按配置创建外部依赖的熔断器，状态变化记录日志
+func (r *Router) newBreaker(name string) *breaker.Breaker {
+	cfg := r.config.CircuitBreaker
+	return breaker.New(name,
+		breaker.WithFailureThreshold(cfg.FailureThreshold),
+		breaker.WithOpenTimeout(cfg.OpenTimeoutDuration()),
+		breaker.WithStateChange(func(name string, from, to breaker.State) {
+			fields := []logger.Field{
+				logger.String("breaker", name),
+				logger.String("from", from.String()),
+				logger.String("to", to.String()),
+			}
+			if to == breaker.StateOpen {
+				r.log.Warn("外部依赖熔断开路，调用将快速回退", fields...)
+				return
+			}
+			r.log.Info("外部依赖熔断状态变化", fields...)
+		}),
+	)
+}
+
+// newStorage 创建上传文件的本地存储，存储目录不可用时返回 nil
+func (r *Router) newStorage() *storage.LocalStorage {
+	st, err := storage.NewLocalStorage(r.config.Storage.LocalDir, r.config.Storage.URLPrefix)
+	if err != nil {
+		r.log.Warn("初始化文件存储失败，头像上传不可用", logger.String("dir", r.config.Storage.LocalDir), logger.Err(err))
+		return nil
+	}
+	return st
+}
+
+// newTokenBlacklist 按配置创建令牌黑名单
+// Redis 连接在首次使用时建立，不影响服务启动；Redis 经熔断器访问，不可用时本实例吊销的令牌
+// 仍由内存黑名单拒绝，其他令牌回退到令牌版本校验并记录告警（开启 fail_closed 时返回 503），登出请求失败
+func (r *Router) newTokenBlacklist() tokenblacklist.TokenBlacklist {
+	cfg := r.config.JWT.Blacklist
+	if cfg.Driver != config.TokenBlacklistRedis {
+		return tokenblacklist.NewMemoryBlacklist()
+	}
+	r.redisClient = redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	r.log.Info("令牌黑名单使用 Redis", logger.String("addr", cfg.Redis.Addr))
+	return tokenblacklist.NewBreakerBlacklist(
+		tokenblacklist.NewRedisBlacklist(r.redisClient, cfg.KeyPrefix),
+		tokenblacklist.NewMemoryBlacklist(),
+		r.newBreaker("token_blacklist"),
+		tokenblacklist.WithFailClosed(cfg.FailClosed),
+		tokenblacklist.WithFallbackHandler(func(jti string, err error) {
+			r.log.Warn("令牌黑名单不可用，回退到本地黑名单与令牌版本校验", logger.String("jti", jti), logger.Err(err))
+		}),
+	)
 }
 
 // initServices 初始化服务层
 func (r *Router) initServices(repos *Repositories) *Services {
 	jwtService := service.NewJWTService(&r.config.JWT)
-	userService := service.NewUserService(repos.User, jwtService, r.config, r.log)
-	riskReportUsageService := service.NewRiskReportUsageService(repos.RiskReportUsage, r.log)
+	r.tokenBlacklist = r.newTokenBlacklist()
+	// 用户列表缓存由 UserService 与批量打标任务共用，任务结束后清空
+	userListCache := cache.NewMemoryCache()
+	userOpts := []service.UserServiceOption{
+		service.WithLoginHistoryRepository(repos.LoginHistory),
+		service.WithPasswordHistoryRepository(repos.PasswordHistory),
+		service.WithUserChangeLogRepository(repos.UserChangeLog),
+		service.WithPersonalAccessTokenRepository(repos.PersonalToken),
+		service.WithUserListCache(userListCache, r.config.Cache.UserListTTLDuration()),
+		service.WithEventBus(r.eventBus),
+		service.WithNotifier(r.newNotifier()),
+		service.WithTokenBlacklist(r.tokenBlacklist),
+	}
+	if r.storage = r.newStorage(); r.storage != nil {
+		userOpts = append(userOpts, service.WithAvatarStorage(r.storage))
+	}
+	if r.config.Security.LoginAnomalyDetection {
+		userOpts = append(userOpts, service.WithLoginAnomalyDetection(repos.SecurityEvent, r.newGeoResolver(), nil))
+	}
+	if extraClaims := service.ConfiguredExtraClaims(r.config.JWT.ExtraClaims); extraClaims != nil {
+		userOpts = append(userOpts, service.WithExtraClaims(extraClaims))
+	}
+	userService := service.NewUserService(repos.User, repos.RefreshToken, jwtService, r.config, r.log, userOpts...)
+	userDetailService := service.NewUserDetailService(repos.User, repos.LoginHistory, repos.RefreshToken, repos.UserTag, r.log)
+	var usageOpts []service.RiskReportUsageServiceOption
+	if r.config.RiskReport.Buffer.Enabled {
+		r.usageBuffer = service.NewUsageBuffer(repos.RiskReportUsage, r.config.RiskReport.Buffer, r.log)
+		r.usageBuffer.Start()
+		usageOpts = append(usageOpts, service.WithUsageBuffer(r.usageBuffer))
+	}
+	usageOpts = append(usageOpts, service.WithTxManager(repository.NewTxManager(r.db)))
+	riskReportUsageService := service.NewRiskReportUsageService(repos.RiskReportUsage, r.config, r.log, usageOpts...)
+	jobOpts := []service.JobServiceOption{service.WithJobUserListCache(userListCache)}
+	if r.storage != nil {
+		jobOpts = append(jobOpts, service.WithJobAvatarStorage(r.storage))
+	}
+	jobService := service.NewJobService(r.jobQueue, repos.User, repos.UserTag, r.log, jobOpts...)
 
 	return &Services{
 		User:            userService,
+		UserDetail:      userDetailService,
+		Job:             jobService,
 		JWT:             jwtService,
 		RiskReportUsage: riskReportUsageService,
+		PersonalToken:   service.NewPersonalAccessTokenService(repos.PersonalToken, repos.User, r.log),
+		DataExport: service.NewDataExportService(service.DataExportRepositories{
+			User:            repos.User,
+			UserTag:         repos.UserTag,
+			LoginHistory:    repos.LoginHistory,
+			RefreshToken:    repos.RefreshToken,
+			PersonalToken:   repos.PersonalToken,
+			SecurityEvent:   repos.SecurityEvent,
+			UserChangeLog:   repos.UserChangeLog,
+			RiskReportUsage: repos.RiskReportUsage,
+		}, r.jobQueue, cache.NewMemoryCache(), r.config.Jobs.DataExportTTLDuration(), r.log),
+		UserExport: service.NewUserExportJobService(userService, r.jobQueue, r.config.Jobs.UserExportDir, r.config.Jobs.UserExportTTLDuration(), r.log),
 	}
 }
 
 // initHandlers 初始化处理器
 func (r *Router) initHandlers(services *Services) *Handlers {
+	userHandlerOpts := []handler.UserHandlerOption{handler.WithAvatarMaxSize(r.config.Avatar.MaxSizeBytes())}
+	if r.config.App.IsDebug() {
+		userHandlerOpts = append(userHandlerOpts, handler.WithQueryExplain())
+	}
+	if r.config.JWT.Cookie.Enabled {
+		userHandlerOpts = append(userHandlerOpts, handler.WithAuthCookie(r.config.JWT.Cookie))
+	}
+	var adminHandlerOpts []handler.AdminHandlerOption
+	if r.database != nil {
+		adminHandlerOpts = append(adminHandlerOpts, handler.WithDatabasePool(r.database))
+	}
 	return &Handlers{
-		User:            handler.NewUserHandler(services.User, r.log),
+		User:            handler.NewUserHandler(services.User, services.UserDetail, r.log, userHandlerOpts...),
+		Admin:           handler.NewAdminHandler(services.User, services.Job, r.features, r.log, adminHandlerOpts...),
+		Debug:           handler.NewDebugHandler(services.JWT, r.log),
 		RiskReportUsage: handler.NewRiskReportUsageHandler(services.RiskReportUsage, r.log),
+		PersonalToken:   handler.NewPersonalAccessTokenHandler(services.PersonalToken, r.log),
+		DataExport:      handler.NewDataExportHandler(services.DataExport, r.log),
+		UserExport:      handler.NewUserExportHandler(services.UserExport, r.log),
 	}
 }
 
 // initMiddleware 初始化中间件
 func (r *Router) initMiddleware(services *Services) *middleware.AuthMiddleware {
-	return middleware.NewAuthMiddleware(services.JWT, r.log)
+	opts := []middleware.AuthOption{
+		middleware.WithTokenVersionValidator(services.User),
+		middleware.WithTokenRenewer(services.User),
+		middleware.WithActivityRecorder(services.User),
+		middleware.WithPersonalAccessTokens(services.PersonalToken),
+		middleware.WithTokenBlacklist(r.tokenBlacklist),
+	}
+	if r.config.JWT.Cookie.Enabled {
+		opts = append(opts, middleware.WithAuthCookie(r.config.JWT.Cookie))
+	}
+	return middleware.NewAuthMiddleware(services.JWT, r.log, opts...)
 }
 
 // setupGlobalMiddleware 配置全局中间件
 func (r *Router) setupGlobalMiddleware() {
-	// 恢复中间件（必须第一个）
-	r.engine.Use(middleware.Recovery(r.log))
+	r.globalMiddlewareChain().Apply(r.engine)
+}
+
+// globalMiddlewareChain 按执行顺序构建全局中间件链
+//   - Recovery 必须第一个，才能捕获后续所有中间件的 panic
+//   - ResponseTime 在 Logger 之前，日志 latency 与 X-Response-Time 使用同一个开始时间
+//   - RequestID 在 Logger 之前，日志才能带上请求 ID
+//   - Timeout 在 Logger 之后，日志才能标记因 deadline 到期取消的请求
+//   - Compress 在 Logger 之后，日志中的响应大小为压缩后的实际传输大小
+func (r *Router) globalMiddlewareChain() *middleware.MiddlewareChain {
+	return middleware.NewMiddlewareChain().
+		Use("recovery", middleware.Recovery(r.log)).
+		Use("response_time", middleware.ResponseTime()).
+		Use("request_id", middleware.RequestID()).
+		Use("logger", middleware.LoggerWithConfig(r.log, middleware.LoggerConfig{
+			SlowThreshold: r.config.Log.SlowRequestThresholdDuration(),
+			ExcludePaths:  r.config.Log.AccessLogExcludePaths,
+		})).
+		UseIf(r.config.App.RequestTimeout > 0, "timeout", func() gin.HandlerFunc {
+			return middleware.TimeoutWithConfig(middleware.TimeoutConfig{
+				Timeout:      r.config.App.RequestTimeoutDuration(),
+				ExcludePaths: longRunningRoutes,
+			})
+		}).
+		UseIf(r.config.Response.Compression.Enabled, "compress", func() gin.HandlerFunc {
+			return middleware.Compress(r.config.Response.Compression.MinSize)
+		}).
+		// CORS（支持按路径前缀配置不同策略）
+		UseIf(r.config.Security.CORS.Enabled, "cors", func() gin.HandlerFunc {
+			defaultCORS, policies := r.corsPolicies()
+			return middleware.CORSWithPolicies(defaultCORS, policies)
+		}).
+		Use("secure_headers", middleware.SecureHeaders()).
+		Use("response_naming", middleware.ResponseNaming(r.config.Response.NamingConvention)).
+		Use("locale", middleware.Locale(r.config.Response.DefaultLanguage)).
+		Use("timezone", middleware.Timezone(r.config.Response.DefaultTimezone)).
+		UseIf(r.config.Response.ServerTime, "server_time", middleware.ServerTime).
+		UseIf(r.config.Request.DisallowUnknownFields, "strict_json", middleware.StrictJSON).
+		UseIf(r.config.Request.JSONSchema.Enabled, "json_schema", r.jsonSchemaMiddleware)
+}
+
+// jsonSchemaMiddleware 加载配置中的 schema 并返回请求体校验中间件
+// 加载失败时记录错误并放行所有请求，不影响服务启动
+func (r *Router) jsonSchemaMiddleware() gin.HandlerFunc {
+	cfg := r.config.Request.JSONSchema
+	routes := make([]middleware.SchemaRoute, 0, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		routes = append(routes, middleware.SchemaRoute{Method: route.Method, Path: route.Path, File: route.Schema})
+	}
 
-	// 请求 ID 中间件
-	r.engine.Use(middleware.RequestID())
+	registry, err := middleware.NewSchemaRegistry(cfg.Dir, routes)
+	if err != nil {
+		r.log.Error("加载 JSON Schema 失败，请求体 schema 校验未启用", logger.String("dir", cfg.Dir), logger.Err(err))
+		return func(c *gin.Context) { c.Next() }
+	}
+	r.log.Info("JSON Schema 加载完成", logger.Int("routes", len(routes)))
+	return middleware.JSONSchema(registry)
+}
 
-	// 日志中间件
-	r.engine.Use(middleware.Logger(r.log))
+// corsPolicies 根据配置构建全局 CORS 配置与按路径的策略
+// 策略中未设置的字段沿用全局配置
+func (r *Router) corsPolicies() (middleware.CORSConfig, []middleware.CORSPolicy) {
+	cfg := r.config.Security.CORS
+	defaultCORS := middleware.CORSConfig{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowedMethods:   cfg.AllowedMethods,
+		AllowedHeaders:   cfg.AllowedHeaders,
+		ExposedHeaders:   cfg.ExposedHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	}
 
-	// CORS 中间件
-	if r.config.Security.CORS.Enabled {
-		r.engine.Use(middleware.CORS(middleware.CORSConfig{
-			AllowedOrigins:   r.config.Security.CORS.AllowedOrigins,
-			AllowedMethods:   r.config.Security.CORS.AllowedMethods,
-			AllowedHeaders:   r.config.Security.CORS.AllowedHeaders,
-			ExposedHeaders:   r.config.Security.CORS.ExposedHeaders,
-			AllowCredentials: r.config.Security.CORS.AllowCredentials,
-			MaxAge:           r.config.Security.CORS.MaxAge,
-		}))
+	policies := make([]middleware.CORSPolicy, 0, len(cfg.Policies))
+	for _, p := range cfg.Policies {
+		policyCORS := defaultCORS
+		if len(p.AllowedOrigins) > 0 {
+			policyCORS.AllowedOrigins = p.AllowedOrigins
+		}
+		if len(p.AllowedMethods) > 0 {
+			policyCORS.AllowedMethods = p.AllowedMethods
+		}
+		if len(p.AllowedHeaders) > 0 {
+			policyCORS.AllowedHeaders = p.AllowedHeaders
+		}
+		if len(p.ExposedHeaders) > 0 {
+			policyCORS.ExposedHeaders = p.ExposedHeaders
+		}
+		if p.AllowCredentials != nil {
+			policyCORS.AllowCredentials = *p.AllowCredentials
+		}
+		if p.MaxAge > 0 {
+			policyCORS.MaxAge = p.MaxAge
+		}
+		policies = append(policies, middleware.CORSPolicy{PathPrefix: p.PathPrefix, Config: policyCORS})
 	}
 
-	// 安全响应头
-	r.engine.Use(middleware.SecureHeaders())
+	return defaultCORS, policies
+}
+
+// feature 返回指定功能开关的中间件，开关关闭时端点返回 503
+// 需挂在认证与权限中间件之后，未认证或无权限的请求不应得知功能开关状态
+func (r *Router) feature(name string) gin.HandlerFunc {
+	return middleware.FeatureGate(r.features, name)
 }
 
 // setupRoutes 配置路由
 func (r *Router) setupRoutes(h *Handlers, auth *middleware.AuthMiddleware) {
-	// 首页
-	r.engine.GET("/", r.home)
+	// 首页（公开内容，允许短期缓存）
+	r.engine.GET("/", middleware.CacheControl(middleware.CachePublicShort), r.home)
 
 	// 健康检查端点（不需要认证）
 	r.engine.GET("/health", r.healthCheck)
 	r.engine.GET("/ready", r.readyCheck)
+	r.engine.GET("/version", r.version)
+
+	// 上传文件（URL 前缀为 CDN 等外部地址时由外部提供访问）
+	if r.storage != nil && strings.HasPrefix(r.config.Storage.URLPrefix, "/") {
+		r.engine.Static(r.config.Storage.URLPrefix, r.storage.Dir())
+	}
 
 	// API v1 路由组
 	v1 := r.engine.Group("/api/v1")
 	{
+		// 认证、用户、管理与调试接口返回令牌或用户资料，禁止缓存
+		noStore := middleware.CacheControl(middleware.CacheNoStore)
+		// 修改密码、删除用户等敏感写操作的重放防护
+		replay := r.replayProtection()
+		// 重型列表查询共享的并发数限制
+		heavy := r.concurrencyLimit()
+
 		// 认证相关路由（公开）
 		authGroup := v1.Group("/auth")
+		authGroup.Use(noStore)
 		{
-			authGroup.POST("/register", h.User.Register)
+			authGroup.POST("/register", r.feature("register"), r.registerAntiabuse(), h.User.Register)
 			authGroup.POST("/login", h.User.Login)
 			authGroup.POST("/refresh", h.User.RefreshToken)
+			authGroup.POST("/logout", auth.RequireAuth(), auth.DenyImpersonation(), h.User.Logout)
 		}
 
 		// 用户相关路由
 		usersGroup := v1.Group("/users")
+		usersGroup.Use(noStore)
 		{
 			// 当前用户操作（需要认证）
-			usersGroup.GET("/me", auth.RequireAuth(), h.User.GetCurrentUser)
-			usersGroup.PUT("/me", auth.RequireAuth(), h.User.UpdateCurrentUser)
-			usersGroup.PUT("/me/password", auth.RequireAuth(), h.User.ChangePassword)
+			// RequireAuthScope 的端点同时接受拥有对应授权范围的个人访问令牌
+			usersGroup.GET("/me", auth.RequireAuthScope(model.PermissionProfileRead), h.User.GetCurrentUser)
+			// 模拟登录只用于排查问题，禁止修改资料、密码
+			usersGroup.PUT("/me", auth.RequireAuthScope(model.PermissionProfileUpdate), auth.DenyImpersonation(), h.User.UpdateCurrentUser)
+			usersGroup.PUT("/me/password", auth.RequireAuth(), auth.DenyImpersonation(), replay, h.User.ChangePassword)
+			usersGroup.POST("/me/avatar", auth.RequireAuthScope(model.PermissionProfileUpdate), auth.DenyImpersonation(), h.User.UploadAvatar)
+			usersGroup.GET("/me/permissions", auth.RequireAuthScope(model.PermissionProfileRead), h.User.GetCurrentUserPermissions)
+			// 个人数据导出包含全部个人数据，只接受登录获得的令牌
+			usersGroup.GET("/me/data-export", auth.RequireAuth(), auth.DenyImpersonation(), h.DataExport.Export)
+
+			// 个人访问令牌管理，只能使用登录获得的令牌
+			usersGroup.GET("/me/tokens", auth.RequireAuth(), auth.DenyImpersonation(), h.PersonalToken.List)
+			usersGroup.POST("/me/tokens", auth.RequireAuth(), auth.DenyImpersonation(), h.PersonalToken.Create)
+			usersGroup.DELETE("/me/tokens/:id", auth.RequireAuth(), auth.DenyImpersonation(), h.PersonalToken.Revoke)
 
 			// 用户管理（需要认证）
-			usersGroup.GET("", auth.RequireAuth(), auth.RequireAdmin(), h.User.ListUsers)
-			usersGroup.GET("/:id", auth.RequireAuth(), h.User.GetUser)
-			usersGroup.PUT("/:id", auth.RequireAuth(), auth.RequireAdmin(), h.User.UpdateUser)
-			usersGroup.DELETE("/:id", auth.RequireAuth(), auth.RequireAdmin(), h.User.DeleteUser)
+			usersGroup.GET("", auth.RequireAuthScope(model.PermissionUsersList), auth.RequireAdmin(), heavy, h.User.ListUsers)
+			usersGroup.GET("/lookup", auth.RequireAuthScope(model.PermissionUsersList), auth.RequireAdmin(), h.User.LookupUser)
+			usersGroup.GET("/export", auth.RequireAuthScope(model.PermissionUsersExport), auth.RequireAdmin(), r.feature("user_export"), h.User.ExportUsers)
+			usersGroup.POST("/import", auth.RequireAuthScope(model.PermissionUsersImport), auth.RequireAdmin(), r.feature("user_import"), h.User.ImportUsers)
+			usersGroup.POST("/batch-get", auth.RequireAuthScope(model.PermissionUsersRead), h.User.BatchGetUsers)
+			usersGroup.POST("/batch/role", auth.RequireAuthScope(model.PermissionUsersUpdate), auth.RequireAdmin(), h.User.BatchUpdateRole)
+			usersGroup.POST("/batch/status", auth.RequireAuthScope(model.PermissionUsersUpdate), auth.RequireAdmin(), h.User.BatchUpdateStatus)
+			usersGroup.GET("/:id", auth.RequireAuthScope(model.PermissionUsersRead), h.User.GetUser)
+			usersGroup.GET("/:id/detail", auth.RequireAuthScope(model.PermissionUsersAudit), auth.RequireAdmin(), h.User.GetUserDetail)
+			usersGroup.GET("/:id/online-status", auth.RequireAuthScope(model.PermissionUsersAudit), auth.RequireAdmin(), h.User.GetOnlineStatus)
+			usersGroup.GET("/:id/changelog", auth.RequireAuthScope(model.PermissionUsersAudit), auth.RequireAdmin(), h.User.GetUserChangeLog)
+			usersGroup.PUT("/:id", auth.RequireAuthScope(model.PermissionUsersUpdate), auth.RequireAdmin(), h.User.UpdateUser)
+			usersGroup.DELETE("/:id", auth.RequireAuthScope(model.PermissionUsersDelete), auth.RequireAdmin(), replay, h.User.DeleteUser)
+			usersGroup.POST("/:id/revoke-tokens", auth.RequireAuthScope(model.PermissionTokensRevoke), auth.RequireAdmin(), h.User.RevokeUserTokens)
+		}
+
+		// 管理端路由（需要管理员权限）
+		adminGroup := v1.Group("/admin")
+		adminGroup.Use(noStore, auth.RequireAuth(), auth.DenyImpersonation(), auth.RequireAdmin())
+		{
+			adminGroup.POST("/revoke-all-tokens", h.Admin.RevokeAllTokens)
+			adminGroup.POST("/jobs/batch-tag", h.Admin.SubmitBatchTag)
+			adminGroup.GET("/jobs/:id", h.Admin.GetJob)
+			adminGroup.POST("/exports", r.feature("user_export"), h.UserExport.Submit)
+			adminGroup.GET("/exports/:id", r.feature("user_export"), h.UserExport.Download)
+			adminGroup.GET("/features", h.Admin.ListFeatures)
+			adminGroup.PUT("/features/:name", h.Admin.UpdateFeature)
+			adminGroup.POST("/impersonate/:id", h.Admin.Impersonate)
+			adminGroup.GET("/database/pool", h.Admin.GetDatabasePool)
+			adminGroup.PUT("/database/pool", h.Admin.UpdateDatabasePool)
+		}
+
+		// 调试路由（release 模式下不注册）
+		if r.config.App.Mode != "release" {
+			debugGroup := v1.Group("/debug")
+			debugGroup.Use(noStore)
+			{
+				debugGroup.POST("/token", h.Debug.ParseToken)
+			}
 		}
 
 		// 风险报告使用记录路由（需要 API Key 认证）
@@ -212,12 +770,23 @@ func (r *Router) setupRoutes(h *Handlers, auth *middleware.AuthMiddleware) {
 		riskReportGroup.Use(apiKeyMiddleware.RequireAPIKey())
 		{
 			// 使用记录上报
-			riskReportGroup.POST("/usage", h.RiskReportUsage.Create)
-			riskReportGroup.POST("/usage/batch", h.RiskReportUsage.BatchCreate)
+			usageReport := r.feature("usage_report")
+			riskReportGroup.POST("/usage", usageReport, h.RiskReportUsage.Create)
+			riskReportGroup.POST("/usage/batch", usageReport, h.RiskReportUsage.BatchCreate)
 			// 查询接口（可选，用于数据分析）
-			riskReportGroup.GET("/usage", h.RiskReportUsage.List)
+			riskReportGroup.GET("/usage", heavy, h.RiskReportUsage.List)
+			riskReportGroup.GET("/usage/export", r.feature("usage_export"), h.RiskReportUsage.Export)
 			riskReportGroup.GET("/usage/:id", h.RiskReportUsage.GetByID)
-			riskReportGroup.GET("/usage/stats/:user_id", h.RiskReportUsage.GetUserStats)
+			riskReportGroup.GET("/usage/stats/market-state", heavy, h.RiskReportUsage.GetMarketStateStats)
+			riskReportGroup.GET("/usage/stats/action-suggestion", heavy, h.RiskReportUsage.GetActionSuggestionStats)
+			riskReportGroup.GET("/usage/stats/:user_id", heavy, h.RiskReportUsage.GetUserStats)
+			riskReportGroup.GET("/usage/quota/:user_id", h.RiskReportUsage.GetQuotaUsage)
+			// 修正、删除与历史数据回填（需要管理 API Key）
+			requireAdminKey := apiKeyMiddleware.RequireAdminAPIKey()
+			riskReportGroup.POST("/usage/backfill", requireAdminKey, h.RiskReportUsage.Backfill)
+			riskReportGroup.POST("/usage/daily/refresh", requireAdminKey, h.RiskReportUsage.RefreshDailyStats)
+			riskReportGroup.PUT("/usage/:id", requireAdminKey, h.RiskReportUsage.Update)
+			riskReportGroup.DELETE("/usage/:id", requireAdminKey, h.RiskReportUsage.Delete)
 		}
 	}
 
@@ -228,6 +797,42 @@ func (r *Router) setupRoutes(h *Handlers, auth *middleware.AuthMiddleware) {
 	r.engine.NoMethod(r.methodNotAllowed)
 }
 
+// registerAntiabuse 注册接口的请求指纹防刷中间件，未启用时直接放行
+func (r *Router) registerAntiabuse() gin.HandlerFunc {
+	cfg := r.config.RateLimit.Antiabuse
+	if !cfg.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return middleware.Antiabuse(middleware.AntiabuseConfig{
+		Window:        cfg.WindowDuration(),
+		Threshold:     cfg.Threshold,
+		BlockDuration: cfg.BlockDurationDuration(),
+	}, r.log)
+}
+
+// concurrencyLimit 重型列表查询的并发数限制中间件，未启用时直接放行
+// 返回的中间件挂到多个端点时共享同一组名额
+func (r *Router) concurrencyLimit() gin.HandlerFunc {
+	cfg := r.config.RateLimit.Concurrency
+	if !cfg.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return middleware.ConcurrencyLimit(cfg.MaxConcurrent, middleware.WithQueueTimeout(cfg.QueueTimeout()))
+}
+
+// replayProtection 敏感写操作的请求重放防护中间件，未启用时直接放行
+// 返回的中间件共用一个 nonce 存储，同一 nonce 不能在不同接口间重复使用
+func (r *Router) replayProtection() gin.HandlerFunc {
+	cfg := r.config.Security.ReplayProtection
+	if !cfg.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return middleware.ReplayProtection(middleware.ReplayProtectionConfig{
+		Window: cfg.WindowDuration(),
+		Store:  cache.NewMemoryCache(),
+	}, r.log)
+}
+
 // home 首页处理函数
 func (r *Router) home(c *gin.Context) {
 	// 解析嵌入的模板
@@ -240,7 +845,7 @@ func (r *Router) home(c *gin.Context) {
 	// 渲染模板
 	c.Header("Content-Type", "text/html; charset=utf-8")
 	data := map[string]interface{}{
-		"Version": r.config.App.Version,
+		"Version": r.buildInfo.Version,
 	}
 	if err := tmpl.Execute(c.Writer, data); err != nil {
 		c.String(http.StatusInternalServerError, "模板渲染失败: %v", err)
@@ -250,28 +855,46 @@ func (r *Router) home(c *gin.Context) {
 // healthCheck 健康检查处理函数
 // 返回服务的基本健康状态
 func (r *Router) healthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, model.HealthResponse{
+	response.Raw(c, http.StatusOK, model.HealthResponse{
 		Status:    "healthy",
-		Version:   r.config.App.Version,
+		Version:   r.buildInfo.Version,
 		Timestamp: time.Now(),
 	})
 }
 
+// version 版本信息处理函数
+// 返回编译期注入的版本号、构建时间与 Git 提交哈希
+func (r *Router) version(c *gin.Context) {
+	response.Raw(c, http.StatusOK, model.VersionResponse{
+		Version:   r.buildInfo.Version,
+		BuildTime: r.buildInfo.BuildTime,
+		GitCommit: r.buildInfo.GitCommit,
+		GoVersion: runtime.Version(),
+	})
+}
+
 // readyCheck 就绪检查处理函数
 // 检查服务是否准备好接收流量（包括数据库连接等）
+// 连接池接近饱和或等待连接次数持续增长时返回 status=degraded，
+// 状态码由 database.pool.degraded_status_code 决定
 func (r *Router) readyCheck(c *gin.Context) {
 	// 检查数据库连接
 	dbStatus := "connected"
+	var pool *model.PoolHealth
 	sqlDB, err := r.db.DB()
 	if err != nil {
 		dbStatus = "error: " + err.Error()
-	} else if err := sqlDB.Ping(); err != nil {
-		dbStatus = "error: " + err.Error()
+	} else {
+		// 先取统计再 Ping，避免把 Ping 自身占用的连接计入
+		pool = r.poolHealth.Check(sqlDB.Stats())
+		if err := sqlDB.Ping(); err != nil {
+			dbStatus = "error: " + err.Error()
+		}
 	}
 
 	// 如果数据库不可用，返回 503
 	if dbStatus != "connected" {
-		c.JSON(http.StatusServiceUnavailable, model.ReadyResponse{
+		response.Raw(c, http.StatusServiceUnavailable, model.ReadyResponse{
 			Status:    "not ready",
 			Database:  dbStatus,
 			Timestamp: time.Now(),
@@ -279,9 +902,21 @@ func (r *Router) readyCheck(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, model.ReadyResponse{
+	if pool.Status == model.PoolStatusDegraded {
+		r.log.Warn("数据库连接池降级", logger.Any("reasons", pool.Reasons))
+		response.Raw(c, r.config.Database.Pool.DegradedStatusCode, model.ReadyResponse{
+			Status:    "degraded",
+			Database:  dbStatus,
+			Pool:      pool,
+			Timestamp: time.Now(),
+		})
+		return
+	}
+
+	response.Raw(c, http.StatusOK, model.ReadyResponse{
 		Status:    "ready",
 		Database:  dbStatus,
+		Pool:      pool,
 		Timestamp: time.Now(),
 	})
 }
@@ -0,0 +1,697 @@
+// Package router 提供 HTTP 路由配置
+//
+// 本文件包含路由注册的单元测试
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/repository"
+	"github.com/example/go-user-api/internal/service"
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// newTestEngine 以指定运行模式构建完整路由
+func newTestEngine(t *testing.T, mode string) (*gin.Engine, *config.Config) {
+	t.Helper()
+	return newTestEngineWithOptions(t, mode)
+}
+
+// newTestEngineWithOptions 以指定运行模式和路由器选项构建完整路由
+func newTestEngineWithOptions(t *testing.T, mode string, opts ...Option) (*gin.Engine, *config.Config) {
+	t.Helper()
+
+	cfg := loadTestConfig(t)
+	cfg.App.Mode = mode
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	log, err := logger.New(&logger.Config{Level: "error", Format: "console"})
+	require.NoError(t, err)
+
+	return New(cfg, db, log, opts...).Setup(), cfg
+}
+
+// postDebugToken 请求调试令牌解析端点
+func postDebugToken(engine *gin.Engine, token string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(model.DebugTokenRequest{Token: token})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/debug/token", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestDebugToken_ParsesInDebugMode(t *testing.T) {
+	// 准备
+	engine, cfg := newTestEngine(t, "debug")
+	user := &model.User{Username: "alice", Role: model.RoleUser}
+	user.ID = "user-1"
+	token, err := service.NewJWTService(&cfg.JWT).GenerateAccessToken(user)
+	require.NoError(t, err)
+
+	// 执行
+	w := postDebugToken(engine, token)
+
+	// 断言
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "user-1", resp.Data["user_id"])
+	assert.Equal(t, "alice", resp.Data["username"])
+	assert.Equal(t, "access", resp.Data["token_type"])
+}
+
+func TestDebugToken_NotRegisteredInReleaseMode(t *testing.T) {
+	engine, _ := newTestEngine(t, "release")
+	t.Cleanup(func() { gin.SetMode(gin.TestMode) })
+
+	w := postDebugToken(engine, "any.token.value")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// requestClientIP 以指定的来源地址和 X-Forwarded-For 请求，返回 gin 解析出的客户端 IP
+func requestClientIP(engine *gin.Engine, remoteAddr, forwardedFor string) string {
+	req := httptest.NewRequest(http.MethodGet, "/client-ip", nil)
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("X-Forwarded-For", forwardedFor)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w.Body.String()
+}
+
+func TestTrustedProxies_ParsesForwardedFor(t *testing.T) {
+	// 准备：信任内网代理网段
+	cfg := loadTestConfig(t)
+	cfg.App.Mode = "test"
+	cfg.Security.TrustedProxies = []string{"10.0.0.0/8"}
+
+	log, err := logger.New(&logger.Config{Level: "error", Format: "console"})
+	require.NoError(t, err)
+	engine := New(cfg, nil, log).Engine()
+	engine.GET("/client-ip", func(c *gin.Context) {
+		c.String(http.StatusOK, c.ClientIP())
+	})
+
+	// 来自可信代理：使用 X-Forwarded-For 中的客户端 IP
+	assert.Equal(t, "203.0.113.7", requestClientIP(engine, "10.1.2.3:5000", "203.0.113.7"))
+	// 来自不可信地址：忽略伪造的 X-Forwarded-For
+	assert.Equal(t, "198.51.100.9", requestClientIP(engine, "198.51.100.9:5000", "203.0.113.7"))
+}
+
+func TestTrustedProxies_DefaultLoopbackOnly(t *testing.T) {
+	cfg := loadTestConfig(t)
+	cfg.App.Mode = "test"
+
+	log, err := logger.New(&logger.Config{Level: "error", Format: "console"})
+	require.NoError(t, err)
+	engine := New(cfg, nil, log).Engine()
+	engine.GET("/client-ip", func(c *gin.Context) {
+		c.String(http.StatusOK, c.ClientIP())
+	})
+
+	assert.Equal(t, "203.0.113.7", requestClientIP(engine, "127.0.0.1:5000", "203.0.113.7"))
+	assert.Equal(t, "10.1.2.3", requestClientIP(engine, "10.1.2.3:5000", "203.0.113.7"))
+}
+
+func TestCacheControl_PerEndpoint(t *testing.T) {
+	engine, _ := newTestEngine(t, "debug")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   string
+	}{
+		{"首页允许短期缓存", http.MethodGet, "/", "public, max-age=60"},
+		{"登录接口禁止缓存", http.MethodPost, "/api/v1/auth/login", "no-store"},
+		{"用户资料禁止缓存", http.MethodGet, "/api/v1/users/me", "no-store"},
+		{"管理接口禁止缓存", http.MethodGet, "/api/v1/admin/jobs/1", "no-store"},
+		{"健康检查不设置", http.MethodGet, "/health", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.want, w.Header().Get("Cache-Control"))
+		})
+	}
+}
+
+// ============================================================
+// 版本信息端点测试
+// ============================================================
+
+func TestVersion_ReturnsInjectedBuildInfo(t *testing.T) {
+	engine, _ := newTestEngineWithOptions(t, "test", WithBuildInfo(BuildInfo{
+		Version:   "v1.2.3",
+		BuildTime: "2024-03-01T08:00:00Z",
+		GitCommit: "abc1234",
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/version", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp model.VersionResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "v1.2.3", resp.Version)
+	assert.Equal(t, "2024-03-01T08:00:00Z", resp.BuildTime)
+	assert.Equal(t, "abc1234", resp.GitCommit)
+	assert.NotEmpty(t, resp.GoVersion)
+
+	// 健康检查使用同一版本号
+	req = httptest.NewRequest(http.MethodGet, "/health", nil)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	var health model.HealthResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &health))
+	assert.Equal(t, "v1.2.3", health.Version)
+}
+
+func TestVersion_DefaultsToConfigVersion(t *testing.T) {
+	engine, cfg := newTestEngine(t, "test")
+
+	req := httptest.NewRequest(http.MethodGet, "/version", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp model.VersionResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, cfg.App.Version, resp.Version)
+	assert.Equal(t, "unknown", resp.GitCommit)
+}
+
+func TestRiskReportUsage_WriteRequiresAdminAPIKey(t *testing.T) {
+	// 准备
+	engine, cfg := newTestEngine(t, "debug")
+	cfg.RiskReport.APIKeys = []string{"reporter-key-0001"}
+	cfg.RiskReport.AdminAPIKeys = []string{"admin-key-0001"}
+
+	perform := func(method, apiKey string) int {
+		req := httptest.NewRequest(method, "/api/v1/risk-report/usage/usage-1", bytes.NewReader([]byte(`{}`)))
+		req.Header.Set("Content-Type", "application/json")
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		// 缺少 API Key
+		assert.Equal(t, http.StatusUnauthorized, perform(method, ""), method)
+		// 普通 API Key 无权修改
+		assert.Equal(t, http.StatusForbidden, perform(method, "reporter-key-0001"), method)
+		// 管理 API Key 通过权限检查
+		code := perform(method, "admin-key-0001")
+		assert.NotEqual(t, http.StatusUnauthorized, code, method)
+		assert.NotEqual(t, http.StatusForbidden, code, method)
+	}
+}
+
+// newPoolTestRouter 构建连接池最多 10 个连接的路由，并占用其中 inUse 个
+func newPoolTestRouter(t *testing.T, inUse int, degradedStatusCode int) *gin.Engine {
+	t.Helper()
+
+	cfg := loadTestConfig(t)
+	cfg.App.Mode = "test"
+	cfg.Database.Pool.DegradedStatusCode = degradedStatusCode
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(10)
+
+	ctx := context.Background()
+	for i := 0; i < inUse; i++ {
+		conn, err := sqlDB.Conn(ctx)
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	log, err := logger.New(&logger.Config{Level: "error", Format: "console"})
+	require.NoError(t, err)
+	return New(cfg, db, log).Setup()
+}
+
+// getReady 请求就绪检查端点
+func getReady(t *testing.T, engine *gin.Engine) (int, model.ReadyResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+	var resp model.ReadyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestReady_PoolHealthy(t *testing.T) {
+	engine := newPoolTestRouter(t, 2, http.StatusOK)
+
+	code, resp := getReady(t, engine)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", resp.Status)
+	require.NotNil(t, resp.Pool)
+	assert.Equal(t, model.PoolStatusHealthy, resp.Pool.Status)
+	assert.Equal(t, 10, resp.Pool.MaxOpenConnections)
+}
+
+func TestReady_PoolSaturatedDegraded(t *testing.T) {
+	// 10 个连接占用 9 个，达到默认阈值 90%
+	engine := newPoolTestRouter(t, 9, http.StatusOK)
+
+	code, resp := getReady(t, engine)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "degraded", resp.Status)
+	assert.Equal(t, "connected", resp.Database)
+	require.NotNil(t, resp.Pool)
+	assert.Equal(t, model.PoolStatusDegraded, resp.Pool.Status)
+	assert.Equal(t, 9, resp.Pool.InUse)
+	assert.NotEmpty(t, resp.Pool.Reasons)
+}
+
+func TestReady_PoolSaturatedConfigured503(t *testing.T) {
+	engine := newPoolTestRouter(t, 9, http.StatusServiceUnavailable)
+
+	code, resp := getReady(t, engine)
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "degraded", resp.Status)
+}
+
+func TestGlobalMiddlewareChain_Order(t *testing.T) {
+	// 准备
+	cfg := loadTestConfig(t)
+	cfg.Security.CORS.Enabled = true
+	cfg.App.RequestTimeout = 8
+	log, err := logger.New(&logger.Config{Level: "error", Format: "console"})
+	require.NoError(t, err)
+	r := New(cfg, nil, log)
+
+	// 执行
+	chain := r.globalMiddlewareChain()
+
+	// 断言：Recovery 在最前，Logger 在 RequestID 之后，Timeout 在 Logger 之后
+	assert.Equal(t, []string{"recovery", "response_time", "request_id", "logger", "timeout", "compress", "cors", "secure_headers", "response_naming", "locale", "timezone"}, chain.Names())
+	assert.Equal(t, 0, chain.Index("recovery"))
+	assert.Greater(t, chain.Index("logger"), chain.Index("request_id"))
+	assert.Greater(t, chain.Index("timeout"), chain.Index("logger"))
+
+	// CORS、压缩、请求超时关闭时不注册，其余顺序不变
+	cfg.Security.CORS.Enabled = false
+	cfg.Response.Compression.Enabled = false
+	cfg.App.RequestTimeout = 0
+	assert.Equal(t, []string{"recovery", "response_time", "request_id", "logger", "secure_headers", "response_naming", "locale", "timezone"}, r.globalMiddlewareChain().Names())
+}
+
+// newAuthTestEngine 构建完成数据表迁移的路由，创建指定角色的用户并签发访问令牌
+// configure 可在构建路由前调整配置
+func newAuthTestEngine(t *testing.T, role string, configure ...func(cfg *config.Config)) (*gin.Engine, *model.User, string) {
+	t.Helper()
+
+	cfg := loadTestConfig(t)
+	cfg.App.Mode = "test"
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	db := openAuthTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.User{}, &model.UserTag{}, &model.PersonalAccessToken{}, &model.RefreshToken{}))
+
+	user := &model.User{Username: "alice", Email: "alice@example.com", Password: "hashed", Status: model.UserStatusActive, Role: role}
+	require.NoError(t, db.Create(user).Error)
+	accessToken, err := service.NewJWTService(&cfg.JWT).GenerateAccessToken(user)
+	require.NoError(t, err)
+
+	return newReplicaEngine(t, cfg), user, accessToken
+}
+
+// loadTestConfig 加载默认配置，JWT 密钥没有默认值，通过环境变量提供
+func loadTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	t.Setenv("APP_JWT_SECRET", "router-test-secret-key")
+	cfg, err := config.Load("")
+	require.NoError(t, err)
+	// 导出文件写入临时目录，不在源码目录中留下文件
+	cfg.Jobs.UserExportDir = t.TempDir()
+	return cfg
+}
+
+// openAuthTestDB 打开以测试名命名的共享内存数据库，同一测试中多次打开得到同一个库
+func openAuthTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// newReplicaEngine 使用同一配置与共享数据库构建一个独立的路由，模拟多副本部署中的另一个实例
+func newReplicaEngine(t *testing.T, cfg *config.Config) *gin.Engine {
+	t.Helper()
+
+	log, err := logger.New(&logger.Config{Level: "error", Format: "console"})
+	require.NoError(t, err)
+	db := openAuthTestDB(t)
+	r := New(cfg, db, log, WithDatabase(repository.WrapDatabase(db, &cfg.Database)))
+	engine := r.Setup()
+	t.Cleanup(func() { _ = r.Shutdown(context.Background()) })
+	return engine
+}
+
+// performWithToken 携带 Bearer 令牌请求接口
+func performWithToken(engine *gin.Engine, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestPersonalAccessToken_AuthenticateAndRevoke(t *testing.T) {
+	// 准备
+	engine, user, accessToken := newAuthTestEngine(t, model.RoleUser)
+
+	// 执行：使用登录令牌创建只读 PAT
+	w := performWithToken(engine, http.MethodPost, "/api/v1/users/me/tokens", accessToken, model.CreatePersonalAccessTokenRequest{
+		Name:   "backup script",
+		Scopes: []string{model.PermissionProfileRead},
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data model.CreatePersonalAccessTokenResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	pat := created.Data.Token
+	require.True(t, service.IsPersonalAccessToken(pat))
+	assert.Equal(t, pat[:len(created.Data.TokenPrefix)], created.Data.TokenPrefix)
+
+	// 断言：PAT 可访问授权范围内的接口
+	w = performWithToken(engine, http.MethodGet, "/api/v1/users/me", pat, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var me struct {
+		Data model.UserResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &me))
+	assert.Equal(t, user.ID, me.Data.ID)
+
+	// 断言：授权范围外的接口与令牌管理接口拒绝 PAT
+	w = performWithToken(engine, http.MethodPut, "/api/v1/users/me", pat, map[string]string{"nickname": "x"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = performWithToken(engine, http.MethodGet, "/api/v1/users/me/tokens", pat, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 执行：撤销
+	w = performWithToken(engine, http.MethodDelete, "/api/v1/users/me/tokens/"+created.Data.ID, accessToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// 断言：撤销后立即失效，列表中不再出现
+	w = performWithToken(engine, http.MethodGet, "/api/v1/users/me", pat, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = performWithToken(engine, http.MethodGet, "/api/v1/users/me/tokens", accessToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Data []model.PersonalAccessTokenResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Empty(t, list.Data)
+}
+
+func TestLogout_RevokesAccessTokenOnAllReplicas(t *testing.T) {
+	// 准备：两个副本共享数据库与 Redis 黑名单
+	mr := miniredis.RunT(t)
+	useRedisBlacklist := func(cfg *config.Config) {
+		cfg.JWT.Blacklist.Driver = config.TokenBlacklistRedis
+		cfg.JWT.Blacklist.Redis.Addr = mr.Addr()
+	}
+	replicaA, _, accessToken := newAuthTestEngine(t, model.RoleUser, useRedisBlacklist)
+	cfg := loadTestConfig(t)
+	cfg.App.Mode = "test"
+	useRedisBlacklist(cfg)
+	replicaB := newReplicaEngine(t, cfg)
+
+	w := performWithToken(replicaB, http.MethodGet, "/api/v1/users/me", accessToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// 执行：在副本 A 登出
+	w = performWithToken(replicaA, http.MethodPost, "/api/v1/auth/logout", accessToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// 断言：两个副本都拒绝已吊销的访问令牌
+	w = performWithToken(replicaA, http.MethodGet, "/api/v1/users/me", accessToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = performWithToken(replicaB, http.MethodGet, "/api/v1/users/me", accessToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestUserLookup(t *testing.T) {
+	// 准备
+	engine, user, accessToken := newAuthTestEngine(t, model.RoleAdmin)
+
+	tests := []struct {
+		name     string
+		query    string
+		wantCode int
+	}{
+		{"邮箱精确命中", "email=alice@example.com", http.StatusOK},
+		{"用户名精确命中", "username=alice", http.StatusOK},
+		{"部分匹配不算命中", "username=ali", http.StatusNotFound},
+		{"邮箱不存在", "email=bob@example.com", http.StatusNotFound},
+		{"未提供条件", "", http.StatusBadRequest},
+		{"同时提供两个条件", "email=alice@example.com&username=alice", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 执行
+			w := performWithToken(engine, http.MethodGet, "/api/v1/users/lookup?"+tt.query, accessToken, nil)
+
+			// 断言
+			require.Equal(t, tt.wantCode, w.Code, w.Body.String())
+			if tt.wantCode == http.StatusOK {
+				var resp struct {
+					Data model.UserResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, user.ID, resp.Data.ID)
+				assert.Equal(t, "alice@example.com", resp.Data.Email)
+			}
+		})
+	}
+}
+
+func TestUserLookup_RequiresAdmin(t *testing.T) {
+	engine, _, accessToken := newAuthTestEngine(t, model.RoleUser)
+
+	w := performWithToken(engine, http.MethodGet, "/api/v1/users/lookup?username=alice", accessToken, nil)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestFeatureGate_CheckedAfterAuth(t *testing.T) {
+	disableExport := func(cfg *config.Config) {
+		cfg.Features = map[string]bool{"user_export": false}
+	}
+
+	// 非管理员先被权限拒绝，不暴露功能开关状态
+	t.Run("user", func(t *testing.T) {
+		engine, _, accessToken := newAuthTestEngine(t, model.RoleUser, disableExport)
+		w := performWithToken(engine, http.MethodGet, "/api/v1/users/export", accessToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	// 管理员通过权限检查后才看到功能已关闭
+	t.Run("admin", func(t *testing.T) {
+		engine, _, accessToken := newAuthTestEngine(t, model.RoleAdmin, disableExport)
+		w := performWithToken(engine, http.MethodGet, "/api/v1/users/export", accessToken, nil)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+func TestPersonalAccessToken_ScopeBeyondPermissionsRejected(t *testing.T) {
+	engine, _, accessToken := newAuthTestEngine(t, model.RoleUser)
+
+	// 普通用户不能创建带管理权限的令牌
+	w := performWithToken(engine, http.MethodPost, "/api/v1/users/me/tokens", accessToken, model.CreatePersonalAccessTokenRequest{
+		Name:   "admin script",
+		Scopes: []string{model.PermissionUsersList},
+	})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAdminDatabasePool_Update(t *testing.T) {
+	// 准备
+	engine, _, accessToken := newAuthTestEngine(t, model.RoleAdmin)
+
+	// 执行：只调整最大连接数
+	w := performWithToken(engine, http.MethodPut, "/api/v1/admin/database/pool", accessToken, map[string]int{"max_open_conns": 7, "max_idle_conns": 3})
+
+	// 断言
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data model.DatabasePoolResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 7, resp.Data.MaxOpenConns)
+	assert.Equal(t, 3, resp.Data.MaxIdleConns)
+	assert.EqualValues(t, 7, resp.Data.Stats["max_open_connections"])
+
+	// 不合理的值被拒绝，配置保持不变
+	w = performWithToken(engine, http.MethodPut, "/api/v1/admin/database/pool", accessToken, map[string]int{"max_idle_conns": 8})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performWithToken(engine, http.MethodGet, "/api/v1/admin/database/pool", accessToken, nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 7, resp.Data.MaxOpenConns)
+}
+
+func TestRiskReportUsage_ListOmitsAIResponseByDefault(t *testing.T) {
+	// 准备
+	cfg := loadTestConfig(t)
+	cfg.App.Mode = "test"
+	cfg.RiskReport.APIKeys = []string{"reporter-key-0001"}
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	require.NoError(t, db.AutoMigrate(&model.RiskReportUsage{}))
+
+	now := time.Now()
+	usage := &model.RiskReportUsage{
+		UserID:       "user-1",
+		Ticker:       "AAPL",
+		RequestTime:  now,
+		ResponseTime: now.Add(time.Second),
+		TotalTokens:  10,
+		AIResponse:   "包含敏感信息的完整分析",
+	}
+	require.NoError(t, db.Create(usage).Error)
+
+	log, err := logger.New(&logger.Config{Level: "error", Format: "console"})
+	require.NoError(t, err)
+	engine := New(cfg, db, log).Setup()
+
+	get := func(path string) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-API-Key", "reporter-key-0001")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body["data"].(map[string]interface{})
+	}
+	firstItem := func(data map[string]interface{}) map[string]interface{} {
+		items := data["list"].([]interface{})
+		require.Len(t, items, 1)
+		return items[0].(map[string]interface{})
+	}
+
+	// 断言：列表默认不含全文
+	item := firstItem(get("/api/v1/risk-report/usage"))
+	assert.NotContains(t, item, "ai_response")
+	assert.Equal(t, true, item["ai_response_omitted"])
+
+	// 断言：显式请求时列表返回全文
+	item = firstItem(get("/api/v1/risk-report/usage?include_response=true"))
+	assert.Equal(t, usage.AIResponse, item["ai_response"])
+	assert.NotContains(t, item, "ai_response_omitted")
+
+	// 断言：详情接口返回全文
+	detail := get("/api/v1/risk-report/usage/" + usage.ID)
+	assert.Equal(t, usage.AIResponse, detail["ai_response"])
+}
+
+func TestAdminUserExport_SubmitThenDownload(t *testing.T) {
+	// 准备
+	engine, _, accessToken := newAuthTestEngine(t, model.RoleAdmin)
+
+	// 执行：提交导出任务
+	w := performWithToken(engine, http.MethodPost, "/api/v1/admin/exports?columns=username,email", accessToken, nil)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var submitted struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &submitted))
+	require.NotEmpty(t, submitted.Data.ID)
+
+	// 断言：任务完成后下载到导出文件
+	var download *httptest.ResponseRecorder
+	require.Eventually(t, func() bool {
+		download = performWithToken(engine, http.MethodGet, "/api/v1/admin/exports/"+submitted.Data.ID, accessToken, nil)
+		return download.Code == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "text/csv; charset=utf-8", download.Header().Get("Content-Type"))
+	assert.Contains(t, download.Header().Get("Content-Disposition"), "attachment")
+	assert.Equal(t, "username,email\nalice,alice@example.com\n", download.Body.String())
+}
+
+func TestAdminUserExport_UnknownJob(t *testing.T) {
+	engine, _, accessToken := newAuthTestEngine(t, model.RoleAdmin)
+
+	w := performWithToken(engine, http.MethodGet, "/api/v1/admin/exports/missing", accessToken, nil)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
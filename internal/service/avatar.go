@@ -0,0 +1,210 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件实现了头像上传与缩略图生成。
+// 图片先读取头部检查宽高再完整解码，避免超大图片耗尽内存；处理过程受并发数与超时限制。
+// 原图与按配置边长居中裁剪的 PNG 缩略图通过 Storage 保存，URL 写回用户资料；
+// 重新上传、永久删除用户时删除旧的头像文件。
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"  // 注册 GIF 解码器
+	_ "image/jpeg" // 注册 JPEG 解码器
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/example/go-user-api/pkg/storage"
+	"github.com/google/uuid"
+	"golang.org/x/image/draw"
+)
+
+// avatarKeyPrefix 头像文件在存储中的路径前缀
+const avatarKeyPrefix = "avatars"
+
+// WithAvatarStorage 设置头像存储
+// 未设置时头像上传接口返回服务不可用
+func WithAvatarStorage(st storage.Storage) UserServiceOption {
+	return func(s *userService) {
+		s.avatarStorage = st
+		slots := s.config.Avatar.MaxConcurrent
+		if slots < 1 {
+			slots = 1
+		}
+		s.avatarSlots = make(chan struct{}, slots)
+	}
+}
+
+// UploadAvatar 上传头像并生成缩略图
+// 每次上传使用新的存储路径，URL 随之变化，客户端与 CDN 不会拿到旧的缓存；
+// 新头像写回用户资料后删除旧头像文件
+func (s *userService) UploadAvatar(ctx context.Context, id string, r io.Reader) (*model.User, error) {
+	if s.avatarStorage == nil {
+		return nil, errors.ErrServiceUnavailable.WithMessage("头像上传未启用")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := s.config.Avatar
+	data, err := io.ReadAll(io.LimitReader(r, cfg.MaxSizeBytes()+1))
+	if err != nil {
+		return nil, errors.ErrBadRequest.WithMessage("读取头像文件失败").WithError(err)
+	}
+	if int64(len(data)) > cfg.MaxSizeBytes() {
+		return nil, errors.ErrBadRequest.WithMessage(fmt.Sprintf("头像文件不能超过 %dMB", cfg.MaxSize))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.ProcessTimeoutDuration())
+	defer cancel()
+
+	format, thumbnails, err := s.generateThumbnails(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	base := fmt.Sprintf("%s/%s/%s", avatarKeyPrefix, id, uuid.NewString())
+	avatarURL, err := s.avatarStorage.Put(ctx, base+"/original."+format, data, "image/"+format)
+	if err != nil {
+		s.log.Error("保存头像失败", logger.String("user_id", id), logger.Err(err))
+		return nil, errors.ErrInternalServer.WithError(err)
+	}
+	urls := make(model.AvatarThumbnails, len(thumbnails))
+	for size, thumbnail := range thumbnails {
+		url, err := s.avatarStorage.Put(ctx, fmt.Sprintf("%s/%d.png", base, size), thumbnail, "image/png")
+		if err != nil {
+			s.log.Error("保存头像缩略图失败", logger.String("user_id", id), logger.Int("size", size), logger.Err(err))
+			return nil, errors.ErrInternalServer.WithError(err)
+		}
+		urls[strconv.Itoa(size)] = url
+	}
+
+	oldKeys := avatarKeys(user)
+	updated, err := s.applyUpdates(ctx, user, map[string]interface{}{
+		"avatar":            avatarURL,
+		"avatar_thumbnails": urls,
+	}, id)
+	if err != nil {
+		return nil, err
+	}
+	deleteAvatarFiles(context.WithoutCancel(ctx), s.avatarStorage, oldKeys, s.log)
+	return updated, nil
+}
+
+// avatarKeys 从用户的头像 URL 还原上传时的存储 key
+// 只识别本服务上传的头像（路径含 avatars/<用户 ID>/），用户填写的外部头像 URL 被忽略
+func avatarKeys(user *model.User) []string {
+	marker := fmt.Sprintf("%s/%s/", avatarKeyPrefix, user.ID)
+	var keys []string
+	add := func(url string) {
+		if i := strings.Index(url, marker); i >= 0 {
+			keys = append(keys, url[i:])
+		}
+	}
+	add(user.Avatar)
+	for _, url := range user.AvatarThumbnails {
+		add(url)
+	}
+	return keys
+}
+
+// deleteAvatarFiles 删除头像文件，st 为 nil 时不做任何事，失败只记录日志
+// 数据库已不再引用这些文件，删除失败只会残留无用文件
+func deleteAvatarFiles(ctx context.Context, st storage.Storage, keys []string, log logger.Logger) {
+	if st == nil {
+		return
+	}
+	for _, key := range keys {
+		if err := st.Delete(ctx, key); err != nil {
+			log.Warn("删除头像文件失败", logger.String("key", key), logger.Err(err))
+		}
+	}
+}
+
+// generateThumbnails 在并发数限制内生成缩略图，返回原图格式（jpeg、png、gif）与各边长的 PNG 数据
+// 超时后立即返回，后台的处理结束时才释放并发名额，超时的请求堆积时新请求会排队而不是继续占用内存
+func (s *userService) generateThumbnails(ctx context.Context, data []byte) (string, map[int][]byte, error) {
+	select {
+	case s.avatarSlots <- struct{}{}:
+	case <-ctx.Done():
+		return "", nil, errors.ErrServiceUnavailable.WithMessage("头像处理繁忙，请稍后重试")
+	}
+
+	type result struct {
+		format     string
+		thumbnails map[int][]byte
+		err        error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() { <-s.avatarSlots }()
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{err: errors.ErrInvalidImage.WithDetail(fmt.Sprint(r))}
+			}
+		}()
+		format, thumbnails, err := renderThumbnails(ctx, data, s.config.Avatar.ThumbnailSizes, s.config.Avatar.MaxDimension)
+		done <- result{format: format, thumbnails: thumbnails, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.format, res.thumbnails, res.err
+	case <-ctx.Done():
+		return "", nil, errors.ErrServiceUnavailable.WithMessage("头像处理超时，请稍后重试")
+	}
+}
+
+// renderThumbnails 解码图片并按 sizes 生成正方形 PNG 缩略图
+// 宽或高超过 maxDimension 时不解码直接拒绝；非正方形图片居中裁剪
+func renderThumbnails(ctx context.Context, data []byte, sizes []int, maxDimension int) (string, map[int][]byte, error) {
+	imgCfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, errors.ErrInvalidImage.WithMessage("无法识别的图片，仅支持 JPEG、PNG、GIF")
+	}
+	if imgCfg.Width < 1 || imgCfg.Height < 1 || imgCfg.Width > maxDimension || imgCfg.Height > maxDimension {
+		return "", nil, errors.ErrInvalidImage.WithMessage(fmt.Sprintf("图片宽高不能超过 %d 像素", maxDimension))
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, errors.ErrInvalidImage.WithMessage("图片已损坏，无法解码")
+	}
+	crop := centerSquare(src.Bounds())
+
+	thumbnails := make(map[int][]byte, len(sizes))
+	for _, size := range sizes {
+		if err := ctx.Err(); err != nil {
+			return "", nil, err
+		}
+		dst := image.NewRGBA(image.Rect(0, 0, size, size))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, dst); err != nil {
+			return "", nil, errors.ErrInternalServer.WithError(err)
+		}
+		thumbnails[size] = buf.Bytes()
+	}
+	return format, thumbnails, nil
+}
+
+// centerSquare 返回矩形居中的最大正方形区域
+func centerSquare(r image.Rectangle) image.Rectangle {
+	side := r.Dx()
+	if r.Dy() < side {
+		side = r.Dy()
+	}
+	x := r.Min.X + (r.Dx()-side)/2
+	y := r.Min.Y + (r.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
@@ -0,0 +1,219 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含头像上传与缩略图生成的单元测试
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStorage 保存在内存中的存储，用于检查写入的文件
+type memoryStorage struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{files: make(map[string][]byte)}
+}
+
+func (m *memoryStorage) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[key] = data
+	return "/uploads/" + key, nil
+}
+
+func (m *memoryStorage) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, key)
+	return nil
+}
+
+// newAvatarTestConfig 返回头像相关配置齐全的测试配置
+func newAvatarTestConfig() *config.Config {
+	cfg := newTestConfig()
+	cfg.Avatar = config.AvatarConfig{
+		MaxSize:        1,
+		MaxDimension:   64,
+		ThumbnailSizes: []int{8, 16, 32},
+		ProcessTimeout: 5,
+		MaxConcurrent:  1,
+	}
+	return cfg
+}
+
+// newAvatarTestImage 生成 w×h 的 PNG 测试图片
+func newAvatarTestImage(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 8), G: uint8(y * 8), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestUserService_UploadAvatar_GeneratesThumbnails(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	store := newMemoryStorage()
+	cfg := newAvatarTestConfig()
+	svc := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger(), WithAvatarStorage(store))
+	ctx := context.Background()
+	user := newTestUser()
+	updated := newTestUser()
+
+	// 设置 mock 期望
+	var fields map[string]interface{}
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil).Once()
+	mockRepo.On("UpdateFields", mock.Anything, user.ID, mock.AnythingOfType("map[string]interface {}")).
+		Run(func(args mock.Arguments) { fields = args.Get(2).(map[string]interface{}) }).
+		Return(nil)
+	mockRepo.On("GetByID", mock.Anything, user.ID).Return(updated, nil).Once()
+
+	// 执行：非正方形图片
+	result, err := svc.UploadAvatar(ctx, user.ID, bytes.NewReader(newAvatarTestImage(t, 40, 20)))
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, updated, result)
+	require.NotNil(t, fields)
+
+	thumbnails := fields["avatar_thumbnails"].(model.AvatarThumbnails)
+	require.Len(t, thumbnails, 3)
+	assert.Len(t, store.files, 4, "原图与 3 张缩略图")
+	for _, size := range cfg.Avatar.ThumbnailSizes {
+		url := thumbnails[strconv.Itoa(size)]
+		require.NotEmpty(t, url)
+		img, err := png.Decode(bytes.NewReader(store.files[url[len("/uploads/"):]]))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, size, size), img.Bounds())
+	}
+	assert.Regexp(t, `^/uploads/avatars/test-user-id/[0-9a-f-]{36}/original\.png$`, fields["avatar"])
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_UploadAvatar_Rejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     func(t *testing.T) []byte
+		expected *errors.AppError
+	}{
+		{name: "不是图片", data: func(t *testing.T) []byte { return []byte("not an image") }, expected: errors.ErrInvalidImage},
+		{name: "尺寸超限", data: func(t *testing.T) []byte { return newAvatarTestImage(t, 65, 10) }, expected: errors.ErrInvalidImage},
+		{name: "文件过大", data: func(t *testing.T) []byte { return make([]byte, 1<<20+1) }, expected: errors.ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 准备
+			mockRepo := new(MockUserRepository)
+			store := newMemoryStorage()
+			cfg := newAvatarTestConfig()
+			svc := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger(), WithAvatarStorage(store))
+			ctx := context.Background()
+			user := newTestUser()
+
+			// 设置 mock 期望
+			mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+
+			// 执行
+			_, err := svc.UploadAvatar(ctx, user.ID, bytes.NewReader(tt.data(t)))
+
+			// 断言：不保存任何文件，也不更新用户
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.expected))
+			assert.Empty(t, store.files)
+			mockRepo.AssertNotCalled(t, "UpdateFields", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUserService_UploadAvatar_StorageNotConfigured(t *testing.T) {
+	// 准备
+	cfg := newAvatarTestConfig()
+	svc := NewUserService(new(MockUserRepository), new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	// 执行
+	_, err := svc.UploadAvatar(context.Background(), "test-user-id", bytes.NewReader(nil))
+
+	// 断言
+	assert.True(t, errors.Is(err, errors.ErrServiceUnavailable))
+}
+
+func TestUserService_UploadAvatar_DeletesPreviousFiles(t *testing.T) {
+	// 准备：用户已有上传过的头像与一个无关文件
+	mockRepo := new(MockUserRepository)
+	store := newMemoryStorage()
+	cfg := newAvatarTestConfig()
+	svc := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger(), WithAvatarStorage(store))
+	ctx := context.Background()
+
+	oldBase := "avatars/test-user-id/old"
+	for _, key := range []string{oldBase + "/original.png", oldBase + "/8.png", "avatars/other-user/a/original.png"} {
+		_, err := store.Put(ctx, key, []byte("old"), "image/png")
+		require.NoError(t, err)
+	}
+	user := newTestUser()
+	user.Avatar = "/uploads/" + oldBase + "/original.png"
+	user.AvatarThumbnails = model.AvatarThumbnails{"8": "/uploads/" + oldBase + "/8.png"}
+
+	// 设置 mock 期望
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil).Once()
+	mockRepo.On("UpdateFields", mock.Anything, user.ID, mock.AnythingOfType("map[string]interface {}")).Return(nil)
+	mockRepo.On("GetByID", mock.Anything, user.ID).Return(newTestUser(), nil).Once()
+
+	// 执行
+	_, err := svc.UploadAvatar(ctx, user.ID, bytes.NewReader(newAvatarTestImage(t, 16, 16)))
+
+	// 断言：旧头像文件删除，新头像与其他用户的文件保留
+	require.NoError(t, err)
+	assert.NotContains(t, store.files, oldBase+"/original.png")
+	assert.NotContains(t, store.files, oldBase+"/8.png")
+	assert.Contains(t, store.files, "avatars/other-user/a/original.png")
+	assert.Len(t, store.files, 5, "新的原图与 3 张缩略图，加上其他用户的文件")
+}
+
+func TestUserService_HardDelete_DeletesAvatarFiles(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	store := newMemoryStorage()
+	cfg := newAvatarTestConfig()
+	svc := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger(), WithAvatarStorage(store))
+	ctx := context.Background()
+
+	key := "avatars/test-user-id/a/original.png"
+	_, err := store.Put(ctx, key, []byte("avatar"), "image/png")
+	require.NoError(t, err)
+	user := newTestUser()
+	user.Avatar = "https://cdn.example.com/uploads/" + key
+
+	// 设置 mock 期望
+	mockRepo.On("HardDelete", ctx, user.ID).Return(user, nil)
+
+	// 执行
+	require.NoError(t, svc.HardDelete(ctx, user.ID))
+
+	// 断言：URL 前缀为 CDN 地址时同样能找到存储 key
+	assert.Empty(t, store.files)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,272 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件实现了用户个人数据导出（GDPR 数据可携带权）。
+// 导出聚合资料、登录历史、会话、个人访问令牌、安全事件、变更记录与风险报告使用记录，
+// 数据量可能较大，因此提交到后台任务队列异步生成，生成的 JSON 在缓存中保留一段时间。
+// 客户端反复请求同一地址：未生成时返回任务状态，生成完成后返回文件内容。
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/repository"
+	"github.com/example/go-user-api/pkg/cache"
+	"github.com/example/go-user-api/pkg/jobqueue"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+// JobTypeDataExport 个人数据导出
+const JobTypeDataExport = "data_export"
+
+const (
+	// dataExportKeyPrefix 导出文件在缓存中的键前缀
+	dataExportKeyPrefix = "data_export:"
+	// dataExportBatchSize 分批读取变更记录与使用记录的每批条数
+	dataExportBatchSize = 500
+	// dataExportFailedMessage 生成失败时返回给用户的原因，具体错误只记录日志
+	dataExportFailedMessage = "导出生成失败，请稍后重新请求"
+)
+
+// DataExportService 个人数据导出服务接口
+type DataExportService interface {
+	// Request 请求导出用户的个人数据
+	// 已生成时返回导出文件内容；否则提交（或复用进行中的）生成任务并返回任务状态
+	Request(ctx context.Context, userID string) (*model.DataExportStatus, []byte, error)
+	// Build 同步聚合用户的个人数据
+	Build(ctx context.Context, userID string) (*model.UserDataExport, error)
+}
+
+// DataExportRepositories 个人数据导出读取的各子系统仓储
+type DataExportRepositories struct {
+	User            repository.UserRepository
+	UserTag         repository.UserTagRepository
+	LoginHistory    repository.LoginHistoryRepository
+	RefreshToken    repository.RefreshTokenRepository
+	PersonalToken   repository.PersonalAccessTokenRepository
+	SecurityEvent   repository.SecurityEventRepository
+	UserChangeLog   repository.UserChangeLogRepository
+	RiskReportUsage repository.RiskReportUsageRepository
+}
+
+// dataExportService 个人数据导出服务实现
+type dataExportService struct {
+	repos DataExportRepositories
+	queue jobqueue.Queue
+	store cache.Cache
+	ttl   time.Duration
+	log   logger.Logger
+	now   func() time.Time
+
+	// jobs 用户 ID 到最近一次生成任务 ID 的映射，避免重复提交
+	mu   sync.Mutex
+	jobs map[string]string
+}
+
+// NewDataExportService 创建个人数据导出服务实例
+// 生成的文件写入 store 并保留 ttl；store 为进程内缓存时，多实例部署需将同一用户的请求路由到同一实例
+func NewDataExportService(repos DataExportRepositories, queue jobqueue.Queue, store cache.Cache, ttl time.Duration, log logger.Logger) DataExportService {
+	return &dataExportService{
+		repos: repos,
+		queue: queue,
+		store: store,
+		ttl:   ttl,
+		log:   log.With(logger.String("service", "data_export")),
+		now:   time.Now,
+		jobs:  make(map[string]string),
+	}
+}
+
+// dataExportKey 返回用户导出文件的缓存键
+func dataExportKey(userID string) string {
+	return dataExportKeyPrefix + userID
+}
+
+// Request 请求导出用户的个人数据
+// 上次任务失败时返回失败状态并清除记录，下次请求重新生成；文件过期后同样重新生成
+func (s *dataExportService) Request(ctx context.Context, userID string) (*model.DataExportStatus, []byte, error) {
+	data, ok, err := s.store.Get(ctx, dataExportKey(userID))
+	if err != nil {
+		s.log.Warn("读取个人数据导出缓存失败", logger.String("user_id", userID), logger.Err(err))
+	} else if ok {
+		return nil, data, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if jobID, ok := s.jobs[userID]; ok {
+		if job, found := s.queue.Get(jobID); found {
+			switch job.Status {
+			case jobqueue.StatusPending, jobqueue.StatusRunning:
+				return dataExportStatus(job), nil, nil
+			case jobqueue.StatusFailed:
+				delete(s.jobs, userID)
+				status := dataExportStatus(job)
+				status.Error = dataExportFailedMessage
+				return status, nil, nil
+			}
+		}
+		// 任务已完成但文件已过期，或任务记录已被清理
+		delete(s.jobs, userID)
+	}
+
+	job, err := s.queue.Submit(JobTypeDataExport, func(ctx context.Context, progress jobqueue.ProgressFunc) error {
+		return s.generate(ctx, userID)
+	})
+	if err != nil {
+		return nil, nil, mapQueueError(err)
+	}
+	s.jobs[userID] = job.ID
+
+	s.log.Info("个人数据导出任务已提交",
+		logger.String("user_id", userID),
+		logger.String("job_id", job.ID),
+	)
+	return dataExportStatus(job), nil, nil
+}
+
+// dataExportStatus 将任务状态转换为导出状态
+func dataExportStatus(job *jobqueue.Job) *model.DataExportStatus {
+	status := model.DataExportPending
+	switch job.Status {
+	case jobqueue.StatusRunning:
+		status = model.DataExportRunning
+	case jobqueue.StatusFailed:
+		status = model.DataExportFailed
+	}
+	return &model.DataExportStatus{
+		JobID:       job.ID,
+		Status:      status,
+		RequestedAt: job.CreatedAt,
+	}
+}
+
+// generate 生成导出文件并写入缓存
+func (s *dataExportService) generate(ctx context.Context, userID string) error {
+	export, err := s.Build(ctx, userID)
+	if err != nil {
+		s.log.Error("生成个人数据导出失败", logger.String("user_id", userID), logger.Err(err))
+		return err
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		s.log.Error("序列化个人数据导出失败", logger.String("user_id", userID), logger.Err(err))
+		return err
+	}
+	if err := s.store.Set(ctx, dataExportKey(userID), data, s.ttl); err != nil {
+		s.log.Error("保存个人数据导出失败", logger.String("user_id", userID), logger.Err(err))
+		return err
+	}
+
+	s.log.Info("个人数据导出已生成", logger.String("user_id", userID), logger.Int("bytes", len(data)))
+	return nil
+}
+
+// Build 同步聚合用户的个人数据
+// 任一子系统读取失败时整体失败，避免交付不完整的导出
+func (s *dataExportService) Build(ctx context.Context, userID string) (*model.UserDataExport, error) {
+	user, err := s.repos.User.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &model.UserDataExport{
+		ExportedAt:           s.now(),
+		Profile:              user.ToResponse(),
+		Tags:                 []string{},
+		LoginHistory:         []model.LoginHistoryResponse{},
+		Sessions:             []model.DataExportSession{},
+		PersonalAccessTokens: []model.PersonalAccessTokenResponse{},
+		SecurityEvents:       []model.DataExportSecurityEvent{},
+		ChangeLog:            []model.DataExportChange{},
+		RiskReportUsage:      []model.RiskReportUsageResponse{},
+	}
+
+	tags, err := s.repos.UserTag.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		export.Tags = append(export.Tags, tag.Name)
+	}
+
+	logins, err := s.repos.LoginHistory.ListRecentByUser(ctx, userID, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range logins {
+		export.LoginHistory = append(export.LoginHistory, model.LoginHistoryResponse{IP: h.IP, LoginAt: h.CreatedAt})
+	}
+
+	sessions, err := s.repos.RefreshToken.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		t := &sessions[i]
+		export.Sessions = append(export.Sessions, model.DataExportSession{
+			CreatedAt: t.CreatedAt,
+			ExpiresAt: t.ExpiresAt,
+			RevokedAt: t.RevokedAt,
+			Active:    t.IsUsable(),
+		})
+	}
+
+	tokens, err := s.repos.PersonalToken.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tokens {
+		export.PersonalAccessTokens = append(export.PersonalAccessTokens, *tokens[i].ToResponse())
+	}
+
+	events, err := s.repos.SecurityEvent.ListRecentByUser(ctx, userID, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		export.SecurityEvents = append(export.SecurityEvents, model.DataExportSecurityEvent{
+			Type:      e.Type,
+			IP:        e.IP,
+			Location:  e.Location,
+			Detail:    e.Detail,
+			CreatedAt: e.CreatedAt,
+		})
+	}
+
+	for page := 1; ; page++ {
+		changes, _, err := s.repos.UserChangeLog.ListByUser(ctx, userID, page, dataExportBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range changes {
+			export.ChangeLog = append(export.ChangeLog, model.DataExportChange{
+				Field:     c.Field,
+				OldValue:  c.OldValue,
+				NewValue:  c.NewValue,
+				ChangedAt: c.CreatedAt,
+			})
+		}
+		if len(changes) < dataExportBatchSize {
+			break
+		}
+	}
+
+	err = s.repos.RiskReportUsage.FindInBatches(ctx, map[string]interface{}{"user_id": userID}, dataExportBatchSize,
+		func(batch []model.RiskReportUsage) error {
+			for i := range batch {
+				export.RiskReportUsage = append(export.RiskReportUsage, *batch[i].ToResponse())
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
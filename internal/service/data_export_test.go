@@ -0,0 +1,196 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含个人数据导出的单元测试，使用内存 SQLite 数据库
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/repository"
+	"github.com/example/go-user-api/pkg/cache"
+	"github.com/example/go-user-api/pkg/jobqueue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// newDataExportFixture 创建基于内存 SQLite 的导出服务，返回数据库以便准备数据
+func newDataExportFixture(t *testing.T) (*gorm.DB, DataExportService) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&model.User{}, &model.UserTag{}, &model.LoginHistory{}, &model.RefreshToken{},
+		&model.PersonalAccessToken{}, &model.SecurityEvent{}, &model.UserChangeLog{},
+		&model.RiskReportUsage{},
+	))
+
+	queue := jobqueue.NewMemoryQueue(1, 10)
+	queue.Start()
+	t.Cleanup(func() {
+		_ = queue.Shutdown(context.Background())
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	svc := NewDataExportService(DataExportRepositories{
+		User:            repository.NewUserRepository(db),
+		UserTag:         repository.NewUserTagRepository(db),
+		LoginHistory:    repository.NewLoginHistoryRepository(db),
+		RefreshToken:    repository.NewRefreshTokenRepository(db),
+		PersonalToken:   repository.NewPersonalAccessTokenRepository(db),
+		SecurityEvent:   repository.NewSecurityEventRepository(db),
+		UserChangeLog:   repository.NewUserChangeLogRepository(db),
+		RiskReportUsage: repository.NewRiskReportUsageRepository(db),
+	}, queue, cache.NewMemoryCache(), time.Hour, newTestLogger())
+	return db, svc
+}
+
+// seedDataExportUser 创建用户并在每个子系统中写入一条属于该用户的记录
+func seedDataExportUser(t *testing.T, db *gorm.DB, username string) *model.User {
+	t.Helper()
+
+	user := &model.User{
+		Username:          username,
+		Email:             username + "@example.com",
+		Password:          "hashed-password",
+		Status:            model.UserStatusActive,
+		Role:              model.RoleUser,
+		ProfileVisibility: model.ProfileVisibilityPrivate,
+	}
+	require.NoError(t, db.Create(user).Error)
+
+	now := time.Now()
+	require.NoError(t, db.Create(&model.UserTag{UserID: user.ID, Name: username + "-tag"}).Error)
+	require.NoError(t, db.Create(&model.LoginHistory{UserID: user.ID, IP: "203.0.113.7"}).Error)
+	require.NoError(t, db.Create(&model.RefreshToken{JTI: username + "-jti", UserID: user.ID, ExpiresAt: now.Add(time.Hour)}).Error)
+	require.NoError(t, db.Create(&model.PersonalAccessToken{
+		UserID: user.ID, Name: username + "-script", TokenHash: username + "-hash", TokenPrefix: "pat_abcd", Scopes: "profile:read",
+	}).Error)
+	require.NoError(t, db.Create(&model.SecurityEvent{UserID: user.ID, Type: "login_anomaly", IP: "198.51.100.1"}).Error)
+	require.NoError(t, db.Create(&model.UserChangeLog{UserID: user.ID, Field: "email", OldValue: "old@example.com", NewValue: user.Email}).Error)
+	require.NoError(t, db.Create(&model.RiskReportUsage{
+		UserID: user.ID, Ticker: "AAPL", RequestTime: now, ResponseTime: now,
+		PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30, AIResponse: username + "-report",
+	}).Error)
+	return user
+}
+
+func TestDataExportService_Build_IncludesAllSubsystems(t *testing.T) {
+	// 准备：另一个用户的数据不应出现在导出中
+	db, svc := newDataExportFixture(t)
+	alice := seedDataExportUser(t, db, "alice")
+	seedDataExportUser(t, db, "bob")
+
+	// 执行
+	export, err := svc.Build(context.Background(), alice.ID)
+
+	// 断言：每个子系统恰好包含 alice 的一条记录
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, export.Profile.ID)
+	assert.Equal(t, "alice@example.com", export.Profile.Email)
+	assert.Equal(t, model.ProfileVisibilityPrivate, export.Profile.ProfileVisibility)
+	assert.Equal(t, []string{"alice-tag"}, export.Tags)
+
+	require.Len(t, export.LoginHistory, 1)
+	assert.Equal(t, "203.0.113.7", export.LoginHistory[0].IP)
+
+	require.Len(t, export.Sessions, 1)
+	assert.True(t, export.Sessions[0].Active)
+
+	require.Len(t, export.PersonalAccessTokens, 1)
+	assert.Equal(t, "alice-script", export.PersonalAccessTokens[0].Name)
+
+	require.Len(t, export.SecurityEvents, 1)
+	assert.Equal(t, "login_anomaly", export.SecurityEvents[0].Type)
+
+	require.Len(t, export.ChangeLog, 1)
+	assert.Equal(t, "old@example.com", export.ChangeLog[0].OldValue)
+
+	require.Len(t, export.RiskReportUsage, 1)
+	assert.Equal(t, "alice-report", export.RiskReportUsage[0].AIResponse)
+
+	// 凭据不进入导出
+	data, err := json.Marshal(export)
+	require.NoError(t, err)
+	for _, secret := range []string{"hashed-password", "alice-hash", "alice-jti"} {
+		assert.NotContains(t, string(data), secret)
+	}
+}
+
+func TestDataExportService_Build_UserNotFound(t *testing.T) {
+	_, svc := newDataExportFixture(t)
+
+	_, err := svc.Build(context.Background(), "missing")
+
+	assert.Error(t, err)
+}
+
+func TestDataExportService_Request_GeneratesAsynchronously(t *testing.T) {
+	// 准备
+	db, svc := newDataExportFixture(t)
+	alice := seedDataExportUser(t, db, "alice")
+	ctx := context.Background()
+
+	// 执行：首次请求提交任务
+	status, data, err := svc.Request(ctx, alice.ID)
+	require.NoError(t, err)
+	require.Nil(t, data)
+	require.NotNil(t, status)
+	assert.NotEmpty(t, status.JobID)
+
+	// 断言：任务完成前重复请求复用同一任务，完成后返回导出文件
+	require.Eventually(t, func() bool {
+		next, got, err := svc.Request(ctx, alice.ID)
+		require.NoError(t, err)
+		if got == nil {
+			assert.Equal(t, status.JobID, next.JobID)
+			return false
+		}
+		data = got
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	var export model.UserDataExport
+	require.NoError(t, json.Unmarshal(data, &export))
+	assert.Equal(t, alice.ID, export.Profile.ID)
+	assert.Len(t, export.RiskReportUsage, 1)
+}
+
+func TestDataExportService_Request_FailedJobReportedThenRetried(t *testing.T) {
+	// 准备：用户不存在，生成任务失败
+	_, svc := newDataExportFixture(t)
+	ctx := context.Background()
+
+	first, _, err := svc.Request(ctx, "missing")
+	require.NoError(t, err)
+
+	// 断言：失败状态只报告一次原因，不暴露内部错误
+	var failed *model.DataExportStatus
+	require.Eventually(t, func() bool {
+		status, _, err := svc.Request(ctx, "missing")
+		require.NoError(t, err)
+		if status.Status != model.DataExportFailed {
+			return false
+		}
+		failed = status
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, first.JobID, failed.JobID)
+	assert.Equal(t, dataExportFailedMessage, failed.Error)
+
+	// 再次请求重新提交任务
+	retried, _, err := svc.Request(ctx, "missing")
+	require.NoError(t, err)
+	assert.NotEqual(t, first.JobID, retried.JobID)
+}
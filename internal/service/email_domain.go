@@ -0,0 +1,45 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含注册邮箱的域名白/黑名单校验。
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/pkg/errors"
+)
+
+// checkEmailDomain 按域名白/黑名单校验邮箱
+// 禁止列表优先；允许列表非空时域名必须在其中。违规时返回带原因的 400 错误
+func checkEmailDomain(email string, rules config.EmailDomainsConfig) error {
+	if len(rules.Allow) == 0 && len(rules.Deny) == 0 {
+		return nil
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return errors.ErrInvalidEmail
+	}
+	domain := strings.ToLower(email[at+1:])
+
+	if matchEmailDomain(domain, rules.Deny) {
+		return errors.ErrValidation.WithMessage(fmt.Sprintf("邮箱域名 %s 禁止注册", domain))
+	}
+	if len(rules.Allow) > 0 && !matchEmailDomain(domain, rules.Allow) {
+		return errors.ErrValidation.WithMessage(fmt.Sprintf("邮箱域名 %s 不在允许注册的域名列表中", domain))
+	}
+	return nil
+}
+
+// matchEmailDomain 判断域名是否命中列表中的某一项（含子域名）
+func matchEmailDomain(domain string, list []string) bool {
+	for _, entry := range list {
+		entry = strings.ToLower(strings.TrimPrefix(entry, "."))
+		if domain == entry || strings.HasSuffix(domain, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
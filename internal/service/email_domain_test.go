@@ -0,0 +1,104 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含注册邮箱域名白/黑名单的单元测试
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newEmailDomainTestRequest 创建指定邮箱的注册请求
+func newEmailDomainTestRequest(email string) *model.RegisterRequest {
+	return &model.RegisterRequest{
+		Username:        "newuser",
+		Email:           email,
+		Password:        "password123",
+		ConfirmPassword: "password123",
+	}
+}
+
+func TestUserService_Register_AllowedEmailDomain(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	cfg.Security.EmailDomains = config.EmailDomainsConfig{Allow: []string{"corp.example.com"}}
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+	ctx := context.Background()
+
+	// 设置 mock 期望：子域名同样允许
+	mockRepo.On("ExistsByUsername", ctx, "newuser").Return(false, nil)
+	mockRepo.On("ExistsByEmail", ctx, "alice@mail.Corp.example.com").Return(false, nil)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(nil)
+
+	// 执行
+	user, err := userService.Register(ctx, newEmailDomainTestRequest("alice@mail.Corp.example.com"))
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, "alice@mail.Corp.example.com", user.Email)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_Register_RejectedEmailDomain(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  config.EmailDomainsConfig
+		email  string
+		reason string
+	}{
+		{
+			name:   "不在允许列表中",
+			rules:  config.EmailDomainsConfig{Allow: []string{"corp.example.com"}},
+			email:  "alice@gmail.com",
+			reason: "不在允许注册的域名列表中",
+		},
+		{
+			name:   "命中禁止列表",
+			rules:  config.EmailDomainsConfig{Deny: []string{"mailinator.com"}},
+			email:  "alice@mailinator.com",
+			reason: "禁止注册",
+		},
+		{
+			name: "禁止列表优先于允许列表",
+			rules: config.EmailDomainsConfig{
+				Allow: []string{"example.com"},
+				Deny:  []string{"temp.example.com"},
+			},
+			email:  "alice@temp.example.com",
+			reason: "禁止注册",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 准备
+			mockRepo := new(MockUserRepository)
+			cfg := newTestConfig()
+			cfg.Security.EmailDomains = tt.rules
+			userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+			ctx := context.Background()
+
+			// 设置 mock 期望
+			mockRepo.On("ExistsByUsername", ctx, "newuser").Return(false, nil)
+
+			// 执行
+			user, err := userService.Register(ctx, newEmailDomainTestRequest(tt.email))
+
+			// 断言：400 且带明确原因，不创建用户
+			assert.Nil(t, user)
+			appErr := errors.AsAppError(err)
+			require.NotNil(t, appErr)
+			assert.Equal(t, 400, appErr.HTTPStatus)
+			assert.Contains(t, appErr.Message, tt.reason)
+			mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
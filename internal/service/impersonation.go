@@ -0,0 +1,65 @@
+// Package service 提供业务逻辑层的实现
+package service
+
+import (
+	"context"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+// Impersonate 超级管理员模拟登录
+// 签发以目标用户身份访问的短期访问令牌，令牌带 impersonated_by 声明：
+//   - 只允许配置中的超级管理员操作，且不能模拟自己或其他管理员
+//   - 不签发刷新令牌，访问令牌不会自动续签
+//   - 目标用户被强制下线时模拟令牌同样失效
+func (s *userService) Impersonate(ctx context.Context, targetID, operatorID string) (*model.ImpersonateResponse, error) {
+	cfg := s.config.Security.Impersonation
+	if !cfg.Enabled {
+		return nil, errors.ErrForbidden.WithMessage("模拟登录未启用")
+	}
+	if !cfg.IsSuperAdmin(operatorID) {
+		s.log.Warn("非超级管理员尝试模拟登录",
+			logger.String("operator_id", operatorID),
+			logger.String("target_id", targetID),
+		)
+		return nil, errors.ErrForbidden.WithMessage("仅超级管理员可以模拟登录")
+	}
+	if targetID == operatorID {
+		return nil, errors.ErrBadRequest.WithMessage("不能模拟自己")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsAdmin() {
+		return nil, errors.ErrForbidden.WithMessage("不能模拟管理员")
+	}
+	if !user.IsActive() {
+		return nil, errors.ErrForbidden.WithMessage("不能模拟非正常状态的用户")
+	}
+
+	expiration := cfg.ExpireDuration()
+	token, _, err := s.jwtService.GenerateImpersonationToken(user, operatorID, expiration)
+	if err != nil {
+		s.log.Error("生成模拟令牌失败", logger.String("target_id", targetID), logger.Err(err))
+		return nil, errors.ErrInternalServer.WithError(err)
+	}
+
+	s.log.Warn("管理员模拟登录",
+		logger.String("operator_id", operatorID),
+		logger.String("target_id", user.ID),
+		logger.String("target_username", user.Username),
+		logger.Duration("expires_in", expiration),
+	)
+
+	return &model.ImpersonateResponse{
+		AccessToken:    token,
+		TokenType:      "Bearer",
+		ExpiresIn:      int64(expiration.Seconds()),
+		ImpersonatedBy: operatorID,
+		User:           user.ToResponse(),
+	}, nil
+}
@@ -0,0 +1,89 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含管理员模拟登录的单元测试
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newImpersonationTestService 创建启用模拟登录、super-admin 为超级管理员的用户服务
+func newImpersonationTestService() (*userService, *MockUserRepository, JWTService) {
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	cfg.Security.Impersonation.Enabled = true
+	cfg.Security.Impersonation.SuperAdmins = []string{"super-admin"}
+	cfg.Security.Impersonation.ExpireMinutes = 10
+	jwtService := NewJWTService(&cfg.JWT)
+	svc := NewUserService(mockRepo, new(MockRefreshTokenRepository), jwtService, cfg, newTestLogger()).(*userService)
+	return svc, mockRepo, jwtService
+}
+
+func TestUserService_Impersonate_TokenCarriesImpersonatedBy(t *testing.T) {
+	// 准备
+	svc, mockRepo, jwtService := newImpersonationTestService()
+	ctx := context.Background()
+	user := newTestUser()
+
+	// 设置 mock 期望
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+
+	// 执行
+	resp, err := svc.Impersonate(ctx, user.ID, "super-admin")
+	require.NoError(t, err)
+	claims, err := jwtService.ValidateToken(resp.AccessToken)
+
+	// 断言：以目标用户身份访问，标记真实操作者，有效期为配置的短时长
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+	assert.Equal(t, "super-admin", claims.ImpersonatedBy)
+	assert.True(t, claims.IsImpersonated())
+	assert.True(t, claims.IsAccessToken())
+	assert.Equal(t, "super-admin", resp.ImpersonatedBy)
+	assert.Equal(t, int64(600), resp.ExpiresIn)
+	assert.InDelta(t, (10 * time.Minute).Seconds(), claims.TimeToExpire().Seconds(), 5)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_Impersonate_Rejected(t *testing.T) {
+	admin := newTestUser()
+	admin.ID = "admin-1"
+	admin.Role = model.RoleAdmin
+
+	tests := []struct {
+		name       string
+		targetID   string
+		operatorID string
+		disabled   bool
+		wantErr    *errors.AppError
+	}{
+		{name: "未启用", targetID: "test-user-id", operatorID: "super-admin", disabled: true, wantErr: errors.ErrForbidden},
+		{name: "非超级管理员", targetID: "test-user-id", operatorID: "admin-1", wantErr: errors.ErrForbidden},
+		{name: "模拟自己", targetID: "super-admin", operatorID: "super-admin", wantErr: errors.ErrBadRequest},
+		{name: "模拟管理员", targetID: "admin-1", operatorID: "super-admin", wantErr: errors.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 准备
+			svc, mockRepo, _ := newImpersonationTestService()
+			svc.config.Security.Impersonation.Enabled = !tt.disabled
+			ctx := context.Background()
+			mockRepo.On("GetByID", ctx, "admin-1").Return(admin, nil).Maybe()
+
+			// 执行
+			resp, err := svc.Impersonate(ctx, tt.targetID, tt.operatorID)
+
+			// 断言
+			assert.Nil(t, resp)
+			assert.True(t, errors.Is(err, tt.wantErr))
+		})
+	}
+}
@@ -0,0 +1,207 @@
+// Package service 提供业务逻辑层的实现
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/repository"
+	"github.com/example/go-user-api/pkg/cache"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/jobqueue"
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/example/go-user-api/pkg/storage"
+)
+
+// 后台任务类型
+const (
+	// JobTypeBatchTag 批量打标
+	JobTypeBatchTag = "batch_tag"
+	// JobTypePurgeDeletedUsers 清理超过保留期的软删除用户
+	JobTypePurgeDeletedUsers = "purge_deleted_users"
+)
+
+// batchTagBatchSize 批量打标时每批处理的用户数
+const batchTagBatchSize = 200
+
+// JobService 后台任务服务接口
+// 负责将管理后台的批量操作提交到任务队列并查询进度
+type JobService interface {
+	// SubmitBatchTag 提交批量打标任务
+	SubmitBatchTag(ctx context.Context, req *model.BatchTagRequest) (*jobqueue.Job, error)
+	// SubmitPurgeDeletedUsers 提交清理任务，永久删除软删除时间超过 retention 的用户
+	SubmitPurgeDeletedUsers(ctx context.Context, retention time.Duration) (*jobqueue.Job, error)
+	// GetJob 查询任务状态
+	GetJob(ctx context.Context, id string) (*jobqueue.Job, error)
+}
+
+// jobService 后台任务服务实现
+type jobService struct {
+	queue    jobqueue.Queue
+	userRepo repository.UserRepository
+	tagRepo  repository.UserTagRepository
+	log      logger.Logger
+
+	// listCache 用户列表缓存，为 nil 时不需要失效
+	listCache cache.Cache
+	// avatarStorage 头像存储，清理用户时删除其头像文件，为 nil 时不删除
+	avatarStorage storage.Storage
+}
+
+// JobServiceOption 后台任务服务的可选配置
+type JobServiceOption func(*jobService)
+
+// WithJobAvatarStorage 设置头像存储，清理软删除用户时一并删除其头像文件
+func WithJobAvatarStorage(st storage.Storage) JobServiceOption {
+	return func(s *jobService) {
+		s.avatarStorage = st
+	}
+}
+
+// NewJobService 创建后台任务服务实例
+func NewJobService(
+	queue jobqueue.Queue,
+	userRepo repository.UserRepository,
+	tagRepo repository.UserTagRepository,
+	log logger.Logger,
+	opts ...JobServiceOption,
+) JobService {
+	s := &jobService{
+		queue:    queue,
+		userRepo: userRepo,
+		tagRepo:  tagRepo,
+		log:      log.With(logger.String("service", "job")),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SubmitBatchTag 提交批量打标任务
+// 任务在后台执行，不受当前请求上下文的取消影响
+func (s *jobService) SubmitBatchTag(ctx context.Context, req *model.BatchTagRequest) (*jobqueue.Job, error) {
+	opts := &repository.UserListOptions{
+		Status: req.Status,
+		Role:   req.Role,
+	}
+	tag := req.Tag
+
+	job, err := s.queue.Submit(JobTypeBatchTag, func(ctx context.Context, progress jobqueue.ProgressFunc) error {
+		return s.runBatchTag(ctx, opts, tag, progress)
+	})
+	if err != nil {
+		return nil, mapQueueError(err)
+	}
+
+	s.log.Info("批量打标任务已提交",
+		logger.String("job_id", job.ID),
+		logger.String("tag", tag),
+	)
+	return job, nil
+}
+
+// runBatchTag 执行批量打标
+// 先统计总数用于进度展示，再分批读取用户并打标；
+// 结束后清空用户列表缓存，任务中途失败时已处理的批次同样生效，因此失败也清空
+func (s *jobService) runBatchTag(ctx context.Context, opts *repository.UserListOptions, tag string, progress jobqueue.ProgressFunc) error {
+	countOpts := *opts
+	countOpts.Page, countOpts.PageSize = 1, 1
+	_, total, err := s.userRepo.List(ctx, &countOpts)
+	if err != nil {
+		return err
+	}
+	progress(0, int(total))
+
+	processed := 0
+	var added int64
+	err = s.userRepo.FindInBatches(ctx, opts, batchTagBatchSize, func(batch []model.User) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ids := make([]string, len(batch))
+		for i := range batch {
+			ids[i] = batch[i].ID
+		}
+		n, err := s.tagRepo.AddToUsers(ctx, ids, tag)
+		if err != nil {
+			return err
+		}
+		added += n
+		processed += len(batch)
+		progress(processed, int(total))
+		return nil
+	})
+	if processed > 0 {
+		// 任务被取消时 ctx 已失效，清空缓存不能随之取消
+		clearUserListCache(context.WithoutCancel(ctx), s.listCache, s.log)
+	}
+	if err != nil {
+		s.log.Error("批量打标任务失败",
+			logger.String("tag", tag),
+			logger.Int("processed", processed),
+			logger.Err(err),
+		)
+		return err
+	}
+
+	s.log.Info("批量打标任务完成",
+		logger.String("tag", tag),
+		logger.Int("processed", processed),
+		logger.Int64("added", added),
+	)
+	return nil
+}
+
+// SubmitPurgeDeletedUsers 提交软删除用户清理任务
+// 保留期从任务执行时开始计算
+func (s *jobService) SubmitPurgeDeletedUsers(ctx context.Context, retention time.Duration) (*jobqueue.Job, error) {
+	if retention <= 0 {
+		return nil, errors.ErrValidation.WithDetail("保留期必须大于 0")
+	}
+
+	job, err := s.queue.Submit(JobTypePurgeDeletedUsers, func(ctx context.Context, progress jobqueue.ProgressFunc) error {
+		before := time.Now().Add(-retention)
+		purged, err := s.userRepo.PurgeDeletedBefore(ctx, before)
+		if err != nil {
+			s.log.Error("清理软删除用户失败", logger.Err(err))
+			return err
+		}
+		for i := range purged {
+			deleteAvatarFiles(ctx, s.avatarStorage, avatarKeys(&purged[i]), s.log)
+		}
+		progress(len(purged), len(purged))
+		s.log.Info("清理软删除用户完成",
+			logger.Int("purged", len(purged)),
+			logger.String("deleted_before", before.UTC().Format(time.RFC3339)),
+		)
+		return nil
+	})
+	if err != nil {
+		return nil, mapQueueError(err)
+	}
+	return job, nil
+}
+
+// GetJob 查询任务状态
+func (s *jobService) GetJob(ctx context.Context, id string) (*jobqueue.Job, error) {
+	job, ok := s.queue.Get(id)
+	if !ok {
+		return nil, errors.ErrResourceNotFound.WithDetail("任务不存在")
+	}
+	return job, nil
+}
+
+// mapQueueError 将队列错误转换为应用错误
+func mapQueueError(err error) error {
+	switch {
+	case stderrors.Is(err, jobqueue.ErrQueueFull):
+		return errors.ErrTooManyRequests.WithDetail("任务队列已满，请稍后再试")
+	case stderrors.Is(err, jobqueue.ErrQueueClosed):
+		return errors.ErrInternalServer.WithDetail("服务正在关闭").WithError(err)
+	default:
+		return errors.ErrInternalServer.WithError(err)
+	}
+}
@@ -0,0 +1,172 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含后台任务服务的单元测试
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/repository"
+	"github.com/example/go-user-api/pkg/cache"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/jobqueue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// waitJobFinished 轮询直到任务结束或超时
+func waitJobFinished(t *testing.T, svc JobService, id string) *jobqueue.Job {
+	t.Helper()
+	var job *jobqueue.Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = svc.GetJob(context.Background(), id)
+		return err == nil && job.Finished()
+	}, 2*time.Second, 5*time.Millisecond)
+	return job
+}
+
+// ============================================================
+// SubmitBatchTag 测试
+// ============================================================
+
+func TestJobService_SubmitBatchTag_Done(t *testing.T) {
+	// 准备
+	userRepo := new(MockUserRepository)
+	tagRepo := new(MockUserTagRepository)
+	queue := jobqueue.NewMemoryQueue(1, 10)
+	queue.Start()
+	defer queue.Shutdown(context.Background())
+	listCache := cache.NewMemoryCache()
+	require.NoError(t, listCache.Set(context.Background(), userListCachePrefix+"page=1", []byte("{}"), time.Minute))
+	svc := NewJobService(queue, userRepo, tagRepo, newTestLogger(), WithJobUserListCache(listCache))
+
+	active := int8(model.UserStatusActive)
+	batches := [][]model.User{
+		{{BaseModel: model.BaseModel{ID: "u1"}}, {BaseModel: model.BaseModel{ID: "u2"}}},
+		{{BaseModel: model.BaseModel{ID: "u3"}}},
+	}
+
+	// 设置 mock 期望
+	userRepo.On("List", mock.Anything, mock.MatchedBy(func(opts *repository.UserListOptions) bool {
+		return opts.Status != nil && *opts.Status == active && opts.PageSize == 1
+	})).Return([]model.User{{BaseModel: model.BaseModel{ID: "u1"}}}, int64(3), nil)
+	userRepo.On("FindInBatches", mock.Anything, mock.Anything, batchTagBatchSize).Return(batches, nil)
+	tagRepo.On("AddToUsers", mock.Anything, []string{"u1", "u2"}, "vip").Return(int64(2), nil)
+	tagRepo.On("AddToUsers", mock.Anything, []string{"u3"}, "vip").Return(int64(1), nil)
+
+	// 执行
+	job, err := svc.SubmitBatchTag(context.Background(), &model.BatchTagRequest{Tag: "vip", Status: &active})
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, JobTypeBatchTag, job.Type)
+
+	finished := waitJobFinished(t, svc, job.ID)
+	assert.Equal(t, jobqueue.StatusDone, finished.Status)
+	assert.Equal(t, 3, finished.Total)
+	assert.Equal(t, 3, finished.Processed)
+	assert.Zero(t, listCache.Len(), "打标完成后应清空用户列表缓存")
+	userRepo.AssertExpectations(t)
+	tagRepo.AssertExpectations(t)
+}
+
+func TestJobService_SubmitBatchTag_Failed(t *testing.T) {
+	// 准备
+	userRepo := new(MockUserRepository)
+	tagRepo := new(MockUserTagRepository)
+	queue := jobqueue.NewMemoryQueue(1, 10)
+	queue.Start()
+	defer queue.Shutdown(context.Background())
+	svc := NewJobService(queue, userRepo, tagRepo, newTestLogger())
+
+	// 设置 mock 期望
+	userRepo.On("List", mock.Anything, mock.Anything).Return([]model.User{}, int64(1), nil)
+	userRepo.On("FindInBatches", mock.Anything, mock.Anything, batchTagBatchSize).
+		Return([][]model.User{{{BaseModel: model.BaseModel{ID: "u1"}}}}, nil)
+	tagRepo.On("AddToUsers", mock.Anything, []string{"u1"}, "vip").
+		Return(int64(0), errors.ErrDatabaseError)
+
+	// 执行
+	job, err := svc.SubmitBatchTag(context.Background(), &model.BatchTagRequest{Tag: "vip"})
+	require.NoError(t, err)
+
+	// 断言
+	finished := waitJobFinished(t, svc, job.ID)
+	assert.Equal(t, jobqueue.StatusFailed, finished.Status)
+	assert.NotEmpty(t, finished.Error)
+}
+
+func TestJobService_SubmitBatchTag_QueueFull(t *testing.T) {
+	// 准备：不启动 worker，容量为 0
+	queue := jobqueue.NewMemoryQueue(1, 0)
+	svc := NewJobService(queue, new(MockUserRepository), new(MockUserTagRepository), newTestLogger())
+
+	// 执行
+	job, err := svc.SubmitBatchTag(context.Background(), &model.BatchTagRequest{Tag: "vip"})
+
+	// 断言
+	assert.Nil(t, job)
+	assert.True(t, errors.Is(err, errors.ErrTooManyRequests))
+}
+
+// ============================================================
+// GetJob 测试
+// ============================================================
+
+func TestJobService_GetJob_NotFound(t *testing.T) {
+	// 准备
+	queue := jobqueue.NewMemoryQueue(1, 1)
+	svc := NewJobService(queue, new(MockUserRepository), new(MockUserTagRepository), newTestLogger())
+
+	// 执行
+	job, err := svc.GetJob(context.Background(), "missing")
+
+	// 断言
+	assert.Nil(t, job)
+	assert.True(t, errors.Is(err, errors.ErrResourceNotFound))
+}
+
+// ============================================================
+// SubmitPurgeDeletedUsers 测试
+// ============================================================
+
+func TestJobService_SubmitPurgeDeletedUsers(t *testing.T) {
+	// 准备
+	userRepo := new(MockUserRepository)
+	queue := jobqueue.NewMemoryQueue(1, 10)
+	queue.Start()
+	defer queue.Shutdown(context.Background())
+	svc := NewJobService(queue, userRepo, new(MockUserTagRepository), newTestLogger())
+
+	retention := 30 * 24 * time.Hour
+	submittedAt := time.Now()
+
+	// 设置 mock 期望：阈值为执行时刻减去保留期
+	userRepo.On("PurgeDeletedBefore", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		return !before.Before(submittedAt.Add(-retention)) && before.Before(time.Now().Add(-retention+time.Second))
+	})).Return(make([]model.User, 4), nil)
+
+	// 执行
+	job, err := svc.SubmitPurgeDeletedUsers(context.Background(), retention)
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, JobTypePurgeDeletedUsers, job.Type)
+	finished := waitJobFinished(t, svc, job.ID)
+	assert.Equal(t, jobqueue.StatusDone, finished.Status)
+	assert.Equal(t, 4, finished.Processed)
+	userRepo.AssertExpectations(t)
+}
+
+func TestJobService_SubmitPurgeDeletedUsers_InvalidRetention(t *testing.T) {
+	svc := NewJobService(jobqueue.NewMemoryQueue(1, 10), new(MockUserRepository), new(MockUserTagRepository), newTestLogger())
+
+	_, err := svc.SubmitPurgeDeletedUsers(context.Background(), 0)
+
+	assert.True(t, errors.Is(err, errors.ErrValidation))
+}
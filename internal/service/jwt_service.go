@@ -2,6 +2,10 @@
 //
 // 本文件实现了 JWT（JSON Web Token）认证服务，
 // 提供令牌的生成、验证和解析功能。
+// 访问令牌可携带额外声明（TokenClaims.Extra），供下游服务读取部门、权限等信息。
+//
+// 支持密钥轮转：配置密钥集合后，签发使用当前密钥并在令牌头写入 kid，
+// 验证时按 kid 选择密钥，历史密钥签发的令牌在过渡期内仍然有效。
 package service
 
 import (
@@ -12,6 +16,7 @@ import (
 	"github.com/example/go-user-api/internal/model"
 	apperrors "github.com/example/go-user-api/pkg/errors"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // TokenType 令牌类型
@@ -22,6 +27,8 @@ const (
 	TokenTypeAccess TokenType = "access"
 	// TokenTypeRefresh 刷新令牌
 	TokenTypeRefresh TokenType = "refresh"
+	// TokenTypePersonalAccess 个人访问令牌，不是 JWT，只出现在认证中间件为 PAT 请求构造的声明中
+	TokenTypePersonalAccess TokenType = "personal_access"
 )
 
 // TokenClaims JWT 令牌声明
@@ -37,17 +44,53 @@ type TokenClaims struct {
 	Role string `json:"role"`
 	// TokenType 令牌类型: access, refresh
 	TokenType TokenType `json:"token_type"`
+	// TokenVersion 签发时用户的令牌版本，与当前版本不一致即视为已撤销
+	TokenVersion int `json:"ver"`
+	// Extra 签发时注入的额外声明（如部门、权限），放在独立的 ext 字段中，不会覆盖标准声明
+	Extra ExtraClaims `json:"ext,omitempty"`
+	// ImpersonatedBy 模拟登录时的真实操作者（管理员）ID，普通令牌为空
+	ImpersonatedBy string `json:"impersonated_by,omitempty"`
+	// AuthTime 会话开始（登录）时间，续签的令牌沿用原值，用于限制会话最长时长
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
 	// RegisteredClaims 标准 JWT 声明
 	jwt.RegisteredClaims
 }
 
+// ExtraClaims 访问令牌的额外声明，键名由签发方决定
+type ExtraClaims map[string]interface{}
+
+// OpaqueJSON 实现 response.Opaque，响应中的键名原样输出
+func (ExtraClaims) OpaqueJSON() {}
+
+// ViewerID 实现 model.Viewer，nil 声明视为未认证
+func (c *TokenClaims) ViewerID() string {
+	if c == nil {
+		return ""
+	}
+	return c.UserID
+}
+
+// ViewerRole 实现 model.Viewer
+func (c *TokenClaims) ViewerRole() string {
+	if c == nil {
+		return ""
+	}
+	return c.Role
+}
+
 // JWTService JWT 服务接口
 // 定义了 JWT 相关的所有操作
 type JWTService interface {
 	// GenerateAccessToken 生成访问令牌
 	GenerateAccessToken(user *model.User) (string, error)
+	// GenerateAccessTokenWithClaims 生成带额外声明的访问令牌，extra 为空时与 GenerateAccessToken 相同
+	GenerateAccessTokenWithClaims(user *model.User, extra map[string]interface{}) (string, error)
+	// GenerateImpersonationToken 生成以 user 身份访问、带 impersonated_by 声明的模拟访问令牌
+	GenerateImpersonationToken(user *model.User, operatorID string, expiration time.Duration) (string, *TokenClaims, error)
 	// GenerateRefreshToken 生成刷新令牌
 	GenerateRefreshToken(user *model.User) (string, error)
+	// IssueRefreshToken 生成刷新令牌并返回其声明（用于持久化 jti）
+	IssueRefreshToken(user *model.User) (string, *TokenClaims, error)
 	// GenerateTokenPair 生成访问令牌和刷新令牌对
 	GenerateTokenPair(user *model.User) (accessToken, refreshToken string, err error)
 	// ValidateToken 验证并解析令牌
@@ -58,31 +101,97 @@ type JWTService interface {
 	GetAccessTokenExpiration() time.Duration
 	// GetRefreshTokenExpiration 获取刷新令牌过期时间
 	GetRefreshTokenExpiration() time.Duration
+	// RenewAccessToken 以用户当前信息为原令牌续签访问令牌，会话超过刷新令牌有效期时返回 ErrTokenExpired
+	RenewAccessToken(claims *TokenClaims, user *model.User, extra map[string]interface{}) (string, error)
+	// GetRenewThreshold 获取访问令牌自动续签阈值，0 表示不续签
+	GetRenewThreshold() time.Duration
 }
 
 // jwtService JWT 服务实现
 type jwtService struct {
 	config *config.JWTConfig
+	// signingKeyID 当前签名密钥的 kid，为空表示使用单一密钥且不写入 kid
+	signingKeyID string
+	// signingKey 当前签名密钥
+	signingKey []byte
+	// verifyKeys 按 kid 索引的验证密钥
+	verifyKeys map[string][]byte
 }
 
 // NewJWTService 创建 JWT 服务实例
-// 参数 cfg 是 JWT 配置
+// 参数 cfg 是 JWT 配置，配置了密钥集合时启用按 kid 的密钥轮转
 func NewJWTService(cfg *config.JWTConfig) JWTService {
-	return &jwtService{
-		config: cfg,
+	s := &jwtService{
+		config:     cfg,
+		signingKey: []byte(cfg.Secret),
+		verifyKeys: make(map[string][]byte, len(cfg.Keys)),
 	}
+	for _, key := range cfg.Keys {
+		s.verifyKeys[key.ID] = []byte(key.Secret)
+	}
+	if len(cfg.Keys) > 0 {
+		s.signingKeyID = cfg.CurrentKeyID
+		if s.signingKeyID == "" {
+			s.signingKeyID = cfg.Keys[0].ID
+		}
+		s.signingKey = s.verifyKeys[s.signingKeyID]
+	}
+	return s
 }
 
 // GenerateAccessToken 生成访问令牌
 // 访问令牌用于 API 认证，有效期较短
 func (s *jwtService) GenerateAccessToken(user *model.User) (string, error) {
-	return s.generateToken(user, TokenTypeAccess, s.config.AccessTokenExpireDuration())
+	return s.GenerateAccessTokenWithClaims(user, nil)
+}
+
+// GenerateAccessTokenWithClaims 生成带额外声明的访问令牌
+// 额外声明验证后可通过 TokenClaims.Extra 或 Claim 读取
+func (s *jwtService) GenerateAccessTokenWithClaims(user *model.User, extra map[string]interface{}) (string, error) {
+	token, _, err := s.generateToken(user, TokenTypeAccess, s.config.AccessTokenExpireDuration(), extra)
+	return token, err
+}
+
+// GenerateImpersonationToken 生成模拟访问令牌
+// 模拟令牌只有访问令牌、不签发刷新令牌，有效期由调用方指定（通常较短）
+func (s *jwtService) GenerateImpersonationToken(user *model.User, operatorID string, expiration time.Duration) (string, *TokenClaims, error) {
+	claims := s.newClaims(user, TokenTypeAccess, expiration, nil)
+	claims.ImpersonatedBy = operatorID
+	token, err := s.sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
 }
 
 // GenerateRefreshToken 生成刷新令牌
 // 刷新令牌用于获取新的访问令牌，有效期较长
 func (s *jwtService) GenerateRefreshToken(user *model.User) (string, error) {
-	return s.generateToken(user, TokenTypeRefresh, s.config.RefreshTokenExpireDuration())
+	token, _, err := s.IssueRefreshToken(user)
+	return token, err
+}
+
+// IssueRefreshToken 生成刷新令牌并返回其声明
+// 调用方可以据此将 jti 与过期时间入库，以支持撤销
+func (s *jwtService) IssueRefreshToken(user *model.User) (string, *TokenClaims, error) {
+	return s.generateToken(user, TokenTypeRefresh, s.config.RefreshTokenExpireDuration(), nil)
+}
+
+// RenewAccessToken 以用户当前信息为原令牌续签访问令牌
+// 角色等用户信息与额外声明由调用方从数据库重新读取，不沿用原令牌；
+// 新令牌沿用原令牌的会话开始时间，会话最长不超过刷新令牌有效期，超过后需重新登录
+func (s *jwtService) RenewAccessToken(claims *TokenClaims, user *model.User, extra map[string]interface{}) (string, error) {
+	sessionEnd := claims.SessionStart().Add(s.config.RefreshTokenExpireDuration())
+	if !time.Now().Before(sessionEnd) {
+		return "", apperrors.ErrTokenExpired.WithMessage("会话已超过最长时长，请重新登录")
+	}
+
+	renewed := s.newClaims(user, TokenTypeAccess, s.config.AccessTokenExpireDuration(), extra)
+	renewed.AuthTime = jwt.NewNumericDate(claims.SessionStart())
+	if renewed.ExpiresAt.Time.After(sessionEnd) {
+		renewed.ExpiresAt = jwt.NewNumericDate(sessionEnd)
+	}
+	return s.sign(renewed)
 }
 
 // GenerateTokenPair 生成访问令牌和刷新令牌对
@@ -102,15 +211,30 @@ func (s *jwtService) GenerateTokenPair(user *model.User) (accessToken, refreshTo
 }
 
 // generateToken 生成 JWT 令牌
-func (s *jwtService) generateToken(user *model.User, tokenType TokenType, expiration time.Duration) (string, error) {
+// 每个令牌都带有唯一的 jti，返回签名后的令牌字符串及其声明
+func (s *jwtService) generateToken(user *model.User, tokenType TokenType, expiration time.Duration, extra map[string]interface{}) (string, *TokenClaims, error) {
+	claims := s.newClaims(user, tokenType, expiration, extra)
+	tokenString, err := s.sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return tokenString, claims, nil
+}
+
+// newClaims 构建令牌声明
+func (s *jwtService) newClaims(user *model.User, tokenType TokenType, expiration time.Duration, extra map[string]interface{}) *TokenClaims {
 	now := time.Now()
-	claims := &TokenClaims{
-		UserID:    user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		Role:      user.Role,
-		TokenType: tokenType,
+	return &TokenClaims{
+		UserID:       user.ID,
+		Username:     user.Username,
+		Email:        user.Email,
+		Role:         user.Role,
+		TokenType:    tokenType,
+		TokenVersion: user.TokenVersion,
+		Extra:        extra,
 		RegisteredClaims: jwt.RegisteredClaims{
+			// 令牌唯一标识
+			ID: uuid.New().String(),
 			// 签发者
 			Issuer: s.config.Issuer,
 			// 主题（用户 ID）
@@ -123,17 +247,18 @@ func (s *jwtService) generateToken(user *model.User, tokenType TokenType, expira
 			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
+}
 
+// sign 使用当前签名密钥对声明签名
+func (s *jwtService) sign(claims *TokenClaims) (string, error) {
 	// 创建令牌
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// 签名并获取完整的编码后的字符串令牌
-	tokenString, err := token.SignedString([]byte(s.config.Secret))
-	if err != nil {
-		return "", err
+	if s.signingKeyID != "" {
+		token.Header["kid"] = s.signingKeyID
 	}
 
-	return tokenString, nil
+	// 签名并获取完整的编码后的字符串令牌
+	return token.SignedString(s.signingKey)
 }
 
 // ValidateToken 验证并解析令牌
@@ -145,7 +270,7 @@ func (s *jwtService) ValidateToken(tokenString string) (*TokenClaims, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, apperrors.ErrTokenMalformed.WithDetail("无效的签名算法")
 		}
-		return []byte(s.config.Secret), nil
+		return s.verificationKey(token)
 	})
 
 	// 处理解析错误
@@ -167,6 +292,25 @@ func (s *jwtService) ValidateToken(tokenString string) (*TokenClaims, error) {
 	return claims, nil
 }
 
+// verificationKey 按令牌头中的 kid 选择验证密钥
+// 不带 kid 的令牌只在显式配置了单一密钥 Secret 时接受：
+// 未配置密钥集合时 Secret 即签名密钥；配置了密钥集合时 Secret 是兼容轮转前旧令牌的遗留密钥，为空则拒绝
+func (s *jwtService) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if s.config.Secret == "" {
+			return nil, apperrors.ErrInvalidToken.WithDetail("令牌缺少密钥标识")
+		}
+		return []byte(s.config.Secret), nil
+	}
+
+	key, ok := s.verifyKeys[kid]
+	if !ok {
+		return nil, apperrors.ErrInvalidToken.WithDetail("未知的签名密钥")
+	}
+	return key, nil
+}
+
 // ParseTokenUnvalidated 解析令牌但不验证
 // 仅用于调试目的，不应在生产环境使用
 func (s *jwtService) ParseTokenUnvalidated(tokenString string) (*TokenClaims, error) {
@@ -193,6 +337,11 @@ func (s *jwtService) GetRefreshTokenExpiration() time.Duration {
 	return s.config.RefreshTokenExpireDuration()
 }
 
+// GetRenewThreshold 获取访问令牌自动续签阈值
+func (s *jwtService) GetRenewThreshold() time.Duration {
+	return s.config.RenewThresholdDuration()
+}
+
 // handleParseError 处理令牌解析错误
 func (s *jwtService) handleParseError(err error) *apperrors.AppError {
 	// 检查是否是过期错误
@@ -224,6 +373,11 @@ func (c *TokenClaims) IsAccessToken() bool {
 	return c.TokenType == TokenTypeAccess
 }
 
+// IsImpersonated 检查是否是模拟登录令牌
+func (c *TokenClaims) IsImpersonated() bool {
+	return c.ImpersonatedBy != ""
+}
+
 // IsRefreshToken 检查是否是刷新令牌
 func (c *TokenClaims) IsRefreshToken() bool {
 	return c.TokenType == TokenTypeRefresh
@@ -237,6 +391,28 @@ func (c *TokenClaims) IsExpired() bool {
 	return time.Now().After(c.ExpiresAt.Time)
 }
 
+// Claim 读取签发时注入的额外声明
+// 经 JSON 编解码后数字为 float64、数组为 []interface{}
+func (c *TokenClaims) Claim(key string) (interface{}, bool) {
+	if c == nil || c.Extra == nil {
+		return nil, false
+	}
+	v, ok := c.Extra[key]
+	return v, ok
+}
+
+// SessionStart 返回会话开始时间
+// 未带 auth_time 的令牌以签发时间为准
+func (c *TokenClaims) SessionStart() time.Time {
+	if c.AuthTime != nil {
+		return c.AuthTime.Time
+	}
+	if c.IssuedAt != nil {
+		return c.IssuedAt.Time
+	}
+	return time.Time{}
+}
+
 // TimeToExpire 返回距离过期的时间
 // 如果已过期，返回负数
 func (c *TokenClaims) TimeToExpire() time.Duration {
@@ -0,0 +1,197 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含 JWT 密钥轮转与访问令牌续签的单元测试
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRotationJWTConfig 创建带密钥集合的 JWT 配置
+func newRotationJWTConfig(currentKeyID string, keys ...config.JWTKey) *config.JWTConfig {
+	cfg := newTestConfig().JWT
+	cfg.Keys = keys
+	cfg.CurrentKeyID = currentKeyID
+	return &cfg
+}
+
+// tokenKeyID 读取令牌头中的 kid
+func tokenKeyID(t *testing.T, tokenString string) string {
+	t.Helper()
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &TokenClaims{})
+	require.NoError(t, err)
+	kid, _ := token.Header["kid"].(string)
+	return kid
+}
+
+func TestJWTService_KeyRotation(t *testing.T) {
+	// ========================================
+	// 准备
+	// ========================================
+	user := newTestUser()
+	oldKey := config.JWTKey{ID: "2024-01", Secret: "old-secret-key-0001"}
+	newKey := config.JWTKey{ID: "2024-06", Secret: "new-secret-key-0002"}
+
+	// 轮转前：只有旧密钥
+	before := NewJWTService(newRotationJWTConfig("2024-01", oldKey))
+	oldToken, err := before.GenerateAccessToken(user)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01", tokenKeyID(t, oldToken))
+
+	// ========================================
+	// 执行：轮转到新密钥，旧密钥保留用于验证
+	// ========================================
+	after := NewJWTService(newRotationJWTConfig("2024-06", oldKey, newKey))
+	newToken, err := after.GenerateAccessToken(user)
+	require.NoError(t, err)
+
+	// ========================================
+	// 断言
+	// ========================================
+	// 新令牌使用新密钥
+	assert.Equal(t, "2024-06", tokenKeyID(t, newToken))
+	_, err = jwt.Parse(newToken, func(*jwt.Token) (interface{}, error) { return []byte(newKey.Secret), nil })
+	assert.NoError(t, err)
+
+	// 旧密钥签发的令牌仍可验证
+	claims, err := after.ValidateToken(oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+
+	claims, err = after.ValidateToken(newToken)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+
+	// 只认识旧密钥的实例无法验证新令牌
+	_, err = before.ValidateToken(newToken)
+	assert.ErrorIs(t, err, errors.ErrInvalidToken)
+}
+
+func TestJWTService_KeyRotation_RetiredKey(t *testing.T) {
+	// ========================================
+	// 准备
+	// ========================================
+	user := newTestUser()
+	oldKey := config.JWTKey{ID: "2024-01", Secret: "old-secret-key-0001"}
+	newKey := config.JWTKey{ID: "2024-06", Secret: "new-secret-key-0002"}
+
+	oldToken, err := NewJWTService(newRotationJWTConfig("2024-01", oldKey)).GenerateAccessToken(user)
+	require.NoError(t, err)
+
+	// ========================================
+	// 执行：过渡期结束，旧密钥从集合中移除
+	// ========================================
+	svc := NewJWTService(newRotationJWTConfig("2024-06", newKey))
+	_, err = svc.ValidateToken(oldToken)
+
+	// ========================================
+	// 断言
+	// ========================================
+	assert.ErrorIs(t, err, errors.ErrInvalidToken)
+}
+
+func TestJWTService_KeyRotation_LegacySecret(t *testing.T) {
+	// ========================================
+	// 准备
+	// ========================================
+	user := newTestUser()
+	legacy := NewJWTService(&newTestConfig().JWT)
+	legacyToken, err := legacy.GenerateAccessToken(user)
+	require.NoError(t, err)
+	assert.Empty(t, tokenKeyID(t, legacyToken))
+
+	// ========================================
+	// 执行：启用密钥集合，Secret 保留
+	// ========================================
+	svc := NewJWTService(newRotationJWTConfig("", config.JWTKey{ID: "2024-06", Secret: "new-secret-key-0002"}))
+	newToken, err := svc.GenerateAccessToken(user)
+	require.NoError(t, err)
+
+	// ========================================
+	// 断言
+	// ========================================
+	// 未指定 current_key_id 时使用第一个密钥
+	assert.Equal(t, "2024-06", tokenKeyID(t, newToken))
+
+	// 启用轮转前签发的无 kid 令牌仍按 Secret 验证
+	claims, err := svc.ValidateToken(legacyToken)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+}
+
+func TestJWTService_KeyRotation_NoLegacySecretRejectsKidless(t *testing.T) {
+	// 准备：攻击者用公开的旧默认密钥签发不带 kid 的令牌
+	user := newTestUser()
+	forgedCfg := newTestConfig().JWT
+	forgedCfg.Secret = "your-secret-key"
+	forged, err := NewJWTService(&forgedCfg).GenerateAccessToken(user)
+	require.NoError(t, err)
+
+	// 执行：启用密钥集合且未配置遗留密钥
+	cfg := newRotationJWTConfig("", config.JWTKey{ID: "2024-06", Secret: "new-secret-key-0002"})
+	cfg.Secret = ""
+	_, err = NewJWTService(cfg).ValidateToken(forged)
+
+	// 断言
+	assert.ErrorIs(t, err, errors.ErrInvalidToken)
+}
+
+func TestJWTService_RenewAccessToken(t *testing.T) {
+	// ========================================
+	// 准备：原令牌签发后用户被提升为管理员
+	// ========================================
+	cfg := newTestConfig().JWT
+	jwtService := NewJWTService(&cfg)
+	user := newTestUser()
+	token, err := jwtService.GenerateAccessToken(user)
+	require.NoError(t, err)
+	claims, err := jwtService.ValidateToken(token)
+	require.NoError(t, err)
+
+	current := *user
+	current.Role = model.RoleAdmin
+
+	// ========================================
+	// 执行
+	// ========================================
+	renewed, err := jwtService.RenewAccessToken(claims, &current, nil)
+	require.NoError(t, err)
+
+	// ========================================
+	// 断言：使用用户当前角色，会话开始时间沿用原令牌
+	// ========================================
+	renewedClaims, err := jwtService.ValidateToken(renewed)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, renewedClaims.Role)
+	assert.Equal(t, claims.IssuedAt.Unix(), renewedClaims.SessionStart().Unix())
+}
+
+func TestJWTService_RenewAccessToken_CapsSessionAge(t *testing.T) {
+	// 准备：刷新令牌有效期 168 小时
+	cfg := newTestConfig().JWT
+	jwtService := NewJWTService(&cfg)
+	user := newTestUser()
+	claims := &TokenClaims{UserID: user.ID, TokenType: TokenTypeAccess}
+
+	// 会话已超过最长时长：拒绝续签
+	claims.AuthTime = jwt.NewNumericDate(time.Now().Add(-169 * time.Hour))
+	_, err := jwtService.RenewAccessToken(claims, user, nil)
+	assert.ErrorIs(t, err, errors.ErrTokenExpired)
+
+	// 接近最长时长：新令牌的过期时间不超过会话结束时间
+	start := time.Now().Add(-167 * time.Hour)
+	claims.AuthTime = jwt.NewNumericDate(start)
+	renewed, err := jwtService.RenewAccessToken(claims, user, nil)
+	require.NoError(t, err)
+	renewedClaims, err := jwtService.ValidateToken(renewed)
+	require.NoError(t, err)
+	assert.WithinDuration(t, start.Add(168*time.Hour), renewedClaims.ExpiresAt.Time, time.Second)
+}
@@ -0,0 +1,105 @@
+// Package service 提供业务逻辑层的实现
+package service
+
+import (
+	"context"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/repository"
+	"github.com/example/go-user-api/pkg/geoip"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+// GeoResolver IP 地理位置解析接口
+// 无法确定位置时返回 nil 且不返回错误
+type GeoResolver interface {
+	Resolve(ctx context.Context, ip string) (*geoip.Location, error)
+}
+
+// LoginAnomalyHook 异常登录通知钩子
+// 在安全事件写入后同步调用，实现方如需发送邮件等耗时操作应自行异步处理
+type LoginAnomalyHook func(ctx context.Context, user *model.User, event *model.SecurityEvent)
+
+// loginAnomalyDetector 登录地点异常检测
+type loginAnomalyDetector struct {
+	events repository.SecurityEventRepository
+	geo    GeoResolver
+	hook   LoginAnomalyHook
+	log    logger.Logger
+}
+
+// WithLoginAnomalyDetection 启用登录地点异常检测
+// 每次登录成功后比较本次 IP 与上次登录 IP：
+//   - 配置了 geo 且两次 IP 都能解析出国家时，国家不同才视为异常
+//   - 否则 IP 不同即视为异常
+//
+// 检测到异常时写入安全事件并调用 hook（可为 nil）；检测失败不影响登录结果
+func WithLoginAnomalyDetection(events repository.SecurityEventRepository, geo GeoResolver, hook LoginAnomalyHook) UserServiceOption {
+	return func(s *userService) {
+		if events == nil {
+			return
+		}
+		s.anomalyDetector = &loginAnomalyDetector{
+			events: events,
+			geo:    geo,
+			hook:   hook,
+			log:    s.log,
+		}
+	}
+}
+
+// check 检测本次登录是否异常，异常时返回已记录的安全事件
+// previousIP 为空（首次登录）时不做判断
+func (d *loginAnomalyDetector) check(ctx context.Context, user *model.User, previousIP, clientIP string) *model.SecurityEvent {
+	if previousIP == "" || clientIP == "" || previousIP == clientIP {
+		return nil
+	}
+
+	event := &model.SecurityEvent{
+		UserID:     user.ID,
+		Type:       model.SecurityEventLoginAnomaly,
+		IP:         clientIP,
+		PreviousIP: previousIP,
+		Detail:     "登录 IP 与上次不同",
+	}
+
+	if d.geo != nil {
+		current := d.resolve(ctx, clientIP)
+		previous := d.resolve(ctx, previousIP)
+		if current != nil && previous != nil {
+			if current.Country == previous.Country {
+				return nil
+			}
+			event.Location = current.String()
+			event.PreviousLocation = previous.String()
+			event.Detail = "登录地点与上次不同"
+		}
+	}
+
+	d.log.Warn("检测到异常地点登录",
+		logger.String("user_id", user.ID),
+		logger.String("ip", clientIP),
+		logger.String("previous_ip", previousIP),
+		logger.String("location", event.Location),
+		logger.String("previous_location", event.PreviousLocation),
+	)
+
+	if err := d.events.Create(ctx, event); err != nil {
+		d.log.Error("记录安全事件失败", logger.Err(err))
+		return nil
+	}
+	if d.hook != nil {
+		d.hook(ctx, user, event)
+	}
+	return event
+}
+
+// resolve 解析 IP 位置，失败时记录日志并视为未知
+func (d *loginAnomalyDetector) resolve(ctx context.Context, ip string) *geoip.Location {
+	loc, err := d.geo.Resolve(ctx, ip)
+	if err != nil {
+		d.log.Debug("解析 IP 地理位置失败", logger.String("ip", ip), logger.Err(err))
+		return nil
+	}
+	return loc
+}
@@ -0,0 +1,144 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含登录地点异常检测的单元测试
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/geoip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================
+// Mock 安全事件仓储与地理位置解析
+// ============================================================
+
+// MockSecurityEventRepository 是 SecurityEventRepository 接口的模拟实现
+type MockSecurityEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockSecurityEventRepository) Create(ctx context.Context, event *model.SecurityEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockSecurityEventRepository) ListRecentByUser(ctx context.Context, userID string, limit int) ([]model.SecurityEvent, error) {
+	args := m.Called(ctx, userID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.SecurityEvent), args.Error(1)
+}
+
+// mapGeoResolver 按预设映射解析 IP
+type mapGeoResolver map[string]*geoip.Location
+
+func (m mapGeoResolver) Resolve(_ context.Context, ip string) (*geoip.Location, error) {
+	return m[ip], nil
+}
+
+// ============================================================
+// 登录异常检测测试
+// ============================================================
+
+// loginWithAnomalyDetection 以上次登录 IP previousIP 的用户从 clientIP 登录
+// 返回安全事件仓储 mock 与钩子收到的事件
+func loginWithAnomalyDetection(t *testing.T, geo GeoResolver, previousIP, clientIP string, expectEvent bool) (*MockSecurityEventRepository, []*model.SecurityEvent) {
+	t.Helper()
+
+	// 准备
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockEvents := new(MockSecurityEventRepository)
+	cfg := newTestConfig()
+
+	var hooked []*model.SecurityEvent
+	hook := func(_ context.Context, _ *model.User, event *model.SecurityEvent) {
+		hooked = append(hooked, event)
+	}
+	usrService := NewUserService(mockRepo, mockTokenRepo, NewJWTService(&cfg.JWT), cfg, newTestLogger(),
+		WithLoginAnomalyDetection(mockEvents, geo, hook),
+	)
+
+	hashedPassword, err := usrService.(*userService).hashPassword("password123")
+	require.NoError(t, err)
+	testUser := newTestUser()
+	testUser.Password = hashedPassword
+	testUser.LastLoginIP = previousIP
+
+	ctx := context.Background()
+
+	// 设置 mock 期望
+	mockRepo.On("GetByUsernameOrEmail", ctx, testUser.Username).Return(testUser, nil)
+	mockRepo.On("UpdateLastLogin", ctx, testUser.ID, clientIP).Return(nil)
+	mockTokenRepo.On("Create", ctx, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+	if expectEvent {
+		mockEvents.On("Create", ctx, mock.AnythingOfType("*model.SecurityEvent")).Return(nil)
+	}
+
+	// 执行
+	resp, err := usrService.Login(ctx, &model.LoginRequest{Username: testUser.Username, Password: "password123"}, clientIP)
+
+	// 断言：检测结果不影响登录
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	return mockEvents, hooked
+}
+
+func TestUserService_Login_AnomalyOnIPChange(t *testing.T) {
+	mockEvents, hooked := loginWithAnomalyDetection(t, nil, "203.0.113.10", "198.51.100.20", true)
+
+	mockEvents.AssertNumberOfCalls(t, "Create", 1)
+	event := mockEvents.Calls[0].Arguments.Get(1).(*model.SecurityEvent)
+	assert.Equal(t, model.SecurityEventLoginAnomaly, event.Type)
+	assert.Equal(t, "test-user-id", event.UserID)
+	assert.Equal(t, "198.51.100.20", event.IP)
+	assert.Equal(t, "203.0.113.10", event.PreviousIP)
+	require.Len(t, hooked, 1)
+	assert.Same(t, event, hooked[0])
+}
+
+func TestUserService_Login_NoAnomalyOnSameIP(t *testing.T) {
+	mockEvents, hooked := loginWithAnomalyDetection(t, nil, "203.0.113.10", "203.0.113.10", false)
+
+	mockEvents.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	assert.Empty(t, hooked)
+}
+
+func TestUserService_Login_NoAnomalyOnFirstLogin(t *testing.T) {
+	mockEvents, hooked := loginWithAnomalyDetection(t, nil, "", "203.0.113.10", false)
+
+	mockEvents.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	assert.Empty(t, hooked)
+}
+
+func TestUserService_Login_GeoSameCountryNotAnomalous(t *testing.T) {
+	geo := mapGeoResolver{
+		"203.0.113.10":  {Country: "CN", City: "Shanghai"},
+		"198.51.100.20": {Country: "CN", City: "Beijing"},
+	}
+	mockEvents, hooked := loginWithAnomalyDetection(t, geo, "203.0.113.10", "198.51.100.20", false)
+
+	mockEvents.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	assert.Empty(t, hooked)
+}
+
+func TestUserService_Login_GeoCountryChangeAnomalous(t *testing.T) {
+	geo := mapGeoResolver{
+		"203.0.113.10":  {Country: "CN", City: "Shanghai"},
+		"198.51.100.20": {Country: "US", Region: "California"},
+	}
+	mockEvents, hooked := loginWithAnomalyDetection(t, geo, "203.0.113.10", "198.51.100.20", true)
+
+	mockEvents.AssertNumberOfCalls(t, "Create", 1)
+	event := mockEvents.Calls[0].Arguments.Get(1).(*model.SecurityEvent)
+	assert.Equal(t, "US/California", event.Location)
+	assert.Equal(t, "CN/Shanghai", event.PreviousLocation)
+	require.Len(t, hooked, 1)
+}
@@ -0,0 +1,96 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件实现了用户在线状态：认证中间件在每次请求时记录用户活跃，
+// 最近活跃时间在配置的时间窗口内即视为在线。
+// 为避免每个请求都写库，同一用户在 activityWriteInterval 内只更新一次活跃时间；
+// 节流记录保存在进程内存中，多实例部署时每个实例分别节流。
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+// activityWriteInterval 同一用户两次写入活跃时间的最小间隔
+const activityWriteInterval = time.Minute
+
+// activityTracker 活跃时间写入节流器
+type activityTracker struct {
+	mu      sync.Mutex
+	written map[string]time.Time
+	now     func() time.Time
+}
+
+// newActivityTracker 创建活跃时间写入节流器
+func newActivityTracker() *activityTracker {
+	return &activityTracker{written: make(map[string]time.Time), now: time.Now}
+}
+
+// allow 判断本次是否需要写入用户的活跃时间，需要时同时记下写入时间
+func (t *activityTracker) allow(userID string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if last, ok := t.written[userID]; ok && now.Sub(last) < activityWriteInterval {
+		return false
+	}
+	t.written[userID] = now
+	t.pruneLocked(now)
+	return true
+}
+
+// forget 撤销 allow 记下的写入时间，写库失败时调用，下次请求重试
+func (t *activityTracker) forget(userID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.written, userID)
+}
+
+// pruneLocked 清理超过节流间隔的记录，避免长时间运行后内存持续增长，调用方需持有锁
+// 只在记录较多时清理，摊销遍历开销
+func (t *activityTracker) pruneLocked(now time.Time) {
+	if len(t.written) < 1024 {
+		return
+	}
+	for id, last := range t.written {
+		if now.Sub(last) >= activityWriteInterval {
+			delete(t.written, id)
+		}
+	}
+}
+
+// RecordActivity 记录用户活跃
+// 由认证中间件在请求通过认证后调用；写库失败只记录日志，不影响请求
+func (s *userService) RecordActivity(ctx context.Context, userID string) {
+	now := s.activity.now()
+	if !s.activity.allow(userID, now) {
+		return
+	}
+	if err := s.userRepo.UpdateLastActive(ctx, userID, now); err != nil {
+		s.activity.forget(userID)
+		s.log.Warn("更新最近活跃时间失败",
+			logger.String("user_id", userID),
+			logger.Err(err),
+		)
+	}
+}
+
+// GetOnlineStatus 查询用户在线状态
+// 最近活跃时间在 security.online_threshold_minutes 以内视为在线，从未活跃的用户视为离线
+func (s *userService) GetOnlineStatus(ctx context.Context, id string) (*model.OnlineStatusResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	online := user.LastActiveAt != nil &&
+		s.activity.now().Sub(*user.LastActiveAt) <= s.config.Security.OnlineThreshold()
+	return &model.OnlineStatusResponse{
+		Online:       online,
+		LastActiveAt: user.LastActiveAt,
+	}, nil
+}
@@ -0,0 +1,114 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含用户在线状态的单元测试
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newOnlineStatusTestService 创建当前时间固定的用户服务，在线判定窗口为 5 分钟
+func newOnlineStatusTestService(now time.Time) (*userService, *MockUserRepository) {
+	mockRepo := new(MockUserRepository)
+	svc := NewUserService(mockRepo, new(MockRefreshTokenRepository), nil, newTestConfig(), newTestLogger()).(*userService)
+	svc.activity.now = func() time.Time { return now }
+	return svc, mockRepo
+}
+
+func TestUserService_GetOnlineStatus(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-2 * time.Minute)
+	stale := now.Add(-2 * time.Hour)
+
+	tests := []struct {
+		name         string
+		lastActiveAt *time.Time
+		expected     bool
+	}{
+		{name: "窗口内活跃视为在线", lastActiveAt: &recent, expected: true},
+		{name: "久未活跃视为离线", lastActiveAt: &stale, expected: false},
+		{name: "从未活跃视为离线", lastActiveAt: nil, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 准备
+			svc, mockRepo := newOnlineStatusTestService(now)
+			ctx := context.Background()
+			user := newTestUser()
+			user.LastActiveAt = tt.lastActiveAt
+
+			// 设置 mock 期望
+			mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+
+			// 执行
+			status, err := svc.GetOnlineStatus(ctx, user.ID)
+
+			// 断言
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, status.Online)
+			assert.Equal(t, tt.lastActiveAt, status.LastActiveAt)
+		})
+	}
+}
+
+func TestUserService_GetOnlineStatus_UserNotFound(t *testing.T) {
+	// 准备
+	svc, mockRepo := newOnlineStatusTestService(time.Now())
+	ctx := context.Background()
+
+	// 设置 mock 期望
+	mockRepo.On("GetByID", ctx, "missing").Return(nil, errors.ErrUserNotFound)
+
+	// 执行
+	status, err := svc.GetOnlineStatus(ctx, "missing")
+
+	// 断言
+	assert.Nil(t, status)
+	assert.ErrorIs(t, err, errors.ErrUserNotFound)
+}
+
+func TestUserService_RecordActivity_Throttled(t *testing.T) {
+	// 准备
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	svc, mockRepo := newOnlineStatusTestService(now)
+	ctx := context.Background()
+
+	// 设置 mock 期望：间隔内的多次请求只写一次库
+	mockRepo.On("UpdateLastActive", ctx, "test-user-id", mock.AnythingOfType("time.Time")).Return(nil)
+
+	// 执行
+	svc.RecordActivity(ctx, "test-user-id")
+	svc.RecordActivity(ctx, "test-user-id")
+	svc.activity.now = func() time.Time { return now.Add(activityWriteInterval) }
+	svc.RecordActivity(ctx, "test-user-id")
+
+	// 断言
+	mockRepo.AssertNumberOfCalls(t, "UpdateLastActive", 2)
+}
+
+func TestUserService_RecordActivity_RetriesAfterFailure(t *testing.T) {
+	// 准备
+	svc, mockRepo := newOnlineStatusTestService(time.Now())
+	ctx := context.Background()
+
+	// 设置 mock 期望：首次写库失败后不进入节流
+	mockRepo.On("UpdateLastActive", ctx, "test-user-id", mock.AnythingOfType("time.Time")).
+		Return(errors.ErrDatabaseError).Once()
+	mockRepo.On("UpdateLastActive", ctx, "test-user-id", mock.AnythingOfType("time.Time")).
+		Return(nil).Once()
+
+	// 执行
+	svc.RecordActivity(ctx, "test-user-id")
+	svc.RecordActivity(ctx, "test-user-id")
+
+	// 断言
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,70 @@
+// Package service 提供业务逻辑层的实现
+package service
+
+import (
+	"context"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/repository"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+// WithPasswordHistoryRepository 启用密码历史防重用
+// 修改密码时新密码不能与当前密码及最近 SecurityConfig.PasswordHistoryCount 个旧密码相同，
+// 修改成功后把被替换的密码哈希写入历史并清理超出个数的旧记录
+func WithPasswordHistoryRepository(repo repository.PasswordHistoryRepository) UserServiceOption {
+	return func(s *userService) {
+		s.passwordHistoryRepo = repo
+	}
+}
+
+// checkPasswordReuse 检查新密码是否与当前密码或最近的旧密码相同
+// 调用前已验证 oldPassword 与当前密码匹配，因此当前密码直接比较明文即可
+func (s *userService) checkPasswordReuse(ctx context.Context, userID, oldPassword, newPassword string) error {
+	if s.passwordHistoryRepo == nil {
+		return nil
+	}
+	if newPassword == oldPassword {
+		return errors.ErrPasswordReused
+	}
+
+	count := s.config.Security.PasswordHistoryCount
+	if count <= 0 {
+		return nil
+	}
+	histories, err := s.passwordHistoryRepo.ListRecentByUser(ctx, userID, count)
+	if err != nil {
+		s.log.Error("查询密码历史失败", logger.Err(err))
+		return err
+	}
+	// bcrypt 哈希带随机盐，只能逐个比对
+	for _, h := range histories {
+		if s.checkPassword(newPassword, h.PasswordHash) {
+			return errors.ErrPasswordReused
+		}
+	}
+	return nil
+}
+
+// recordPasswordHistory 记录被替换的旧密码哈希
+// 密码已修改成功，这里失败只记录日志
+func (s *userService) recordPasswordHistory(ctx context.Context, userID, oldHash string) {
+	if s.passwordHistoryRepo == nil {
+		return
+	}
+
+	count := s.config.Security.PasswordHistoryCount
+	if count > 0 {
+		if err := s.passwordHistoryRepo.Create(ctx, &model.PasswordHistory{
+			UserID:       userID,
+			PasswordHash: oldHash,
+		}); err != nil {
+			s.log.Warn("记录密码历史失败", logger.String("user_id", userID), logger.Err(err))
+			return
+		}
+	}
+	if _, err := s.passwordHistoryRepo.PruneByUser(ctx, userID, count); err != nil {
+		s.log.Warn("清理密码历史失败", logger.String("user_id", userID), logger.Err(err))
+	}
+}
@@ -0,0 +1,142 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含密码历史防重用的单元测试
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================
+// Mock 密码历史仓储
+// ============================================================
+
+// MockPasswordHistoryRepository 是 PasswordHistoryRepository 接口的模拟实现
+type MockPasswordHistoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordHistoryRepository) Create(ctx context.Context, history *model.PasswordHistory) error {
+	args := m.Called(ctx, history)
+	return args.Error(0)
+}
+
+func (m *MockPasswordHistoryRepository) ListRecentByUser(ctx context.Context, userID string, limit int) ([]model.PasswordHistory, error) {
+	args := m.Called(ctx, userID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.PasswordHistory), args.Error(1)
+}
+
+func (m *MockPasswordHistoryRepository) PruneByUser(ctx context.Context, userID string, keep int) (int64, error) {
+	args := m.Called(ctx, userID, keep)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// ============================================================
+// 密码历史测试
+// ============================================================
+
+// newPasswordHistoryFixture 创建启用密码历史的用户服务
+// 当前密码为 "currentpass"，历史中有 "recentpass" 与 "olderpass"
+func newPasswordHistoryFixture(t *testing.T) (*userService, *MockUserRepository, *MockRefreshTokenRepository, *MockPasswordHistoryRepository, *model.User) {
+	t.Helper()
+
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockHistory := new(MockPasswordHistoryRepository)
+	cfg := newTestConfig()
+	cfg.Security.PasswordHistoryCount = 3
+	jwtService := NewJWTService(&cfg.JWT)
+	svc := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, newTestLogger(),
+		WithPasswordHistoryRepository(mockHistory),
+	).(*userService)
+
+	hash := func(password string) string {
+		h, err := svc.hashPassword(password)
+		require.NoError(t, err)
+		return h
+	}
+	user := newTestUser()
+	user.Password = hash("currentpass")
+
+	mockRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	mockHistory.On("ListRecentByUser", mock.Anything, user.ID, 3).Return([]model.PasswordHistory{
+		{UserID: user.ID, PasswordHash: hash("recentpass")},
+		{UserID: user.ID, PasswordHash: hash("olderpass")},
+	}, nil)
+
+	return svc, mockRepo, mockTokenRepo, mockHistory, user
+}
+
+func TestUserService_UpdatePassword_RejectsRecentPassword(t *testing.T) {
+	// 准备
+	svc, mockRepo, _, mockHistory, user := newPasswordHistoryFixture(t)
+	ctx := context.Background()
+
+	// 执行：换回最近用过的旧密码
+	err := svc.UpdatePassword(ctx, user.ID, &model.ChangePasswordRequest{
+		OldPassword:     "currentpass",
+		NewPassword:     "olderpass",
+		ConfirmPassword: "olderpass",
+	})
+
+	// 断言
+	assert.True(t, errors.Is(err, errors.ErrPasswordReused))
+	mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	mockHistory.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUserService_UpdatePassword_RejectsCurrentPassword(t *testing.T) {
+	// 准备
+	svc, mockRepo, _, mockHistory, user := newPasswordHistoryFixture(t)
+	ctx := context.Background()
+
+	// 执行：新密码与当前密码相同
+	err := svc.UpdatePassword(ctx, user.ID, &model.ChangePasswordRequest{
+		OldPassword:     "currentpass",
+		NewPassword:     "currentpass",
+		ConfirmPassword: "currentpass",
+	})
+
+	// 断言：无需查询历史即可拒绝
+	assert.True(t, errors.Is(err, errors.ErrPasswordReused))
+	mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	mockHistory.AssertNotCalled(t, "ListRecentByUser", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserService_UpdatePassword_NewPasswordRecordsHistory(t *testing.T) {
+	// 准备
+	svc, mockRepo, mockTokenRepo, mockHistory, user := newPasswordHistoryFixture(t)
+	ctx := context.Background()
+	oldHash := user.Password
+
+	// 设置 mock 期望：修改成功后记录被替换的哈希并裁剪到 3 条
+	mockRepo.On("UpdatePassword", ctx, user.ID, mock.AnythingOfType("string")).Return(nil)
+	mockTokenRepo.On("RevokeAllByUser", ctx, user.ID).Return(nil)
+	mockHistory.On("Create", ctx, mock.MatchedBy(func(h *model.PasswordHistory) bool {
+		return h.UserID == user.ID && h.PasswordHash == oldHash
+	})).Return(nil)
+	mockHistory.On("PruneByUser", ctx, user.ID, 3).Return(int64(0), nil)
+
+	// 执行
+	err := svc.UpdatePassword(ctx, user.ID, &model.ChangePasswordRequest{
+		OldPassword:     "currentpass",
+		NewPassword:     "brandnewpass",
+		ConfirmPassword: "brandnewpass",
+	})
+
+	// 断言
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockTokenRepo.AssertExpectations(t)
+	mockHistory.AssertExpectations(t)
+}
@@ -0,0 +1,251 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件实现了个人访问令牌（PAT）。
+// PAT 是以固定前缀开头的随机字符串，与 JWT 一样通过 Authorization: Bearer 传递，
+// 服务端只保存其 SHA-256 哈希；认证时按哈希查找并校验撤销、过期状态与用户状态。
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/repository"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+const (
+	// PersonalAccessTokenPrefix 个人访问令牌前缀，认证中间件据此区分 PAT 与 JWT
+	PersonalAccessTokenPrefix = "pat_"
+	// maxPersonalAccessTokensPerUser 每个用户可同时持有的有效令牌数
+	maxPersonalAccessTokensPerUser = 20
+	// personalAccessTokenDisplayLen 列表中展示的令牌开头字符数（含前缀）
+	personalAccessTokenDisplayLen = 12
+	// lastUsedUpdateInterval 最近使用时间的更新间隔，避免每次请求都写库
+	lastUsedUpdateInterval = time.Minute
+)
+
+// PersonalAccessTokenService 个人访问令牌服务接口
+type PersonalAccessTokenService interface {
+	// Create 为用户创建令牌，返回值中包含只出现一次的令牌明文
+	Create(ctx context.Context, userID string, req *model.CreatePersonalAccessTokenRequest) (*model.CreatePersonalAccessTokenResponse, error)
+	// List 列出用户未撤销的令牌
+	List(ctx context.Context, userID string) ([]*model.PersonalAccessTokenResponse, error)
+	// Revoke 撤销用户的令牌
+	Revoke(ctx context.Context, userID, id string) error
+	// Authenticate 校验令牌，返回令牌所属用户与令牌记录
+	Authenticate(ctx context.Context, token string) (*model.User, *model.PersonalAccessToken, error)
+}
+
+// personalAccessTokenService 个人访问令牌服务实现
+type personalAccessTokenService struct {
+	tokenRepo repository.PersonalAccessTokenRepository
+	userRepo  repository.UserRepository
+	log       logger.Logger
+	now       func() time.Time
+}
+
+// NewPersonalAccessTokenService 创建个人访问令牌服务实例
+func NewPersonalAccessTokenService(tokenRepo repository.PersonalAccessTokenRepository, userRepo repository.UserRepository, log logger.Logger) PersonalAccessTokenService {
+	return &personalAccessTokenService{
+		tokenRepo: tokenRepo,
+		userRepo:  userRepo,
+		log:       log.With(logger.String("service", "personal_access_token")),
+		now:       time.Now,
+	}
+}
+
+// WithPersonalAccessTokenRepository 设置个人访问令牌仓储
+// 设置后强制下线与修改密码时一并撤销用户的个人访问令牌；PAT 不携带令牌版本，只能通过撤销使其失效
+func WithPersonalAccessTokenRepository(repo repository.PersonalAccessTokenRepository) UserServiceOption {
+	return func(s *userService) {
+		s.personalTokenRepo = repo
+	}
+}
+
+// revokePersonalAccessTokens 撤销用户的所有个人访问令牌
+func (s *userService) revokePersonalAccessTokens(ctx context.Context, userID string) error {
+	if s.personalTokenRepo == nil {
+		return nil
+	}
+	if err := s.personalTokenRepo.RevokeAllByUser(ctx, userID); err != nil {
+		s.log.Error("撤销个人访问令牌失败", logger.String("user_id", userID), logger.Err(err))
+		return err
+	}
+	return nil
+}
+
+// IsPersonalAccessToken 判断令牌字符串是否为个人访问令牌
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// Create 为用户创建令牌
+// 授权范围必须是用户当前拥有的权限
+func (s *personalAccessTokenService) Create(ctx context.Context, userID string, req *model.CreatePersonalAccessTokenRequest) (*model.CreatePersonalAccessTokenResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	scopes, err := normalizeScopes(req.Scopes, user.Permissions())
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.tokenRepo.CountActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxPersonalAccessTokensPerUser {
+		return nil, errors.ErrBadRequest.WithMessage(fmt.Sprintf("每个用户最多持有 %d 个有效的个人访问令牌，请先撤销不用的令牌", maxPersonalAccessTokensPerUser))
+	}
+
+	plain, err := generatePersonalAccessToken()
+	if err != nil {
+		return nil, errors.ErrInternalServer.WithError(err)
+	}
+	record := &model.PersonalAccessToken{
+		UserID:      userID,
+		Name:        req.Name,
+		TokenHash:   hashPersonalAccessToken(plain),
+		TokenPrefix: plain[:personalAccessTokenDisplayLen],
+		Scopes:      strings.Join(scopes, " "),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := s.now().AddDate(0, 0, req.ExpiresInDays)
+		record.ExpiresAt = &expiresAt
+	}
+	if err := s.tokenRepo.Create(ctx, record); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("创建个人访问令牌",
+		logger.String("user_id", userID),
+		logger.String("token_id", record.ID),
+		logger.Any("scopes", scopes),
+	)
+	return &model.CreatePersonalAccessTokenResponse{
+		PersonalAccessTokenResponse: *record.ToResponse(),
+		Token:                       plain,
+	}, nil
+}
+
+// List 列出用户未撤销的令牌
+func (s *personalAccessTokenService) List(ctx context.Context, userID string) ([]*model.PersonalAccessTokenResponse, error) {
+	tokens, err := s.tokenRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*model.PersonalAccessTokenResponse, len(tokens))
+	for i := range tokens {
+		result[i] = tokens[i].ToResponse()
+	}
+	return result, nil
+}
+
+// Revoke 撤销用户的令牌，立即生效
+func (s *personalAccessTokenService) Revoke(ctx context.Context, userID, id string) error {
+	if err := s.tokenRepo.Revoke(ctx, userID, id); err != nil {
+		return err
+	}
+	s.log.Info("撤销个人访问令牌",
+		logger.String("user_id", userID),
+		logger.String("token_id", id),
+	)
+	return nil
+}
+
+// Authenticate 校验令牌
+// 令牌不存在返回 ErrInvalidToken，已撤销返回 ErrTokenRevoked，已过期返回 ErrTokenExpired；
+// 用户被删除或禁用时令牌同样不可用
+func (s *personalAccessTokenService) Authenticate(ctx context.Context, token string) (*model.User, *model.PersonalAccessToken, error) {
+	if !IsPersonalAccessToken(token) {
+		return nil, nil, errors.ErrTokenMalformed
+	}
+	record, err := s.tokenRepo.GetByHash(ctx, hashPersonalAccessToken(token))
+	if err != nil {
+		return nil, nil, err
+	}
+	if record.IsRevoked() {
+		return nil, nil, errors.ErrTokenRevoked
+	}
+	if record.IsExpired() {
+		return nil, nil, errors.ErrTokenExpired
+	}
+
+	user, err := s.userRepo.GetByID(ctx, record.UserID)
+	if err != nil {
+		if errors.Is(err, errors.ErrUserNotFound) {
+			return nil, nil, errors.ErrTokenRevoked
+		}
+		return nil, nil, err
+	}
+	if !user.IsActive() {
+		return nil, nil, errors.ErrUserDisabled
+	}
+
+	s.touch(ctx, record)
+	return user, record, nil
+}
+
+// touch 更新令牌最近使用时间，距上次更新不足 lastUsedUpdateInterval 时跳过
+// 写库失败只记录日志，不影响认证
+func (s *personalAccessTokenService) touch(ctx context.Context, record *model.PersonalAccessToken) {
+	now := s.now()
+	if record.LastUsedAt != nil && now.Sub(*record.LastUsedAt) < lastUsedUpdateInterval {
+		return
+	}
+	if err := s.tokenRepo.UpdateLastUsed(ctx, record.ID, now); err != nil {
+		s.log.Warn("更新个人访问令牌使用时间失败",
+			logger.String("token_id", record.ID),
+			logger.Err(err),
+		)
+		return
+	}
+	record.LastUsedAt = &now
+}
+
+// normalizeScopes 去重并排序授权范围，超出 allowed 的范围返回验证错误
+func normalizeScopes(scopes, allowed []string) ([]string, error) {
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, p := range allowed {
+		allowedSet[p] = true
+	}
+
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !allowedSet[scope] {
+			return nil, errors.ErrValidation.WithDetail("授权范围不存在或超出当前权限: " + scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// generatePersonalAccessToken 生成带前缀的 256 位随机令牌
+func generatePersonalAccessToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return PersonalAccessTokenPrefix + hex.EncodeToString(buf), nil
+}
+
+// hashPersonalAccessToken 计算令牌的 SHA-256 哈希
+// 令牌本身是高熵随机串，无需加盐与慢哈希
+func hashPersonalAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
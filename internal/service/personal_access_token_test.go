@@ -0,0 +1,223 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含个人访问令牌的单元测试
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================
+// Mock 个人访问令牌仓储
+// ============================================================
+
+// MockPersonalAccessTokenRepository 是 PersonalAccessTokenRepository 接口的模拟实现
+type MockPersonalAccessTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockPersonalAccessTokenRepository) Create(ctx context.Context, token *model.PersonalAccessToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockPersonalAccessTokenRepository) GetByHash(ctx context.Context, hash string) (*model.PersonalAccessToken, error) {
+	args := m.Called(ctx, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PersonalAccessToken), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) ListByUser(ctx context.Context, userID string) ([]model.PersonalAccessToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.PersonalAccessToken), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) CountActiveByUser(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) Revoke(ctx context.Context, userID, id string) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *MockPersonalAccessTokenRepository) RevokeAllByUser(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockPersonalAccessTokenRepository) RevokeAll(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockPersonalAccessTokenRepository) UpdateLastUsed(ctx context.Context, id string, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func TestPersonalAccessTokenService_Create_StoresHashOnly(t *testing.T) {
+	// 准备
+	tokenRepo := new(MockPersonalAccessTokenRepository)
+	userRepo := new(MockUserRepository)
+	svc := NewPersonalAccessTokenService(tokenRepo, userRepo, newTestLogger())
+	ctx := context.Background()
+	user := newTestUser()
+
+	// 设置 mock 期望
+	var saved *model.PersonalAccessToken
+	userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	tokenRepo.On("CountActiveByUser", ctx, user.ID).Return(int64(0), nil)
+	tokenRepo.On("Create", ctx, mock.AnythingOfType("*model.PersonalAccessToken")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*model.PersonalAccessToken) }).
+		Return(nil)
+
+	// 执行：重复的授权范围去重
+	resp, err := svc.Create(ctx, user.ID, &model.CreatePersonalAccessTokenRequest{
+		Name:          "ci",
+		Scopes:        []string{model.PermissionUsersRead, model.PermissionProfileRead, model.PermissionUsersRead},
+		ExpiresInDays: 30,
+	})
+
+	// 断言
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.True(t, IsPersonalAccessToken(resp.Token))
+	assert.NotEqual(t, resp.Token, saved.TokenHash)
+	assert.Equal(t, hashPersonalAccessToken(resp.Token), saved.TokenHash)
+	assert.Equal(t, "profile:read users:read", saved.Scopes)
+	assert.Equal(t, []string{model.PermissionProfileRead, model.PermissionUsersRead}, resp.Scopes)
+	require.NotNil(t, resp.ExpiresAt)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 30), *resp.ExpiresAt, time.Minute)
+}
+
+func TestPersonalAccessTokenService_Authenticate_Rejected(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	disabled := newTestUser()
+	disabled.Status = model.UserStatusDisabled
+
+	tests := []struct {
+		name     string
+		record   *model.PersonalAccessToken
+		user     *model.User
+		expected *errors.AppError
+	}{
+		{name: "已撤销", record: &model.PersonalAccessToken{UserID: "test-user-id", RevokedAt: &past}, expected: errors.ErrTokenRevoked},
+		{name: "已过期", record: &model.PersonalAccessToken{UserID: "test-user-id", ExpiresAt: &past}, expected: errors.ErrTokenExpired},
+		{name: "用户已禁用", record: &model.PersonalAccessToken{UserID: "test-user-id"}, user: disabled, expected: errors.ErrUserDisabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 准备
+			tokenRepo := new(MockPersonalAccessTokenRepository)
+			userRepo := new(MockUserRepository)
+			svc := NewPersonalAccessTokenService(tokenRepo, userRepo, newTestLogger())
+			ctx := context.Background()
+			token := PersonalAccessTokenPrefix + "secret"
+
+			// 设置 mock 期望
+			tokenRepo.On("GetByHash", ctx, hashPersonalAccessToken(token)).Return(tt.record, nil)
+			if tt.user != nil {
+				userRepo.On("GetByID", ctx, tt.user.ID).Return(tt.user, nil)
+			}
+
+			// 执行
+			_, _, err := svc.Authenticate(ctx, token)
+
+			// 断言：不更新最近使用时间
+			assert.True(t, errors.Is(err, tt.expected), err)
+			tokenRepo.AssertNotCalled(t, "UpdateLastUsed", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUserService_RevokeTokens_RevokesPersonalAccessTokens(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	patRepo := new(MockPersonalAccessTokenRepository)
+	cfg := newTestConfig()
+	svc := NewUserService(mockRepo, mockTokenRepo, NewJWTService(&cfg.JWT), cfg, newTestLogger(),
+		WithPersonalAccessTokenRepository(patRepo))
+	ctx := context.Background()
+
+	// 设置 mock 期望
+	mockRepo.On("IncrementTokenVersion", ctx, "test-user-id").Return(nil)
+	mockTokenRepo.On("RevokeAllByUser", ctx, "test-user-id").Return(nil)
+	patRepo.On("RevokeAllByUser", ctx, "test-user-id").Return(nil)
+
+	// 执行
+	err := svc.RevokeTokens(ctx, "test-user-id")
+
+	// 断言：PAT 不携带令牌版本，需要单独撤销
+	require.NoError(t, err)
+	patRepo.AssertExpectations(t)
+}
+
+func TestUserService_RevokeAllTokens_RevokesPersonalAccessTokens(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	patRepo := new(MockPersonalAccessTokenRepository)
+	cfg := newTestConfig()
+	svc := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger(),
+		WithPersonalAccessTokenRepository(patRepo))
+	ctx := context.Background()
+
+	// 设置 mock 期望
+	mockRepo.On("IncrementAllTokenVersions", ctx).Return(int64(3), nil)
+	patRepo.On("RevokeAll", ctx).Return(nil)
+
+	// 执行
+	affected, err := svc.RevokeAllTokens(ctx)
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), affected)
+	patRepo.AssertExpectations(t)
+}
+
+func TestUserService_UpdatePassword_RevokesPersonalAccessTokens(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	patRepo := new(MockPersonalAccessTokenRepository)
+	cfg := newTestConfig()
+	usrService := NewUserService(mockRepo, mockTokenRepo, NewJWTService(&cfg.JWT), cfg, newTestLogger(),
+		WithPersonalAccessTokenRepository(patRepo))
+	ctx := context.Background()
+	hashedPassword, _ := usrService.(*userService).hashPassword("oldpassword")
+	testUser := newTestUser()
+	testUser.Password = hashedPassword
+
+	// 设置 mock 期望
+	mockRepo.On("GetByID", ctx, testUser.ID).Return(testUser, nil)
+	mockRepo.On("UpdatePassword", ctx, testUser.ID, mock.AnythingOfType("string")).Return(nil)
+	mockTokenRepo.On("RevokeAllByUser", ctx, testUser.ID).Return(nil)
+	patRepo.On("RevokeAllByUser", ctx, testUser.ID).Return(nil)
+
+	// 执行
+	err := usrService.UpdatePassword(ctx, testUser.ID, &model.ChangePasswordRequest{
+		OldPassword:     "oldpassword",
+		NewPassword:     "newpassword123",
+		ConfirmPassword: "newpassword123",
+	})
+
+	// 断言
+	require.NoError(t, err)
+	patRepo.AssertExpectations(t)
+}
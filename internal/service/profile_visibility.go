@@ -0,0 +1,64 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件实现了按资料可见性获取用户资料。
+// 用户可将资料设为 public、friends 或 private，查看他人资料时
+// 根据查看者与资料主人的关系决定返回完整资料、简要信息还是拒绝访问。
+package service
+
+import (
+	"context"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+// FriendshipChecker 好友关系查询
+// 由关注等社交关系的提供方实现，用于资料可见性为 friends 时判断查看者能否看到完整资料
+type FriendshipChecker interface {
+	// AreFriends 判断 viewerID 是否为 userID 的好友
+	AreFriends(ctx context.Context, userID, viewerID string) (bool, error)
+}
+
+// WithFriendshipChecker 设置好友关系查询
+// 本服务没有好友关系数据，默认不设置：可见性为 friends 的资料对本人与管理员以外的所有人只返回简要信息。
+// 嵌入本服务并提供社交关系的代码通过该选项接入好友查询
+func WithFriendshipChecker(checker FriendshipChecker) UserServiceOption {
+	return func(s *userService) {
+		s.friendships = checker
+	}
+}
+
+// GetProfile 按资料可见性获取用户资料
+func (s *userService) GetProfile(ctx context.Context, id string, viewer model.Viewer) (*model.User, model.ProfileAccess, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, model.ProfileAccessDenied, err
+	}
+
+	access := user.ProfileAccessFor(viewer, s.isFriend(ctx, user, viewer))
+	if access == model.ProfileAccessDenied {
+		return nil, access, errors.ErrForbidden.WithMessage("该用户的资料不公开")
+	}
+	return user, access, nil
+}
+
+// isFriend 判断查看者是否为用户的好友
+// 只在资料仅好友可见时查询；查询失败按非好友处理，只返回简要信息
+func (s *userService) isFriend(ctx context.Context, user *model.User, viewer model.Viewer) bool {
+	if s.friendships == nil || user.ProfileVisibility != model.ProfileVisibilityFriends ||
+		viewer == nil || viewer.ViewerID() == "" || viewer.ViewerID() == user.ID {
+		return false
+	}
+
+	ok, err := s.friendships.AreFriends(ctx, user.ID, viewer.ViewerID())
+	if err != nil {
+		s.log.Warn("查询好友关系失败",
+			logger.String("user_id", user.ID),
+			logger.String("viewer_id", viewer.ViewerID()),
+			logger.Err(err),
+		)
+		return false
+	}
+	return ok
+}
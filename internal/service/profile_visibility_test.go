@@ -0,0 +1,131 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含资料可见性的单元测试
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_GetProfile_Private(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+	ctx := context.Background()
+	testUser := newTestUser()
+	testUser.ProfileVisibility = model.ProfileVisibilityPrivate
+
+	// 设置 mock 期望
+	mockRepo.On("GetByID", ctx, testUser.ID).Return(testUser, nil)
+
+	// 执行：陌生人查看
+	user, _, err := userService.GetProfile(ctx, testUser.ID, &TokenClaims{UserID: "stranger", Role: model.RoleUser})
+
+	// 断言：拒绝访问
+	assert.Nil(t, user)
+	assert.True(t, errors.Is(err, errors.ErrForbidden), err)
+
+	// 执行：本人查看
+	user, access, err := userService.GetProfile(ctx, testUser.ID, &TokenClaims{UserID: testUser.ID, Role: model.RoleUser})
+
+	// 断言：完整资料
+	require.NoError(t, err)
+	assert.Equal(t, model.ProfileAccessFull, access)
+	assert.Equal(t, testUser.Email, user.ToResponseFor(&TokenClaims{UserID: testUser.ID}).Email)
+}
+
+// stubFriendships 以固定的好友列表实现 FriendshipChecker
+type stubFriendships map[string]bool
+
+func (f stubFriendships) AreFriends(_ context.Context, _, viewerID string) (bool, error) {
+	return f[viewerID], nil
+}
+
+func TestUserService_GetProfile_FriendsOnly(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	testUser := newTestUser()
+	testUser.ProfileVisibility = model.ProfileVisibilityFriends
+	ctx := context.Background()
+	mockRepo.On("GetByID", ctx, testUser.ID).Return(testUser, nil)
+
+	tests := []struct {
+		name        string
+		friendships FriendshipChecker
+		viewer      *TokenClaims
+		want        model.ProfileAccess
+	}{
+		{"好友", stubFriendships{"friend": true}, &TokenClaims{UserID: "friend", Role: model.RoleUser}, model.ProfileAccessFull},
+		{"陌生人", stubFriendships{"friend": true}, &TokenClaims{UserID: "stranger", Role: model.RoleUser}, model.ProfileAccessBrief},
+		{"未接入好友查询时按非好友处理", nil, &TokenClaims{UserID: "friend", Role: model.RoleUser}, model.ProfileAccessBrief},
+		{"未接入好友查询时本人完整可见", nil, &TokenClaims{UserID: testUser.ID, Role: model.RoleUser}, model.ProfileAccessFull},
+		{"未接入好友查询时管理员完整可见", nil, &TokenClaims{UserID: "admin", Role: model.RoleAdmin}, model.ProfileAccessFull},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []UserServiceOption
+			if tt.friendships != nil {
+				opts = append(opts, WithFriendshipChecker(tt.friendships))
+			}
+			userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger(), opts...)
+
+			// 执行
+			user, access, err := userService.GetProfile(ctx, testUser.ID, tt.viewer)
+
+			// 断言
+			require.NoError(t, err)
+			assert.Equal(t, testUser.ID, user.ID)
+			assert.Equal(t, tt.want, access)
+		})
+	}
+}
+
+func TestUserService_GetManyByIDs_HidesPrivateProfiles(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+	ctx := context.Background()
+	public := model.User{BaseModel: model.BaseModel{ID: "u1"}, Username: "alice", ProfileVisibility: model.ProfileVisibilityPublic}
+	private := model.User{BaseModel: model.BaseModel{ID: "u2"}, Username: "bob", ProfileVisibility: model.ProfileVisibilityPrivate}
+	friendsOnly := model.User{BaseModel: model.BaseModel{ID: "u3"}, Username: "carol", ProfileVisibility: model.ProfileVisibilityFriends}
+
+	// 设置 mock 期望
+	mockRepo.On("GetByIDs", ctx, []string{"u1", "u2", "u3"}).Return([]model.User{public, private, friendsOnly}, nil)
+
+	tests := []struct {
+		name        string
+		viewer      *TokenClaims
+		wantUsers   int
+		wantMissing []string
+	}{
+		{"陌生人", &TokenClaims{UserID: "stranger", Role: model.RoleUser}, 1, []string{"u2", "u3"}},
+		{"未认证", nil, 1, []string{"u2", "u3"}},
+		{"本人", &TokenClaims{UserID: "u2", Role: model.RoleUser}, 2, []string{"u3"}},
+		{"管理员", &TokenClaims{UserID: "admin", Role: model.RoleAdmin}, 3, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 执行
+			var viewer model.Viewer
+			if tt.viewer != nil {
+				viewer = tt.viewer
+			}
+			users, missing, err := userService.GetManyByIDs(ctx, []string{"u1", "u2", "u3"}, viewer)
+
+			// 断言：私密资料、非好友查看的仅好友可见资料与不存在的用户一样列入缺失 ID
+			require.NoError(t, err)
+			assert.Len(t, users, tt.wantUsers)
+			assert.Equal(t, "u1", users[0].ID)
+			assert.Equal(t, tt.wantMissing, missing)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件实现了注册总量节流：按自然小时统计全局注册数，超过上限后拒绝注册。
+// 计数保存在进程内存中，多实例部署时每个实例分别计数。
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// registrationThrottle 按自然小时计数的注册节流器
+// 注册前先 reserve 占用名额，注册失败时 release 归还，保证并发注册也不会超过上限
+type registrationThrottle struct {
+	mu          sync.Mutex
+	limit       int
+	windowStart time.Time
+	count       int
+	now         func() time.Time
+}
+
+// newRegistrationThrottle 创建注册节流器，limit <= 0 时返回 nil 表示不限
+func newRegistrationThrottle(limit int) *registrationThrottle {
+	if limit <= 0 {
+		return nil
+	}
+	return &registrationThrottle{limit: limit, now: time.Now}
+}
+
+// reserve 占用一个注册名额，返回名额所属的小时窗口；本小时名额已用尽时 ok 为 false
+func (t *registrationThrottle) reserve() (window time.Time, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rollLocked()
+	if t.count >= t.limit {
+		return t.windowStart, false
+	}
+	t.count++
+	return t.windowStart, true
+}
+
+// release 归还 reserve 占用的名额
+// 已进入新的小时窗口时计数已重置，归还不再生效
+func (t *registrationThrottle) release(window time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rollLocked()
+	if window.Equal(t.windowStart) && t.count > 0 {
+		t.count--
+	}
+}
+
+// rollLocked 进入新的小时后重置计数，调用方需持有锁
+func (t *registrationThrottle) rollLocked() {
+	window := t.now().Truncate(time.Hour)
+	if !window.Equal(t.windowStart) {
+		t.windowStart = window
+		t.count = 0
+	}
+}
@@ -0,0 +1,91 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含注册开关与每小时注册上限的单元测试
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newRegisterRequest 创建注册请求
+func newRegisterRequest(i int) *model.RegisterRequest {
+	return &model.RegisterRequest{
+		Username:        fmt.Sprintf("user%d", i),
+		Email:           fmt.Sprintf("user%d@example.com", i),
+		Password:        "password123",
+		ConfirmPassword: "password123",
+	}
+}
+
+func TestUserService_Register_Closed(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	cfg.Security.Registration.Enabled = false
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	// 执行
+	user, err := userService.Register(context.Background(), newRegisterRequest(1))
+
+	// 断言：直接拒绝，不访问仓储
+	assert.Nil(t, user)
+	require.ErrorIs(t, err, errors.ErrRegistrationClosed)
+	assert.Equal(t, http.StatusForbidden, err.(*errors.AppError).HTTPStatus)
+	mockRepo.AssertNotCalled(t, "ExistsByUsername", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUserService_Register_HourlyLimit(t *testing.T) {
+	// ========================================
+	// 准备
+	// ========================================
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	cfg.Security.Registration.HourlyLimit = 2
+	svc := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	now := time.Date(2024, 3, 1, 10, 15, 0, 0, time.UTC)
+	svc.(*userService).registrationThrottle.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// ========================================
+	// 设置 mock 期望
+	// ========================================
+	mockRepo.On("ExistsByUsername", ctx, mock.Anything).Return(false, nil)
+	mockRepo.On("ExistsByEmail", ctx, mock.Anything).Return(false, nil)
+	// 第一次创建失败，名额应归还
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(errors.ErrDatabaseError).Once()
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(nil)
+
+	// ========================================
+	// 执行 & 断言
+	// ========================================
+	_, err := svc.Register(ctx, newRegisterRequest(0))
+	require.ErrorIs(t, err, errors.ErrDatabaseError)
+
+	for i := 1; i <= 2; i++ {
+		_, err := svc.Register(ctx, newRegisterRequest(i))
+		require.NoError(t, err, "第 %d 个注册应成功", i)
+	}
+
+	// 本小时已满
+	user, err := svc.Register(ctx, newRegisterRequest(3))
+	assert.Nil(t, user)
+	require.ErrorIs(t, err, errors.ErrTooManyRequests)
+	assert.Equal(t, http.StatusTooManyRequests, err.(*errors.AppError).HTTPStatus)
+
+	// 进入下一个小时后重新计数
+	now = now.Add(time.Hour)
+	_, err = svc.Register(ctx, newRegisterRequest(4))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,190 @@
+// Package service 提供业务逻辑层的实现
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+// quotaPeriod token 配额的统计周期
+// 已用量按请求时间所在的周期窗口实时聚合，跨周期后自然归零，无需重置任务
+type quotaPeriod struct {
+	// name 周期名称，用于日志与缓存键
+	name string
+	// label 超出配额时提示中的周期描述
+	label string
+	// layout 周期在缓存键中的时间格式
+	layout string
+	// quota 返回用户在该周期的配额，0 表示不限
+	quota func(c *config.RiskReportConfig, userID string) int64
+	// window 返回 t 所在周期的起止时间，结束时间包含在内
+	window func(t time.Time) (time.Time, time.Time)
+}
+
+var (
+	// dailyQuota 自然日（UTC）配额
+	dailyQuota = quotaPeriod{
+		name:   "daily",
+		label:  "今日",
+		layout: "2006-01-02",
+		quota:  (*config.RiskReportConfig).DailyTokenQuotaFor,
+		window: dayRange,
+	}
+	// monthlyQuota 自然月（UTC）配额
+	monthlyQuota = quotaPeriod{
+		name:   "monthly",
+		label:  "本月",
+		layout: "2006-01",
+		quota:  (*config.RiskReportConfig).MonthlyTokenQuotaFor,
+		window: monthRange,
+	}
+	// quotaPeriods 上报时依次检查的配额周期
+	quotaPeriods = []quotaPeriod{dailyQuota, monthlyQuota}
+)
+
+// quotaTracker token 配额检查
+// 同一批次内按「用户 + 周期」缓存已用量，批量上报时只查询一次并累加本批次已接受的记录。
+// 配额是软限制：并发上报时可能少量超出
+type quotaTracker struct {
+	s    *riskReportUsageService
+	used map[string]int64
+}
+
+// newQuotaTracker 创建配额检查器
+func (s *riskReportUsageService) newQuotaTracker() *quotaTracker {
+	return &quotaTracker{s: s, used: make(map[string]int64)}
+}
+
+// reserve 检查记录是否超出配额，未超出时计入已用量
+// 按记录的请求时间所在自然日、自然月（UTC）分别统计，任一周期超出即返回 ErrQuotaExceeded
+func (t *quotaTracker) reserve(ctx context.Context, userID string, requestTime time.Time, tokens int) error {
+	if requestTime.IsZero() {
+		requestTime = t.s.now()
+	}
+
+	// 所有周期都通过后才计入已用量，避免部分周期被多算
+	keys := make([]string, 0, len(quotaPeriods))
+	for _, p := range quotaPeriods {
+		quota := p.quota(&t.s.config.RiskReport, userID)
+		if quota <= 0 {
+			continue
+		}
+
+		start, end := p.window(requestTime)
+		key := userID + "|" + p.name + "|" + start.Format(p.layout)
+
+		used, ok := t.used[key]
+		if !ok {
+			var err error
+			used, err = t.s.tokensUsed(ctx, userID, start, end)
+			if err != nil {
+				return err
+			}
+			t.used[key] = used
+		}
+
+		if used+int64(tokens) > quota {
+			t.s.log.Warn("token 配额已用尽",
+				logger.String("user_id", userID),
+				logger.String("period", p.name),
+				logger.String("window", start.Format(p.layout)),
+				logger.Int64("used", used),
+				logger.Int64("quota", quota),
+				logger.Int("tokens", tokens),
+			)
+			return errors.ErrQuotaExceeded.WithDetail(fmt.Sprintf("%s已用 %d / %d token", p.label, used, quota))
+		}
+		keys = append(keys, key)
+	}
+
+	for _, key := range keys {
+		t.used[key] += int64(tokens)
+	}
+	return nil
+}
+
+// GetCurrentQuotaUsage 获取用户当前自然日、自然月的配额用量
+func (s *riskReportUsageService) GetCurrentQuotaUsage(ctx context.Context, userID string) (*model.QuotaUsageResponse, error) {
+	now := s.now()
+
+	daily, err := s.quotaPeriodUsage(ctx, dailyQuota, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	monthly, err := s.quotaPeriodUsage(ctx, monthlyQuota, userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.QuotaUsageResponse{
+		UserID:  userID,
+		Daily:   *daily,
+		Monthly: *monthly,
+	}, nil
+}
+
+// quotaPeriodUsage 计算用户在 now 所在周期的配额用量
+func (s *riskReportUsageService) quotaPeriodUsage(ctx context.Context, p quotaPeriod, userID string, now time.Time) (*model.QuotaPeriodUsage, error) {
+	start, end := p.window(now)
+	used, err := s.tokensUsed(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	quota := p.quota(&s.config.RiskReport, userID)
+	remaining := int64(-1)
+	if quota > 0 {
+		remaining = quota - used
+		if remaining < 0 {
+			remaining = 0
+		}
+	}
+
+	return &model.QuotaPeriodUsage{
+		Quota:       quota,
+		Used:        used,
+		Remaining:   remaining,
+		PeriodStart: start,
+		ResetAt:     end.Add(time.Nanosecond),
+	}, nil
+}
+
+// tokensUsed 查询用户在 [start, end] 内已用的 token 数
+// 启用写入缓冲时包括缓冲中尚未落库的记录
+func (s *riskReportUsageService) tokensUsed(ctx context.Context, userID string, start, end time.Time) (int64, error) {
+	stats, err := s.repo.GetStatsByUser(ctx, userID, start, end)
+	if err != nil {
+		s.log.Error("查询 token 用量失败",
+			logger.String("user_id", userID),
+			logger.Err(err),
+		)
+		return 0, err
+	}
+	used := stats.TotalTokens
+	if s.buffer != nil {
+		used += s.buffer.PendingTokens(userID, start, end)
+	}
+	return used, nil
+}
+
+// dayRange 返回 t 所在自然日（UTC）的起止时间，结束时间包含在内
+func dayRange(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	return start, end
+}
+
+// monthRange 返回 t 所在自然月（UTC）的起止时间，结束时间包含在内
+func monthRange(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0).Add(-time.Nanosecond)
+	return start, end
+}
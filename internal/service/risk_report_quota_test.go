@@ -0,0 +1,268 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含 risk-report token 配额检查的单元测试
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newQuotaTestRequest 创建 2024 年 3 月的上报请求
+func newQuotaTestRequest(userID string, tokens int) *model.CreateRiskReportUsageRequest {
+	requestTime := time.Date(2024, 3, 15, 8, 0, 0, 0, time.UTC)
+	return &model.CreateRiskReportUsageRequest{
+		UserID:           userID,
+		Ticker:           "AAPL",
+		RequestTime:      requestTime,
+		ResponseTime:     requestTime.Add(time.Second),
+		PromptTokens:     tokens,
+		CompletionTokens: 0,
+		TotalTokens:      tokens,
+		AIResponse:       "ok",
+	}
+}
+
+// ============================================================
+// Create 配额测试
+// ============================================================
+
+func TestRiskReportUsageService_Create_WithinQuota(t *testing.T) {
+	// 准备
+	mockRepo := new(MockRiskReportUsageRepository)
+	cfg := newTestConfig()
+	cfg.RiskReport.MonthlyTokenQuota = 1000
+	usageService := NewRiskReportUsageService(mockRepo, cfg, newTestLogger())
+	ctx := context.Background()
+
+	// 设置 mock 期望：当月已用 900，本次 100，恰好用满
+	mockRepo.On("GetStatsByUser", ctx, "user-1",
+		time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 31, 23, 59, 59, 999999999, time.UTC),
+	).Return(&model.UsageStatsResponse{TotalTokens: 900}, nil)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.RiskReportUsage")).Return(nil)
+
+	// 执行
+	usage, err := usageService.Create(ctx, newQuotaTestRequest("user-1", 100))
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, 100, usage.TotalTokens)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRiskReportUsageService_Create_QuotaExceeded(t *testing.T) {
+	// 准备
+	mockRepo := new(MockRiskReportUsageRepository)
+	cfg := newTestConfig()
+	cfg.RiskReport.MonthlyTokenQuota = 1000
+	usageService := NewRiskReportUsageService(mockRepo, cfg, newTestLogger())
+	ctx := context.Background()
+
+	// 设置 mock 期望
+	mockRepo.On("GetStatsByUser", ctx, "user-1", mock.Anything, mock.Anything).
+		Return(&model.UsageStatsResponse{TotalTokens: 950}, nil)
+
+	// 执行
+	usage, err := usageService.Create(ctx, newQuotaTestRequest("user-1", 100))
+
+	// 断言：返回 429 的配额错误，不写入记录
+	assert.Nil(t, usage)
+	require.True(t, errors.Is(err, errors.ErrQuotaExceeded))
+	assert.Equal(t, 429, err.(*errors.AppError).HTTPStatus)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestRiskReportUsageService_Create_QuotaOverride(t *testing.T) {
+	// 准备：全局配额 1000，vip 用户覆盖为不限
+	mockRepo := new(MockRiskReportUsageRepository)
+	cfg := newTestConfig()
+	cfg.RiskReport.MonthlyTokenQuota = 1000
+	cfg.RiskReport.QuotaOverrides = []config.QuotaOverrideConfig{{UserID: "vip", MonthlyTokenQuota: 0}}
+	usageService := NewRiskReportUsageService(mockRepo, cfg, newTestLogger())
+	ctx := context.Background()
+
+	// 设置 mock 期望：不限额时无需查询用量
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.RiskReportUsage")).Return(nil)
+
+	// 执行
+	_, err := usageService.Create(ctx, newQuotaTestRequest("vip", 5000))
+
+	// 断言
+	require.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "GetStatsByUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRiskReportUsageService_Create_DailyQuotaExceeded(t *testing.T) {
+	// 准备：月度不限，每日配额 500
+	mockRepo := new(MockRiskReportUsageRepository)
+	cfg := newTestConfig()
+	cfg.RiskReport.DailyTokenQuota = 500
+	usageService := NewRiskReportUsageService(mockRepo, cfg, newTestLogger())
+	ctx := context.Background()
+
+	// 设置 mock 期望：只按请求时间所在自然日聚合
+	mockRepo.On("GetStatsByUser", ctx, "user-1",
+		time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 15, 23, 59, 59, 999999999, time.UTC),
+	).Return(&model.UsageStatsResponse{TotalTokens: 450}, nil)
+
+	// 执行
+	usage, err := usageService.Create(ctx, newQuotaTestRequest("user-1", 100))
+
+	// 断言
+	assert.Nil(t, usage)
+	require.True(t, errors.Is(err, errors.ErrQuotaExceeded))
+	assert.Contains(t, err.(*errors.AppError).Detail, "今日已用 450 / 500")
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// ============================================================
+// BatchCreate 配额测试
+// ============================================================
+
+func TestRiskReportUsageService_BatchCreate_QuotaAccumulatesWithinBatch(t *testing.T) {
+	// 准备
+	mockRepo := new(MockRiskReportUsageRepository)
+	cfg := newTestConfig()
+	cfg.RiskReport.MonthlyTokenQuota = 1000
+	usageService := NewRiskReportUsageService(mockRepo, cfg, newTestLogger())
+	ctx := context.Background()
+
+	req := &model.BatchCreateRiskReportUsageRequest{
+		Records: []model.CreateRiskReportUsageRequest{
+			*newQuotaTestRequest("user-1", 300),
+			*newQuotaTestRequest("user-1", 300),
+			*newQuotaTestRequest("user-1", 300), // 累计 1100，超额
+		},
+	}
+
+	// 设置 mock 期望：同一用户同一月份只查询一次
+	mockRepo.On("GetStatsByUser", ctx, "user-1", mock.Anything, mock.Anything).
+		Return(&model.UsageStatsResponse{TotalTokens: 500}, nil).Once()
+	mockRepo.On("BatchCreate", ctx, mock.MatchedBy(func(usages []model.RiskReportUsage) bool {
+		return len(usages) == 1
+	})).Return(nil)
+
+	// 执行
+	resp, err := usageService.BatchCreate(ctx, req)
+
+	// 断言：已用 500，第一条通过，后两条超额
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.SuccessCount)
+	assert.Equal(t, 2, resp.FailureCount)
+	require.Len(t, resp.Errors, 2)
+	assert.Contains(t, resp.Errors[0], "记录 2")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestMonthRange(t *testing.T) {
+	// 东八区 4 月 1 日凌晨仍属于 UTC 的 3 月
+	start, end := monthRange(time.Date(2024, 4, 1, 5, 0, 0, 0, time.FixedZone("CST", 8*3600)))
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2024, 3, 31, 23, 59, 59, 999999999, time.UTC), end)
+}
+
+func TestDayRange(t *testing.T) {
+	// 东八区 3 月 16 日早上仍属于 UTC 的 3 月 15 日
+	start, end := dayRange(time.Date(2024, 3, 16, 7, 0, 0, 0, time.FixedZone("CST", 8*3600)))
+	assert.Equal(t, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2024, 3, 15, 23, 59, 59, 999999999, time.UTC), end)
+}
+
+// ============================================================
+// GetCurrentQuotaUsage 测试
+// ============================================================
+
+func TestRiskReportUsageService_GetCurrentQuotaUsage(t *testing.T) {
+	// 准备
+	mockRepo := new(MockRiskReportUsageRepository)
+	cfg := newTestConfig()
+	cfg.RiskReport.MonthlyTokenQuota = 1000
+	svc := NewRiskReportUsageService(mockRepo, cfg, newTestLogger()).(*riskReportUsageService)
+	svc.now = func() time.Time { return time.Date(2024, 3, 15, 8, 0, 0, 0, time.UTC) }
+	ctx := context.Background()
+
+	// 设置 mock 期望
+	mockRepo.On("GetStatsByUser", ctx, "user-1",
+		time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 15, 23, 59, 59, 999999999, time.UTC),
+	).Return(&model.UsageStatsResponse{TotalTokens: 200}, nil)
+	mockRepo.On("GetStatsByUser", ctx, "user-1",
+		time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 31, 23, 59, 59, 999999999, time.UTC),
+	).Return(&model.UsageStatsResponse{TotalTokens: 1200}, nil)
+
+	// 执行
+	usage, err := svc.GetCurrentQuotaUsage(ctx, "user-1")
+
+	// 断言：每日不限额时剩余为 -1；月度超出时剩余不为负
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", usage.UserID)
+	assert.Equal(t, int64(0), usage.Daily.Quota)
+	assert.Equal(t, int64(200), usage.Daily.Used)
+	assert.Equal(t, int64(-1), usage.Daily.Remaining)
+	assert.Equal(t, time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC), usage.Daily.ResetAt)
+	assert.Equal(t, int64(1000), usage.Monthly.Quota)
+	assert.Equal(t, int64(1200), usage.Monthly.Used)
+	assert.Equal(t, int64(0), usage.Monthly.Remaining)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), usage.Monthly.PeriodStart)
+	assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), usage.Monthly.ResetAt)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRiskReportUsageService_GetCurrentQuotaUsage_ResetsAcrossMonthBoundary(t *testing.T) {
+	// 准备：月度配额 1000，3 月已用满
+	mockRepo := new(MockRiskReportUsageRepository)
+	cfg := newTestConfig()
+	cfg.RiskReport.MonthlyTokenQuota = 1000
+	cfg.RiskReport.DailyTokenQuota = 500
+	svc := NewRiskReportUsageService(mockRepo, cfg, newTestLogger()).(*riskReportUsageService)
+	ctx := context.Background()
+
+	// 设置 mock 期望：3 月 31 日与 3 月已用满，4 月 1 日与 4 月尚无记录
+	mockRepo.On("GetStatsByUser", ctx, "user-1",
+		time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 31, 23, 59, 59, 999999999, time.UTC),
+	).Return(&model.UsageStatsResponse{TotalTokens: 500}, nil)
+	mockRepo.On("GetStatsByUser", ctx, "user-1",
+		time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 31, 23, 59, 59, 999999999, time.UTC),
+	).Return(&model.UsageStatsResponse{TotalTokens: 1000}, nil)
+	mockRepo.On("GetStatsByUser", ctx, "user-1",
+		time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 1, 23, 59, 59, 999999999, time.UTC),
+	).Return(&model.UsageStatsResponse{TotalTokens: 0}, nil)
+	mockRepo.On("GetStatsByUser", ctx, "user-1",
+		time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 30, 23, 59, 59, 999999999, time.UTC),
+	).Return(&model.UsageStatsResponse{TotalTokens: 0}, nil)
+
+	// 执行：月末最后一刻与下月第一刻各查询一次
+	svc.now = func() time.Time { return time.Date(2024, 3, 31, 23, 59, 59, 0, time.UTC) }
+	before, err := svc.GetCurrentQuotaUsage(ctx, "user-1")
+	require.NoError(t, err)
+	svc.now = func() time.Time { return time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC) }
+	after, err := svc.GetCurrentQuotaUsage(ctx, "user-1")
+	require.NoError(t, err)
+
+	// 断言：跨月后已用量归零，剩余恢复为完整配额
+	assert.Equal(t, int64(1000), before.Monthly.Used)
+	assert.Equal(t, int64(0), before.Monthly.Remaining)
+	assert.Equal(t, int64(0), before.Daily.Remaining)
+	assert.Equal(t, int64(0), after.Monthly.Used)
+	assert.Equal(t, int64(1000), after.Monthly.Remaining)
+	assert.Equal(t, int64(0), after.Daily.Used)
+	assert.Equal(t, int64(500), after.Daily.Remaining)
+	assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), after.Monthly.PeriodStart)
+	mockRepo.AssertExpectations(t)
+}
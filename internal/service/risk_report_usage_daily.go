@@ -0,0 +1,62 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件实现了使用记录日聚合的刷新。
+// 聚合表覆盖到最晚的已聚合日为止，统计接口对覆盖范围内的整天直接读聚合表；
+// 明细写入时仓储已同步更新受影响的聚合行，定期刷新重新计算最近几天作为兜底，
+// 绕过接口直接修改明细后可手动从指定日期起重新聚合。
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+// RefreshDailyStats 重新计算使用记录的日聚合
+// 聚合截止到当天零点（UTC），当天的数据始终从明细实时统计；
+// 聚合表为空时聚合全部历史，否则从 req.From（未填写时为 lookback_days 天前）起重新计算，
+// 起始日晚于已覆盖范围时提前到覆盖范围结束日，使聚合表始终连续
+func (s *riskReportUsageService) RefreshDailyStats(ctx context.Context, req *model.RefreshUsageDailyRequest) (*model.RefreshUsageDailyResponse, error) {
+	before := model.UsageDay(s.now())
+
+	var from time.Time
+	if req.From != "" {
+		t, err := time.Parse(time.DateOnly, req.From)
+		if err != nil {
+			return nil, errors.ErrValidation.WithDetail("from 必须是 YYYY-MM-DD 格式的日期")
+		}
+		from = t
+	} else {
+		from = before.AddDate(0, 0, -s.config.RiskReport.DailyAggregate.LookbackDays)
+	}
+
+	coverage, err := s.repo.DailyCoverage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if coverage.IsZero() {
+		from = time.Time{}
+	} else if from.After(coverage) {
+		from = coverage
+	}
+
+	rows, err := s.repo.RefreshDaily(ctx, from, before)
+	if err != nil {
+		s.log.Error("刷新使用记录日聚合失败", logger.Err(err))
+		return nil, err
+	}
+
+	resp := &model.RefreshUsageDailyResponse{Before: before, Rows: rows}
+	if !from.IsZero() {
+		resp.From = &from
+	}
+	s.log.Info("刷新使用记录日聚合",
+		logger.Any("from", resp.From),
+		logger.Any("before", before),
+		logger.Int("rows", rows),
+	)
+	return resp, nil
+}
@@ -0,0 +1,67 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含使用记录日聚合刷新的单元测试
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiskReportUsageService_RefreshDailyStats_Window(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+	today := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		from     string
+		coverage time.Time
+		wantFrom time.Time
+	}{
+		{"首次聚合全部历史", "", time.Time{}, time.Time{}},
+		{"定期刷新重新计算最近几天", "", today, today.AddDate(0, 0, -2)},
+		{"起始日晚于覆盖范围时提前到覆盖范围结束日", "", today.AddDate(0, 0, -5), today.AddDate(0, 0, -5)},
+		{"手动指定起始日", "2024-01-15", today, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 准备
+			mockRepo := new(MockRiskReportUsageRepository)
+			cfg := newTestConfig()
+			cfg.RiskReport.DailyAggregate.LookbackDays = 2
+			svc := NewRiskReportUsageService(mockRepo, cfg, newTestLogger())
+			svc.(*riskReportUsageService).now = func() time.Time { return now }
+			ctx := context.Background()
+
+			// 设置 mock 期望：聚合截止到当天零点
+			mockRepo.On("DailyCoverage", ctx).Return(tt.coverage, nil)
+			mockRepo.On("RefreshDaily", ctx, tt.wantFrom, today).Return(4, nil)
+
+			// 执行
+			resp, err := svc.RefreshDailyStats(ctx, &model.RefreshUsageDailyRequest{From: tt.from})
+
+			// 断言
+			require.NoError(t, err)
+			assert.Equal(t, 4, resp.Rows)
+			assert.Equal(t, today, resp.Before)
+			assert.Equal(t, tt.wantFrom.IsZero(), resp.From == nil)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRiskReportUsageService_RefreshDailyStats_InvalidFrom(t *testing.T) {
+	mockRepo := new(MockRiskReportUsageRepository)
+	svc := NewRiskReportUsageService(mockRepo, newTestConfig(), newTestLogger())
+
+	_, err := svc.RefreshDailyStats(context.Background(), &model.RefreshUsageDailyRequest{From: "2024/01/15"})
+
+	assert.True(t, errors.Is(err, errors.ErrValidation), err)
+	mockRepo.AssertNotCalled(t, "RefreshDaily")
+}
@@ -3,10 +3,16 @@ package service
 
 import (
 	"context"
+	"encoding/csv"
+	stderrors "errors"
 	"fmt"
+	"io"
+	"math"
 	"regexp"
+	"strconv"
 	"time"
 
+	"github.com/example/go-user-api/internal/config"
 	"github.com/example/go-user-api/internal/model"
 	"github.com/example/go-user-api/internal/repository"
 	"github.com/example/go-user-api/pkg/errors"
@@ -20,29 +26,93 @@ type RiskReportUsageService interface {
 	Create(ctx context.Context, req *model.CreateRiskReportUsageRequest) (*model.RiskReportUsage, error)
 	// BatchCreate 批量创建使用记录
 	BatchCreate(ctx context.Context, req *model.BatchCreateRiskReportUsageRequest) (*model.BatchCreateRiskReportUsageResponse, error)
+	// Backfill 回填历史使用记录，不校验时间且不占用配额
+	Backfill(ctx context.Context, req *model.BackfillRiskReportUsageRequest) (*model.BatchCreateRiskReportUsageResponse, error)
 	// GetByID 根据 ID 获取使用记录
 	GetByID(ctx context.Context, id string) (*model.RiskReportUsage, error)
+	// Update 更新使用记录，更新后重新校验字段一致性
+	Update(ctx context.Context, id string, req *model.UpdateRiskReportUsageRequest) (*model.RiskReportUsage, error)
+	// Delete 删除使用记录
+	Delete(ctx context.Context, id string) error
 	// List 获取使用记录列表
 	List(ctx context.Context, req *model.RiskReportUsageListRequest) ([]model.RiskReportUsage, int64, error)
 	// GetUserStats 获取用户统计信息
-	GetUserStats(ctx context.Context, userID string, startTime, endTime time.Time) (map[string]interface{}, error)
+	GetUserStats(ctx context.Context, userID string, startTime, endTime time.Time) (*model.UsageStatsResponse, error)
+	// StatsByMarketState 按市场状态分组统计调用分布
+	StatsByMarketState(ctx context.Context, userID string, startTime, endTime time.Time) ([]model.MarketStateStats, error)
+	// StatsByActionSuggestion 按操作建议（买入/卖出/持有等）统计调用分布
+	StatsByActionSuggestion(ctx context.Context, userID string, startTime, endTime time.Time) ([]model.ActionSuggestionStats, error)
+	// Export 以 CSV 格式流式导出使用记录，返回导出的记录数
+	Export(ctx context.Context, req *model.RiskReportUsageExportRequest, w io.Writer) (int, error)
+	// GetCurrentQuotaUsage 获取用户当前自然日、自然月的配额用量
+	GetCurrentQuotaUsage(ctx context.Context, userID string) (*model.QuotaUsageResponse, error)
+	// RefreshDailyStats 重新计算使用记录的日聚合，GetUserStats 对已聚合的整天直接读聚合结果
+	RefreshDailyStats(ctx context.Context, req *model.RefreshUsageDailyRequest) (*model.RefreshUsageDailyResponse, error)
+}
+
+// exportBatchSize 导出时每批读取的记录数
+const exportBatchSize = 500
+
+// exportCSVHeader 导出 CSV 的列头
+var exportCSVHeader = []string{
+	"id", "user_id", "ticker", "request_time", "response_time",
+	"prompt_tokens", "completion_tokens", "total_tokens", "cost",
+	"market_state", "action_suggestion", "response_duration_ms", "error_message",
 }
 
 // riskReportUsageService 风险报告使用记录服务实现
 type riskReportUsageService struct {
-	repo repository.RiskReportUsageRepository
-	log  logger.Logger
+	repo   repository.RiskReportUsageRepository
+	config *config.Config
+	log    logger.Logger
+
+	// buffer 单条上报的写入缓冲，为 nil 时直接写库
+	buffer *UsageBuffer
+
+	// txManager 事务管理器，为 nil 时批量上报不开启事务
+	txManager repository.TxManager
+
+	// now 返回当前时间，用于确定配额周期（测试时可替换）
+	now func() time.Time
+}
+
+// RiskReportUsageServiceOption 风险报告使用记录服务的可选配置
+type RiskReportUsageServiceOption func(*riskReportUsageService)
+
+// WithUsageBuffer 设置单条上报的写入缓冲
+// 设置后 Create 校验通过的记录进入缓冲，由缓冲批量写库；批量上报不经过缓冲
+func WithUsageBuffer(buffer *UsageBuffer) RiskReportUsageServiceOption {
+	return func(s *riskReportUsageService) {
+		s.buffer = buffer
+	}
+}
+
+// WithTxManager 设置事务管理器
+// 设置后 BatchCreate 的配额检查与写库在同一事务中执行；
+// ctx 中已有外部事务时加入外部事务，由调用方统一提交或回滚
+func WithTxManager(txManager repository.TxManager) RiskReportUsageServiceOption {
+	return func(s *riskReportUsageService) {
+		s.txManager = txManager
+	}
 }
 
 // NewRiskReportUsageService 创建风险报告使用记录服务实例
 func NewRiskReportUsageService(
 	repo repository.RiskReportUsageRepository,
+	cfg *config.Config,
 	log logger.Logger,
+	opts ...RiskReportUsageServiceOption,
 ) RiskReportUsageService {
-	return &riskReportUsageService{
-		repo: repo,
-		log:  log.With(logger.String("service", "risk_report_usage")),
+	s := &riskReportUsageService{
+		repo:   repo,
+		config: cfg,
+		log:    log.With(logger.String("service", "risk_report_usage")),
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // Create 创建使用记录
@@ -57,6 +127,11 @@ func (s *riskReportUsageService) Create(ctx context.Context, req *model.CreateRi
 		return nil, err
 	}
 
+	// 检查月度 token 配额
+	if err := s.newQuotaTracker().reserve(ctx, req.UserID, req.RequestTime, req.TotalTokens); err != nil {
+		return nil, err
+	}
+
 	// 构建模型
 	usage := &model.RiskReportUsage{
 		UserID:               req.UserID,
@@ -78,8 +153,13 @@ func (s *riskReportUsageService) Create(ctx context.Context, req *model.CreateRi
 		ResponseDurationMs:   req.ResponseDurationMs,
 	}
 
-	// 保存到数据库
-	if err := s.repo.Create(ctx, usage); err != nil {
+	// 启用缓冲时交由缓冲批量写库，否则直接保存到数据库
+	if s.buffer != nil {
+		if err := s.buffer.Add(ctx, usage); err != nil {
+			s.log.Error("使用记录加入写入缓冲失败", logger.Err(err))
+			return nil, err
+		}
+	} else if err := s.repo.Create(ctx, usage); err != nil {
 		s.log.Error("创建使用记录失败", logger.Err(err))
 		return nil, err
 	}
@@ -94,7 +174,26 @@ func (s *riskReportUsageService) Create(ctx context.Context, req *model.CreateRi
 }
 
 // BatchCreate 批量创建使用记录
+// ctx 中带外部事务（repository.TxManager.WithinTx）时记录写入该事务，外部事务回滚时一并回滚
 func (s *riskReportUsageService) BatchCreate(ctx context.Context, req *model.BatchCreateRiskReportUsageRequest) (*model.BatchCreateRiskReportUsageResponse, error) {
+	if s.txManager == nil {
+		return s.batchCreate(ctx, req)
+	}
+
+	var response *model.BatchCreateRiskReportUsageResponse
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		response, err = s.batchCreate(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// batchCreate 校验、检查配额并批量写入使用记录
+func (s *riskReportUsageService) batchCreate(ctx context.Context, req *model.BatchCreateRiskReportUsageRequest) (*model.BatchCreateRiskReportUsageResponse, error) {
 	s.log.Debug("批量创建使用记录", logger.Int("count", len(req.Records)))
 
 	response := &model.BatchCreateRiskReportUsageResponse{
@@ -103,6 +202,7 @@ func (s *riskReportUsageService) BatchCreate(ctx context.Context, req *model.Bat
 	}
 
 	usages := make([]model.RiskReportUsage, 0, len(req.Records))
+	quota := s.newQuotaTracker()
 
 	// 验证并转换每条记录
 	for i, record := range req.Records {
@@ -113,26 +213,18 @@ func (s *riskReportUsageService) BatchCreate(ctx context.Context, req *model.Bat
 			continue
 		}
 
-		usage := model.RiskReportUsage{
-			UserID:               record.UserID,
-			Ticker:               record.Ticker,
-			RequestTime:          record.RequestTime,
-			ResponseTime:         record.ResponseTime,
-			PromptTokens:         record.PromptTokens,
-			CompletionTokens:     record.CompletionTokens,
-			TotalTokens:          record.TotalTokens,
-			AIResponse:           record.AIResponse,
-			StockPrice:           record.StockPrice,
-			MarketState:          record.MarketState,
-			NewsSentimentScore:   record.NewsSentimentScore,
-			NewsSentimentLabel:   record.NewsSentimentLabel,
-			PeakSignalsTriggered: record.PeakSignalsTriggered,
-			ActionSuggestion:     record.ActionSuggestion,
-			RateLimitRemaining:   record.RateLimitRemaining,
-			ErrorMessage:         record.ErrorMessage,
-			ResponseDurationMs:   record.ResponseDurationMs,
+		// 超出配额的记录单独拒绝，查询失败则整批失败
+		if err := quota.reserve(ctx, record.UserID, record.RequestTime, record.TotalTokens); err != nil {
+			appErr, ok := err.(*errors.AppError)
+			if !ok || !errors.Is(appErr, errors.ErrQuotaExceeded) {
+				return nil, err
+			}
+			response.Errors = append(response.Errors, fmt.Sprintf("记录 %d %s: %s", i+1, appErr.Message, appErr.Detail))
+			response.FailureCount++
+			continue
 		}
-		usages = append(usages, usage)
+
+		usages = append(usages, usageFromCreateRequest(&record))
 	}
 
 	// 批量插入
@@ -157,6 +249,78 @@ func (s *riskReportUsageService) BatchCreate(ctx context.Context, req *model.Bat
 	return response, nil
 }
 
+// Backfill 回填历史使用记录
+// 从旧系统迁移的数据时间早于上线时间，且旧系统时钟可能存在偏差，因此跳过未来时间与
+// request_time/response_time 先后顺序的校验，其余字段按创建规则校验；历史调用已经发生，不检查也不占用配额。
+// 校验失败的记录单独报告，其余记录分批写入；设置了事务管理器时整批在同一事务中写入
+func (s *riskReportUsageService) Backfill(ctx context.Context, req *model.BackfillRiskReportUsageRequest) (*model.BatchCreateRiskReportUsageResponse, error) {
+	s.log.Debug("回填历史使用记录", logger.Int("count", len(req.Records)))
+
+	response := &model.BatchCreateRiskReportUsageResponse{
+		RecordIDs: make([]string, 0),
+		Errors:    make([]string, 0),
+	}
+
+	usages := make([]model.RiskReportUsage, 0, len(req.Records))
+	for i := range req.Records {
+		if err := s.validateUsage(&req.Records[i], usageValidation{skipTimeChecks: true}); err != nil {
+			response.Errors = append(response.Errors, fmt.Sprintf("记录 %d 验证失败: %s", i+1, err.Error()))
+			response.FailureCount++
+			continue
+		}
+		usages = append(usages, usageFromCreateRequest(&req.Records[i]))
+	}
+
+	if len(usages) > 0 {
+		write := func(ctx context.Context) error { return s.repo.BatchCreate(ctx, usages) }
+		var err error
+		if s.txManager != nil {
+			err = s.txManager.WithinTx(ctx, write)
+		} else {
+			err = write(ctx)
+		}
+		if err != nil {
+			s.log.Error("回填历史使用记录失败", logger.Err(err))
+			return nil, err
+		}
+
+		for i := range usages {
+			response.RecordIDs = append(response.RecordIDs, usages[i].ID)
+		}
+		response.SuccessCount = len(usages)
+	}
+
+	s.log.Info("回填历史使用记录完成",
+		logger.Int("success", response.SuccessCount),
+		logger.Int("failure", response.FailureCount),
+	)
+
+	return response, nil
+}
+
+// usageFromCreateRequest 由创建请求构建使用记录
+func usageFromCreateRequest(req *model.CreateRiskReportUsageRequest) model.RiskReportUsage {
+	return model.RiskReportUsage{
+		UserID:               req.UserID,
+		Ticker:               req.Ticker,
+		RequestTime:          req.RequestTime,
+		ResponseTime:         req.ResponseTime,
+		PromptTokens:         req.PromptTokens,
+		CompletionTokens:     req.CompletionTokens,
+		TotalTokens:          req.TotalTokens,
+		AIResponse:           req.AIResponse,
+		StockPrice:           req.StockPrice,
+		MarketState:          req.MarketState,
+		NewsSentimentScore:   req.NewsSentimentScore,
+		NewsSentimentLabel:   req.NewsSentimentLabel,
+		PeakSignalsTriggered: req.PeakSignalsTriggered,
+		ActionSuggestion:     req.ActionSuggestion,
+		RateLimitRemaining:   req.RateLimitRemaining,
+		ErrorMessage:         req.ErrorMessage,
+		ResponseDurationMs:   req.ResponseDurationMs,
+	}
+}
+
 // GetByID 根据 ID 获取使用记录
 func (s *riskReportUsageService) GetByID(ctx context.Context, id string) (*model.RiskReportUsage, error) {
 	usage, err := s.repo.GetByID(ctx, id)
@@ -170,6 +334,119 @@ func (s *riskReportUsageService) GetByID(ctx context.Context, id string) (*model
 	return usage, nil
 }
 
+// Update 更新使用记录
+// 将请求中出现的字段合并到现有记录后按创建时的规则重新校验，
+// 例如只修改 prompt_tokens 而不同步 total_tokens 会被拒绝。
+// 更新用于修正错误数据，不重新检查月度配额
+func (s *riskReportUsageService) Update(ctx context.Context, id string, req *model.UpdateRiskReportUsageRequest) (*model.RiskReportUsage, error) {
+	usage, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	applyUsageUpdate(usage, req)
+	if err := s.validateCreateRequest(usageAsCreateRequest(usage)); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, usage); err != nil {
+		s.log.Error("更新使用记录失败", logger.String("id", id), logger.Err(err))
+		return nil, err
+	}
+
+	s.log.Info("使用记录已更新",
+		logger.String("id", usage.ID),
+		logger.String("user_id", usage.UserID),
+	)
+	return usage, nil
+}
+
+// Delete 删除使用记录
+func (s *riskReportUsageService) Delete(ctx context.Context, id string) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		s.log.Error("删除使用记录失败", logger.String("id", id), logger.Err(err))
+		return err
+	}
+
+	s.log.Info("使用记录已删除", logger.String("id", id))
+	return nil
+}
+
+// applyUsageUpdate 将更新请求中出现的字段写入记录
+func applyUsageUpdate(u *model.RiskReportUsage, req *model.UpdateRiskReportUsageRequest) {
+	if req.Ticker != nil {
+		u.Ticker = *req.Ticker
+	}
+	if req.RequestTime != nil {
+		u.RequestTime = *req.RequestTime
+	}
+	if req.ResponseTime != nil {
+		u.ResponseTime = *req.ResponseTime
+	}
+	if req.PromptTokens != nil {
+		u.PromptTokens = *req.PromptTokens
+	}
+	if req.CompletionTokens != nil {
+		u.CompletionTokens = *req.CompletionTokens
+	}
+	if req.TotalTokens != nil {
+		u.TotalTokens = *req.TotalTokens
+	}
+	if req.AIResponse != nil {
+		u.AIResponse = *req.AIResponse
+	}
+	if req.StockPrice != nil {
+		u.StockPrice = req.StockPrice
+	}
+	if req.MarketState != nil {
+		u.MarketState = *req.MarketState
+	}
+	if req.NewsSentimentScore != nil {
+		u.NewsSentimentScore = req.NewsSentimentScore
+	}
+	if req.NewsSentimentLabel != nil {
+		u.NewsSentimentLabel = model.NormalizeSentimentLabel(*req.NewsSentimentLabel)
+	}
+	if req.PeakSignalsTriggered != nil {
+		u.PeakSignalsTriggered = req.PeakSignalsTriggered
+	}
+	if req.ActionSuggestion != nil {
+		u.ActionSuggestion = *req.ActionSuggestion
+	}
+	if req.RateLimitRemaining != nil {
+		u.RateLimitRemaining = req.RateLimitRemaining
+	}
+	if req.ErrorMessage != nil {
+		u.ErrorMessage = *req.ErrorMessage
+	}
+	if req.ResponseDurationMs != nil {
+		u.ResponseDurationMs = req.ResponseDurationMs
+	}
+}
+
+// usageAsCreateRequest 将记录转换为创建请求，以复用创建时的校验规则
+func usageAsCreateRequest(u *model.RiskReportUsage) *model.CreateRiskReportUsageRequest {
+	return &model.CreateRiskReportUsageRequest{
+		UserID:               u.UserID,
+		Ticker:               u.Ticker,
+		RequestTime:          u.RequestTime,
+		ResponseTime:         u.ResponseTime,
+		PromptTokens:         u.PromptTokens,
+		CompletionTokens:     u.CompletionTokens,
+		TotalTokens:          u.TotalTokens,
+		AIResponse:           u.AIResponse,
+		StockPrice:           u.StockPrice,
+		MarketState:          u.MarketState,
+		NewsSentimentScore:   u.NewsSentimentScore,
+		NewsSentimentLabel:   u.NewsSentimentLabel,
+		PeakSignalsTriggered: u.PeakSignalsTriggered,
+		ActionSuggestion:     u.ActionSuggestion,
+		RateLimitRemaining:   u.RateLimitRemaining,
+		ErrorMessage:         u.ErrorMessage,
+		ResponseDurationMs:   u.ResponseDurationMs,
+	}
+}
+
 // List 获取使用记录列表
 func (s *riskReportUsageService) List(ctx context.Context, req *model.RiskReportUsageListRequest) ([]model.RiskReportUsage, int64, error) {
 	// 设置默认分页参数
@@ -199,6 +476,11 @@ func (s *riskReportUsageService) List(ctx context.Context, req *model.RiskReport
 		}
 	}
 
+	// 客户端已取消时不再发起查询
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
 	// 查询数据
 	usages, total, err := s.repo.List(ctx, filters, req.Page, req.PageSize)
 	if err != nil {
@@ -210,7 +492,11 @@ func (s *riskReportUsageService) List(ctx context.Context, req *model.RiskReport
 }
 
 // GetUserStats 获取用户统计信息
-func (s *riskReportUsageService) GetUserStats(ctx context.Context, userID string, startTime, endTime time.Time) (map[string]interface{}, error) {
+func (s *riskReportUsageService) GetUserStats(ctx context.Context, userID string, startTime, endTime time.Time) (*model.UsageStatsResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	stats, err := s.repo.GetStatsByUser(ctx, userID, startTime, endTime)
 	if err != nil {
 		s.log.Error("获取用户统计信息失败",
@@ -219,11 +505,181 @@ func (s *riskReportUsageService) GetUserStats(ctx context.Context, userID string
 		)
 		return nil, err
 	}
+
+	// 平均值容易被极端值拉偏，同时返回延迟百分位
+	latency, err := s.repo.LatencyPercentiles(ctx, userID, startTime, endTime)
+	if err != nil {
+		s.log.Error("获取请求延迟百分位失败",
+			logger.String("user_id", userID),
+			logger.Err(err),
+		)
+		return nil, err
+	}
+	stats.P50ResponseTimeMs = latency.P50
+	stats.P95ResponseTimeMs = latency.P95
+	stats.P99ResponseTimeMs = latency.P99
+
 	return stats, nil
 }
 
+// StatsByMarketState 按市场状态分组统计调用分布
+func (s *riskReportUsageService) StatsByMarketState(ctx context.Context, userID string, startTime, endTime time.Time) ([]model.MarketStateStats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.repo.StatsByMarketState(ctx, userID, startTime, endTime)
+	if err != nil {
+		s.log.Error("按市场状态统计失败",
+			logger.String("user_id", userID),
+			logger.Err(err),
+		)
+		return nil, err
+	}
+	return stats, nil
+}
+
+// StatsByActionSuggestion 按操作建议统计调用分布，并计算各建议的占比
+func (s *riskReportUsageService) StatsByActionSuggestion(ctx context.Context, userID string, startTime, endTime time.Time) ([]model.ActionSuggestionStats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.repo.StatsByActionSuggestion(ctx, userID, startTime, endTime)
+	if err != nil {
+		s.log.Error("按操作建议统计失败",
+			logger.String("user_id", userID),
+			logger.Err(err),
+		)
+		return nil, err
+	}
+
+	var total int64
+	for _, stat := range stats {
+		total += stat.Count
+	}
+	if total > 0 {
+		for i := range stats {
+			stats[i].Ratio = math.Round(float64(stats[i].Count)/float64(total)*10000) / 10000
+		}
+	}
+	return stats, nil
+}
+
+// Export 以 CSV 格式流式导出使用记录
+// 先校验参数，校验失败时不会向 w 写入任何内容；
+// 之后分批读取并逐批写出，避免一次性加载全部结果
+func (s *riskReportUsageService) Export(ctx context.Context, req *model.RiskReportUsageExportRequest, w io.Writer) (int, error) {
+	filters := map[string]interface{}{"user_id": req.UserID}
+	if req.StartTime != "" {
+		startTime, err := time.Parse(time.RFC3339, req.StartTime)
+		if err != nil {
+			return 0, errors.ErrValidation.WithDetail("start_time 必须是 RFC3339 格式")
+		}
+		filters["start_time"] = startTime
+	}
+	if req.EndTime != "" {
+		endTime, err := time.Parse(time.RFC3339, req.EndTime)
+		if err != nil {
+			return 0, errors.ErrValidation.WithDetail("end_time 必须是 RFC3339 格式")
+		}
+		filters["end_time"] = endTime
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	promptPrice := s.config.RiskReport.PromptTokenPrice
+	completionPrice := s.config.RiskReport.CompletionTokenPrice
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(exportCSVHeader); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err := s.repo.FindInBatches(ctx, filters, exportBatchSize, func(batch []model.RiskReportUsage) error {
+		// 客户端断开后停止读取后续批次
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for i := range batch {
+			if err := cw.Write(usageCSVRecord(&batch[i], promptPrice, completionPrice)); err != nil {
+				return err
+			}
+		}
+		count += len(batch)
+		// 每批写完立即刷新，让客户端尽早收到数据
+		cw.Flush()
+		return cw.Error()
+	})
+	if stderrors.Is(err, context.Canceled) {
+		s.log.Info("客户端断开，导出已中止",
+			logger.String("user_id", req.UserID),
+			logger.Int("exported", count),
+		)
+		return count, err
+	}
+	if err != nil {
+		s.log.Error("导出使用记录失败",
+			logger.String("user_id", req.UserID),
+			logger.Int("exported", count),
+			logger.Err(err),
+		)
+		return count, err
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return count, err
+	}
+
+	s.log.Info("导出使用记录完成",
+		logger.String("user_id", req.UserID),
+		logger.Int("count", count),
+	)
+	return count, nil
+}
+
+// usageCSVRecord 将使用记录转换为 CSV 行，列顺序与 exportCSVHeader 一致
+func usageCSVRecord(u *model.RiskReportUsage, promptPrice, completionPrice float64) []string {
+	duration := ""
+	if u.ResponseDurationMs != nil {
+		duration = strconv.Itoa(*u.ResponseDurationMs)
+	}
+	// 调用方上报的文本字段可能以公式字符开头，写出前转义
+	return []string{
+		u.ID,
+		escapeFormula(u.UserID),
+		escapeFormula(u.Ticker),
+		u.RequestTime.Format(time.RFC3339),
+		u.ResponseTime.Format(time.RFC3339),
+		strconv.Itoa(u.PromptTokens),
+		strconv.Itoa(u.CompletionTokens),
+		strconv.Itoa(u.TotalTokens),
+		strconv.FormatFloat(u.Cost(promptPrice, completionPrice), 'f', 6, 64),
+		escapeFormula(u.MarketState),
+		escapeFormula(u.ActionSuggestion),
+		duration,
+		escapeFormula(u.ErrorMessage),
+	}
+}
+
+// usageValidation 使用记录校验的放宽项
+type usageValidation struct {
+	// skipTimeChecks 跳过时间先后顺序与未来时间校验，用于回填历史数据
+	skipTimeChecks bool
+}
+
 // validateCreateRequest 验证创建请求
+// 校验通过时 news_sentiment_label 会被归一化为枚举值
 func (s *riskReportUsageService) validateCreateRequest(req *model.CreateRiskReportUsageRequest) error {
+	return s.validateUsage(req, usageValidation{})
+}
+
+// validateUsage 按放宽项验证使用记录
+func (s *riskReportUsageService) validateUsage(req *model.CreateRiskReportUsageRequest, v usageValidation) error {
 	// 验证 ticker 格式（1-10 个字符，包含字母、数字、点号）
 	tickerPattern := regexp.MustCompile(`^[A-Z0-9.]{1,10}$`)
 	if !tickerPattern.MatchString(req.Ticker) {
@@ -235,7 +691,7 @@ func (s *riskReportUsageService) validateCreateRequest(req *model.CreateRiskRepo
 	}
 
 	// 验证时间顺序
-	if req.RequestTime.After(req.ResponseTime) {
+	if !v.skipTimeChecks && req.RequestTime.After(req.ResponseTime) {
 		return errors.New(
 			errors.CodeValidation,
 			400,
@@ -245,7 +701,7 @@ func (s *riskReportUsageService) validateCreateRequest(req *model.CreateRiskRepo
 
 	// 验证时间不能是未来时间（允许 5 分钟误差）
 	maxAllowedTime := time.Now().Add(5 * time.Minute)
-	if req.ResponseTime.After(maxAllowedTime) {
+	if !v.skipTimeChecks && req.ResponseTime.After(maxAllowedTime) {
 		return errors.New(
 			errors.CodeValidation,
 			400,
@@ -295,5 +751,26 @@ func (s *riskReportUsageService) validateCreateRequest(req *model.CreateRiskRepo
 		}
 	}
 
+	// 验证新闻情绪分数范围（如果提供）
+	if score := req.NewsSentimentScore; score != nil && (*score < model.SentimentScoreMin || *score > model.SentimentScoreMax) {
+		return errors.New(
+			errors.CodeValidation,
+			400,
+			fmt.Sprintf("news_sentiment_score 必须在 %d 到 %d 之间", model.SentimentScoreMin, model.SentimentScoreMax),
+		)
+	}
+
+	// 验证新闻情绪标签（如果提供），归一化后写回请求
+	if req.NewsSentimentLabel != "" {
+		req.NewsSentimentLabel = model.NormalizeSentimentLabel(req.NewsSentimentLabel)
+		if !model.IsValidSentimentLabel(req.NewsSentimentLabel) {
+			return errors.New(
+				errors.CodeValidation,
+				400,
+				"news_sentiment_label 必须是 positive/neutral/negative 之一",
+			)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,592 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含风险报告使用记录服务的单元测试
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================
+// Mock 使用记录仓储
+// ============================================================
+
+// MockRiskReportUsageRepository 是 RiskReportUsageRepository 接口的模拟实现
+type MockRiskReportUsageRepository struct {
+	mock.Mock
+}
+
+func (m *MockRiskReportUsageRepository) Create(ctx context.Context, usage *model.RiskReportUsage) error {
+	args := m.Called(ctx, usage)
+	return args.Error(0)
+}
+
+func (m *MockRiskReportUsageRepository) BatchCreate(ctx context.Context, usages []model.RiskReportUsage) error {
+	args := m.Called(ctx, usages)
+	return args.Error(0)
+}
+
+func (m *MockRiskReportUsageRepository) GetByID(ctx context.Context, id string) (*model.RiskReportUsage, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.RiskReportUsage), args.Error(1)
+}
+
+func (m *MockRiskReportUsageRepository) Update(ctx context.Context, usage *model.RiskReportUsage) error {
+	args := m.Called(ctx, usage)
+	return args.Error(0)
+}
+
+func (m *MockRiskReportUsageRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRiskReportUsageRepository) List(ctx context.Context, filters map[string]interface{}, page, pageSize int) ([]model.RiskReportUsage, int64, error) {
+	args := m.Called(ctx, filters, page, pageSize)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]model.RiskReportUsage), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRiskReportUsageRepository) GetStatsByUser(ctx context.Context, userID string, startTime, endTime time.Time) (*model.UsageStatsResponse, error) {
+	args := m.Called(ctx, userID, startTime, endTime)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.UsageStatsResponse), args.Error(1)
+}
+
+func (m *MockRiskReportUsageRepository) StatsByActionSuggestion(ctx context.Context, userID string, startTime, endTime time.Time) ([]model.ActionSuggestionStats, error) {
+	args := m.Called(ctx, userID, startTime, endTime)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ActionSuggestionStats), args.Error(1)
+}
+
+func (m *MockRiskReportUsageRepository) StatsByMarketState(ctx context.Context, userID string, startTime, endTime time.Time) ([]model.MarketStateStats, error) {
+	args := m.Called(ctx, userID, startTime, endTime)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.MarketStateStats), args.Error(1)
+}
+
+func (m *MockRiskReportUsageRepository) LatencyPercentiles(ctx context.Context, userID string, startTime, endTime time.Time) (*model.LatencyPercentiles, error) {
+	args := m.Called(ctx, userID, startTime, endTime)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.LatencyPercentiles), args.Error(1)
+}
+
+// FindInBatches 将预设的批次依次交给回调
+func (m *MockRiskReportUsageRepository) FindInBatches(ctx context.Context, filters map[string]interface{}, batchSize int, fn func(batch []model.RiskReportUsage) error) error {
+	args := m.Called(ctx, filters, batchSize)
+	if batches, ok := args.Get(0).([][]model.RiskReportUsage); ok {
+		for _, batch := range batches {
+			if err := fn(batch); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockRiskReportUsageRepository) DailyCoverage(ctx context.Context) (time.Time, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockRiskReportUsageRepository) RefreshDaily(ctx context.Context, from, before time.Time) (int, error) {
+	args := m.Called(ctx, from, before)
+	return args.Int(0), args.Error(1)
+}
+
+// ============================================================
+// 导出测试
+// ============================================================
+
+func newTestUsage(userID string, promptTokens, completionTokens int) model.RiskReportUsage {
+	now := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	return model.RiskReportUsage{
+		UserID:           userID,
+		Ticker:           "AAPL",
+		RequestTime:      now,
+		ResponseTime:     now.Add(time.Second),
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}
+}
+
+func TestRiskReportUsageService_Export_Success(t *testing.T) {
+	// 准备
+	mockRepo := new(MockRiskReportUsageRepository)
+	cfg := newTestConfig()
+	cfg.RiskReport.PromptTokenPrice = 0.01
+	cfg.RiskReport.CompletionTokenPrice = 0.02
+	usageService := NewRiskReportUsageService(mockRepo, cfg, newTestLogger())
+
+	ctx := context.Background()
+	req := &model.RiskReportUsageExportRequest{
+		UserID:    "user-1",
+		StartTime: "2024-03-01T00:00:00Z",
+		EndTime:   "2024-03-02T00:00:00Z",
+	}
+	batches := [][]model.RiskReportUsage{
+		{newTestUsage("user-1", 1000, 500), newTestUsage("user-1", 100, 100)},
+		{newTestUsage("user-1", 2000, 0)},
+	}
+
+	// 设置 mock 期望：过滤条件包含用户与时间范围
+	mockRepo.On("FindInBatches", ctx, mock.MatchedBy(func(f map[string]interface{}) bool {
+		_, hasStart := f["start_time"].(time.Time)
+		_, hasEnd := f["end_time"].(time.Time)
+		return f["user_id"] == "user-1" && hasStart && hasEnd
+	}), exportBatchSize).Return(batches, nil)
+
+	// 执行
+	var buf bytes.Buffer
+	count, err := usageService.Export(ctx, req, &buf)
+
+	// 断言：表头 + 3 行数据
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, exportCSVHeader, records[0])
+	assert.Equal(t, "AAPL", records[1][2])
+	assert.Equal(t, "1500", records[1][7])
+	assert.Equal(t, "0.020000", records[1][8]) // 1000/1000*0.01 + 500/1000*0.02
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRiskReportUsageService_Export_EscapesFormulas(t *testing.T) {
+	// 准备：上报的文本字段以公式字符开头
+	mockRepo := new(MockRiskReportUsageRepository)
+	usageService := NewRiskReportUsageService(mockRepo, newTestConfig(), newTestLogger())
+
+	ctx := context.Background()
+	usage := newTestUsage("user-1", 100, 100)
+	usage.Ticker = "=HYPERLINK(\"http://evil\")"
+	usage.ErrorMessage = "@SUM(A1)"
+	mockRepo.On("FindInBatches", ctx, mock.Anything, exportBatchSize).
+		Return([][]model.RiskReportUsage{{usage}}, nil)
+
+	// 执行
+	var buf bytes.Buffer
+	_, err := usageService.Export(ctx, &model.RiskReportUsageExportRequest{UserID: "user-1"}, &buf)
+
+	// 断言：公式前加单引号，数值列不受影响
+	require.NoError(t, err)
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "'=HYPERLINK(\"http://evil\")", records[1][2])
+	assert.Equal(t, "'@SUM(A1)", records[1][12])
+	assert.Equal(t, "200", records[1][7])
+}
+
+func TestRiskReportUsageService_Export_InvalidTime(t *testing.T) {
+	// 准备
+	mockRepo := new(MockRiskReportUsageRepository)
+	usageService := NewRiskReportUsageService(mockRepo, newTestConfig(), newTestLogger())
+
+	req := &model.RiskReportUsageExportRequest{
+		UserID:    "user-1",
+		StartTime: "2024-03-01",
+	}
+
+	// 执行
+	var buf bytes.Buffer
+	_, err := usageService.Export(context.Background(), req, &buf)
+
+	// 断言：参数错误时不写出任何内容
+	assert.Error(t, err)
+	assert.Equal(t, errors.CodeValidation, errors.AsAppError(err).Code)
+	assert.Zero(t, buf.Len())
+	mockRepo.AssertNotCalled(t, "FindInBatches", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// ============================================================
+// 请求取消测试
+// ============================================================
+
+func TestRiskReportUsageService_List_ContextCanceled(t *testing.T) {
+	// 准备：客户端已断开
+	mockRepo := new(MockRiskReportUsageRepository)
+	usageService := NewRiskReportUsageService(mockRepo, newTestConfig(), newTestLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// 执行
+	usages, total, err := usageService.List(ctx, &model.RiskReportUsageListRequest{UserID: "user-1"})
+
+	// 断言：提前返回 context.Canceled，不访问仓储
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, usages)
+	assert.Zero(t, total)
+	mockRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRiskReportUsageService_GetUserStats_IncludesPercentiles(t *testing.T) {
+	// 准备
+	mockRepo := new(MockRiskReportUsageRepository)
+	usageService := NewRiskReportUsageService(mockRepo, newTestConfig(), newTestLogger())
+
+	// 设置 mock 期望
+	mockRepo.On("GetStatsByUser", mock.Anything, "user-1", mock.Anything, mock.Anything).
+		Return(&model.UsageStatsResponse{
+			TotalQueries:          20,
+			TotalTokens:           3000,
+			TotalPromptTokens:     2000,
+			TotalCompletionTokens: 1000,
+			AvgResponseTimeMs:     300,
+		}, nil)
+	mockRepo.On("LatencyPercentiles", mock.Anything, "user-1", mock.Anything, mock.Anything).
+		Return(&model.LatencyPercentiles{Count: 20, P50: 120, P95: 900, P99: 2500}, nil)
+
+	// 执行
+	stats, err := usageService.GetUserStats(context.Background(), "user-1", time.Time{}, time.Time{})
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, &model.UsageStatsResponse{
+		TotalQueries:          20,
+		TotalTokens:           3000,
+		TotalPromptTokens:     2000,
+		TotalCompletionTokens: 1000,
+		AvgResponseTimeMs:     300,
+		P50ResponseTimeMs:     120,
+		P95ResponseTimeMs:     900,
+		P99ResponseTimeMs:     2500,
+	}, stats)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRiskReportUsageService_StatsByActionSuggestion_Ratio(t *testing.T) {
+	mockRepo := new(MockRiskReportUsageRepository)
+	usageService := NewRiskReportUsageService(mockRepo, newTestConfig(), newTestLogger())
+	ctx := context.Background()
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	mockRepo.On("StatsByActionSuggestion", ctx, "user-1", start, end).Return([]model.ActionSuggestionStats{
+		{ActionSuggestion: "持有", Count: 3},
+		{ActionSuggestion: "买入", Count: 2},
+		{ActionSuggestion: model.ActionSuggestionUnknown, Count: 1},
+	}, nil)
+
+	stats, err := usageService.StatsByActionSuggestion(ctx, "user-1", start, end)
+
+	require.NoError(t, err)
+	assert.Equal(t, []model.ActionSuggestionStats{
+		{ActionSuggestion: "持有", Count: 3, Ratio: 0.5},
+		{ActionSuggestion: "买入", Count: 2, Ratio: 0.3333},
+		{ActionSuggestion: model.ActionSuggestionUnknown, Count: 1, Ratio: 0.1667},
+	}, stats)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRiskReportUsageService_StatsByActionSuggestion_Empty(t *testing.T) {
+	mockRepo := new(MockRiskReportUsageRepository)
+	usageService := NewRiskReportUsageService(mockRepo, newTestConfig(), newTestLogger())
+	ctx := context.Background()
+
+	mockRepo.On("StatsByActionSuggestion", ctx, "", time.Time{}, time.Time{}).Return([]model.ActionSuggestionStats{}, nil)
+
+	stats, err := usageService.StatsByActionSuggestion(ctx, "", time.Time{}, time.Time{})
+
+	require.NoError(t, err)
+	assert.Empty(t, stats)
+}
+
+func TestRiskReportUsageService_StatsByMarketState_ContextCanceled(t *testing.T) {
+	mockRepo := new(MockRiskReportUsageRepository)
+	usageService := NewRiskReportUsageService(mockRepo, newTestConfig(), newTestLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stats, err := usageService.StatsByMarketState(ctx, "user-1", time.Time{}, time.Time{})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, stats)
+	mockRepo.AssertNotCalled(t, "StatsByMarketState", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// cancelOnWrite 首次写入后取消上下文，模拟客户端在下载过程中断开
+type cancelOnWrite struct {
+	bytes.Buffer
+	cancel context.CancelFunc
+}
+
+func (w *cancelOnWrite) Write(p []byte) (int, error) {
+	defer w.cancel()
+	return w.Buffer.Write(p)
+}
+
+func TestRiskReportUsageService_Export_StopsWhenCanceled(t *testing.T) {
+	// 准备
+	mockRepo := new(MockRiskReportUsageRepository)
+	usageService := NewRiskReportUsageService(mockRepo, newTestConfig(), newTestLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	batches := [][]model.RiskReportUsage{
+		{newTestUsage("user-1", 100, 100)},
+		{newTestUsage("user-1", 200, 200)},
+	}
+
+	// 设置 mock 期望
+	mockRepo.On("FindInBatches", ctx, mock.Anything, exportBatchSize).Return(batches, nil)
+
+	// 执行：第一批写出后客户端断开
+	w := &cancelOnWrite{cancel: cancel}
+	count, err := usageService.Export(ctx, &model.RiskReportUsageExportRequest{UserID: "user-1"}, w)
+
+	// 断言：只导出第一批，返回 context.Canceled
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, count)
+}
+
+// ============================================================
+// 更新与删除测试
+// ============================================================
+
+func TestRiskReportUsageService_Update_Success(t *testing.T) {
+	// 准备
+	mockRepo := new(MockRiskReportUsageRepository)
+	usageService := NewRiskReportUsageService(mockRepo, newTestConfig(), newTestLogger())
+	ctx := context.Background()
+
+	existing := newTestUsage("user-1", 100, 50)
+	existing.ID = "usage-1"
+	existing.AIResponse = "ok"
+
+	// 设置 mock 期望
+	mockRepo.On("GetByID", ctx, "usage-1").Return(&existing, nil)
+	mockRepo.On("Update", ctx, mock.AnythingOfType("*model.RiskReportUsage")).Return(nil)
+
+	// 执行：修正 token 数并补充市场状态
+	prompt, completion, total := 200, 80, 280
+	state := model.MarketStateREGULAR
+	usage, err := usageService.Update(ctx, "usage-1", &model.UpdateRiskReportUsageRequest{
+		PromptTokens:     &prompt,
+		CompletionTokens: &completion,
+		TotalTokens:      &total,
+		MarketState:      &state,
+	})
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, 200, usage.PromptTokens)
+	assert.Equal(t, 80, usage.CompletionTokens)
+	assert.Equal(t, 280, usage.TotalTokens)
+	assert.Equal(t, model.MarketStateREGULAR, usage.MarketState)
+	// 未出现在请求中的字段保持不变
+	assert.Equal(t, "user-1", usage.UserID)
+	assert.Equal(t, "AAPL", usage.Ticker)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRiskReportUsageService_Update_InconsistentTokens(t *testing.T) {
+	// 准备
+	mockRepo := new(MockRiskReportUsageRepository)
+	usageService := NewRiskReportUsageService(mockRepo, newTestConfig(), newTestLogger())
+	ctx := context.Background()
+
+	existing := newTestUsage("user-1", 100, 50)
+	existing.ID = "usage-1"
+
+	// 设置 mock 期望
+	mockRepo.On("GetByID", ctx, "usage-1").Return(&existing, nil)
+
+	// 执行：只改 prompt_tokens，total_tokens 不再等于两者之和
+	prompt := 200
+	usage, err := usageService.Update(ctx, "usage-1", &model.UpdateRiskReportUsageRequest{PromptTokens: &prompt})
+
+	// 断言
+	require.Error(t, err)
+	assert.Nil(t, usage)
+	assert.Contains(t, err.Error(), "total_tokens")
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestRiskReportUsageService_Update_NotFound(t *testing.T) {
+	// 准备
+	mockRepo := new(MockRiskReportUsageRepository)
+	usageService := NewRiskReportUsageService(mockRepo, newTestConfig(), newTestLogger())
+	ctx := context.Background()
+
+	// 设置 mock 期望
+	mockRepo.On("GetByID", ctx, "missing").Return(nil, errors.ErrResourceNotFound)
+
+	// 执行
+	ticker := "MSFT"
+	_, err := usageService.Update(ctx, "missing", &model.UpdateRiskReportUsageRequest{Ticker: &ticker})
+
+	// 断言
+	assert.ErrorIs(t, err, errors.ErrResourceNotFound)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestRiskReportUsageService_Delete(t *testing.T) {
+	// 准备
+	mockRepo := new(MockRiskReportUsageRepository)
+	usageService := NewRiskReportUsageService(mockRepo, newTestConfig(), newTestLogger())
+	ctx := context.Background()
+
+	// 设置 mock 期望
+	mockRepo.On("Delete", ctx, "usage-1").Return(nil)
+	mockRepo.On("Delete", ctx, "missing").Return(errors.ErrResourceNotFound)
+
+	// 执行 & 断言
+	assert.NoError(t, usageService.Delete(ctx, "usage-1"))
+	assert.ErrorIs(t, usageService.Delete(ctx, "missing"), errors.ErrResourceNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+// ============================================================
+// 新闻情绪校验测试
+// ============================================================
+
+func TestRiskReportUsageService_Create_InvalidSentiment(t *testing.T) {
+	score := func(v int) *int { return &v }
+	tests := []struct {
+		name    string
+		score   *int
+		label   string
+		message string
+	}{
+		{name: "分数低于下限", score: score(-101), message: "news_sentiment_score 必须在 -100 到 100 之间"},
+		{name: "分数高于上限", score: score(101), message: "news_sentiment_score 必须在 -100 到 100 之间"},
+		{name: "非法标签", score: score(50), label: "bullish", message: "news_sentiment_label 必须是 positive/neutral/negative 之一"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 准备
+			mockRepo := new(MockRiskReportUsageRepository)
+			usageService := NewRiskReportUsageService(mockRepo, newTestConfig(), newTestLogger())
+			req := newQuotaTestRequest("user-1", 100)
+			req.NewsSentimentScore = tt.score
+			req.NewsSentimentLabel = tt.label
+
+			// 执行
+			usage, err := usageService.Create(context.Background(), req)
+
+			// 断言
+			assert.Nil(t, usage)
+			appErr := errors.AsAppError(err)
+			require.NotNil(t, appErr)
+			assert.Equal(t, errors.CodeValidation, appErr.Code)
+			assert.Equal(t, tt.message, appErr.Message)
+			mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRiskReportUsageService_Create_NormalizesSentimentLabel(t *testing.T) {
+	tests := []struct {
+		score    int
+		label    string
+		expected string
+	}{
+		{score: 100, label: " Positive ", expected: model.SentimentLabelPositive},
+		{score: 0, label: "中性", expected: model.SentimentLabelNeutral},
+		{score: -100, label: "偏空", expected: model.SentimentLabelNegative},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			// 准备
+			mockRepo := new(MockRiskReportUsageRepository)
+			usageService := NewRiskReportUsageService(mockRepo, newTestConfig(), newTestLogger())
+			ctx := context.Background()
+			req := newQuotaTestRequest("user-1", 100)
+			req.NewsSentimentScore = &tt.score
+			req.NewsSentimentLabel = tt.label
+
+			// 设置 mock 期望
+			mockRepo.On("Create", ctx, mock.AnythingOfType("*model.RiskReportUsage")).Return(nil)
+
+			// 执行
+			usage, err := usageService.Create(ctx, req)
+
+			// 断言：边界分数通过，标签保存为枚举值
+			require.NoError(t, err)
+			assert.Equal(t, tt.score, *usage.NewsSentimentScore)
+			assert.Equal(t, tt.expected, usage.NewsSentimentLabel)
+		})
+	}
+}
+
+func TestRiskReportUsageService_Backfill_HistoricalRecords(t *testing.T) {
+	// 准备
+	mockRepo := new(MockRiskReportUsageRepository)
+	cfg := newTestConfig()
+	// 配额很小，回填不检查配额
+	cfg.RiskReport.MonthlyTokenQuota = 1
+	usageService := NewRiskReportUsageService(mockRepo, cfg, newTestLogger())
+	ctx := context.Background()
+
+	old := newQuotaTestRequest("user-1", 100)
+	old.RequestTime = time.Date(2019, 5, 1, 9, 30, 0, 0, time.UTC)
+	old.ResponseTime = old.RequestTime.Add(2 * time.Second)
+	// 旧系统时钟偏差：响应时间早于请求时间
+	skewed := newQuotaTestRequest("user-2", 200)
+	skewed.RequestTime = time.Date(2018, 12, 31, 23, 59, 59, 0, time.UTC)
+	skewed.ResponseTime = skewed.RequestTime.Add(-time.Second)
+	// 核心字段校验仍然生效
+	invalid := newQuotaTestRequest("user-3", 100)
+	invalid.Ticker = "invalid ticker"
+
+	// 设置 mock 期望
+	var saved []model.RiskReportUsage
+	mockRepo.On("BatchCreate", ctx, mock.AnythingOfType("[]model.RiskReportUsage")).
+		Run(func(args mock.Arguments) {
+			saved = args.Get(1).([]model.RiskReportUsage)
+			for i := range saved {
+				saved[i].ID = fmt.Sprintf("usage-%d", i+1)
+			}
+		}).
+		Return(nil)
+
+	// 执行
+	result, err := usageService.Backfill(ctx, &model.BackfillRiskReportUsageRequest{
+		Records: []model.CreateRiskReportUsageRequest{*old, *skewed, *invalid},
+	})
+
+	// 断言：历史时间戳原样写入
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.SuccessCount)
+	assert.Equal(t, 1, result.FailureCount)
+	assert.Equal(t, []string{"usage-1", "usage-2"}, result.RecordIDs)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0], "记录 3")
+	require.Len(t, saved, 2)
+	assert.Equal(t, old.RequestTime, saved[0].RequestTime)
+	assert.Equal(t, skewed.ResponseTime, saved[1].ResponseTime)
+	mockRepo.AssertNotCalled(t, "GetStatsByUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
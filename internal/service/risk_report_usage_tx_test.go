@@ -0,0 +1,100 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含风险报告批量上报事务传播的测试，使用内存 SQLite 数据库
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// newUsageTxFixture 创建基于内存 SQLite 的使用记录仓储、事务管理器与服务
+func newUsageTxFixture(t *testing.T) (repository.RiskReportUsageRepository, repository.TxManager, RiskReportUsageService) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.RiskReportUsage{}, &model.RiskReportUsageDaily{}))
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	repo := repository.NewRiskReportUsageRepository(db)
+	txm := repository.NewTxManager(db)
+	cfg := newTestConfig()
+	cfg.RiskReport.MonthlyTokenQuota = 10000
+	svc := NewRiskReportUsageService(repo, cfg, newTestLogger(), WithTxManager(txm))
+	return repo, txm, svc
+}
+
+// countUserUsages 统计用户的使用记录条数，ctx 携带事务时在该事务中统计
+func countUserUsages(t *testing.T, ctx context.Context, repo repository.RiskReportUsageRepository, userID string) int64 {
+	t.Helper()
+	_, total, err := repo.List(ctx, map[string]interface{}{"user_id": userID}, 1, 1)
+	require.NoError(t, err)
+	return total
+}
+
+func TestRiskReportUsageService_BatchCreate_RollsBackWithOuterTransaction(t *testing.T) {
+	// 准备
+	repo, txm, svc := newUsageTxFixture(t)
+	ctx := context.Background()
+	req := &model.BatchCreateRiskReportUsageRequest{
+		Records: []model.CreateRiskReportUsageRequest{
+			*newQuotaTestRequest("user-1", 100),
+			*newQuotaTestRequest("user-1", 200),
+		},
+	}
+	quotaErr := stderrors.New("更新用户配额失败")
+
+	// 执行：批量创建成功后配额更新失败
+	var resp *model.BatchCreateRiskReportUsageResponse
+	err := txm.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = svc.BatchCreate(ctx, req)
+		if err != nil {
+			return err
+		}
+		// 事务内可以看到本批次写入的记录
+		assert.Equal(t, int64(2), countUserUsages(t, ctx, repo, "user-1"))
+		return quotaErr
+	})
+
+	// 断言：批量创建本身成功，但随外部事务一起回滚
+	assert.ErrorIs(t, err, quotaErr)
+	require.NotNil(t, resp)
+	assert.Equal(t, 2, resp.SuccessCount)
+	assert.Equal(t, int64(0), countUserUsages(t, context.Background(), repo, "user-1"))
+}
+
+func TestRiskReportUsageService_BatchCreate_CommitsWithOuterTransaction(t *testing.T) {
+	// 准备
+	repo, txm, svc := newUsageTxFixture(t)
+	ctx := context.Background()
+	req := &model.BatchCreateRiskReportUsageRequest{
+		Records: []model.CreateRiskReportUsageRequest{*newQuotaTestRequest("user-1", 100)},
+	}
+
+	// 执行
+	err := txm.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := svc.BatchCreate(ctx, req)
+		return err
+	})
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), countUserUsages(t, context.Background(), repo, "user-1"))
+}
@@ -0,0 +1,204 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件提供表格数据（CSV / xlsx）的读写工具，供导入导出功能共用。
+package service
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// 支持的表格格式
+const (
+	// FormatCSV CSV 格式
+	FormatCSV = "csv"
+	// FormatXLSX Excel 格式
+	FormatXLSX = "xlsx"
+)
+
+// 表格格式对应的 Content-Type
+const (
+	// ContentTypeCSV CSV 文件的 Content-Type
+	ContentTypeCSV = "text/csv; charset=utf-8"
+	// ContentTypeXLSX xlsx 文件的 Content-Type
+	ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// xlsxSheetName 导出 xlsx 时使用的工作表名称
+const xlsxSheetName = "Sheet1"
+
+// formulaPrefixes 表格软件会按公式解析的单元格起始字符
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeFormula 防止单元格内容被表格软件当作公式执行（CSV 注入）
+// 以公式字符开头的文本前加单引号；数值（如 -1.5）保持原样
+func escapeFormula(v string) string {
+	if v == "" || !strings.ContainsRune(formulaPrefixes, rune(v[0])) {
+		return v
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return v
+	}
+	return "'" + v
+}
+
+// tableWriter 表格写入器
+// 先写表头，再逐行写入数据，最后必须调用 Close 输出完整内容；单元格内容经 escapeFormula 转义
+type tableWriter interface {
+	// WriteRow 写入一行数据
+	WriteRow(row []string) error
+	// Flush 将已缓冲的数据尽量写出，格式不支持流式输出时为空操作
+	Flush() error
+	// Close 写出剩余内容并释放资源
+	Close() error
+}
+
+// newTableWriter 根据格式创建表格写入器，并写入表头
+func newTableWriter(format string, w io.Writer, header []string) (tableWriter, error) {
+	var tw tableWriter
+	switch format {
+	case FormatCSV:
+		tw = &csvTableWriter{w: csv.NewWriter(w)}
+		if err := tw.WriteRow(header); err != nil {
+			return nil, err
+		}
+	case FormatXLSX:
+		xw, err := newXLSXTableWriter(w, header)
+		if err != nil {
+			return nil, err
+		}
+		tw = xw
+	default:
+		return nil, fmt.Errorf("不支持的表格格式: %s", format)
+	}
+	return tw, nil
+}
+
+// csvTableWriter CSV 表格写入器
+type csvTableWriter struct {
+	w *csv.Writer
+}
+
+func (t *csvTableWriter) WriteRow(row []string) error {
+	escaped := make([]string, len(row))
+	for i, v := range row {
+		escaped[i] = escapeFormula(v)
+	}
+	return t.w.Write(escaped)
+}
+
+func (t *csvTableWriter) Flush() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+func (t *csvTableWriter) Close() error {
+	return t.Flush()
+}
+
+// xlsxTableWriter xlsx 表格写入器
+// 使用 excelize 的流式写入减少内存占用；xlsx 是压缩包格式，
+// 只能在 Close 时一次性输出
+type xlsxTableWriter struct {
+	w    io.Writer
+	file *excelize.File
+	sw   *excelize.StreamWriter
+	row  int
+}
+
+// newXLSXTableWriter 创建 xlsx 写入器并写入带样式的表头
+func newXLSXTableWriter(w io.Writer, header []string) (*xlsxTableWriter, error) {
+	file := excelize.NewFile()
+	sw, err := file.NewStreamWriter(xlsxSheetName)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	// 表头加粗、浅灰底色，并冻结首行方便浏览
+	styleID, err := file.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#D9D9D9"}},
+	})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := sw.SetPanes(&excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	cells := make([]interface{}, len(header))
+	for i, h := range header {
+		cells[i] = excelize.Cell{StyleID: styleID, Value: h}
+	}
+	if err := sw.SetRow("A1", cells); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &xlsxTableWriter{w: w, file: file, sw: sw, row: 1}, nil
+}
+
+func (t *xlsxTableWriter) WriteRow(row []string) error {
+	t.row++
+	cell, err := excelize.CoordinatesToCellName(1, t.row)
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, len(row))
+	for i, v := range row {
+		values[i] = escapeFormula(v)
+	}
+	return t.sw.SetRow(cell, values)
+}
+
+func (t *xlsxTableWriter) Flush() error {
+	return nil
+}
+
+func (t *xlsxTableWriter) Close() error {
+	defer t.file.Close()
+	if err := t.sw.Flush(); err != nil {
+		return err
+	}
+	_, err := t.file.WriteTo(t.w)
+	return err
+}
+
+// readTable 读取表格的全部行（包含表头）
+// xlsx 只读取第一个工作表
+func readTable(format string, r io.Reader) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		// 允许各行列数不一致，缺失的列按空值处理
+		cr.FieldsPerRecord = -1
+		return cr.ReadAll()
+	case FormatXLSX:
+		file, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return file.GetRows(file.GetSheetName(0))
+	default:
+		return nil, fmt.Errorf("不支持的表格格式: %s", format)
+	}
+}
+
+// IsSupportedFormat 判断是否为支持的表格格式
+func IsSupportedFormat(format string) bool {
+	return format == FormatCSV || format == FormatXLSX
+}
@@ -0,0 +1,39 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件实现了登出时吊销访问令牌。
+// 访问令牌是无状态的，登出后仍可使用到自然过期；设置令牌黑名单后，
+// 登出时把当前访问令牌的 jti 加入黑名单，认证中间件命中即拒绝。
+package service
+
+import (
+	"context"
+
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/example/go-user-api/pkg/tokenblacklist"
+)
+
+// WithTokenBlacklist 设置令牌黑名单
+// 设置后登出时当前访问令牌立即失效；认证中间件需使用同一个黑名单（见 middleware.WithTokenBlacklist）
+func WithTokenBlacklist(bl tokenblacklist.TokenBlacklist) UserServiceOption {
+	return func(s *userService) {
+		s.tokenBlacklist = bl
+	}
+}
+
+// revokeAccessToken 将访问令牌加入黑名单，记录保留到令牌过期
+// 未设置黑名单或令牌缺少 jti、过期时间时跳过
+func (s *userService) revokeAccessToken(ctx context.Context, claims *TokenClaims) error {
+	if s.tokenBlacklist == nil || claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	if err := s.tokenBlacklist.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		s.log.Error("吊销访问令牌失败",
+			logger.String("user_id", claims.UserID),
+			logger.String("jti", claims.ID),
+			logger.Err(err),
+		)
+		return errors.ErrServiceUnavailable.WithError(err)
+	}
+	return nil
+}
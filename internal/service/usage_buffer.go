@@ -0,0 +1,250 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含风险报告使用记录的异步批量写入缓冲。
+// 高频单条上报逐条写库压力大，启用缓冲后记录先进入内存，
+// 达到批量大小或定时间隔时通过 BatchCreate 一次写入，服务关闭时写入剩余记录。
+// 写库失败的批次放回缓冲头部，等下一个写库间隔重试。
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/repository"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+// UsageBuffer 使用记录写入缓冲
+//
+// 写库失败的批次放回缓冲头部，至少间隔一个写库周期后重试（BatchCreate 整批写入或整批失败，重试不会重复落库）；
+// 缓冲中的记录超过 maxPending 时丢弃最早的记录并记录日志，避免数据库长时间不可用时内存无限增长。
+// 进程异常退出时缓冲中尚未写库的记录会丢失。
+//
+// 使用示例：
+//
+//	buf := service.NewUsageBuffer(repo, cfg.RiskReport.Buffer, log)
+//	buf.Start()
+//	defer buf.Close(ctx)
+type UsageBuffer struct {
+	repo      repository.RiskReportUsageRepository
+	log       logger.Logger
+	batchSize int
+	interval  time.Duration
+	// maxPending 缓冲最多保留的记录数，包括写库失败待重试的记录
+	maxPending int
+	// sync 同步模式：不启动后台 goroutine，达到批量大小时由 Add 的调用方直接写库
+	sync bool
+
+	mu      sync.Mutex
+	pending []model.RiskReportUsage
+	// inflight 正在写库的批次，写库期间仍计入配额用量
+	inflight []model.RiskReportUsage
+	// retryAfter 写库失败后，在此之前不因缓冲满而提前写库
+	retryAfter time.Time
+	closed     bool
+	started    bool
+
+	// flushMu 保证同一时刻只有一个批次在写库
+	flushMu sync.Mutex
+	// full 缓冲达到批量大小时通知后台 goroutine
+	full chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewUsageBuffer 创建使用记录写入缓冲
+func NewUsageBuffer(repo repository.RiskReportUsageRepository, cfg config.UsageBufferConfig, log logger.Logger) *UsageBuffer {
+	batchSize := cfg.BatchSize
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	maxPending := cfg.MaxPending
+	if maxPending < batchSize {
+		maxPending = batchSize
+	}
+	return &UsageBuffer{
+		repo:       repo,
+		log:        log.With(logger.String("component", "usage_buffer")),
+		batchSize:  batchSize,
+		interval:   cfg.FlushIntervalDuration(),
+		maxPending: maxPending,
+		sync:       cfg.Sync,
+		full:       make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start 启动后台定时写库，同步模式下不做任何事
+func (b *UsageBuffer) Start() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.sync || b.started || b.closed || b.interval <= 0 {
+		return
+	}
+	b.started = true
+	go b.loop()
+}
+
+// Add 将记录加入缓冲
+// 记录的 ID 与创建时间在入缓冲时生成，调用方可直接返回给客户端
+func (b *UsageBuffer) Add(ctx context.Context, usage *model.RiskReportUsage) error {
+	now := time.Now()
+	if usage.ID == "" {
+		usage.ID = model.DefaultIDGenerator.NewID()
+	}
+	if usage.CreatedAt.IsZero() {
+		usage.CreatedAt = now
+	}
+	if usage.UpdatedAt.IsZero() {
+		usage.UpdatedAt = now
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return errors.ErrInternalServer.WithDetail("使用记录缓冲已关闭")
+	}
+	b.pending = append(b.pending, *usage)
+	b.dropOverflowLocked()
+	full := len(b.pending) >= b.batchSize && !time.Now().Before(b.retryAfter)
+	b.mu.Unlock()
+
+	if !full {
+		return nil
+	}
+	if b.sync {
+		// 记录已被接受，写库失败由 Flush 放回缓冲并记录日志，不影响本次上报结果
+		_ = b.Flush(ctx)
+		return nil
+	}
+	select {
+	case b.full <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Len 返回缓冲中尚未写库的记录数
+func (b *UsageBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+// PendingTokens 返回用户在 [start, end] 内尚未写库的 token 数，包括正在写库的批次
+// 配额检查将其与已落库的用量相加，避免缓冲期间超出配额
+func (b *UsageBuffer) PendingTokens(userID string, start, end time.Time) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var total int64
+	for _, records := range [][]model.RiskReportUsage{b.inflight, b.pending} {
+		for i := range records {
+			r := &records[i]
+			if r.UserID == userID && !r.RequestTime.Before(start) && !r.RequestTime.After(end) {
+				total += int64(r.TotalTokens)
+			}
+		}
+	}
+	return total
+}
+
+// Flush 立即将缓冲中的记录写库
+// 写库失败时批次放回缓冲头部，等待下次写库重试
+func (b *UsageBuffer) Flush(ctx context.Context) error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = nil
+	b.inflight = batch
+	b.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+	err := b.repo.BatchCreate(ctx, batch)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.inflight = nil
+	if err != nil {
+		b.pending = append(batch, b.pending...)
+		b.retryAfter = time.Now().Add(b.interval)
+		b.dropOverflowLocked()
+		b.log.Error("批量写入使用记录失败，记录已放回缓冲等待重试",
+			logger.Int("count", len(batch)),
+			logger.Int("pending", len(b.pending)),
+			logger.Err(err),
+		)
+		return err
+	}
+	b.retryAfter = time.Time{}
+	b.log.Debug("批量写入使用记录", logger.Int("count", len(batch)))
+	return nil
+}
+
+// dropOverflowLocked 缓冲超过 maxPending 时丢弃最早的记录，调用方需持有 mu
+func (b *UsageBuffer) dropOverflowLocked() {
+	overflow := len(b.pending) - b.maxPending
+	if overflow <= 0 {
+		return
+	}
+	b.pending = append([]model.RiskReportUsage(nil), b.pending[overflow:]...)
+	b.log.Error("使用记录缓冲已满，丢弃最早的记录",
+		logger.Int("dropped", overflow),
+		logger.Int("max_pending", b.maxPending),
+	)
+}
+
+// Close 停止接收新记录，等待后台 goroutine 退出后写入剩余记录
+func (b *UsageBuffer) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	started := b.started
+	b.mu.Unlock()
+
+	if started {
+		close(b.stop)
+		select {
+		case <-b.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return b.Flush(ctx)
+}
+
+// loop 后台定时写库，缓冲满时提前写库
+func (b *UsageBuffer) loop() {
+	defer close(b.done)
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-b.full:
+			// 写库失败后的重试等待期内，缓冲满不提前写库，等定时写库重试
+			b.mu.Lock()
+			waiting := time.Now().Before(b.retryAfter)
+			b.mu.Unlock()
+			if waiting {
+				continue
+			}
+		case <-b.stop:
+			return
+		}
+		_ = b.Flush(context.Background())
+	}
+}
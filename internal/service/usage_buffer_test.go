@@ -0,0 +1,200 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含使用记录写入缓冲的单元测试
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordBatches 让 mock 的 BatchCreate 记录每次写入的批次
+func recordBatches(mockRepo *MockRiskReportUsageRepository) func() [][]model.RiskReportUsage {
+	var mu sync.Mutex
+	var batches [][]model.RiskReportUsage
+	mockRepo.On("BatchCreate", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			mu.Lock()
+			defer mu.Unlock()
+			batches = append(batches, args.Get(1).([]model.RiskReportUsage))
+		}).
+		Return(nil)
+	return func() [][]model.RiskReportUsage {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([][]model.RiskReportUsage(nil), batches...)
+	}
+}
+
+func TestUsageBuffer_FlushesWhenBatchSizeReached(t *testing.T) {
+	// 准备：同步模式，批量大小 3
+	mockRepo := new(MockRiskReportUsageRepository)
+	batches := recordBatches(mockRepo)
+	buf := NewUsageBuffer(mockRepo, config.UsageBufferConfig{Enabled: true, BatchSize: 3, FlushInterval: 1000, Sync: true}, newTestLogger())
+	buf.Start()
+	ctx := context.Background()
+
+	// 执行：前两条只进入缓冲
+	for i := 0; i < 2; i++ {
+		usage := newTestUsage("user-1", 10, 5)
+		require.NoError(t, buf.Add(ctx, &usage))
+	}
+
+	// 断言
+	assert.Empty(t, batches())
+	assert.Equal(t, 2, buf.Len())
+
+	// 执行：第三条达到阈值
+	usage := newTestUsage("user-1", 10, 5)
+	require.NoError(t, buf.Add(ctx, &usage))
+
+	// 断言：三条记录一次写库，并已生成 ID
+	require.Len(t, batches(), 1)
+	assert.Len(t, batches()[0], 3)
+	assert.NotEmpty(t, batches()[0][2].ID)
+	assert.Equal(t, usage.ID, batches()[0][2].ID)
+	assert.Zero(t, buf.Len())
+}
+
+func TestUsageBuffer_CloseFlushesRemaining(t *testing.T) {
+	// 准备
+	mockRepo := new(MockRiskReportUsageRepository)
+	batches := recordBatches(mockRepo)
+	buf := NewUsageBuffer(mockRepo, config.UsageBufferConfig{Enabled: true, BatchSize: 10, FlushInterval: 60000}, newTestLogger())
+	buf.Start()
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		usage := newTestUsage("user-1", 10, 5)
+		require.NoError(t, buf.Add(ctx, &usage))
+	}
+	assert.Empty(t, batches())
+
+	// 执行
+	require.NoError(t, buf.Close(ctx))
+
+	// 断言：剩余记录写库，关闭后拒绝新记录
+	require.Len(t, batches(), 1)
+	assert.Len(t, batches()[0], 4)
+	usage := newTestUsage("user-1", 10, 5)
+	assert.Error(t, buf.Add(ctx, &usage))
+}
+
+func TestUsageBuffer_FlushesOnInterval(t *testing.T) {
+	// 准备：异步模式，间隔 10ms
+	mockRepo := new(MockRiskReportUsageRepository)
+	batches := recordBatches(mockRepo)
+	buf := NewUsageBuffer(mockRepo, config.UsageBufferConfig{Enabled: true, BatchSize: 100, FlushInterval: 10}, newTestLogger())
+	buf.Start()
+	defer buf.Close(context.Background())
+
+	// 执行
+	usage := newTestUsage("user-1", 10, 5)
+	require.NoError(t, buf.Add(context.Background(), &usage))
+
+	// 断言
+	assert.Eventually(t, func() bool { return len(batches()) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestRiskReportUsageService_Create_UsesBuffer(t *testing.T) {
+	// 准备
+	mockRepo := new(MockRiskReportUsageRepository)
+	batches := recordBatches(mockRepo)
+	buf := NewUsageBuffer(mockRepo, config.UsageBufferConfig{Enabled: true, BatchSize: 2, FlushInterval: 1000, Sync: true}, newTestLogger())
+	svc := NewRiskReportUsageService(mockRepo, newTestConfig(), newTestLogger(), WithUsageBuffer(buf))
+	ctx := context.Background()
+
+	// 执行
+	for i := 0; i < 2; i++ {
+		usage, err := svc.Create(ctx, newQuotaTestRequest("user-1", 100))
+		require.NoError(t, err)
+		assert.NotEmpty(t, usage.ID)
+	}
+
+	// 断言：不逐条写库，两条记录批量落库
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	require.Len(t, batches(), 1)
+	assert.Len(t, batches()[0], 2)
+}
+
+func TestUsageBuffer_RequeuesFailedBatch(t *testing.T) {
+	// 准备：第一次写库失败，第二次成功
+	mockRepo := new(MockRiskReportUsageRepository)
+	mockRepo.On("BatchCreate", mock.Anything, mock.Anything).Return(errors.ErrDatabaseError).Once()
+	batches := recordBatches(mockRepo)
+	buf := NewUsageBuffer(mockRepo, config.UsageBufferConfig{Enabled: true, BatchSize: 10, FlushInterval: 1000}, newTestLogger())
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		usage := newTestUsage("user-1", 10, 5)
+		require.NoError(t, buf.Add(ctx, &usage))
+	}
+
+	// 执行：写库失败
+	require.Error(t, buf.Flush(ctx))
+
+	// 断言：批次放回缓冲，新记录排在其后
+	assert.Equal(t, 2, buf.Len())
+	usage := newTestUsage("user-1", 10, 5)
+	require.NoError(t, buf.Add(ctx, &usage))
+
+	// 执行：重试
+	require.NoError(t, buf.Flush(ctx))
+
+	// 断言：三条记录一次写库，最新的记录在最后
+	require.Len(t, batches(), 1)
+	require.Len(t, batches()[0], 3)
+	assert.Equal(t, usage.ID, batches()[0][2].ID)
+	assert.Zero(t, buf.Len())
+}
+
+func TestUsageBuffer_DropsOldestBeyondMaxPending(t *testing.T) {
+	// 准备：写库一直失败，最多保留 3 条
+	mockRepo := new(MockRiskReportUsageRepository)
+	mockRepo.On("BatchCreate", mock.Anything, mock.Anything).Return(errors.ErrDatabaseError)
+	buf := NewUsageBuffer(mockRepo, config.UsageBufferConfig{Enabled: true, BatchSize: 2, FlushInterval: 1000, MaxPending: 3}, newTestLogger())
+	ctx := context.Background()
+
+	ids := make([]string, 0, 4)
+	for i := 0; i < 4; i++ {
+		usage := newTestUsage("user-1", 10, 5)
+		require.NoError(t, buf.Add(ctx, &usage))
+		ids = append(ids, usage.ID)
+	}
+
+	// 断言：丢弃最早的记录，剩余记录的 token 仍计入待写用量
+	assert.Equal(t, 3, buf.Len())
+	start, end := monthRange(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, int64(45), buf.PendingTokens("user-1", start, end))
+	assert.Zero(t, buf.PendingTokens("user-2", start, end))
+}
+
+func TestRiskReportUsageService_Create_QuotaCountsBufferedTokens(t *testing.T) {
+	// 准备：当月已落库 800，缓冲不会在本测试中写库
+	mockRepo := new(MockRiskReportUsageRepository)
+	cfg := newTestConfig()
+	cfg.RiskReport.MonthlyTokenQuota = 1000
+	buf := NewUsageBuffer(mockRepo, config.UsageBufferConfig{Enabled: true, BatchSize: 10, FlushInterval: 1000}, newTestLogger())
+	svc := NewRiskReportUsageService(mockRepo, cfg, newTestLogger(), WithUsageBuffer(buf))
+	ctx := context.Background()
+	mockRepo.On("GetStatsByUser", ctx, "user-1", mock.Anything, mock.Anything).
+		Return(&model.UsageStatsResponse{TotalTokens: 800}, nil)
+
+	// 执行
+	_, err := svc.Create(ctx, newQuotaTestRequest("user-1", 150))
+	require.NoError(t, err)
+	_, err = svc.Create(ctx, newQuotaTestRequest("user-1", 100))
+
+	// 断言：缓冲中的 150 计入已用量，800 + 150 + 100 超出配额
+	require.True(t, errors.Is(err, errors.ErrQuotaExceeded))
+	assert.Equal(t, 1, buf.Len())
+}
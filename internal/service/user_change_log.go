@@ -0,0 +1,127 @@
+// Package service 提供业务逻辑层的实现
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/repository"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+// monitoredUserFields 需要记录变更历史的用户字段，value 读取字段当前值
+var monitoredUserFields = []struct {
+	name  string
+	value func(u *model.User) string
+}{
+	{"email", func(u *model.User) string { return u.Email }},
+	{"role", func(u *model.User) string { return u.Role }},
+	{"status", func(u *model.User) string { return strconv.Itoa(int(u.Status)) }},
+}
+
+// WithUserChangeLogRepository 启用用户关键字段变更记录
+// 设置后通过 Update/AdminUpdate 修改 email、role、status 时，每个发生变化的字段写入一条变更记录
+func WithUserChangeLogRepository(repo repository.UserChangeLogRepository) UserServiceOption {
+	return func(s *userService) {
+		s.changeLogRepo = repo
+	}
+}
+
+// diffMonitoredFields 比较更新前的用户与待更新字段，返回受监控字段的变更记录
+func diffMonitoredFields(before *model.User, updates map[string]interface{}, changedBy string) []model.UserChangeLog {
+	var logs []model.UserChangeLog
+	for _, field := range monitoredUserFields {
+		value, ok := updates[field.name]
+		if !ok {
+			continue
+		}
+		oldValue := field.value(before)
+		newValue := formatFieldValue(value)
+		if oldValue == newValue {
+			continue
+		}
+		logs = append(logs, model.UserChangeLog{
+			UserID:    before.ID,
+			Field:     field.name,
+			OldValue:  oldValue,
+			NewValue:  newValue,
+			ChangedBy: changedBy,
+		})
+	}
+	return logs
+}
+
+// changesAuthorization 判断待更新字段是否改变用户的角色或状态
+func changesAuthorization(before *model.User, updates map[string]interface{}) bool {
+	if role, ok := updates["role"]; ok && formatFieldValue(role) != before.Role {
+		return true
+	}
+	if status, ok := updates["status"]; ok && formatFieldValue(status) != strconv.Itoa(int(before.Status)) {
+		return true
+	}
+	return false
+}
+
+// formatFieldValue 将待更新的字段值格式化为字符串
+func formatFieldValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int8:
+		return strconv.Itoa(int(v))
+	case int:
+		return strconv.Itoa(v)
+	default:
+		return ""
+	}
+}
+
+// recordUserChanges 写入受监控字段的变更记录
+// 用户数据已更新成功，这里失败只记录日志
+func (s *userService) recordUserChanges(ctx context.Context, before *model.User, updates map[string]interface{}, changedBy string) {
+	if s.changeLogRepo == nil {
+		return
+	}
+	logs := diffMonitoredFields(before, updates, changedBy)
+	if len(logs) == 0 {
+		return
+	}
+	if operatorID := logger.ImpersonatorFromContext(ctx); operatorID != "" {
+		for i := range logs {
+			logs[i].ImpersonatedBy = operatorID
+		}
+	}
+	if err := s.changeLogRepo.CreateBatch(ctx, logs); err != nil {
+		s.log.Error("记录用户变更历史失败",
+			logger.String("user_id", before.ID),
+			logger.String("changed_by", changedBy),
+			logger.Err(err),
+		)
+	}
+}
+
+// ListChangeLogs 分页获取用户的关键字段变更记录
+func (s *userService) ListChangeLogs(ctx context.Context, userID string, req *model.UserChangeLogListRequest) ([]model.UserChangeLog, int64, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = s.config.Pagination.DefaultPageSize
+	}
+
+	// 用户不存在时返回 404，而不是空列表
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, 0, err
+	}
+	if s.changeLogRepo == nil {
+		return []model.UserChangeLog{}, 0, nil
+	}
+
+	logs, total, err := s.changeLogRepo.ListByUser(ctx, userID, req.Page, req.PageSize)
+	if err != nil {
+		s.log.Error("查询用户变更历史失败", logger.String("user_id", userID), logger.Err(err))
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
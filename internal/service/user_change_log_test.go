@@ -0,0 +1,161 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含用户变更历史记录的单元测试
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================
+// Mock 用户变更记录仓储
+// ============================================================
+
+// MockUserChangeLogRepository 是 UserChangeLogRepository 接口的模拟实现
+type MockUserChangeLogRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserChangeLogRepository) CreateBatch(ctx context.Context, logs []model.UserChangeLog) error {
+	args := m.Called(ctx, logs)
+	return args.Error(0)
+}
+
+func (m *MockUserChangeLogRepository) ListByUser(ctx context.Context, userID string, page, pageSize int) ([]model.UserChangeLog, int64, error) {
+	args := m.Called(ctx, userID, page, pageSize)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]model.UserChangeLog), args.Get(1).(int64), args.Error(2)
+}
+
+// ============================================================
+// 变更历史测试
+// ============================================================
+
+// newChangeLogFixture 创建启用变更记录的用户服务
+func newChangeLogFixture() (*userService, *MockUserRepository, *MockUserChangeLogRepository) {
+	mockRepo := new(MockUserRepository)
+	mockChangeLog := new(MockUserChangeLogRepository)
+	cfg := newTestConfig()
+	svc := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger(),
+		WithUserChangeLogRepository(mockChangeLog),
+	).(*userService)
+	return svc, mockRepo, mockChangeLog
+}
+
+func TestUserService_AdminUpdate_RecordsEmailChange(t *testing.T) {
+	// 准备
+	svc, mockRepo, mockChangeLog := newChangeLogFixture()
+	ctx := context.Background()
+	user := newTestUser()
+
+	// 设置 mock 期望
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockRepo.On("UpdateFields", ctx, user.ID, map[string]interface{}{"email": "new@example.com"}).Return(nil)
+	var recorded []model.UserChangeLog
+	mockChangeLog.On("CreateBatch", ctx, mock.Anything).
+		Run(func(args mock.Arguments) { recorded = args.Get(1).([]model.UserChangeLog) }).
+		Return(nil)
+
+	// 执行
+	_, err := svc.AdminUpdate(ctx, user.ID, &model.AdminUpdateUserRequest{Email: "new@example.com"}, "admin-id")
+
+	// 断言
+	require.NoError(t, err)
+	require.Len(t, recorded, 1)
+	assert.Equal(t, user.ID, recorded[0].UserID)
+	assert.Equal(t, "email", recorded[0].Field)
+	assert.Equal(t, "test@example.com", recorded[0].OldValue)
+	assert.Equal(t, "new@example.com", recorded[0].NewValue)
+	assert.Equal(t, "admin-id", recorded[0].ChangedBy)
+	mockChangeLog.AssertNumberOfCalls(t, "CreateBatch", 1)
+}
+
+func TestUserService_RecordUserChanges_MarksImpersonator(t *testing.T) {
+	// 准备：请求上下文来自模拟令牌
+	svc, _, mockChangeLog := newChangeLogFixture()
+	ctx := logger.ContextWithImpersonator(context.Background(), "super-admin")
+	user := newTestUser()
+
+	var recorded []model.UserChangeLog
+	mockChangeLog.On("CreateBatch", ctx, mock.Anything).
+		Run(func(args mock.Arguments) { recorded = args.Get(1).([]model.UserChangeLog) }).
+		Return(nil)
+
+	// 执行
+	svc.recordUserChanges(ctx, user, map[string]interface{}{"email": "new@example.com"}, user.ID)
+
+	// 断言：审计记录同时保留令牌身份与真实操作者
+	require.Len(t, recorded, 1)
+	assert.Equal(t, user.ID, recorded[0].ChangedBy)
+	assert.Equal(t, "super-admin", recorded[0].ImpersonatedBy)
+}
+
+func TestUserService_AdminUpdate_SkipsUnchangedFields(t *testing.T) {
+	// 准备
+	svc, mockRepo, mockChangeLog := newChangeLogFixture()
+	ctx := context.Background()
+	user := newTestUser()
+	status := model.UserStatusActive
+
+	// 设置 mock 期望：role、status 与当前值相同，只改昵称
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockRepo.On("UpdateFields", ctx, user.ID, mock.AnythingOfType("map[string]interface {}")).Return(nil)
+
+	// 执行
+	_, err := svc.AdminUpdate(ctx, user.ID, &model.AdminUpdateUserRequest{
+		UpdateUserRequest: model.UpdateUserRequest{Nickname: "New Name"},
+		Role:              model.RoleUser,
+		Status:            &status,
+	}, "admin-id")
+
+	// 断言：角色与状态未变化，已签发的令牌保持有效
+	require.NoError(t, err)
+	mockChangeLog.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "IncrementTokenVersion", mock.Anything, mock.Anything)
+}
+
+func TestUserService_AdminUpdate_RoleChangeRevokesTokens(t *testing.T) {
+	// 准备
+	svc, mockRepo, mockChangeLog := newChangeLogFixture()
+	ctx := context.Background()
+	user := newTestUser()
+	user.Email = "admin@example.com"
+
+	// 设置 mock 期望
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockRepo.On("UpdateFields", ctx, user.ID, map[string]interface{}{"role": model.RoleAdmin}).Return(nil)
+	mockRepo.On("IncrementTokenVersion", ctx, user.ID).Return(nil)
+	mockChangeLog.On("CreateBatch", ctx, mock.Anything).Return(nil)
+
+	// 执行
+	_, err := svc.AdminUpdate(ctx, user.ID, &model.AdminUpdateUserRequest{Role: model.RoleAdmin}, "admin-id")
+
+	// 断言：携带旧角色的令牌失效
+	require.NoError(t, err)
+	mockRepo.AssertCalled(t, "IncrementTokenVersion", ctx, user.ID)
+}
+
+func TestDiffMonitoredFields_RoleAndStatus(t *testing.T) {
+	user := newTestUser()
+
+	logs := diffMonitoredFields(user, map[string]interface{}{
+		"status":   model.UserStatusDisabled,
+		"role":     model.RoleAdmin,
+		"nickname": "ignored",
+	}, "admin-id")
+
+	require.Len(t, logs, 2)
+	assert.Equal(t, "role", logs[0].Field)
+	assert.Equal(t, model.RoleUser, logs[0].OldValue)
+	assert.Equal(t, model.RoleAdmin, logs[0].NewValue)
+	assert.Equal(t, "status", logs[1].Field)
+}
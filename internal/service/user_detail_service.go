@@ -0,0 +1,144 @@
+// Package service 提供业务逻辑层的实现
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/repository"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+// recentLoginLimit 用户详情中返回的最近登录记录条数
+const recentLoginLimit = 10
+
+// 用户详情中各部分的名称，用于标识查询失败的部分
+const (
+	detailPartLogins   = "recent_logins"
+	detailPartSessions = "active_sessions"
+	detailPartTags     = "tags"
+)
+
+// UserDetailService 用户详情服务接口
+// 为管理员聚合用户的审计信息
+type UserDetailService interface {
+	// GetDetail 获取用户详细审计信息
+	GetDetail(ctx context.Context, userID string) (*model.UserDetailResponse, error)
+}
+
+// userDetailService 用户详情服务实现
+type userDetailService struct {
+	userRepo         repository.UserRepository
+	loginHistoryRepo repository.LoginHistoryRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	tagRepo          repository.UserTagRepository
+	log              logger.Logger
+}
+
+// NewUserDetailService 创建用户详情服务实例
+func NewUserDetailService(
+	userRepo repository.UserRepository,
+	loginHistoryRepo repository.LoginHistoryRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	tagRepo repository.UserTagRepository,
+	log logger.Logger,
+) UserDetailService {
+	return &userDetailService{
+		userRepo:         userRepo,
+		loginHistoryRepo: loginHistoryRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		tagRepo:          tagRepo,
+		log:              log.With(logger.String("service", "user_detail")),
+	}
+}
+
+// GetDetail 获取用户详细审计信息
+// 用户核心信息必须成功获取；登录历史、会话数、标签并发查询，
+// 其中任一部分失败时不影响整体返回，只在响应中标记为部分结果
+func (s *userDetailService) GetDetail(ctx context.Context, userID string) (*model.UserDetailResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 客户端已取消时不再发起并发查询
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	detail := &model.UserDetailResponse{
+		User:         user.ToResponse(),
+		RecentLogins: []model.LoginHistoryResponse{},
+		Tags:         []string{},
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		errMap = make(map[string]string)
+	)
+
+	// recordErr 记录某部分查询失败
+	// 完整错误只写日志，响应中只返回错误的对外描述，不暴露 SQL 等内部信息
+	recordErr := func(part string, err error) {
+		s.log.Warn("获取用户详情部分数据失败",
+			logger.String("user_id", userID),
+			logger.String("part", part),
+			logger.Err(err),
+		)
+		mu.Lock()
+		errMap[part] = errors.FromError(err).Message
+		mu.Unlock()
+	}
+
+	wg.Add(3)
+
+	go func() {
+		defer wg.Done()
+		histories, err := s.loginHistoryRepo.ListRecentByUser(ctx, userID, recentLoginLimit)
+		if err != nil {
+			recordErr(detailPartLogins, err)
+			return
+		}
+		logins := make([]model.LoginHistoryResponse, len(histories))
+		for i, h := range histories {
+			logins[i] = model.LoginHistoryResponse{IP: h.IP, LoginAt: h.CreatedAt}
+		}
+		detail.RecentLogins = logins
+	}()
+
+	go func() {
+		defer wg.Done()
+		count, err := s.refreshTokenRepo.CountActiveByUser(ctx, userID)
+		if err != nil {
+			recordErr(detailPartSessions, err)
+			return
+		}
+		detail.ActiveSessions = count
+	}()
+
+	go func() {
+		defer wg.Done()
+		tags, err := s.tagRepo.ListByUser(ctx, userID)
+		if err != nil {
+			recordErr(detailPartTags, err)
+			return
+		}
+		names := make([]string, len(tags))
+		for i, tag := range tags {
+			names[i] = tag.Name
+		}
+		detail.Tags = names
+	}()
+
+	wg.Wait()
+
+	if len(errMap) > 0 {
+		detail.Partial = true
+		detail.Errors = errMap
+	}
+
+	return detail, nil
+}
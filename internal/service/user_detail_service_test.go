@@ -0,0 +1,156 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含用户详情服务的单元测试
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ============================================================
+// Mock 登录历史与标签仓储
+// ============================================================
+
+// MockLoginHistoryRepository 是 LoginHistoryRepository 接口的模拟实现
+type MockLoginHistoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoginHistoryRepository) Create(ctx context.Context, history *model.LoginHistory) error {
+	args := m.Called(ctx, history)
+	return args.Error(0)
+}
+
+func (m *MockLoginHistoryRepository) ListRecentByUser(ctx context.Context, userID string, limit int) ([]model.LoginHistory, error) {
+	args := m.Called(ctx, userID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.LoginHistory), args.Error(1)
+}
+
+// MockUserTagRepository 是 UserTagRepository 接口的模拟实现
+type MockUserTagRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserTagRepository) ListByUser(ctx context.Context, userID string) ([]model.UserTag, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.UserTag), args.Error(1)
+}
+
+func (m *MockUserTagRepository) AddToUsers(ctx context.Context, userIDs []string, name string) (int64, error) {
+	args := m.Called(ctx, userIDs, name)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// ============================================================
+// 用户详情测试
+// ============================================================
+
+func TestUserDetailService_GetDetail_Success(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	mockHistoryRepo := new(MockLoginHistoryRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockTagRepo := new(MockUserTagRepository)
+	detailService := NewUserDetailService(mockRepo, mockHistoryRepo, mockTokenRepo, mockTagRepo, newTestLogger())
+
+	ctx := context.Background()
+	user := newTestUser()
+	loginAt := time.Now().Add(-time.Hour)
+	histories := []model.LoginHistory{
+		{BaseModel: model.BaseModel{CreatedAt: loginAt}, UserID: user.ID, IP: "10.0.0.1"},
+	}
+	tags := []model.UserTag{
+		{UserID: user.ID, Name: "beta"},
+		{UserID: user.ID, Name: "vip"},
+	}
+
+	// 设置 mock 期望
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockHistoryRepo.On("ListRecentByUser", ctx, user.ID, recentLoginLimit).Return(histories, nil)
+	mockTokenRepo.On("CountActiveByUser", ctx, user.ID).Return(int64(2), nil)
+	mockTagRepo.On("ListByUser", ctx, user.ID).Return(tags, nil)
+
+	// 执行
+	detail, err := detailService.GetDetail(ctx, user.ID)
+
+	// 断言
+	assert.NoError(t, err)
+	assert.NotNil(t, detail)
+	assert.Equal(t, user.ID, detail.User.ID)
+	assert.Len(t, detail.RecentLogins, 1)
+	assert.Equal(t, "10.0.0.1", detail.RecentLogins[0].IP)
+	assert.Equal(t, loginAt, detail.RecentLogins[0].LoginAt)
+	assert.Equal(t, int64(2), detail.ActiveSessions)
+	assert.Equal(t, []string{"beta", "vip"}, detail.Tags)
+	assert.False(t, detail.Partial)
+	assert.Empty(t, detail.Errors)
+
+	mockRepo.AssertExpectations(t)
+	mockHistoryRepo.AssertExpectations(t)
+	mockTokenRepo.AssertExpectations(t)
+	mockTagRepo.AssertExpectations(t)
+}
+
+func TestUserDetailService_GetDetail_PartialFailure(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	mockHistoryRepo := new(MockLoginHistoryRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockTagRepo := new(MockUserTagRepository)
+	detailService := NewUserDetailService(mockRepo, mockHistoryRepo, mockTokenRepo, mockTagRepo, newTestLogger())
+
+	ctx := context.Background()
+	user := newTestUser()
+
+	// 设置 mock 期望：登录历史查询失败，其余正常
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockHistoryRepo.On("ListRecentByUser", ctx, user.ID, recentLoginLimit).Return(nil, errors.ErrDatabaseError.WithError(stderrors.New("no such table: login_histories")))
+	mockTokenRepo.On("CountActiveByUser", ctx, user.ID).Return(int64(1), nil)
+	mockTagRepo.On("ListByUser", ctx, user.ID).Return([]model.UserTag{{UserID: user.ID, Name: "vip"}}, nil)
+
+	// 执行
+	detail, err := detailService.GetDetail(ctx, user.ID)
+
+	// 断言：整体成功，失败部分被标记，且不暴露底层错误
+	assert.NoError(t, err)
+	assert.True(t, detail.Partial)
+	assert.Equal(t, errors.ErrDatabaseError.Message, detail.Errors[detailPartLogins])
+	assert.NotContains(t, detail.Errors[detailPartLogins], "login_histories")
+	assert.Empty(t, detail.RecentLogins)
+	assert.Equal(t, int64(1), detail.ActiveSessions)
+	assert.Equal(t, []string{"vip"}, detail.Tags)
+}
+
+func TestUserDetailService_GetDetail_UserNotFound(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	detailService := NewUserDetailService(mockRepo, new(MockLoginHistoryRepository),
+		new(MockRefreshTokenRepository), new(MockUserTagRepository), newTestLogger())
+
+	ctx := context.Background()
+
+	// 设置 mock 期望
+	mockRepo.On("GetByID", ctx, "missing").Return(nil, errors.ErrUserNotFound)
+
+	// 执行
+	detail, err := detailService.GetDetail(ctx, "missing")
+
+	// 断言
+	assert.Error(t, err)
+	assert.Nil(t, detail)
+	assert.Equal(t, errors.ErrUserNotFound, err)
+}
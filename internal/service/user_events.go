@@ -0,0 +1,51 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含用户生命周期事件的定义与发布。
+// 用户被禁用、删除时发布事件，风险报告配额等子系统订阅后异步联动处理。
+package service
+
+import (
+	"context"
+
+	"github.com/example/go-user-api/pkg/eventbus"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+const (
+	// EventUserDisabled 用户被禁用，Payload 为 UserEvent
+	EventUserDisabled = "user.disabled"
+	// EventUserDeleted 用户被删除（软删除或永久删除），Payload 为 UserEvent
+	EventUserDeleted = "user.deleted"
+)
+
+// UserEvent 用户生命周期事件的数据
+type UserEvent struct {
+	// UserID 用户 ID
+	UserID string
+	// Username 用户名，永久删除且事先未查询用户时为空
+	Username string
+	// OperatorID 操作人 ID，非管理员操作时为空
+	OperatorID string
+	// Permanent 是否永久删除，仅 user.deleted 事件有效
+	Permanent bool
+}
+
+// WithEventBus 设置事件总线
+// 设置后用户被禁用、删除时发布 user.disabled、user.deleted 事件
+func WithEventBus(bus eventbus.EventBus) UserServiceOption {
+	return func(s *userService) {
+		s.eventBus = bus
+	}
+}
+
+// publishUserEvent 发布用户事件，未设置事件总线时不发布
+func (s *userService) publishUserEvent(ctx context.Context, name string, payload UserEvent) {
+	if s.eventBus == nil {
+		return
+	}
+	s.eventBus.Publish(ctx, eventbus.Event{Name: name, Payload: payload})
+	s.log.Debug("发布用户事件",
+		logger.String("event", name),
+		logger.String("user_id", payload.UserID),
+	)
+}
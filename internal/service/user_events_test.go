@@ -0,0 +1,111 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含用户生命周期事件发布的单元测试
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/eventbus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newEventTestService 创建带事件总线的用户服务，返回订阅指定事件的通道
+func newEventTestService(t *testing.T, eventName string) (UserService, *MockUserRepository, <-chan eventbus.Event) {
+	t.Helper()
+	bus := eventbus.NewMemoryBus()
+	t.Cleanup(func() { _ = bus.Shutdown(context.Background()) })
+
+	received := make(chan eventbus.Event, 1)
+	bus.Subscribe(eventName, func(_ context.Context, event eventbus.Event) {
+		received <- event
+	})
+
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	svc := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger(), WithEventBus(bus))
+	return svc, mockRepo, received
+}
+
+// waitEvent 等待订阅者收到事件
+func waitEvent(t *testing.T, received <-chan eventbus.Event) eventbus.Event {
+	t.Helper()
+	select {
+	case event := <-received:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("订阅者未收到事件")
+		return eventbus.Event{}
+	}
+}
+
+func TestUserService_AdminUpdate_PublishesUserDisabled(t *testing.T) {
+	// 准备
+	svc, mockRepo, received := newEventTestService(t, EventUserDisabled)
+	ctx := context.Background()
+	user := newTestUser()
+	disabled := model.UserStatusDisabled
+
+	// 设置 mock 期望
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockRepo.On("UpdateFields", ctx, user.ID, map[string]interface{}{"status": disabled}).Return(nil)
+	mockRepo.On("IncrementTokenVersion", ctx, user.ID).Return(nil)
+
+	// 执行
+	_, err := svc.AdminUpdate(ctx, user.ID, &model.AdminUpdateUserRequest{Status: &disabled}, "admin-id")
+
+	// 断言
+	require.NoError(t, err)
+	event := waitEvent(t, received)
+	assert.Equal(t, EventUserDisabled, event.Name)
+	assert.False(t, event.OccurredAt.IsZero())
+	assert.Equal(t, UserEvent{UserID: user.ID, Username: user.Username, OperatorID: "admin-id"}, event.Payload)
+}
+
+func TestUserService_AdminUpdate_AlreadyDisabledNoEvent(t *testing.T) {
+	// 准备
+	svc, mockRepo, received := newEventTestService(t, EventUserDisabled)
+	ctx := context.Background()
+	user := newTestUser()
+	user.Status = model.UserStatusDisabled
+	disabled := model.UserStatusDisabled
+
+	// 设置 mock 期望
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockRepo.On("UpdateFields", ctx, user.ID, mock.Anything).Return(nil)
+
+	// 执行
+	_, err := svc.AdminUpdate(ctx, user.ID, &model.AdminUpdateUserRequest{Status: &disabled}, "admin-id")
+
+	// 断言：状态未变化，不发布事件
+	require.NoError(t, err)
+	select {
+	case event := <-received:
+		t.Fatalf("不应发布事件: %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestUserService_Delete_PublishesUserDeleted(t *testing.T) {
+	// 准备
+	svc, mockRepo, received := newEventTestService(t, EventUserDeleted)
+	ctx := context.Background()
+	user := newTestUser()
+
+	// 设置 mock 期望
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockRepo.On("Delete", ctx, user.ID).Return(nil)
+
+	// 执行
+	err := svc.Delete(ctx, user.ID)
+
+	// 断言
+	require.NoError(t, err)
+	event := waitEvent(t, received)
+	assert.Equal(t, UserEvent{UserID: user.ID, Username: user.Username}, event.Payload)
+}
@@ -0,0 +1,225 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件实现了用户批量导出的后台任务化。
+// 全量导出可能很大，同步接口容易超时，因此提交到后台任务队列生成，
+// 生成的文件流式写入导出目录并保留一段时间，管理员凭任务 ID 下载。
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/jobqueue"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+// JobTypeUserExport 用户批量导出
+const JobTypeUserExport = "user_export"
+
+// 导出任务结果中记录的字段
+const (
+	// userExportResultFormat 导出文件格式
+	userExportResultFormat = "format"
+	// userExportResultPath 导出文件相对导出目录的路径
+	userExportResultPath = "path"
+)
+
+// UserExporter 按过滤条件导出用户，由 UserService 实现
+type UserExporter interface {
+	// ExportUsers 按过滤条件导出用户到 w，返回导出的用户数
+	ExportUsers(ctx context.Context, req *model.UserExportRequest, w io.Writer) (int, error)
+}
+
+// ExportFile 已生成的导出文件
+type ExportFile struct {
+	// Filename 下载时使用的文件名
+	Filename string
+	// ContentType 文件的 Content-Type
+	ContentType string
+	// Path 文件在本地的完整路径
+	Path string
+}
+
+// UserExportJobService 用户批量导出任务服务接口
+type UserExportJobService interface {
+	// Submit 校验参数后提交导出任务
+	Submit(ctx context.Context, req *model.UserExportRequest) (*jobqueue.Job, error)
+	// Download 获取导出任务的文件
+	// 任务未完成时文件为 nil，返回任务状态；任务失败或文件已过期时返回错误
+	Download(ctx context.Context, id string) (*ExportFile, *jobqueue.Job, error)
+}
+
+// userExportJobService 用户批量导出任务服务实现
+type userExportJobService struct {
+	exporter UserExporter
+	queue    jobqueue.Queue
+	dir      string
+	ttl      time.Duration
+	log      logger.Logger
+	now      func() time.Time
+}
+
+// NewUserExportJobService 创建用户批量导出任务服务实例
+// 生成的文件写入 dir 并保留 ttl，dir 在首次生成时创建；
+// dir 包含用户数据，不能挂载到静态文件服务。文件在本地磁盘上，下载请求需路由到提交任务的实例
+func NewUserExportJobService(exporter UserExporter, queue jobqueue.Queue, dir string, ttl time.Duration, log logger.Logger) UserExportJobService {
+	return &userExportJobService{
+		exporter: exporter,
+		queue:    queue,
+		dir:      dir,
+		ttl:      ttl,
+		log:      log.With(logger.String("service", "user_export")),
+		now:      time.Now,
+	}
+}
+
+// Submit 校验参数后提交导出任务
+// 格式与导出列在提交时校验，避免任务执行后才失败；任务不受当前请求上下文的取消影响
+func (s *userExportJobService) Submit(ctx context.Context, req *model.UserExportRequest) (*jobqueue.Job, error) {
+	params := *req
+	if params.Format == "" {
+		params.Format = FormatCSV
+	}
+	if !IsSupportedFormat(params.Format) {
+		return nil, errors.ErrValidation.WithDetail("不支持的导出格式: " + params.Format)
+	}
+	if _, err := parseUserExportColumns(params.Columns); err != nil {
+		return nil, err
+	}
+
+	s.pruneExpired()
+
+	// 文件以任务 ID 命名，任务可能在 Submit 返回前就开始执行，因此通过 channel 传入 ID
+	submitted := make(chan string, 1)
+	job, err := s.queue.Submit(JobTypeUserExport, func(ctx context.Context, progress jobqueue.ProgressFunc) error {
+		return s.generate(ctx, <-submitted, &params, progress)
+	})
+	if err != nil {
+		return nil, mapQueueError(err)
+	}
+	submitted <- job.ID
+
+	s.log.Info("用户导出任务已提交",
+		logger.String("job_id", job.ID),
+		logger.String("format", params.Format),
+	)
+	return job, nil
+}
+
+// generate 生成导出文件并在任务结果中记录格式与路径
+// 先写入临时文件再重命名，下载方不会读到写了一半的文件
+func (s *userExportJobService) generate(ctx context.Context, jobID string, req *model.UserExportRequest, progress jobqueue.ProgressFunc) error {
+	name := jobID + "." + req.Format
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		s.log.Error("创建导出目录失败", logger.String("dir", s.dir), logger.Err(err))
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".export-*")
+	if err != nil {
+		s.log.Error("创建用户导出文件失败", logger.String("job_id", jobID), logger.Err(err))
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	count, err := s.exporter.ExportUsers(ctx, req, tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		s.log.Error("用户导出任务失败", logger.String("job_id", jobID), logger.Err(err))
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, name)); err != nil {
+		s.log.Error("保存用户导出文件失败", logger.String("job_id", jobID), logger.Err(err))
+		return err
+	}
+
+	s.queue.SetResult(jobID, map[string]string{
+		userExportResultFormat: req.Format,
+		userExportResultPath:   name,
+	})
+	progress(count, count)
+
+	s.log.Info("用户导出文件已生成",
+		logger.String("job_id", jobID),
+		logger.Int("count", count),
+	)
+	return nil
+}
+
+// Download 获取导出任务的文件
+// 文件格式与路径取自任务结果；超过保留时间的文件视为已过期并删除
+func (s *userExportJobService) Download(ctx context.Context, id string) (*ExportFile, *jobqueue.Job, error) {
+	job, ok := s.queue.Get(id)
+	if !ok || job.Type != JobTypeUserExport {
+		return nil, nil, errors.ErrResourceNotFound.WithMessage("导出任务不存在")
+	}
+
+	switch job.Status {
+	case jobqueue.StatusPending, jobqueue.StatusRunning:
+		return nil, job, nil
+	case jobqueue.StatusFailed:
+		return nil, job, errors.ErrConflict.WithMessage("导出任务执行失败，请重新提交").WithDetail(job.Error)
+	}
+
+	expired := errors.ErrResourceNotFound.WithMessage("导出文件已过期，请重新提交")
+	format := job.Result[userExportResultFormat]
+	name := job.Result[userExportResultPath]
+	if name == "" || name != filepath.Base(name) {
+		return nil, job, expired
+	}
+	path := filepath.Join(s.dir, name)
+	if job.FinishedAt != nil && s.now().Sub(*job.FinishedAt) > s.ttl {
+		s.remove(path)
+		return nil, job, expired
+	}
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, job, expired
+		}
+		return nil, job, errors.ErrInternalServer.WithError(err)
+	}
+
+	contentType := ContentTypeCSV
+	if format == FormatXLSX {
+		contentType = ContentTypeXLSX
+	}
+	return &ExportFile{
+		Filename:    fmt.Sprintf("users_%s.%s", job.CreatedAt.Format("20060102150405"), format),
+		ContentType: contentType,
+		Path:        path,
+	}, job, nil
+}
+
+// pruneExpired 删除导出目录中超过保留时间的文件（含生成失败残留的临时文件）
+// 在提交新任务时顺带执行，失败只记录日志
+func (s *userExportJobService) pruneExpired() {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.log.Warn("读取导出目录失败", logger.String("dir", s.dir), logger.Err(err))
+		}
+		return
+	}
+	cutoff := s.now().Add(-s.ttl)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || info.ModTime().After(cutoff) {
+			continue
+		}
+		s.remove(filepath.Join(s.dir, entry.Name()))
+	}
+}
+
+// remove 删除导出文件，失败只记录日志
+func (s *userExportJobService) remove(path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		s.log.Warn("删除过期导出文件失败", logger.String("path", path), logger.Err(err))
+	}
+}
@@ -0,0 +1,175 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含用户批量导出任务的单元测试
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/jobqueue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUserExporter 返回固定内容的导出实现
+// release 不为 nil 时阻塞到 release 关闭，用于观察未完成的任务
+type fakeUserExporter struct {
+	content string
+	err     error
+	release chan struct{}
+}
+
+func (e *fakeUserExporter) ExportUsers(ctx context.Context, req *model.UserExportRequest, w io.Writer) (int, error) {
+	if e.release != nil {
+		<-e.release
+	}
+	if e.err != nil {
+		return 0, e.err
+	}
+	_, err := io.WriteString(w, e.content)
+	return 2, err
+}
+
+// newUserExportJobTestService 创建使用内存队列与临时导出目录的导出任务服务
+func newUserExportJobTestService(t *testing.T, exporter UserExporter) UserExportJobService {
+	t.Helper()
+	queue := jobqueue.NewMemoryQueue(1, 10)
+	queue.Start()
+	t.Cleanup(func() { _ = queue.Shutdown(context.Background()) })
+	return NewUserExportJobService(exporter, queue, t.TempDir(), time.Hour, newTestLogger())
+}
+
+// readExportFile 读取导出文件内容
+func readExportFile(t *testing.T, file *ExportFile) string {
+	t.Helper()
+	data, err := os.ReadFile(file.Path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// waitUserExportFile 等待导出任务完成并返回文件
+func waitUserExportFile(t *testing.T, svc UserExportJobService, id string) *ExportFile {
+	t.Helper()
+	var file *ExportFile
+	require.Eventually(t, func() bool {
+		f, _, err := svc.Download(context.Background(), id)
+		require.NoError(t, err)
+		file = f
+		return f != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	return file
+}
+
+func TestUserExportJobService_SubmitThenDownload(t *testing.T) {
+	// 准备
+	exporter := &fakeUserExporter{content: "username\nalice\nbob\n", release: make(chan struct{})}
+	svc := newUserExportJobTestService(t, exporter)
+	ctx := context.Background()
+
+	// 执行：提交任务
+	job, err := svc.Submit(ctx, &model.UserExportRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, JobTypeUserExport, job.Type)
+
+	// 断言：生成完成前返回任务状态
+	file, pending, err := svc.Download(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Nil(t, file)
+	assert.False(t, pending.Finished())
+
+	// 断言：生成完成后可下载文件，进度为导出的用户数
+	close(exporter.release)
+	file = waitUserExportFile(t, svc, job.ID)
+	assert.Equal(t, "username\nalice\nbob\n", readExportFile(t, file))
+	assert.Equal(t, ContentTypeCSV, file.ContentType)
+	assert.Regexp(t, `^users_\d{14}\.csv$`, file.Filename)
+
+	_, done, err := svc.Download(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, jobqueue.StatusDone, done.Status)
+	assert.Equal(t, 2, done.Processed)
+	assert.Equal(t, jobqueue.Result{"format": FormatCSV, "path": job.ID + ".csv"}, done.Result)
+}
+
+func TestUserExportJobService_Download_ExpiredFile(t *testing.T) {
+	// 准备：文件生成后超过保留时间
+	svc := newUserExportJobTestService(t, &fakeUserExporter{content: "username\n"})
+	ctx := context.Background()
+	job, err := svc.Submit(ctx, &model.UserExportRequest{})
+	require.NoError(t, err)
+	file := waitUserExportFile(t, svc, job.ID)
+
+	impl := svc.(*userExportJobService)
+	impl.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	// 执行
+	_, _, err = svc.Download(ctx, job.ID)
+
+	// 断言：返回已过期并删除文件
+	assert.True(t, errors.Is(err, errors.ErrResourceNotFound))
+	_, statErr := os.Stat(file.Path)
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func TestUserExportJobService_XLSXContentType(t *testing.T) {
+	svc := newUserExportJobTestService(t, &fakeUserExporter{content: "PK"})
+
+	job, err := svc.Submit(context.Background(), &model.UserExportRequest{Format: FormatXLSX})
+	require.NoError(t, err)
+
+	file := waitUserExportFile(t, svc, job.ID)
+	assert.Equal(t, ContentTypeXLSX, file.ContentType)
+	assert.Regexp(t, `\.xlsx$`, file.Filename)
+}
+
+func TestUserExportJobService_Submit_InvalidParams(t *testing.T) {
+	svc := newUserExportJobTestService(t, &fakeUserExporter{})
+
+	tests := []struct {
+		name string
+		req  model.UserExportRequest
+	}{
+		{"不支持的格式", model.UserExportRequest{Format: "pdf"}},
+		{"不允许的导出列", model.UserExportRequest{Columns: "id,password"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Submit(context.Background(), &tt.req)
+
+			require.Error(t, err)
+			assert.Equal(t, http.StatusBadRequest, errors.AsAppError(err).HTTPStatus)
+		})
+	}
+}
+
+func TestUserExportJobService_Download_FailedJob(t *testing.T) {
+	// 准备
+	svc := newUserExportJobTestService(t, &fakeUserExporter{err: stderrors.New("db down")})
+	ctx := context.Background()
+	job, err := svc.Submit(ctx, &model.UserExportRequest{})
+	require.NoError(t, err)
+
+	// 断言：任务失败后返回冲突错误
+	require.Eventually(t, func() bool {
+		_, _, err := svc.Download(ctx, job.ID)
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	_, _, err = svc.Download(ctx, job.ID)
+	assert.True(t, errors.Is(err, errors.ErrConflict))
+}
+
+func TestUserExportJobService_Download_UnknownJob(t *testing.T) {
+	svc := newUserExportJobTestService(t, &fakeUserExporter{})
+
+	_, _, err := svc.Download(context.Background(), "missing")
+
+	assert.True(t, errors.Is(err, errors.ErrResourceNotFound))
+}
@@ -0,0 +1,429 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件实现用户的批量导入与导出，支持 CSV 与 xlsx 两种表格格式。
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/repository"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+const (
+	// userExportBatchSize 导出时每批读取的用户数
+	userExportBatchSize = 500
+	// maxImportRows 单次导入允许的最大数据行数
+	maxImportRows = 1000
+)
+
+// userExportHeader 用户导出的表头，未指定导出列时按此顺序导出全部列
+var userExportHeader = []string{
+	"id", "username", "email", "nickname", "phone",
+	"role", "status", "created_at", "last_login_at",
+}
+
+// userExportColumns 导出列白名单及取值函数
+var userExportColumns = map[string]func(u *model.User) string{
+	"id":         func(u *model.User) string { return u.ID },
+	"username":   func(u *model.User) string { return u.Username },
+	"email":      func(u *model.User) string { return u.Email },
+	"nickname":   func(u *model.User) string { return u.Nickname },
+	"phone":      func(u *model.User) string { return u.Phone },
+	"role":       func(u *model.User) string { return u.Role },
+	"status":     func(u *model.User) string { return strconv.Itoa(int(u.Status)) },
+	"created_at": func(u *model.User) string { return u.CreatedAt.Format(time.RFC3339) },
+	"last_login_at": func(u *model.User) string {
+		if u.LastLoginAt == nil {
+			return ""
+		}
+		return u.LastLoginAt.Format(time.RFC3339)
+	},
+}
+
+// parseUserExportColumns 解析逗号分隔的导出列
+// 为空时返回全部列；列名不区分大小写，重复列只保留第一次出现的位置，
+// 不在白名单中的列返回验证错误
+func parseUserExportColumns(spec string) ([]string, error) {
+	if strings.TrimSpace(spec) == "" {
+		return userExportHeader, nil
+	}
+
+	var columns, invalid []string
+	seen := make(map[string]bool)
+	for _, col := range strings.Split(spec, ",") {
+		col = strings.ToLower(strings.TrimSpace(col))
+		if col == "" || seen[col] {
+			continue
+		}
+		seen[col] = true
+		if _, ok := userExportColumns[col]; !ok {
+			invalid = append(invalid, col)
+			continue
+		}
+		columns = append(columns, col)
+	}
+
+	if len(invalid) > 0 {
+		return nil, errors.ErrValidation.WithDetail(fmt.Sprintf("不支持的导出列: %s，可选: %s",
+			strings.Join(invalid, ", "), strings.Join(userExportHeader, ", ")))
+	}
+	if len(columns) == 0 {
+		return userExportHeader, nil
+	}
+	return columns, nil
+}
+
+// 用户导入支持的列名，列顺序不限，未知列会被忽略
+const (
+	importColUsername = "username"
+	importColEmail    = "email"
+	importColPassword = "password"
+	importColNickname = "nickname"
+	importColPhone    = "phone"
+	importColRole     = "role"
+	importColStatus   = "status"
+)
+
+// ExportUsers 按过滤条件导出用户
+// 分批读取用户并逐批写出；CSV 每批写完立即刷新，xlsx 在结束时一次性输出
+func (s *userService) ExportUsers(ctx context.Context, req *model.UserExportRequest, w io.Writer) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	format := req.Format
+	if format == "" {
+		format = FormatCSV
+	}
+	if !IsSupportedFormat(format) {
+		return 0, errors.ErrValidation.WithDetail("不支持的导出格式: " + format)
+	}
+
+	columns, err := parseUserExportColumns(req.Columns)
+	if err != nil {
+		return 0, err
+	}
+
+	tw, err := newTableWriter(format, w, columns)
+	if err != nil {
+		return 0, errors.ErrInternalServer.WithError(err)
+	}
+
+	opts := &repository.UserListOptions{
+		Username: req.Username,
+		Email:    req.Email,
+		Status:   req.Status,
+		Role:     req.Role,
+	}
+
+	count := 0
+	err = s.userRepo.FindInBatches(ctx, opts, userExportBatchSize, func(batch []model.User) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for i := range batch {
+			if err := tw.WriteRow(userExportRecord(&batch[i], columns)); err != nil {
+				return err
+			}
+		}
+		count += len(batch)
+		return tw.Flush()
+	})
+	if err != nil {
+		s.log.Error("导出用户失败",
+			logger.String("format", format),
+			logger.Int("exported", count),
+			logger.Err(err),
+		)
+		return count, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return count, err
+	}
+
+	s.log.Info("导出用户完成",
+		logger.String("format", format),
+		logger.Int("count", count),
+	)
+	return count, nil
+}
+
+// userExportRecord 将用户转换为导出行，列顺序与 columns 一致
+func userExportRecord(u *model.User, columns []string) []string {
+	record := make([]string, len(columns))
+	for i, col := range columns {
+		record[i] = userExportColumns[col](u)
+	}
+	return record
+}
+
+// BatchProgressFunc 批量操作的进度回调，在执行批量操作的 goroutine 中同步调用
+type BatchProgressFunc func(progress model.BatchProgress)
+
+// ImportUsers 从表格导入用户
+// 第一行为表头，必须包含 username 和 password 列；
+// 每行独立校验与创建，失败的行记录在结果中，不影响其他行。
+// 文件校验失败时直接返回错误，不会回调 progress
+func (s *userService) ImportUsers(ctx context.Context, format string, r io.Reader, progress BatchProgressFunc) (*model.UserImportResult, error) {
+	if !IsSupportedFormat(format) {
+		return nil, errors.ErrValidation.WithDetail("不支持的导入格式: " + format)
+	}
+
+	rows, err := readTable(format, r)
+	if err != nil {
+		return nil, errors.ErrValidation.WithDetail("无法解析导入文件: " + err.Error())
+	}
+	if len(rows) == 0 {
+		return nil, errors.ErrValidation.WithDetail("导入文件为空")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{importColUsername, importColPassword} {
+		if _, ok := columns[required]; !ok {
+			return nil, errors.ErrValidation.WithDetail("导入文件缺少必需的列: " + required)
+		}
+	}
+	if len(rows)-1 > maxImportRows {
+		return nil, errors.ErrValidation.WithDetail(fmt.Sprintf("单次最多导入 %d 行", maxImportRows))
+	}
+
+	total := 0
+	for _, cells := range rows[1:] {
+		if !isBlankRow(cells) {
+			total++
+		}
+	}
+
+	result := &model.UserImportResult{Errors: []model.UserImportError{}}
+	// 记录文件内已出现的用户名与邮箱，拒绝文件内部重复
+	seenUsernames := make(map[string]bool)
+	seenEmails := make(map[string]bool)
+
+	// 导入中途取消时已创建的用户同样需要让列表缓存失效
+	defer func() {
+		if result.Created > 0 {
+			s.invalidateUserListCache(ctx)
+		}
+	}()
+
+	for i, cells := range rows[1:] {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if isBlankRow(cells) {
+			continue
+		}
+
+		result.Total++
+		row := parseImportRow(columns, cells)
+		var rowErr *model.UserImportError
+		if msg := s.importRow(ctx, &row, seenUsernames, seenEmails); msg != "" {
+			rowErr = &model.UserImportError{
+				Row:      i + 2, // 表头为第 1 行
+				Username: row.Username,
+				Message:  msg,
+			}
+			result.Failed++
+			result.Errors = append(result.Errors, *rowErr)
+		} else {
+			result.Created++
+		}
+
+		if progress != nil {
+			progress(model.BatchProgress{
+				Processed: result.Total,
+				Total:     total,
+				Succeeded: result.Created,
+				Failed:    result.Failed,
+				Error:     rowErr,
+			})
+		}
+	}
+
+	s.log.Info("导入用户完成",
+		logger.String("format", format),
+		logger.Int("total", result.Total),
+		logger.Int("created", result.Created),
+		logger.Int("failed", result.Failed),
+	)
+	return result, nil
+}
+
+// userImportRow 导入文件中的一行用户数据
+type userImportRow struct {
+	Username string
+	Email    string
+	Password string
+	Nickname string
+	Phone    string
+	Role     string
+	Status   string
+}
+
+// importRow 校验并创建一行用户，失败时返回面向用户的错误原因
+// seenUsernames、seenEmails 记录文件内已出现的用户名与邮箱（小写）
+func (s *userService) importRow(ctx context.Context, row *userImportRow, seenUsernames, seenEmails map[string]bool) string {
+	username, email := row.Username, row.Email
+
+	user, msg := s.buildImportUser(row)
+	if msg != "" {
+		return msg
+	}
+
+	lowerUsername := strings.ToLower(username)
+	if seenUsernames[lowerUsername] {
+		return "文件内用户名重复"
+	}
+	lowerEmail := strings.ToLower(email)
+	if email != "" && seenEmails[lowerEmail] {
+		return "文件内邮箱重复"
+	}
+	seenUsernames[lowerUsername] = true
+	if email != "" {
+		seenEmails[lowerEmail] = true
+	}
+
+	if msg := s.checkImportConflicts(ctx, username, email); msg != "" {
+		return msg
+	}
+
+	// 所有校验通过后再加密密码，避免为失败行做无谓的哈希计算
+	hashedPassword, err := s.hashPassword(row.Password)
+	if err != nil {
+		s.log.Error("加密密码失败", logger.Err(err))
+		return "密码加密失败"
+	}
+	user.Password = hashedPassword
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		if appErr := errors.AsAppError(err); appErr != nil {
+			return appErr.Message
+		}
+		s.log.Error("导入用户失败", logger.String("username", username), logger.Err(err))
+		return "创建用户失败"
+	}
+	return ""
+}
+
+// parseImportRow 按表头列位置解析一行，缺失的列按空值处理
+func parseImportRow(columns map[string]int, cells []string) userImportRow {
+	cell := func(name string) string {
+		idx, ok := columns[name]
+		if !ok || idx >= len(cells) {
+			return ""
+		}
+		return strings.TrimSpace(cells[idx])
+	}
+	return userImportRow{
+		Username: cell(importColUsername),
+		Email:    cell(importColEmail),
+		Password: cell(importColPassword),
+		Nickname: cell(importColNickname),
+		Phone:    cell(importColPhone),
+		Role:     cell(importColRole),
+		Status:   cell(importColStatus),
+	}
+}
+
+// buildImportUser 校验导入行并构建用户对象（不含密码哈希）
+// 校验失败时返回面向用户的错误原因
+func (s *userService) buildImportUser(row *userImportRow) (*model.User, string) {
+	role := row.Role
+	nickname := row.Nickname
+
+	// 用户名规则与注册接口一致
+	if err := checkUsername(row.Username, s.config.Security.Username); err != nil {
+		return nil, errors.FromError(err).Message
+	}
+	if len(row.Password) < 6 || len(row.Password) > 50 {
+		return nil, "密码长度必须为 6-50 个字符"
+	}
+	if row.Email == "" {
+		if s.config.Security.RequireEmail {
+			return nil, "邮箱不能为空"
+		}
+	} else if _, err := mail.ParseAddress(row.Email); err != nil || len(row.Email) > 100 {
+		return nil, "邮箱格式无效"
+	}
+	if len([]rune(nickname)) > 50 {
+		return nil, "昵称不能超过 50 个字符"
+	}
+	if len(row.Phone) > 20 {
+		return nil, "手机号不能超过 20 个字符"
+	}
+
+	switch role {
+	case "":
+		role = model.RoleUser
+	case model.RoleUser, model.RoleAdmin:
+	default:
+		return nil, "角色必须是 user 或 admin"
+	}
+
+	userStatus := model.UserStatusActive
+	if row.Status != "" {
+		v, err := strconv.Atoi(row.Status)
+		if err != nil || v < int(model.UserStatusDisabled) || v > int(model.UserStatusInactive) {
+			return nil, "状态必须是 0、1 或 2"
+		}
+		userStatus = int8(v)
+	}
+
+	if nickname == "" {
+		nickname = row.Username
+	}
+
+	return &model.User{
+		Username: row.Username,
+		Email:    row.Email,
+		Nickname: nickname,
+		Phone:    row.Phone,
+		Role:     role,
+		Status:   userStatus,
+	}, ""
+}
+
+// checkImportConflicts 检查用户名和邮箱是否已被占用
+func (s *userService) checkImportConflicts(ctx context.Context, username, email string) string {
+	exists, err := s.userRepo.ExistsByUsername(ctx, username)
+	if err != nil {
+		return "检查用户名失败"
+	}
+	if exists {
+		return errors.ErrUsernameExists.Message
+	}
+
+	if email != "" {
+		exists, err = s.userRepo.ExistsByEmail(ctx, email)
+		if err != nil {
+			return "检查邮箱失败"
+		}
+		if exists {
+			return errors.ErrEmailAlreadyUsed.Message
+		}
+	}
+	return ""
+}
+
+// isBlankRow 判断是否为空行
+func isBlankRow(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,285 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含用户导入导出的单元测试
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/repository"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+// newExportTestUsers 创建用于导出测试的用户
+func newExportTestUsers() []model.User {
+	createdAt := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	lastLogin := createdAt.Add(time.Hour)
+
+	alice := model.User{Username: "alice", Email: "alice@example.com", Nickname: "Alice",
+		Role: model.RoleAdmin, Status: model.UserStatusActive, LastLoginAt: &lastLogin}
+	alice.ID = "user-1"
+	alice.CreatedAt = createdAt
+
+	bob := model.User{Username: "bob", Nickname: "Bob", Phone: "13800000000",
+		Role: model.RoleUser, Status: model.UserStatusDisabled}
+	bob.ID = "user-2"
+	bob.CreatedAt = createdAt
+
+	return []model.User{alice, bob}
+}
+
+// ============================================================
+// 导出测试
+// ============================================================
+
+func TestUserService_ExportUsers_XLSX(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	ctx := context.Background()
+	users := newExportTestUsers()
+
+	// 设置 mock 期望：两个批次
+	mockRepo.On("FindInBatches", ctx, mock.Anything, userExportBatchSize).
+		Return([][]model.User{users[:1], users[1:]}, nil)
+
+	// 执行
+	var buf bytes.Buffer
+	count, err := userService.ExportUsers(ctx, &model.UserExportRequest{Format: FormatXLSX}, &buf)
+
+	// 断言：生成的文件可被重新解析，行列与导出数据一致
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	file, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	defer file.Close()
+
+	rows, err := file.GetRows(file.GetSheetName(0))
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, userExportHeader, rows[0])
+	assert.Equal(t, []string{"user-1", "alice", "alice@example.com", "Alice", "", "admin", "1",
+		"2024-03-01T08:00:00Z", "2024-03-01T09:00:00Z"}, rows[1])
+	// 末尾的空单元格不会被 GetRows 返回
+	assert.Equal(t, []string{"user-2", "bob", "", "Bob", "13800000000", "user", "0",
+		"2024-03-01T08:00:00Z"}, rows[2])
+
+	// 表头带有加粗样式
+	styleID, err := file.GetCellStyle(file.GetSheetName(0), "A1")
+	require.NoError(t, err)
+	style, err := file.GetStyle(styleID)
+	require.NoError(t, err)
+	require.NotNil(t, style.Font)
+	assert.True(t, style.Font.Bold)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_ExportUsers_DefaultCSV(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	ctx := context.Background()
+	status := model.UserStatusActive
+
+	// 设置 mock 期望：过滤条件传递到仓储
+	mockRepo.On("FindInBatches", ctx, mock.MatchedBy(func(o *repository.UserListOptions) bool {
+		return o.Role == model.RoleAdmin && o.Status != nil && *o.Status == status
+	}), userExportBatchSize).Return([][]model.User{newExportTestUsers()[:1]}, nil)
+
+	// 执行
+	var buf bytes.Buffer
+	count, err := userService.ExportUsers(ctx, &model.UserExportRequest{Role: model.RoleAdmin, Status: &status}, &buf)
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, userExportHeader, records[0])
+	assert.Equal(t, "alice", records[1][1])
+}
+
+func TestUserService_ExportUsers_SelectedColumns(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	ctx := context.Background()
+
+	// 设置 mock 期望
+	mockRepo.On("FindInBatches", ctx, mock.Anything, userExportBatchSize).
+		Return([][]model.User{newExportTestUsers()}, nil)
+
+	// 执行：列顺序按参数顺序，大小写与空白不敏感，重复列只导出一次
+	var buf bytes.Buffer
+	count, err := userService.ExportUsers(ctx, &model.UserExportRequest{Columns: "email, ID,username,email"}, &buf)
+
+	// 断言：表头与内容只含所选列
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"email", "id", "username"},
+		{"alice@example.com", "user-1", "alice"},
+		{"", "user-2", "bob"},
+	}, records)
+}
+
+func TestUserService_ExportUsers_EscapesFormulas(t *testing.T) {
+	// 准备：昵称以公式字符开头，手机号带国际区号
+	users := newExportTestUsers()[:1]
+	users[0].Nickname = "=cmd|' /C calc'!A0"
+	users[0].Phone = "+8613800000000"
+
+	for _, format := range []string{FormatCSV, FormatXLSX} {
+		t.Run(format, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			cfg := newTestConfig()
+			userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+			ctx := context.Background()
+			mockRepo.On("FindInBatches", ctx, mock.Anything, userExportBatchSize).
+				Return([][]model.User{users}, nil)
+
+			// 执行
+			var buf bytes.Buffer
+			_, err := userService.ExportUsers(ctx, &model.UserExportRequest{Format: format, Columns: "nickname,phone"}, &buf)
+			require.NoError(t, err)
+
+			// 断言：公式前加单引号，数值保持原样
+			rows, err := readTable(format, &buf)
+			require.NoError(t, err)
+			require.Len(t, rows, 2)
+			assert.Equal(t, []string{"'=cmd|' /C calc'!A0", "+8613800000000"}, rows[1])
+		})
+	}
+}
+
+func TestUserService_ExportUsers_InvalidColumn(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	// 执行：password 不在白名单中
+	var buf bytes.Buffer
+	_, err := userService.ExportUsers(context.Background(), &model.UserExportRequest{Columns: "id,password"}, &buf)
+
+	// 断言：返回验证错误且未写出任何内容
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrValidation))
+	assert.Contains(t, err.(*errors.AppError).Detail, "password")
+	assert.Zero(t, buf.Len())
+	mockRepo.AssertNotCalled(t, "FindInBatches", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// ============================================================
+// 导入测试
+// ============================================================
+
+// buildXLSX 使用导出同样的写入器生成 xlsx 文件
+func buildXLSX(t *testing.T, header []string, rows [][]string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	tw, err := newTableWriter(FormatXLSX, &buf, header)
+	require.NoError(t, err)
+	for _, row := range rows {
+		require.NoError(t, tw.WriteRow(row))
+	}
+	require.NoError(t, tw.Close())
+	return &buf
+}
+
+func TestUserService_ImportUsers_XLSX(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	ctx := context.Background()
+	file := buildXLSX(t,
+		[]string{"Username", "Email", "Password", "Role"},
+		[][]string{
+			{"carol", "carol@example.com", "password123", "admin"},
+			{"", "", "", ""},                                   // 空行被跳过
+			{"dave", "dave@example.com", "123", ""},            // 密码过短
+			{"carol", "carol2@example.com", "password123", ""}, // 文件内重复
+			{"erin", "erin@example.com", "password123", ""},    // 用户名已存在
+		},
+	)
+
+	// 设置 mock 期望
+	mockRepo.On("ExistsByUsername", ctx, "carol").Return(false, nil)
+	mockRepo.On("ExistsByEmail", ctx, "carol@example.com").Return(false, nil)
+	mockRepo.On("ExistsByUsername", ctx, "erin").Return(true, nil)
+	mockRepo.On("Create", ctx, mock.MatchedBy(func(u *model.User) bool {
+		return u.Username == "carol" && u.Role == model.RoleAdmin && u.Password != "password123" &&
+			u.Nickname == "carol" && u.Status == model.UserStatusActive
+	})).Return(nil)
+
+	// 执行
+	var progress []model.BatchProgress
+	result, err := userService.ImportUsers(ctx, FormatXLSX, file, func(p model.BatchProgress) {
+		progress = append(progress, p)
+	})
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Total)
+	assert.Equal(t, 1, result.Created)
+	assert.Equal(t, 3, result.Failed)
+	require.Len(t, result.Errors, 3)
+	assert.Equal(t, 4, result.Errors[0].Row)
+	assert.Equal(t, "dave", result.Errors[0].Username)
+	assert.Equal(t, 5, result.Errors[1].Row)
+	assert.Equal(t, 6, result.Errors[2].Row)
+	assert.Equal(t, errors.ErrUsernameExists.Message, result.Errors[2].Message)
+
+	// 每个非空行回调一次进度，失败行附带错误明细
+	require.Len(t, progress, 4)
+	for i, p := range progress {
+		assert.Equal(t, i+1, p.Processed)
+		assert.Equal(t, 4, p.Total)
+	}
+	assert.Nil(t, progress[0].Error)
+	require.NotNil(t, progress[1].Error)
+	assert.Equal(t, "dave", progress[1].Error.Username)
+	assert.Equal(t, 1, progress[3].Succeeded)
+	assert.Equal(t, 3, progress[3].Failed)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_ImportUsers_MissingRequiredColumn(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	// 执行：CSV 缺少 password 列
+	result, err := userService.ImportUsers(context.Background(), FormatCSV,
+		bytes.NewBufferString("username,email\nfrank,frank@example.com\n"), nil)
+
+	// 断言
+	assert.Nil(t, result)
+	require.Error(t, err)
+	assert.Equal(t, errors.CodeValidation, errors.AsAppError(err).Code)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,153 @@
+// Package service 提供业务逻辑层的实现
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/repository"
+	"github.com/example/go-user-api/pkg/cache"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+// userListCachePrefix 用户列表缓存键前缀，写操作时按前缀整体失效
+const userListCachePrefix = "users:list:"
+
+// cachedUserList 缓存的用户列表结果
+// 只序列化 model.User 的 JSON 字段，密码哈希、令牌版本等 json:"-" 字段不会进入缓存
+type cachedUserList struct {
+	Users []model.User `json:"users"`
+	Total int64        `json:"total"`
+}
+
+// WithUserListCache 设置用户列表缓存
+// 相同查询参数在 ttl 内直接返回缓存结果；用户写操作会清空全部列表缓存。
+// 最后登录时间等登录时更新的字段不触发失效，最多延迟一个 ttl
+func WithUserListCache(c cache.Cache, ttl time.Duration) UserServiceOption {
+	return func(s *userService) {
+		if c == nil || ttl <= 0 {
+			return
+		}
+		s.listCache = c
+		s.listCacheTTL = ttl
+	}
+}
+
+// WithJobUserListCache 设置与 UserService 共用的用户列表缓存
+// 列表结果包含用户标签，批量打标结束后清空，否则新标签要等缓存过期才可见
+func WithJobUserListCache(c cache.Cache) JobServiceOption {
+	return func(s *jobService) {
+		s.listCache = c
+	}
+}
+
+// userListCacheKey 由规范化后的查询参数生成缓存键
+// 参数按名称排序、排序字段统一小写、预加载列表排序去重，保证等价查询得到相同的键
+func userListCacheKey(opts *repository.UserListOptions) string {
+	values := url.Values{}
+	values.Set("page", strconv.Itoa(opts.Page))
+	values.Set("page_size", strconv.Itoa(opts.PageSize))
+	if opts.Username != "" {
+		values.Set("username", opts.Username)
+	}
+	if opts.Email != "" {
+		values.Set("email", opts.Email)
+	}
+	if opts.Status != nil {
+		values.Set("status", strconv.Itoa(int(*opts.Status)))
+	}
+	if opts.Role != "" {
+		values.Set("role", opts.Role)
+	}
+	if opts.Source != "" {
+		values.Set("source", opts.Source)
+	}
+	if opts.LastLoginBefore != nil {
+		values.Set("last_login_before", opts.LastLoginBefore.UTC().Format(time.RFC3339Nano))
+	}
+	if opts.LastLoginAfter != nil {
+		values.Set("last_login_after", opts.LastLoginAfter.UTC().Format(time.RFC3339Nano))
+	}
+	if opts.SortBy != "" {
+		values.Set("sort_by", strings.ToLower(opts.SortBy))
+	}
+	if opts.SortOrder != "" {
+		values.Set("sort_order", strings.ToLower(opts.SortOrder))
+	}
+	if opts.SkipTotal {
+		values.Set("skip_total", "true")
+	}
+	if len(opts.Preloads) > 0 {
+		preloads := append([]string(nil), opts.Preloads...)
+		sort.Strings(preloads)
+		seen := make(map[string]bool, len(preloads))
+		for _, p := range preloads {
+			if !seen[p] {
+				seen[p] = true
+				values.Add("preload", p)
+			}
+		}
+	}
+	return userListCachePrefix + values.Encode()
+}
+
+// getCachedUserList 读取列表缓存，未启用、未命中或数据损坏时 ok 为 false
+func (s *userService) getCachedUserList(ctx context.Context, key string) ([]model.User, int64, bool) {
+	if s.listCache == nil {
+		return nil, 0, false
+	}
+
+	data, ok, err := s.listCache.Get(ctx, key)
+	if err != nil {
+		s.log.Warn("读取用户列表缓存失败", logger.Err(err))
+		return nil, 0, false
+	}
+	if !ok {
+		return nil, 0, false
+	}
+
+	var cached cachedUserList
+	if err := json.Unmarshal(data, &cached); err != nil {
+		s.log.Warn("解析用户列表缓存失败", logger.Err(err))
+		return nil, 0, false
+	}
+	return cached.Users, cached.Total, true
+}
+
+// setCachedUserList 写入列表缓存，失败只记录日志
+func (s *userService) setCachedUserList(ctx context.Context, key string, users []model.User, total int64) {
+	if s.listCache == nil {
+		return
+	}
+
+	data, err := json.Marshal(cachedUserList{Users: users, Total: total})
+	if err != nil {
+		s.log.Warn("序列化用户列表缓存失败", logger.Err(err))
+		return
+	}
+	if err := s.listCache.Set(ctx, key, data, s.listCacheTTL); err != nil {
+		s.log.Warn("写入用户列表缓存失败", logger.Err(err))
+	}
+}
+
+// invalidateUserListCache 清空全部用户列表缓存
+// 在用户数据发生变更后调用，失败只记录日志
+func (s *userService) invalidateUserListCache(ctx context.Context) {
+	clearUserListCache(ctx, s.listCache, s.log)
+}
+
+// clearUserListCache 按前缀清空用户列表缓存，c 为 nil 时不做任何事，失败只记录日志
+func clearUserListCache(ctx context.Context, c cache.Cache, log logger.Logger) {
+	if c == nil {
+		return
+	}
+	if err := c.DeletePrefix(ctx, userListCachePrefix); err != nil {
+		log.Warn("清空用户列表缓存失败", logger.Err(err))
+	}
+}
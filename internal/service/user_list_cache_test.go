@@ -0,0 +1,163 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含用户列表缓存的单元测试
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/internal/repository"
+	"github.com/example/go-user-api/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newCachedUserService 创建启用列表缓存的用户服务
+func newCachedUserService(repo *MockUserRepository) UserService {
+	cfg := newTestConfig()
+	return NewUserService(repo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger(),
+		WithUserListCache(cache.NewMemoryCache(), time.Minute),
+	)
+}
+
+func TestUserService_List_CacheHit(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	userService := newCachedUserService(mockRepo)
+	ctx := context.Background()
+
+	user := newTestUser()
+
+	// 设置 mock 期望：仓储只应被查询一次
+	mockRepo.On("List", ctx, mock.Anything).Return([]model.User{*user}, int64(1), nil).Once()
+
+	// 执行
+	first, total1, err1 := userService.List(ctx, &model.UserListRequest{Page: 1, SortBy: "created_at"})
+	second, total2, err2 := userService.List(ctx, &model.UserListRequest{Page: 1, SortBy: "created_at"})
+
+	// 断言
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	assert.Equal(t, int64(1), total1)
+	assert.Equal(t, total1, total2)
+	require.Len(t, second, 1)
+	assert.Equal(t, first[0].ID, second[0].ID)
+	assert.Equal(t, first[0].Username, second[0].Username)
+	// 缓存结果不包含密码哈希
+	assert.Empty(t, second[0].Password)
+	mockRepo.AssertNumberOfCalls(t, "List", 1)
+}
+
+func TestUserService_List_DifferentQueriesNotShared(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	userService := newCachedUserService(mockRepo)
+	ctx := context.Background()
+
+	// 设置 mock 期望
+	mockRepo.On("List", ctx, mock.Anything).Return([]model.User{}, int64(0), nil)
+
+	// 执行
+	_, _, err := userService.List(ctx, &model.UserListRequest{Page: 1})
+	require.NoError(t, err)
+	_, _, err = userService.List(ctx, &model.UserListRequest{Page: 2})
+	require.NoError(t, err)
+
+	// 断言
+	mockRepo.AssertNumberOfCalls(t, "List", 2)
+}
+
+func TestUserService_List_CacheInvalidatedOnWrite(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(repo *MockUserRepository)
+		write func(ctx context.Context, svc UserService) error
+	}{
+		{
+			name: "更新用户",
+			setup: func(repo *MockUserRepository) {
+				repo.On("GetByID", mock.Anything, "test-user-id").Return(newTestUser(), nil)
+				repo.On("UpdateFields", mock.Anything, "test-user-id", mock.Anything).Return(nil)
+			},
+			write: func(ctx context.Context, svc UserService) error {
+				_, err := svc.Update(ctx, "test-user-id", &model.UpdateUserRequest{Nickname: "new"})
+				return err
+			},
+		},
+		{
+			name: "删除用户",
+			setup: func(repo *MockUserRepository) {
+				repo.On("GetByID", mock.Anything, "test-user-id").Return(newTestUser(), nil)
+				repo.On("Delete", mock.Anything, "test-user-id").Return(nil)
+			},
+			write: func(ctx context.Context, svc UserService) error {
+				return svc.Delete(ctx, "test-user-id")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 准备
+			mockRepo := new(MockUserRepository)
+			userService := newCachedUserService(mockRepo)
+			ctx := context.Background()
+			req := &model.UserListRequest{Page: 1}
+
+			// 设置 mock 期望
+			mockRepo.On("List", ctx, mock.Anything).Return([]model.User{*newTestUser()}, int64(1), nil)
+			tt.setup(mockRepo)
+
+			// 执行：查询 -> 写操作 -> 再次查询
+			_, _, err := userService.List(ctx, req)
+			require.NoError(t, err)
+			require.NoError(t, tt.write(ctx, userService))
+			_, _, err = userService.List(ctx, req)
+			require.NoError(t, err)
+
+			// 断言：写操作后重新查询仓储
+			mockRepo.AssertNumberOfCalls(t, "List", 2)
+		})
+	}
+}
+
+func TestUserService_List_CacheDisabledWithoutOption(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+	ctx := context.Background()
+
+	// 设置 mock 期望
+	mockRepo.On("List", ctx, mock.Anything).Return([]model.User{}, int64(0), nil)
+
+	// 执行
+	_, _, _ = userService.List(ctx, &model.UserListRequest{})
+	_, _, _ = userService.List(ctx, &model.UserListRequest{})
+
+	// 断言
+	mockRepo.AssertNumberOfCalls(t, "List", 2)
+}
+
+func TestUserListCacheKey_Normalized(t *testing.T) {
+	active := int8(1)
+	a := &repository.UserListOptions{
+		Page: 1, PageSize: 10, Status: &active,
+		SortBy: "Created_At", SortOrder: "DESC",
+		Preloads: []string{"Tags", "Tags"},
+	}
+	b := &repository.UserListOptions{
+		Page: 1, PageSize: 10, Status: &active,
+		SortBy: "created_at", SortOrder: "desc",
+		Preloads: []string{"Tags"},
+	}
+	c := &repository.UserListOptions{Page: 1, PageSize: 10}
+
+	assert.Equal(t, userListCacheKey(a), userListCacheKey(b))
+	assert.NotEqual(t, userListCacheKey(a), userListCacheKey(c))
+	assert.Contains(t, userListCacheKey(a), userListCachePrefix)
+}
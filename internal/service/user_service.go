@@ -11,13 +11,20 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"sync"
 	"time"
 
 	"github.com/example/go-user-api/internal/config"
 	"github.com/example/go-user-api/internal/model"
 	"github.com/example/go-user-api/internal/repository"
+	"github.com/example/go-user-api/pkg/cache"
 	"github.com/example/go-user-api/pkg/errors"
+	"github.com/example/go-user-api/pkg/eventbus"
 	"github.com/example/go-user-api/pkg/logger"
+	"github.com/example/go-user-api/pkg/storage"
+	"github.com/example/go-user-api/pkg/tokenblacklist"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -30,48 +37,208 @@ type UserService interface {
 	Login(ctx context.Context, req *model.LoginRequest, clientIP string) (*model.LoginResponse, error)
 	// GetByID 根据 ID 获取用户
 	GetByID(ctx context.Context, id string) (*model.User, error)
+	// GetProfile 按资料可见性获取用户资料，返回查看者的访问范围；不可查看时返回 ErrForbidden
+	GetProfile(ctx context.Context, id string, viewer model.Viewer) (*model.User, model.ProfileAccess, error)
+	// GetManyByIDs 根据 ID 列表批量获取用户，返回查看者可查看完整资料的用户与缺失的 ID
+	GetManyByIDs(ctx context.Context, ids []string, viewer model.Viewer) ([]model.User, []string, error)
 	// GetByUsername 根据用户名获取用户
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	// Lookup 按确切的邮箱或用户名查找用户
+	Lookup(ctx context.Context, req *model.UserLookupRequest) (*model.User, error)
 	// Update 更新用户信息
 	Update(ctx context.Context, id string, req *model.UpdateUserRequest) (*model.User, error)
+	// AdminUpdate 管理员更新用户信息，可修改邮箱、用户名、状态与角色
+	AdminUpdate(ctx context.Context, id string, req *model.AdminUpdateUserRequest, operatorID string) (*model.User, error)
+	// BatchUpdateRole 批量修改用户角色，返回角色实际发生变化的用户数
+	// progress 不为 nil 时每处理完一个用户回调一次
+	BatchUpdateRole(ctx context.Context, req *model.BatchUpdateRoleRequest, operatorID string, progress BatchProgressFunc) (*model.BatchUpdateRoleResponse, error)
+	// BatchUpdateStatus 批量修改用户状态，单个用户失败不影响其他用户
+	// progress 不为 nil 时每处理完一个用户回调一次
+	BatchUpdateStatus(ctx context.Context, req *model.BatchUpdateStatusRequest, operatorID string, progress BatchProgressFunc) (*model.BatchUpdateStatusResponse, error)
+	// ListChangeLogs 分页获取用户的关键字段变更记录
+	ListChangeLogs(ctx context.Context, userID string, req *model.UserChangeLogListRequest) ([]model.UserChangeLog, int64, error)
 	// UpdatePassword 修改密码
 	UpdatePassword(ctx context.Context, id string, req *model.ChangePasswordRequest) error
 	// Delete 删除用户
 	Delete(ctx context.Context, id string) error
+	// HardDelete 永久删除用户（含已软删除的用户）及其个人数据
+	HardDelete(ctx context.Context, id string) error
 	// List 获取用户列表
 	List(ctx context.Context, req *model.UserListRequest) ([]model.User, int64, error)
+	// ExplainList 返回用户列表查询的执行计划，用于排查慢查询
+	ExplainList(ctx context.Context, req *model.UserListRequest) (*model.QueryPlan, error)
+	// ExportUsers 按过滤条件导出用户到 w，返回导出的用户数
+	ExportUsers(ctx context.Context, req *model.UserExportRequest, w io.Writer) (int, error)
+	// ImportUsers 从表格导入用户，单行失败不影响其他行
+	// progress 不为 nil 时每处理完一行回调一次
+	ImportUsers(ctx context.Context, format string, r io.Reader, progress BatchProgressFunc) (*model.UserImportResult, error)
+	// UploadAvatar 上传头像，保存原图并生成各尺寸缩略图
+	UploadAvatar(ctx context.Context, id string, r io.Reader) (*model.User, error)
 	// RefreshToken 刷新访问令牌
 	RefreshToken(ctx context.Context, refreshToken string) (*model.RefreshTokenResponse, error)
+	// Logout 登出，撤销用户的所有刷新令牌；设置了令牌黑名单时当前访问令牌立即失效
+	Logout(ctx context.Context, claims *TokenClaims) error
+	// RevokeTokens 强制用户下线，使其已签发的所有令牌失效
+	RevokeTokens(ctx context.Context, userID string) error
+	// Impersonate 超级管理员以目标用户身份签发短期模拟令牌
+	Impersonate(ctx context.Context, targetID, operatorID string) (*model.ImpersonateResponse, error)
+	// RevokeAllTokens 强制所有用户下线，返回影响的用户数
+	RevokeAllTokens(ctx context.Context) (int64, error)
+	// ValidateTokenVersion 校验令牌版本是否与用户当前版本一致
+	ValidateTokenVersion(ctx context.Context, userID string, version int) error
+	// RenewAccessToken 以用户当前的角色与额外声明续签访问令牌
+	RenewAccessToken(ctx context.Context, claims *TokenClaims) (string, error)
+	// RecordActivity 记录用户活跃，同一用户短时间内的多次调用只写库一次
+	RecordActivity(ctx context.Context, userID string)
+	// GetOnlineStatus 查询用户在线状态
+	GetOnlineStatus(ctx context.Context, id string) (*model.OnlineStatusResponse, error)
 	// ValidateToken 验证令牌
 	ValidateToken(ctx context.Context, token string) (*TokenClaims, error)
 }
 
 // userService 用户服务实现
 type userService struct {
-	userRepo   repository.UserRepository
-	jwtService JWTService
-	config     *config.Config
-	log        logger.Logger
+	userRepo         repository.UserRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	loginHistoryRepo repository.LoginHistoryRepository
+	jwtService       JWTService
+	config           *config.Config
+	log              logger.Logger
+
+	// passwordHistoryRepo 密码历史仓储，为 nil 时不检查密码重用
+	passwordHistoryRepo repository.PasswordHistoryRepository
+
+	// changeLogRepo 用户变更记录仓储，为 nil 时不记录变更历史
+	changeLogRepo repository.UserChangeLogRepository
+
+	// personalTokenRepo 个人访问令牌仓储，为 nil 时强制下线与修改密码不撤销个人访问令牌
+	personalTokenRepo repository.PersonalAccessTokenRepository
+
+	// registrationThrottle 每小时注册总量节流，为 nil 时不限
+	registrationThrottle *registrationThrottle
+
+	// anomalyDetector 登录地点异常检测，为 nil 时不检测
+	anomalyDetector *loginAnomalyDetector
+
+	// listCache 用户列表缓存，为 nil 时不缓存
+	listCache    cache.Cache
+	listCacheTTL time.Duration
+
+	// extraClaims 签发访问令牌时生成额外声明，为 nil 时不注入
+	extraClaims ExtraClaimsFunc
+
+	// friendships 好友关系查询，为 nil 时任何人都不视为好友
+	friendships FriendshipChecker
+
+	// eventBus 用户生命周期事件总线，为 nil 时不发布事件
+	eventBus eventbus.EventBus
+
+	// notifier 用户通知发送器，为 nil 时不发送欢迎通知
+	notifier Notifier
+
+	// tokenBlacklist 已吊销访问令牌的黑名单，为 nil 时登出后访问令牌在过期前仍然有效
+	tokenBlacklist tokenblacklist.TokenBlacklist
+
+	// avatarStorage 头像存储，为 nil 时不支持上传头像
+	avatarStorage storage.Storage
+	// avatarSlots 头像处理的并发名额
+	avatarSlots chan struct{}
+
+	// updateValidator 用户更新的业务校验
+	updateValidator *Validator[userUpdate]
+
+	// activity 最近活跃时间的写入节流
+	activity *activityTracker
+
+	// dummyHash 用户不存在时参与比较的假哈希，首次使用时按配置的成本生成
+	dummyHash     string
+	dummyHashOnce sync.Once
+}
+
+// UserServiceOption 用户服务的可选配置
+// 用于注入非必需的协作组件，未设置时对应功能不生效
+type UserServiceOption func(*userService)
+
+// WithLoginHistoryRepository 设置登录历史仓储
+// 设置后每次成功登录都会写入一条登录历史
+func WithLoginHistoryRepository(repo repository.LoginHistoryRepository) UserServiceOption {
+	return func(s *userService) {
+		s.loginHistoryRepo = repo
+	}
+}
+
+// ExtraClaimsFunc 根据用户生成访问令牌的额外声明
+// 返回 nil 或空 map 表示不注入
+type ExtraClaimsFunc func(ctx context.Context, user *model.User) map[string]interface{}
+
+// WithExtraClaims 设置访问令牌额外声明的生成函数
+// 登录与刷新令牌签发访问令牌时调用，下游可通过 TokenClaims.Extra 读取。
+// 路由按 jwt.extra_claims 配置传入 ConfiguredExtraClaims；需要查询其他数据（如部门、权限）时，
+// 嵌入本服务的代码自行提供生成函数，后设置的选项覆盖先设置的
+func WithExtraClaims(fn ExtraClaimsFunc) UserServiceOption {
+	return func(s *userService) {
+		s.extraClaims = fn
+	}
+}
+
+// ConfiguredExtraClaims 按配置生成额外声明：固定声明加上指定的用户字段
+// 配置为空时返回 nil，表示不注入额外声明
+func ConfiguredExtraClaims(cfg config.ExtraClaimsConfig) ExtraClaimsFunc {
+	if len(cfg.Static) == 0 && len(cfg.UserFields) == 0 {
+		return nil
+	}
+	return func(_ context.Context, user *model.User) map[string]interface{} {
+		claims := make(map[string]interface{}, len(cfg.Static)+len(cfg.UserFields))
+		for k, v := range cfg.Static {
+			claims[k] = v
+		}
+		for _, field := range cfg.UserFields {
+			switch field {
+			case "nickname":
+				claims[field] = user.Nickname
+			case "gender":
+				claims[field] = user.Gender
+			case "source":
+				claims[field] = user.Source
+			case "profile_visibility":
+				claims[field] = user.ProfileVisibility
+			}
+		}
+		return claims
+	}
 }
 
 // NewUserService 创建用户服务实例
 // 参数：
 //   - userRepo: 用户仓储实例
+//   - refreshTokenRepo: 刷新令牌仓储实例
 //   - jwtService: JWT 服务实例
 //   - cfg: 应用配置
 //   - log: 日志记录器
+//   - opts: 可选配置
 func NewUserService(
 	userRepo repository.UserRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
 	jwtService JWTService,
 	cfg *config.Config,
 	log logger.Logger,
+	opts ...UserServiceOption,
 ) UserService {
-	return &userService{
-		userRepo:   userRepo,
-		jwtService: jwtService,
-		config:     cfg,
-		log:        log.With(logger.String("service", "user")),
+	s := &userService{
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		jwtService:       jwtService,
+		config:           cfg,
+		log:              log.With(logger.String("service", "user")),
+
+		registrationThrottle: newRegistrationThrottle(cfg.Security.Registration.HourlyLimit),
+		activity:             newActivityTracker(),
+		updateValidator:      newUserUpdateValidator(time.Now),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // Register 用户注册
@@ -82,7 +249,21 @@ func (s *userService) Register(ctx context.Context, req *model.RegisterRequest)
 		logger.String("email", req.Email),
 	)
 
-	// 检查用户名是否已存在
+	// 活动期间可临时关闭注册
+	if !s.config.Security.Registration.Enabled {
+		return nil, errors.ErrRegistrationClosed
+	}
+
+	if err := checkUsername(req.Username, s.config.Security.Username); err != nil {
+		return nil, err
+	}
+	source := model.NormalizeRegistrationSource(req.Source)
+	if len(source) > model.MaxRegistrationSourceLen {
+		return nil, errors.ErrValidation.WithDetail(fmt.Sprintf("注册来源不能超过 %d 个字符", model.MaxRegistrationSourceLen))
+	}
+
+	// 用户名、邮箱的唯一性由数据库唯一索引保证（并发注册时由 Create 返回冲突错误），
+	// 这里的存在性检查只用于在加密密码前快速失败
 	exists, err := s.userRepo.ExistsByUsername(ctx, req.Username)
 	if err != nil {
 		s.log.Error("检查用户名失败", logger.Err(err))
@@ -92,14 +273,40 @@ func (s *userService) Register(ctx context.Context, req *model.RegisterRequest)
 		return nil, errors.ErrUsernameExists
 	}
 
-	// 检查邮箱是否已存在
-	exists, err = s.userRepo.ExistsByEmail(ctx, req.Email)
-	if err != nil {
-		s.log.Error("检查邮箱失败", logger.Err(err))
-		return nil, err
+	// 检查邮箱：按配置决定是否必填，填写了才做唯一性检查
+	if req.Email == "" {
+		if s.config.Security.RequireEmail {
+			return nil, errors.ErrValidation.WithDetail("邮箱不能为空")
+		}
+	} else {
+		if err := checkEmailDomain(req.Email, s.config.Security.EmailDomains); err != nil {
+			return nil, err
+		}
+		exists, err = s.userRepo.ExistsByEmail(ctx, req.Email)
+		if err != nil {
+			s.log.Error("检查邮箱失败", logger.Err(err))
+			return nil, err
+		}
+		if exists {
+			return nil, errors.ErrEmailAlreadyUsed
+		}
 	}
-	if exists {
-		return nil, errors.ErrEmailAlreadyUsed
+
+	// 占用本小时的注册名额，注册失败时归还
+	registered := false
+	if s.registrationThrottle != nil {
+		window, ok := s.registrationThrottle.reserve()
+		if !ok {
+			s.log.Warn("注册数已达每小时上限",
+				logger.Int("hourly_limit", s.config.Security.Registration.HourlyLimit),
+			)
+			return nil, errors.ErrTooManyRequests.WithDetail("本小时注册人数已达上限，请稍后再试")
+		}
+		defer func() {
+			if !registered {
+				s.registrationThrottle.release(window)
+			}
+		}()
 	}
 
 	// 加密密码
@@ -111,12 +318,14 @@ func (s *userService) Register(ctx context.Context, req *model.RegisterRequest)
 
 	// 创建用户对象
 	user := &model.User{
-		Username: req.Username,
-		Email:    req.Email,
-		Password: hashedPassword,
-		Nickname: req.Nickname,
-		Status:   model.UserStatusActive,
-		Role:     model.RoleUser,
+		Username:     req.Username,
+		Email:        req.Email,
+		Password:     hashedPassword,
+		Nickname:     req.Nickname,
+		Status:       model.UserStatusActive,
+		Role:         model.RoleUser,
+		Source:       source,
+		ReferralCode: req.ReferralCode,
 	}
 
 	// 如果没有设置昵称，使用用户名作为昵称
@@ -126,14 +335,26 @@ func (s *userService) Register(ctx context.Context, req *model.RegisterRequest)
 
 	// 保存用户到数据库
 	if err := s.userRepo.Create(ctx, user); err != nil {
+		// 并发注册时前置检查可能都通过，冲突属于正常业务结果
+		if errors.Is(err, errors.ErrUsernameExists) || errors.Is(err, errors.ErrEmailAlreadyUsed) {
+			s.log.Info("注册时用户名或邮箱已被占用",
+				logger.String("username", req.Username),
+				logger.Err(err),
+			)
+			return nil, err
+		}
 		s.log.Error("创建用户失败", logger.Err(err))
 		return nil, err
 	}
+	registered = true
+	s.invalidateUserListCache(ctx)
 
 	s.log.Info("用户注册成功",
 		logger.String("user_id", user.ID),
 		logger.String("username", user.Username),
+		logger.String("source", user.Source),
 	)
+	s.notifyWelcome(ctx, user)
 
 	return user, nil
 }
@@ -149,7 +370,9 @@ func (s *userService) Login(ctx context.Context, req *model.LoginRequest, client
 	// 根据用户名或邮箱查找用户
 	user, err := s.userRepo.GetByUsernameOrEmail(ctx, req.Username)
 	if err != nil {
-		if errors.IsAppError(err) && errors.AsAppError(err).Code == errors.CodeUserNotFound {
+		if errors.Is(err, errors.ErrUserNotFound) {
+			// 用户不存在时同样执行一次 bcrypt 比较，避免通过响应耗时枚举用户名
+			s.checkPassword(req.Password, s.getDummyHash())
 			return nil, errors.ErrInvalidCredential
 		}
 		return nil, err
@@ -172,24 +395,40 @@ func (s *userService) Login(ctx context.Context, req *model.LoginRequest, client
 	}
 
 	// 生成访问令牌和刷新令牌
-	accessToken, err := s.jwtService.GenerateAccessToken(user)
+	accessToken, err := s.generateAccessToken(ctx, user)
 	if err != nil {
 		s.log.Error("生成访问令牌失败", logger.Err(err))
 		return nil, errors.ErrInternalServer.WithError(err)
 	}
 
-	refreshToken, err := s.jwtService.GenerateRefreshToken(user)
+	refreshToken, err := s.issueRefreshToken(ctx, user)
 	if err != nil {
-		s.log.Error("生成刷新令牌失败", logger.Err(err))
-		return nil, errors.ErrInternalServer.WithError(err)
+		return nil, err
 	}
 
+	// 更新前记下上次登录 IP，用于异常检测；上次登录时间为空即首次登录
+	previousIP := user.LastLoginIP
+	firstLogin := user.LastLoginAt == nil
+
 	// 更新最后登录信息
 	if err := s.userRepo.UpdateLastLogin(ctx, user.ID, clientIP); err != nil {
 		// 更新登录信息失败不影响登录结果，只记录日志
 		s.log.Warn("更新登录信息失败", logger.Err(err))
 	}
 
+	// 记录登录历史，失败同样不影响登录结果
+	if s.loginHistoryRepo != nil {
+		history := &model.LoginHistory{UserID: user.ID, IP: clientIP}
+		if err := s.loginHistoryRepo.Create(ctx, history); err != nil {
+			s.log.Warn("记录登录历史失败", logger.Err(err))
+		}
+	}
+
+	// 登录地点异常检测，结果不影响登录
+	if s.anomalyDetector != nil {
+		s.anomalyDetector.check(ctx, user, previousIP, clientIP)
+	}
+
 	s.log.Info("用户登录成功",
 		logger.String("user_id", user.ID),
 		logger.String("username", user.Username),
@@ -202,6 +441,8 @@ func (s *userService) Login(ctx context.Context, req *model.LoginRequest, client
 		TokenType:    "Bearer",
 		ExpiresIn:    int64(s.config.JWT.AccessTokenExpireDuration().Seconds()),
 		User:         user.ToResponse(),
+		Permissions:  user.Permissions(),
+		FirstLogin:   firstLogin,
 	}, nil
 }
 
@@ -210,11 +451,69 @@ func (s *userService) GetByID(ctx context.Context, id string) (*model.User, erro
 	return s.userRepo.GetByID(ctx, id)
 }
 
+// GetManyByIDs 根据 ID 列表批量获取用户
+// 重复的 ID 只查询一次；返回的用户与缺失 ID 都按请求中首次出现的顺序排列。
+// 与 GetProfile 一致按资料可见性过滤，只返回查看者可查看完整资料的用户；
+// 私密资料与仅好友可见且查看者不是好友的用户，与不存在的用户一样列入缺失 ID
+func (s *userService) GetManyByIDs(ctx context.Context, ids []string, viewer model.Viewer) ([]model.User, []string, error) {
+	unique := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	if len(unique) == 0 {
+		return nil, nil, errors.ErrValidation.WithDetail("用户 ID 列表不能为空")
+	}
+	if len(unique) > model.MaxBatchGetUsers {
+		return nil, nil, errors.ErrValidation.WithDetail(fmt.Sprintf("单次最多获取 %d 个用户", model.MaxBatchGetUsers))
+	}
+
+	found, err := s.userRepo.GetByIDs(ctx, unique)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byID := make(map[string]model.User, len(found))
+	for _, u := range found {
+		byID[u.ID] = u
+	}
+
+	users := make([]model.User, 0, len(found))
+	missing := make([]string, 0)
+	for _, id := range unique {
+		if u, ok := byID[id]; ok && u.ProfileAccessFor(viewer, s.isFriend(ctx, &u, viewer)) == model.ProfileAccessFull {
+			users = append(users, u)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return users, missing, nil
+}
+
 // GetByUsername 根据用户名获取用户
 func (s *userService) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	return s.userRepo.GetByUsername(ctx, username)
 }
 
+// Lookup 按确切的邮箱或用户名查找用户
+// 两者必须且只能提供一个，否则返回验证错误；用户不存在时返回 ErrUserNotFound
+func (s *userService) Lookup(ctx context.Context, req *model.UserLookupRequest) (*model.User, error) {
+	switch {
+	case req.Email != "" && req.Username != "":
+		return nil, errors.ErrValidation.WithDetail("email 与 username 只能提供一个")
+	case req.Email != "":
+		return s.userRepo.GetByEmail(ctx, req.Email)
+	case req.Username != "":
+		return s.userRepo.GetByUsername(ctx, req.Username)
+	default:
+		return nil, errors.ErrValidation.WithDetail("请提供 email 或 username")
+	}
+}
+
 // Update 更新用户信息
 // 只更新请求中非空的字段
 func (s *userService) Update(ctx context.Context, id string, req *model.UpdateUserRequest) (*model.User, error) {
@@ -228,7 +527,264 @@ func (s *userService) Update(ctx context.Context, id string, req *model.UpdateUs
 		return nil, err
 	}
 
-	// 构建更新字段
+	adminReq := &model.AdminUpdateUserRequest{UpdateUserRequest: *req}
+	if err := s.updateValidator.Validate(ctx, userUpdate{current: user, req: adminReq}); err != nil {
+		return nil, err
+	}
+
+	return s.applyUpdates(ctx, user, profileUpdates(req), id)
+}
+
+// AdminUpdate 管理员更新用户信息
+// 在资料字段之外还可修改邮箱、用户名、状态与角色，email、role、status 的变化会记录变更历史
+func (s *userService) AdminUpdate(ctx context.Context, id string, req *model.AdminUpdateUserRequest, operatorID string) (*model.User, error) {
+	s.log.Debug("管理员更新用户信息",
+		logger.String("user_id", id),
+		logger.String("operator_id", operatorID),
+	)
+
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.updateValidator.Validate(ctx, userUpdate{current: user, req: req}); err != nil {
+		return nil, err
+	}
+
+	updates := profileUpdates(&req.UpdateUserRequest)
+	if req.Email != "" {
+		updates["email"] = req.Email
+	}
+	if req.Username != "" && req.Username != user.Username {
+		if err := checkUsername(req.Username, s.config.Security.Username); err != nil {
+			return nil, err
+		}
+		updates["username"] = req.Username
+	}
+	if req.Status != nil {
+		updates["status"] = *req.Status
+	}
+	if req.Role != "" {
+		updates["role"] = req.Role
+	}
+
+	updated, err := s.applyUpdates(ctx, user, updates, operatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Status != nil && *req.Status == model.UserStatusDisabled && !user.IsDisabled() {
+		s.publishUserEvent(ctx, EventUserDisabled, UserEvent{
+			UserID:     user.ID,
+			Username:   updated.Username,
+			OperatorID: operatorID,
+		})
+	}
+	return updated, nil
+}
+
+// BatchUpdateRole 批量修改用户角色
+// 所有用户在同一事务中更新，任一用户不存在时全部不修改；操作者不能借此降低自己的角色。
+// 角色实际发生变化的用户会记录变更历史，其已签发的令牌失效。
+// 角色在同一事务中一次更新，progress 在事务提交后随每个用户的变更记录回调
+func (s *userService) BatchUpdateRole(ctx context.Context, req *model.BatchUpdateRoleRequest, operatorID string, progress BatchProgressFunc) (*model.BatchUpdateRoleResponse, error) {
+	if !model.IsValidRole(req.Role) {
+		return nil, errors.ErrValidation.WithDetail(fmt.Sprintf("不支持的角色: %s", req.Role))
+	}
+
+	ids, seen := uniqueIDs(req.IDs)
+	if len(ids) == 0 {
+		return nil, errors.ErrValidation.WithDetail("用户 ID 列表不能为空")
+	}
+	if len(ids) > model.MaxBatchUpdateRole {
+		return nil, errors.ErrValidation.WithDetail(fmt.Sprintf("单次最多修改 %d 个用户的角色", model.MaxBatchUpdateRole))
+	}
+	if seen[operatorID] && req.Role != model.RoleAdmin {
+		return nil, errors.ErrBadRequest.WithDetail("不能降低自己的角色")
+	}
+
+	s.log.Debug("批量修改用户角色",
+		logger.Int("count", len(ids)),
+		logger.String("role", req.Role),
+		logger.String("operator_id", operatorID),
+	)
+
+	// 读取修改前的用户用于记录变更历史，同时提前发现不存在的 ID
+	before, err := s.userRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(before) != len(ids) {
+		found := make(map[string]bool, len(before))
+		for _, u := range before {
+			found[u.ID] = true
+		}
+		for _, id := range ids {
+			if !found[id] {
+				return nil, errors.ErrUserNotFound.WithDetail(fmt.Sprintf("用户不存在: %s", id))
+			}
+		}
+	}
+
+	updated, err := s.userRepo.UpdateRoleBatch(ctx, ids, req.Role)
+	if err != nil {
+		if !errors.Is(err, errors.ErrUserNotFound) {
+			s.log.Error("批量修改用户角色失败", logger.Err(err))
+		}
+		return nil, err
+	}
+
+	updates := map[string]interface{}{"role": req.Role}
+	for i := range before {
+		s.recordUserChanges(ctx, &before[i], updates, operatorID)
+		if progress != nil {
+			progress(model.BatchProgress{Processed: i + 1, Total: len(ids), Succeeded: i + 1})
+		}
+	}
+	s.invalidateUserListCache(ctx)
+
+	s.log.Info("批量修改用户角色成功",
+		logger.Int("count", len(ids)),
+		logger.Int64("updated", updated),
+		logger.String("role", req.Role),
+	)
+
+	return &model.BatchUpdateRoleResponse{
+		Role:    req.Role,
+		Total:   len(ids),
+		Updated: updated,
+	}, nil
+}
+
+// BatchUpdateStatus 批量修改用户状态
+// 逐个用户更新，单个用户不存在或更新失败时记录在结果中，不影响其他用户；操作者不能借此禁用自己。
+// 状态实际发生变化的用户会记录变更历史，其已签发的令牌失效，被禁用的用户发布禁用事件
+func (s *userService) BatchUpdateStatus(ctx context.Context, req *model.BatchUpdateStatusRequest, operatorID string, progress BatchProgressFunc) (*model.BatchUpdateStatusResponse, error) {
+	if req.Status == nil || *req.Status < model.UserStatusDisabled || *req.Status > model.UserStatusInactive {
+		return nil, errors.ErrValidation.WithDetail("不支持的用户状态")
+	}
+	status := *req.Status
+
+	ids, seen := uniqueIDs(req.IDs)
+	if len(ids) == 0 {
+		return nil, errors.ErrValidation.WithDetail("用户 ID 列表不能为空")
+	}
+	if len(ids) > model.MaxBatchUpdateStatus {
+		return nil, errors.ErrValidation.WithDetail(fmt.Sprintf("单次最多修改 %d 个用户的状态", model.MaxBatchUpdateStatus))
+	}
+	if seen[operatorID] && status != model.UserStatusActive {
+		return nil, errors.ErrBadRequest.WithDetail("不能禁用自己")
+	}
+
+	s.log.Debug("批量修改用户状态",
+		logger.Int("count", len(ids)),
+		logger.Int("status", int(status)),
+		logger.String("operator_id", operatorID),
+	)
+
+	result := &model.BatchUpdateStatusResponse{Status: status, Total: len(ids), Errors: []model.UserImportError{}}
+	// 中途取消时已修改的用户同样需要让列表缓存失效
+	defer func() {
+		if result.Succeeded > 0 {
+			s.invalidateUserListCache(ctx)
+		}
+	}()
+
+	for i, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var itemErr *model.UserImportError
+		if err := s.updateStatus(ctx, id, status, operatorID); err != nil {
+			itemErr = &model.UserImportError{Row: i + 1, ID: id, Message: errors.FromError(err).Message}
+			result.Failed++
+			result.Errors = append(result.Errors, *itemErr)
+		} else {
+			result.Succeeded++
+		}
+
+		if progress != nil {
+			progress(model.BatchProgress{
+				Processed: i + 1,
+				Total:     len(ids),
+				Succeeded: result.Succeeded,
+				Failed:    result.Failed,
+				Error:     itemErr,
+			})
+		}
+	}
+
+	s.log.Info("批量修改用户状态完成",
+		logger.Int("total", result.Total),
+		logger.Int("succeeded", result.Succeeded),
+		logger.Int("failed", result.Failed),
+		logger.Int("status", int(status)),
+	)
+	return result, nil
+}
+
+// updateStatus 修改单个用户的状态，用户由正常变为禁用时发布禁用事件
+func (s *userService) updateStatus(ctx context.Context, id string, status int8, operatorID string) error {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	updated, err := s.applyUpdates(ctx, user, map[string]interface{}{"status": status}, operatorID)
+	if err != nil {
+		return err
+	}
+	if status == model.UserStatusDisabled && !user.IsDisabled() {
+		s.publishUserEvent(ctx, EventUserDisabled, UserEvent{
+			UserID:     user.ID,
+			Username:   updated.Username,
+			OperatorID: operatorID,
+		})
+	}
+	return nil
+}
+
+// uniqueIDs 去除空 ID 与重复 ID，保持原有顺序，同时返回出现过的 ID 集合
+func uniqueIDs(raw []string) ([]string, map[string]bool) {
+	ids := make([]string, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, id := range raw {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids, seen
+}
+
+// applyUpdates 执行字段更新并记录受监控字段的变更，返回更新后的用户
+// 没有要更新的字段时直接返回 user；角色或状态发生变化时递增令牌版本，
+// 已签发的令牌携带旧的角色与状态，需要用户重新登录
+func (s *userService) applyUpdates(ctx context.Context, user *model.User, updates map[string]interface{}, changedBy string) (*model.User, error) {
+	if len(updates) == 0 {
+		return user, nil
+	}
+
+	if err := s.userRepo.UpdateFields(ctx, user.ID, updates); err != nil {
+		s.log.Error("更新用户失败", logger.Err(err))
+		return nil, err
+	}
+	if changesAuthorization(user, updates) {
+		if err := s.userRepo.IncrementTokenVersion(ctx, user.ID); err != nil {
+			s.log.Error("递增令牌版本失败", logger.String("user_id", user.ID), logger.Err(err))
+			return nil, err
+		}
+	}
+	s.recordUserChanges(ctx, user, updates, changedBy)
+	s.invalidateUserListCache(ctx)
+
+	// 返回更新后的用户
+	return s.userRepo.GetByID(ctx, user.ID)
+}
+
+// profileUpdates 将资料更新请求转换为待更新字段，空值表示不修改
+func profileUpdates(req *model.UpdateUserRequest) map[string]interface{} {
 	updates := make(map[string]interface{})
 
 	if req.Nickname != "" {
@@ -236,6 +792,8 @@ func (s *userService) Update(ctx context.Context, id string, req *model.UpdateUs
 	}
 	if req.Avatar != "" {
 		updates["avatar"] = req.Avatar
+		// 直接指定的头像 URL 没有对应的缩略图，旧缩略图不再适用
+		updates["avatar_thumbnails"] = model.AvatarThumbnails(nil)
 	}
 	if req.Phone != "" {
 		updates["phone"] = req.Phone
@@ -249,20 +807,10 @@ func (s *userService) Update(ctx context.Context, id string, req *model.UpdateUs
 	if req.Birthday != nil {
 		updates["birthday"] = *req.Birthday
 	}
-
-	// 如果没有要更新的字段，直接返回当前用户
-	if len(updates) == 0 {
-		return user, nil
-	}
-
-	// 执行更新
-	if err := s.userRepo.UpdateFields(ctx, id, updates); err != nil {
-		s.log.Error("更新用户失败", logger.Err(err))
-		return nil, err
+	if req.ProfileVisibility != "" {
+		updates["profile_visibility"] = req.ProfileVisibility
 	}
-
-	// 返回更新后的用户
-	return s.userRepo.GetByID(ctx, id)
+	return updates
 }
 
 // UpdatePassword 修改密码
@@ -282,6 +830,11 @@ func (s *userService) UpdatePassword(ctx context.Context, id string, req *model.
 		return errors.ErrInvalidPassword
 	}
 
+	// 禁止换回最近用过的密码
+	if err := s.checkPasswordReuse(ctx, id, req.OldPassword, req.NewPassword); err != nil {
+		return err
+	}
+
 	// 加密新密码
 	hashedPassword, err := s.hashPassword(req.NewPassword)
 	if err != nil {
@@ -294,6 +847,17 @@ func (s *userService) UpdatePassword(ctx context.Context, id string, req *model.
 		s.log.Error("更新密码失败", logger.Err(err))
 		return err
 	}
+	s.invalidateUserListCache(ctx)
+	s.recordPasswordHistory(ctx, id, user.Password)
+
+	// 修改密码后撤销所有刷新令牌与个人访问令牌，强制其他会话重新登录
+	if err := s.refreshTokenRepo.RevokeAllByUser(ctx, id); err != nil {
+		s.log.Error("撤销刷新令牌失败", logger.Err(err))
+		return err
+	}
+	if err := s.revokePersonalAccessTokens(ctx, id); err != nil {
+		return err
+	}
 
 	s.log.Info("用户密码修改成功",
 		logger.String("user_id", id),
@@ -309,7 +873,7 @@ func (s *userService) Delete(ctx context.Context, id string) error {
 	)
 
 	// 检查用户是否存在
-	_, err := s.userRepo.GetByID(ctx, id)
+	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -319,6 +883,8 @@ func (s *userService) Delete(ctx context.Context, id string) error {
 		s.log.Error("删除用户失败", logger.Err(err))
 		return err
 	}
+	s.invalidateUserListCache(ctx)
+	s.publishUserEvent(ctx, EventUserDeleted, UserEvent{UserID: user.ID, Username: user.Username})
 
 	s.log.Info("用户删除成功",
 		logger.String("user_id", id),
@@ -327,8 +893,75 @@ func (s *userService) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// HardDelete 永久删除用户
+// 用于 GDPR 删除权等需要立即删除的场景，已软删除的用户也可硬删除；删除后不可恢复。
+// 用户上传的头像文件一并删除
+func (s *userService) HardDelete(ctx context.Context, id string) error {
+	s.log.Debug("永久删除用户",
+		logger.String("user_id", id),
+	)
+
+	user, err := s.userRepo.HardDelete(ctx, id)
+	if err != nil {
+		if !errors.Is(err, errors.ErrUserNotFound) {
+			s.log.Error("永久删除用户失败", logger.Err(err))
+		}
+		return err
+	}
+	deleteAvatarFiles(ctx, s.avatarStorage, avatarKeys(user), s.log)
+	s.invalidateUserListCache(ctx)
+	s.publishUserEvent(ctx, EventUserDeleted, UserEvent{UserID: id, Permanent: true})
+
+	s.log.Info("用户已永久删除",
+		logger.String("user_id", id),
+	)
+
+	return nil
+}
+
 // List 获取用户列表
 func (s *userService) List(ctx context.Context, req *model.UserListRequest) ([]model.User, int64, error) {
+	// 客户端已取消时不再发起查询
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	opts, err := s.userListOptions(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.checkOffset(opts.Page, opts.PageSize); err != nil {
+		return nil, 0, err
+	}
+
+	key := userListCacheKey(opts)
+	if users, total, ok := s.getCachedUserList(ctx, key); ok {
+		return users, total, nil
+	}
+
+	users, total, err := s.userRepo.List(ctx, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.setCachedUserList(ctx, key, users, total)
+	return users, total, nil
+}
+
+// ExplainList 返回用户列表查询的执行计划
+// 与 List 使用相同的过滤、排序与分页条件，不经过列表缓存
+func (s *userService) ExplainList(ctx context.Context, req *model.UserListRequest) (*model.QueryPlan, error) {
+	opts, err := s.userListOptions(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkOffset(opts.Page, opts.PageSize); err != nil {
+		return nil, err
+	}
+	return s.userRepo.ExplainList(ctx, opts)
+}
+
+// userListOptions 将列表请求转换为仓储查询选项
+func (s *userService) userListOptions(req *model.UserListRequest) (*repository.UserListOptions, error) {
 	opts := &repository.UserListOptions{
 		Page:      req.GetDefaultPage(),
 		PageSize:  req.GetDefaultPageSize(s.config.Pagination.DefaultPageSize, s.config.Pagination.MaxPageSize),
@@ -336,11 +969,40 @@ func (s *userService) List(ctx context.Context, req *model.UserListRequest) ([]m
 		Email:     req.Email,
 		Status:    req.Status,
 		Role:      req.Role,
+		Source:    model.NormalizeRegistrationSource(req.Source),
 		SortBy:    req.SortBy,
 		SortOrder: req.SortOrder,
+		Preloads:  req.Preload,
+		SkipTotal: req.SkipTotal,
 	}
+	if req.LastLoginBefore != "" {
+		t, err := time.Parse(time.RFC3339, req.LastLoginBefore)
+		if err != nil {
+			return nil, errors.ErrValidation.WithDetail("last_login_before 必须是 RFC3339 格式")
+		}
+		opts.LastLoginBefore = &t
+	}
+	if req.LastLoginAfter != "" {
+		t, err := time.Parse(time.RFC3339, req.LastLoginAfter)
+		if err != nil {
+			return nil, errors.ErrValidation.WithDetail("last_login_after 必须是 RFC3339 格式")
+		}
+		opts.LastLoginAfter = &t
+	}
+	return opts, nil
+}
 
-	return s.userRepo.List(ctx, opts)
+// checkOffset 检查翻页深度，偏移量超过 MaxOffset 时返回 400
+func (s *userService) checkOffset(page, pageSize int) error {
+	maxOffset := s.config.Pagination.MaxOffset
+	if maxOffset <= 0 {
+		return nil
+	}
+	if offset := int64(page-1) * int64(pageSize); offset > int64(maxOffset) {
+		return errors.ErrBadRequest.WithDetail(fmt.Sprintf(
+			"翻页过深（offset %d 超过上限 %d），请缩小筛选条件或改用游标分页", offset, maxOffset))
+	}
+	return nil
 }
 
 // RefreshToken 刷新访问令牌
@@ -358,6 +1020,22 @@ func (s *userService) RefreshToken(ctx context.Context, refreshToken string) (*m
 		return nil, errors.ErrInvalidToken.WithDetail("不是有效的刷新令牌")
 	}
 
+	// 检查令牌是否已入库且未被撤销
+	stored, err := s.refreshTokenRepo.GetByJTI(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if stored.Revoked || stored.UserID != claims.UserID {
+		s.log.Warn("使用已撤销的刷新令牌",
+			logger.String("user_id", claims.UserID),
+			logger.String("jti", claims.ID),
+		)
+		return nil, errors.ErrTokenRevoked
+	}
+	if stored.IsExpired() {
+		return nil, errors.ErrTokenExpired
+	}
+
 	// 获取用户信息
 	user, err := s.userRepo.GetByID(ctx, claims.UserID)
 	if err != nil {
@@ -369,8 +1047,13 @@ func (s *userService) RefreshToken(ctx context.Context, refreshToken string) (*m
 		return nil, errors.ErrUserDisabled
 	}
 
+	// 检查令牌版本，强制下线后旧的刷新令牌不能再使用
+	if claims.TokenVersion != user.TokenVersion {
+		return nil, errors.ErrTokenRevoked
+	}
+
 	// 生成新的访问令牌
-	accessToken, err := s.jwtService.GenerateAccessToken(user)
+	accessToken, err := s.generateAccessToken(ctx, user)
 	if err != nil {
 		s.log.Error("生成访问令牌失败", logger.Err(err))
 		return nil, errors.ErrInternalServer.WithError(err)
@@ -387,11 +1070,143 @@ func (s *userService) RefreshToken(ctx context.Context, refreshToken string) (*m
 	}, nil
 }
 
+// Logout 登出
+// 撤销用户的所有刷新令牌；设置了令牌黑名单时将当前访问令牌加入黑名单，
+// 否则已签发的访问令牌将在过期后自然失效
+func (s *userService) Logout(ctx context.Context, claims *TokenClaims) error {
+	userID := claims.UserID
+	if err := s.refreshTokenRepo.RevokeAllByUser(ctx, userID); err != nil {
+		s.log.Error("撤销刷新令牌失败", logger.Err(err))
+		return err
+	}
+	if err := s.revokeAccessToken(ctx, claims); err != nil {
+		return err
+	}
+
+	s.log.Info("用户登出成功",
+		logger.String("user_id", userID),
+	)
+
+	return nil
+}
+
+// RevokeTokens 强制用户下线
+// 递增令牌版本使已签发的访问令牌失效，并撤销所有刷新令牌与个人访问令牌
+func (s *userService) RevokeTokens(ctx context.Context, userID string) error {
+	if err := s.userRepo.IncrementTokenVersion(ctx, userID); err != nil {
+		s.log.Error("递增令牌版本失败", logger.String("user_id", userID), logger.Err(err))
+		return err
+	}
+	if err := s.refreshTokenRepo.RevokeAllByUser(ctx, userID); err != nil {
+		s.log.Error("撤销刷新令牌失败", logger.String("user_id", userID), logger.Err(err))
+		return err
+	}
+	if err := s.revokePersonalAccessTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	s.log.Warn("用户已被强制下线",
+		logger.String("user_id", userID),
+	)
+	return nil
+}
+
+// RevokeAllTokens 强制所有用户下线
+// 递增全部用户的令牌版本，已签发的访问令牌与刷新令牌都将无法通过校验；
+// 个人访问令牌不携带令牌版本，单独全部撤销
+func (s *userService) RevokeAllTokens(ctx context.Context) (int64, error) {
+	affected, err := s.userRepo.IncrementAllTokenVersions(ctx)
+	if err != nil {
+		s.log.Error("递增全部令牌版本失败", logger.Err(err))
+		return 0, err
+	}
+	if s.personalTokenRepo != nil {
+		if err := s.personalTokenRepo.RevokeAll(ctx); err != nil {
+			s.log.Error("撤销全部个人访问令牌失败", logger.Err(err))
+			return 0, err
+		}
+	}
+
+	s.log.Warn("所有用户已被强制下线",
+		logger.Int64("affected", affected),
+	)
+	return affected, nil
+}
+
+// ValidateTokenVersion 校验令牌版本
+// 版本不一致说明用户已被强制下线，返回 ErrTokenRevoked
+func (s *userService) ValidateTokenVersion(ctx context.Context, userID string, version int) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, errors.ErrUserNotFound) {
+			return errors.ErrTokenRevoked
+		}
+		return err
+	}
+	if user.TokenVersion != version {
+		return errors.ErrTokenRevoked
+	}
+	return nil
+}
+
+// RenewAccessToken 以用户当前的角色与额外声明续签访问令牌
+// 用户已删除、禁用或令牌版本已变化时不续签；会话最长时长由 JWTService 限制
+func (s *userService) RenewAccessToken(ctx context.Context, claims *TokenClaims) (string, error) {
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, errors.ErrUserNotFound) {
+			return "", errors.ErrTokenRevoked
+		}
+		return "", err
+	}
+	if user.IsDisabled() {
+		return "", errors.ErrUserDisabled
+	}
+	if user.TokenVersion != claims.TokenVersion {
+		return "", errors.ErrTokenRevoked
+	}
+
+	var extra map[string]interface{}
+	if s.extraClaims != nil {
+		extra = s.extraClaims(ctx, user)
+	}
+	return s.jwtService.RenewAccessToken(claims, user, extra)
+}
+
 // ValidateToken 验证令牌
 func (s *userService) ValidateToken(ctx context.Context, token string) (*TokenClaims, error) {
 	return s.jwtService.ValidateToken(token)
 }
 
+// generateAccessToken 签发访问令牌，设置了额外声明生成函数时一并注入
+func (s *userService) generateAccessToken(ctx context.Context, user *model.User) (string, error) {
+	if s.extraClaims == nil {
+		return s.jwtService.GenerateAccessToken(user)
+	}
+	return s.jwtService.GenerateAccessTokenWithClaims(user, s.extraClaims(ctx, user))
+}
+
+// issueRefreshToken 签发刷新令牌并入库
+func (s *userService) issueRefreshToken(ctx context.Context, user *model.User) (string, error) {
+	token, claims, err := s.jwtService.IssueRefreshToken(user)
+	if err != nil {
+		s.log.Error("生成刷新令牌失败", logger.Err(err))
+		return "", errors.ErrInternalServer.WithError(err)
+	}
+
+	record := &model.RefreshToken{
+		JTI:       claims.ID,
+		UserID:    user.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+	if err := s.refreshTokenRepo.Create(ctx, record); err != nil {
+		s.log.Error("保存刷新令牌失败", logger.Err(err))
+		return "", err
+	}
+
+	return token, nil
+}
+
 // hashPassword 使用 bcrypt 加密密码
 func (s *userService) hashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), s.config.Security.BcryptCost)
@@ -407,9 +1222,28 @@ func (s *userService) checkPassword(password, hashedPassword string) bool {
 	return err == nil
 }
 
+// getDummyHash 返回用于抹平耗时差异的假哈希
+// 与真实密码使用相同的 bcrypt 成本，比较耗时才与密码错误的路径一致
+func (s *userService) getDummyHash() string {
+	s.dummyHashOnce.Do(func() {
+		hash, err := s.hashPassword("go-user-api-dummy-password")
+		if err != nil {
+			s.log.Warn("生成假密码哈希失败", logger.Err(err))
+			return
+		}
+		s.dummyHash = hash
+	})
+	return s.dummyHash
+}
+
 // CreateAdmin 创建管理员用户（用于初始化）
-// 如果已存在任何用户，则不创建
+// 如果已存在任何用户，则不创建。
+// 用户名按策略校验长度与字符集；保留字用于防止普通用户冒充系统账号，初始管理员可以使用（如 admin）
 func (s *userService) CreateAdmin(ctx context.Context, username, email, password string) (*model.User, error) {
+	if err := checkUsernameFormat(username, s.config.Security.Username); err != nil {
+		return nil, err
+	}
+
 	// 检查是否已存在用户
 	count, err := s.userRepo.Count(ctx)
 	if err != nil {
@@ -441,6 +1275,7 @@ func (s *userService) CreateAdmin(ctx context.Context, username, email, password
 	if err := s.userRepo.Create(ctx, admin); err != nil {
 		return nil, err
 	}
+	s.invalidateUserListCache(ctx)
 
 	s.log.Info("管理员用户创建成功",
 		logger.String("user_id", admin.ID),
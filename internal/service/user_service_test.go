@@ -5,6 +5,8 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -15,6 +17,8 @@ import (
 	"github.com/example/go-user-api/pkg/logger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // ============================================================
@@ -39,6 +43,14 @@ func (m *MockUserRepository) GetByID(ctx context.Context, id string) (*model.Use
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetByIDs(ctx context.Context, ids []string) ([]model.User, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.User), args.Error(1)
+}
+
 func (m *MockUserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	args := m.Called(ctx, username)
 	if args.Get(0) == nil {
@@ -73,14 +85,42 @@ func (m *MockUserRepository) UpdateFields(ctx context.Context, id string, fields
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) UpdateRoleBatch(ctx context.Context, ids []string, role string) (int64, error) {
+	args := m.Called(ctx, ids, role)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockUserRepository) Delete(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) HardDelete(ctx context.Context, id string) error {
+func (m *MockUserRepository) HardDelete(ctx context.Context, id string) (*model.User, error) {
 	args := m.Called(ctx, id)
-	return args.Error(0)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) ([]model.User, error) {
+	args := m.Called(ctx, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindInBatches(ctx context.Context, opts *repository.UserListOptions, batchSize int, fn func(batch []model.User) error) error {
+	args := m.Called(ctx, opts, batchSize)
+	if batches, ok := args.Get(0).([][]model.User); ok {
+		for _, batch := range batches {
+			if err := fn(batch); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
 }
 
 func (m *MockUserRepository) List(ctx context.Context, opts *repository.UserListOptions) ([]model.User, int64, error) {
@@ -116,6 +156,74 @@ func (m *MockUserRepository) UpdateLastLogin(ctx context.Context, id string, ip
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) ExplainList(ctx context.Context, opts *repository.UserListOptions) (*model.QueryPlan, error) {
+	args := m.Called(ctx, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.QueryPlan), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateLastActive(ctx context.Context, id string, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) IncrementTokenVersion(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) IncrementAllTokenVersions(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// ============================================================
+// Mock 刷新令牌仓储
+// ============================================================
+
+// MockRefreshTokenRepository 是 RefreshTokenRepository 接口的模拟实现
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) GetByJTI(ctx context.Context, jti string) (*model.RefreshToken, error) {
+	args := m.Called(ctx, jti)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) DeleteInactiveBefore(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeAllByUser(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) CountActiveByUser(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) ListByUser(ctx context.Context, userID string) ([]model.RefreshToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RefreshToken), args.Error(1)
+}
+
 // ============================================================
 // 测试辅助函数
 // ============================================================
@@ -135,7 +243,16 @@ func newTestConfig() *config.Config {
 			RefreshTokenExpire: 168,
 		},
 		Security: config.SecurityConfig{
-			BcryptCost: 4, // 使用较低的成本加快测试速度
+			BcryptCost:   4, // 使用较低的成本加快测试速度
+			RequireEmail: true,
+			Registration: config.RegistrationConfig{Enabled: true},
+
+			OnlineThresholdMinutes: 5,
+			Username: config.UsernamePolicyConfig{
+				MinLength: 3,
+				MaxLength: 30,
+				Reserved:  []string{"admin", "root", "api"},
+			},
 		},
 		Pagination: config.PaginationConfig{
 			DefaultPageSize: 20,
@@ -177,10 +294,11 @@ func newTestUser() *model.User {
 func TestUserService_Register_Success(t *testing.T) {
 	// 准备
 	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
 	cfg := newTestConfig()
 	log := newTestLogger()
 	jwtService := NewJWTService(&cfg.JWT)
-	userService := NewUserService(mockRepo, jwtService, cfg, log)
+	userService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
 
 	ctx := context.Background()
 	req := &model.RegisterRequest{
@@ -211,13 +329,107 @@ func TestUserService_Register_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUserService_Register_RecordsSource(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, mockTokenRepo, NewJWTService(&cfg.JWT), cfg, newTestLogger())
+	ctx := context.Background()
+	req := &model.RegisterRequest{
+		Username:        "newuser",
+		Email:           "new@example.com",
+		Password:        "password123",
+		ConfirmPassword: "password123",
+		Source:          " Newsletter ",
+		ReferralCode:    "FRIEND2024",
+	}
+
+	// 设置 mock 期望
+	var saved *model.User
+	mockRepo.On("ExistsByUsername", ctx, "newuser").Return(false, nil)
+	mockRepo.On("ExistsByEmail", ctx, "new@example.com").Return(false, nil)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*model.User) }).
+		Return(nil)
+
+	// 执行
+	_, err := userService.Register(ctx, req)
+
+	// 断言：来源规范化为小写后保存
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.Equal(t, "newsletter", saved.Source)
+	assert.Equal(t, "FRIEND2024", saved.ReferralCode)
+}
+
+func TestUserService_List_FilterBySource(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, mockTokenRepo, NewJWTService(&cfg.JWT), cfg, newTestLogger())
+	ctx := context.Background()
+
+	// 设置 mock 期望：来源按规范化后的值过滤
+	mockRepo.On("List", ctx, mock.MatchedBy(func(opts *repository.UserListOptions) bool {
+		return opts.Source == "newsletter"
+	})).Return([]model.User{*newTestUser()}, int64(1), nil)
+
+	// 执行
+	_, total, err := userService.List(ctx, &model.UserListRequest{Source: "Newsletter"})
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_List_FilterByLastLogin(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+	ctx := context.Background()
+	cutoff := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+
+	// 设置 mock 期望：RFC3339 时间解析后传给仓储
+	mockRepo.On("List", ctx, mock.MatchedBy(func(opts *repository.UserListOptions) bool {
+		return opts.LastLoginBefore != nil && opts.LastLoginBefore.Equal(cutoff) && opts.LastLoginAfter == nil
+	})).Return([]model.User{*newTestUser()}, int64(1), nil)
+
+	// 执行
+	_, total, err := userService.List(ctx, &model.UserListRequest{LastLoginBefore: "2024-05-02T00:00:00Z", SortBy: "last_login_at"})
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_List_InvalidLastLoginTime(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	// 执行
+	_, _, err := userService.List(context.Background(), &model.UserListRequest{LastLoginAfter: "2024-05-02"})
+
+	// 断言：返回校验错误，不访问仓储
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrValidation))
+	mockRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+}
+
 func TestUserService_Register_UsernameExists(t *testing.T) {
 	// 准备
 	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
 	cfg := newTestConfig()
 	log := newTestLogger()
 	jwtService := NewJWTService(&cfg.JWT)
-	userService := NewUserService(mockRepo, jwtService, cfg, log)
+	userService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
 
 	ctx := context.Background()
 	req := &model.RegisterRequest{
@@ -241,13 +453,79 @@ func TestUserService_Register_UsernameExists(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUserService_Register_WithoutEmail(t *testing.T) {
+	// 准备：关闭邮箱必填
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	cfg := newTestConfig()
+	cfg.Security.RequireEmail = false
+	log := newTestLogger()
+	jwtService := NewJWTService(&cfg.JWT)
+	userService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
+
+	ctx := context.Background()
+	req := &model.RegisterRequest{
+		Username:        "noemail",
+		Password:        "password123",
+		ConfirmPassword: "password123",
+	}
+
+	// 设置 mock 期望：不应检查邮箱唯一性
+	mockRepo.On("ExistsByUsername", ctx, "noemail").Return(false, nil)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(nil)
+
+	// 执行
+	user, err := userService.Register(ctx, req)
+
+	// 断言
+	assert.NoError(t, err)
+	assert.NotNil(t, user)
+	assert.Empty(t, user.Email)
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "ExistsByEmail", mock.Anything, mock.Anything)
+}
+
+func TestUserService_Register_EmailRequired(t *testing.T) {
+	// 准备：默认要求邮箱
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	cfg := newTestConfig()
+	log := newTestLogger()
+	jwtService := NewJWTService(&cfg.JWT)
+	userService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
+
+	ctx := context.Background()
+	req := &model.RegisterRequest{
+		Username:        "noemail",
+		Password:        "password123",
+		ConfirmPassword: "password123",
+	}
+
+	// 设置 mock 期望
+	mockRepo.On("ExistsByUsername", ctx, "noemail").Return(false, nil)
+
+	// 执行
+	user, err := userService.Register(ctx, req)
+
+	// 断言
+	assert.Error(t, err)
+	assert.Nil(t, user)
+	appErr := errors.AsAppError(err)
+	assert.NotNil(t, appErr)
+	assert.Equal(t, errors.CodeValidation, appErr.Code)
+
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
 func TestUserService_Register_EmailExists(t *testing.T) {
 	// 准备
 	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
 	cfg := newTestConfig()
 	log := newTestLogger()
 	jwtService := NewJWTService(&cfg.JWT)
-	userService := NewUserService(mockRepo, jwtService, cfg, log)
+	userService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
 
 	ctx := context.Background()
 	req := &model.RegisterRequest{
@@ -272,6 +550,57 @@ func TestUserService_Register_EmailExists(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUserService_Register_ConcurrentSameEmail(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	cfg := newTestConfig()
+	log := newTestLogger()
+	jwtService := NewJWTService(&cfg.JWT)
+	userService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
+
+	ctx := context.Background()
+
+	// 设置 mock 期望：两个请求的前置检查都通过，由唯一索引决定胜负
+	mockRepo.On("ExistsByUsername", ctx, mock.Anything).Return(false, nil)
+	mockRepo.On("ExistsByEmail", ctx, "race@example.com").Return(false, nil)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(nil).Once()
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(errors.ErrEmailAlreadyUsed).Once()
+
+	// 执行
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = userService.Register(ctx, &model.RegisterRequest{
+				Username:        fmt.Sprintf("racer%d", i),
+				Email:           "race@example.com",
+				Password:        "password123",
+				ConfirmPassword: "password123",
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	// 断言：只有一个成功，另一个得到明确的邮箱冲突错误
+	var succeeded, conflicted int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, errors.ErrEmailAlreadyUsed):
+			conflicted++
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+	assert.Equal(t, 1, conflicted)
+	mockRepo.AssertExpectations(t)
+}
+
 // ============================================================
 // 登录测试
 // ============================================================
@@ -279,10 +608,11 @@ func TestUserService_Register_EmailExists(t *testing.T) {
 func TestUserService_Login_Success(t *testing.T) {
 	// 准备
 	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
 	cfg := newTestConfig()
 	log := newTestLogger()
 	jwtService := NewJWTService(&cfg.JWT)
-	usrService := NewUserService(mockRepo, jwtService, cfg, log)
+	usrService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
 
 	ctx := context.Background()
 
@@ -311,6 +641,7 @@ func TestUserService_Login_Success(t *testing.T) {
 	// 设置 mock 期望
 	mockRepo.On("GetByUsernameOrEmail", ctx, "testuser").Return(testUser, nil)
 	mockRepo.On("UpdateLastLogin", ctx, "test-user-id", "127.0.0.1").Return(nil)
+	mockTokenRepo.On("Create", ctx, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
 
 	// 执行
 	resp, err := usrService.Login(ctx, req, "127.0.0.1")
@@ -325,15 +656,156 @@ func TestUserService_Login_Success(t *testing.T) {
 	assert.Equal(t, "testuser", resp.User.Username)
 
 	mockRepo.AssertExpectations(t)
+	mockTokenRepo.AssertExpectations(t)
+}
+
+func TestUserService_Login_ReturnsPermissions(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "管理员包含管理权限",
+			role:     model.RoleAdmin,
+			contains: []string{model.PermissionProfileRead, model.PermissionUsersList, model.PermissionUsersDelete},
+		},
+		{
+			name:     "普通用户只有基础权限",
+			role:     model.RoleUser,
+			contains: []string{model.PermissionProfileRead, model.PermissionPasswordChange},
+			excludes: []string{model.PermissionUsersList, model.PermissionUsersDelete},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 准备
+			mockRepo := new(MockUserRepository)
+			mockTokenRepo := new(MockRefreshTokenRepository)
+			cfg := newTestConfig()
+			svc := NewUserService(mockRepo, mockTokenRepo, NewJWTService(&cfg.JWT), cfg, newTestLogger()).(*userService)
+			ctx := context.Background()
+
+			user := newTestUser()
+			user.Role = tt.role
+			user.Password, _ = svc.hashPassword("password123")
+
+			// 设置 mock 期望
+			mockRepo.On("GetByUsernameOrEmail", ctx, "testuser").Return(user, nil)
+			mockRepo.On("UpdateLastLogin", ctx, user.ID, "127.0.0.1").Return(nil)
+			mockTokenRepo.On("Create", ctx, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+
+			// 执行
+			resp, err := svc.Login(ctx, &model.LoginRequest{Username: "testuser", Password: "password123"}, "127.0.0.1")
+
+			// 断言
+			require.NoError(t, err)
+			for _, p := range tt.contains {
+				assert.Contains(t, resp.Permissions, p)
+			}
+			for _, p := range tt.excludes {
+				assert.NotContains(t, resp.Permissions, p)
+			}
+		})
+	}
+}
+
+func TestUserService_Login_FirstLogin(t *testing.T) {
+	// 准备：全新用户，从未登录过
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	cfg := newTestConfig()
+	svc := NewUserService(mockRepo, mockTokenRepo, NewJWTService(&cfg.JWT), cfg, newTestLogger()).(*userService)
+	ctx := context.Background()
+
+	user := newTestUser()
+	user.LastLoginAt = nil
+	user.Password, _ = svc.hashPassword("password123")
+	req := &model.LoginRequest{Username: "testuser", Password: "password123"}
+
+	// 设置 mock 期望：UpdateLastLogin 写入登录时间，下次读取时可见
+	mockRepo.On("GetByUsernameOrEmail", ctx, "testuser").Return(user, nil)
+	mockRepo.On("UpdateLastLogin", ctx, user.ID, "127.0.0.1").
+		Run(func(mock.Arguments) {
+			now := time.Now()
+			user.LastLoginAt = &now
+		}).
+		Return(nil)
+	mockTokenRepo.On("Create", ctx, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+
+	// 执行：连续登录两次
+	first, err := svc.Login(ctx, req, "127.0.0.1")
+	require.NoError(t, err)
+	second, err := svc.Login(ctx, req, "127.0.0.1")
+	require.NoError(t, err)
+
+	// 断言
+	assert.True(t, first.FirstLogin)
+	assert.False(t, second.FirstLogin)
+	mockRepo.AssertNumberOfCalls(t, "UpdateLastLogin", 2)
+}
+
+func TestUserService_Login_InjectsExtraClaims(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	cfg := newTestConfig()
+	jwtService := NewJWTService(&cfg.JWT)
+	svc := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, newTestLogger(),
+		WithExtraClaims(func(_ context.Context, u *model.User) map[string]interface{} {
+			return map[string]interface{}{"department": "risk", "uid": u.ID}
+		}),
+	).(*userService)
+	ctx := context.Background()
+
+	user := newTestUser()
+	user.Password, _ = svc.hashPassword("password123")
+
+	// 设置 mock 期望
+	mockRepo.On("GetByUsernameOrEmail", ctx, "testuser").Return(user, nil)
+	mockRepo.On("UpdateLastLogin", ctx, user.ID, "127.0.0.1").Return(nil)
+	mockTokenRepo.On("Create", ctx, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+
+	// 执行
+	resp, err := svc.Login(ctx, &model.LoginRequest{Username: "testuser", Password: "password123"}, "127.0.0.1")
+	require.NoError(t, err)
+	claims, err := jwtService.ValidateToken(resp.AccessToken)
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+	assert.Equal(t, "risk", claims.Extra["department"])
+	assert.Equal(t, user.ID, claims.Extra["uid"])
+}
+
+func TestConfiguredExtraClaims(t *testing.T) {
+	user := newTestUser()
+	user.Nickname = "Tester"
+	user.Source = "wechat"
+
+	// 配置为空时不注入
+	assert.Nil(t, ConfiguredExtraClaims(config.ExtraClaimsConfig{}))
+
+	// 固定声明加用户字段
+	fn := ConfiguredExtraClaims(config.ExtraClaimsConfig{
+		Static:     map[string]string{"tenant": "acme"},
+		UserFields: []string{"nickname", "source"},
+	})
+	require.NotNil(t, fn)
+	assert.Equal(t, map[string]interface{}{"tenant": "acme", "nickname": "Tester", "source": "wechat"},
+		fn(context.Background(), user))
 }
 
 func TestUserService_Login_UserNotFound(t *testing.T) {
 	// 准备
 	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
 	cfg := newTestConfig()
 	log := newTestLogger()
 	jwtService := NewJWTService(&cfg.JWT)
-	userService := NewUserService(mockRepo, jwtService, cfg, log)
+	userService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
 
 	ctx := context.Background()
 	req := &model.LoginRequest{
@@ -358,10 +830,11 @@ func TestUserService_Login_UserNotFound(t *testing.T) {
 func TestUserService_Login_WrongPassword(t *testing.T) {
 	// 准备
 	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
 	cfg := newTestConfig()
 	log := newTestLogger()
 	jwtService := NewJWTService(&cfg.JWT)
-	usrService := NewUserService(mockRepo, jwtService, cfg, log)
+	usrService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
 
 	ctx := context.Background()
 
@@ -396,13 +869,82 @@ func TestUserService_Login_WrongPassword(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUserService_Login_UserNotFound_ComparesDummyHash(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	usrService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+	svc := usrService.(*userService)
+
+	ctx := context.Background()
+	req := &model.LoginRequest{Username: "nonexistent", Password: "password123"}
+
+	// 设置 mock 期望
+	mockRepo.On("GetByUsernameOrEmail", ctx, "nonexistent").Return(nil, errors.ErrUserNotFound)
+
+	// 执行
+	_, err := usrService.Login(ctx, req, "127.0.0.1")
+
+	// 断言：用户不存在路径生成并比较了与配置成本一致的假哈希
+	assert.Equal(t, errors.ErrInvalidCredential, err)
+	require.NotEmpty(t, svc.dummyHash)
+	cost, err := bcrypt.Cost([]byte(svc.dummyHash))
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Security.BcryptCost, cost)
+}
+
+func TestUserService_Login_TimingParity(t *testing.T) {
+	if testing.Short() {
+		t.Skip("耗时测试，-short 模式下跳过")
+	}
+
+	// 准备：使用默认成本，使 bcrypt 耗时远大于其他开销
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	cfg.Security.BcryptCost = bcrypt.DefaultCost
+	usrService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+	svc := usrService.(*userService)
+
+	hashedPassword, err := svc.hashPassword("correctpassword")
+	require.NoError(t, err)
+	testUser := &model.User{
+		BaseModel: model.BaseModel{ID: "test-user-id"},
+		Username:  "testuser",
+		Password:  hashedPassword,
+		Status:    model.UserStatusActive,
+	}
+
+	ctx := context.Background()
+
+	// 设置 mock 期望
+	mockRepo.On("GetByUsernameOrEmail", ctx, "testuser").Return(testUser, nil)
+	mockRepo.On("GetByUsernameOrEmail", ctx, "nonexistent").Return(nil, errors.ErrUserNotFound)
+
+	measure := func(username string) time.Duration {
+		start := time.Now()
+		_, err := usrService.Login(ctx, &model.LoginRequest{Username: username, Password: "wrongpassword"}, "127.0.0.1")
+		assert.Equal(t, errors.ErrInvalidCredential, err)
+		return time.Since(start)
+	}
+
+	// 执行：先预热一次以排除假哈希的生成耗时
+	measure("nonexistent")
+	notFound := measure("nonexistent")
+	wrongPassword := measure("testuser")
+
+	// 断言：两条路径耗时处于同一量级
+	assert.Greater(t, notFound, wrongPassword/2, "用户不存在: %v, 密码错误: %v", notFound, wrongPassword)
+	assert.Greater(t, wrongPassword, notFound/2, "用户不存在: %v, 密码错误: %v", notFound, wrongPassword)
+}
+
 func TestUserService_Login_UserDisabled(t *testing.T) {
 	// 准备
 	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
 	cfg := newTestConfig()
 	log := newTestLogger()
 	jwtService := NewJWTService(&cfg.JWT)
-	userService := NewUserService(mockRepo, jwtService, cfg, log)
+	userService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
 
 	ctx := context.Background()
 	testUser := &model.User{
@@ -439,10 +981,11 @@ func TestUserService_Login_UserDisabled(t *testing.T) {
 func TestUserService_GetByID_Success(t *testing.T) {
 	// 准备
 	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
 	cfg := newTestConfig()
 	log := newTestLogger()
 	jwtService := NewJWTService(&cfg.JWT)
-	userService := NewUserService(mockRepo, jwtService, cfg, log)
+	userService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
 
 	ctx := context.Background()
 	testUser := newTestUser()
@@ -465,10 +1008,11 @@ func TestUserService_GetByID_Success(t *testing.T) {
 func TestUserService_GetByID_NotFound(t *testing.T) {
 	// 准备
 	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
 	cfg := newTestConfig()
 	log := newTestLogger()
 	jwtService := NewJWTService(&cfg.JWT)
-	userService := NewUserService(mockRepo, jwtService, cfg, log)
+	userService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
 
 	ctx := context.Background()
 
@@ -486,17 +1030,256 @@ func TestUserService_GetByID_NotFound(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUserService_GetManyByIDs_PartialFound(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	ctx := context.Background()
+	u1 := model.User{BaseModel: model.BaseModel{ID: "u1"}, Username: "alice"}
+	u3 := model.User{BaseModel: model.BaseModel{ID: "u3"}, Username: "carol"}
+
+	// 设置 mock 期望：重复的 ID 只查询一次，仓储返回顺序与请求不同
+	mockRepo.On("GetByIDs", ctx, []string{"u3", "u2", "u1"}).Return([]model.User{u1, u3}, nil)
+
+	// 执行
+	users, missing, err := userService.GetManyByIDs(ctx, []string{"u3", "u2", "u3", "u1"}, nil)
+
+	// 断言：只返回存在的用户，按请求顺序排列，并报告缺失的 ID
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "u3", users[0].ID)
+	assert.Equal(t, "u1", users[1].ID)
+	assert.Equal(t, []string{"u2"}, missing)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_GetManyByIDs_TooMany(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	ids := make([]string, model.MaxBatchGetUsers+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("id-%d", i)
+	}
+
+	// 执行
+	users, missing, err := userService.GetManyByIDs(context.Background(), ids, nil)
+
+	// 断言
+	assert.True(t, errors.Is(err, errors.ErrValidation))
+	assert.Nil(t, users)
+	assert.Nil(t, missing)
+	mockRepo.AssertNotCalled(t, "GetByIDs", mock.Anything, mock.Anything)
+}
+
+func TestUserService_BatchUpdateRole_Success(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	ctx := context.Background()
+	u1 := model.User{BaseModel: model.BaseModel{ID: "u1"}, Role: model.RoleUser}
+	u2 := model.User{BaseModel: model.BaseModel{ID: "u2"}, Role: model.RoleAdmin}
+
+	// 设置 mock 期望：重复的 ID 只处理一次
+	mockRepo.On("GetByIDs", ctx, []string{"u1", "u2"}).Return([]model.User{u1, u2}, nil)
+	mockRepo.On("UpdateRoleBatch", ctx, []string{"u1", "u2"}, model.RoleAdmin).Return(int64(1), nil)
+
+	// 执行
+	var progress []model.BatchProgress
+	result, err := userService.BatchUpdateRole(ctx, &model.BatchUpdateRoleRequest{
+		IDs:  []string{"u1", "u2", "u1"},
+		Role: model.RoleAdmin,
+	}, "admin-id", func(p model.BatchProgress) {
+		progress = append(progress, p)
+	})
+
+	// 断言：每个用户回调一次进度
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, result.Role)
+	assert.Equal(t, 2, result.Total)
+	assert.Equal(t, int64(1), result.Updated)
+	assert.Equal(t, []model.BatchProgress{
+		{Processed: 1, Total: 2, Succeeded: 1},
+		{Processed: 2, Total: 2, Succeeded: 2},
+	}, progress)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_BatchUpdateStatus_PartialFailure(t *testing.T) {
+	// 准备：u2 不存在
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	ctx := context.Background()
+	u1 := &model.User{BaseModel: model.BaseModel{ID: "u1"}, Username: "alice", Status: model.UserStatusActive}
+	disabled := *u1
+	disabled.Status = model.UserStatusDisabled
+	mockRepo.On("GetByID", ctx, "u1").Return(u1, nil).Once()
+	mockRepo.On("UpdateFields", ctx, "u1", mock.Anything).Return(nil)
+	mockRepo.On("IncrementTokenVersion", ctx, "u1").Return(nil)
+	mockRepo.On("GetByID", ctx, "u1").Return(&disabled, nil)
+	mockRepo.On("GetByID", ctx, "u2").Return(nil, errors.ErrUserNotFound)
+
+	// 执行
+	status := model.UserStatusDisabled
+	var progress []model.BatchProgress
+	result, err := userService.BatchUpdateStatus(ctx, &model.BatchUpdateStatusRequest{
+		IDs:    []string{"u1", "u2"},
+		Status: &status,
+	}, "admin-id", func(p model.BatchProgress) {
+		progress = append(progress, p)
+	})
+
+	// 断言：u1 成功，u2 失败且不影响 u1；每个用户回调一次进度
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Total)
+	assert.Equal(t, 1, result.Succeeded)
+	assert.Equal(t, 1, result.Failed)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, "u2", result.Errors[0].ID)
+	assert.Equal(t, 2, result.Errors[0].Row)
+
+	require.Len(t, progress, 2)
+	assert.Nil(t, progress[0].Error)
+	require.NotNil(t, progress[1].Error)
+	assert.Equal(t, 1, progress[1].Failed)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_BatchUpdateStatus_CannotDisableSelf(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	// 执行
+	status := model.UserStatusDisabled
+	result, err := userService.BatchUpdateStatus(context.Background(), &model.BatchUpdateStatusRequest{
+		IDs:    []string{"u1", "admin-id"},
+		Status: &status,
+	}, "admin-id", nil)
+
+	// 断言
+	assert.True(t, errors.Is(err, errors.ErrBadRequest))
+	assert.Nil(t, result)
+	mockRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+func TestUserService_BatchUpdateRole_InvalidRole(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	// 执行
+	result, err := userService.BatchUpdateRole(context.Background(), &model.BatchUpdateRoleRequest{
+		IDs:  []string{"u1"},
+		Role: "superuser",
+	}, "admin-id", nil)
+
+	// 断言
+	assert.True(t, errors.Is(err, errors.ErrValidation))
+	assert.Nil(t, result)
+	mockRepo.AssertNotCalled(t, "UpdateRoleBatch", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserService_BatchUpdateRole_CannotDemoteSelf(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	// 执行
+	result, err := userService.BatchUpdateRole(context.Background(), &model.BatchUpdateRoleRequest{
+		IDs:  []string{"u1", "admin-id"},
+		Role: model.RoleUser,
+	}, "admin-id", nil)
+
+	// 断言
+	assert.True(t, errors.Is(err, errors.ErrBadRequest))
+	assert.Nil(t, result)
+	mockRepo.AssertNotCalled(t, "UpdateRoleBatch", mock.Anything, mock.Anything, mock.Anything)
+}
+
 // ============================================================
 // 更新用户测试
 // ============================================================
 
+func TestUserService_List_ContextCanceled(t *testing.T) {
+	// 准备：客户端已断开
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, mockTokenRepo, NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// 执行
+	users, total, err := userService.List(ctx, &model.UserListRequest{})
+
+	// 断言：提前返回 context.Canceled，不访问仓储
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, users)
+	assert.Zero(t, total)
+	mockRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+}
+
+func TestUserService_List_OffsetExceeded(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	cfg.Pagination.MaxOffset = 1000
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	// 执行：offset = (52-1)*20 = 1020 > 1000
+	users, total, err := userService.List(context.Background(), &model.UserListRequest{Page: 52, PageSize: 20})
+
+	// 断言：返回 400 并建议改用游标分页，不访问仓储
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrBadRequest))
+	appErr := errors.AsAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, 400, appErr.HTTPStatus)
+	assert.Contains(t, appErr.Detail, "游标分页")
+	assert.Nil(t, users)
+	assert.Zero(t, total)
+	mockRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+}
+
+func TestUserService_List_OffsetAtLimitAllowed(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	cfg.Pagination.MaxOffset = 1000
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+
+	// 设置 mock 期望
+	mockRepo.On("List", mock.Anything, mock.AnythingOfType("*repository.UserListOptions")).Return([]model.User{}, int64(0), nil)
+
+	// 执行：offset = (51-1)*20 = 1000，恰好等于上限
+	_, _, err := userService.List(context.Background(), &model.UserListRequest{Page: 51, PageSize: 20})
+
+	// 断言
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestUserService_Update_Success(t *testing.T) {
 	// 准备
 	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
 	cfg := newTestConfig()
 	log := newTestLogger()
 	jwtService := NewJWTService(&cfg.JWT)
-	userService := NewUserService(mockRepo, jwtService, cfg, log)
+	userService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
 
 	ctx := context.Background()
 	testUser := newTestUser()
@@ -530,10 +1313,11 @@ func TestUserService_Update_Success(t *testing.T) {
 func TestUserService_Delete_Success(t *testing.T) {
 	// 准备
 	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
 	cfg := newTestConfig()
 	log := newTestLogger()
 	jwtService := NewJWTService(&cfg.JWT)
-	userService := NewUserService(mockRepo, jwtService, cfg, log)
+	userService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
 
 	ctx := context.Background()
 	testUser := newTestUser()
@@ -554,10 +1338,11 @@ func TestUserService_Delete_Success(t *testing.T) {
 func TestUserService_Delete_NotFound(t *testing.T) {
 	// 准备
 	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
 	cfg := newTestConfig()
 	log := newTestLogger()
 	jwtService := NewJWTService(&cfg.JWT)
-	userService := NewUserService(mockRepo, jwtService, cfg, log)
+	userService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
 
 	ctx := context.Background()
 
@@ -581,10 +1366,11 @@ func TestUserService_Delete_NotFound(t *testing.T) {
 func TestUserService_UpdatePassword_Success(t *testing.T) {
 	// 准备
 	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
 	cfg := newTestConfig()
 	log := newTestLogger()
 	jwtService := NewJWTService(&cfg.JWT)
-	usrService := NewUserService(mockRepo, jwtService, cfg, log)
+	usrService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
 
 	ctx := context.Background()
 
@@ -609,6 +1395,7 @@ func TestUserService_UpdatePassword_Success(t *testing.T) {
 	// 设置 mock 期望
 	mockRepo.On("GetByID", ctx, "test-user-id").Return(testUser, nil)
 	mockRepo.On("UpdatePassword", ctx, "test-user-id", mock.AnythingOfType("string")).Return(nil)
+	mockTokenRepo.On("RevokeAllByUser", ctx, "test-user-id").Return(nil)
 
 	// 执行
 	err := usrService.UpdatePassword(ctx, "test-user-id", req)
@@ -617,15 +1404,17 @@ func TestUserService_UpdatePassword_Success(t *testing.T) {
 	assert.NoError(t, err)
 
 	mockRepo.AssertExpectations(t)
+	mockTokenRepo.AssertExpectations(t)
 }
 
 func TestUserService_UpdatePassword_WrongOldPassword(t *testing.T) {
 	// 准备
 	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
 	cfg := newTestConfig()
 	log := newTestLogger()
 	jwtService := NewJWTService(&cfg.JWT)
-	usrService := NewUserService(mockRepo, jwtService, cfg, log)
+	usrService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
 
 	ctx := context.Background()
 
@@ -659,3 +1448,214 @@ func TestUserService_UpdatePassword_WrongOldPassword(t *testing.T) {
 
 	mockRepo.AssertExpectations(t)
 }
+
+// ============================================================
+// 刷新令牌测试
+// ============================================================
+
+func TestUserService_RefreshToken_Success(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	cfg := newTestConfig()
+	log := newTestLogger()
+	jwtService := NewJWTService(&cfg.JWT)
+	userService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
+
+	ctx := context.Background()
+	testUser := newTestUser()
+	refreshToken, claims, err := jwtService.IssueRefreshToken(testUser)
+	assert.NoError(t, err)
+
+	stored := &model.RefreshToken{
+		JTI:       claims.ID,
+		UserID:    testUser.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+
+	// 设置 mock 期望
+	mockTokenRepo.On("GetByJTI", ctx, claims.ID).Return(stored, nil)
+	mockRepo.On("GetByID", ctx, testUser.ID).Return(testUser, nil)
+
+	// 执行
+	resp, err := userService.RefreshToken(ctx, refreshToken)
+
+	// 断言
+	assert.NoError(t, err)
+	assert.NotNil(t, resp)
+	assert.NotEmpty(t, resp.AccessToken)
+
+	mockRepo.AssertExpectations(t)
+	mockTokenRepo.AssertExpectations(t)
+}
+
+func TestUserService_RefreshToken_RevokedAfterLogout(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	cfg := newTestConfig()
+	log := newTestLogger()
+	jwtService := NewJWTService(&cfg.JWT)
+	userService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
+
+	ctx := context.Background()
+	testUser := newTestUser()
+	refreshToken, claims, err := jwtService.IssueRefreshToken(testUser)
+	assert.NoError(t, err)
+
+	stored := &model.RefreshToken{
+		JTI:       claims.ID,
+		UserID:    testUser.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+
+	// 登出时将该用户的刷新令牌标记为已撤销
+	mockTokenRepo.On("RevokeAllByUser", ctx, testUser.ID).Return(nil).Run(func(mock.Arguments) {
+		stored.Revoked = true
+	})
+	mockTokenRepo.On("GetByJTI", ctx, claims.ID).Return(stored, nil)
+
+	// 执行
+	assert.NoError(t, userService.Logout(ctx, &TokenClaims{UserID: testUser.ID}))
+	resp, err := userService.RefreshToken(ctx, refreshToken)
+
+	// 断言
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+	assert.Equal(t, errors.ErrTokenRevoked, err)
+
+	mockRepo.AssertExpectations(t)
+	mockTokenRepo.AssertExpectations(t)
+}
+
+// ============================================================
+// 强制下线测试
+// ============================================================
+
+func TestUserService_RevokeTokens_InvalidatesOldTokens(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	cfg := newTestConfig()
+	log := newTestLogger()
+	jwtService := NewJWTService(&cfg.JWT)
+	usrService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
+
+	ctx := context.Background()
+	svc := usrService.(*userService)
+	hashedPassword, _ := svc.hashPassword("password123")
+	testUser := newTestUser()
+	testUser.Password = hashedPassword
+	req := &model.LoginRequest{Username: "testuser", Password: "password123"}
+
+	// 设置 mock 期望：递增版本时修改用户数据
+	mockRepo.On("GetByUsernameOrEmail", ctx, "testuser").Return(testUser, nil)
+	mockRepo.On("GetByID", ctx, testUser.ID).Return(testUser, nil)
+	mockRepo.On("UpdateLastLogin", ctx, testUser.ID, "127.0.0.1").Return(nil)
+	mockRepo.On("IncrementTokenVersion", ctx, testUser.ID).Return(nil).Run(func(mock.Arguments) {
+		testUser.TokenVersion++
+	})
+	mockTokenRepo.On("Create", ctx, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+	mockTokenRepo.On("RevokeAllByUser", ctx, testUser.ID).Return(nil)
+
+	// 执行：登录后强制下线
+	oldResp, err := usrService.Login(ctx, req, "127.0.0.1")
+	assert.NoError(t, err)
+	oldClaims, err := jwtService.ValidateToken(oldResp.AccessToken)
+	assert.NoError(t, err)
+	assert.NoError(t, usrService.ValidateTokenVersion(ctx, testUser.ID, oldClaims.TokenVersion))
+
+	assert.NoError(t, usrService.RevokeTokens(ctx, testUser.ID))
+
+	// 断言：旧令牌失效
+	err = usrService.ValidateTokenVersion(ctx, testUser.ID, oldClaims.TokenVersion)
+	assert.Equal(t, errors.ErrTokenRevoked, err)
+
+	// 断言：重新登录获得的令牌有效
+	newResp, err := usrService.Login(ctx, req, "127.0.0.1")
+	assert.NoError(t, err)
+	newClaims, err := jwtService.ValidateToken(newResp.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, oldClaims.TokenVersion+1, newClaims.TokenVersion)
+	assert.NoError(t, usrService.ValidateTokenVersion(ctx, testUser.ID, newClaims.TokenVersion))
+
+	mockRepo.AssertExpectations(t)
+	mockTokenRepo.AssertExpectations(t)
+}
+
+func TestUserService_RenewAccessToken_ReloadsUser(t *testing.T) {
+	// 准备：令牌以普通用户身份签发，之后用户被提升为管理员
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	jwtService := NewJWTService(&cfg.JWT)
+	usrService := NewUserService(mockRepo, new(MockRefreshTokenRepository), jwtService, cfg, newTestLogger())
+
+	ctx := context.Background()
+	testUser := newTestUser()
+	token, err := jwtService.GenerateAccessToken(testUser)
+	require.NoError(t, err)
+	claims, err := jwtService.ValidateToken(token)
+	require.NoError(t, err)
+
+	promoted := newTestUser()
+	promoted.Role = model.RoleAdmin
+	mockRepo.On("GetByID", ctx, testUser.ID).Return(promoted, nil).Once()
+
+	// 执行
+	renewed, err := usrService.RenewAccessToken(ctx, claims)
+
+	// 断言：续签的令牌使用数据库中的当前角色
+	require.NoError(t, err)
+	renewedClaims, err := jwtService.ValidateToken(renewed)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, renewedClaims.Role)
+
+	// 用户被禁用后不再续签
+	disabled := newTestUser()
+	disabled.Status = model.UserStatusDisabled
+	mockRepo.On("GetByID", ctx, testUser.ID).Return(disabled, nil).Once()
+	_, err = usrService.RenewAccessToken(ctx, claims)
+	assert.ErrorIs(t, err, errors.ErrUserDisabled)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_RefreshToken_RejectedAfterRevokeAll(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	cfg := newTestConfig()
+	log := newTestLogger()
+	jwtService := NewJWTService(&cfg.JWT)
+	userService := NewUserService(mockRepo, mockTokenRepo, jwtService, cfg, log)
+
+	ctx := context.Background()
+	testUser := newTestUser()
+	refreshToken, claims, err := jwtService.IssueRefreshToken(testUser)
+	assert.NoError(t, err)
+	stored := &model.RefreshToken{
+		JTI:       claims.ID,
+		UserID:    testUser.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+
+	// 设置 mock 期望：全体递增版本
+	mockRepo.On("IncrementAllTokenVersions", ctx).Return(int64(1), nil).Run(func(mock.Arguments) {
+		testUser.TokenVersion++
+	})
+	mockRepo.On("GetByID", ctx, testUser.ID).Return(testUser, nil)
+	mockTokenRepo.On("GetByJTI", ctx, claims.ID).Return(stored, nil)
+
+	// 执行
+	affected, err := userService.RevokeAllTokens(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+	resp, err := userService.RefreshToken(ctx, refreshToken)
+
+	// 断言：刷新令牌虽未单独撤销，但版本已过期
+	assert.Nil(t, resp)
+	assert.Equal(t, errors.ErrTokenRevoked, err)
+
+	mockRepo.AssertExpectations(t)
+	mockTokenRepo.AssertExpectations(t)
+}
@@ -0,0 +1,78 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含用户名的格式与保留字校验。
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/pkg/errors"
+)
+
+// checkUsername 按用户名策略校验用户名
+// 依次检查长度、字符集与保留字，违规时返回带原因的 400 错误
+func checkUsername(username string, policy config.UsernamePolicyConfig) error {
+	if err := checkUsernameFormat(username, policy); err != nil {
+		return err
+	}
+	if isReservedUsername(username, policy.Reserved) {
+		return errors.ErrValidation.WithMessage(fmt.Sprintf("用户名 %s 为系统保留，不能使用", username))
+	}
+	return nil
+}
+
+// checkUsernameFormat 按用户名策略校验长度与字符集，不检查保留字
+func checkUsernameFormat(username string, policy config.UsernamePolicyConfig) error {
+	length := utf8.RuneCountInString(username)
+	if length < policy.MinLength || length > policy.MaxLength {
+		return errors.ErrValidation.WithMessage(
+			fmt.Sprintf("用户名长度必须为 %d-%d 个字符", policy.MinLength, policy.MaxLength))
+	}
+
+	for i, r := range username {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case (r == '_' && policy.AllowUnderscore) || (r == '-' && policy.AllowHyphen):
+			if i == 0 || i == len(username)-1 {
+				return errors.ErrValidation.WithMessage("用户名不能以下划线或连字符开头或结尾")
+			}
+		default:
+			return errors.ErrValidation.WithMessage("用户名只能包含" + usernameCharsetDesc(policy))
+		}
+	}
+	return nil
+}
+
+// isReservedUsername 判断用户名是否为保留字
+// 不区分大小写并忽略下划线与连字符，防止 Admin、ad_min 之类的变体绕过
+func isReservedUsername(username string, reserved []string) bool {
+	normalized := normalizeUsername(username)
+	for _, entry := range reserved {
+		if normalized == normalizeUsername(entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeUsername 转为小写并去掉下划线与连字符
+func normalizeUsername(s string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(s)))
+}
+
+// usernameCharsetDesc 返回允许字符的描述，用于错误提示
+func usernameCharsetDesc(policy config.UsernamePolicyConfig) string {
+	desc := "字母、数字"
+	switch {
+	case policy.AllowUnderscore && policy.AllowHyphen:
+		desc += "、下划线和连字符"
+	case policy.AllowUnderscore:
+		desc += "和下划线"
+	case policy.AllowHyphen:
+		desc += "和连字符"
+	}
+	return desc
+}
@@ -0,0 +1,152 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含用户名格式与保留字策略的单元测试
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/example/go-user-api/internal/config"
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newUsernameTestRequest 创建指定用户名的注册请求
+func newUsernameTestRequest(username string) *model.RegisterRequest {
+	return &model.RegisterRequest{
+		Username:        username,
+		Email:           "alice@example.com",
+		Password:        "password123",
+		ConfirmPassword: "password123",
+	}
+}
+
+func TestCheckUsername(t *testing.T) {
+	policy := config.UsernamePolicyConfig{
+		MinLength: 3,
+		MaxLength: 10,
+		Reserved:  []string{"admin", "root", "api"},
+	}
+	withSeparators := policy
+	withSeparators.AllowUnderscore = true
+	withSeparators.AllowHyphen = true
+
+	tests := []struct {
+		name     string
+		policy   config.UsernamePolicyConfig
+		username string
+		reason   string // 为空表示应通过
+	}{
+		{name: "字母数字", policy: policy, username: "alice01"},
+		{name: "恰好最小长度", policy: policy, username: "bob"},
+		{name: "太短", policy: policy, username: "ab", reason: "3-10"},
+		{name: "太长", policy: policy, username: "abcdefghijk", reason: "3-10"},
+		{name: "未允许下划线", policy: policy, username: "alice_w", reason: "只能包含字母、数字"},
+		{name: "允许下划线和连字符", policy: withSeparators, username: "alice_w-1"},
+		{name: "分隔符在开头", policy: withSeparators, username: "_alice", reason: "开头或结尾"},
+		{name: "分隔符在结尾", policy: withSeparators, username: "alice-", reason: "开头或结尾"},
+		{name: "非 ASCII 字符", policy: policy, username: "张三丰", reason: "只能包含"},
+		{name: "保留字", policy: policy, username: "admin", reason: "系统保留"},
+		{name: "保留字不区分大小写", policy: policy, username: "ROOT", reason: "系统保留"},
+		{name: "保留字加分隔符", policy: withSeparators, username: "a_p-i", reason: "系统保留"},
+		{name: "包含保留字但不相同", policy: policy, username: "admin2", reason: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkUsername(tt.username, tt.policy)
+
+			if tt.reason == "" {
+				assert.NoError(t, err)
+				return
+			}
+			appErr := errors.AsAppError(err)
+			require.NotNil(t, appErr)
+			assert.Equal(t, 400, appErr.HTTPStatus)
+			assert.Contains(t, appErr.Message, tt.reason)
+		})
+	}
+}
+
+func TestUserService_Register_ReservedUsername(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+	ctx := context.Background()
+
+	// 执行
+	user, err := userService.Register(ctx, newUsernameTestRequest("Admin"))
+
+	// 断言：400 且带明确原因，不查询也不创建用户
+	assert.Nil(t, user)
+	appErr := errors.AsAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, 400, appErr.HTTPStatus)
+	assert.Contains(t, appErr.Message, "系统保留")
+	mockRepo.AssertNotCalled(t, "ExistsByUsername", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUserService_Register_ValidUsername(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	cfg.Security.Username.AllowUnderscore = true
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger())
+	ctx := context.Background()
+
+	// 设置 mock 期望
+	mockRepo.On("ExistsByUsername", ctx, "alice_w").Return(false, nil)
+	mockRepo.On("ExistsByEmail", ctx, "alice@example.com").Return(false, nil)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(nil)
+
+	// 执行
+	user, err := userService.Register(ctx, newUsernameTestRequest("alice_w"))
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, "alice_w", user.Username)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_CreateAdmin_InvalidUsername(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger()).(*userService)
+	ctx := context.Background()
+
+	// 执行
+	user, err := userService.CreateAdmin(ctx, "ad min", "admin@example.com", "password123")
+
+	// 断言：格式不合法时不查询也不创建用户
+	assert.Nil(t, user)
+	assert.True(t, errors.Is(err, errors.ErrValidation))
+	mockRepo.AssertNotCalled(t, "Count", mock.Anything)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUserService_CreateAdmin_AllowsReservedUsername(t *testing.T) {
+	// 准备
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	userService := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger()).(*userService)
+	ctx := context.Background()
+
+	// 设置 mock 期望
+	mockRepo.On("Count", ctx).Return(int64(0), nil)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(nil)
+
+	// 执行
+	user, err := userService.CreateAdmin(ctx, "admin", "admin@example.com", "password123")
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, "admin", user.Username)
+	mockRepo.AssertExpectations(t)
+}
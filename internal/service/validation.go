@@ -0,0 +1,146 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件实现了可组合的业务校验器。
+// struct tag 只适合单字段的格式校验，跨字段或依赖当前数据的规则（生日必须早于今天、
+// 管理员必须有邮箱等）以 Rule 的形式集中在这里管理，由服务方法在写库前统一执行。
+// 所有规则都会执行，不满足的规则汇总为 ValidationError，与绑定校验的错误结构一致。
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+)
+
+// Rule 业务校验规则，满足时返回 nil
+type Rule[T any] func(ctx context.Context, v T) *model.FieldError
+
+// Validator 按顺序执行一组规则的校验器
+type Validator[T any] struct {
+	rules []Rule[T]
+}
+
+// NewValidator 创建校验器
+func NewValidator[T any](rules ...Rule[T]) *Validator[T] {
+	return &Validator[T]{rules: rules}
+}
+
+// With 返回追加了规则的新校验器，原校验器不受影响
+func (v *Validator[T]) With(rules ...Rule[T]) *Validator[T] {
+	combined := make([]Rule[T], 0, len(v.rules)+len(rules))
+	combined = append(combined, v.rules...)
+	combined = append(combined, rules...)
+	return &Validator[T]{rules: combined}
+}
+
+// Validate 执行全部规则，有规则不满足时返回 *ValidationError
+func (v *Validator[T]) Validate(ctx context.Context, value T) error {
+	var fieldErrors []model.FieldError
+	for _, rule := range v.rules {
+		if fe := rule(ctx, value); fe != nil {
+			fieldErrors = append(fieldErrors, *fe)
+		}
+	}
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: fieldErrors}
+}
+
+// ValidationError 业务校验失败，逐字段说明原因
+// errors.Is(err, errors.ErrValidation) 为 true，未专门处理该类型的调用方按普通验证错误处理
+type ValidationError struct {
+	// Fields 不满足的字段
+	Fields []model.FieldError
+}
+
+// Error 实现 error 接口
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, fe := range e.Fields {
+		messages[i] = fe.Field + ": " + fe.Message
+	}
+	return "业务校验失败: " + strings.Join(messages, "; ")
+}
+
+// Unwrap 返回对应的 AppError
+func (e *ValidationError) Unwrap() error {
+	return errors.ErrValidation
+}
+
+// userUpdate 用户更新的校验输入：修改前的用户与更新请求
+// 个人资料更新也转换为管理员更新请求，共用同一组规则
+type userUpdate struct {
+	current *model.User
+	req     *model.AdminUpdateUserRequest
+}
+
+// email 返回更新后的邮箱
+func (u userUpdate) email() string {
+	if u.req.Email != "" {
+		return u.req.Email
+	}
+	return u.current.Email
+}
+
+// minBirthday 允许的最早生日
+var minBirthday = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newUserUpdateValidator 创建用户更新的业务校验器，now 用于判断「今天」
+func newUserUpdateValidator(now func() time.Time) *Validator[userUpdate] {
+	return NewValidator(
+		birthdayBeforeToday(now),
+		birthdayNotTooEarly,
+		adminRequiresEmail,
+	)
+}
+
+// birthdayBeforeToday 生日必须早于今天（按生日所在时区的日期比较）
+func birthdayBeforeToday(now func() time.Time) Rule[userUpdate] {
+	return func(ctx context.Context, u userUpdate) *model.FieldError {
+		birthday := u.req.Birthday
+		if birthday == nil {
+			return nil
+		}
+		y, m, d := now().In(birthday.Location()).Date()
+		if birthday.Before(time.Date(y, m, d, 0, 0, 0, 0, birthday.Location())) {
+			return nil
+		}
+		return &model.FieldError{
+			Field:   "birthday",
+			Kind:    model.FieldErrorKindInvalid,
+			Tag:     "before_today",
+			Message: "生日必须早于今天",
+		}
+	}
+}
+
+// birthdayNotTooEarly 生日不能早于 1900-01-01
+func birthdayNotTooEarly(ctx context.Context, u userUpdate) *model.FieldError {
+	if u.req.Birthday == nil || !u.req.Birthday.Before(minBirthday) {
+		return nil
+	}
+	return &model.FieldError{
+		Field:   "birthday",
+		Kind:    model.FieldErrorKindInvalid,
+		Tag:     "min_date",
+		Message: "生日不能早于 1900-01-01",
+	}
+}
+
+// adminRequiresEmail 管理员账号必须有邮箱，用于接收安全通知
+// 只在设为管理员时检查，不影响存量无邮箱管理员的其他资料修改
+func adminRequiresEmail(ctx context.Context, u userUpdate) *model.FieldError {
+	if u.req.Role != model.RoleAdmin || u.email() != "" {
+		return nil
+	}
+	return &model.FieldError{
+		Field:   "email",
+		Kind:    model.FieldErrorKindMissing,
+		Tag:     "required_for_admin",
+		Message: "管理员账号必须填写邮箱",
+	}
+}
@@ -0,0 +1,157 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含业务校验器的单元测试
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_Update_FutureBirthdayRejected(t *testing.T) {
+	// 准备
+	userService, mockRepo := setupValidationTestService()
+	ctx := context.Background()
+	user := newTestUser()
+	tomorrow := time.Now().AddDate(0, 0, 1)
+
+	// 设置 mock 期望
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+
+	// 执行
+	_, err := userService.Update(ctx, user.ID, &model.UpdateUserRequest{Birthday: &tomorrow})
+
+	// 断言：返回字段级错误，且不写库
+	require.Error(t, err)
+	var validationErr *ValidationError
+	require.True(t, stderrors.As(err, &validationErr))
+	assert.Equal(t, []model.FieldError{{
+		Field:   "birthday",
+		Kind:    model.FieldErrorKindInvalid,
+		Tag:     "before_today",
+		Message: "生日必须早于今天",
+	}}, validationErr.Fields)
+	assert.True(t, errors.Is(err, errors.ErrValidation))
+	mockRepo.AssertNotCalled(t, "UpdateFields", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserService_AdminUpdate_AdminRequiresEmail(t *testing.T) {
+	// 准备
+	userService, mockRepo := setupValidationTestService()
+	ctx := context.Background()
+	user := newTestUser()
+	user.Email = ""
+
+	// 设置 mock 期望
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+
+	// 执行
+	_, err := userService.AdminUpdate(ctx, user.ID, &model.AdminUpdateUserRequest{Role: model.RoleAdmin}, "admin-id")
+
+	// 断言
+	var validationErr *ValidationError
+	require.True(t, stderrors.As(err, &validationErr))
+	require.Len(t, validationErr.Fields, 1)
+	assert.Equal(t, "email", validationErr.Fields[0].Field)
+	assert.Equal(t, model.FieldErrorKindMissing, validationErr.Fields[0].Kind)
+	mockRepo.AssertNotCalled(t, "UpdateFields", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserUpdateValidator(t *testing.T) {
+	now := time.Date(2024, 6, 15, 10, 0, 0, 0, time.UTC)
+	validator := newUserUpdateValidator(func() time.Time { return now })
+	date := func(y int, m time.Month, d int) *time.Time {
+		t := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	tests := []struct {
+		name    string
+		current *model.User
+		req     *model.AdminUpdateUserRequest
+		fields  []string
+	}{
+		{name: "昨天出生", req: birthdayRequest(date(2024, 6, 14)), fields: nil},
+		{name: "今天出生", req: birthdayRequest(date(2024, 6, 15)), fields: []string{"birthday"}},
+		{name: "早于 1900 年", req: birthdayRequest(date(1899, 12, 31)), fields: []string{"birthday"}},
+		{
+			name:    "设为管理员时同时填写邮箱",
+			current: &model.User{},
+			req:     &model.AdminUpdateUserRequest{Email: "a@example.com", Role: model.RoleAdmin},
+			fields:  nil,
+		},
+		{
+			name:    "多条规则同时不满足",
+			current: &model.User{},
+			req: &model.AdminUpdateUserRequest{
+				UpdateUserRequest: model.UpdateUserRequest{Birthday: date(2030, 1, 1)},
+				Role:              model.RoleAdmin,
+			},
+			fields: []string{"birthday", "email"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := tt.current
+			if current == nil {
+				current = newTestUser()
+			}
+
+			err := validator.Validate(context.Background(), userUpdate{current: current, req: tt.req})
+
+			if tt.fields == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErr *ValidationError
+			require.True(t, stderrors.As(err, &validationErr))
+			var fields []string
+			for _, fe := range validationErr.Fields {
+				fields = append(fields, fe.Field)
+			}
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+}
+
+func TestValidator_With(t *testing.T) {
+	base := NewValidator(func(ctx context.Context, v int) *model.FieldError {
+		if v < 0 {
+			return &model.FieldError{Field: "value", Message: "不能为负数"}
+		}
+		return nil
+	})
+	strict := base.With(func(ctx context.Context, v int) *model.FieldError {
+		if v > 10 {
+			return &model.FieldError{Field: "value", Message: "不能大于 10"}
+		}
+		return nil
+	})
+
+	// 追加规则不影响原校验器
+	assert.NoError(t, base.Validate(context.Background(), 11))
+	assert.Error(t, strict.Validate(context.Background(), 11))
+	assert.Error(t, strict.Validate(context.Background(), -1))
+	assert.NoError(t, strict.Validate(context.Background(), 5))
+}
+
+// setupValidationTestService 创建用于业务校验测试的用户服务
+func setupValidationTestService() (UserService, *MockUserRepository) {
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	return NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger()), mockRepo
+}
+
+// birthdayRequest 创建只修改生日的更新请求
+func birthdayRequest(birthday *time.Time) *model.AdminUpdateUserRequest {
+	return &model.AdminUpdateUserRequest{UpdateUserRequest: model.UpdateUserRequest{Birthday: birthday}}
+}
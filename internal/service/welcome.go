@@ -0,0 +1,60 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件实现了注册成功后的欢迎通知。
+// 通知在独立的 goroutine 中发送，不阻塞注册响应；发送失败只记录日志，不影响注册结果。
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/example/go-user-api/pkg/logger"
+)
+
+// welcomeTimeout 单次发送欢迎通知的超时时间
+const welcomeTimeout = 30 * time.Second
+
+// Notifier 用户通知发送接口
+type Notifier interface {
+	// SendWelcome 向新注册用户发送欢迎通知
+	SendWelcome(ctx context.Context, user *model.User) error
+}
+
+// WithNotifier 设置用户通知发送器
+// 设置后注册成功且填写了邮箱的用户会收到欢迎通知
+func WithNotifier(n Notifier) UserServiceOption {
+	return func(s *userService) {
+		s.notifier = n
+	}
+}
+
+// notifyWelcome 异步发送欢迎通知，未设置通知发送器或用户未填写邮箱时跳过
+func (s *userService) notifyWelcome(ctx context.Context, user *model.User) {
+	if s.notifier == nil || user.Email == "" {
+		return
+	}
+
+	// 复制一份用户数据，避免与调用方并发读写；请求结束后仍需继续发送，不能继承请求的取消
+	recipient := *user
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				s.log.Error("发送欢迎通知 panic",
+					logger.String("user_id", recipient.ID),
+					logger.Any("panic", r),
+				)
+			}
+		}()
+
+		ctx, cancel := context.WithTimeout(ctx, welcomeTimeout)
+		defer cancel()
+		if err := s.notifier.SendWelcome(ctx, &recipient); err != nil {
+			s.log.Warn("发送欢迎通知失败",
+				logger.String("user_id", recipient.ID),
+				logger.Err(err),
+			)
+		}
+	}()
+}
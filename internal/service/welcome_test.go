@@ -0,0 +1,130 @@
+// Package service 提供业务逻辑层的实现
+//
+// 本文件包含注册欢迎通知的单元测试
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/example/go-user-api/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockNotifier 模拟通知发送器，每次发送后把收到的用户写入 sent
+type MockNotifier struct {
+	mock.Mock
+	sent chan *model.User
+}
+
+func newMockNotifier() *MockNotifier {
+	return &MockNotifier{sent: make(chan *model.User, 1)}
+}
+
+func (m *MockNotifier) SendWelcome(ctx context.Context, user *model.User) error {
+	args := m.Called(ctx, user)
+	m.sent <- user
+	return args.Error(0)
+}
+
+// waitWelcome 等待通知发送器被调用
+func waitWelcome(t *testing.T, n *MockNotifier) *model.User {
+	t.Helper()
+	select {
+	case user := <-n.sent:
+		return user
+	case <-time.After(time.Second):
+		t.Fatal("未发送欢迎通知")
+		return nil
+	}
+}
+
+// newWelcomeTestService 创建带通知发送器的用户服务
+func newWelcomeTestService(n Notifier) (UserService, *MockUserRepository) {
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	svc := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger(), WithNotifier(n))
+	return svc, mockRepo
+}
+
+// newWelcomeRegisterRequest 创建注册请求
+func newWelcomeRegisterRequest(email string) *model.RegisterRequest {
+	return &model.RegisterRequest{
+		Username:        "newuser",
+		Email:           email,
+		Password:        "password123",
+		ConfirmPassword: "password123",
+		Nickname:        "New User",
+	}
+}
+
+func TestUserService_Register_SendsWelcome(t *testing.T) {
+	// 准备
+	notifier := newMockNotifier()
+	svc, mockRepo := newWelcomeTestService(notifier)
+	ctx := context.Background()
+
+	// 设置 mock 期望
+	mockRepo.On("ExistsByUsername", ctx, "newuser").Return(false, nil)
+	mockRepo.On("ExistsByEmail", ctx, "new@example.com").Return(false, nil)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(nil)
+	notifier.On("SendWelcome", mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
+
+	// 执行
+	user, err := svc.Register(ctx, newWelcomeRegisterRequest("new@example.com"))
+	require.NoError(t, err)
+
+	// 断言：异步收到的用户信息与注册结果一致
+	sent := waitWelcome(t, notifier)
+	assert.Equal(t, user.ID, sent.ID)
+	assert.Equal(t, "newuser", sent.Username)
+	assert.Equal(t, "new@example.com", sent.Email)
+	assert.Equal(t, "New User", sent.Nickname)
+	notifier.AssertNumberOfCalls(t, "SendWelcome", 1)
+}
+
+func TestUserService_Register_WelcomeFailureDoesNotFailRegister(t *testing.T) {
+	// 准备
+	notifier := newMockNotifier()
+	svc, mockRepo := newWelcomeTestService(notifier)
+	ctx := context.Background()
+
+	// 设置 mock 期望
+	mockRepo.On("ExistsByUsername", ctx, "newuser").Return(false, nil)
+	mockRepo.On("ExistsByEmail", ctx, "new@example.com").Return(false, nil)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(nil)
+	notifier.On("SendWelcome", mock.Anything, mock.AnythingOfType("*model.User")).Return(stderrors.New("smtp unavailable"))
+
+	// 执行
+	user, err := svc.Register(ctx, newWelcomeRegisterRequest("new@example.com"))
+
+	// 断言
+	require.NoError(t, err)
+	assert.NotNil(t, user)
+	waitWelcome(t, notifier)
+}
+
+func TestUserService_Register_SkipsWelcomeWithoutEmail(t *testing.T) {
+	// 准备
+	notifier := newMockNotifier()
+	mockRepo := new(MockUserRepository)
+	cfg := newTestConfig()
+	cfg.Security.RequireEmail = false
+	svc := NewUserService(mockRepo, new(MockRefreshTokenRepository), NewJWTService(&cfg.JWT), cfg, newTestLogger(), WithNotifier(notifier))
+	ctx := context.Background()
+
+	// 设置 mock 期望
+	mockRepo.On("ExistsByUsername", ctx, "newuser").Return(false, nil)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(nil)
+
+	// 执行
+	_, err := svc.Register(ctx, newWelcomeRegisterRequest(""))
+
+	// 断言
+	require.NoError(t, err)
+	notifier.AssertNotCalled(t, "SendWelcome", mock.Anything, mock.Anything)
+}
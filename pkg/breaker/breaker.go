@@ -0,0 +1,199 @@
+// Package breaker 提供外部依赖调用的熔断器
+//
+// 缓存、邮件等外部依赖故障时，每次调用都要等到超时才失败，会拖慢甚至拖垮主流程。
+// 熔断器统计连续失败次数，达到阈值后开路（open）：开路期间调用直接返回 ErrOpen，
+// 调用方据此快速回退；开路超时后进入半开（half-open），放行一次试探调用，
+// 成功则闭合（closed）恢复正常，失败则重新开路。
+//
+// 使用示例：
+//
+//	b := breaker.New("mail", breaker.WithFailureThreshold(5), breaker.WithOpenTimeout(30*time.Second))
+//	err := b.Execute(func() error { return send(ctx, msg) })
+//	if errors.Is(err, breaker.ErrOpen) {
+//		// 依赖不可用，走回退逻辑
+//	}
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen 熔断器开路，调用未执行
+var ErrOpen = errors.New("熔断器已开路")
+
+// State 熔断器状态
+type State int
+
+const (
+	// StateClosed 闭合，正常放行调用
+	StateClosed State = iota
+	// StateOpen 开路，调用直接返回 ErrOpen
+	StateOpen
+	// StateHalfOpen 半开，只放行一次试探调用
+	StateHalfOpen
+)
+
+// String 返回状态名称
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// 默认配置
+const (
+	// DefaultFailureThreshold 默认连续失败多少次后开路
+	DefaultFailureThreshold = 5
+	// DefaultOpenTimeout 默认开路持续时间，之后进入半开
+	DefaultOpenTimeout = 30 * time.Second
+)
+
+// Breaker 熔断器，可并发使用
+type Breaker struct {
+	name             string
+	failureThreshold int
+	openTimeout      time.Duration
+	onStateChange    func(name string, from, to State)
+	now              func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	// probing 半开状态下是否已有试探调用在执行
+	probing bool
+}
+
+// Option 熔断器的可选配置
+type Option func(*Breaker)
+
+// WithFailureThreshold 设置连续失败多少次后开路，小于 1 时忽略
+func WithFailureThreshold(n int) Option {
+	return func(b *Breaker) {
+		if n >= 1 {
+			b.failureThreshold = n
+		}
+	}
+}
+
+// WithOpenTimeout 设置开路持续时间，小于等于 0 时忽略
+func WithOpenTimeout(d time.Duration) Option {
+	return func(b *Breaker) {
+		if d > 0 {
+			b.openTimeout = d
+		}
+	}
+}
+
+// WithStateChange 设置状态变化回调，用于记录日志或指标
+// 回调在持有锁之外同步调用，不应阻塞
+func WithStateChange(fn func(name string, from, to State)) Option {
+	return func(b *Breaker) {
+		b.onStateChange = fn
+	}
+}
+
+// New 创建熔断器，name 用于日志区分不同依赖
+func New(name string, opts ...Option) *Breaker {
+	b := &Breaker{
+		name:             name,
+		failureThreshold: DefaultFailureThreshold,
+		openTimeout:      DefaultOpenTimeout,
+		now:              time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Name 返回熔断器名称
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State 返回当前状态，开路已超时的熔断器报告为半开
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateOpen && !b.now().Before(b.openedAt.Add(b.openTimeout)) {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+// Execute 通过熔断器执行 fn
+// 开路期间或半开状态下已有试探调用时不执行 fn，直接返回 ErrOpen；
+// 否则返回 fn 的结果，fn 返回非 nil 错误计为一次失败
+func (b *Breaker) Execute(fn func() error) error {
+	if err := b.before(); err != nil {
+		return err
+	}
+	err := fn()
+	b.after(err == nil)
+	return err
+}
+
+// before 判断是否放行本次调用
+func (b *Breaker) before() error {
+	b.mu.Lock()
+	from := b.state
+	switch b.state {
+	case StateOpen:
+		if b.now().Before(b.openedAt.Add(b.openTimeout)) {
+			b.mu.Unlock()
+			return ErrOpen
+		}
+		b.state = StateHalfOpen
+		b.probing = true
+	case StateHalfOpen:
+		if b.probing {
+			b.mu.Unlock()
+			return ErrOpen
+		}
+		b.probing = true
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	return nil
+}
+
+// after 记录调用结果并更新状态
+func (b *Breaker) after(success bool) {
+	b.mu.Lock()
+	from := b.state
+	if success {
+		b.failures = 0
+		b.state = StateClosed
+		b.probing = false
+	} else {
+		b.failures++
+		if b.state == StateHalfOpen || b.failures >= b.failureThreshold {
+			b.state = StateOpen
+			b.openedAt = b.now()
+			b.probing = false
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+// notify 状态发生变化时调用回调
+func (b *Breaker) notify(from, to State) {
+	if from != to && b.onStateChange != nil {
+		b.onStateChange(b.name, from, to)
+	}
+}
@@ -0,0 +1,99 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errDown = errors.New("依赖不可用")
+
+// newTestBreaker 创建使用可调整时钟的熔断器
+func newTestBreaker(opts ...Option) (*Breaker, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := New("test", opts...)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	b, _ := newTestBreaker(WithFailureThreshold(3), WithOpenTimeout(time.Minute))
+	calls := 0
+	failing := func() error {
+		calls++
+		return errDown
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.ErrorIs(t, b.Execute(failing), errDown)
+	}
+	assert.Equal(t, StateOpen, b.State())
+
+	// 开路后快速失败，不再调用依赖
+	assert.ErrorIs(t, b.Execute(failing), ErrOpen)
+	assert.Equal(t, 3, calls)
+}
+
+func TestBreaker_SuccessResetsFailureCount(t *testing.T) {
+	b, _ := newTestBreaker(WithFailureThreshold(3))
+
+	_ = b.Execute(func() error { return errDown })
+	_ = b.Execute(func() error { return errDown })
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	_ = b.Execute(func() error { return errDown })
+	_ = b.Execute(func() error { return errDown })
+
+	// 失败不连续，不开路
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_HalfOpenProbe(t *testing.T) {
+	var transitions []string
+	b, now := newTestBreaker(WithFailureThreshold(1), WithOpenTimeout(30*time.Second),
+		WithStateChange(func(_ string, from, to State) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		}))
+
+	_ = b.Execute(func() error { return errDown })
+	assert.Equal(t, StateOpen, b.State())
+
+	// 开路超时前仍快速失败
+	*now = now.Add(29 * time.Second)
+	assert.ErrorIs(t, b.Execute(func() error { return nil }), ErrOpen)
+
+	// 超时后试探失败，重新开路并重新计时
+	*now = now.Add(time.Second)
+	assert.Equal(t, StateHalfOpen, b.State())
+	assert.ErrorIs(t, b.Execute(func() error { return errDown }), errDown)
+	assert.Equal(t, StateOpen, b.State())
+	assert.ErrorIs(t, b.Execute(func() error { return nil }), ErrOpen)
+
+	// 再次超时后试探成功，恢复闭合
+	*now = now.Add(30 * time.Second)
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	assert.Equal(t, StateClosed, b.State())
+
+	assert.Equal(t, []string{
+		"closed->open",
+		"open->half-open", "half-open->open",
+		"open->half-open", "half-open->closed",
+	}, transitions)
+}
+
+func TestBreaker_HalfOpenAllowsSingleProbe(t *testing.T) {
+	b, now := newTestBreaker(WithFailureThreshold(1), WithOpenTimeout(time.Second))
+	_ = b.Execute(func() error { return errDown })
+	*now = now.Add(time.Second)
+
+	// 试探调用执行期间，其他调用快速失败
+	var concurrent error
+	err := b.Execute(func() error {
+		concurrent = b.Execute(func() error { return nil })
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, concurrent, ErrOpen)
+	assert.Equal(t, StateClosed, b.State())
+}
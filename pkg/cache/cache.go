@@ -0,0 +1,153 @@
+// Package cache 提供键值缓存抽象
+//
+// 业务代码依赖 Cache 接口，单实例部署使用 MemoryCache，
+// 多实例部署可替换为 Redis 等共享实现。缓存是尽力而为的：
+// 读取失败按未命中处理，写入失败不影响业务结果。
+//
+// 使用示例：
+//
+//	c := cache.NewMemoryCache()
+//	_ = c.Set(ctx, "users:list:page=1", data, 5*time.Second)
+//	if data, ok, _ := c.Get(ctx, "users:list:page=1"); ok {
+//		// 命中
+//	}
+//	_ = c.DeletePrefix(ctx, "users:list:")
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache 缓存接口
+type Cache interface {
+	// Get 读取缓存，未命中或已过期时 ok 为 false
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set 写入缓存，ttl <= 0 表示不过期
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// SetNX 仅在键不存在或已过期时写入，返回是否写入；检查与写入是原子的
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	// Delete 删除指定键
+	Delete(ctx context.Context, key string) error
+	// DeletePrefix 删除所有以 prefix 开头的键
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// entry 缓存条目
+type entry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// expired 条目是否已过期
+func (e entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// MemoryCache 基于内存的缓存实现
+// 过期条目在读取时惰性删除，并在写入时顺带清理
+type MemoryCache struct {
+	mu    sync.RWMutex
+	items map[string]entry
+	now   func() time.Time
+}
+
+// NewMemoryCache 创建内存缓存
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		items: make(map[string]entry),
+		now:   time.Now,
+	}
+}
+
+// Get 实现 Cache
+func (c *MemoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.RLock()
+	e, ok := c.items[key]
+	c.mu.RUnlock()
+
+	if !ok {
+		return nil, false, nil
+	}
+	if e.expired(c.now()) {
+		c.mu.Lock()
+		// 重新检查，避免删除并发写入的新值
+		if cur, ok := c.items[key]; ok && cur.expired(c.now()) {
+			delete(c.items, key)
+		}
+		c.mu.Unlock()
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+// Set 实现 Cache
+func (c *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	now := c.now()
+	e := entry{value: value}
+	if ttl > 0 {
+		e.expiresAt = now.Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pruneLocked(now)
+	c.items[key] = e
+	return nil
+}
+
+// SetNX 实现 Cache
+func (c *MemoryCache) SetNX(_ context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	now := c.now()
+	e := entry{value: value}
+	if ttl > 0 {
+		e.expiresAt = now.Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cur, ok := c.items[key]; ok && !cur.expired(now) {
+		return false, nil
+	}
+	c.pruneLocked(now)
+	c.items[key] = e
+	return true, nil
+}
+
+// Delete 实现 Cache
+func (c *MemoryCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+	return nil
+}
+
+// DeletePrefix 实现 Cache
+func (c *MemoryCache) DeletePrefix(_ context.Context, prefix string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.items, key)
+		}
+	}
+	return nil
+}
+
+// Len 返回当前条目数（含尚未清理的过期条目）
+func (c *MemoryCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.items)
+}
+
+// pruneLocked 清理已过期条目，调用方需持有写锁
+func (c *MemoryCache) pruneLocked(now time.Time) {
+	for key, e := range c.items {
+		if e.expired(now) {
+			delete(c.items, key)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCache_SetGet(t *testing.T) {
+	c := NewMemoryCache()
+	ctx := context.Background()
+
+	_, ok, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set(ctx, "k", []byte("v"), time.Minute))
+	value, ok, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("v"), value)
+}
+
+func TestMemoryCache_Expiration(t *testing.T) {
+	c := NewMemoryCache()
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Set(ctx, "short", []byte("1"), time.Second))
+	require.NoError(t, c.Set(ctx, "forever", []byte("2"), 0))
+
+	now = now.Add(2 * time.Second)
+
+	_, ok, _ := c.Get(ctx, "short")
+	assert.False(t, ok)
+	_, ok, _ = c.Get(ctx, "forever")
+	assert.True(t, ok)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestMemoryCache_SetNX(t *testing.T) {
+	c := NewMemoryCache()
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	ok, err := c.SetNX(ctx, "nonce", []byte("1"), time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// 键存在时不写入，原值保留
+	ok, err = c.SetNX(ctx, "nonce", []byte("2"), time.Second)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	value, _, _ := c.Get(ctx, "nonce")
+	assert.Equal(t, []byte("1"), value)
+
+	// 过期后可再次写入
+	now = now.Add(2 * time.Second)
+	ok, err = c.SetNX(ctx, "nonce", []byte("3"), time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestMemoryCache_DeletePrefix(t *testing.T) {
+	c := NewMemoryCache()
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "users:list:a", []byte("1"), time.Minute))
+	require.NoError(t, c.Set(ctx, "users:list:b", []byte("2"), time.Minute))
+	require.NoError(t, c.Set(ctx, "users:detail:a", []byte("3"), time.Minute))
+
+	require.NoError(t, c.DeletePrefix(ctx, "users:list:"))
+
+	_, ok, _ := c.Get(ctx, "users:list:a")
+	assert.False(t, ok)
+	_, ok, _ = c.Get(ctx, "users:list:b")
+	assert.False(t, ok)
+	_, ok, _ = c.Get(ctx, "users:detail:a")
+	assert.True(t, ok)
+
+	require.NoError(t, c.Delete(ctx, "users:detail:a"))
+	assert.Equal(t, 0, c.Len())
+}
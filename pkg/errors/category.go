@@ -0,0 +1,77 @@
+// Package errors 提供应用程序统一的错误处理机制
+//
+// 本文件实现了错误码的分类。
+// 分类从错误码区间推导，客户端可按大类统一处理错误，而不必逐个匹配错误码：
+//
+//   - 1xxxx: auth（认证与权限）
+//   - 2xxxx: user（用户状态）
+//   - 3xxxx: validation（数据校验）
+//   - 4xxxx: resource（资源）
+//   - 5xxxx: server（服务器内部）
+//
+// 通用错误码（100xx）虽然位于 1xxxx 区间，但含义各不相同，按单个错误码归类。
+package errors
+
+// 错误分类
+const (
+	// CategoryAuth 认证与权限错误：未登录、令牌无效、无权限等
+	CategoryAuth = "auth"
+	// CategoryUser 用户状态错误：用户不存在、已禁用、用户名已存在等
+	CategoryUser = "user"
+	// CategoryValidation 数据校验错误：请求参数或字段格式不合法
+	CategoryValidation = "validation"
+	// CategoryResource 资源错误：资源不存在、冲突、配额已用尽等
+	CategoryResource = "resource"
+	// CategoryRateLimit 请求过于频繁
+	CategoryRateLimit = "rate_limit"
+	// CategoryServer 服务器错误：内部错误、数据库错误、服务暂不可用等
+	CategoryServer = "server"
+	// CategoryUnknown 不在任何已知区间内的错误码
+	CategoryUnknown = "unknown"
+)
+
+// generalCategories 通用错误码（100xx）的分类
+var generalCategories = map[int]string{
+	CodeUnknown:       CategoryServer,
+	CodeBadRequest:    CategoryValidation,
+	CodeUnauthorized:  CategoryAuth,
+	CodeForbidden:     CategoryAuth,
+	CodeNotFound:      CategoryResource,
+	CodeConflict:      CategoryResource,
+	CodeInternalError: CategoryServer,
+	CodeValidation:    CategoryValidation,
+	CodeTooManyReqs:   CategoryRateLimit,
+	CodeUnavailable:   CategoryServer,
+	CodeTimeout:       CategoryServer,
+}
+
+// CategoryOf 返回错误码所属的分类
+// 成功码（0）返回空字符串；未登记的通用错误码与区间外的错误码返回 CategoryUnknown
+func CategoryOf(code int) string {
+	if code == CodeSuccess {
+		return ""
+	}
+	if category, ok := generalCategories[code]; ok {
+		return category
+	}
+
+	switch {
+	case code >= 11000 && code < 20000:
+		return CategoryAuth
+	case code >= 20000 && code < 30000:
+		return CategoryUser
+	case code >= 30000 && code < 40000:
+		return CategoryValidation
+	case code >= 40000 && code < 50000:
+		return CategoryResource
+	case code >= 50000 && code < 60000:
+		return CategoryServer
+	default:
+		return CategoryUnknown
+	}
+}
+
+// Category 返回错误所属的分类，见 CategoryOf
+func (e *AppError) Category() string {
+	return CategoryOf(e.Code)
+}
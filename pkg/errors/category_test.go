@@ -0,0 +1,57 @@
+// Package errors 提供应用程序统一的错误处理机制
+//
+// 本文件包含错误分类的单元测试
+package errors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategoryOf(t *testing.T) {
+	tests := []struct {
+		name string
+		code int
+		want string
+	}{
+		{"成功", CodeSuccess, ""},
+		{"通用-请求参数错误", CodeBadRequest, CategoryValidation},
+		{"通用-数据验证失败", CodeValidation, CategoryValidation},
+		{"通用-未授权", CodeUnauthorized, CategoryAuth},
+		{"通用-禁止访问", CodeForbidden, CategoryAuth},
+		{"通用-资源不存在", CodeNotFound, CategoryResource},
+		{"通用-资源冲突", CodeConflict, CategoryResource},
+		{"通用-请求过于频繁", CodeTooManyReqs, CategoryRateLimit},
+		{"通用-服务器内部错误", CodeInternalError, CategoryServer},
+		{"通用-服务暂不可用", CodeUnavailable, CategoryServer},
+		{"通用-请求超时", CodeTimeout, CategoryServer},
+		{"通用-未知错误", CodeUnknown, CategoryServer},
+		{"通用-未登记", 10099, CategoryUnknown},
+		{"认证-令牌过期", CodeTokenExpired, CategoryAuth},
+		{"认证-凭证无效", CodeInvalidCredential, CategoryAuth},
+		{"用户-不存在", CodeUserNotFound, CategoryUser},
+		{"用户-已禁用", CodeUserDisabled, CategoryUser},
+		{"校验-邮箱格式", CodeInvalidEmail, CategoryValidation},
+		{"校验-图片", CodeInvalidImage, CategoryValidation},
+		{"资源-配额已用尽", CodeQuotaExceeded, CategoryResource},
+		{"服务器-数据库错误", CodeDatabaseError, CategoryServer},
+		{"服务器-重复条目", CodeDuplicateEntry, CategoryServer},
+		{"区间外", 60001, CategoryUnknown},
+		{"负数", -1, CategoryUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CategoryOf(tt.code))
+		})
+	}
+}
+
+func TestAppError_Category(t *testing.T) {
+	// 副本与自定义错误按错误码归类，与消息无关
+	assert.Equal(t, CategoryAuth, ErrTokenRevoked.Category())
+	assert.Equal(t, CategoryUser, ErrUserNotFound.WithMessage("用户 42 不存在").Category())
+	assert.Equal(t, CategoryValidation, ErrValidation.WithDetail("page").Category())
+	assert.Equal(t, CategoryServer, FromError(assert.AnError).Category())
+}
@@ -13,9 +13,13 @@
 //
 //	// 包装原始错误
 //	return errors.Wrap(err, errors.CodeDatabaseError, "数据库操作失败")
+//
+//	// 按错误码判断错误类型
+//	if errors.Is(err, errors.ErrUserNotFound) { ... }
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 	"net/http"
 )
@@ -39,6 +43,8 @@ const (
 	CodeInternalError = 10006 // 服务器内部错误
 	CodeValidation    = 10007 // 数据验证失败
 	CodeTooManyReqs   = 10008 // 请求过于频繁
+	CodeUnavailable   = 10009 // 服务暂不可用
+	CodeTimeout       = 10010 // 请求处理超时
 
 	// 认证相关错误码 (1xxxx)
 	CodeInvalidToken      = 11001 // 无效的令牌
@@ -47,14 +53,17 @@ const (
 	CodeInvalidCredential = 11004 // 无效的凭证
 	CodeTokenMalformed    = 11005 // 令牌格式错误
 	CodeTokenNotFound     = 11006 // 令牌不存在
+	CodeTokenRevoked      = 11007 // 令牌已撤销
 
 	// 用户相关错误码 (2xxxx)
-	CodeUserNotFound      = 20001 // 用户不存在
-	CodeUserAlreadyExists = 20002 // 用户已存在
-	CodeUserDisabled      = 20003 // 用户已禁用
-	CodeEmailAlreadyUsed  = 20004 // 邮箱已被使用
-	CodeUsernameExists    = 20005 // 用户名已存在
-	CodePasswordTooWeak   = 20006 // 密码强度不足
+	CodeUserNotFound       = 20001 // 用户不存在
+	CodeUserAlreadyExists  = 20002 // 用户已存在
+	CodeUserDisabled       = 20003 // 用户已禁用
+	CodeEmailAlreadyUsed   = 20004 // 邮箱已被使用
+	CodeUsernameExists     = 20005 // 用户名已存在
+	CodePasswordTooWeak    = 20006 // 密码强度不足
+	CodePasswordReused     = 20007 // 密码与最近使用过的密码相同
+	CodeRegistrationClosed = 20008 // 注册已关闭
 
 	// 数据验证错误码 (3xxxx)
 	CodeInvalidEmail    = 30001 // 无效的邮箱格式
@@ -63,11 +72,13 @@ const (
 	CodeFieldRequired   = 30004 // 必填字段缺失
 	CodeFieldTooLong    = 30005 // 字段长度超限
 	CodeFieldTooShort   = 30006 // 字段长度不足
+	CodeInvalidImage    = 30007 // 无效的图片
 
 	// 资源相关错误码 (4xxxx)
 	CodeResourceNotFound = 40001 // 资源不存在
 	CodeResourceExists   = 40002 // 资源已存在
 	CodeResourceLocked   = 40003 // 资源已锁定
+	CodeQuotaExceeded    = 40004 // 配额已用尽
 
 	// 数据库相关错误码 (5xxxx)
 	CodeDatabaseError   = 50001 // 数据库错误
@@ -103,6 +114,19 @@ func (e *AppError) Unwrap() error {
 	return e.Err
 }
 
+// Is 实现错误码匹配，支持 errors.Is
+// target 也是 *AppError 且错误码相同时视为同一错误，
+// 因此经 WithDetail / WithError 产生的副本仍能与预定义错误匹配：
+//
+//	errors.Is(err, ErrUserNotFound)
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	if !ok {
+		return false
+	}
+	return e.Code == t.Code
+}
+
 // WithDetail 添加错误详情
 func (e *AppError) WithDetail(detail string) *AppError {
 	newErr := *e
@@ -110,6 +134,14 @@ func (e *AppError) WithDetail(detail string) *AppError {
 	return &newErr
 }
 
+// WithMessage 替换面向用户的错误消息，错误码与 HTTP 状态码不变
+// 用于需要向用户说明具体原因的场景（Detail 只面向开发者，不会返回给客户端）
+func (e *AppError) WithMessage(message string) *AppError {
+	newErr := *e
+	newErr.Message = message
+	return &newErr
+}
+
 // WithError 包装原始错误
 func (e *AppError) WithError(err error) *AppError {
 	newErr := *e
@@ -146,6 +178,12 @@ func WrapWithStatus(err error, code int, httpStatus int, message string) *AppErr
 	}
 }
 
+// Is 报告错误链中是否存在与 target 匹配的错误
+// 等同于标准库 errors.Is，便于只导入本包的调用方使用
+func Is(err, target error) bool {
+	return stderrors.Is(err, target)
+}
+
 // IsAppError 检查错误是否为 AppError 类型
 func IsAppError(err error) bool {
 	_, ok := err.(*AppError)
@@ -229,12 +267,26 @@ var (
 		Message:    "数据验证失败",
 	}
 
+	// ErrServiceUnavailable 服务暂不可用
+	ErrServiceUnavailable = &AppError{
+		Code:       CodeUnavailable,
+		HTTPStatus: http.StatusServiceUnavailable,
+		Message:    "服务暂不可用，请稍后再试",
+	}
+
 	// ErrTooManyRequests 请求过于频繁
 	ErrTooManyRequests = &AppError{
 		Code:       CodeTooManyReqs,
 		HTTPStatus: http.StatusTooManyRequests,
 		Message:    "请求过于频繁，请稍后再试",
 	}
+
+	// ErrRequestTimeout 请求处理超过截止时间
+	ErrRequestTimeout = &AppError{
+		Code:       CodeTimeout,
+		HTTPStatus: http.StatusGatewayTimeout,
+		Message:    "请求处理超时，请稍后再试",
+	}
 )
 
 // 认证相关错误
@@ -280,6 +332,13 @@ var (
 		HTTPStatus: http.StatusUnauthorized,
 		Message:    "请提供访问令牌",
 	}
+
+	// ErrTokenRevoked 令牌已撤销
+	ErrTokenRevoked = &AppError{
+		Code:       CodeTokenRevoked,
+		HTTPStatus: http.StatusUnauthorized,
+		Message:    "令牌已被撤销",
+	}
 )
 
 // 用户相关错误
@@ -325,6 +384,20 @@ var (
 		HTTPStatus: http.StatusBadRequest,
 		Message:    "密码强度不足，请使用更复杂的密码",
 	}
+
+	// ErrPasswordReused 新密码与当前或最近使用过的密码相同
+	ErrPasswordReused = &AppError{
+		Code:       CodePasswordReused,
+		HTTPStatus: http.StatusBadRequest,
+		Message:    "新密码不能与最近使用过的密码相同",
+	}
+
+	// ErrRegistrationClosed 注册已关闭
+	ErrRegistrationClosed = &AppError{
+		Code:       CodeRegistrationClosed,
+		HTTPStatus: http.StatusForbidden,
+		Message:    "注册暂未开放",
+	}
 )
 
 // 数据验证相关错误
@@ -356,6 +429,13 @@ var (
 		HTTPStatus: http.StatusBadRequest,
 		Message:    "必填字段缺失",
 	}
+
+	// ErrInvalidImage 无法识别的图片或图片尺寸超限
+	ErrInvalidImage = &AppError{
+		Code:       CodeInvalidImage,
+		HTTPStatus: http.StatusBadRequest,
+		Message:    "无效的图片",
+	}
 )
 
 // 资源相关错误
@@ -366,6 +446,13 @@ var (
 		HTTPStatus: http.StatusNotFound,
 		Message:    "请求的资源不存在",
 	}
+
+	// ErrQuotaExceeded 配额已用尽
+	ErrQuotaExceeded = &AppError{
+		Code:       CodeQuotaExceeded,
+		HTTPStatus: http.StatusTooManyRequests,
+		Message:    "token 配额已用尽",
+	}
 )
 
 // 数据库相关错误
@@ -0,0 +1,47 @@
+// Package errors 提供应用程序统一的错误处理机制
+//
+// 本文件包含 AppError 错误匹配的单元测试
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppError_Is_SameCodeDifferentInstance(t *testing.T) {
+	// WithDetail / WithMessage / WithError 产生的副本与预定义错误码相同
+	withDetail := ErrUserNotFound.WithDetail("id=42")
+	withError := ErrUserNotFound.WithError(fmt.Errorf("record not found"))
+	newInstance := New(CodeUserNotFound, http.StatusNotFound, "另一条消息")
+	withMessage := ErrUserNotFound.WithMessage("用户 42 不存在")
+
+	assert.True(t, stderrors.Is(withDetail, ErrUserNotFound))
+	assert.True(t, stderrors.Is(withMessage, ErrUserNotFound))
+	assert.Equal(t, http.StatusNotFound, withMessage.HTTPStatus)
+	assert.NotEqual(t, ErrUserNotFound.Message, withMessage.Message)
+	assert.True(t, stderrors.Is(withError, ErrUserNotFound))
+	assert.True(t, stderrors.Is(newInstance, ErrUserNotFound))
+	assert.True(t, Is(withDetail, ErrUserNotFound))
+}
+
+func TestAppError_Is_DifferentCode(t *testing.T) {
+	assert.False(t, stderrors.Is(ErrUserNotFound, ErrUserDisabled))
+	assert.False(t, stderrors.Is(ErrUserNotFound.WithDetail("x"), ErrUsernameExists))
+	assert.False(t, stderrors.Is(ErrUserNotFound, fmt.Errorf("plain error")))
+}
+
+func TestAppError_Is_ThroughWrapping(t *testing.T) {
+	// 被标准库 %w 包装后仍能匹配
+	wrapped := fmt.Errorf("查询失败: %w", ErrUserNotFound.WithDetail("id=42"))
+	assert.True(t, stderrors.Is(wrapped, ErrUserNotFound))
+
+	// AppError 包装的原始错误也能通过错误链匹配
+	cause := stderrors.New("connection refused")
+	dbErr := Wrap(cause, CodeDatabaseError, "数据库错误")
+	assert.True(t, stderrors.Is(dbErr, cause))
+	assert.True(t, stderrors.Is(dbErr, ErrDatabaseError))
+}
@@ -0,0 +1,64 @@
+// Package errors 提供应用程序统一的错误处理机制
+//
+// 本文件包含错误消息的多语言文本。
+// 默认消息为中文（即 AppError.Message），其他语言按错误码查表，未收录的错误码沿用中文消息。
+package errors
+
+// englishMessages 错误码对应的英文消息
+var englishMessages = map[int]string{
+	CodeUnknown:       "Unknown error",
+	CodeBadRequest:    "Bad request",
+	CodeUnauthorized:  "Please log in first",
+	CodeForbidden:     "Access denied",
+	CodeNotFound:      "Resource not found",
+	CodeConflict:      "Resource conflict",
+	CodeInternalError: "Internal server error",
+	CodeValidation:    "Validation failed",
+	CodeTooManyReqs:   "Too many requests, please try again later",
+	CodeUnavailable:   "Service temporarily unavailable, please try again later",
+	CodeTimeout:       "Request timed out, please try again later",
+
+	CodeInvalidToken:      "Invalid token",
+	CodeTokenExpired:      "Token expired",
+	CodeInvalidPassword:   "Incorrect password",
+	CodeInvalidCredential: "Invalid username or password",
+	CodeTokenMalformed:    "Malformed token",
+	CodeTokenNotFound:     "Token not found",
+	CodeTokenRevoked:      "Token revoked",
+
+	CodeUserNotFound:       "User not found",
+	CodeUserAlreadyExists:  "User already exists",
+	CodeUserDisabled:       "User is disabled",
+	CodeEmailAlreadyUsed:   "Email is already in use",
+	CodeUsernameExists:     "Username already exists",
+	CodePasswordTooWeak:    "Password is too weak",
+	CodePasswordReused:     "Password was used recently",
+	CodeRegistrationClosed: "Registration is closed",
+
+	CodeInvalidEmail:    "Invalid email format",
+	CodeInvalidUsername: "Invalid username format",
+	CodeInvalidPhone:    "Invalid phone number format",
+	CodeFieldRequired:   "Required field is missing",
+	CodeFieldTooLong:    "Field is too long",
+	CodeFieldTooShort:   "Field is too short",
+	CodeInvalidImage:    "Invalid image",
+
+	CodeResourceNotFound: "Resource not found",
+	CodeResourceExists:   "Resource already exists",
+	CodeResourceLocked:   "Resource is locked",
+	CodeQuotaExceeded:    "Quota exceeded",
+
+	CodeDatabaseError:   "Database error",
+	CodeDatabaseTimeout: "Database timeout",
+	CodeDuplicateEntry:  "Duplicate entry",
+}
+
+// LocalizedMessage 返回错误码在指定语言下的消息
+// lang 为语言的基础标签（如 "en"）；不支持的语言或未收录的错误码返回 false
+func LocalizedMessage(code int, lang string) (string, bool) {
+	if lang != "en" {
+		return "", false
+	}
+	msg, ok := englishMessages[code]
+	return msg, ok
+}
@@ -0,0 +1,141 @@
+// Package eventbus 提供进程内的事件发布/订阅
+//
+// 业务服务在关键状态变更时发布事件，其他子系统订阅后异步联动处理，
+// 发布方无需依赖订阅方。订阅者在独立的 goroutine 中执行，
+// 执行失败或 panic 不影响发布方与其他订阅者。
+//
+// 使用示例：
+//
+//	bus := eventbus.NewMemoryBus()
+//	defer bus.Shutdown(ctx)
+//
+//	bus.Subscribe("user.disabled", func(ctx context.Context, event eventbus.Event) {
+//		// ... 联动处理
+//	})
+//	bus.Publish(ctx, eventbus.Event{Name: "user.disabled", Payload: payload})
+package eventbus
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Event 事件
+type Event struct {
+	// Name 事件名称，例如 user.disabled
+	Name string
+	// Payload 事件数据，具体类型由事件名称约定
+	Payload interface{}
+	// OccurredAt 发生时间，发布时为零值则自动填充
+	OccurredAt time.Time
+}
+
+// Handler 事件处理函数
+// ctx 保留发布时上下文中的值（如请求 ID），但不会随请求结束而取消
+type Handler func(ctx context.Context, event Event)
+
+// EventBus 事件总线接口
+type EventBus interface {
+	// Publish 发布事件，立即返回，订阅者异步执行
+	Publish(ctx context.Context, event Event)
+	// Subscribe 订阅指定名称的事件
+	Subscribe(name string, handler Handler)
+}
+
+// PanicHandler 订阅者 panic 时的回调，用于记录日志
+type PanicHandler func(event Event, recovered interface{})
+
+// Option MemoryBus 的可选配置
+type Option func(*MemoryBus)
+
+// WithPanicHandler 设置订阅者 panic 时的回调
+func WithPanicHandler(fn PanicHandler) Option {
+	return func(b *MemoryBus) {
+		b.onPanic = fn
+	}
+}
+
+// MemoryBus 基于内存的事件总线
+// 每个订阅者在独立的 goroutine 中处理事件，不保证不同事件之间的处理顺序；
+// 事件不持久化，进程退出时未处理完的事件会丢失
+type MemoryBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	closed   bool
+	wg       sync.WaitGroup
+	onPanic  PanicHandler
+	now      func() time.Time
+}
+
+// NewMemoryBus 创建内存事件总线
+func NewMemoryBus(opts ...Option) *MemoryBus {
+	b := &MemoryBus{
+		handlers: make(map[string][]Handler),
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Subscribe 实现 EventBus
+func (b *MemoryBus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], handler)
+}
+
+// Publish 实现 EventBus
+// 总线关闭后发布的事件直接丢弃
+func (b *MemoryBus) Publish(ctx context.Context, event Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = b.now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+
+	// 订阅者在请求结束后仍可能在执行，不能继承请求的取消
+	ctx = context.WithoutCancel(ctx)
+	for _, handler := range b.handlers[event.Name] {
+		b.wg.Add(1)
+		go b.dispatch(ctx, handler, event)
+	}
+}
+
+// dispatch 执行单个订阅者，panic 时交给 onPanic 处理
+func (b *MemoryBus) dispatch(ctx context.Context, handler Handler, event Event) {
+	defer b.wg.Done()
+	defer func() {
+		if r := recover(); r != nil && b.onPanic != nil {
+			b.onPanic(event, r)
+		}
+	}()
+	handler(ctx, event)
+}
+
+// Shutdown 关闭事件总线
+// 不再接受新事件，等待正在执行的订阅者结束；ctx 到期时返回 ctx 的错误
+func (b *MemoryBus) Shutdown(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
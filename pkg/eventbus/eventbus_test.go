@@ -0,0 +1,90 @@
+// Package eventbus 提供进程内的事件发布/订阅
+//
+// 本文件包含内存事件总线的单元测试
+package eventbus
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requestIDKey 测试用的上下文键
+type requestIDKey struct{}
+
+func TestMemoryBus_PublishToSubscribers(t *testing.T) {
+	// 准备：同一事件两个订阅者，另一事件一个订阅者
+	bus := NewMemoryBus()
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+	record := func(tag string) Handler {
+		return func(ctx context.Context, event Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, tag+":"+event.Payload.(string)+":"+ctx.Value(requestIDKey{}).(string))
+		}
+	}
+	bus.Subscribe("user.disabled", record("a"))
+	bus.Subscribe("user.disabled", record("b"))
+	bus.Subscribe("user.deleted", record("c"))
+
+	// 执行：发布后立即取消请求上下文，订阅者仍可读取上下文中的值
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), requestIDKey{}, "req-1"))
+	bus.Publish(ctx, Event{Name: "user.disabled", Payload: "u1"})
+	cancel()
+	require.NoError(t, bus.Shutdown(context.Background()))
+
+	// 断言
+	assert.ElementsMatch(t, []string{"a:u1:req-1", "b:u1:req-1"}, received)
+}
+
+func TestMemoryBus_PanicIsolated(t *testing.T) {
+	// 准备
+	var recovered interface{}
+	bus := NewMemoryBus(WithPanicHandler(func(_ Event, r interface{}) { recovered = r }))
+	done := make(chan struct{})
+	bus.Subscribe("user.deleted", func(context.Context, Event) { panic("boom") })
+	bus.Subscribe("user.deleted", func(context.Context, Event) { close(done) })
+
+	// 执行
+	bus.Publish(context.Background(), Event{Name: "user.deleted"})
+	require.NoError(t, bus.Shutdown(context.Background()))
+
+	// 断言：panic 被捕获，其他订阅者正常执行
+	assert.Equal(t, "boom", recovered)
+	select {
+	case <-done:
+	default:
+		t.Fatal("其他订阅者未执行")
+	}
+}
+
+func TestMemoryBus_Shutdown(t *testing.T) {
+	// 准备：订阅者执行较慢
+	bus := NewMemoryBus()
+	release := make(chan struct{})
+	calls := 0
+	bus.Subscribe("user.disabled", func(context.Context, Event) {
+		<-release
+		calls++
+	})
+	bus.Publish(context.Background(), Event{Name: "user.disabled"})
+
+	// 执行：等待超时
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := bus.Shutdown(ctx)
+
+	// 断言：超时返回错误；关闭后发布的事件被丢弃
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	bus.Publish(context.Background(), Event{Name: "user.disabled"})
+	close(release)
+	require.NoError(t, bus.Shutdown(context.Background()))
+	assert.Equal(t, 1, calls)
+}
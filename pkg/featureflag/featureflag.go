@@ -0,0 +1,95 @@
+// Package featureflag 提供接口级别的功能开关
+//
+// 开关初始状态来自配置，运行时可通过管理端点修改，用于线上出问题时快速关闭某个端点。
+// 运行时修改只保存在当前进程内存中，重启后恢复为配置值；多实例部署需要逐个实例修改。
+//
+// 使用示例：
+//
+//	flags := featureflag.New(map[string]bool{"user_import": false})
+//	flags.Register("user_import")
+//	if !flags.Enabled("user_import") {
+//		// 返回 503
+//	}
+//	flags.Set("user_import", true)
+package featureflag
+
+import (
+	"sort"
+	"sync"
+)
+
+// Flag 单个开关的状态
+type Flag struct {
+	// Name 开关名称
+	Name string `json:"name"`
+	// Enabled 是否开启
+	Enabled bool `json:"enabled"`
+}
+
+// Flags 功能开关集合，并发安全
+type Flags struct {
+	mu sync.RWMutex
+	// flags 已注册开关的当前状态
+	flags map[string]bool
+	// initial 配置中的初始状态，注册时使用
+	initial map[string]bool
+}
+
+// New 创建功能开关集合
+// initial 为配置中的初始状态，未出现在其中的开关注册时默认开启
+func New(initial map[string]bool) *Flags {
+	copied := make(map[string]bool, len(initial))
+	for name, enabled := range initial {
+		copied[name] = enabled
+	}
+	return &Flags{
+		flags:   make(map[string]bool),
+		initial: copied,
+	}
+}
+
+// Register 注册开关，初始状态取配置值，配置中没有时为开启
+// 重复注册不改变当前状态
+func (f *Flags) Register(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.flags[name]; ok {
+		return
+	}
+	enabled, ok := f.initial[name]
+	if !ok {
+		enabled = true
+	}
+	f.flags[name] = enabled
+}
+
+// Enabled 返回开关是否开启，未注册的开关视为开启
+func (f *Flags) Enabled(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	enabled, ok := f.flags[name]
+	return !ok || enabled
+}
+
+// Set 修改已注册开关的状态，开关未注册时返回 false
+func (f *Flags) Set(name string, enabled bool) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.flags[name]; !ok {
+		return false
+	}
+	f.flags[name] = enabled
+	return true
+}
+
+// List 返回所有已注册开关的状态，按名称排序
+func (f *Flags) List() []Flag {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	list := make([]Flag, 0, len(f.flags))
+	for name, enabled := range f.flags {
+		list = append(list, Flag{Name: name, Enabled: enabled})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
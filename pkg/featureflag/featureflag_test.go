@@ -0,0 +1,41 @@
+package featureflag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlags_RegisterUsesInitialState(t *testing.T) {
+	f := New(map[string]bool{"user_import": false})
+	f.Register("user_import")
+	f.Register("user_export")
+
+	assert.False(t, f.Enabled("user_import"))
+	assert.True(t, f.Enabled("user_export"))
+	// 未注册的开关视为开启
+	assert.True(t, f.Enabled("unknown"))
+	assert.Equal(t, []Flag{
+		{Name: "user_export", Enabled: true},
+		{Name: "user_import", Enabled: false},
+	}, f.List())
+}
+
+func TestFlags_Set(t *testing.T) {
+	f := New(nil)
+	f.Register("register")
+
+	assert.True(t, f.Set("register", false))
+	assert.False(t, f.Enabled("register"))
+
+	// 重复注册不重置运行时修改的状态
+	f.Register("register")
+	assert.False(t, f.Enabled("register"))
+
+	assert.True(t, f.Set("register", true))
+	assert.True(t, f.Enabled("register"))
+
+	// 未注册的开关不能修改
+	assert.False(t, f.Set("unknown", false))
+	assert.True(t, f.Enabled("unknown"))
+}
@@ -0,0 +1,159 @@
+// Package geoip 提供 IP 地理位置解析
+//
+// 内置两种实现：
+// - CIDRResolver：从本地 CSV 库加载网段与地理位置的映射，按最长前缀匹配
+// - StubResolver：内网/回环地址解析为 LAN，其他地址一律未知，用于未配置地址库的环境
+//
+// CSV 库格式（首行为表头，可包含注释行 #）：
+//
+//	network,country,region,city
+//	1.0.0.0/24,AU,Queensland,Brisbane
+//	2001:db8::/32,ZZ,,
+package geoip
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Location 地理位置
+type Location struct {
+	// Country 国家/地区代码，如 CN、US
+	Country string `json:"country"`
+	// Region 省/州
+	Region string `json:"region,omitempty"`
+	// City 城市
+	City string `json:"city,omitempty"`
+}
+
+// String 返回可读的位置描述，如 "CN/Guangdong/Shenzhen"
+func (l *Location) String() string {
+	if l == nil {
+		return ""
+	}
+	parts := []string{l.Country}
+	if l.Region != "" {
+		parts = append(parts, l.Region)
+	}
+	if l.City != "" {
+		parts = append(parts, l.City)
+	}
+	return strings.Join(parts, "/")
+}
+
+// CountryLAN 内网地址的国家代码
+const CountryLAN = "LAN"
+
+// isLocal 是否为内网、回环或链路本地地址
+func isLocal(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast()
+}
+
+// StubResolver 不依赖地址库的占位实现
+type StubResolver struct{}
+
+// Resolve 内网地址返回 LAN，其他地址返回 nil 表示未知
+func (StubResolver) Resolve(_ context.Context, ip string) (*Location, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil, fmt.Errorf("无效的 IP 地址: %s", ip)
+	}
+	if isLocal(parsed) {
+		return &Location{Country: CountryLAN}, nil
+	}
+	return nil, nil
+}
+
+// cidrEntry 网段与位置
+type cidrEntry struct {
+	network  *net.IPNet
+	prefix   int
+	location Location
+}
+
+// CIDRResolver 基于本地网段库的实现
+type CIDRResolver struct {
+	entries []cidrEntry
+}
+
+// LoadCIDRFile 从 CSV 文件加载网段库
+func LoadCIDRFile(path string) (*CIDRResolver, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开 GeoIP 库失败: %w", err)
+	}
+	defer f.Close()
+	return LoadCIDR(f)
+}
+
+// LoadCIDR 从 CSV 内容加载网段库
+func LoadCIDR(r io.Reader) (*CIDRResolver, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("解析 GeoIP 库失败: %w", err)
+	}
+
+	resolver := &CIDRResolver{}
+	for i, record := range records {
+		// 跳过表头
+		if i == 0 && len(record) > 0 && strings.EqualFold(strings.TrimSpace(record[0]), "network") {
+			continue
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("GeoIP 库第 %d 行字段不足", i+1)
+		}
+		_, network, err := net.ParseCIDR(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("GeoIP 库第 %d 行网段无效: %w", i+1, err)
+		}
+		prefix, _ := network.Mask.Size()
+		loc := Location{Country: strings.TrimSpace(record[1])}
+		if len(record) > 2 {
+			loc.Region = strings.TrimSpace(record[2])
+		}
+		if len(record) > 3 {
+			loc.City = strings.TrimSpace(record[3])
+		}
+		resolver.entries = append(resolver.entries, cidrEntry{network: network, prefix: prefix, location: loc})
+	}
+
+	// 前缀越长越精确，优先匹配
+	sort.SliceStable(resolver.entries, func(i, j int) bool {
+		return resolver.entries[i].prefix > resolver.entries[j].prefix
+	})
+	return resolver, nil
+}
+
+// Resolve 按最长前缀匹配解析 IP，未收录的公网地址返回 nil
+func (r *CIDRResolver) Resolve(_ context.Context, ip string) (*Location, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil, fmt.Errorf("无效的 IP 地址: %s", ip)
+	}
+	for _, e := range r.entries {
+		if e.network.Contains(parsed) {
+			loc := e.location
+			return &loc, nil
+		}
+	}
+	if isLocal(parsed) {
+		return &Location{Country: CountryLAN}, nil
+	}
+	return nil, nil
+}
+
+// Len 返回已加载的网段数
+func (r *CIDRResolver) Len() int {
+	return len(r.entries)
+}
@@ -0,0 +1,62 @@
+package geoip
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDB = `network,country,region,city
+# 注释行
+203.0.113.0/24,CN,Shanghai,Shanghai
+203.0.113.128/25,CN,Zhejiang,Hangzhou
+198.51.100.0/24,US,California,
+2001:db8::/32,JP,,
+`
+
+func TestCIDRResolver_Resolve(t *testing.T) {
+	r, err := LoadCIDR(strings.NewReader(testDB))
+	require.NoError(t, err)
+	assert.Equal(t, 4, r.Len())
+	ctx := context.Background()
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"203.0.113.10", "CN/Shanghai/Shanghai"},
+		{"203.0.113.200", "CN/Zhejiang/Hangzhou"}, // 最长前缀优先
+		{"198.51.100.1", "US/California"},
+		{"2001:db8::1", "JP"},
+		{"10.0.0.1", "LAN"},
+		{"192.0.2.1", ""}, // 未收录
+	}
+	for _, tt := range tests {
+		loc, err := r.Resolve(ctx, tt.ip)
+		require.NoError(t, err, tt.ip)
+		assert.Equal(t, tt.want, loc.String(), tt.ip)
+	}
+
+	_, err = r.Resolve(ctx, "not-an-ip")
+	assert.Error(t, err)
+}
+
+func TestLoadCIDR_InvalidNetwork(t *testing.T) {
+	_, err := LoadCIDR(strings.NewReader("network,country\n300.0.0.0/8,XX\n"))
+	assert.Error(t, err)
+}
+
+func TestStubResolver_Resolve(t *testing.T) {
+	ctx := context.Background()
+
+	loc, err := StubResolver{}.Resolve(ctx, "127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, CountryLAN, loc.Country)
+
+	loc, err = StubResolver{}.Resolve(ctx, "8.8.8.8")
+	require.NoError(t, err)
+	assert.Nil(t, loc)
+}
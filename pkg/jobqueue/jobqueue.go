@@ -0,0 +1,313 @@
+// Package jobqueue 提供异步任务队列
+//
+// 管理后台触发的批量操作（如给一批用户打标）耗时较长，不适合在请求中同步完成。
+// 调用方将任务提交到队列后立即返回任务 ID，后台 worker 逐个执行，
+// 执行过程中通过 ProgressFunc 上报进度，调用方可随时查询任务状态。
+//
+// 使用示例：
+//
+//	q := jobqueue.NewMemoryQueue(2, 100)
+//	q.Start()
+//	defer q.Shutdown(ctx)
+//
+//	job, err := q.Submit("batch_tag", func(ctx context.Context, progress jobqueue.ProgressFunc) error {
+//		// ... 分批处理，每批完成后调用 progress(processed, total)
+//		return nil
+//	})
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status 任务状态
+type Status string
+
+const (
+	// StatusPending 已入队，等待执行
+	StatusPending Status = "pending"
+	// StatusRunning 执行中
+	StatusRunning Status = "running"
+	// StatusDone 执行成功
+	StatusDone Status = "done"
+	// StatusFailed 执行失败
+	StatusFailed Status = "failed"
+)
+
+// 队列错误
+var (
+	// ErrQueueFull 队列已满，无法提交新任务
+	ErrQueueFull = errors.New("任务队列已满")
+	// ErrQueueClosed 队列已关闭，无法提交新任务
+	ErrQueueClosed = errors.New("任务队列已关闭")
+)
+
+// Job 任务状态快照
+type Job struct {
+	// ID 任务 ID
+	ID string `json:"id"`
+	// Type 任务类型
+	Type string `json:"type"`
+	// Status 任务状态
+	Status Status `json:"status"`
+	// Total 需要处理的总数，未知时为 0
+	Total int `json:"total"`
+	// Processed 已处理的数量
+	Processed int `json:"processed"`
+	// Error 失败原因
+	Error string `json:"error,omitempty"`
+	// Result 任务产出的结果信息（如生成文件的格式与路径），由任务通过 SetResult 记录
+	Result Result `json:"result,omitempty"`
+	// CreatedAt 提交时间
+	CreatedAt time.Time `json:"created_at"`
+	// StartedAt 开始执行时间
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	// FinishedAt 结束时间
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Result 任务的结果信息，键名由任务决定
+type Result map[string]string
+
+// OpaqueJSON 实现 response.Opaque，响应中的键名原样输出
+func (Result) OpaqueJSON() {}
+
+// Finished 任务是否已结束（成功或失败）
+func (j *Job) Finished() bool {
+	return j.Status == StatusDone || j.Status == StatusFailed
+}
+
+// ProgressFunc 任务进度上报函数
+type ProgressFunc func(processed, total int)
+
+// TaskFunc 任务执行函数
+// ctx 在队列关闭超时后被取消，任务应尽快返回
+type TaskFunc func(ctx context.Context, progress ProgressFunc) error
+
+// Queue 任务队列接口
+type Queue interface {
+	// Submit 提交任务，返回任务的初始状态
+	Submit(jobType string, task TaskFunc) (*Job, error)
+	// Get 查询任务状态，返回的是快照副本
+	Get(id string) (*Job, bool)
+	// SetResult 记录任务的结果信息，任务不存在时返回 false
+	SetResult(id string, result map[string]string) bool
+}
+
+// defaultRetention 已结束任务的保留时间，超过后在提交新任务时清理
+const defaultRetention = 24 * time.Hour
+
+// queuedTask 待执行的任务
+type queuedTask struct {
+	id   string
+	task TaskFunc
+}
+
+// MemoryQueue 基于内存的任务队列实现
+// 任务状态只保存在当前进程中，重启后丢失；多实例部署时查询需路由到提交任务的实例
+type MemoryQueue struct {
+	mu      sync.RWMutex
+	jobs    map[string]*Job
+	tasks   chan queuedTask
+	workers int
+	closed  bool
+
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewMemoryQueue 创建内存任务队列
+// 参数：
+//   - workers: 并发执行任务的 worker 数
+//   - capacity: 等待执行的任务上限，超过后 Submit 返回 ErrQueueFull
+func NewMemoryQueue(workers, capacity int) *MemoryQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	if capacity < 0 {
+		capacity = 0
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &MemoryQueue{
+		jobs:      make(map[string]*Job),
+		tasks:     make(chan queuedTask, capacity),
+		workers:   workers,
+		ctx:       ctx,
+		cancel:    cancel,
+		retention: defaultRetention,
+		now:       time.Now,
+	}
+}
+
+// Start 启动 worker
+func (q *MemoryQueue) Start() {
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+}
+
+// Shutdown 关闭队列
+// 不再接受新任务，等待已入队的任务执行完毕；
+// ctx 到期时取消正在执行的任务并返回 ctx 的错误
+func (q *MemoryQueue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.tasks)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Submit 提交任务
+func (q *MemoryQueue) Submit(jobType string, task TaskFunc) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil, ErrQueueClosed
+	}
+	q.pruneLocked()
+
+	job := &Job{
+		ID:        uuid.NewString(),
+		Type:      jobType,
+		Status:    StatusPending,
+		CreatedAt: q.now(),
+	}
+
+	select {
+	case q.tasks <- queuedTask{id: job.ID, task: task}:
+	default:
+		return nil, ErrQueueFull
+	}
+
+	q.jobs[job.ID] = job
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// Get 查询任务状态
+func (q *MemoryQueue) Get(id string) (*Job, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return nil, false
+	}
+	snapshot := *job
+	return &snapshot, true
+}
+
+// SetResult 记录任务的结果信息
+// 保存的是 result 的副本，调用方之后修改 result 不影响已记录的结果
+func (q *MemoryQueue) SetResult(id string, result map[string]string) bool {
+	copied := make(Result, len(result))
+	for k, v := range result {
+		copied[k] = v
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return false
+	}
+	job.Result = copied
+	return true
+}
+
+// worker 从队列中取出任务并执行，直到队列关闭
+func (q *MemoryQueue) worker() {
+	defer q.wg.Done()
+	for t := range q.tasks {
+		q.run(t)
+	}
+}
+
+// run 执行单个任务并更新状态
+// 任务 panic 时标记为失败，不影响 worker 继续处理后续任务
+func (q *MemoryQueue) run(t queuedTask) {
+	q.update(t.id, func(job *Job) {
+		started := q.now()
+		job.Status = StatusRunning
+		job.StartedAt = &started
+	})
+
+	progress := func(processed, total int) {
+		q.update(t.id, func(job *Job) {
+			job.Processed = processed
+			job.Total = total
+			if job.Total < job.Processed {
+				job.Total = job.Processed
+			}
+		})
+	}
+
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("任务异常: %v", r)
+			}
+		}()
+		err = t.task(q.ctx, progress)
+	}()
+
+	q.update(t.id, func(job *Job) {
+		finished := q.now()
+		job.FinishedAt = &finished
+		if err != nil {
+			job.Status = StatusFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = StatusDone
+	})
+}
+
+// update 在锁内修改任务状态
+func (q *MemoryQueue) update(id string, fn func(job *Job)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if job, ok := q.jobs[id]; ok {
+		fn(job)
+	}
+}
+
+// pruneLocked 清理超过保留时间的已结束任务，调用方需持有写锁
+func (q *MemoryQueue) pruneLocked() {
+	cutoff := q.now().Add(-q.retention)
+	for id, job := range q.jobs {
+		if job.Finished() && job.FinishedAt != nil && job.FinishedAt.Before(cutoff) {
+			delete(q.jobs, id)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitFinished 轮询直到任务结束或超时
+func waitFinished(t *testing.T, q *MemoryQueue, id string) *Job {
+	t.Helper()
+	var job *Job
+	require.Eventually(t, func() bool {
+		var ok bool
+		job, ok = q.Get(id)
+		return ok && job.Finished()
+	}, 2*time.Second, 5*time.Millisecond)
+	return job
+}
+
+func TestMemoryQueue_SubmitRunsToDone(t *testing.T) {
+	// 准备
+	q := NewMemoryQueue(1, 10)
+	q.Start()
+	defer q.Shutdown(context.Background())
+
+	// 执行
+	job, err := q.Submit("batch_tag", func(ctx context.Context, progress ProgressFunc) error {
+		progress(0, 3)
+		progress(2, 3)
+		progress(3, 3)
+		return nil
+	})
+
+	// 断言
+	require.NoError(t, err)
+	assert.NotEmpty(t, job.ID)
+	assert.Equal(t, "batch_tag", job.Type)
+	assert.Equal(t, StatusPending, job.Status)
+
+	finished := waitFinished(t, q, job.ID)
+	assert.Equal(t, StatusDone, finished.Status)
+	assert.Equal(t, 3, finished.Processed)
+	assert.Equal(t, 3, finished.Total)
+	assert.NotNil(t, finished.StartedAt)
+	assert.NotNil(t, finished.FinishedAt)
+	assert.Empty(t, finished.Error)
+}
+
+func TestMemoryQueue_SetResult(t *testing.T) {
+	// 准备
+	q := NewMemoryQueue(1, 10)
+	q.Start()
+	defer q.Shutdown(context.Background())
+
+	// 执行：任务通过 channel 拿到自身 ID 后记录结果
+	ids := make(chan string, 1)
+	job, err := q.Submit("export", func(ctx context.Context, progress ProgressFunc) error {
+		q.SetResult(<-ids, map[string]string{"format": "csv"})
+		return nil
+	})
+	require.NoError(t, err)
+	ids <- job.ID
+
+	// 断言
+	finished := waitFinished(t, q, job.ID)
+	assert.Equal(t, Result{"format": "csv"}, finished.Result)
+	assert.False(t, q.SetResult("missing", nil))
+}
+
+func TestMemoryQueue_TaskErrorMarksFailed(t *testing.T) {
+	// 准备
+	q := NewMemoryQueue(1, 10)
+	q.Start()
+	defer q.Shutdown(context.Background())
+
+	// 执行
+	job, err := q.Submit("batch_tag", func(ctx context.Context, progress ProgressFunc) error {
+		return errors.New("boom")
+	})
+	require.NoError(t, err)
+
+	// 断言
+	finished := waitFinished(t, q, job.ID)
+	assert.Equal(t, StatusFailed, finished.Status)
+	assert.Equal(t, "boom", finished.Error)
+}
+
+func TestMemoryQueue_TaskPanicMarksFailed(t *testing.T) {
+	// 准备
+	q := NewMemoryQueue(1, 10)
+	q.Start()
+	defer q.Shutdown(context.Background())
+
+	// 执行：panic 的任务不应影响后续任务
+	panicked, err := q.Submit("panic", func(ctx context.Context, progress ProgressFunc) error {
+		panic("unexpected")
+	})
+	require.NoError(t, err)
+	next, err := q.Submit("ok", func(ctx context.Context, progress ProgressFunc) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	// 断言
+	assert.Equal(t, StatusFailed, waitFinished(t, q, panicked.ID).Status)
+	assert.Equal(t, StatusDone, waitFinished(t, q, next.ID).Status)
+}
+
+func TestMemoryQueue_SubmitQueueFull(t *testing.T) {
+	// 准备：不启动 worker，容量为 1
+	q := NewMemoryQueue(1, 1)
+	noop := func(ctx context.Context, progress ProgressFunc) error { return nil }
+
+	// 执行
+	_, err := q.Submit("a", noop)
+	require.NoError(t, err)
+	_, err = q.Submit("b", noop)
+
+	// 断言
+	assert.ErrorIs(t, err, ErrQueueFull)
+}
+
+func TestMemoryQueue_SubmitAfterShutdown(t *testing.T) {
+	// 准备
+	q := NewMemoryQueue(1, 1)
+	q.Start()
+	require.NoError(t, q.Shutdown(context.Background()))
+
+	// 执行
+	_, err := q.Submit("a", func(ctx context.Context, progress ProgressFunc) error { return nil })
+
+	// 断言
+	assert.ErrorIs(t, err, ErrQueueClosed)
+}
+
+func TestMemoryQueue_ShutdownTimeoutCancelsRunningTask(t *testing.T) {
+	// 准备
+	q := NewMemoryQueue(1, 1)
+	q.Start()
+	started := make(chan struct{})
+	job, err := q.Submit("slow", func(ctx context.Context, progress ProgressFunc) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	require.NoError(t, err)
+	<-started
+
+	// 执行
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = q.Shutdown(ctx)
+
+	// 断言
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	finished, ok := q.Get(job.ID)
+	require.True(t, ok)
+	assert.Equal(t, StatusFailed, finished.Status)
+}
+
+func TestMemoryQueue_PrunesExpiredJobs(t *testing.T) {
+	// 准备
+	q := NewMemoryQueue(1, 10)
+	q.Start()
+	defer q.Shutdown(context.Background())
+
+	old, err := q.Submit("old", func(ctx context.Context, progress ProgressFunc) error { return nil })
+	require.NoError(t, err)
+	waitFinished(t, q, old.ID)
+
+	// 执行：时间推进到保留期之后再提交新任务
+	q.mu.Lock()
+	q.now = func() time.Time { return time.Now().Add(defaultRetention + time.Hour) }
+	q.mu.Unlock()
+	_, err = q.Submit("new", func(ctx context.Context, progress ProgressFunc) error { return nil })
+	require.NoError(t, err)
+
+	// 断言
+	_, ok := q.Get(old.ID)
+	assert.False(t, ok)
+}
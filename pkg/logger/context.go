@@ -0,0 +1,80 @@
+// Package logger 提供统一的日志记录功能
+//
+// 本文件提供在 context.Context 中传递请求级日志字段的工具，
+// 使得无法访问 HTTP 上下文的下层（如数据库日志）也能关联到具体请求。
+package logger
+
+import "context"
+
+// contextKey 上下文键类型，避免与其他包的键冲突
+type contextKey string
+
+const (
+	// requestIDContextKey 请求 ID 上下文键
+	requestIDContextKey contextKey = "request_id"
+	// userIDContextKey 用户 ID 上下文键
+	userIDContextKey contextKey = "user_id"
+	// impersonatorContextKey 模拟登录的真实操作者上下文键
+	impersonatorContextKey contextKey = "impersonated_by"
+)
+
+// ContextWithRequestID 返回携带请求 ID 的上下文
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, requestID)
+}
+
+// ContextWithUserID 返回携带用户 ID 的上下文
+func ContextWithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDContextKey, userID)
+}
+
+// ContextWithImpersonator 返回携带模拟登录真实操作者 ID 的上下文
+func ContextWithImpersonator(ctx context.Context, operatorID string) context.Context {
+	return context.WithValue(ctx, impersonatorContextKey, operatorID)
+}
+
+// RequestIDFromContext 从上下文中获取请求 ID
+// 不存在时返回空字符串
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+// UserIDFromContext 从上下文中获取用户 ID
+// 不存在时返回空字符串
+func UserIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(userIDContextKey).(string)
+	return id
+}
+
+// ImpersonatorFromContext 从上下文中获取模拟登录的真实操作者 ID
+// 非模拟登录请求返回空字符串
+func ImpersonatorFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(impersonatorContextKey).(string)
+	return id
+}
+
+// FieldsFromContext 从上下文中提取请求级日志字段
+// 只返回上下文中实际存在的字段
+func FieldsFromContext(ctx context.Context) []Field {
+	var fields []Field
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		fields = append(fields, String("request_id", requestID))
+	}
+	if userID := UserIDFromContext(ctx); userID != "" {
+		fields = append(fields, String("user_id", userID))
+	}
+	if operatorID := ImpersonatorFromContext(ctx); operatorID != "" {
+		fields = append(fields, String("impersonated_by", operatorID))
+	}
+	return fields
+}
@@ -0,0 +1,46 @@
+// Package logger 提供统一的日志记录功能
+//
+// 本文件提供应用错误（AppError）的结构化日志字段，
+// 使日志平台可以按错误码聚合统计。
+package logger
+
+import (
+	stderrors "errors"
+
+	"github.com/example/go-user-api/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// AppErr 创建错误字段
+// err 链中含 *errors.AppError 时展开为 error_code、error_message、error_detail（为空时省略）
+// 与 error 四个平铺字段，便于按错误码聚合；普通错误等同于 Err，err 为 nil 时不输出字段
+func AppErr(err error) Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	var appErr *errors.AppError
+	if !stderrors.As(err, &appErr) {
+		return Err(err)
+	}
+	return zap.Inline(appErrorFields{err: err, appErr: appErr})
+}
+
+// appErrorFields AppError 的平铺日志字段
+type appErrorFields struct {
+	// err 原始错误，可能是包装了 AppError 的错误
+	err    error
+	appErr *errors.AppError
+}
+
+// MarshalLogObject 实现 zapcore.ObjectMarshaler
+func (f appErrorFields) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt("error_code", f.appErr.Code)
+	enc.AddString("error_message", f.appErr.Message)
+	if f.appErr.Detail != "" {
+		enc.AddString("error_detail", f.appErr.Detail)
+	}
+	// 与 Err 保持同名字段，不使用 AppErr 的日志查询条件不受影响
+	enc.AddString("error", f.err.Error())
+	return nil
+}
@@ -0,0 +1,57 @@
+// Package logger 提供统一的日志记录功能
+//
+// 本文件包含错误日志字段的单元测试
+package logger
+
+import (
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+// encodeField 将字段编码为 map，便于断言
+func encodeField(f Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	return enc.Fields
+}
+
+func TestAppErr_AppError(t *testing.T) {
+	// 准备
+	err := errors.ErrQuotaExceeded.WithDetail("本月已用 900 / 1000 token")
+
+	// 执行
+	fields := encodeField(AppErr(err))
+
+	// 断言
+	assert.Equal(t, errors.CodeQuotaExceeded, fields["error_code"])
+	assert.Equal(t, err.Message, fields["error_message"])
+	assert.Equal(t, "本月已用 900 / 1000 token", fields["error_detail"])
+	assert.Equal(t, err.Error(), fields["error"])
+}
+
+func TestAppErr_WrappedAppErrorWithoutDetail(t *testing.T) {
+	// 准备
+	err := fmt.Errorf("导入第 3 行: %w", errors.ErrUserNotFound)
+
+	// 执行
+	fields := encodeField(AppErr(err))
+
+	// 断言：包装后仍能识别错误码，error 保留完整错误链
+	assert.Equal(t, errors.CodeUserNotFound, fields["error_code"])
+	assert.NotContains(t, fields, "error_detail")
+	assert.Equal(t, err.Error(), fields["error"])
+}
+
+func TestAppErr_PlainError(t *testing.T) {
+	// 执行
+	fields := encodeField(AppErr(stderrors.New("connection refused")))
+
+	// 断言：退化为 Err
+	assert.Equal(t, map[string]interface{}{"error": "connection refused"}, fields)
+	assert.Empty(t, encodeField(AppErr(nil)))
+}
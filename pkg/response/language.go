@@ -0,0 +1,125 @@
+// Package response 提供统一的 HTTP 响应格式
+//
+// 本文件实现了错误消息的语言选择。
+// 默认输出中文消息，客户端可通过请求头要求英文：
+//
+//	X-Lang: en
+//	Accept-Language: en-US,en;q=0.9
+//
+// 成功响应的消息固定为 "success"，只有错误响应按错误码翻译。
+package response
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/example/go-user-api/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// Language 响应语言
+type Language string
+
+const (
+	// LanguageZhCN 简体中文（默认）
+	LanguageZhCN Language = "zh-CN"
+	// LanguageEn 英文
+	LanguageEn Language = "en"
+)
+
+const (
+	// LanguageHeader 强制指定响应语言的请求头，优先于 Accept-Language
+	LanguageHeader = "X-Lang"
+	// ContextKeyLanguage 响应语言在 gin.Context 中的键
+	ContextKeyLanguage = "language"
+)
+
+// ParseLanguage 解析语言标签
+// 按基础语言匹配（不区分大小写）：zh、zh-CN、zh_Hans 均为中文，en、en-US 均为英文；无法识别时返回 false
+func ParseLanguage(s string) (Language, bool) {
+	tag := strings.ToLower(strings.TrimSpace(s))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	switch tag {
+	case "zh":
+		return LanguageZhCN, true
+	case "en":
+		return LanguageEn, true
+	default:
+		return "", false
+	}
+}
+
+// ParseAcceptLanguage 从 Accept-Language 头中选出支持的语言
+// 按 q 值从高到低匹配，q 相同时保持原顺序；没有支持的语言时返回 false
+func ParseAcceptLanguage(header string) (Language, bool) {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{tag: tag, q: q})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	for _, c := range candidates {
+		if lang, ok := ParseLanguage(c.tag); ok {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+// SetLanguage 设置当前请求的响应语言
+// 通常由中间件根据请求头或配置调用
+func SetLanguage(c *gin.Context, lang Language) {
+	c.Set(ContextKeyLanguage, lang)
+}
+
+// GetLanguage 获取当前请求的响应语言，未设置时为中文
+func GetLanguage(c *gin.Context) Language {
+	if v, exists := c.Get(ContextKeyLanguage); exists {
+		if lang, ok := v.(Language); ok {
+			return lang
+		}
+	}
+	return LanguageZhCN
+}
+
+// localizeMessage 按当前请求的语言翻译错误消息
+// 成功响应、中文请求或未收录的错误码保持原消息
+func localizeMessage(c *gin.Context, code int, message string) string {
+	if code == CodeSuccess {
+		return message
+	}
+	lang := GetLanguage(c)
+	if lang == LanguageZhCN {
+		return message
+	}
+	if msg, ok := errors.LocalizedMessage(code, string(lang)); ok {
+		return msg
+	}
+	return message
+}
@@ -0,0 +1,31 @@
+// Package response 提供统一的 HTTP 响应格式
+//
+// 本文件包含响应语言解析的单元测试
+package response
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Language
+		ok     bool
+	}{
+		{"en-US,en;q=0.9", LanguageEn, true},
+		{"zh-CN,zh;q=0.9,en;q=0.8", LanguageZhCN, true},
+		{"fr-FR, en;q=0.5, zh;q=0.7", LanguageZhCN, true},
+		{"en;q=0, zh", LanguageZhCN, true},
+		{"fr, de", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseAcceptLanguage(tt.header)
+		assert.Equal(t, tt.ok, ok, tt.header)
+		assert.Equal(t, tt.want, got, tt.header)
+	}
+}
@@ -0,0 +1,325 @@
+// Package response 提供统一的 HTTP 响应格式
+//
+// 本文件实现了响应字段命名风格的转换。
+// 默认输出 snake_case，部分客户端可通过请求头或配置要求 camelCase：
+//
+//	X-Naming-Convention: camelCase
+package response
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NamingConvention 响应字段命名风格
+type NamingConvention string
+
+const (
+	// NamingSnakeCase 下划线风格，例如 user_id（默认）
+	NamingSnakeCase NamingConvention = "snake_case"
+	// NamingCamelCase 小驼峰风格，例如 userId
+	NamingCamelCase NamingConvention = "camelCase"
+)
+
+const (
+	// NamingConventionHeader 指定响应命名风格的请求头
+	NamingConventionHeader = "X-Naming-Convention"
+	// ContextKeyNamingConvention 命名风格在 gin.Context 中的键
+	ContextKeyNamingConvention = "namingConvention"
+)
+
+// ParseNamingConvention 解析命名风格字符串
+// 支持 snake_case/snake 与 camelCase/camel（不区分大小写），无法识别时返回 false
+func ParseNamingConvention(s string) (NamingConvention, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "snake_case", "snake":
+		return NamingSnakeCase, true
+	case "camelcase", "camel":
+		return NamingCamelCase, true
+	default:
+		return "", false
+	}
+}
+
+// SetNamingConvention 设置当前请求的响应命名风格
+// 通常由中间件根据请求头或配置调用
+func SetNamingConvention(c *gin.Context, convention NamingConvention) {
+	c.Set(ContextKeyNamingConvention, convention)
+}
+
+// getNamingConvention 获取当前请求的响应命名风格，未设置时为 snake_case
+func getNamingConvention(c *gin.Context) NamingConvention {
+	if v, exists := c.Get(ContextKeyNamingConvention); exists {
+		if convention, ok := v.(NamingConvention); ok {
+			return convention
+		}
+	}
+	return NamingSnakeCase
+}
+
+// Opaque 标记值内的键名原样输出，不做命名风格转换
+// 令牌额外声明、任务结果、EXPLAIN 结果行等值的键名由调用方或数据库决定，转换后客户端无法按原名读取，
+// 这类值的类型实现该接口即可（无需引用本包，声明同名方法即可）。json.RawMessage 同样原样输出
+type Opaque interface {
+	// OpaqueJSON 仅用于标记，不会被调用
+	OpaqueJSON()
+}
+
+var (
+	opaqueType        = reflect.TypeOf((*Opaque)(nil)).Elem()
+	rawMessageType    = reflect.TypeOf(json.RawMessage(nil))
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// ConvertKeys 按指定命名风格转换数据中所有 JSON 对象的键名（包括嵌套结构）
+// 数据先按其 json tag 序列化，再递归转换键名；序列化失败时原样返回。
+// 实现 Opaque 的值与 json.RawMessage 不做转换
+func ConvertKeys(data interface{}, convention NamingConvention) interface{} {
+	// 序列化后类型信息丢失，先从原始数据中记录不转换的值所在位置
+	opaque := collectOpaque(reflect.ValueOf(data))
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	// 保留数字精度，避免大整数被转换为浮点数
+	decoder.UseNumber()
+
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return data
+	}
+
+	convert := toSnakeCase
+	if convention == NamingCamelCase {
+		convert = toCamelCase
+	}
+	return convertKeys(generic, convert, opaque)
+}
+
+// convertKeys 递归转换 map 的键名，opaque 标记的值原样保留
+func convertKeys(v interface{}, convert func(string) string, opaque *opaqueNode) interface{} {
+	if opaque.isOpaque() {
+		return v
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			result[convert(k)] = convertKeys(item, convert, opaque.field(k))
+		}
+		return result
+	case []interface{}:
+		for i, item := range val {
+			val[i] = convertKeys(item, convert, opaque.item(i))
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// opaqueNode 记录数据中不转换键名的值所在位置，结构与序列化后的 JSON 对应
+// 不包含此类值的分支不记录，nil 表示整棵子树都正常转换
+type opaqueNode struct {
+	opaque bool
+	fields map[string]*opaqueNode
+	items  map[int]*opaqueNode
+}
+
+func (n *opaqueNode) isOpaque() bool {
+	return n != nil && n.opaque
+}
+
+func (n *opaqueNode) field(key string) *opaqueNode {
+	if n == nil {
+		return nil
+	}
+	return n.fields[key]
+}
+
+func (n *opaqueNode) item(i int) *opaqueNode {
+	if n == nil {
+		return nil
+	}
+	return n.items[i]
+}
+
+// setField 记录对象键对应的子节点，child 为 nil 时不记录
+func (n *opaqueNode) setField(key string, child *opaqueNode) *opaqueNode {
+	if child == nil {
+		return n
+	}
+	if n == nil {
+		n = &opaqueNode{}
+	}
+	if n.fields == nil {
+		n.fields = make(map[string]*opaqueNode)
+	}
+	n.fields[key] = child
+	return n
+}
+
+// collectOpaque 按 encoding/json 的规则遍历数据，找出实现 Opaque 的值与 json.RawMessage
+// 自定义了序列化方式的值无法对应到输出结构，按普通值处理
+func collectOpaque(v reflect.Value) *opaqueNode {
+	if !v.IsValid() {
+		return nil
+	}
+	t := v.Type()
+	if t == rawMessageType || t.Implements(opaqueType) {
+		return &opaqueNode{opaque: true}
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return collectOpaque(v.Elem())
+	}
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return collectStructOpaque(v, nil)
+	case reflect.Map:
+		var node *opaqueNode
+		iter := v.MapRange()
+		for iter.Next() {
+			key, ok := mapKeyString(iter.Key())
+			if !ok {
+				continue
+			}
+			node = node.setField(key, collectOpaque(iter.Value()))
+		}
+		return node
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		var node *opaqueNode
+		for i := 0; i < v.Len(); i++ {
+			child := collectOpaque(v.Index(i))
+			if child == nil {
+				continue
+			}
+			if node == nil {
+				node = &opaqueNode{}
+			}
+			if node.items == nil {
+				node.items = make(map[int]*opaqueNode)
+			}
+			node.items[i] = child
+		}
+		return node
+	default:
+		return nil
+	}
+}
+
+// collectStructOpaque 按 json tag 遍历结构体字段，未指定名称的嵌入结构体字段提升到外层
+func collectStructOpaque(v reflect.Value, node *opaqueNode) *opaqueNode {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() && !sf.Anonymous {
+			continue
+		}
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		fv := v.Field(i)
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && !ft.Implements(opaqueType) &&
+				!ft.Implements(jsonMarshalerType) && !ft.Implements(textMarshalerType) {
+				node = collectStructOpaque(fv, node)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		node = node.setField(name, collectOpaque(fv))
+	}
+	return node
+}
+
+// mapKeyString 按 encoding/json 的规则得到 map 键序列化后的字符串
+func mapKeyString(k reflect.Value) (string, bool) {
+	if k.Kind() == reflect.String {
+		return k.String(), true
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		return string(b), err == nil
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return fmt.Sprint(k.Interface()), true
+	default:
+		return "", false
+	}
+}
+
+// toCamelCase 将 snake_case 转换为 camelCase
+func toCamelCase(s string) string {
+	if !strings.Contains(s, "_") {
+		return s
+	}
+	parts := strings.Split(s, "_")
+	var b strings.Builder
+	b.WriteString(parts[0])
+	for _, part := range parts[1:] {
+		if part == "" {
+			continue
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
+
+// toSnakeCase 将 camelCase 转换为 snake_case
+func toSnakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
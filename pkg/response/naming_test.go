@@ -0,0 +1,141 @@
+// Package response 提供统一的 HTTP 响应格式
+//
+// 本文件包含响应命名风格转换的单元测试
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// namingTestData 带嵌套结构的测试数据
+type namingTestData struct {
+	UserID  string `json:"user_id"`
+	Profile struct {
+		NickName  string `json:"nick_name"`
+		LastLogin string `json:"last_login"`
+	} `json:"profile"`
+	RecentItems []map[string]int `json:"recent_items"`
+}
+
+func newNamingTestData() namingTestData {
+	var data namingTestData
+	data.UserID = "u-1"
+	data.Profile.NickName = "alice"
+	data.Profile.LastLogin = "2024-01-01"
+	data.RecentItems = []map[string]int{{"item_count": 3}}
+	return data
+}
+
+// performNamingRequest 以指定命名风格请求一个返回测试数据的端点
+func performNamingRequest(t *testing.T, convention NamingConvention) map[string]interface{} {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.GET("/data", func(c *gin.Context) {
+		SetNamingConvention(c, convention)
+		Success(c, newNamingTestData())
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/data", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return body
+}
+
+func TestJSON_NamingConvention(t *testing.T) {
+	// snake_case（默认）
+	snake := performNamingRequest(t, NamingSnakeCase)
+	data := snake["data"].(map[string]interface{})
+	assert.Equal(t, "u-1", data["user_id"])
+	assert.Equal(t, "alice", data["profile"].(map[string]interface{})["nick_name"])
+	assert.Contains(t, data["recent_items"].([]interface{})[0], "item_count")
+
+	// camelCase，嵌套结构与数组中的对象同样被转换
+	camel := performNamingRequest(t, NamingCamelCase)
+	data = camel["data"].(map[string]interface{})
+	assert.Equal(t, "u-1", data["userId"])
+	assert.NotContains(t, data, "user_id")
+	profile := data["profile"].(map[string]interface{})
+	assert.Equal(t, "alice", profile["nickName"])
+	assert.Equal(t, "2024-01-01", profile["lastLogin"])
+	assert.Contains(t, data["recentItems"].([]interface{})[0], "itemCount")
+}
+
+func TestConvertKeys_CamelToSnake(t *testing.T) {
+	input := map[string]interface{}{
+		"userId": "u-1",
+		"profile": map[string]interface{}{
+			"nickName": "alice",
+		},
+	}
+
+	result := ConvertKeys(input, NamingSnakeCase).(map[string]interface{})
+
+	assert.Equal(t, "u-1", result["user_id"])
+	assert.Equal(t, "alice", result["profile"].(map[string]interface{})["nick_name"])
+}
+
+// opaqueMap 测试用的 Opaque 值
+type opaqueMap map[string]interface{}
+
+func (opaqueMap) OpaqueJSON() {}
+
+func TestConvertKeys_KeepsOpaqueValues(t *testing.T) {
+	// 准备
+	type job struct {
+		JobID  string    `json:"job_id"`
+		Result opaqueMap `json:"result"`
+	}
+	type page struct {
+		Items []job             `json:"items"`
+		Extra map[string]string `json:"extra"`
+		Raw   json.RawMessage   `json:"raw_body"`
+	}
+	input := page{
+		Items: []job{
+			{JobID: "j-1"},
+			{JobID: "j-2", Result: opaqueMap{"file_path": "a.csv", "row_stats": map[string]int{"ok_rows": 1}}},
+		},
+		Extra: map[string]string{"dept_id": "d-1"},
+		Raw:   json.RawMessage(`{"user_id":"u-1"}`),
+	}
+
+	// 执行
+	result := ConvertKeys(input, NamingCamelCase).(map[string]interface{})
+
+	// 断言：Opaque 值与 json.RawMessage 内的键名原样保留，其他值即使字段名相同也照常转换
+	items := result["items"].([]interface{})
+	assert.Equal(t, "j-1", items[0].(map[string]interface{})["jobId"])
+	second := items[1].(map[string]interface{})
+	assert.Equal(t, "j-2", second["jobId"])
+	assert.Equal(t, "a.csv", second["result"].(map[string]interface{})["file_path"])
+	assert.Contains(t, second["result"].(map[string]interface{})["row_stats"], "ok_rows")
+	assert.Equal(t, map[string]interface{}{"deptId": "d-1"}, result["extra"])
+	assert.Equal(t, "u-1", result["rawBody"].(map[string]interface{})["user_id"])
+}
+
+func TestRaw_NamingConvention(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/health", func(c *gin.Context) {
+		SetNamingConvention(c, NamingCamelCase)
+		Raw(c, http.StatusOK, map[string]string{"build_time": "now"})
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"buildTime":"now"}`, w.Body.String())
+}
@@ -0,0 +1,168 @@
+// Package response 提供统一的 HTTP 响应格式
+//
+// 本文件实现基于 Accept 头的内容协商。
+// 客户端发送 Accept: application/xml（或 text/xml）时以 XML 输出，其他情况输出 JSON。
+// XML 的元素名与 JSON 键名一致（同样受命名风格影响），数组元素统一命名为 item；
+// 不是合法 XML 元素名的键（如数字开头的 "64"、含空格的扩展字段键）输出为 <entry key="64">：
+//
+//	<?xml version="1.0" encoding="UTF-8"?>
+//	<response>
+//	    <code>0</code>
+//	    <message>success</message>
+//	    <data>
+//	        <list><item>...</item></list>
+//	    </data>
+//	</response>
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// xmlRootElement XML 响应的根元素名
+	xmlRootElement = "response"
+	// xmlItemElement XML 数组元素名
+	xmlItemElement = "item"
+	// xmlEntryElement 键不是合法元素名时使用的元素名，原键放在 key 属性中
+	xmlEntryElement = "entry"
+	// contentTypeXML XML 响应的 Content-Type
+	contentTypeXML = "application/xml; charset=utf-8"
+)
+
+// wantsXML 根据 Accept 头判断是否以 XML 输出
+// 未携带 Accept 或接受任意类型时返回 false（默认 JSON）
+func wantsXML(c *gin.Context) bool {
+	switch c.NegotiateFormat(gin.MIMEJSON, gin.MIMEXML, gin.MIMEXML2) {
+	case gin.MIMEXML, gin.MIMEXML2:
+		return true
+	default:
+		return false
+	}
+}
+
+// render 按协商结果输出响应体
+// XML 编码失败时退回 JSON，保证客户端总能收到响应
+func render(c *gin.Context, httpCode int, body interface{}) {
+	if wantsXML(c) {
+		data, err := marshalXML(body)
+		if err == nil {
+			c.Data(httpCode, contentTypeXML, data)
+			return
+		}
+		_ = c.Error(fmt.Errorf("XML 编码失败，退回 JSON: %w", err))
+	}
+	c.JSON(httpCode, body)
+}
+
+// marshalXML 将任意值编码为 XML
+// 先按 JSON 规则序列化，再逐个 token 转为 XML 元素，
+// 使元素名与 JSON 键名、字段顺序保持一致，并支持 map 等 encoding/xml 无法直接处理的类型
+func marshalXML(v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	if err := writeXMLValue(enc, dec, xmlStartElement(xmlRootElement)); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// xmlStartElement 返回 JSON 键对应的 XML 开始标签
+// 键不是合法元素名时输出为 <entry key="...">
+func xmlStartElement(key string) xml.StartElement {
+	if isXMLName(key) {
+		return xml.StartElement{Name: xml.Name{Local: key}}
+	}
+	return xml.StartElement{
+		Name: xml.Name{Local: xmlEntryElement},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: key}},
+	}
+}
+
+// isXMLName 判断 s 能否直接用作 XML 元素名
+// 只接受字母或下划线开头、由字母、数字、下划线、连字符与点组成的名称；
+// 冒号（命名空间前缀）与 xml 开头的保留名称均视为不合法
+func isXMLName(s string) bool {
+	if s == "" || strings.HasPrefix(strings.ToLower(s), "xml") {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case unicode.IsLetter(r) || r == '_':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// writeXMLValue 从 JSON 解码器读取一个值并写为以 start 开始的 XML 元素
+// null 输出为空元素
+func writeXMLValue(enc *xml.Encoder, dec *json.Decoder, start xml.StartElement) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				key, _ := keyTok.(string)
+				if err := writeXMLValue(enc, dec, xmlStartElement(key)); err != nil {
+					return err
+				}
+			}
+		case '[':
+			for dec.More() {
+				if err := writeXMLValue(enc, dec, xmlStartElement(xmlItemElement)); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("意外的 JSON 分隔符: %v", t)
+		}
+		// 读取结束分隔符
+		if _, err := dec.Token(); err != nil && err != io.EOF {
+			return err
+		}
+	case nil:
+		// null：空元素
+	default:
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprint(t))); err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
@@ -0,0 +1,169 @@
+// Package response 提供统一的 HTTP 响应格式
+//
+// 本文件包含响应内容协商的单元测试
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// negotiateTestUser 测试用的用户数据
+type negotiateTestUser struct {
+	ID       string   `json:"id"`
+	Username string   `json:"username"`
+	Tags     []string `json:"tags"`
+}
+
+// xmlUserResponse 用于解析 XML 响应
+type xmlUserResponse struct {
+	XMLName xml.Name `xml:"response"`
+	Code    int      `xml:"code"`
+	Message string   `xml:"message"`
+	Data    struct {
+		ID       string   `xml:"id"`
+		Username string   `xml:"username"`
+		Tags     []string `xml:"tags>item"`
+	} `xml:"data"`
+}
+
+// newNegotiateTestEngine 创建带成功与失败端点的测试引擎
+func newNegotiateTestEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.GET("/user", func(c *gin.Context) {
+		Success(c, negotiateTestUser{ID: "u-1", Username: "alice", Tags: []string{"vip", "beta"}})
+	})
+	engine.GET("/missing", func(c *gin.Context) {
+		NotFound(c, "用户不存在")
+	})
+	engine.GET("/users", func(c *gin.Context) {
+		SuccessWithPagination(c, []negotiateTestUser{{ID: "u-1", Username: "alice"}}, 1, 20, 1)
+	})
+	return engine
+}
+
+// performNegotiateRequest 以指定 Accept 头请求端点
+func performNegotiateRequest(engine *gin.Engine, path, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestJSON_AcceptXML(t *testing.T) {
+	engine := newNegotiateTestEngine()
+
+	for _, accept := range []string{"application/xml", "text/xml", "application/xml;q=0.9, text/html;q=0.8"} {
+		w := performNegotiateRequest(engine, "/user", accept)
+
+		require.Equal(t, http.StatusOK, w.Code, accept)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/xml", accept)
+
+		var resp xmlUserResponse
+		require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &resp), accept)
+		assert.Equal(t, 0, resp.Code)
+		assert.Equal(t, "success", resp.Message)
+		assert.Equal(t, "u-1", resp.Data.ID)
+		assert.Equal(t, "alice", resp.Data.Username)
+		assert.Equal(t, []string{"vip", "beta"}, resp.Data.Tags)
+	}
+}
+
+func TestJSON_DefaultsToJSON(t *testing.T) {
+	engine := newNegotiateTestEngine()
+
+	for _, accept := range []string{"", "*/*", "application/json", "text/html"} {
+		w := performNegotiateRequest(engine, "/user", accept)
+
+		require.Equal(t, http.StatusOK, w.Code, accept)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json", accept)
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), accept)
+		assert.Equal(t, "alice", body["data"].(map[string]interface{})["username"])
+	}
+}
+
+func TestJSON_AcceptXML_Error(t *testing.T) {
+	engine := newNegotiateTestEngine()
+
+	w := performNegotiateRequest(engine, "/missing", "application/xml")
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/xml")
+
+	var resp struct {
+		Code    int    `xml:"code"`
+		Message string `xml:"message"`
+		Data    string `xml:"data"`
+	}
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NotZero(t, resp.Code)
+	assert.Equal(t, "用户不存在", resp.Message)
+	assert.Empty(t, resp.Data)
+}
+
+func TestJSON_AcceptXML_PageAndNaming(t *testing.T) {
+	engine := newNegotiateTestEngine()
+
+	// 分页结构
+	w := performNegotiateRequest(engine, "/users", "application/xml")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var page struct {
+		List       []negotiateTestUser `xml:"data>list>item"`
+		Page       int                 `xml:"data>pagination>page"`
+		TotalPages int                 `xml:"data>pagination>total_pages"`
+	}
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &page))
+	require.Len(t, page.List, 1)
+	assert.Equal(t, 1, page.Page)
+	assert.Equal(t, 1, page.TotalPages)
+
+	// camelCase 命名风格同样作用于 XML 元素名
+	engine.GET("/camel", func(c *gin.Context) {
+		SetNamingConvention(c, NamingCamelCase)
+		Success(c, map[string]string{"user_id": "u-1"})
+	})
+	w = performNegotiateRequest(engine, "/camel", "application/xml")
+	assert.Contains(t, w.Body.String(), "<userId>u-1</userId>")
+}
+
+func TestMarshalXML_InvalidElementNames(t *testing.T) {
+	data, err := marshalXML(map[string]interface{}{
+		"64":  1,
+		"ext": map[string]interface{}{"my key": "v", "a:b": "ns", "xmlns": "x", "ok": true},
+	})
+	require.NoError(t, err)
+
+	// 输出必须是格式良好的 XML
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		_, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err, string(data))
+	}
+
+	body := string(data)
+	assert.Contains(t, body, `<entry key="64">1</entry>`)
+	assert.Contains(t, body, `<entry key="my key">v</entry>`)
+	assert.Contains(t, body, `<entry key="a:b">ns</entry>`)
+	assert.Contains(t, body, `<entry key="xmlns">x</entry>`)
+	assert.Contains(t, body, `<ok>true</ok>`)
+}
@@ -1,6 +1,7 @@
 // Package response 提供统一的 HTTP 响应格式
 //
-// 本包定义了标准化的 JSON 响应结构，确保 API 返回格式的一致性。
+// 本包定义了标准化的响应结构，确保 API 返回格式的一致性。
+// 默认输出 JSON，可通过 Accept 头协商为 XML。
 // 所有 API 响应都应该使用本包提供的函数来构建响应。
 //
 // 响应格式示例：
@@ -18,6 +19,7 @@
 //	{
 //	    "code": 10001,
 //	    "message": "用户名已存在",
+//	    "category": "validation",
 //	    "data": null
 //	}
 //
@@ -41,6 +43,7 @@ package response
 import (
 	"net/http"
 
+	"github.com/example/go-user-api/pkg/errors"
 	"github.com/gin-gonic/gin"
 )
 
@@ -50,8 +53,12 @@ type Response struct {
 	Code int `json:"code"`
 	// Message 响应消息，成功时为 "success"，失败时为错误描述
 	Message string `json:"message"`
+	// Category 错误分类，仅错误响应输出（见 errors.CategoryOf）
+	Category string `json:"category,omitempty"`
 	// Data 响应数据，可以是任意类型
 	Data interface{} `json:"data"`
+	// ServerTime 服务器时间（Unix 毫秒时间戳），仅在开启时输出（见 server_time.go）
+	ServerTime int64 `json:"server_time,omitempty"`
 }
 
 // Pagination 分页信息
@@ -64,6 +71,8 @@ type Pagination struct {
 	Total int64 `json:"total"`
 	// TotalPages 总页数
 	TotalPages int `json:"total_pages"`
+	// TotalUnknown 未统计总数（客户端要求跳过计数），此时 Total 为 -1、TotalPages 为 0
+	TotalUnknown bool `json:"total_unknown,omitempty"`
 }
 
 // PageData 分页数据响应
@@ -94,36 +103,65 @@ const (
 	CodeValidationError = 10007
 	// CodeTooManyRequests 请求过于频繁
 	CodeTooManyRequests = 10008
+	// CodeServiceUnavailable 服务暂不可用
+	CodeServiceUnavailable = 10009
 )
 
 // 常用消息定义
 const (
-	MsgSuccess           = "success"
-	MsgBadRequest        = "请求参数错误"
-	MsgUnauthorized      = "请先登录"
-	MsgForbidden         = "没有权限访问"
-	MsgNotFound          = "资源不存在"
-	MsgConflict          = "资源已存在"
-	MsgInternalError     = "服务器内部错误"
-	MsgValidationError   = "数据验证失败"
-	MsgTooManyRequests   = "请求过于频繁，请稍后再试"
-	MsgInvalidToken      = "无效的令牌"
-	MsgTokenExpired      = "令牌已过期"
-	MsgUserNotFound      = "用户不存在"
-	MsgWrongPassword     = "密码错误"
-	MsgUserAlreadyExists = "用户已存在"
-	MsgEmailAlreadyUsed  = "邮箱已被使用"
+	MsgSuccess            = "success"
+	MsgBadRequest         = "请求参数错误"
+	MsgUnauthorized       = "请先登录"
+	MsgForbidden          = "没有权限访问"
+	MsgNotFound           = "资源不存在"
+	MsgConflict           = "资源已存在"
+	MsgInternalError      = "服务器内部错误"
+	MsgValidationError    = "数据验证失败"
+	MsgTooManyRequests    = "请求过于频繁，请稍后再试"
+	MsgServiceUnavailable = "服务暂不可用，请稍后再试"
+	MsgInvalidToken       = "无效的令牌"
+	MsgTokenExpired       = "令牌已过期"
+	MsgUserNotFound       = "用户不存在"
+	MsgWrongPassword      = "密码错误"
+	MsgUserAlreadyExists  = "用户已存在"
+	MsgEmailAlreadyUsed   = "邮箱已被使用"
 )
 
-// JSON 发送 JSON 响应
+// JSON 发送统一格式的响应
+// 默认输出 JSON，请求的 Accept 头要求 XML 时输出 XML（见 negotiate.go）；
+// 响应体输出前按当前请求转换时区与键名（见 Transform）；
+// 错误消息按当前请求的语言翻译（见 language.go），并按错误码附带错误分类；
+// 当前请求开启了服务器时间戳时附带 server_time（见 server_time.go）
 func JSON(c *gin.Context, httpCode int, code int, message string, data interface{}) {
-	c.JSON(httpCode, Response{
-		Code:    code,
-		Message: message,
-		Data:    data,
+	Raw(c, httpCode, Response{
+		Code:       code,
+		Message:    localizeMessage(c, code, message),
+		Category:   errors.CategoryOf(code),
+		Data:       data,
+		ServerTime: serverTime(c),
 	})
 }
 
+// Raw 发送不带统一包装的响应，用于健康检查等约定了响应结构的接口
+// 与 JSON 相同，输出前经过 Transform 并按 Accept 头协商格式
+func Raw(c *gin.Context, httpCode int, body interface{}) {
+	render(c, httpCode, Transform(c, body))
+}
+
+// Transform 按当前请求的要求转换响应数据，所有输出给客户端的数据都应经过这里
+// 要求 camelCase 命名风格时转换所有键名（见 naming.go）；
+// 指定了时区时转换所有时间字段（见 timezone.go）。
+// 键名转换需要原始数据的类型信息识别 Opaque 值，因此先于时区转换执行
+func Transform(c *gin.Context, data interface{}) interface{} {
+	if convention := getNamingConvention(c); convention != NamingSnakeCase {
+		data = ConvertKeys(data, convention)
+	}
+	if loc := getTimezone(c); loc != nil {
+		data = ConvertTimes(data, loc)
+	}
+	return data
+}
+
 // Success 发送成功响应
 func Success(c *gin.Context, data interface{}) {
 	JSON(c, http.StatusOK, CodeSuccess, MsgSuccess, data)
@@ -139,13 +177,32 @@ func Created(c *gin.Context, data interface{}) {
 	JSON(c, http.StatusCreated, CodeSuccess, MsgSuccess, data)
 }
 
+// Accepted 发送已受理响应（用于异步任务）
+func Accepted(c *gin.Context, data interface{}) {
+	JSON(c, http.StatusAccepted, CodeSuccess, MsgSuccess, data)
+}
+
 // NoContent 发送无内容响应（用于删除操作）
 func NoContent(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
 // SuccessWithPagination 发送分页数据响应
+// total 为负数表示总数未知，分页信息中标记 total_unknown
 func SuccessWithPagination(c *gin.Context, list interface{}, page, pageSize int, total int64) {
+	if total < 0 {
+		Success(c, PageData{
+			List: list,
+			Pagination: Pagination{
+				Page:         page,
+				PageSize:     pageSize,
+				Total:        -1,
+				TotalUnknown: true,
+			},
+		})
+		return
+	}
+
 	totalPages := int(total) / pageSize
 	if int(total)%pageSize > 0 {
 		totalPages++
@@ -275,3 +332,11 @@ func AbortWithTooManyRequests(c *gin.Context, message string) {
 	}
 	Abort(c, http.StatusTooManyRequests, CodeTooManyRequests, message)
 }
+
+// AbortWithServiceUnavailable 中止请求并发送服务暂不可用响应
+func AbortWithServiceUnavailable(c *gin.Context, message string) {
+	if message == "" {
+		message = MsgServiceUnavailable
+	}
+	Abort(c, http.StatusServiceUnavailable, CodeServiceUnavailable, message)
+}
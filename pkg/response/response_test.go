@@ -0,0 +1,77 @@
+// Package response 提供统一的 HTTP 响应格式
+//
+// 本文件包含分页响应与错误分类输出的单元测试
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// paginationOf 以指定 total 输出分页响应并解析分页信息
+func paginationOf(t *testing.T, total int64) map[string]interface{} {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.GET("/list", func(c *gin.Context) {
+		SuccessWithPagination(c, []string{"a", "b"}, 1, 20, total)
+	})
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/list", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data struct {
+			Pagination map[string]interface{} `json:"pagination"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Data.Pagination
+}
+
+func TestSuccessWithPagination_KnownTotal(t *testing.T) {
+	pagination := paginationOf(t, 41)
+
+	assert.Equal(t, float64(41), pagination["total"])
+	assert.Equal(t, float64(3), pagination["total_pages"])
+	assert.NotContains(t, pagination, "total_unknown")
+}
+
+func TestSuccessWithPagination_UnknownTotal(t *testing.T) {
+	pagination := paginationOf(t, -1)
+
+	assert.Equal(t, float64(-1), pagination["total"])
+	assert.Equal(t, float64(0), pagination["total_pages"])
+	assert.Equal(t, true, pagination["total_unknown"])
+}
+
+func TestError_IncludesCategory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.GET("/ok", func(c *gin.Context) { Success(c, nil) })
+	engine.GET("/fail", func(c *gin.Context) {
+		Error(c, http.StatusUnauthorized, 11002, "访问令牌已过期")
+	})
+
+	var body map[string]interface{}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "auth", body["category"])
+
+	// 成功响应不输出 category
+	body = nil
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.NotContains(t, body, "category")
+}
@@ -0,0 +1,36 @@
+// Package response 提供统一的 HTTP 响应格式
+//
+// 本文件实现了响应信封中的服务器时间戳。
+// 启用后响应带上 server_time 字段（Unix 毫秒时间戳），供客户端做请求签名或时钟同步：
+//
+//	{
+//	    "code": 0,
+//	    "message": "success",
+//	    "data": { ... },
+//	    "server_time": 1704067200000
+//	}
+package response
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKeyServerTime 是否在响应中输出服务器时间戳的开关在 gin.Context 中的键
+const ContextKeyServerTime = "serverTime"
+
+// EnableServerTime 为当前请求开启响应中的 server_time 字段
+// 通常由中间件调用
+func EnableServerTime(c *gin.Context) {
+	c.Set(ContextKeyServerTime, true)
+}
+
+// serverTime 返回写入响应的服务器时间戳（Unix 毫秒），未开启时返回 0（不输出）
+// 时间戳在写响应时取值，尽量贴近客户端收到响应的时刻
+func serverTime(c *gin.Context) int64 {
+	if c.GetBool(ContextKeyServerTime) {
+		return time.Now().UnixMilli()
+	}
+	return 0
+}
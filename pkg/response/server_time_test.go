@@ -0,0 +1,46 @@
+// Package response 提供统一的 HTTP 响应格式
+//
+// 本文件包含服务器时间戳的单元测试
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerTime(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	perform := func(enabled bool) map[string]interface{} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		if enabled {
+			EnableServerTime(c)
+		}
+		Success(c, gin.H{"ok": true})
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	// 未开启时不输出
+	assert.NotContains(t, perform(false), "server_time")
+
+	// 开启后输出写响应时刻的毫秒时间戳
+	before := time.Now().UnixMilli()
+	body := perform(true)
+	after := time.Now().UnixMilli()
+	serverTime, ok := body["server_time"].(float64)
+	require.True(t, ok, body)
+	assert.GreaterOrEqual(t, int64(serverTime), before)
+	assert.LessOrEqual(t, int64(serverTime), after)
+}
@@ -0,0 +1,116 @@
+// Package response 提供统一的 HTTP 响应格式
+//
+// 本文件实现了响应时间字段的时区转换。
+// 默认按服务器序列化结果输出（RFC3339，带时区偏移），客户端可通过请求头要求目标时区：
+//
+//	X-Timezone: Asia/Shanghai
+//
+// 转换后的响应会带上同名响应头标注实际使用的时区。
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// TimezoneHeader 指定响应时区的请求头，同时用于在响应中标注实际时区
+	TimezoneHeader = "X-Timezone"
+	// ContextKeyTimezone 响应时区在 gin.Context 中的键
+	ContextKeyTimezone = "timezone"
+)
+
+// ParseTimezone 解析 IANA 时区名，例如 Asia/Shanghai、UTC
+// 空字符串、Local 或无法识别时返回 false（不允许依赖服务器本地时区）
+func ParseTimezone(s string) (*time.Location, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "local") {
+		return nil, false
+	}
+	if strings.EqualFold(s, "utc") {
+		return time.UTC, true
+	}
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return nil, false
+	}
+	return loc, true
+}
+
+// SetTimezone 设置当前请求的响应时区并写入 X-Timezone 响应头
+// 通常由中间件根据请求头或配置调用
+func SetTimezone(c *gin.Context, loc *time.Location) {
+	c.Set(ContextKeyTimezone, loc)
+	c.Header(TimezoneHeader, loc.String())
+}
+
+// getTimezone 获取当前请求的响应时区，未设置时返回 nil（不转换）
+func getTimezone(c *gin.Context) *time.Location {
+	if v, exists := c.Get(ContextKeyTimezone); exists {
+		if loc, ok := v.(*time.Location); ok {
+			return loc
+		}
+	}
+	return nil
+}
+
+// ConvertTimes 将数据中所有 RFC3339 格式的时间字符串转换到指定时区（包括嵌套结构）
+// 数据先按其 json tag 序列化，再递归转换字符串值；序列化失败时原样返回。
+// 时间点本身不变，只改变呈现的时区偏移
+func ConvertTimes(data interface{}, loc *time.Location) interface{} {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	// 保留数字精度，避免大整数被转换为浮点数
+	decoder.UseNumber()
+
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return data
+	}
+	return convertTimes(generic, loc)
+}
+
+// convertTimes 递归转换时间字符串
+func convertTimes(v interface{}, loc *time.Location) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = convertTimes(item, loc)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = convertTimes(item, loc)
+		}
+		return val
+	case string:
+		if t, ok := parseTimestamp(val); ok {
+			return t.In(loc).Format(time.RFC3339Nano)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// parseTimestamp 识别 time.Time 默认 JSON 序列化产生的 RFC3339 时间字符串
+// 只识别带日期和时间的完整时间戳，纯日期等字符串保持原样
+func parseTimestamp(s string) (time.Time, bool) {
+	// 最短形式为 2006-01-02T15:04:05Z
+	if len(s) < len("2006-01-02T15:04:05Z") || s[4] != '-' || s[10] != 'T' {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
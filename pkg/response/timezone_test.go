@@ -0,0 +1,54 @@
+// Package response 提供统一的 HTTP 响应格式
+//
+// 本文件包含响应时区转换的单元测试
+package response
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimezone(t *testing.T) {
+	loc, ok := ParseTimezone("Asia/Shanghai")
+	require.True(t, ok)
+	assert.Equal(t, "Asia/Shanghai", loc.String())
+
+	loc, ok = ParseTimezone(" utc ")
+	require.True(t, ok)
+	assert.Equal(t, time.UTC, loc)
+
+	for _, s := range []string{"", "Local", "Mars/Olympus", "+08:00"} {
+		_, ok := ParseTimezone(s)
+		assert.False(t, ok, s)
+	}
+}
+
+func TestConvertTimes(t *testing.T) {
+	// 准备
+	loc, ok := ParseTimezone("Asia/Shanghai")
+	require.True(t, ok)
+	data := map[string]interface{}{
+		"created_at": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		"user": map[string]interface{}{
+			"last_login_at": time.Date(2024, 1, 1, 12, 0, 0, 500, time.UTC),
+		},
+		"items":    []interface{}{time.Date(2023, 12, 31, 20, 0, 0, 0, time.UTC)},
+		"birthday": "2024-01-01",
+		"name":     "alice",
+		"count":    int64(9007199254740993),
+	}
+
+	// 执行
+	result := ConvertTimes(data, loc).(map[string]interface{})
+
+	// 断言：时间字段换算到目标时区，其他字段保持不变
+	assert.Equal(t, "2024-01-01T08:00:00+08:00", result["created_at"])
+	assert.Equal(t, "2024-01-01T20:00:00.0000005+08:00", result["user"].(map[string]interface{})["last_login_at"])
+	assert.Equal(t, "2024-01-01T04:00:00+08:00", result["items"].([]interface{})[0])
+	assert.Equal(t, "2024-01-01", result["birthday"])
+	assert.Equal(t, "alice", result["name"])
+	assert.Equal(t, "9007199254740993", result["count"].(interface{ String() string }).String())
+}
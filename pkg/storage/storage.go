@@ -0,0 +1,106 @@
+// Package storage 提供文件存储抽象
+//
+// 业务代码依赖 Storage 接口保存用户上传的文件（如头像），
+// 单机部署使用 LocalStorage 写入本地目录并由服务自身提供静态访问，
+// 多实例部署可替换为对象存储等共享实现。
+//
+// 使用示例：
+//
+//	s, err := storage.NewLocalStorage("./data/uploads", "/uploads")
+//	url, err := s.Put(ctx, "avatars/u1/128.png", data, "image/png")
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Storage 文件存储接口
+type Storage interface {
+	// Put 保存文件，已存在时覆盖，返回可公开访问的 URL
+	// key 为以 / 分隔的相对路径，例如 avatars/u1/128.png
+	Put(ctx context.Context, key string, data []byte, contentType string) (string, error)
+	// Delete 删除文件，文件不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+}
+
+// LocalStorage 基于本地目录的存储实现
+// 文件写入 dir 下与 key 对应的路径，URL 为 baseURL 与 key 的拼接
+type LocalStorage struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStorage 创建本地存储，dir 不存在时自动创建
+func NewLocalStorage(dir, baseURL string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建存储目录失败: %w", err)
+	}
+	return &LocalStorage{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// Dir 返回存储根目录，用于挂载静态文件服务
+func (s *LocalStorage) Dir() string {
+	return s.dir
+}
+
+// Put 实现 Storage
+// 先写入临时文件再重命名，读取方不会看到写了一半的文件
+func (s *LocalStorage) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	target, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return "", fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("写入文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("写入文件失败: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", fmt.Errorf("设置文件权限失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", fmt.Errorf("保存文件失败: %w", err)
+	}
+	return s.baseURL + "/" + path.Clean(key), nil
+}
+
+// Delete 实现 Storage
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除文件失败: %w", err)
+	}
+	return nil
+}
+
+// path 将 key 转换为本地路径，拒绝绝对路径与跳出存储目录的 key
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean == "/" || clean != "/"+key {
+		return "", fmt.Errorf("无效的存储路径: %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
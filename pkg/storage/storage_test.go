@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStorage_PutAndDelete(t *testing.T) {
+	// 准备
+	dir := t.TempDir()
+	s, err := NewLocalStorage(dir, "/uploads/")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// 执行
+	url, err := s.Put(ctx, "avatars/u1/64.png", []byte("png"), "image/png")
+
+	// 断言
+	require.NoError(t, err)
+	assert.Equal(t, "/uploads/avatars/u1/64.png", url)
+	data, err := os.ReadFile(filepath.Join(dir, "avatars", "u1", "64.png"))
+	require.NoError(t, err)
+	assert.Equal(t, "png", string(data))
+
+	require.NoError(t, s.Delete(ctx, "avatars/u1/64.png"))
+	_, err = os.Stat(filepath.Join(dir, "avatars", "u1", "64.png"))
+	assert.True(t, os.IsNotExist(err))
+	// 重复删除不报错
+	assert.NoError(t, s.Delete(ctx, "avatars/u1/64.png"))
+}
+
+func TestLocalStorage_RejectsInvalidKeys(t *testing.T) {
+	s, err := NewLocalStorage(t.TempDir(), "/uploads")
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "avatars/../../secret", "avatars//a.png"} {
+		_, err := s.Put(context.Background(), key, []byte("x"), "text/plain")
+		assert.Error(t, err, key)
+	}
+}
@@ -0,0 +1,91 @@
+// Package tokenblacklist 提供已吊销令牌（jti）的黑名单
+//
+// 本文件实现带熔断的黑名单包装。
+// 共享黑名单（如 Redis）故障时，每次认证都要等到连接超时才失败；包装后连续失败达到阈值即开路，
+// 开路期间不再访问故障的后端。本进程的吊销记录总会同时写入 fallback，后端不可用时仍能拒绝本进程吊销过的令牌。
+// 其他副本吊销的令牌无法确认：默认按 fallback 的结果放行（fail-open），由调用方继续校验令牌版本；
+// WithFailClosed 开启后改为返回错误，由调用方拒绝请求。
+package tokenblacklist
+
+import (
+	"context"
+	"time"
+
+	"github.com/example/go-user-api/pkg/breaker"
+)
+
+// BreakerBlacklist 带熔断的黑名单包装
+type BreakerBlacklist struct {
+	inner      TokenBlacklist
+	fallback   TokenBlacklist
+	breaker    *breaker.Breaker
+	failClosed bool
+	onFallback func(jti string, err error)
+}
+
+// BreakerOption 熔断黑名单的可选配置
+type BreakerOption func(*BreakerBlacklist)
+
+// WithFailClosed 后端不可用时 IsRevoked 返回错误，而不是按 fallback 的结果放行
+func WithFailClosed(failClosed bool) BreakerOption {
+	return func(b *BreakerBlacklist) {
+		b.failClosed = failClosed
+	}
+}
+
+// WithFallbackHandler 设置后端查询失败、回退到 fallback 时的回调，通常用于记录告警日志
+func WithFallbackHandler(fn func(jti string, err error)) BreakerOption {
+	return func(b *BreakerBlacklist) {
+		b.onFallback = fn
+	}
+}
+
+// NewBreakerBlacklist 用熔断器包装黑名单
+// fallback 保存本进程的吊销记录，通常为 MemoryBlacklist
+func NewBreakerBlacklist(inner, fallback TokenBlacklist, b *breaker.Breaker, opts ...BreakerOption) *BreakerBlacklist {
+	bl := &BreakerBlacklist{inner: inner, fallback: fallback, breaker: b}
+	for _, opt := range opts {
+		opt(bl)
+	}
+	return bl
+}
+
+// Revoke 实现 TokenBlacklist
+// 先写入 fallback，再经熔断器写入后端；后端写入失败时返回错误，此时吊销只在本进程生效
+func (b *BreakerBlacklist) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	if err := b.fallback.Revoke(ctx, jti, expiresAt); err != nil {
+		return err
+	}
+	return b.breaker.Execute(func() error {
+		return b.inner.Revoke(ctx, jti, expiresAt)
+	})
+}
+
+// IsRevoked 实现 TokenBlacklist
+// 命中 fallback 时直接返回已吊销，不访问后端；否则经熔断器查询后端。
+// 后端失败或开路时默认返回 fallback 的结果（未吊销）；开启 WithFailClosed 时返回错误，开路时为 breaker.ErrOpen
+func (b *BreakerBlacklist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	revoked, err := b.fallback.IsRevoked(ctx, jti)
+	if err != nil {
+		return false, err
+	}
+	if revoked {
+		return true, nil
+	}
+
+	err = b.breaker.Execute(func() error {
+		var checkErr error
+		revoked, checkErr = b.inner.IsRevoked(ctx, jti)
+		return checkErr
+	})
+	if err != nil {
+		if b.failClosed {
+			return false, err
+		}
+		if b.onFallback != nil {
+			b.onFallback(jti, err)
+		}
+		return false, nil
+	}
+	return revoked, nil
+}
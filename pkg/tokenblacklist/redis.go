@@ -0,0 +1,53 @@
+// Package tokenblacklist 提供已吊销令牌（jti）的黑名单
+//
+// 本文件实现了基于 Redis 的黑名单。
+// 每个被吊销的 jti 对应一个 key，TTL 等于令牌剩余有效期，令牌过期后由 Redis 自动清理。
+package tokenblacklist
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisKeyPrefix 黑名单 key 的默认前缀
+const DefaultRedisKeyPrefix = "jwt:blacklist:"
+
+// RedisBlacklist 基于 Redis 的黑名单
+type RedisBlacklist struct {
+	client redis.UniversalClient
+	prefix string
+	now    func() time.Time
+}
+
+// NewRedisBlacklist 创建 Redis 黑名单
+// prefix 为空时使用 DefaultRedisKeyPrefix；共享同一 Redis 的多个服务应使用不同前缀
+func NewRedisBlacklist(client redis.UniversalClient, prefix string) *RedisBlacklist {
+	if prefix == "" {
+		prefix = DefaultRedisKeyPrefix
+	}
+	return &RedisBlacklist{
+		client: client,
+		prefix: prefix,
+		now:    time.Now,
+	}
+}
+
+// Revoke 实现 TokenBlacklist
+func (b *RedisBlacklist) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := expiresAt.Sub(b.now())
+	if ttl <= 0 {
+		return nil
+	}
+	return b.client.Set(ctx, b.prefix+jti, 1, ttl).Err()
+}
+
+// IsRevoked 实现 TokenBlacklist
+func (b *RedisBlacklist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := b.client.Exists(ctx, b.prefix+jti).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
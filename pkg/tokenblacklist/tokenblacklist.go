@@ -0,0 +1,70 @@
+// Package tokenblacklist 提供已吊销令牌（jti）的黑名单
+//
+// 令牌吊销后其 jti 进入黑名单，直到令牌自然过期；认证时命中黑名单即拒绝。
+// 单实例部署可使用 MemoryBlacklist，多副本部署或需要跨重启保留吊销状态时
+// 使用 RedisBlacklist，各副本共享同一 Redis 即可一致吊销。
+//
+// 使用示例：
+//
+//	bl := tokenblacklist.NewRedisBlacklist(redisClient, "jwt:blacklist:")
+//	_ = bl.Revoke(ctx, claims.ID, claims.ExpiresAt.Time)
+//	if revoked, _ := bl.IsRevoked(ctx, claims.ID); revoked {
+//		// 拒绝请求
+//	}
+package tokenblacklist
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenBlacklist 令牌黑名单接口
+type TokenBlacklist interface {
+	// Revoke 吊销 jti，记录保留到 expiresAt；expiresAt 已过去时无需记录，直接返回
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	// IsRevoked 判断 jti 是否已被吊销
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// MemoryBlacklist 基于内存的黑名单
+// 只在当前进程内有效，重启后丢失；过期记录在写入时顺带清理
+type MemoryBlacklist struct {
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+	now     func() time.Time
+}
+
+// NewMemoryBlacklist 创建内存黑名单
+func NewMemoryBlacklist() *MemoryBlacklist {
+	return &MemoryBlacklist{
+		revoked: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// Revoke 实现 TokenBlacklist
+func (b *MemoryBlacklist) Revoke(_ context.Context, jti string, expiresAt time.Time) error {
+	now := b.now()
+	if !expiresAt.After(now) {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for id, exp := range b.revoked {
+		if !exp.After(now) {
+			delete(b.revoked, id)
+		}
+	}
+	b.revoked[jti] = expiresAt
+	return nil
+}
+
+// IsRevoked 实现 TokenBlacklist
+func (b *MemoryBlacklist) IsRevoked(_ context.Context, jti string) (bool, error) {
+	b.mu.RLock()
+	expiresAt, ok := b.revoked[jti]
+	b.mu.RUnlock()
+	return ok && expiresAt.After(b.now()), nil
+}
@@ -0,0 +1,134 @@
+package tokenblacklist
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/example/go-user-api/pkg/breaker"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryBlacklist_RevokeUntilExpiry(t *testing.T) {
+	bl := NewMemoryBlacklist()
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bl.now = func() time.Time { return now }
+
+	require.NoError(t, bl.Revoke(ctx, "jti-1", now.Add(time.Minute)))
+	// 已过期的令牌不记录
+	require.NoError(t, bl.Revoke(ctx, "jti-2", now.Add(-time.Second)))
+
+	revoked, err := bl.IsRevoked(ctx, "jti-1")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, _ = bl.IsRevoked(ctx, "jti-2")
+	assert.False(t, revoked)
+
+	// 令牌过期后不再需要黑名单记录
+	now = now.Add(2 * time.Minute)
+	revoked, _ = bl.IsRevoked(ctx, "jti-1")
+	assert.False(t, revoked)
+}
+
+// newRedisClient 连接 miniredis，测试结束时关闭
+func newRedisClient(t *testing.T, mr *miniredis.Miniredis) *redis.Client {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestRedisBlacklist_SharedAcrossReplicas(t *testing.T) {
+	// 准备：两个副本各自持有连接，共享同一 Redis
+	mr := miniredis.RunT(t)
+	replicaA := NewRedisBlacklist(newRedisClient(t, mr), "")
+	replicaB := NewRedisBlacklist(newRedisClient(t, mr), "")
+	ctx := context.Background()
+
+	revoked, err := replicaB.IsRevoked(ctx, "jti-1")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	// 执行：副本 A 吊销
+	require.NoError(t, replicaA.Revoke(ctx, "jti-1", time.Now().Add(10*time.Minute)))
+
+	// 断言：副本 B 同样判定失效，TTL 为令牌剩余有效期
+	revoked, err = replicaB.IsRevoked(ctx, "jti-1")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	ttl := mr.TTL(DefaultRedisKeyPrefix + "jti-1")
+	assert.InDelta(t, (10 * time.Minute).Seconds(), ttl.Seconds(), 2)
+
+	// 令牌过期后 key 由 Redis 清理
+	mr.FastForward(11 * time.Minute)
+	revoked, err = replicaB.IsRevoked(ctx, "jti-1")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestRedisBlacklist_SkipsExpiredToken(t *testing.T) {
+	mr := miniredis.RunT(t)
+	bl := NewRedisBlacklist(newRedisClient(t, mr), "app:")
+
+	require.NoError(t, bl.Revoke(context.Background(), "jti-1", time.Now().Add(-time.Second)))
+
+	assert.False(t, mr.Exists("app:jti-1"))
+}
+
+func TestRedisBlacklist_ErrorWhenUnavailable(t *testing.T) {
+	mr := miniredis.RunT(t)
+	bl := NewRedisBlacklist(newRedisClient(t, mr), "")
+	mr.Close()
+
+	_, err := bl.IsRevoked(context.Background(), "jti-1")
+
+	assert.Error(t, err)
+}
+
+func TestBreakerBlacklist_FallsBackWhenRedisDown(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisBL := NewRedisBlacklist(newRedisClient(t, mr), "")
+	bl := NewBreakerBlacklist(redisBL, NewMemoryBlacklist(), breaker.New("token_blacklist", breaker.WithFailureThreshold(2)))
+	ctx := context.Background()
+	exp := time.Now().Add(time.Hour)
+
+	require.NoError(t, bl.Revoke(ctx, "jti-before", exp))
+	mr.Close()
+
+	// 后端不可用：写入失败，但本进程的吊销仍然生效
+	assert.Error(t, bl.Revoke(ctx, "jti-local", exp))
+	revoked, err := bl.IsRevoked(ctx, "jti-local")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	// 无法确认的令牌按本地结果放行，并通知回退
+	var fallbackErrs []error
+	bl = NewBreakerBlacklist(redisBL, NewMemoryBlacklist(), breaker.New("token_blacklist", breaker.WithFailureThreshold(2)),
+		WithFallbackHandler(func(jti string, err error) { fallbackErrs = append(fallbackErrs, err) }))
+	for i := 0; i < 3; i++ {
+		revoked, err = bl.IsRevoked(ctx, "jti-other")
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	}
+	require.Len(t, fallbackErrs, 3)
+	assert.ErrorIs(t, fallbackErrs[2], breaker.ErrOpen)
+}
+
+func TestBreakerBlacklist_FailClosed(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisBL := NewRedisBlacklist(newRedisClient(t, mr), "")
+	bl := NewBreakerBlacklist(redisBL, NewMemoryBlacklist(), breaker.New("token_blacklist", breaker.WithFailureThreshold(1)),
+		WithFailClosed(true))
+	ctx := context.Background()
+	mr.Close()
+
+	// 无法确认的令牌返回错误；连续失败后开路，不再等待后端
+	_, err := bl.IsRevoked(ctx, "jti-other")
+	assert.Error(t, err)
+	_, err = bl.IsRevoked(ctx, "jti-other")
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+}